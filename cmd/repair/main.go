@@ -11,7 +11,6 @@ import (
 
 	"github.com/pokerjest/animateAutoTool/internal/config"
 	"github.com/pokerjest/animateAutoTool/internal/db"
-	"github.com/pokerjest/animateAutoTool/internal/downloader"
 	"github.com/pokerjest/animateAutoTool/internal/qbutil"
 	"github.com/pokerjest/animateAutoTool/internal/service"
 	"gorm.io/gorm"
@@ -72,7 +71,7 @@ func main() {
 		return
 	}
 
-	client := downloader.NewQBittorrentClient(qbCfg.URL)
+	client := qbutil.NewClient(qbCfg)
 	if err := client.Login(qbCfg.Username, qbCfg.Password); err != nil {
 		log.Fatalf("qBittorrent 登录失败: %v", err)
 	}
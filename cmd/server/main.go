@@ -23,6 +23,7 @@ import (
 	"github.com/pokerjest/animateAutoTool/internal/config"
 	"github.com/pokerjest/animateAutoTool/internal/db"
 	"github.com/pokerjest/animateAutoTool/internal/event"
+	"github.com/pokerjest/animateAutoTool/internal/eventlog"
 	"github.com/pokerjest/animateAutoTool/internal/launcher"
 	applogging "github.com/pokerjest/animateAutoTool/internal/logging"
 	"github.com/pokerjest/animateAutoTool/internal/runtimejournal"
@@ -123,7 +124,13 @@ func runServer(parent context.Context) (runErr error) {
 			startupLife.Stop()
 		}
 
-		waits := []func(){api.WaitBackgroundTasks, event.GlobalBus.Wait, mgr.StopAll}
+		// The event journal flushes after the bus drains so the last events
+		// published during shutdown are still written before SQLite closes.
+		waitEvents := func() {
+			event.GlobalBus.Wait()
+			eventlog.Stop()
+		}
+		waits := []func(){api.WaitBackgroundTasks, waitEvents, mgr.StopAll}
 		if sch != nil {
 			waits = append(waits, sch.Wait)
 		}
@@ -185,6 +192,9 @@ func runServer(parent context.Context) (runErr error) {
 	}
 	api.InitRoutes(r)
 	api.InitR2Cache()
+	api.StartBackupScheduler()
+	api.StartBangumiCollectionSync()
+	api.StartAniListProgressSync()
 
 	sch = scheduler.NewManagerWithContext(appCtx)
 	sch.Start()
@@ -14,9 +14,21 @@ const (
 	GraphQLEndpoint = "https://graphql.anilist.co"
 )
 
+// Media list statuses accepted by SaveMediaListEntry.
+const (
+	StatusCurrent   = "CURRENT"
+	StatusPlanning  = "PLANNING"
+	StatusCompleted = "COMPLETED"
+	StatusDropped   = "DROPPED"
+	StatusPaused    = "PAUSED"
+	StatusRepeating = "REPEATING"
+)
+
 type Client struct {
 	client *resty.Client
 	Token  string
+	// Endpoint defaults to GraphQLEndpoint; tests point it at a local stub.
+	Endpoint string
 }
 
 func NewClient(token string, proxyURL string) *Client {
@@ -27,13 +39,22 @@ func NewClient(token string, proxyURL string) *Client {
 	if token != "" {
 		c.SetHeader("Authorization", "Bearer "+token)
 	}
+	httpx.ObserveMetadataProvider(c, "anilist")
 
 	return &Client{
-		client: c,
-		Token:  token,
+		client:   c,
+		Token:    token,
+		Endpoint: GraphQLEndpoint,
 	}
 }
 
+func (c *Client) endpoint() string {
+	if c.Endpoint != "" {
+		return c.Endpoint
+	}
+	return GraphQLEndpoint
+}
+
 type MediaTitle struct {
 	Romaji  string `json:"romaji"`
 	English string `json:"english"`
@@ -52,12 +73,16 @@ type Media struct {
 	CoverImage     CoverImage      `json:"coverImage"`
 	Description    string          `json:"description"`
 	AverageScore   int             `json:"averageScore"`
+	Episodes       int             `json:"episodes"`
 	MediaListEntry *MediaListEntry `json:"mediaListEntry"`
 }
 
 type MediaListEntry struct {
-	Progress int    `json:"progress"`
-	Status   string `json:"status"`
+	ID        int     `json:"id"`
+	Progress  int     `json:"progress"`
+	Status    string  `json:"status"`
+	Score     float64 `json:"score"`
+	UpdatedAt int64   `json:"updatedAt"`
 }
 
 type PageData struct {
@@ -139,7 +164,7 @@ func (c *Client) SearchAnimeResultsContext(ctx context.Context, query string, li
 
 	resp, err := httpx.NewRequest(ctx, c.client).
 		SetBody(payload).
-		Post(GraphQLEndpoint)
+		Post(c.endpoint())
 
 	if err != nil {
 		return nil, err
@@ -194,7 +219,7 @@ func (c *Client) GetAnimeDetailsContext(ctx context.Context, id int) (*Media, er
 
 	resp, err := httpx.NewRequest(ctx, c.client).
 		SetBody(payload).
-		Post(GraphQLEndpoint)
+		Post(c.endpoint())
 
 	if err != nil {
 		return nil, err
@@ -221,12 +246,27 @@ func (c *Client) GetMediaListEntry(mediaID int) (*MediaListEntry, error) {
 }
 
 func (c *Client) GetMediaListEntryContext(ctx context.Context, mediaID int) (*MediaListEntry, error) {
+	media, err := c.GetMediaProgressContext(ctx, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	return media.MediaListEntry, nil
+}
+
+// GetMediaProgressContext returns the episode count of a media together with
+// the user's list entry, which is nil when the media is not on the list.
+func (c *Client) GetMediaProgressContext(ctx context.Context, mediaID int) (*Media, error) {
 	graphqlQuery := `
 	query ($id: Int) {
 	  Media(id: $id) {
+	    id
+	    episodes
 	    mediaListEntry {
+	      id
 	      progress
 	      status
+	      score
+	      updatedAt
 	    }
 	  }
 	}
@@ -238,25 +278,90 @@ func (c *Client) GetMediaListEntryContext(ctx context.Context, mediaID int) (*Me
 		},
 	}
 
-	resp, err := httpx.NewRequest(ctx, c.client).
-		SetBody(payload).
-		Post(GraphQLEndpoint)
-
-	if err != nil {
+	var result MediaResponse
+	if err := c.post(ctx, payload, &result); err != nil {
 		return nil, err
 	}
-	if resp.IsError() {
-		return nil, fmt.Errorf("AniList API Error: %s", resp.Status())
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("AniList GraphQL Error: %s", result.Errors[0].Message)
 	}
 
-	var result MediaResponse
-	if err := json.Unmarshal(resp.Body(), &result); err != nil {
-		return nil, err
+	return &result.Data.Media, nil
+}
+
+// SaveMediaListEntryOptions holds the fields to write; nil fields are left
+// unchanged on AniList.
+type SaveMediaListEntryOptions struct {
+	Status   string
+	Progress *int
+	Score    *float64
+}
+
+type saveMediaListEntryResponse struct {
+	Data struct {
+		SaveMediaListEntry *MediaListEntry `json:"SaveMediaListEntry"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// SaveMediaListEntryContext creates or updates the user's list entry for a
+// media. It needs a token with list write access.
+func (c *Client) SaveMediaListEntryContext(ctx context.Context, mediaID int, opts SaveMediaListEntryOptions) (*MediaListEntry, error) {
+	if c.Token == "" {
+		return nil, fmt.Errorf("AniList token is required to update the list")
+	}
+	graphqlQuery := `
+	mutation ($mediaId: Int, $status: MediaListStatus, $progress: Int, $score: Float) {
+	  SaveMediaListEntry(mediaId: $mediaId, status: $status, progress: $progress, score: $score) {
+	    id
+	    progress
+	    status
+	    score
+	    updatedAt
+	  }
+	}
+	`
+	variables := map[string]interface{}{
+		"mediaId": mediaID,
+	}
+	if opts.Status != "" {
+		variables["status"] = opts.Status
+	}
+	if opts.Progress != nil {
+		variables["progress"] = *opts.Progress
+	}
+	if opts.Score != nil {
+		variables["score"] = *opts.Score
+	}
+	payload := map[string]interface{}{
+		"query":     graphqlQuery,
+		"variables": variables,
 	}
 
+	var result saveMediaListEntryResponse
+	if err := c.post(ctx, payload, &result); err != nil {
+		return nil, err
+	}
 	if len(result.Errors) > 0 {
 		return nil, fmt.Errorf("AniList GraphQL Error: %s", result.Errors[0].Message)
 	}
+	if result.Data.SaveMediaListEntry == nil {
+		return nil, fmt.Errorf("AniList GraphQL Error: empty SaveMediaListEntry response")
+	}
+	return result.Data.SaveMediaListEntry, nil
+}
 
-	return result.Data.Media.MediaListEntry, nil
+func (c *Client) post(ctx context.Context, payload interface{}, out interface{}) error {
+	resp, err := httpx.NewRequest(ctx, c.client).
+		SetBody(payload).
+		Post(c.endpoint())
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("AniList API Error: %s", resp.Status())
+	}
+	return json.Unmarshal(resp.Body(), out)
 }
@@ -0,0 +1,85 @@
+package anilist
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+func TestSaveMediaListEntrySendsOnlySetFields(t *testing.T) {
+	var requests []graphQLRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("Authorization = %q", got)
+		}
+		var request graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		requests = append(requests, request)
+		if strings.Contains(request.Query, "SaveMediaListEntry") {
+			_, _ = w.Write([]byte(`{"data":{"SaveMediaListEntry":{"id":9,"progress":5,"status":"CURRENT","score":8.5,"updatedAt":1760000000}}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"Media":{"id":154587,"episodes":28,"mediaListEntry":{"id":9,"progress":4,"status":"CURRENT","score":0}}}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("token", "")
+	client.Endpoint = server.URL
+
+	media, err := client.GetMediaProgressContext(context.Background(), 154587)
+	if err != nil {
+		t.Fatalf("GetMediaProgressContext returned error: %v", err)
+	}
+	if media.Episodes != 28 || media.MediaListEntry == nil || media.MediaListEntry.Progress != 4 {
+		t.Fatalf("unexpected media progress: %+v", media)
+	}
+
+	progress, score := 5, 8.5
+	entry, err := client.SaveMediaListEntryContext(context.Background(), 154587, SaveMediaListEntryOptions{Status: StatusCurrent, Progress: &progress, Score: &score})
+	if err != nil {
+		t.Fatalf("SaveMediaListEntryContext returned error: %v", err)
+	}
+	if entry.ID != 9 || entry.Progress != 5 || entry.Score != 8.5 {
+		t.Fatalf("unexpected saved entry: %+v", entry)
+	}
+	variables := requests[1].Variables
+	if variables["mediaId"] != float64(154587) || variables["status"] != StatusCurrent || variables["progress"] != float64(5) || variables["score"] != 8.5 {
+		t.Fatalf("unexpected mutation variables: %v", variables)
+	}
+
+	if _, err := client.SaveMediaListEntryContext(context.Background(), 154587, SaveMediaListEntryOptions{Status: StatusCompleted}); err != nil {
+		t.Fatalf("status-only update returned error: %v", err)
+	}
+	if _, ok := requests[2].Variables["progress"]; ok {
+		t.Fatalf("unset fields must not be sent: %v", requests[2].Variables)
+	}
+	if _, ok := requests[2].Variables["score"]; ok {
+		t.Fatalf("unset fields must not be sent: %v", requests[2].Variables)
+	}
+}
+
+func TestSaveMediaListEntryReportsGraphQLErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"SaveMediaListEntry":null},"errors":[{"message":"Invalid token"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("token", "")
+	client.Endpoint = server.URL
+	if _, err := client.SaveMediaListEntryContext(context.Background(), 1, SaveMediaListEntryOptions{Status: StatusCurrent}); err == nil || !strings.Contains(err.Error(), "Invalid token") {
+		t.Fatalf("expected GraphQL error, got %v", err)
+	}
+	if _, err := NewClient("", "").SaveMediaListEntryContext(context.Background(), 1, SaveMediaListEntryOptions{}); err == nil {
+		t.Fatal("an anonymous client must not send mutations")
+	}
+}
@@ -60,7 +60,7 @@ func startAIAnalysis(c *gin.Context, input service.AIProposalInput, runner aiAna
 	if requestID == "" {
 		requestID = uuid.NewString()
 	}
-	taskstate.Global.Start(taskID, "ai-analysis", "AI 运维分析", "正在通过安全工具收集上下文")
+	taskstate.Global.StartBy(taskstate.Initiator{UserID: userID, Username: username}, taskID, "ai-analysis", "AI 运维分析", "正在通过安全工具收集上下文")
 	meta := ai.ToolExecutionMeta{
 		RequestID: requestID, TaskID: taskID, SessionID: aiChatHistoryKey(c), ProposalID: row.ID,
 		UserID: userID, Username: username, Provider: settings.Provider, Model: settings.Model,
@@ -867,7 +867,7 @@ func applyLocalOrganizeProposalTool(ctx context.Context, raw string) (string, er
 		return "", errors.New("整理计划参数无效")
 	}
 	meta := currentAIToolMeta(ctx)
-	taskID, err := startLocalOrganizePlan(strconv.FormatUint(uint64(meta.UserID), 10), req.PlanID, req.IncludeAnimeIDs)
+	taskID, err := startLocalOrganizePlan(taskstate.Initiator{UserID: meta.UserID, Username: meta.Username}, strconv.FormatUint(uint64(meta.UserID), 10), req.PlanID, req.IncludeAnimeIDs)
 	if err != nil {
 		return "", err
 	}
@@ -937,7 +937,8 @@ func applySubscriptionRuleProposalTool(ctx context.Context, raw string) (string,
 		return "", err
 	}
 	taskID := "ai-subscription-recheck-" + strconv.FormatUint(uint64(subscription.ID), 10)
-	taskstate.Global.Start(taskID, "subscription-repair", "AI 订阅规则复核", "正在按新规则重新检查订阅")
+	meta := currentAIToolMeta(ctx)
+	taskstate.Global.StartBy(taskstate.Initiator{UserID: meta.UserID, Username: meta.Username}, taskID, "subscription-repair", "AI 订阅规则复核", "正在按新规则重新检查订阅")
 	GoBackground(func(context.Context) {
 		if err := runSubscriptionCheck(&subscription, "ai-rule"); err != nil {
 			taskstate.Global.Fail(taskID, err)
@@ -956,14 +957,17 @@ func runConfirmedLibraryScanTool(ctx context.Context, raw string) (string, error
 		return "", runtimejournal.ErrRecoveryInProgress
 	}
 	taskID := "ai-library-scan-" + strconv.FormatInt(time.Now().UnixNano(), 10)
-	taskstate.Global.Start(taskID, "scan", "AI 确认的本地扫描", "正在扫描本地媒体库")
+	meta := currentAIToolMeta(ctx)
+	taskstate.Global.StartBy(taskstate.Initiator{UserID: meta.UserID, Username: meta.Username}, taskID, "scan", "AI 确认的本地扫描", "正在扫描本地媒体库")
 	GoBackground(func(appCtx context.Context) {
-		if err := service.NewScannerService().ScanAllWithProgressContext(appCtx, nil); err != nil {
+		ctx, cancel := taskstate.Global.WithCancel(appCtx, taskID)
+		defer cancel()
+		if err := service.NewScannerService().ScanAllWithProgressContext(ctx, nil); err != nil {
 			taskstate.Global.Fail(taskID, err)
 			return
 		}
 		service.NewAgentService().RunAgentForLibrary()
-		if err := service.RequestJellyfinLibraryRefresh(appCtx); err != nil && !errors.Is(err, service.ErrJellyfinNotConfigured) {
+		if err := service.RequestJellyfinLibraryRefresh(ctx); err != nil && !errors.Is(err, service.ErrJellyfinNotConfigured) {
 			taskstate.Global.Fail(taskID, err)
 			return
 		}
@@ -0,0 +1,283 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pokerjest/animateAutoTool/internal/anilist"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"github.com/pokerjest/animateAutoTool/internal/taskstate"
+)
+
+const (
+	aniListSyncTaskID        = "anilist-progress-sync"
+	aniListSyncInterval      = 6 * time.Hour
+	aniListSyncTriggerPlay   = "playback"
+	aniListSyncTriggerDaily  = "schedule"
+	aniListSyncTriggerManual = "manual"
+	maxAniListAuditItems     = 50
+)
+
+var errAniListSyncRunning = errors.New("AniList 进度同步正在运行")
+
+var (
+	aniListSyncRunning atomic.Bool
+	// aniListSyncMu serializes playback pushes with the reconciliation run,
+	// so both never read and write the same list entry at once.
+	aniListSyncMu sync.Mutex
+)
+
+// newAniListListClient returns the client used for list reads and writes.
+// Tests replace it to point at a local GraphQL stub.
+var newAniListListClient = func(token string) service.AniListListClient {
+	return anilist.NewClient(token, configuredProxyURL(model.ConfigKeyProxyAniList))
+}
+
+// loadAniListSyncSettings reports whether list sync is enabled and which
+// conflict policy applies.
+func loadAniListSyncSettings() (bool, string) {
+	enabled := strings.EqualFold(configValue(model.ConfigKeyAniListSyncEnabled), ValueTrue) && configValue(model.ConfigKeyAniListToken) != ""
+	policy, err := service.ParseAniListSyncPolicy(configValue(model.ConfigKeyAniListSyncPolicy))
+	if err != nil {
+		policy = service.AniListSyncPolicyHighest
+	}
+	return enabled, policy
+}
+
+func normalizeAniListSyncPolicy(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	policy, err := service.ParseAniListSyncPolicy(value)
+	if err != nil {
+		return "", errors.New("冲突策略只能是 highest（进度高者优先）、local（本地优先）或 remote（AniList 优先）")
+	}
+	return policy, nil
+}
+
+// aniListSyncUserID returns the account whose playback belongs to the linked
+// AniList list. Installs that saved the token before the owner was recorded
+// fall back to the oldest admin, the account that existed back then.
+func aniListSyncUserID() (uint, error) {
+	if value := configValue(model.ConfigKeyAniListSyncUserID); value != "" {
+		userID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || userID == 0 {
+			return 0, fmt.Errorf("invalid %s %q", model.ConfigKeyAniListSyncUserID, value)
+		}
+		return uint(userID), nil
+	}
+	users, err := store.NewUserStore(db.DB).List()
+	if err != nil {
+		return 0, err
+	}
+	for _, user := range users {
+		if service.EffectiveUserRole(&user) == model.UserRoleAdmin {
+			return user.ID, nil
+		}
+	}
+	return 0, errors.New("no admin account owns the AniList token")
+}
+
+// isAniListSyncUser reports whether userID's playback is pushed to AniList.
+func isAniListSyncUser(userID uint) bool {
+	owner, err := aniListSyncUserID()
+	if err != nil {
+		log.Printf("WARN: AniListSync: resolve linked account failed error=%v", err)
+		return false
+	}
+	return owner == userID
+}
+
+// syncEndedEpisodeToAniList pushes the progress of one series after the
+// linked account finished an episode. It waits for a running reconciliation
+// instead of racing it.
+func syncEndedEpisodeToAniList(ctx context.Context, userID, localAnimeID uint) {
+	enabled, policy := loadAniListSyncSettings()
+	if !enabled {
+		return
+	}
+	aniListSyncMu.Lock()
+	defer aniListSyncMu.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+	client := newAniListListClient(configValue(model.ConfigKeyAniListToken))
+	report, err := service.SyncAniListProgress(ctx, client, policy, userID, localAnimeID)
+	if err != nil {
+		log.Printf("WARN: AniListSync: playback sync failed local_anime_id=%d error=%v", localAnimeID, err)
+		return
+	}
+	for _, item := range report.Items {
+		if item.Error != "" {
+			log.Printf("WARN: AniListSync: playback sync failed media_id=%d error=%s", item.MediaID, item.Error)
+		}
+	}
+}
+
+// StartAniListProgressSync reconciles playback history with AniList every
+// few hours while list sync is enabled.
+func StartAniListProgressSync() {
+	GoBackground(func(appCtx context.Context) {
+		for {
+			timer := time.NewTimer(aniListSyncInterval)
+			select {
+			case <-appCtx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			enabled, policy := loadAniListSyncSettings()
+			if !enabled {
+				continue
+			}
+			if _, err := runAniListProgressSync(appCtx, policy, aniListSyncTriggerDaily, service.AuditContext{Username: "scheduler"}); err != nil && !errors.Is(err, errAniListSyncRunning) {
+				log.Printf("WARN: AniListSync: scheduled run failed error=%v", err)
+			}
+		}
+	}, "anilist-progress-sync")
+}
+
+func runAniListProgressSync(ctx context.Context, policy, trigger string, auditCtx service.AuditContext) (service.AniListSyncReport, error) {
+	if !aniListSyncRunning.CompareAndSwap(false, true) {
+		return service.AniListSyncReport{}, errAniListSyncRunning
+	}
+	defer aniListSyncRunning.Store(false)
+	aniListSyncMu.Lock()
+	defer aniListSyncMu.Unlock()
+
+	taskstate.Global.StartBy(taskstate.Initiator{UserID: auditCtx.UserID, Username: auditCtx.Username}, aniListSyncTaskID, "anilist-sync", "同步 AniList 进度", "正在比对本地播放记录和 AniList 列表")
+	runCtx, cancel := taskstate.Global.WithCancel(ctx, aniListSyncTaskID)
+	defer cancel()
+
+	var report service.AniListSyncReport
+	userID, err := aniListSyncUserID()
+	if err == nil {
+		client := newAniListListClient(configValue(model.ConfigKeyAniListToken))
+		report, err = service.SyncAniListProgress(runCtx, client, policy, userID)
+	}
+	report.Trigger = trigger
+
+	details := report
+	if len(details.Items) > maxAniListAuditItems {
+		details.Items = details.Items[:maxAniListAuditItems]
+	}
+	outcome := service.AuditOutcomeSuccess
+	if err != nil || report.Failed > 0 {
+		outcome = service.AuditOutcomeFailure
+	}
+	service.RecordAudit(auditCtx, service.AuditEntry{
+		Action:     service.AuditActionAniListSyncRun,
+		Outcome:    outcome,
+		TargetType: "anilist_sync",
+		Details:    details,
+	})
+	if err != nil {
+		log.Printf("WARN: AniListSync: run failed trigger=%s checked=%d error=%v", trigger, report.Checked, err)
+		taskstate.Global.Fail(aniListSyncTaskID, err)
+		return report, err
+	}
+	log.Printf("AniListSync: run finished trigger=%s policy=%s checked=%d pushed=%d kept=%d conflicts=%d failed=%d", trigger, policy, report.Checked, report.Pushed, report.Kept, report.Conflicts, report.Failed)
+	taskstate.Global.Complete(aniListSyncTaskID, fmt.Sprintf("已比对 %d 部：更新 %d 部，保留 AniList 进度 %d 部，失败 %d 部", report.Checked, report.Pushed, report.Kept, report.Failed))
+	return report, nil
+}
+
+// V1RunAniListSyncHandler reconciles all series once in the background.
+func V1RunAniListSyncHandler(c *gin.Context) {
+	if configValue(model.ConfigKeyAniListToken) == "" {
+		v1Error(c, http.StatusBadRequest, "anilist_not_configured", "还没有配置 AniList Token")
+		return
+	}
+	if aniListSyncRunning.Load() {
+		v1Error(c, http.StatusConflict, "anilist_sync_running", errAniListSyncRunning.Error())
+		return
+	}
+	_, policy := loadAniListSyncSettings()
+	auditCtx := buildAuditContext(c)
+	if !GoBackground(func(appCtx context.Context) {
+		if _, err := runAniListProgressSync(appCtx, policy, aniListSyncTriggerManual, auditCtx); err != nil && !errors.Is(err, errAniListSyncRunning) {
+			log.Printf("WARN: AniListSync: manual run failed error=%v", err)
+		}
+	}, "anilist-progress-sync-run") {
+		v1Error(c, http.StatusServiceUnavailable, "service_unavailable", "服务正在关闭，无法启动同步")
+		return
+	}
+	v1Message(c, http.StatusAccepted, "AniList 进度同步已经启动", gin.H{"task_id": aniListSyncTaskID, "status": "running"})
+}
+
+type aniListEntryRequest struct {
+	Status   string   `json:"status"`
+	Progress *int     `json:"progress"`
+	Score    *float64 `json:"score"`
+}
+
+var aniListEntryStatuses = map[string]bool{
+	anilist.StatusCurrent: true, anilist.StatusPlanning: true, anilist.StatusCompleted: true,
+	anilist.StatusDropped: true, anilist.StatusPaused: true, anilist.StatusRepeating: true,
+}
+
+// V1AniListEntryHandler writes status, progress or score of one AniList
+// media to the configured account.
+func V1AniListEntryHandler(c *gin.Context) {
+	token := configValue(model.ConfigKeyAniListToken)
+	if token == "" {
+		v1Error(c, http.StatusBadRequest, "anilist_not_configured", "还没有配置 AniList Token")
+		return
+	}
+	mediaID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || mediaID <= 0 {
+		v1Error(c, http.StatusBadRequest, "invalid_id", "AniList 条目 ID 无效")
+		return
+	}
+	var req aniListEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		v1Error(c, http.StatusBadRequest, "invalid_anilist_entry", "AniList 列表更新请求格式不正确")
+		return
+	}
+	req.Status = strings.ToUpper(strings.TrimSpace(req.Status))
+	switch {
+	case req.Status == "" && req.Progress == nil && req.Score == nil:
+		v1Error(c, http.StatusBadRequest, "invalid_anilist_entry", "至少需要提供状态、进度或评分中的一项")
+		return
+	case req.Status != "" && !aniListEntryStatuses[req.Status]:
+		v1Error(c, http.StatusBadRequest, "invalid_anilist_entry", "状态只能是 CURRENT、PLANNING、COMPLETED、DROPPED、PAUSED 或 REPEATING")
+		return
+	case req.Progress != nil && *req.Progress < 0:
+		v1Error(c, http.StatusBadRequest, "invalid_anilist_entry", "进度不能为负数")
+		return
+	case req.Score != nil && (*req.Score < 0 || *req.Score > 100):
+		v1Error(c, http.StatusBadRequest, "invalid_anilist_entry", "评分必须在 0 到 100 之间")
+		return
+	}
+
+	entry, err := newAniListListClient(token).SaveMediaListEntryContext(c.Request.Context(), mediaID, anilist.SaveMediaListEntryOptions{
+		Status: req.Status, Progress: req.Progress, Score: req.Score,
+	})
+	if err != nil {
+		log.Printf("WARN: AniListSync: save entry failed media_id=%d error=%v", mediaID, err)
+		v1Error(c, http.StatusBadGateway, "anilist_unavailable", "更新 AniList 列表失败："+humanizeOperationError(err.Error()))
+		return
+	}
+	if err := db.DB.Model(&model.AnimeMetadata{}).Where("ani_list_id = ?", mediaID).Update("AniListWatchedEps", entry.Progress).Error; err != nil {
+		log.Printf("WARN: AniListSync: cache progress failed media_id=%d error=%v", mediaID, err)
+	}
+	service.RecordAudit(buildAuditContext(c), service.AuditEntry{
+		Action:     service.AuditActionAniListEntryUpdate,
+		Outcome:    service.AuditOutcomeSuccess,
+		TargetType: "anilist_media",
+		TargetID:   strconv.Itoa(mediaID),
+		Details:    req,
+	})
+	v1Message(c, http.StatusOK, "已更新 AniList 列表", entry)
+}
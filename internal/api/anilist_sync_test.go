@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/anilist"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubAniListList struct {
+	mu      sync.Mutex
+	entries map[int]*anilist.MediaListEntry
+	saved   chan anilist.SaveMediaListEntryOptions
+}
+
+func (s *stubAniListList) GetMediaProgressContext(_ context.Context, mediaID int) (*anilist.Media, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &anilist.Media{ID: mediaID, Episodes: 12, MediaListEntry: s.entries[mediaID]}, nil
+}
+
+func (s *stubAniListList) SaveMediaListEntryContext(_ context.Context, mediaID int, opts anilist.SaveMediaListEntryOptions) (*anilist.MediaListEntry, error) {
+	s.mu.Lock()
+	entry := s.entries[mediaID]
+	if entry == nil {
+		entry = &anilist.MediaListEntry{ID: mediaID}
+		s.entries[mediaID] = entry
+	}
+	if opts.Status != "" {
+		entry.Status = opts.Status
+	}
+	if opts.Progress != nil {
+		entry.Progress = *opts.Progress
+	}
+	if opts.Score != nil {
+		entry.Score = *opts.Score
+	}
+	saved := *entry
+	s.mu.Unlock()
+	s.saved <- opts
+	return &saved, nil
+}
+
+func TestAniListSyncPushesEndedEpisodesAndUpdatesEntries(t *testing.T) {
+	resetAuthFixtures(t)
+	syncKeys := []string{model.ConfigKeyAniListToken, model.ConfigKeyAniListSyncEnabled, model.ConfigKeyAniListSyncPolicy, model.ConfigKeyAniListSyncUserID}
+	reset := func() { _ = db.DB.Where("key IN ?", syncKeys).Delete(&model.GlobalConfig{}).Error }
+	reset()
+	t.Cleanup(reset)
+
+	stub := &stubAniListList{entries: map[int]*anilist.MediaListEntry{}, saved: make(chan anilist.SaveMediaListEntryOptions, 8)}
+	previousClient := newAniListListClient
+	newAniListListClient = func(string) service.AniListListClient { return stub }
+	t.Cleanup(func() { newAniListListClient = previousClient })
+
+	r := setupRouter()
+	cookie, _ := loginCookie(t, r, "admin")
+	anime, first, _ := seedPlaybackAnime(t, 91)
+	const mediaID = 154587
+	require.NoError(t, db.DB.Model(&model.AnimeMetadata{}).Where("id = ?", *anime.MetadataID).Update("AniListID", mediaID).Error)
+
+	w := serveAs(r, cookie, http.MethodPost, "/api/v1/anilist/sync", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code, "running without an AniList token should be rejected")
+
+	// Saving the token links the list to the admin who saved it.
+	w = serveAs(r, cookie, http.MethodPut, "/api/v1/settings", `{"values":{"anilist_token":"token","anilist_sync_enabled":"true"}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	admin, err := store.NewUserStore(db.DB).GetByUsername("admin")
+	require.NoError(t, err)
+	ownerID, err := aniListSyncUserID()
+	require.NoError(t, err)
+	assert.Equal(t, admin.ID, ownerID)
+
+	// Other accounts neither write to the list nor push their playback.
+	createTestUser(t, r, cookie, "anilist-member", model.UserRoleMember)
+	memberCookie := v1LoginCookie(t, r, "anilist-member", "household-pass")
+	assert.Equal(t, http.StatusForbidden, serveAs(r, memberCookie, http.MethodPost, "/api/v1/anilist/sync", "").Code)
+	assert.Equal(t, http.StatusForbidden, serveAs(r, memberCookie, http.MethodPost, fmt.Sprintf("/api/v1/anilist/media/%d/entry", mediaID), `{"progress":3}`).Code)
+	recorder := postPlaybackProgress(t, r, memberCookie, PlaybackProgressInput{EpisodeID: first.ID, Event: "ended", Ticks: 1000, DurationTicks: 1000})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	select {
+	case opts := <-stub.saved:
+		t.Fatalf("a member's playback must not update the linked AniList list: %+v", opts)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	// A playback push waits for a running reconciliation.
+	aniListSyncMu.Lock()
+	recorder = postPlaybackProgress(t, r, cookie, PlaybackProgressInput{EpisodeID: first.ID, Event: "ended", Ticks: 1000, DurationTicks: 1000})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	select {
+	case opts := <-stub.saved:
+		aniListSyncMu.Unlock()
+		t.Fatalf("the playback push must not run alongside a reconciliation: %+v", opts)
+	case <-time.After(300 * time.Millisecond):
+	}
+	aniListSyncMu.Unlock()
+	select {
+	case opts := <-stub.saved:
+		require.NotNil(t, opts.Progress)
+		assert.Equal(t, 1, *opts.Progress)
+		assert.Equal(t, anilist.StatusCurrent, opts.Status)
+	case <-time.After(5 * time.Second):
+		t.Fatal("finishing an episode should update AniList")
+	}
+	require.Eventually(t, func() bool {
+		var metadata model.AnimeMetadata
+		return db.DB.First(&metadata, *anime.MetadataID).Error == nil && metadata.AniListWatchedEps == 1
+	}, 5*time.Second, 20*time.Millisecond, "the pushed progress should be cached")
+
+	path := fmt.Sprintf("/api/v1/anilist/media/%d/entry", mediaID)
+	for _, body := range []string{`{}`, `{"status":"WATCHING"}`, `{"progress":-1}`, `{"score":101}`} {
+		w = serveAs(r, cookie, http.MethodPost, path, body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+	w = serveAs(r, cookie, http.MethodPost, path, `{"status":"completed","progress":12,"score":85}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	opts := <-stub.saved
+	assert.Equal(t, anilist.StatusCompleted, opts.Status)
+	require.NotNil(t, opts.Score)
+	assert.Equal(t, 85.0, *opts.Score)
+	var metadata model.AnimeMetadata
+	require.NoError(t, db.DB.First(&metadata, *anime.MetadataID).Error)
+	assert.Equal(t, 12, metadata.AniListWatchedEps)
+
+	entries, err := service.ListAuditLogs(store.AuditLogQuery{Action: service.AuditActionAniListEntryUpdate, Limit: 5})
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	assert.Equal(t, fmt.Sprint(mediaID), entries[0].TargetID)
+}
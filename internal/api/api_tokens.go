@@ -0,0 +1,237 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+)
+
+const apiTokenContextKey = "auth.api_token"
+
+// Routes whose every method needs the admin scope, even plain reads: they
+// expose configuration, backups or other accounts.
+var apiTokenAdminRoutePrefixes = []string{
+	"/settings", "/backup", "/users", "/audit-logs", "/diagnostics",
+	"/health", "/runtime", "/setup", "/system",
+}
+
+var apiTokenWriteScopes = []struct {
+	prefix string
+	scope  string
+}{
+	{"/subscriptions", service.APITokenScopeSubscriptions},
+	{"/tasks", service.APITokenScopeSubscriptions},
+	{"/library", service.APITokenScopeLibrary},
+	{"/local-anime", service.APITokenScopeLibrary},
+	{"/local-directories", service.APITokenScopeLibrary},
+	{"/bangumi", service.APITokenScopeLibrary},
+	{"/playback", service.APITokenScopePlayback},
+	{"/jellyfin", service.APITokenScopePlayback},
+	{"/media", service.APITokenScopePlayback},
+}
+
+type apiTokenView struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+func newAPITokenView(token *model.APIToken) apiTokenView {
+	return apiTokenView{
+		ID:         token.ID,
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     service.APITokenScopeList(token),
+		CreatedAt:  token.CreatedAt,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		LastUsedIP: token.LastUsedIP,
+		RevokedAt:  token.RevokedAt,
+	}
+}
+
+// apiTokenScopeForRoute returns the scope a token needs for a matched v1
+// route, or "" when tokens may not call it at all. Tokens never manage
+// sessions or other tokens, so a leaked token cannot mint a replacement.
+func apiTokenScopeForRoute(method, route string) string {
+	route = strings.TrimPrefix(route, "/api/v1")
+	if route == "" || hasRoutePrefix(route, "/session") || hasRoutePrefix(route, "/tokens") {
+		return ""
+	}
+	for _, prefix := range apiTokenAdminRoutePrefixes {
+		if hasRoutePrefix(route, prefix) {
+			return service.APITokenScopeAdmin
+		}
+	}
+	if method == http.MethodGet || method == http.MethodHead {
+		return service.APITokenScopeRead
+	}
+	for _, entry := range apiTokenWriteScopes {
+		if hasRoutePrefix(route, entry.prefix) {
+			return entry.scope
+		}
+	}
+	return service.APITokenScopeAdmin
+}
+
+func hasRoutePrefix(route, prefix string) bool {
+	return route == prefix || strings.HasPrefix(route, prefix+"/")
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	header := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(header) < len("Bearer ") || !strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+		return "", false
+	}
+	return strings.TrimSpace(header[len("Bearer "):]), true
+}
+
+func requestAPIToken(c *gin.Context) (*model.APIToken, bool) {
+	value, exists := c.Get(apiTokenContextKey)
+	if !exists {
+		return nil, false
+	}
+	token, ok := value.(*model.APIToken)
+	return token, ok && token != nil
+}
+
+// APITokenMiddleware authenticates `Authorization: Bearer` requests with a
+// personal API token. Requests without the header fall through to the
+// session cookie. Token requests skip the same-origin check because browsers
+// never attach the header on their own, and every use lands in the audit log.
+func APITokenMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		secret, ok := bearerToken(c.Request)
+		if !ok {
+			c.Next()
+			return
+		}
+		auth := service.NewAuthService()
+		token, user, err := auth.AuthenticateAPIToken(secret)
+		if err != nil {
+			message := err.Error()
+			if !errors.Is(err, service.ErrAPITokenExpired) && !errors.Is(err, service.ErrAPITokenRevoked) && !errors.Is(err, service.ErrAPITokenOwnerAbsent) {
+				message = service.ErrAPITokenInvalid.Error()
+			}
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			v1Error(c, http.StatusUnauthorized, "invalid_token", message)
+			return
+		}
+		c.Set(apiTokenContextKey, token)
+		c.Set(requestUserContextKey, user)
+
+		scope := apiTokenScopeForRoute(c.Request.Method, c.FullPath())
+		if scope == "" || !service.APITokenHasScope(token, scope) {
+			recordAPITokenUse(c, token, http.StatusForbidden)
+			v1Error(c, http.StatusForbidden, "token_scope", "该 API 令牌没有访问此接口的权限")
+			return
+		}
+		if err := auth.TouchAPIToken(token, requestClientIP(c)); err != nil {
+			log.Printf("api token: failed to record last use of token %d: %v", token.ID, err)
+		}
+		c.Next()
+		recordAPITokenUse(c, token, c.Writer.Status())
+	}
+}
+
+func recordAPITokenUse(c *gin.Context, token *model.APIToken, status int) {
+	outcome := service.AuditOutcomeSuccess
+	if status >= http.StatusBadRequest {
+		outcome = service.AuditOutcomeFailure
+	}
+	service.RecordAudit(buildAuditContext(c), service.AuditEntry{
+		Action:     service.AuditActionAPITokenUse,
+		TargetType: "api_token",
+		TargetID:   strconv.FormatUint(uint64(token.ID), 10),
+		Outcome:    outcome,
+		Details: map[string]any{
+			"name":   token.Name,
+			"method": c.Request.Method,
+			"path":   c.Request.URL.Path,
+			"status": status,
+		},
+	})
+}
+
+func V1APITokensHandler(c *gin.Context) {
+	tokens, err := service.NewAuthService().ListAPITokens(requestUserID(c))
+	if err != nil {
+		v1Error(c, http.StatusInternalServerError, "tokens_unavailable", "无法读取 API 令牌")
+		return
+	}
+	items := make([]apiTokenView, 0, len(tokens))
+	for i := range tokens {
+		items = append(items, newAPITokenView(&tokens[i]))
+	}
+	v1Data(c, http.StatusOK, gin.H{"items": items, "scopes": service.APITokenScopes})
+}
+
+func V1CreateAPITokenHandler(c *gin.Context) {
+	var req struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		v1Error(c, http.StatusBadRequest, "invalid_token_request", "API 令牌请求格式不正确")
+		return
+	}
+	user, ok := requestUser(c)
+	if !ok {
+		v1Error(c, http.StatusUnauthorized, "unauthorized", "当前登录状态已失效")
+		return
+	}
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	secret, token, err := service.NewAuthService().IssueAPIToken(user, req.Name, req.Scopes, ttl)
+	if err != nil {
+		v1Error(c, http.StatusBadRequest, "invalid_token_request", err.Error())
+		return
+	}
+	service.RecordAudit(buildAuditContext(c), service.AuditEntry{
+		Action:     service.AuditActionAPITokenCreate,
+		TargetType: "api_token",
+		TargetID:   strconv.FormatUint(uint64(token.ID), 10),
+		Outcome:    service.AuditOutcomeSuccess,
+		Details:    map[string]any{"name": token.Name, "scopes": service.APITokenScopeList(token), "expires_at": token.ExpiresAt},
+	})
+	v1Data(c, http.StatusCreated, gin.H{"token": secret, "item": newAPITokenView(token)})
+}
+
+func V1RevokeAPITokenHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		v1Error(c, http.StatusBadRequest, "invalid_token_id", "API 令牌 ID 不正确")
+		return
+	}
+	actor, _ := requestUser(c)
+	token, err := service.NewAuthService().RevokeAPIToken(uint(id), actor)
+	if errors.Is(err, service.ErrAPITokenNotFound) {
+		v1Error(c, http.StatusNotFound, "token_not_found", err.Error())
+		return
+	}
+	if err != nil {
+		v1Error(c, http.StatusInternalServerError, "token_revoke_failed", "撤销 API 令牌失败")
+		return
+	}
+	service.RecordAudit(buildAuditContext(c), service.AuditEntry{
+		Action:     service.AuditActionAPITokenRevoke,
+		TargetType: "api_token",
+		TargetID:   strconv.FormatUint(uint64(token.ID), 10),
+		Outcome:    service.AuditOutcomeSuccess,
+		Details:    map[string]string{"name": token.Name},
+	})
+	v1Message(c, http.StatusOK, "API 令牌已撤销", newAPITokenView(token))
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pokerjest/animateAutoTool/internal/authsession"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestAPIToken(t *testing.T, r *gin.Engine, cookie, body string) (string, apiTokenView) {
+	t.Helper()
+	w := serveAs(r, cookie, http.MethodPost, "/api/v1/tokens", body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var resp struct {
+		Data struct {
+			Token string       `json:"token"`
+			Item  apiTokenView `json:"item"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp.Data.Token, resp.Data.Item
+}
+
+// serveWithToken sends a scripted request: no cookie and a foreign Origin, as
+// a cron job or Home Assistant would.
+func serveWithToken(r *gin.Engine, secret, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	markRemoteRequest(req)
+	req.Header.Set("Origin", "https://automation.example")
+	req.Header.Set("Authorization", "Bearer "+secret)
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestV1APITokensAuthenticateScopedRequests(t *testing.T) {
+	resetAuthFixtures(t)
+	r := setupRouter()
+	cookie, _ := loginCookie(t, r, "admin")
+
+	secret, item := createTestAPIToken(t, r, cookie, `{"name":"home assistant","scopes":["subscriptions","read"],"expires_in_days":30}`)
+	assert.Regexp(t, `^aat_[A-Za-z0-9_-]{40,}$`, secret)
+	assert.Equal(t, []string{"read", "subscriptions"}, item.Scopes)
+	assert.Equal(t, secret[:len(item.Prefix)], item.Prefix)
+	require.NotNil(t, item.ExpiresAt)
+
+	var stored model.APIToken
+	require.NoError(t, db.DB.First(&stored, item.ID).Error)
+	assert.Equal(t, service.HashAPIToken(secret), stored.TokenHash)
+	w := serveAs(r, cookie, http.MethodGet, "/api/v1/tokens", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), secret)
+	assert.NotContains(t, w.Body.String(), stored.TokenHash)
+
+	assert.Equal(t, http.StatusOK, serveWithToken(r, secret, http.MethodGet, "/api/v1/library", "").Code)
+	w = serveWithToken(r, secret, http.MethodPost, "/api/v1/subscriptions/999/run", "")
+	assert.Equal(t, http.StatusNotFound, w.Code, "write reached the handler despite the foreign Origin: %s", w.Body.String())
+
+	for _, route := range [][2]string{
+		{http.MethodPut, "/api/v1/settings"},
+		{http.MethodGet, "/api/v1/settings"},
+		{http.MethodPost, "/api/v1/library/refresh"},
+		{http.MethodGet, "/api/v1/tokens"},
+		{http.MethodPost, "/api/v1/session/logout"},
+	} {
+		w := serveWithToken(r, secret, route[0], route[1], "{}")
+		assert.Equal(t, http.StatusForbidden, w.Code, route[1])
+		assert.Contains(t, w.Body.String(), `"code":"token_scope"`, route[1])
+	}
+
+	require.NoError(t, db.DB.First(&stored, item.ID).Error)
+	require.NotNil(t, stored.LastUsedAt)
+	assert.NotEmpty(t, stored.LastUsedIP)
+	var uses []model.AuditLog
+	require.NoError(t, db.DB.Where("action = ? AND target_id = ?", service.AuditActionAPITokenUse, strconv.FormatUint(uint64(item.ID), 10)).Find(&uses).Error)
+	assert.Len(t, uses, 7)
+	assert.Equal(t, "admin", uses[0].Username)
+
+	w = serveAs(r, cookie, http.MethodDelete, "/api/v1/tokens/"+strconv.FormatUint(uint64(item.ID), 10), "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = serveWithToken(r, secret, http.MethodGet, "/api/v1/library", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"invalid_token"`)
+
+	w = serveWithToken(r, "aat_not-a-real-token", http.MethodGet, "/api/v1/library", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestV1APITokensFollowRoleAndSessionGeneration(t *testing.T) {
+	resetAuthFixtures(t)
+	r := setupRouter()
+	adminCookie, _ := loginCookie(t, r, "admin")
+	createTestUser(t, r, adminCookie, "viewer", model.UserRoleViewer)
+	viewerCookie := v1LoginCookie(t, r, "viewer", "household-pass")
+
+	// Scopes never lift a token above its owner's role.
+	secret, _ := createTestAPIToken(t, r, viewerCookie, `{"name":"cron","scopes":["admin"]}`)
+	assert.Equal(t, http.StatusOK, serveWithToken(r, secret, http.MethodGet, "/api/v1/library", "").Code)
+	w := serveWithToken(r, secret, http.MethodPost, "/api/v1/subscriptions/999/run", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"forbidden"`)
+
+	w = serveAs(r, adminCookie, http.MethodPost, "/api/v1/tokens", `{"name":"bad","scopes":["root"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Invalidating every session (as a restore does) revokes tokens as well.
+	previous, required := authsession.Current(), authsession.Required()
+	t.Cleanup(func() { authsession.Set(previous, required) })
+	authsession.InvalidateAll()
+	w = serveWithToken(r, secret, http.MethodGet, "/api/v1/library", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), service.ErrAPITokenRevoked.Error())
+}
@@ -84,6 +84,15 @@ func LoginPostHandler(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户名或密码不正确"})
 		return
 	}
+	// The legacy endpoint has no second step, so accounts with two-factor
+	// login must use /api/v1/session/login.
+	if service.TwoFactorEnabled(user) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":               "该账户已启用两步验证，请使用新版登录页",
+			"two_factor_required": true,
+		})
+		return
+	}
 
 	clearFailedLoginAttempts(clientIP)
 
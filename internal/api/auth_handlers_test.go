@@ -14,6 +14,7 @@ import (
 	"github.com/pokerjest/animateAutoTool/internal/db"
 	"github.com/pokerjest/animateAutoTool/internal/model"
 	"github.com/pokerjest/animateAutoTool/internal/service"
+	"github.com/pokerjest/animateAutoTool/internal/store"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -225,14 +226,10 @@ func TestBootstrapSetupCompletesPasswordRotationAndQBSave(t *testing.T) {
 		t.Fatalf("expected new password to work: %v", err)
 	}
 
-	var configs []model.GlobalConfig
-	if err := db.DB.Find(&configs).Error; err != nil {
+	configMap, err := store.NewConfigStore(db.DB).ListMap()
+	if err != nil {
 		t.Fatalf("failed to fetch configs: %v", err)
 	}
-	configMap := make(map[string]string, len(configs))
-	for _, cfg := range configs {
-		configMap[cfg.Key] = cfg.Value
-	}
 
 	assert.Equal(t, "external", configMap[model.ConfigKeyQBMode])
 	assert.Equal(t, "http://qb.local:8080", configMap[model.ConfigKeyQBUrl])
@@ -312,7 +309,7 @@ func TestBootstrapPendingBlocksRemoteAccessUntilLocalSetupCompletes(t *testing.T
 	r := setupRouter()
 
 	bootstrapPassword := "bootstrap-secret-123"
-	if err := service.NewAuthService().ResetPasswordByUsername("admin", bootstrapPassword); err != nil {
+	if _, err := service.NewAuthService().ResetPasswordByUsername("admin", bootstrapPassword); err != nil {
 		t.Fatalf("failed to align admin password with bootstrap password: %v", err)
 	}
 	if err := bootstrap.SaveAdminBootstrapInfo(bootstrap.AdminBootstrapInfo{
@@ -351,7 +348,7 @@ func TestBootstrapLoginPageShowsLocalCredentialPathWithoutPassword(t *testing.T)
 	r := setupRouter()
 
 	bootstrapPassword := "bootstrap-secret-456"
-	if err := service.NewAuthService().ResetPasswordByUsername("admin", bootstrapPassword); err != nil {
+	if _, err := service.NewAuthService().ResetPasswordByUsername("admin", bootstrapPassword); err != nil {
 		t.Fatalf("failed to align admin password with bootstrap password: %v", err)
 	}
 	if err := bootstrap.SaveAdminBootstrapInfo(bootstrap.AdminBootstrapInfo{
@@ -14,6 +14,9 @@ import (
 var errNoActiveSession = errors.New("no active session")
 
 func currentSessionUserID(c *gin.Context) (uint, error) {
+	if token, ok := requestAPIToken(c); ok {
+		return token.UserID, nil
+	}
 	if value, exists := c.Get(passwordlessUserIDContextKey); exists {
 		if userID, ok := value.(uint); ok && userID != 0 {
 			return userID, nil
@@ -29,17 +32,27 @@ func currentSessionUserID(c *gin.Context) (uint, error) {
 		return 0, errNoActiveSession
 	}
 
-	switch value := userID.(type) {
+	id, ok := sessionUint(userID)
+	if !ok {
+		return 0, errors.New("invalid session user id")
+	}
+	return id, nil
+}
+
+// sessionUint reads a numeric session value regardless of how the cookie
+// codec round-tripped it.
+func sessionUint(value any) (uint, bool) {
+	switch value := value.(type) {
 	case uint:
-		return value, nil
+		return value, true
 	case int:
-		return uint(value), nil
+		return uint(value), true
 	case int64:
-		return uint(value), nil
+		return uint(value), true
 	case float64:
-		return uint(value), nil
+		return uint(value), true
 	default:
-		return 0, errors.New("invalid session user id")
+		return 0, false
 	}
 }
 
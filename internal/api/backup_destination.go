@@ -0,0 +1,179 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/pokerjest/animateAutoTool/internal/backupdest"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"golang.org/x/crypto/ssh"
+)
+
+// backupDestinationSettingKeys lists the settings each offsite destination
+// reads. The connection test accepts overrides for exactly these keys.
+var backupDestinationSettingKeys = map[string][]string{
+	backupdest.KindR2:     {model.ConfigKeyR2Endpoint, model.ConfigKeyR2Bucket, model.ConfigKeyR2AccessKey, model.ConfigKeyR2SecretKey},
+	backupdest.KindS3:     {model.ConfigKeyS3Endpoint, model.ConfigKeyS3Region, model.ConfigKeyS3Bucket, model.ConfigKeyS3AccessKey, model.ConfigKeyS3SecretKey, model.ConfigKeyS3PathStyle},
+	backupdest.KindWebDAV: {model.ConfigKeyWebDAVURL, model.ConfigKeyWebDAVUsername, model.ConfigKeyWebDAVPassword},
+	backupdest.KindSFTP: {
+		model.ConfigKeySFTPHost, model.ConfigKeySFTPUsername, model.ConfigKeySFTPPassword,
+		model.ConfigKeySFTPPrivateKey, model.ConfigKeySFTPHostFingerprint, model.ConfigKeySFTPDir,
+	},
+}
+
+// configuredBackupDestinationKind returns the destination used for cloud
+// backups. Installations that predate the setting keep using R2.
+func configuredBackupDestinationKind() string {
+	return backupdest.NormalizeKind(configValue(model.ConfigKeyBackupDestination))
+}
+
+// backupDestinationConfigured reports whether the required settings of kind
+// are filled in, without contacting the service.
+func backupDestinationConfigured(kind string) bool {
+	switch kind {
+	case backupdest.KindS3:
+		return configValue(model.ConfigKeyS3Bucket) != ""
+	case backupdest.KindWebDAV:
+		return configValue(model.ConfigKeyWebDAVURL) != ""
+	case backupdest.KindSFTP:
+		return configValue(model.ConfigKeySFTPHost) != "" && configValue(model.ConfigKeySFTPUsername) != ""
+	default:
+		return configValue(model.ConfigKeyR2Endpoint) != "" && configValue(model.ConfigKeyR2Bucket) != ""
+	}
+}
+
+// openBackupDestination builds the destination of kind from the saved
+// settings. overrides replace single settings; blank or masked values fall
+// back to the saved ones so secrets never have to be sent again.
+func openBackupDestination(kind string, overrides map[string]string, httpClient *http.Client) (backupdest.Destination, error) {
+	value := func(key string) string {
+		if override := overrides[key]; strings.TrimSpace(override) != "" && !isMasked(override) {
+			return strings.TrimSpace(override)
+		}
+		return configValue(key)
+	}
+	switch kind {
+	case backupdest.KindS3:
+		return backupdest.NewS3(backupdest.S3Config{
+			Endpoint:   value(model.ConfigKeyS3Endpoint),
+			Region:     value(model.ConfigKeyS3Region),
+			Bucket:     value(model.ConfigKeyS3Bucket),
+			AccessKey:  value(model.ConfigKeyS3AccessKey),
+			SecretKey:  value(model.ConfigKeyS3SecretKey),
+			PathStyle:  !strings.EqualFold(value(model.ConfigKeyS3PathStyle), ValueFalse),
+			HTTPClient: httpClient,
+		})
+	case backupdest.KindWebDAV:
+		return backupdest.NewWebDAV(backupdest.WebDAVConfig{
+			URL:        value(model.ConfigKeyWebDAVURL),
+			Username:   value(model.ConfigKeyWebDAVUsername),
+			Password:   value(model.ConfigKeyWebDAVPassword),
+			HTTPClient: httpClient,
+		})
+	case backupdest.KindSFTP:
+		return backupdest.NewSFTP(backupdest.SFTPConfig{
+			Addr:            value(model.ConfigKeySFTPHost),
+			Username:        value(model.ConfigKeySFTPUsername),
+			Password:        value(model.ConfigKeySFTPPassword),
+			PrivateKey:      value(model.ConfigKeySFTPPrivateKey),
+			HostFingerprint: value(model.ConfigKeySFTPHostFingerprint),
+			Dir:             value(model.ConfigKeySFTPDir),
+			Timeout:         r2RequestTimeout,
+		})
+	default:
+		return backupdest.NewR2(backupdest.S3Config{
+			Endpoint:   value(model.ConfigKeyR2Endpoint),
+			Bucket:     value(model.ConfigKeyR2Bucket),
+			AccessKey:  value(model.ConfigKeyR2AccessKey),
+			SecretKey:  value(model.ConfigKeyR2SecretKey),
+			HTTPClient: httpClient,
+		})
+	}
+}
+
+// backupDestinationErrorMessage turns destination errors into messages a
+// user can act on.
+func backupDestinationErrorMessage(kind string, err error) string {
+	var hostKeyErr *backupdest.HostKeyError
+	switch {
+	case errors.Is(err, backupdest.ErrIncompleteConfig):
+		return backupdest.Label(kind) + " 配置不完整，请先填写必填项"
+	case errors.As(err, &hostKeyErr) && hostKeyErr.Expected == "":
+		return "SFTP 服务器的主机指纹为 " + hostKeyErr.Fingerprint + "，确认无误后填入 sftp_host_fingerprint 再重试"
+	case errors.As(err, &hostKeyErr):
+		return "SFTP 服务器的主机指纹 " + hostKeyErr.Fingerprint + " 与已保存的 " + hostKeyErr.Expected + " 不一致，可能连接到了错误的服务器"
+	default:
+		return err.Error()
+	}
+}
+
+func normalizeBackupDestination(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if !backupdest.IsKnownKind(value) {
+		return "", errors.New("云备份存储只支持 r2、s3、webdav 或 sftp")
+	}
+	return backupdest.NormalizeKind(value), nil
+}
+
+func normalizeBackupDestinationURL(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", errors.New("存储地址必须是 http 或 https 链接")
+	}
+	return parsed.String(), nil
+}
+
+func normalizeS3PathStyle(value string) (string, error) {
+	value = strings.ToLower(value)
+	if value != "" && value != model.ConfigValueTrue && value != ValueFalse {
+		return "", errors.New("S3 路径风格开关必须为 true 或 false")
+	}
+	return value, nil
+}
+
+func normalizeSFTPHost(value string) (string, error) {
+	addr, err := backupdest.NormalizeSFTPAddr(value)
+	if err != nil {
+		return "", errors.New("SFTP 地址格式应为 主机 或 主机:端口")
+	}
+	return addr, nil
+}
+
+func normalizeSFTPHostFingerprint(value string) (string, error) {
+	fingerprint, err := backupdest.NormalizeHostFingerprint(value)
+	if err != nil {
+		return "", errors.New("主机指纹应为 ssh-keygen -lf 输出的 SHA256:... 形式")
+	}
+	return fingerprint, nil
+}
+
+func normalizeSFTPPrivateKey(value string) (string, error) {
+	if _, err := ssh.ParsePrivateKey([]byte(value + "\n")); err != nil {
+		var passphraseErr *ssh.PassphraseMissingError
+		if errors.As(err, &passphraseErr) {
+			return "", errors.New("暂不支持带口令的私钥，请导出一份无口令的专用密钥")
+		}
+		return "", errors.New("无法解析 SFTP 私钥，请粘贴 OpenSSH 或 PEM 格式的完整私钥")
+	}
+	return value, nil
+}
+
+func normalizeSFTPDir(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	for _, segment := range strings.Split(value, "/") {
+		if segment == ".." {
+			return "", errors.New("SFTP 目录不能包含 ..")
+		}
+	}
+	return path.Clean(value), nil
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/scheduler"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/webdav"
+)
+
+func newTestWebDAVServer(t *testing.T) (webdav.FileSystem, *httptest.Server) {
+	t.Helper()
+	fs := webdav.NewMemFS()
+	handler := &webdav.Handler{FileSystem: fs, LockSystem: webdav.NewMemLS()}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "nas" || pass != "dav-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(invalidateRemoteBackupCache)
+	return fs, server
+}
+
+func TestV1BackupDestinationSettingsValidation(t *testing.T) {
+	resetAuthFixtures(t)
+	r := setupRouter()
+	cookie, _ := loginCookie(t, r, "admin")
+
+	for _, body := range []string{
+		`{"values":{"backup_destination":"ftp"}}`,
+		`{"values":{"webdav_url":"ftp://nas/backups"}}`,
+		`{"values":{"sftp_host_fingerprint":"MD5:aa:bb"}}`,
+		`{"values":{"sftp_private_key":"not a key"}}`,
+		`{"values":{"sftp_dir":"/srv/../etc"}}`,
+	} {
+		w := serveAs(r, cookie, http.MethodPut, "/api/v1/settings", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		assert.Contains(t, w.Body.String(), "invalid_backup_destination", body)
+	}
+
+	w := serveAs(r, cookie, http.MethodPut, "/api/v1/settings", `{"values":{"backup_destination":"MinIO","s3_endpoint":"http://minio:9000","s3_secret_key":"minio-secret","sftp_host":"nas.local"}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "s3", configValue(model.ConfigKeyBackupDestination))
+	assert.Equal(t, "nas.local:22", configValue(model.ConfigKeySFTPHost))
+	assert.Equal(t, "minio-secret", configValue(model.ConfigKeyS3SecretKey))
+
+	w = serveAs(r, cookie, http.MethodGet, "/api/v1/settings", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "minio-secret")
+}
+
+func TestV1BackupFlowsUseWebDAVDestination(t *testing.T) {
+	resetAuthFixtures(t)
+	now := time.Date(2026, 10, 16, 3, 0, 0, 0, time.Local)
+	resetBackupScheduleState(t, now)
+	fs, server := newTestWebDAVServer(t)
+	for key, value := range map[string]string{
+		model.ConfigKeyBackupDestination:      "webdav",
+		model.ConfigKeyWebDAVURL:              server.URL + "/backups/anime",
+		model.ConfigKeyWebDAVUsername:         "nas",
+		model.ConfigKeyWebDAVPassword:         "dav-secret",
+		model.ConfigKeyBackupScheduleTargets:  "r2",
+		model.ConfigKeyBackupSchedulePassword: "scheduled-secret",
+		model.ConfigKeyBackupScheduleMode:     service.BackupModeSettings,
+	} {
+		require.NoError(t, db.SaveGlobalConfig(key, value))
+	}
+	r := setupRouter()
+	cookie, _ := loginCookie(t, r, "admin")
+
+	// Blank secrets fall back to the saved password; a wrong one is used as given.
+	w := serveAs(r, cookie, http.MethodPost, "/api/v1/backup/r2/test", `{"destination":"webdav","values":{"webdav_url":"`+server.URL+`/probe","webdav_password":""}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "WebDAV 连接成功")
+	w = serveAs(r, cookie, http.MethodPost, "/api/v1/backup/r2/test", `{"destination":"webdav","values":{"webdav_password":"wrong"}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "读取校验失败")
+
+	// The legacy "r2" schedule target now means the configured destination.
+	policy, err := scheduler.LoadBackupPolicy()
+	require.NoError(t, err)
+	require.Equal(t, []string{scheduler.BackupTargetRemote}, policy.Targets)
+	run, err := backupScheduler.Run(context.Background(), policy, scheduler.BackupTriggerAPI, "backup-destination-test", service.AuditContext{Username: "admin"})
+	require.NoError(t, err, run.Error)
+	require.Len(t, run.Targets, 1)
+	assert.Equal(t, "webdav", run.Targets[0].Destination)
+	assert.Equal(t, server.URL+"/backups/anime/"+run.Artifact, run.Targets[0].Location)
+	_, err = fs.Stat(context.Background(), "/backups/anime/"+run.Artifact)
+	require.NoError(t, err)
+
+	w = serveAs(r, cookie, http.MethodGet, "/api/v1/backup", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var overview struct {
+		Data struct {
+			R2 struct {
+				Configured  bool           `json:"configured"`
+				Destination string         `json:"destination"`
+				Files       []R2BackupFile `json:"files"`
+			} `json:"r2"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &overview))
+	assert.True(t, overview.Data.R2.Configured)
+	assert.Equal(t, "webdav", overview.Data.R2.Destination)
+	require.Len(t, overview.Data.R2.Files, 1)
+	assert.Equal(t, run.Artifact, overview.Data.R2.Files[0].Key)
+
+	w = serveAs(r, cookie, http.MethodPost, "/api/v1/backup/r2/delete", `{"key":"`+run.Artifact+`"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	_, err = fs.Stat(context.Background(), "/backups/anime/"+run.Artifact)
+	assert.True(t, os.IsNotExist(err), "expected archive to be deleted, got %v", err)
+	var deletions int64
+	require.NoError(t, db.DB.Model(&model.AuditLog{}).Where("action = ? AND target_type = ?", service.AuditActionR2BackupDelete, "webdav_object").Count(&deletions).Error)
+	assert.Equal(t, int64(1), deletions)
+}
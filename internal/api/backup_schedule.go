@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pokerjest/animateAutoTool/internal/backupdest"
+	"github.com/pokerjest/animateAutoTool/internal/httpx"
+	"github.com/pokerjest/animateAutoTool/internal/scheduler"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+)
+
+// BackupScheduleStatus is returned by GET /api/v1/backup/schedule.
+type BackupScheduleStatus struct {
+	Enabled            bool                    `json:"enabled"`
+	Schedule           string                  `json:"schedule"`
+	Mode               string                  `json:"mode"`
+	Targets            []string                `json:"targets"`
+	LocalDir           string                  `json:"local_dir"`
+	PasswordConfigured bool                    `json:"password_configured"`
+	Retention          service.BackupRetention `json:"retention"`
+	NextRunAt          *time.Time              `json:"next_run_at,omitempty"`
+	ConfigError        string                  `json:"config_error,omitempty"`
+	Running            bool                    `json:"running"`
+	LastRun            *scheduler.BackupRun    `json:"last_run,omitempty"`
+}
+
+// backupScheduler runs the schedule for the process; the "remote" target
+// goes to the configured backup destination.
+var backupScheduler = scheduler.NewBackupRunner(scheduledBackupRemote)
+
+func scheduledBackupRemote() scheduler.BackupRemote {
+	kind := configuredBackupDestinationKind()
+	return scheduler.BackupRemote{
+		Kind: kind,
+		Open: func() (backupdest.Destination, error) {
+			destination, err := openBackupDestination(kind, nil, httpx.NewHTTPClient(0))
+			if err != nil {
+				return nil, fmt.Errorf("%s 配置有误: %s", backupdest.Label(kind), backupDestinationErrorMessage(kind, err))
+			}
+			return destination, nil
+		},
+		Describe: func(err error) string { return backupDestinationErrorMessage(kind, err) },
+		Changed:  invalidateRemoteBackupCache,
+	}
+}
+
+func normalizeBackupScheduleCron(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	schedule, err := scheduler.ParseCronSchedule(value)
+	if err != nil {
+		return "", err
+	}
+	return schedule.String(), nil
+}
+
+func normalizeBackupScheduleMode(value string) (string, error) {
+	value = strings.ToLower(value)
+	switch value {
+	case "", service.BackupModeFull, service.BackupModeSettings, service.BackupModeCloudflare:
+		return value, nil
+	default:
+		return "", errors.New("定时备份模式只支持 full、settings 或 cloudflare")
+	}
+}
+
+func normalizeBackupScheduleTargets(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	targets, err := scheduler.ParseBackupTargets(value)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(targets, ","), nil
+}
+
+func normalizeBackupScheduleLocalDir(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if !filepath.IsAbs(value) {
+		return "", errors.New("定时备份目录必须是绝对路径")
+	}
+	return filepath.Clean(value), nil
+}
+
+func normalizeBackupSchedulePassword(value string) (string, error) {
+	if len(value) < scheduler.MinBackupPassword {
+		return "", fmt.Errorf("定时备份密码至少需要 %d 个字符", scheduler.MinBackupPassword)
+	}
+	return value, nil
+}
+
+func normalizeBackupRetentionCount(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	count, err := scheduler.ParseBackupRetentionCount(value)
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(count), nil
+}
+
+// StartBackupScheduler runs the backup schedule until the app shuts down.
+func StartBackupScheduler() {
+	GoBackground(backupScheduler.Loop, "backup-schedule")
+}
+
+func buildBackupScheduleStatus() BackupScheduleStatus {
+	policy, err := scheduler.LoadBackupPolicy()
+	status := BackupScheduleStatus{
+		Enabled:            policy.Enabled,
+		Schedule:           policy.Schedule.String(),
+		Mode:               policy.Mode,
+		Targets:            policy.Targets,
+		LocalDir:           policy.LocalDir,
+		PasswordConfigured: policy.Password != "",
+		Retention:          policy.Retention,
+		NextRunAt:          backupScheduler.NextRun(policy),
+		Running:            backupScheduler.Running(),
+		LastRun:            backupScheduler.LastRun(),
+	}
+	if status.Targets == nil {
+		status.Targets = []string{}
+	}
+	if err == nil {
+		err = policy.Validate()
+	}
+	if err != nil {
+		status.ConfigError = err.Error()
+	}
+	return status
+}
+
+func V1BackupScheduleHandler(c *gin.Context) {
+	v1Data(c, http.StatusOK, buildBackupScheduleStatus())
+}
+
+// V1RunBackupScheduleHandler runs the configured backup schedule once, even
+// when the schedule itself is disabled.
+func V1RunBackupScheduleHandler(c *gin.Context) {
+	policy, err := scheduler.LoadBackupPolicy()
+	if err == nil {
+		err = policy.Validate()
+	}
+	if err != nil {
+		v1Error(c, http.StatusBadRequest, "invalid_backup_schedule", err.Error())
+		return
+	}
+	if backupScheduler.Running() {
+		v1Error(c, http.StatusConflict, "backup_schedule_running", scheduler.ErrBackupRunning.Error())
+		return
+	}
+	taskID := uuid.NewString()
+	auditCtx := buildAuditContext(c)
+	runner := backupScheduler
+	if !GoBackground(func(appCtx context.Context) {
+		if _, err := runner.Run(appCtx, policy, scheduler.BackupTriggerAPI, taskID, auditCtx); err != nil {
+			log.Printf("ERROR: BackupSchedule: manual run failed task=%s error=%v", taskID, err)
+		}
+	}, "backup-schedule-run") {
+		v1Error(c, http.StatusServiceUnavailable, "service_unavailable", "服务正在关闭，无法启动定时备份")
+		return
+	}
+	v1Message(c, http.StatusAccepted, "定时备份任务已经启动", gin.H{"task_id": taskID, "status": "running"})
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/scheduler"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+	"github.com/pokerjest/animateAutoTool/internal/taskstate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetBackupScheduleState(t *testing.T, now time.Time) {
+	t.Helper()
+	previous := backupScheduler
+	backupScheduler = &scheduler.BackupRunner{Remote: scheduledBackupRemote, Now: func() time.Time { return now }}
+	require.NoError(t, db.DB.Where("action LIKE ?", "backup.schedule.%").Delete(&model.AuditLog{}).Error)
+	t.Cleanup(func() { backupScheduler = previous })
+}
+
+func TestBackupScheduleFailureIsReportedInHealth(t *testing.T) {
+	resetAuthFixtures(t)
+	resetBackupScheduleState(t, time.Date(2026, 10, 16, 3, 0, 0, 0, time.Local))
+	require.NoError(t, db.SaveGlobalConfig(model.ConfigKeyBackupScheduleEnabled, ValueTrue))
+
+	policy, err := scheduler.LoadBackupPolicy()
+	require.NoError(t, err)
+	run, err := backupScheduler.Run(context.Background(), policy, scheduler.BackupTriggerCron, "backup-schedule-fail", service.AuditContext{Username: "scheduler"})
+	require.Error(t, err)
+	assert.Equal(t, scheduler.BackupRunFailure, run.Status)
+	task, _ := taskstate.Global.Get("backup-schedule-fail")
+	assert.Equal(t, taskstate.StatusError, task.Status)
+
+	report := buildHealthReport()
+	assert.True(t, report.BackupScheduleEnabled)
+	assert.Equal(t, scheduler.BackupRunFailure, report.BackupScheduleLastStatus)
+	assert.Contains(t, report.BackupScheduleProblem, "备份压缩包密码")
+	assert.NotEqual(t, "emerald", report.HealthTone)
+	assert.Contains(t, strings.Join(report.Recommendations, "\n"), "定时备份未能完成")
+}
+
+func TestV1BackupScheduleSettingsAndEndpoints(t *testing.T) {
+	resetAuthFixtures(t)
+	resetBackupScheduleState(t, time.Date(2026, 10, 16, 2, 30, 0, 0, time.Local))
+	r := setupRouter()
+	cookie, _ := loginCookie(t, r, "admin")
+
+	w := serveAs(r, cookie, http.MethodPut, "/api/v1/settings", `{"values":{"backup_schedule_cron":"0 25 * * *"}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "invalid_backup_schedule")
+	w = serveAs(r, cookie, http.MethodPut, "/api/v1/settings", `{"values":{"backup_schedule_targets":"local,ftp"}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = serveAs(r, cookie, http.MethodPost, "/api/v1/backup/schedule/run", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = serveAs(r, cookie, http.MethodPut, "/api/v1/settings", `{"values":{"backup_schedule_enabled":"true","backup_schedule_cron":"@daily","backup_schedule_targets":"r2, local","backup_schedule_password":"long-enough","backup_retention_weekly":"2"}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = serveAs(r, cookie, http.MethodGet, "/api/v1/backup/schedule", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NotContains(t, w.Body.String(), "long-enough")
+	var status struct {
+		Data BackupScheduleStatus `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.True(t, status.Data.Enabled)
+	assert.Equal(t, "@daily", status.Data.Schedule)
+	assert.Equal(t, []string{"local", "remote"}, status.Data.Targets)
+	assert.True(t, status.Data.PasswordConfigured)
+	assert.Equal(t, service.BackupRetention{KeepLast: 7, Daily: 7, Weekly: 2, Monthly: 6}, status.Data.Retention)
+	require.NotNil(t, status.Data.NextRunAt)
+	assert.Equal(t, time.Date(2026, 10, 17, 0, 0, 0, 0, time.Local), status.Data.NextRunAt.In(time.Local))
+	assert.Empty(t, status.Data.ConfigError)
+}
@@ -0,0 +1,358 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"github.com/pokerjest/animateAutoTool/internal/taskstate"
+	"gorm.io/gorm"
+)
+
+const (
+	bangumiSyncTaskID        = "bangumi-collection-sync"
+	bangumiSyncInterval      = 24 * time.Hour
+	bangumiSyncCheckInterval = time.Hour
+	bangumiSyncTriggerDaily  = "schedule"
+	bangumiSyncTriggerAPI    = "manual"
+	maxBangumiSyncRecords    = 500
+)
+
+var errBangumiSyncRunning = errors.New("Bangumi 收藏同步正在运行")
+
+var (
+	bangumiSyncRunning atomic.Bool
+	bangumiSyncMu      sync.Mutex
+	bangumiSyncLast    *service.BangumiSyncReport
+	bangumiSyncLoaded  bool
+	bangumiSyncNow     = time.Now
+)
+
+// newBangumiSyncClients returns the Bangumi and Mikan clients used by the
+// collection sync. Tests replace it.
+var newBangumiSyncClients = func() (service.BangumiCollectionReader, service.MikanSubjectResolver) {
+	return service.NewConfiguredBangumiClient(), newConfiguredMikanParser()
+}
+
+// BangumiSyncStatus is returned by GET /api/v1/subscriptions/bangumi-sync.
+type BangumiSyncStatus struct {
+	Enabled     bool                          `json:"enabled"`
+	Connected   bool                          `json:"connected"`
+	Mode        string                        `json:"mode"`
+	Types       []string                      `json:"types"`
+	Subgroups   []string                      `json:"subgroups"`
+	ConfigError string                        `json:"config_error,omitempty"`
+	Running     bool                          `json:"running"`
+	NextRunAt   *time.Time                    `json:"next_run_at,omitempty"`
+	LastRun     *service.BangumiSyncReport    `json:"last_run,omitempty"`
+	Records     []model.BangumiCollectionSync `json:"records"`
+}
+
+func loadBangumiSyncOptions() (bool, service.BangumiSyncOptions, error) {
+	enabled := strings.EqualFold(configValue(model.ConfigKeyBangumiSyncEnabled), ValueTrue)
+	opts := service.BangumiSyncOptions{
+		Mode:      service.BangumiSyncModePropose,
+		Subgroups: service.ParseBangumiSyncSubgroups(configValue(model.ConfigKeyBangumiSyncSubgroups)),
+	}
+	if strings.EqualFold(strings.TrimSpace(configValue(model.ConfigKeyBangumiSyncMode)), service.BangumiSyncModeSubscribe) {
+		opts.Mode = service.BangumiSyncModeSubscribe
+	}
+	types, err := service.ParseBangumiSyncTypes(configValue(model.ConfigKeyBangumiSyncTypes))
+	if err != nil {
+		return enabled, opts, errors.New("Bangumi 收藏同步的收藏类型只能是 wish 和 watching")
+	}
+	opts.Types = types
+	return enabled, opts, nil
+}
+
+func normalizeBangumiSyncTypes(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	types, err := service.ParseBangumiSyncTypes(value)
+	if err != nil {
+		return "", errors.New("收藏类型只能是 wish（想看）和 watching（在看），多个用逗号分隔")
+	}
+	return service.FormatBangumiSyncTypes(types), nil
+}
+
+func normalizeBangumiSyncMode(value string) (string, error) {
+	value = strings.ToLower(value)
+	switch value {
+	case "", service.BangumiSyncModePropose, service.BangumiSyncModeSubscribe:
+		return value, nil
+	default:
+		return "", errors.New("同步方式只能是 propose（等待确认）或 subscribe（自动订阅）")
+	}
+}
+
+func normalizeBangumiSyncSubgroups(value string) (string, error) {
+	return strings.Join(service.ParseBangumiSyncSubgroups(value), ","), nil
+}
+
+// StartBangumiCollectionSync checks once an hour whether the daily Bangumi
+// collection sync is due. Settings are re-read on every check.
+func StartBangumiCollectionSync() {
+	GoBackground(func(appCtx context.Context) {
+		for {
+			timer := time.NewTimer(bangumiSyncCheckInterval)
+			select {
+			case <-appCtx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			enabled, opts, err := loadBangumiSyncOptions()
+			if !enabled || err != nil || configValue(model.ConfigKeyBangumiAccessToken) == "" {
+				continue
+			}
+			if next := nextBangumiSyncRun(); next.After(bangumiSyncNow()) {
+				continue
+			}
+			if _, err := runBangumiCollectionSync(appCtx, opts, bangumiSyncTriggerDaily, service.AuditContext{Username: "scheduler"}); err != nil && !errors.Is(err, errBangumiSyncRunning) {
+				log.Printf("WARN: BangumiSync: scheduled run failed error=%v", err)
+			}
+		}
+	}, "bangumi-collection-sync")
+}
+
+// nextBangumiSyncRun is one interval after the last run, or now when the
+// sync has never run.
+func nextBangumiSyncRun() time.Time {
+	last := lastBangumiSyncRun()
+	if last == nil || last.StartedAt.IsZero() {
+		return bangumiSyncNow()
+	}
+	return last.StartedAt.Add(bangumiSyncInterval)
+}
+
+func runBangumiCollectionSync(ctx context.Context, opts service.BangumiSyncOptions, trigger string, auditCtx service.AuditContext) (service.BangumiSyncReport, error) {
+	if !bangumiSyncRunning.CompareAndSwap(false, true) {
+		return service.BangumiSyncReport{}, errBangumiSyncRunning
+	}
+	defer bangumiSyncRunning.Store(false)
+
+	taskstate.Global.StartBy(taskstate.Initiator{UserID: auditCtx.UserID, Username: auditCtx.Username}, bangumiSyncTaskID, "bangumi-sync", "同步 Bangumi 收藏", "正在读取想看和在看列表")
+	runCtx, cancel := taskstate.Global.WithCancel(ctx, bangumiSyncTaskID)
+	defer cancel()
+
+	reader, mikan := newBangumiSyncClients()
+	report, err := service.SyncBangumiCollections(runCtx, reader, mikan, configValue(model.ConfigKeyBangumiAccessToken), opts, createBangumiSyncSubscription, bangumiSyncNow)
+	report.Trigger = trigger
+
+	bangumiSyncMu.Lock()
+	bangumiSyncLast = &report
+	bangumiSyncLoaded = true
+	bangumiSyncMu.Unlock()
+
+	outcome := service.AuditOutcomeSuccess
+	if err != nil {
+		outcome = service.AuditOutcomeFailure
+	}
+	service.RecordAudit(auditCtx, service.AuditEntry{
+		Action:     service.AuditActionBangumiSyncRun,
+		Outcome:    outcome,
+		TargetType: "bangumi_sync",
+		Details:    report,
+	})
+	if err != nil {
+		log.Printf("WARN: BangumiSync: run failed trigger=%s seen=%d error=%v", trigger, report.Seen, err)
+		taskstate.Global.Fail(bangumiSyncTaskID, err)
+		return report, err
+	}
+	log.Printf("BangumiSync: run finished trigger=%s seen=%d subscribed=%d proposed=%d", trigger, report.Seen, len(report.Subscribed), len(report.Proposed))
+	taskstate.Global.Complete(bangumiSyncTaskID, bangumiSyncSummary(report))
+	return report, nil
+}
+
+func bangumiSyncSummary(report service.BangumiSyncReport) string {
+	return fmt.Sprintf("已检查 %d 部：新订阅 %d 部，待确认 %d 部", report.Seen, len(report.Subscribed), len(report.Proposed))
+}
+
+// createBangumiSyncSubscription stores a subscription created by the sync
+// or by confirming a proposal, and checks it right away.
+func createBangumiSyncSubscription(sub *model.Subscription) error {
+	metadata := &service.SubscriptionBundleMetadata{}
+	if sub.Metadata != nil {
+		metadata.Title, metadata.BangumiID = sub.Metadata.Title, sub.Metadata.BangumiID
+	}
+	enrich := linkImportedMetadata(sub, metadata)
+	if err := storeNewSubscription(sub, enrich); err != nil {
+		return err
+	}
+	created := *sub
+	GoBackground(func(context.Context) {
+		if err := runSubscriptionCheck(&created, "bangumi-sync"); err != nil {
+			log.Printf("WARN: Skipping async subscription run for %s: %v", created.Title, err)
+		}
+	})
+	return nil
+}
+
+// lastBangumiSyncRun returns the most recent run, falling back to the latest
+// audit entry after a restart.
+func lastBangumiSyncRun() *service.BangumiSyncReport {
+	bangumiSyncMu.Lock()
+	defer bangumiSyncMu.Unlock()
+	if !bangumiSyncLoaded {
+		bangumiSyncLoaded = true
+		entries, err := service.ListAuditLogs(store.AuditLogQuery{Action: service.AuditActionBangumiSyncRun, Limit: 1})
+		if err == nil && len(entries) > 0 {
+			var report service.BangumiSyncReport
+			if err := json.Unmarshal([]byte(entries[0].Details), &report); err == nil {
+				bangumiSyncLast = &report
+			}
+		}
+	}
+	if bangumiSyncLast == nil {
+		return nil
+	}
+	report := *bangumiSyncLast
+	return &report
+}
+
+func bangumiSyncStore() *store.BangumiCollectionSyncStore {
+	return store.NewBangumiCollectionSyncStore(db.DB)
+}
+
+// V1BangumiSyncHandler returns the sync settings, the last run and the
+// per-subject records, optionally filtered by ?status=.
+func V1BangumiSyncHandler(c *gin.Context) {
+	enabled, opts, err := loadBangumiSyncOptions()
+	status := BangumiSyncStatus{
+		Enabled:   enabled,
+		Connected: configValue(model.ConfigKeyBangumiAccessToken) != "",
+		Mode:      opts.Mode,
+		Types:     strings.Split(service.FormatBangumiSyncTypes(opts.Types), ","),
+		Subgroups: opts.Subgroups,
+		Running:   bangumiSyncRunning.Load(),
+		LastRun:   lastBangumiSyncRun(),
+		Records:   []model.BangumiCollectionSync{},
+	}
+	if err != nil {
+		status.ConfigError = err.Error()
+		status.Types = []string{}
+	}
+	if status.Subgroups == nil {
+		status.Subgroups = []string{}
+	}
+	if enabled && status.Connected && err == nil {
+		next := nextBangumiSyncRun()
+		status.NextRunAt = &next
+	}
+
+	var statuses []string
+	if raw := strings.TrimSpace(c.Query("status")); raw != "" {
+		statuses = strings.Split(raw, ",")
+	}
+	records, err := bangumiSyncStore().List(statuses, maxBangumiSyncRecords)
+	if err != nil || records == nil {
+		v1Error(c, http.StatusInternalServerError, "bangumi_sync_unavailable", "无法读取 Bangumi 同步记录")
+		return
+	}
+	status.Records = records
+	v1Data(c, http.StatusOK, status)
+}
+
+// V1RunBangumiSyncHandler runs the collection sync once in the background,
+// even when the daily sync is disabled.
+func V1RunBangumiSyncHandler(c *gin.Context) {
+	_, opts, err := loadBangumiSyncOptions()
+	if err != nil {
+		v1Error(c, http.StatusBadRequest, "invalid_bangumi_sync", err.Error())
+		return
+	}
+	if configValue(model.ConfigKeyBangumiAccessToken) == "" {
+		v1Error(c, http.StatusBadRequest, "bangumi_not_connected", "请先在设置中登录 Bangumi 账号")
+		return
+	}
+	if bangumiSyncRunning.Load() {
+		v1Error(c, http.StatusConflict, "bangumi_sync_running", errBangumiSyncRunning.Error())
+		return
+	}
+	auditCtx := buildAuditContext(c)
+	if !GoBackground(func(appCtx context.Context) {
+		if _, err := runBangumiCollectionSync(appCtx, opts, bangumiSyncTriggerAPI, auditCtx); err != nil && !errors.Is(err, errBangumiSyncRunning) {
+			log.Printf("WARN: BangumiSync: manual run failed error=%v", err)
+		}
+	}, "bangumi-collection-sync-run") {
+		v1Error(c, http.StatusServiceUnavailable, "service_unavailable", "服务正在关闭，无法启动同步")
+		return
+	}
+	v1Message(c, http.StatusAccepted, "Bangumi 收藏同步已经启动", gin.H{"task_id": bangumiSyncTaskID, "status": "running"})
+}
+
+// V1BangumiSyncActionHandler confirms or dismisses one record.
+func V1BangumiSyncActionHandler(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			v1Error(c, http.StatusBadRequest, "invalid_id", "同步记录 ID 无效")
+			return
+		}
+		records := bangumiSyncStore()
+		record, err := records.GetByID(uint(id))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			v1Error(c, http.StatusNotFound, "bangumi_sync_not_found", "未找到对应的同步记录")
+			return
+		}
+		if err != nil {
+			v1Error(c, http.StatusInternalServerError, "bangumi_sync_unavailable", "无法读取 Bangumi 同步记录")
+			return
+		}
+
+		auditAction := service.AuditActionBangumiSyncDismiss
+		message := "已忽略，之后的同步不会再处理该条目"
+		switch action {
+		case "accept":
+			if record.Status != service.BangumiSyncStatusProposed {
+				v1Error(c, http.StatusConflict, "bangumi_sync_not_proposed", "只能确认待确认的条目")
+				return
+			}
+			sub := service.BangumiSyncSubscription(record)
+			if err := createBangumiSyncSubscription(sub); err != nil {
+				if err.Error() == "exists" {
+					v1Error(c, http.StatusConflict, "subscription_exists", "该 RSS 已经订阅")
+					return
+				}
+				v1Error(c, http.StatusBadRequest, "subscription_create_failed", humanizeOperationError(err.Error()))
+				return
+			}
+			record.Status, record.SubscriptionID, record.Reason = service.BangumiSyncStatusSubscribed, sub.ID, "已确认订阅"
+			auditAction, message = service.AuditActionBangumiSyncAccept, "已创建订阅"
+		case "dismiss":
+			switch record.Status {
+			case service.BangumiSyncStatusProposed, service.BangumiSyncStatusUnresolved, service.BangumiSyncStatusFailed:
+			default:
+				v1Error(c, http.StatusConflict, "bangumi_sync_not_pending", "只能忽略待确认或未匹配的条目")
+				return
+			}
+			record.Status, record.Reason = service.BangumiSyncStatusDismissed, "已手动忽略"
+		}
+		if err := records.Save(record); err != nil {
+			v1Error(c, http.StatusInternalServerError, "bangumi_sync_save_failed", "保存同步记录失败")
+			return
+		}
+		service.RecordAudit(buildAuditContext(c), service.AuditEntry{
+			Action:     auditAction,
+			Outcome:    service.AuditOutcomeSuccess,
+			TargetType: "bangumi_subject",
+			TargetID:   strconv.Itoa(record.SubjectID),
+			Details:    map[string]any{"title": record.Title, "subscription_id": record.SubscriptionID},
+		})
+		v1Message(c, http.StatusOK, message, record)
+	}
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/pokerjest/animateAutoTool/internal/bangumi"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/parser"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubBangumiCollections map[int][]bangumi.UserCollectionItem
+
+func (s stubBangumiCollections) GetCurrentUserContext(context.Context, string) (*bangumi.UserProfile, error) {
+	return &bangumi.UserProfile{ID: 1, Username: "tester"}, nil
+}
+
+func (s stubBangumiCollections) GetUserCollectionContext(_ context.Context, _, _ string, collectionType, _, offset int) ([]bangumi.UserCollectionItem, error) {
+	if offset > 0 {
+		return nil, nil
+	}
+	return s[collectionType], nil
+}
+
+type stubBangumiSyncMikan map[string][]parser.SearchResult
+
+func (s stubBangumiSyncMikan) ResolveBangumiSubjectContext(_ context.Context, subjectID, _ string) ([]parser.SearchResult, error) {
+	return s[subjectID], nil
+}
+
+func (s stubBangumiSyncMikan) GetSubgroupsContext(_ context.Context, bangumiID string) ([]parser.Subgroup, error) {
+	return []parser.Subgroup{{ID: "370", Name: "LoliHouse"}, {ID: "382", Name: "桜都字幕组"}}, nil
+}
+
+func TestBangumiCollectionSyncProposesAndAcceptsSubscriptions(t *testing.T) {
+	resetAuthFixtures(t)
+	clearSubscriptionTransferTables(t)
+	checked := stubSubscriptionImportSideEffects(t)
+	syncKeys := []string{model.ConfigKeyBangumiAccessToken, model.ConfigKeyBangumiSyncEnabled, model.ConfigKeyBangumiSyncMode, model.ConfigKeyBangumiSyncSubgroups}
+	reset := func() {
+		clearSubscriptionTransferTables(t)
+		require.NoError(t, db.DB.Exec("DELETE FROM bangumi_collection_syncs").Error)
+		_ = db.DB.Where("key IN ?", syncKeys).Delete(&model.GlobalConfig{}).Error
+		bangumiSyncMu.Lock()
+		bangumiSyncLast, bangumiSyncLoaded = nil, false
+		bangumiSyncMu.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+
+	previousClients := newBangumiSyncClients
+	newBangumiSyncClients = func() (service.BangumiCollectionReader, service.MikanSubjectResolver) {
+		return stubBangumiCollections{
+			service.BangumiCollectionWatching: {{SubjectID: 400602, Subject: bangumi.Subject{ID: 400602, NameCN: "葬送的芙莉莲"}}},
+			service.BangumiCollectionWish:     {{SubjectID: 999999, Subject: bangumi.Subject{ID: 999999, Name: "Unreleased"}}},
+		}, stubBangumiSyncMikan{
+			"400602": {{MikanID: "3141", Title: "葬送的芙莉莲"}},
+		}
+	}
+	t.Cleanup(func() { newBangumiSyncClients = previousClients })
+
+	r := setupRouter()
+	cookie, _ := loginCookie(t, r, "admin")
+
+	w := serveAs(r, cookie, http.MethodPost, "/api/v1/subscriptions/bangumi-sync/run", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code, "running without a Bangumi login should be rejected")
+
+	require.NoError(t, store.NewConfigStore(db.DB).SetMany(map[string]string{
+		model.ConfigKeyBangumiAccessToken:   "token",
+		model.ConfigKeyBangumiSyncEnabled:   model.ConfigValueTrue,
+		model.ConfigKeyBangumiSyncSubgroups: "LoliHouse",
+	}))
+	_, opts, err := loadBangumiSyncOptions()
+	require.NoError(t, err)
+	assert.Equal(t, service.BangumiSyncModePropose, opts.Mode)
+	report, err := runBangumiCollectionSync(context.Background(), opts, bangumiSyncTriggerAPI, service.AuditContext{Username: "admin"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Seen)
+	assert.Equal(t, []string{"葬送的芙莉莲"}, report.Proposed)
+
+	w = serveAs(r, cookie, http.MethodGet, "/api/v1/subscriptions/bangumi-sync?status=proposed", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var status struct {
+		Data BangumiSyncStatus `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.True(t, status.Data.Enabled)
+	assert.Equal(t, []string{"wish", "watching"}, status.Data.Types)
+	require.NotNil(t, status.Data.LastRun)
+	require.NotNil(t, status.Data.NextRunAt)
+	require.Len(t, status.Data.Records, 1)
+	proposal := status.Data.Records[0]
+	assert.Equal(t, "370", proposal.SubgroupID)
+
+	w = serveAs(r, cookie, http.MethodPost, fmt.Sprintf("/api/v1/subscriptions/bangumi-sync/%d/accept", proposal.ID), "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "bangumi-sync:葬送的芙莉莲", <-checked)
+	var sub model.Subscription
+	require.NoError(t, db.DB.Preload("Metadata").First(&sub).Error)
+	assert.Equal(t, "https://mikanani.me/RSS/Bangumi?bangumiId=3141&subgroupid=370", sub.RSSUrl)
+	require.NotNil(t, sub.Metadata)
+	assert.Equal(t, 400602, sub.Metadata.BangumiID)
+
+	w = serveAs(r, cookie, http.MethodPost, fmt.Sprintf("/api/v1/subscriptions/bangumi-sync/%d/accept", proposal.ID), "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = serveAs(r, cookie, http.MethodGet, "/api/v1/subscriptions/bangumi-sync?status=unresolved", "")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	require.Len(t, status.Data.Records, 1)
+	w = serveAs(r, cookie, http.MethodPost, fmt.Sprintf("/api/v1/subscriptions/bangumi-sync/%d/dismiss", status.Data.Records[0].ID), "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Deleting the accepted subscription must not bring it back.
+	require.NoError(t, store.NewSubscriptionStore(db.DB).DeleteCascade(sub.ID))
+	report, err = runBangumiCollectionSync(context.Background(), opts, bangumiSyncTriggerAPI, service.AuditContext{Username: "admin"})
+	require.NoError(t, err)
+	assert.Empty(t, report.Proposed)
+	assert.Equal(t, 1, report.Counts[service.BangumiSyncStatusDeleted])
+	assert.Equal(t, 1, report.Counts[service.BangumiSyncStatusDismissed])
+
+	entries, err := service.ListAuditLogs(store.AuditLogQuery{Action: service.AuditActionBangumiSyncRun, Limit: 5})
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestBangumiCollectionSyncSkipsSubscriptionsDeletedBeforeTheFirstSync(t *testing.T) {
+	resetAuthFixtures(t)
+	clearSubscriptionTransferTables(t)
+	stubSubscriptionImportSideEffects(t)
+	syncKeys := []string{model.ConfigKeyBangumiAccessToken, model.ConfigKeyBangumiSyncEnabled, model.ConfigKeyBangumiSyncMode, model.ConfigKeyBangumiSyncSubgroups}
+	reset := func() {
+		clearSubscriptionTransferTables(t)
+		require.NoError(t, db.DB.Exec("DELETE FROM bangumi_collection_syncs").Error)
+		_ = db.DB.Where("key IN ?", syncKeys).Delete(&model.GlobalConfig{}).Error
+		bangumiSyncMu.Lock()
+		bangumiSyncLast, bangumiSyncLoaded = nil, false
+		bangumiSyncMu.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+
+	previousClients := newBangumiSyncClients
+	newBangumiSyncClients = func() (service.BangumiCollectionReader, service.MikanSubjectResolver) {
+		return stubBangumiCollections{
+			service.BangumiCollectionWatching: {
+				{SubjectID: 400602, Subject: bangumi.Subject{ID: 400602, NameCN: "葬送的芙莉莲"}},
+				{SubjectID: 425998, Subject: bangumi.Subject{ID: 425998, NameCN: "药屋少女的呢喃"}},
+			},
+		}, stubBangumiSyncMikan{
+			"400602": {{MikanID: "3141", Title: "葬送的芙莉莲"}},
+			"425998": {{MikanID: "3310", Title: "药屋少女的呢喃"}},
+		}
+	}
+	t.Cleanup(func() { newBangumiSyncClients = previousClients })
+
+	r := setupRouter()
+	cookie, _ := loginCookie(t, r, "admin")
+
+	// One subscription knows its Bangumi subject, the other only its Mikan ID.
+	metadata := model.AnimeMetadata{Title: "葬送的芙莉莲", BangumiID: 400602}
+	require.NoError(t, db.DB.Create(&metadata).Error)
+	frieren := model.Subscription{Title: "葬送的芙莉莲", RSSUrl: "https://mikanani.me/RSS/Bangumi?bangumiId=3141", MetadataID: &metadata.ID}
+	diaries := model.Subscription{Title: "药屋少女的呢喃", MikanID: "3310", RSSUrl: "https://mikanani.me/RSS/Bangumi?bangumiId=3310"}
+	require.NoError(t, db.DB.Create(&frieren).Error)
+	require.NoError(t, db.DB.Create(&diaries).Error)
+	w := serveAs(r, cookie, http.MethodDelete, fmt.Sprintf("/api/v1/subscriptions/%d", frieren.ID), "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = serveAs(r, cookie, http.MethodDelete, fmt.Sprintf("/api/subscriptions/%d", diaries.ID), "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	require.NoError(t, store.NewConfigStore(db.DB).SetMany(map[string]string{
+		model.ConfigKeyBangumiAccessToken:   "token",
+		model.ConfigKeyBangumiSyncEnabled:   model.ConfigValueTrue,
+		model.ConfigKeyBangumiSyncMode:      service.BangumiSyncModeSubscribe,
+		model.ConfigKeyBangumiSyncSubgroups: "LoliHouse",
+	}))
+	_, opts, err := loadBangumiSyncOptions()
+	require.NoError(t, err)
+	report, err := runBangumiCollectionSync(context.Background(), opts, bangumiSyncTriggerAPI, service.AuditContext{Username: "admin"})
+	require.NoError(t, err)
+	assert.Empty(t, report.Subscribed)
+	assert.Equal(t, 2, report.Counts[service.BangumiSyncStatusDeleted])
+
+	var count int64
+	require.NoError(t, db.DB.Model(&model.Subscription{}).Count(&count).Error)
+	assert.Zero(t, count, "the sync must not re-create deleted subscriptions")
+	var records []model.BangumiCollectionSync
+	require.NoError(t, db.DB.Order("subject_id").Find(&records).Error)
+	require.Len(t, records, 2, "the Mikan-only tombstone should be taken over by its subject")
+	assert.Equal(t, 400602, records[0].SubjectID)
+	assert.Equal(t, 425998, records[1].SubjectID)
+	assert.Equal(t, "3310", records[1].MikanID)
+	assert.Equal(t, diaries.ID, records[1].SubscriptionID)
+}
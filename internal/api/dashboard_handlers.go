@@ -11,7 +11,6 @@ import (
 	"github.com/pokerjest/animateAutoTool/internal/bangumi"
 	"github.com/pokerjest/animateAutoTool/internal/config"
 	"github.com/pokerjest/animateAutoTool/internal/db"
-	"github.com/pokerjest/animateAutoTool/internal/downloader"
 	"github.com/pokerjest/animateAutoTool/internal/model"
 	"github.com/pokerjest/animateAutoTool/internal/qbutil"
 	"github.com/pokerjest/animateAutoTool/internal/scheduler"
@@ -314,7 +313,7 @@ func RepairDownloadLogsHandler(c *gin.Context) {
 
 	qbCfg := qbutil.LoadConfig()
 	if !qbutil.ManagedBinaryMissing(qbCfg, config.BinDir()) && !qbutil.MissingExternalURL(qbCfg) && strings.TrimSpace(qbCfg.URL) != "" {
-		client := downloader.NewQBittorrentClient(qbCfg.URL)
+		client := qbutil.NewClient(qbCfg)
 		if err := client.Login(qbCfg.Username, qbCfg.Password); err != nil {
 			lastErr = err
 		} else if _, err := service.SyncDownloadLogStatusesWithQBClient(client); err != nil {
@@ -344,7 +343,7 @@ func clientOrNil(cfg qbutil.Config, ready bool) service.TorrentStatusSource {
 	if !ready || qbutil.ManagedBinaryMissing(cfg, config.BinDir()) || qbutil.MissingExternalURL(cfg) || strings.TrimSpace(cfg.URL) == "" {
 		return nil
 	}
-	client := downloader.NewQBittorrentClient(cfg.URL)
+	client := qbutil.NewClient(cfg)
 	if err := client.Login(cfg.Username, cfg.Password); err != nil {
 		return nil
 	}
@@ -343,6 +343,7 @@ func healthSubscriptionFailures() ([]healthSubscriptionFailure, error) {
 		Where(`NOT EXISTS (
 			SELECT 1 FROM subscription_run_logs AS newer
 			WHERE newer.subscription_id = subscription_run_logs.subscription_id
+			  AND newer.status <> 'skipped'
 			  AND (newer.checked_at > subscription_run_logs.checked_at
 			       OR (newer.checked_at = subscription_run_logs.checked_at AND newer.id > subscription_run_logs.id))
 		)`).
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pokerjest/animateAutoTool/internal/downloader"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/pathutil"
+	"github.com/pokerjest/animateAutoTool/internal/qbutil"
+)
+
+const pathMappingTestTimeout = 15 * time.Second
+
+var errDownloaderNotConfigured = errors.New("downloader not configured")
+
+// listDownloaderTorrents is swapped in tests so the mapping check can run
+// without a real downloader.
+var listDownloaderTorrents = func(ctx context.Context) ([]downloader.TorrentInfo, error) {
+	qbCfg := qbutil.LoadConfig()
+	if strings.TrimSpace(qbCfg.URL) == "" {
+		return nil, errDownloaderNotConfigured
+	}
+	client := qbutil.NewClient(qbCfg)
+	if err := client.LoginContext(ctx, qbCfg.Username, qbCfg.Password); err != nil {
+		return nil, err
+	}
+	return client.ListTorrentsContext(ctx)
+}
+
+type pathMappingTestPath struct {
+	Remote string            `json:"remote"`
+	Local  string            `json:"local"`
+	Rule   *pathutil.Mapping `json:"rule"`
+	Exists bool              `json:"exists"`
+}
+
+// V1DownloaderPathMappingTestHandler maps one torrent reported by the
+// downloader through the given (or saved) mappings and checks that the result
+// exists on this host, so a wrong prefix shows up before files are moved.
+func V1DownloaderPathMappingTestHandler(c *gin.Context) {
+	var input struct {
+		Mappings *string `json:"mappings"`
+		Hash     string  `json:"hash"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		v1Error(c, http.StatusBadRequest, "invalid_path_mapping_test", "路径映射测试请求格式不正确")
+		return
+	}
+	raw := configValue(model.ConfigKeyDownloaderPathMappings)
+	if input.Mappings != nil {
+		raw = *input.Mappings
+	}
+	mappings, err := pathutil.ParseMappings(raw)
+	if err != nil {
+		v1Error(c, http.StatusBadRequest, "invalid_downloader_path_mappings", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), pathMappingTestTimeout)
+	defer cancel()
+	torrents, err := listDownloaderTorrents(ctx)
+	if errors.Is(err, errDownloaderNotConfigured) {
+		v1Error(c, http.StatusBadRequest, "downloader_not_configured", "请先配置下载器地址")
+		return
+	}
+	if err != nil {
+		v1Error(c, http.StatusBadGateway, "downloader_unreachable", humanizeOperationError(err.Error()))
+		return
+	}
+	torrent, ok := pickPathMappingSample(torrents, input.Hash)
+	if !ok {
+		if strings.TrimSpace(input.Hash) != "" {
+			v1Error(c, http.StatusNotFound, "torrent_not_found", "下载器中没有找到该任务")
+			return
+		}
+		v1Error(c, http.StatusNotFound, "no_torrents", "下载器中还没有任务，无法测试路径映射")
+		return
+	}
+
+	mapper := pathutil.NewMapper(mappings)
+	savePath := mapPathForTest(mapper, torrent.SavePath)
+	contentPath := mapPathForTest(mapper, torrent.ContentPath)
+	v1Data(c, http.StatusOK, gin.H{
+		"hash":         torrent.Hash,
+		"name":         torrent.Name,
+		"save_path":    savePath,
+		"content_path": contentPath,
+		"mappings":     mappings,
+		"ok":           savePath.Exists && (torrent.ContentPath == "" || contentPath.Exists),
+	})
+}
+
+func pickPathMappingSample(torrents []downloader.TorrentInfo, hash string) (downloader.TorrentInfo, bool) {
+	hash = strings.TrimSpace(hash)
+	for _, torrent := range torrents {
+		if hash == "" || strings.EqualFold(torrent.Hash, hash) {
+			return torrent, true
+		}
+	}
+	return downloader.TorrentInfo{}, false
+}
+
+func mapPathForTest(mapper *pathutil.Mapper, remote string) pathMappingTestPath {
+	result := pathMappingTestPath{Remote: remote, Local: mapper.ToLocal(remote)}
+	if rule, err := mapper.Match(remote); err == nil {
+		result.Rule = &rule
+	}
+	if strings.TrimSpace(result.Local) != "" {
+		_, err := os.Stat(result.Local)
+		result.Exists = err == nil
+	}
+	return result
+}
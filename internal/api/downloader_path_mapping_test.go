@@ -0,0 +1,99 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/downloader"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestV1DownloaderPathMappingTestMapsSampleTorrent(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("local mapping targets use POSIX paths")
+	}
+	resetAuthFixtures(t)
+	local := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(local, "Show", "Season 1"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(local, "Show", "Season 1", "ep01.mkv"), []byte("video"), 0o600))
+
+	original := listDownloaderTorrents
+	listDownloaderTorrents = func(context.Context) ([]downloader.TorrentInfo, error) {
+		return []downloader.TorrentInfo{
+			{Hash: "aaa", Name: "other", SavePath: `/elsewhere`},
+			{Hash: "BBB", Name: "ep01", SavePath: `D:\Downloads\Show\Season 1`, ContentPath: `D:\Downloads\Show\Season 1\ep01.mkv`},
+		}, nil
+	}
+	t.Cleanup(func() { listDownloaderTorrents = original })
+	r := setupRouter()
+	cookie, _ := loginCookie(t, r, "admin")
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/settings/downloader/path-mappings/test", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Cookie", cookie)
+		markLocalRequest(req)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(`{"hash":"bbb","mappings":"d:/downloads => ` + local + `"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data struct {
+			Hash        string              `json:"hash"`
+			OK          bool                `json:"ok"`
+			SavePath    pathMappingTestPath `json:"save_path"`
+			ContentPath pathMappingTestPath `json:"content_path"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "BBB", resp.Data.Hash)
+	assert.True(t, resp.Data.OK)
+	assert.Equal(t, filepath.Join(local, "Show", "Season 1", "ep01.mkv"), resp.Data.ContentPath.Local)
+	require.NotNil(t, resp.Data.ContentPath.Rule)
+	assert.Equal(t, `d:\downloads`, resp.Data.ContentPath.Rule.Remote)
+
+	// 未传 mappings 时使用已保存的配置；此处没有配置，路径保持原样
+	w = post(`{"hash":"aaa"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"local":"/elsewhere"`)
+	assert.Contains(t, w.Body.String(), `"ok":false`)
+
+	w = post(`{"mappings":"/downloads -> /mnt"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"code":"invalid_downloader_path_mappings"`)
+
+	w = post(`{"hash":"missing"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+}
+
+func TestV1SettingsNormalizesDownloaderPathMappings(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("local mapping targets use POSIX paths")
+	}
+	resetAuthFixtures(t)
+	r := setupRouter()
+	cookie, _ := loginCookie(t, r, "admin")
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/settings", bytes.NewBufferString(`{"values":{"downloader_path_mappings":"/downloads/ => /mnt/nas/downloads\nC:/Torrents => /mnt/c"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Cookie", cookie)
+	markLocalRequest(req)
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `/downloads => /mnt/nas/downloads; C:\Torrents => /mnt/c`,
+		store.NewConfigStore(db.DB).GetDefault(model.ConfigKeyDownloaderPathMappings, ""))
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pokerjest/animateAutoTool/internal/bootstrap"
+	"github.com/pokerjest/animateAutoTool/internal/config"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/httpx"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/security"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+)
+
+const (
+	forwardAuthDefaultHeader  = "Remote-User"
+	forwardAuthJWTHeader      = "Cf-Access-Jwt-Assertion"
+	forwardAuthUserContextKey = "auth.forward_user"
+	forwardAuthJWKSTimeout    = 10 * time.Second
+
+	authModeForwardAuth = "forward_auth"
+	authModeIPAllowlist = "ip_allowlist"
+)
+
+var forwardAuthHeaderPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]{0,63}$`)
+
+var forwardAuthVerifierCache struct {
+	sync.Mutex
+	verifier *security.JWKSVerifier
+}
+
+func normalizeForwardAuthHeader(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if !forwardAuthHeaderPattern.MatchString(value) {
+		return "", errors.New("身份请求头只能包含字母、数字和连字符，例如 Remote-User")
+	}
+	return value, nil
+}
+
+func normalizeForwardAuthUserMap(value string) (string, error) {
+	entries, err := service.ParseForwardAuthUserMap(value)
+	if err != nil {
+		return "", err
+	}
+	return service.FormatForwardAuthUserMap(entries), nil
+}
+
+func normalizeForwardAuthProvisionRole(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	return service.NormalizeUserRole(value)
+}
+
+func normalizeForwardAuthJWKSURL(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return "", errors.New("JWKS 地址必须是 https 链接，例如 https://<团队名>.cloudflareaccess.com/cdn-cgi/access/certs")
+	}
+	return parsed.String(), nil
+}
+
+func forwardAuthEnabled() bool {
+	return strings.EqualFold(
+		strings.TrimSpace(config.SystemSetting(model.ConfigKeyAuthForwardEnabled)),
+		model.ConfigValueTrue,
+	)
+}
+
+func forwardAuthHeaderName() string {
+	if header := strings.TrimSpace(config.SystemSetting(model.ConfigKeyAuthForwardHeader)); header != "" {
+		return header
+	}
+	return forwardAuthDefaultHeader
+}
+
+func forwardAuthVerifier(jwksURL string) *security.JWKSVerifier {
+	forwardAuthVerifierCache.Lock()
+	defer forwardAuthVerifierCache.Unlock()
+	if forwardAuthVerifierCache.verifier == nil || forwardAuthVerifierCache.verifier.URL() != jwksURL {
+		forwardAuthVerifierCache.verifier = security.NewJWKSVerifier(jwksURL, httpx.NewHTTPClient(forwardAuthJWKSTimeout))
+	}
+	return forwardAuthVerifierCache.verifier
+}
+
+// forwardAuthUserForRequest maps the identity header set by a trusted reverse
+// proxy (Cloudflare Access, Authelia, oauth2-proxy, ...) to an account. Like
+// the IP allowlist it only applies after setup, and the header is ignored
+// unless the request came through server.trusted_proxies, so clients cannot
+// assert an identity themselves. With a JWKS URL configured the proxy's
+// signed assertion must also verify against the configured audience and name
+// the same identity, by email or, for assertions without one, by subject.
+func forwardAuthUserForRequest(c *gin.Context) (*model.User, bool) {
+	if value, exists := c.Get(forwardAuthUserContextKey); exists {
+		user, ok := value.(*model.User)
+		return user, ok && user != nil
+	}
+	user := resolveForwardAuthUser(c)
+	c.Set(forwardAuthUserContextKey, user)
+	if user == nil {
+		return nil, false
+	}
+	c.Set(passwordlessUserIDContextKey, user.ID)
+	return user, true
+}
+
+func resolveForwardAuthUser(c *gin.Context) *model.User {
+	if !forwardAuthEnabled() || bootstrap.BootstrapSetupPending() || db.DB == nil {
+		return nil
+	}
+	identity := strings.TrimSpace(c.GetHeader(forwardAuthHeaderName()))
+	if identity == "" || !requestFromTrustedProxy(c) {
+		return nil
+	}
+
+	if jwksURL := strings.TrimSpace(config.SystemSetting(model.ConfigKeyAuthForwardJWKSURL)); jwksURL != "" {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), forwardAuthJWKSTimeout)
+		defer cancel()
+		audience := strings.TrimSpace(config.SystemSetting(model.ConfigKeyAuthForwardAudience))
+		claims, err := forwardAuthVerifier(jwksURL).Verify(ctx, c.GetHeader(forwardAuthJWTHeader), audience)
+		if err != nil {
+			log.Printf("forward auth: rejected assertion for %q: %v", identity, err)
+			return nil
+		}
+		asserted := claims.Email
+		if asserted == "" {
+			asserted = claims.Subject
+		}
+		if asserted == "" || !strings.EqualFold(asserted, identity) {
+			log.Printf("forward auth: assertion identity %q does not match header identity %q", asserted, identity)
+			return nil
+		}
+	}
+
+	userMap, err := service.ParseForwardAuthUserMap(config.SystemSetting(model.ConfigKeyAuthForwardUserMap))
+	if err != nil {
+		log.Printf("forward auth: ignoring invalid user map: %v", err)
+		userMap = nil
+	}
+	user, created, err := service.NewAuthService().ForwardAuthUser(identity, service.ForwardAuthOptions{
+		UserMap:       userMap,
+		ProvisionRole: strings.TrimSpace(config.SystemSetting(model.ConfigKeyAuthForwardProvisionRole)),
+	})
+	if err != nil {
+		if !errors.Is(err, service.ErrForwardAuthUnknownUser) {
+			log.Printf("forward auth: failed to resolve %q: %v", identity, err)
+		}
+		return nil
+	}
+	if created {
+		auditCtx := auditContextForLogin(c, user.Username)
+		auditCtx.UserID = user.ID
+		service.RecordAudit(auditCtx, service.AuditEntry{
+			Action:     service.AuditActionUserCreate,
+			TargetType: "user",
+			TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+			Outcome:    service.AuditOutcomeSuccess,
+			Details:    map[string]string{"username": user.Username, "role": user.Role, "method": authModeForwardAuth},
+		})
+	}
+	return user
+}
+
+// passwordlessUserForRequest returns the account for requests authenticated
+// outside the session cookie and the mode that admitted them.
+func passwordlessUserForRequest(c *gin.Context) (*model.User, string, bool) {
+	if user, ok := forwardAuthUserForRequest(c); ok {
+		return user, authModeForwardAuth, true
+	}
+	if user, ok := passwordlessAdminForRequest(c); ok {
+		return user, authModeIPAllowlist, true
+	}
+	return nil, "", false
+}
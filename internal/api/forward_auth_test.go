@@ -0,0 +1,204 @@
+package api
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pokerjest/animateAutoTool/internal/config"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var forwardAuthSettingKeys = []string{
+	model.ConfigKeyAuthForwardEnabled,
+	model.ConfigKeyAuthForwardHeader,
+	model.ConfigKeyAuthForwardUserMap,
+	model.ConfigKeyAuthForwardProvisionRole,
+	model.ConfigKeyAuthForwardJWKSURL,
+	model.ConfigKeyAuthForwardAudience,
+}
+
+func withForwardAuthSettings(t *testing.T, values map[string]string) {
+	t.Helper()
+
+	previousSettings := map[string]string{}
+	if config.AppConfig != nil {
+		for key, value := range config.AppConfig.SystemSettings {
+			previousSettings[key] = value
+		}
+	}
+	previousTrustedProxies := append([]string(nil), config.AppConfig.Server.TrustedProxies...)
+	config.AppConfig.Server.TrustedProxies = []string{"127.0.0.1"}
+	values[model.ConfigKeyAuthForwardEnabled] = model.ConfigValueTrue
+	require.NoError(t, store.NewConfigStore(db.DB).SetMany(values))
+	t.Cleanup(func() {
+		_ = db.DB.Where("key IN ?", forwardAuthSettingKeys).Delete(&model.GlobalConfig{}).Error
+		_ = config.ReplaceSystemSettings(previousSettings)
+		config.AppConfig.Server.TrustedProxies = previousTrustedProxies
+	})
+}
+
+func serveForwardAuth(r *gin.Engine, remoteAddr, path string, headers map[string]string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = remoteAddr
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func forwardAuthSession(t *testing.T, w *httptest.ResponseRecorder) (bool, string, string, string) {
+	t.Helper()
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var payload struct {
+		Data struct {
+			Authenticated bool   `json:"authenticated"`
+			Username      string `json:"username"`
+			Role          string `json:"role"`
+			AuthMode      string `json:"auth_mode"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &payload))
+	return payload.Data.Authenticated, payload.Data.Username, payload.Data.Role, payload.Data.AuthMode
+}
+
+func TestForwardAuthTrustsHeaderOnlyFromTrustedProxies(t *testing.T) {
+	resetAuthFixtures(t)
+	withForwardAuthSettings(t, map[string]string{
+		model.ConfigKeyAuthForwardHeader:  "X-Forwarded-User",
+		model.ConfigKeyAuthForwardUserMap: "Owner@Example.com => admin",
+	})
+	r := setupRouter()
+	header := map[string]string{"X-Forwarded-User": "owner@example.com"}
+
+	authenticated, username, role, mode := forwardAuthSession(t, serveForwardAuth(r, testLocalRemoteAddr, "/api/v1/session", header))
+	assert.True(t, authenticated)
+	assert.Equal(t, "admin", username)
+	assert.Equal(t, model.UserRoleAdmin, role)
+	assert.Equal(t, authModeForwardAuth, mode)
+	assert.Equal(t, http.StatusOK, serveForwardAuth(r, testLocalRemoteAddr, "/api/v1/settings", header).Code)
+
+	// A client that is not a trusted proxy cannot assert an identity.
+	assert.Equal(t, http.StatusUnauthorized, serveForwardAuth(r, testRemoteAddr, "/api/v1/settings", header).Code)
+	// Unknown identities are not provisioned unless a role is configured.
+	unknown := map[string]string{"X-Forwarded-User": "stranger@example.com"}
+	assert.Equal(t, http.StatusUnauthorized, serveForwardAuth(r, testLocalRemoteAddr, "/api/v1/library", unknown).Code)
+}
+
+func TestForwardAuthProvisionsUnknownIdentities(t *testing.T) {
+	resetAuthFixtures(t)
+	withForwardAuthSettings(t, map[string]string{model.ConfigKeyAuthForwardProvisionRole: model.UserRoleViewer})
+	r := setupRouter()
+	header := map[string]string{forwardAuthDefaultHeader: "kid@example.com"}
+
+	_, username, role, _ := forwardAuthSession(t, serveForwardAuth(r, testLocalRemoteAddr, "/api/v1/session", header))
+	assert.Equal(t, "kid@example.com", username)
+	assert.Equal(t, model.UserRoleViewer, role)
+	assert.Equal(t, http.StatusOK, serveForwardAuth(r, testLocalRemoteAddr, "/api/v1/library", header).Code)
+	assert.Equal(t, http.StatusForbidden, serveForwardAuth(r, testLocalRemoteAddr, "/api/v1/settings", header).Code)
+
+	var users int64
+	require.NoError(t, db.DB.Model(&model.User{}).Where("username = ?", "kid@example.com").Count(&users).Error)
+	assert.EqualValues(t, 1, users)
+	var audits int64
+	require.NoError(t, db.DB.Model(&model.AuditLog{}).Where("action = ? AND username = ?", service.AuditActionUserCreate, "kid@example.com").Count(&audits).Error)
+	assert.EqualValues(t, 1, audits)
+}
+
+func TestForwardAuthVerifiesCloudflareAccessAssertion(t *testing.T) {
+	resetAuthFixtures(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kid": "cf", "kty": "RSA",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	t.Cleanup(jwks.Close)
+	// Stored directly: the settings API only accepts https JWKS URLs.
+	withForwardAuthSettings(t, map[string]string{
+		model.ConfigKeyAuthForwardHeader:   "Cf-Access-Authenticated-User-Email",
+		model.ConfigKeyAuthForwardUserMap:  "owner@example.com => admin",
+		model.ConfigKeyAuthForwardJWKSURL:  jwks.URL,
+		model.ConfigKeyAuthForwardAudience: "aud-tag",
+	})
+	r := setupRouter()
+
+	signed := func(claims map[string]any) string {
+		header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "cf"})
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+		payload, _ := json.Marshal(claims)
+		input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+		digest := sha256.Sum256([]byte(input))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+		return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+	}
+	assertion := func(email, audience string) string {
+		return signed(map[string]any{"email": email, "iss": jwks.URL, "aud": []string{audience}})
+	}
+	request := func(jwt string) int {
+		headers := map[string]string{"Cf-Access-Authenticated-User-Email": "owner@example.com"}
+		if jwt != "" {
+			headers[forwardAuthJWTHeader] = jwt
+		}
+		return serveForwardAuth(r, testLocalRemoteAddr, "/api/v1/settings", headers).Code
+	}
+
+	assert.Equal(t, http.StatusOK, request(assertion("owner@example.com", "aud-tag")))
+	assert.Equal(t, http.StatusUnauthorized, request(""))
+	assert.Equal(t, http.StatusUnauthorized, request(assertion("intruder@example.com", "aud-tag")))
+	assert.Equal(t, http.StatusUnauthorized, request(assertion("owner@example.com", "other-app")))
+	assert.Equal(t, http.StatusUnauthorized, request(signed(map[string]any{"email": "owner@example.com", "iss": "https://other-team.cloudflareaccess.com", "aud": "aud-tag"})))
+	// Assertions without an email must still name the header identity.
+	assert.Equal(t, http.StatusUnauthorized, request(signed(map[string]any{"sub": "service-token", "iss": jwks.URL, "aud": "aud-tag"})))
+	assert.Equal(t, http.StatusOK, request(signed(map[string]any{"sub": "owner@example.com", "iss": jwks.URL, "aud": "aud-tag"})))
+}
+
+func TestForwardAuthSettingsAreValidated(t *testing.T) {
+	resetAuthFixtures(t)
+	r := setupRouter()
+	cookie, _ := loginCookie(t, r, "admin")
+	for _, values := range []string{
+		`{"values":{"auth_forward_header":"Remote User"}}`,
+		`{"values":{"auth_forward_user_map":"missing-arrow"}}`,
+		`{"values":{"auth_forward_provision_role":"owner"}}`,
+		`{"values":{"auth_forward_jwks_url":"http://team.cloudflareaccess.com/cdn-cgi/access/certs"}}`,
+		`{"values":{"auth_forward_jwks_url":"https://team.cloudflareaccess.com/cdn-cgi/access/certs"}}`,
+		`{"values":{"auth_forward_jwks_url":"https://team.cloudflareaccess.com/cdn-cgi/access/certs","auth_forward_audience":" "}}`,
+	} {
+		w := serveAs(r, cookie, http.MethodPut, "/api/v1/settings", values)
+		assert.Equal(t, http.StatusBadRequest, w.Code, values)
+		assert.Contains(t, w.Body.String(), `"code":"invalid_forward_auth"`, values)
+	}
+	previousSettings := map[string]string{}
+	for key, value := range config.AppConfig.SystemSettings {
+		previousSettings[key] = value
+	}
+	t.Cleanup(func() {
+		_ = db.DB.Where("key IN ?", forwardAuthSettingKeys).Delete(&model.GlobalConfig{}).Error
+		_ = config.ReplaceSystemSettings(previousSettings)
+	})
+	w := serveAs(r, cookie, http.MethodPut, "/api/v1/settings", `{"values":{"auth_forward_jwks_url":"https://team.cloudflareaccess.com/cdn-cgi/access/certs","auth_forward_audience":"aud-tag"}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = serveAs(r, cookie, http.MethodPut, "/api/v1/settings", `{"values":{"auth_forward_audience":""}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "clearing the audience must not leave the JWKS check without one")
+}
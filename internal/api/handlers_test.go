@@ -133,6 +133,12 @@ func resetAuthFixtures(t *testing.T) {
 	if err := db.DB.Exec("DELETE FROM users").Error; err != nil {
 		t.Fatalf("failed to clear users: %v", err)
 	}
+	if err := db.DB.Exec("DELETE FROM api_tokens").Error; err != nil {
+		t.Fatalf("failed to clear api tokens: %v", err)
+	}
+	if err := db.DB.Exec("DELETE FROM user_recovery_codes").Error; err != nil {
+		t.Fatalf("failed to clear recovery codes: %v", err)
+	}
 	if err := bootstrap.ClearAdminBootstrapInfo(); err != nil && !os.IsNotExist(err) {
 		t.Fatalf("failed to clear bootstrap admin info: %v", err)
 	}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/pokerjest/animateAutoTool/internal/db"
 	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/scheduler"
 	"github.com/pokerjest/animateAutoTool/internal/service"
 )
 
@@ -28,6 +29,10 @@ type HealthReport struct {
 	SubscriptionsPlayable    int64           `json:"subscriptions_playable"`
 	SubscriptionsPendingSync int64           `json:"subscriptions_pending_sync"`
 	StaleSubscriptions72H    int64           `json:"stale_subscriptions_72h"`
+	BackupScheduleEnabled    bool            `json:"backup_schedule_enabled"`
+	BackupScheduleLastStatus string          `json:"backup_schedule_last_status,omitempty"`
+	BackupScheduleLastRunAt  *time.Time      `json:"backup_schedule_last_run_at,omitempty"`
+	BackupScheduleProblem    string          `json:"backup_schedule_problem,omitempty"`
 	HealthTone               string          `json:"health_tone"`
 	Summary                  string          `json:"summary"`
 	Recommendations          []string        `json:"recommendations"`
@@ -74,6 +79,7 @@ func buildHealthReport() HealthReport {
 	report.JellyfinEpisodeCount, _ = laStore.CountEpisodesWithJellyfin()
 	report.StaleSubscriptions72H, _ = subStore.CountStaleSince(time.Now().Add(-72 * time.Hour))
 	populateSubscriptionMediaHealth(&report)
+	populateBackupScheduleHealth(&report)
 
 	report.Recommendations = buildRecommendations(report)
 	report.Summary = buildHealthSummary(report)
@@ -118,6 +124,26 @@ func populateSubscriptionMediaHealth(report *HealthReport) {
 	}
 }
 
+// populateBackupScheduleHealth surfaces the last scheduled backup. A failed
+// run is reported even when the schedule has since been disabled; settings
+// problems only matter while the schedule is enabled.
+func populateBackupScheduleHealth(report *HealthReport) {
+	status := buildBackupScheduleStatus()
+	report.BackupScheduleEnabled = status.Enabled
+	if status.Enabled && status.ConfigError != "" {
+		report.BackupScheduleProblem = status.ConfigError
+	}
+	if status.LastRun == nil {
+		return
+	}
+	finishedAt := status.LastRun.FinishedAt
+	report.BackupScheduleLastStatus = status.LastRun.Status
+	report.BackupScheduleLastRunAt = &finishedAt
+	if status.LastRun.Status != scheduler.BackupRunSuccess && report.BackupScheduleProblem == "" {
+		report.BackupScheduleProblem = scheduler.BackupRunProblems(*status.LastRun)
+	}
+}
+
 func buildRecommendations(report HealthReport) []string {
 	recommendations := make([]string, 0, 5)
 	if report.DownloadFailed > 0 || report.DownloadStale > 0 {
@@ -132,6 +158,9 @@ func buildRecommendations(report HealthReport) []string {
 	if report.OpenLibraryIssues > 0 {
 		recommendations = append(recommendations, "本地媒体库仍有打开的诊断问题，建议进入本地番剧页查看修复建议。")
 	}
+	if report.BackupScheduleProblem != "" {
+		recommendations = append(recommendations, "定时备份未能完成："+report.BackupScheduleProblem+"。请检查备份设置和存储位置，然后在备份页手动运行一次。")
+	}
 	if !report.Configs["Jellyfin URL"] || !report.Configs["Jellyfin API Key"] {
 		recommendations = append(recommendations, "如果需要即下即看，请补全 Jellyfin URL 和 API Key。")
 	}
@@ -148,6 +177,8 @@ func buildHealthSummary(report HealthReport) string {
 		return "存在长时间无进展的订阅，系统需要补一次检查。"
 	case report.SubscriptionsPendingSync > 0:
 		return "订阅主链已经入库，但还有部分番剧没进入可播放状态。"
+	case report.BackupScheduleProblem != "":
+		return "主链运行正常，但定时备份最近没有成功。"
 	default:
 		return "主链整体健康：下载、扫描和媒体库闭环都比较稳定。"
 	}
@@ -157,7 +188,7 @@ func determineHealthTone(report HealthReport) string {
 	switch {
 	case report.DownloadFailed > 0 || report.DownloadStale > 0:
 		return "rose"
-	case report.StaleSubscriptions72H > 0 || report.SubscriptionsPendingSync > 0 || report.OpenLibraryIssues > 0:
+	case report.StaleSubscriptions72H > 0 || report.SubscriptionsPendingSync > 0 || report.OpenLibraryIssues > 0 || report.BackupScheduleProblem != "":
 		return "amber"
 	default:
 		return "emerald"
@@ -34,6 +34,7 @@ type PlayInfoResponse struct {
 	PosterURL        string              `json:"poster_url"`
 	Title            string              `json:"title"`
 	EpisodeTitle     string              `json:"episode_title"`
+	Subtitles        []PlaybackSubtitle  `json:"subtitles,omitempty"`
 	Diagnostic       *PlaybackDiagnostic `json:"diagnostic,omitempty"`
 }
 
@@ -14,6 +14,7 @@ import (
 	"github.com/pokerjest/animateAutoTool/internal/db"
 	"github.com/pokerjest/animateAutoTool/internal/model"
 	"github.com/pokerjest/animateAutoTool/internal/service"
+	"github.com/pokerjest/animateAutoTool/internal/store"
 	"github.com/pokerjest/animateAutoTool/internal/tmdb"
 )
 
@@ -500,11 +501,8 @@ func ProxyTMDBImageHandler(c *gin.Context) {
 		return
 	}
 
-	// Fetch token from DB
-	var token model.GlobalConfig
-	db.DB.Where("key = ?", model.ConfigKeyTMDBToken).First(&token)
-
-	tmdbClient := tmdb.NewClient(token.Value, configuredProxyURL(model.ConfigKeyProxyTMDB))
+	token := store.NewConfigStore(db.DB).GetDefault(model.ConfigKeyTMDBToken, "")
+	tmdbClient := tmdb.NewClient(token, configuredProxyURL(model.ConfigKeyProxyTMDB))
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
 	defer cancel()
 	resp, err := tmdbClient.ProxyImageContext(ctx, path)
@@ -18,6 +18,7 @@ import (
 	"github.com/pokerjest/animateAutoTool/internal/model"
 	"github.com/pokerjest/animateAutoTool/internal/parser"
 	"github.com/pokerjest/animateAutoTool/internal/pathutil"
+	"github.com/pokerjest/animateAutoTool/internal/qbutil"
 
 	"github.com/pokerjest/animateAutoTool/internal/event"
 	"github.com/pokerjest/animateAutoTool/internal/service"
@@ -521,13 +522,13 @@ func backfillRenamedDownloadLog(anime model.LocalAnime, episode model.LocalEpiso
 }
 
 func syncDownloadLogsFromQB() {
-	qbURL, qbUsername, qbPassword := FetchQBConfig()
-	if strings.TrimSpace(qbURL) == "" {
+	qbCfg := qbutil.LoadConfig()
+	if strings.TrimSpace(qbCfg.URL) == "" {
 		return
 	}
 
-	client := downloader.NewQBittorrentClient(qbURL)
-	if err := client.Login(qbUsername, qbPassword); err != nil {
+	client := qbutil.NewClient(qbCfg)
+	if err := client.Login(qbCfg.Username, qbCfg.Password); err != nil {
 		log.Printf("Download log sync skipped: qB login failed: %v", err)
 		return
 	}
@@ -538,13 +539,13 @@ func syncDownloadLogsFromQB() {
 }
 
 func renameManagedQBFile(oldPath, newPath string) (bool, error) {
-	qbURL, qbUsername, qbPassword := FetchQBConfig()
-	if strings.TrimSpace(qbURL) == "" {
+	qbCfg := qbutil.LoadConfig()
+	if strings.TrimSpace(qbCfg.URL) == "" {
 		return false, nil
 	}
 
-	client := downloader.NewQBittorrentClient(qbURL)
-	if err := client.Login(qbUsername, qbPassword); err != nil {
+	client := qbutil.NewClient(qbCfg)
+	if err := client.Login(qbCfg.Username, qbCfg.Password); err != nil {
 		return false, err
 	}
 
@@ -553,7 +554,9 @@ func renameManagedQBFile(oldPath, newPath string) (bool, error) {
 		return false, err
 	}
 
+	mapper := service.DownloaderPathMapper()
 	for _, torrent := range torrents {
+		torrent = service.LocalTorrentPaths(mapper, torrent)
 		if !sameFilesystemPath(torrent.ContentPath, oldPath) {
 			continue
 		}
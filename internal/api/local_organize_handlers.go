@@ -52,7 +52,7 @@ func V1ApplyLocalOrganizeHandler(c *gin.Context) {
 		return
 	}
 
-	taskID, err := startLocalOrganizePlan(localOrganizeOwner(c), request.PlanID, request.IncludeAnimeIDs)
+	taskID, err := startLocalOrganizePlan(taskInitiator(c), localOrganizeOwner(c), request.PlanID, request.IncludeAnimeIDs)
 	if errors.Is(err, errLocalOrganizeInProgress) {
 		v1Error(c, http.StatusConflict, "organize_in_progress", "已有整理任务正在运行，请等待完成后再试")
 		return
@@ -77,7 +77,7 @@ var (
 	errInvalidOrganizeSelection = errors.New("invalid local organize selection")
 )
 
-func startLocalOrganizePlan(owner, planID string, includedIDs []uint) (string, error) {
+func startLocalOrganizePlan(initiator taskstate.Initiator, owner, planID string, includedIDs []uint) (string, error) {
 	localOrganizeRunMu.Lock()
 	if localOrganizeRunning {
 		localOrganizeRunMu.Unlock()
@@ -101,8 +101,10 @@ func startLocalOrganizePlan(owner, planID string, includedIDs []uint) (string, e
 	localOrganizeRunMu.Unlock()
 
 	taskID := "local-organize-" + shortOrganizePlanID(plan.PlanID)
-	taskstate.Global.Start(taskID, "organize", "整理本地番剧", "正在复核文件并准备整理")
-	GoBackground(func(ctx context.Context) {
+	taskstate.Global.StartBy(initiator, taskID, "organize", "整理本地番剧", "正在复核文件并准备整理")
+	GoBackground(func(appCtx context.Context) {
+		ctx, cancel := taskstate.Global.WithCancel(appCtx, taskID)
+		defer cancel()
 		runLocalOrganizeTask(ctx, taskID, organizer, plan, includedIDs)
 	})
 	return taskID, nil
@@ -118,6 +120,9 @@ func runLocalOrganizeTask(ctx context.Context, taskID string, organizer *service
 		taskstate.Global.Progress(taskID, message, current, total)
 	})
 	if err != nil {
+		if errors.Is(err, context.Canceled) && result.Moved > 0 {
+			err = fmt.Errorf("已移动 %d 项后停止，其余文件保持原样，请重新扫描本地媒体", result.Moved)
+		}
 		taskstate.Global.Fail(taskID, err)
 		return
 	}
@@ -126,7 +131,10 @@ func runLocalOrganizeTask(ctx context.Context, taskID string, organizer *service
 	if result.Moved > 0 {
 		current, _ := taskstate.Global.Get(taskID)
 		taskstate.Global.Progress(taskID, "文件整理完成，正在重新扫描本地媒体", current.Total, current.Total)
-		if scanErr := service.NewScannerService().ScanAllWithProgressContext(ctx, nil); scanErr != nil {
+		if scanErr := service.NewScannerService().ScanAllWithProgressContext(ctx, nil); errors.Is(scanErr, context.Canceled) {
+			taskstate.Global.Fail(taskID, fmt.Errorf("%s；重新扫描已取消，请稍后手动扫描", summary))
+			return
+		} else if scanErr != nil {
 			taskstate.Global.Fail(taskID, fmt.Errorf("%s；重新扫描失败: %w", summary, scanErr))
 			return
 		}
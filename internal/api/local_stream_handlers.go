@@ -0,0 +1,235 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"gorm.io/gorm"
+)
+
+var errLocalPathOutsideRoots = errors.New("episode path is outside the registered local directories")
+
+var localVideoContentTypes = map[string]string{
+	".mp4":  "video/mp4",
+	".m4v":  "video/mp4",
+	".mkv":  "video/x-matroska",
+	".webm": "video/webm",
+	".mov":  "video/quicktime",
+	".avi":  "video/x-msvideo",
+	".flv":  "video/x-flv",
+	".wmv":  "video/x-ms-wmv",
+	".ts":   "video/mp2t",
+	".m2ts": "video/mp2t",
+	".rmvb": "application/vnd.rn-realmedia-vbr",
+}
+
+func localEpisodeStreamURL(episodeID uint) string {
+	return fmt.Sprintf("/api/v1/local-anime/episodes/%d/stream", episodeID)
+}
+
+func localVideoContentType(path string) string {
+	if contentType, ok := localVideoContentTypes[strings.ToLower(filepath.Ext(path))]; ok {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// jellyfinPlaybackConfigured reports whether playback should go through
+// Jellyfin. Without it the built-in direct-play stream is used instead.
+func jellyfinPlaybackConfigured() bool {
+	return configValue(model.ConfigKeyJellyfinUrl) != "" && configValue(model.ConfigKeyJellyfinApiKey) != ""
+}
+
+// pathWithinRoot reports whether target equals root or sits below it. Both
+// paths must already be cleaned and resolved.
+func pathWithinRoot(root, target string) bool {
+	rel, err := filepath.Rel(root, target)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// resolveLocalEpisodeFile resolves symlinks in the episode path and only
+// returns it when the real file lives under one of the registered local
+// directories, so a tampered database row or a symlink cannot expose
+// arbitrary files.
+func resolveLocalEpisodeFile(path string, dirs []model.LocalAnimeDirectory) (string, error) {
+	if strings.TrimSpace(path) == "" || !filepath.IsAbs(path) {
+		return "", errLocalPathOutsideRoots
+	}
+	cleaned := filepath.Clean(path)
+	lexicallyInside := false
+	for _, dir := range dirs {
+		if strings.TrimSpace(dir.Path) != "" && pathWithinRoot(filepath.Clean(dir.Path), cleaned) {
+			lexicallyInside = true
+			break
+		}
+	}
+	// Reject lexically escaping paths before touching the filesystem so the
+	// response does not reveal whether a file outside the roots exists.
+	if !lexicallyInside {
+		return "", errLocalPathOutsideRoots
+	}
+	resolved, err := filepath.EvalSymlinks(cleaned)
+	if err != nil {
+		return "", err
+	}
+	for _, dir := range dirs {
+		if strings.TrimSpace(dir.Path) == "" {
+			continue
+		}
+		root, rootErr := filepath.EvalSymlinks(filepath.Clean(dir.Path))
+		if rootErr != nil {
+			continue
+		}
+		if pathWithinRoot(root, resolved) {
+			return resolved, nil
+		}
+	}
+	return "", errLocalPathOutsideRoots
+}
+
+func loadStreamableLocalEpisode(c *gin.Context) (*model.LocalEpisode, string, bool) {
+	episodeID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		v1Error(c, http.StatusBadRequest, "invalid_episode_id", "剧集 ID 无效")
+		return nil, "", false
+	}
+	return loadStreamableLocalEpisodeByID(c, uint(episodeID))
+}
+
+func loadStreamableLocalEpisodeByID(c *gin.Context, episodeID uint) (*model.LocalEpisode, string, bool) {
+	laStore := localAnimeStore()
+	if laStore == nil {
+		v1Error(c, http.StatusServiceUnavailable, "database_unavailable", "数据库未初始化")
+		return nil, "", false
+	}
+	episode, err := laStore.GetEpisode(episodeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			v1Error(c, http.StatusNotFound, "episode_not_found", "未找到对应的剧集")
+			return nil, "", false
+		}
+		v1Error(c, http.StatusInternalServerError, "episode_lookup_failed", "读取剧集失败")
+		return nil, "", false
+	}
+	dirs, err := laStore.ListDirectories()
+	if err != nil {
+		v1Error(c, http.StatusInternalServerError, "local_directories_failed", "读取本地目录失败")
+		return nil, "", false
+	}
+	path, err := resolveLocalEpisodeFile(episode.Path, dirs)
+	switch {
+	case errors.Is(err, errLocalPathOutsideRoots):
+		log.Printf("WARN: Local stream rejected episode=%d path=%q: %v", episode.ID, episode.Path, err)
+		v1Error(c, http.StatusForbidden, "episode_path_forbidden", "视频文件不在已登记的本地目录中")
+		return nil, "", false
+	case errors.Is(err, os.ErrNotExist):
+		v1Error(c, http.StatusNotFound, "episode_file_missing", "对应的视频文件已经不在本地目录里")
+		return nil, "", false
+	case err != nil:
+		log.Printf("WARN: Local stream failed to resolve episode=%d path=%q: %v", episode.ID, episode.Path, err)
+		v1Error(c, http.StatusInternalServerError, "episode_file_unreadable", "无法读取视频文件")
+		return nil, "", false
+	}
+	return episode, path, true
+}
+
+// V1LocalEpisodeStreamHandler serves a scanned episode file directly from
+// disk with byte-range support, so local files play without Jellyfin.
+func V1LocalEpisodeStreamHandler(c *gin.Context) {
+	episode, path, ok := loadStreamableLocalEpisode(c)
+	if !ok {
+		return
+	}
+	serveLocalEpisodeFile(c, episode, path)
+}
+
+func serveLocalEpisodeFile(c *gin.Context, episode *model.LocalEpisode, path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Printf("WARN: Local stream failed to open episode=%d: %v", episode.ID, err)
+		v1Error(c, http.StatusNotFound, "episode_file_missing", "对应的视频文件已经不在本地目录里")
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		v1Error(c, http.StatusNotFound, "episode_file_missing", "对应的视频文件已经不在本地目录里")
+		return
+	}
+	c.Header("Content-Type", localVideoContentType(path))
+	c.Header("Cache-Control", "private, max-age=0, must-revalidate")
+	http.ServeContent(c.Writer, c.Request, filepath.Base(path), info.ModTime(), file)
+}
+
+// V1LocalEpisodePlayInfoHandler returns the same payload shape as the
+// Jellyfin play info endpoint but points the player at the direct stream.
+func V1LocalEpisodePlayInfoHandler(c *gin.Context) {
+	episode, path, ok := loadStreamableLocalEpisode(c)
+	if !ok {
+		return
+	}
+	anime, err := localAnimeStore().GetWithMetadata(episode.LocalAnimeID)
+	if err != nil {
+		v1Error(c, http.StatusNotFound, "anime_not_found", "未找到对应的番剧")
+		return
+	}
+	size := episode.FileSize
+	if info, statErr := os.Stat(path); statErr == nil {
+		size = info.Size()
+	}
+	container := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	if episode.Container != "" {
+		container = episode.Container
+	}
+
+	resume, runtimeTicks, played := int64(0), episode.DurationTicks, false
+	if userID, userErr := currentSessionUserID(c); userErr == nil {
+		if history, historyErr := store.NewPlaybackHistoryStore(db.DB).Find(userID, episode.ID); historyErr == nil {
+			played = history.Completed
+			if !history.Completed {
+				resume = history.PositionTicks
+			}
+			if history.DurationTicks > 0 {
+				runtimeTicks = history.DurationTicks
+			}
+		}
+	}
+
+	title, poster := anime.Title, anime.Image
+	if anime.Metadata != nil {
+		if anime.Metadata.Title != "" {
+			title = anime.Metadata.Title
+		}
+		if anime.Metadata.Image != "" {
+			poster = anime.Metadata.Image
+		}
+	}
+	subtitles := localEpisodeSubtitles(c, episode, path)
+	v1Data(c, http.StatusOK, PlayInfoResponse{
+		StreamURL:    localEpisodeStreamURL(episode.ID),
+		ResumeTicks:  resume,
+		RuntimeTicks: runtimeTicks,
+		Played:       played,
+		Media: JellyfinMediaInfo{
+			Container: container, Size: size, SubtitleCount: len(subtitles),
+			VideoCodec: strings.ToLower(episode.VideoCodec), AudioCodec: strings.ToLower(episode.AudioCodec),
+		},
+		PosterURL:    poster,
+		Title:        title,
+		EpisodeTitle: fmt.Sprintf("S%dE%d - %s", episode.SeasonNum, episode.EpisodeNum, episode.Title),
+		Subtitles:    subtitles,
+	})
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedStreamEpisode(t *testing.T, root, path string) model.LocalEpisode {
+	t.Helper()
+	dir := model.LocalAnimeDirectory{Path: root}
+	require.NoError(t, db.DB.Create(&dir).Error)
+	anime := model.LocalAnime{DirectoryID: dir.ID, Title: "直连播放", Path: filepath.Dir(path)}
+	require.NoError(t, db.DB.Create(&anime).Error)
+	episode := model.LocalEpisode{LocalAnimeID: anime.ID, Title: "Episode 1", SeasonNum: 1, EpisodeNum: 1, Path: path}
+	require.NoError(t, db.DB.Create(&episode).Error)
+	t.Cleanup(func() {
+		_ = db.DB.Unscoped().Where("local_anime_id = ?", anime.ID).Delete(&model.PlaybackHistory{}).Error
+		_ = db.DB.Unscoped().Delete(&model.LocalEpisode{}, episode.ID).Error
+		_ = db.DB.Unscoped().Delete(&model.LocalAnime{}, anime.ID).Error
+		_ = db.DB.Unscoped().Delete(&model.LocalAnimeDirectory{}, dir.ID).Error
+	})
+	return episode
+}
+
+func getLocalStream(router http.Handler, cookie, path, rangeHeader string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if cookie != "" {
+		req.Header.Set("Cookie", cookie)
+	}
+	if rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+	markLocalRequest(req)
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestLocalEpisodeStreamServesByteRanges(t *testing.T) {
+	resetAuthFixtures(t)
+	router := setupRouter()
+	cookie, _ := loginCookie(t, router, "admin")
+
+	root := t.TempDir()
+	videoPath := filepath.Join(root, "Show", "Show - 01.mkv")
+	require.NoError(t, os.MkdirAll(filepath.Dir(videoPath), 0o755))
+	require.NoError(t, os.WriteFile(videoPath, []byte("0123456789"), 0o644))
+	episode := seedStreamEpisode(t, root, videoPath)
+	streamPath := fmt.Sprintf("/api/v1/local-anime/episodes/%d/stream", episode.ID)
+
+	recorder := getLocalStream(router, "", streamPath, "")
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = getLocalStream(router, cookie, streamPath, "")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.Equal(t, "0123456789", recorder.Body.String())
+	assert.Equal(t, "video/x-matroska", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "bytes", recorder.Header().Get("Accept-Ranges"))
+
+	recorder = getLocalStream(router, cookie, streamPath, "bytes=2-5")
+	require.Equal(t, http.StatusPartialContent, recorder.Code)
+	assert.Equal(t, "2345", recorder.Body.String())
+	assert.Equal(t, "bytes 2-5/10", recorder.Header().Get("Content-Range"))
+
+	recorder = getLocalStream(router, cookie, streamPath, "bytes=20-")
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, recorder.Code)
+
+	recorder = getLocalStream(router, cookie, fmt.Sprintf("/api/v1/local-anime/episodes/%d/play", episode.ID), "")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var payload struct {
+		Data PlayInfoResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &payload))
+	assert.Equal(t, streamPath, payload.Data.StreamURL)
+	assert.Empty(t, payload.Data.DirectStreamURL)
+	assert.Equal(t, "mkv", payload.Data.Media.Container)
+	assert.Equal(t, int64(10), payload.Data.Media.Size)
+}
+
+func TestLocalEpisodeStreamRejectsPathsOutsideRoots(t *testing.T) {
+	resetAuthFixtures(t)
+	router := setupRouter()
+	cookie, _ := loginCookie(t, router, "admin")
+
+	root := t.TempDir()
+	outside := t.TempDir()
+	secret := filepath.Join(outside, "secret.mkv")
+	require.NoError(t, os.WriteFile(secret, []byte("secret"), 0o644))
+
+	escaped := seedStreamEpisode(t, root, filepath.Join(root, "..", filepath.Base(outside), "secret.mkv"))
+	recorder := getLocalStream(router, cookie, fmt.Sprintf("/api/v1/local-anime/episodes/%d/stream", escaped.ID), "")
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), "secret")
+
+	link := filepath.Join(root, "link.mkv")
+	if err := os.Symlink(secret, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	linked := seedStreamEpisode(t, t.TempDir(), link)
+	recorder = getLocalStream(router, cookie, fmt.Sprintf("/api/v1/local-anime/episodes/%d/stream", linked.ID), "")
+	assert.Equal(t, http.StatusForbidden, recorder.Code, "symlinks must not escape the registered roots")
+
+	missing := seedStreamEpisode(t, t.TempDir(), filepath.Join(root, "missing.mkv"))
+	recorder = getLocalStream(router, cookie, fmt.Sprintf("/api/v1/local-anime/episodes/%d/stream", missing.ID), "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestContinueWatchingUsesLocalStreamWithoutJellyfin(t *testing.T) {
+	resetAuthFixtures(t)
+	router := setupRouter()
+	cookie, _ := loginCookie(t, router, "admin")
+	_, first, _ := seedPlaybackAnime(t, 72)
+
+	recorder := postPlaybackProgress(t, router, cookie, PlaybackProgressInput{EpisodeID: first.ID, Event: "pause", Ticks: 300, DurationTicks: 1000})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	items := getContinueWatching(t, router, cookie)
+	require.Len(t, items, 1)
+	assert.Equal(t, fmt.Sprintf("/api/v1/local-anime/episodes/%d/stream", first.ID), items[0].StreamURL)
+}
+
+func TestResolveLocalEpisodeFileChecksRootsBeforeFilesystem(t *testing.T) {
+	root := t.TempDir()
+	dirs := []model.LocalAnimeDirectory{{Path: root}}
+
+	_, err := resolveLocalEpisodeFile(filepath.Join(root, "..", "does-not-exist.mkv"), dirs)
+	assert.ErrorIs(t, err, errLocalPathOutsideRoots)
+	_, err = resolveLocalEpisodeFile("relative/video.mkv", dirs)
+	assert.ErrorIs(t, err, errLocalPathOutsideRoots)
+	_, err = resolveLocalEpisodeFile(filepath.Join(root, "missing.mkv"), dirs)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	video := filepath.Join(root, "ok.mp4")
+	require.NoError(t, os.WriteFile(video, []byte("x"), 0o644))
+	resolved, err := resolveLocalEpisodeFile(video, dirs)
+	require.NoError(t, err)
+	assert.Equal(t, "ok.mp4", filepath.Base(resolved))
+}
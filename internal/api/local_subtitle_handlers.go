@@ -0,0 +1,189 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+	"github.com/pokerjest/animateAutoTool/internal/subtitle"
+)
+
+const (
+	subtitleTrackExternalPrefix = "ext-"
+	subtitleTrackEmbeddedPrefix = "emb-"
+	maxSubtitleFileBytes        = 20 << 20
+)
+
+// PlaybackSubtitle is a subtitle track the web player can attach as <track>.
+// URL always returns WebVTT.
+type PlaybackSubtitle struct {
+	ID       string `json:"id"`
+	Label    string `json:"label"`
+	Language string `json:"language"`
+	Format   string `json:"format"`
+	Source   string `json:"source"`
+	URL      string `json:"url"`
+	Default  bool   `json:"default"`
+	Forced   bool   `json:"forced"`
+	Playable bool   `json:"playable"`
+}
+
+func localSubtitleURL(episodeID uint, trackID string) string {
+	return fmt.Sprintf("/api/v1/local-anime/episodes/%d/subtitles/%s", episodeID, trackID)
+}
+
+// localEpisodeSubtitles lists indexed sidecars followed by embedded streams.
+// Embedded streams come from the scan probe cache when available; otherwise
+// discovery is best effort and silently skipped without ffprobe.
+func localEpisodeSubtitles(c *gin.Context, episode *model.LocalEpisode, videoPath string) []PlaybackSubtitle {
+	result := []PlaybackSubtitle{}
+	rows, err := service.EpisodeSubtitles(episode)
+	if err != nil {
+		log.Printf("WARN: Local subtitles failed to load episode=%d: %v", episode.ID, err)
+	}
+	for _, row := range rows {
+		trackID := subtitleTrackExternalPrefix + strconv.FormatUint(uint64(row.ID), 10)
+		result = append(result, PlaybackSubtitle{
+			ID: trackID, Label: row.Label, Language: row.Language, Format: row.Format,
+			Source: subtitle.SourceExternal, URL: localSubtitleURL(episode.ID, trackID),
+			Default: row.Default, Forced: row.Forced, Playable: true,
+		})
+	}
+	embedded, cached := service.CachedEmbeddedSubtitles(episode)
+	if !cached {
+		embedded, err = subtitle.ProbeEmbedded(c.Request.Context(), videoPath)
+		if err != nil && !errors.Is(err, subtitle.ErrToolUnavailable) {
+			log.Printf("WARN: Local subtitles failed to probe episode=%d: %v", episode.ID, err)
+		}
+	}
+	for _, track := range embedded {
+		trackID := subtitleTrackEmbeddedPrefix + strconv.Itoa(track.StreamIndex)
+		item := PlaybackSubtitle{
+			ID: trackID, Label: track.Label, Language: track.Language, Format: track.Format,
+			Source: subtitle.SourceEmbedded, Default: track.Default, Forced: track.Forced, Playable: track.Playable(),
+		}
+		if item.Playable {
+			item.URL = localSubtitleURL(episode.ID, trackID)
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// V1LocalEpisodeSubtitlesHandler lists the subtitle tracks of a local episode.
+func V1LocalEpisodeSubtitlesHandler(c *gin.Context) {
+	episode, path, ok := loadStreamableLocalEpisode(c)
+	if !ok {
+		return
+	}
+	v1Data(c, http.StatusOK, gin.H{"items": localEpisodeSubtitles(c, episode, path)})
+}
+
+// V1LocalEpisodeSubtitleHandler serves one subtitle track as WebVTT. Sidecars
+// are converted in-process; embedded text streams are extracted with ffmpeg.
+func V1LocalEpisodeSubtitleHandler(c *gin.Context) {
+	episode, videoPath, ok := loadStreamableLocalEpisode(c)
+	if !ok {
+		return
+	}
+	trackID := c.Param("track")
+	var (
+		body []byte
+		err  error
+	)
+	switch {
+	case strings.HasPrefix(trackID, subtitleTrackExternalPrefix):
+		body, ok = externalSubtitleWebVTT(c, episode, strings.TrimPrefix(trackID, subtitleTrackExternalPrefix))
+		if !ok {
+			return
+		}
+	case strings.HasPrefix(trackID, subtitleTrackEmbeddedPrefix):
+		streamIndex, parseErr := strconv.Atoi(strings.TrimPrefix(trackID, subtitleTrackEmbeddedPrefix))
+		if parseErr != nil || streamIndex < 0 {
+			v1Error(c, http.StatusNotFound, "subtitle_not_found", "未找到对应的字幕")
+			return
+		}
+		body, err = subtitle.ExtractWebVTT(c.Request.Context(), videoPath, streamIndex)
+		if errors.Is(err, subtitle.ErrToolUnavailable) {
+			v1Error(c, http.StatusServiceUnavailable, "ffmpeg_unavailable", "未找到 ffmpeg，无法读取内嵌字幕")
+			return
+		}
+		if err != nil {
+			log.Printf("WARN: Local subtitles failed to extract episode=%d stream=%d: %v", episode.ID, streamIndex, err)
+			v1Error(c, http.StatusUnprocessableEntity, "subtitle_extract_failed", "内嵌字幕无法转换为 WebVTT")
+			return
+		}
+	default:
+		v1Error(c, http.StatusNotFound, "subtitle_not_found", "未找到对应的字幕")
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, "text/vtt; charset=utf-8", body)
+}
+
+func externalSubtitleWebVTT(c *gin.Context, episode *model.LocalEpisode, rawID string) ([]byte, bool) {
+	subtitleID, err := strconv.ParseUint(rawID, 10, 32)
+	if err != nil {
+		v1Error(c, http.StatusNotFound, "subtitle_not_found", "未找到对应的字幕")
+		return nil, false
+	}
+	rows, err := service.EpisodeSubtitles(episode)
+	if err != nil {
+		v1Error(c, http.StatusInternalServerError, "subtitle_lookup_failed", "读取字幕失败")
+		return nil, false
+	}
+	var row *model.LocalSubtitle
+	for i := range rows {
+		if rows[i].ID == uint(subtitleID) {
+			row = &rows[i]
+			break
+		}
+	}
+	if row == nil {
+		v1Error(c, http.StatusNotFound, "subtitle_not_found", "未找到对应的字幕")
+		return nil, false
+	}
+	dirs, err := localAnimeStore().ListDirectories()
+	if err != nil {
+		v1Error(c, http.StatusInternalServerError, "local_directories_failed", "读取本地目录失败")
+		return nil, false
+	}
+	path, err := resolveLocalEpisodeFile(row.Path, dirs)
+	switch {
+	case errors.Is(err, errLocalPathOutsideRoots):
+		v1Error(c, http.StatusForbidden, "subtitle_path_forbidden", "字幕文件不在已登记的本地目录中")
+		return nil, false
+	case err != nil:
+		v1Error(c, http.StatusNotFound, "subtitle_file_missing", "字幕文件已经不在本地目录里")
+		return nil, false
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		v1Error(c, http.StatusNotFound, "subtitle_file_missing", "字幕文件已经不在本地目录里")
+		return nil, false
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxSubtitleFileBytes+1))
+	if err != nil {
+		v1Error(c, http.StatusInternalServerError, "subtitle_read_failed", "读取字幕失败")
+		return nil, false
+	}
+	if len(data) > maxSubtitleFileBytes {
+		v1Error(c, http.StatusRequestEntityTooLarge, "subtitle_too_large", "字幕文件过大")
+		return nil, false
+	}
+	body, err := subtitle.ToWebVTT(row.Format, data)
+	if err != nil {
+		v1Error(c, http.StatusUnprocessableEntity, "subtitle_convert_failed", err.Error())
+		return nil, false
+	}
+	return body, true
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalPlayInfoListsSidecarSubtitlesAsWebVTT(t *testing.T) {
+	resetAuthFixtures(t)
+	router := setupRouter()
+	cookie, _ := loginCookie(t, router, "admin")
+
+	root := t.TempDir()
+	videoPath := filepath.Join(root, "Show - 01.mkv")
+	require.NoError(t, os.WriteFile(videoPath, []byte("video"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "Show - 01.chs.srt"), []byte("1\n00:00:01,000 --> 00:00:02,000\n你好\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "Show - 01.cht.ass"), []byte("[Events]\nFormat: Layer, Start, End, Text\nDialogue: 0,0:00:01.00,0:00:02.00,{\\b1}你好\n"), 0o644))
+	episode := seedStreamEpisode(t, root, videoPath)
+	t.Cleanup(func() { _ = db.DB.Where("local_episode_id = ?", episode.ID).Delete(&model.LocalSubtitle{}).Error })
+
+	recorder := getLocalStream(router, cookie, fmt.Sprintf("/api/v1/local-anime/episodes/%d/play", episode.ID), "")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var payload struct {
+		Data PlayInfoResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &payload))
+	require.Len(t, payload.Data.Subtitles, 2)
+	assert.Equal(t, 2, payload.Data.Media.SubtitleCount)
+	byLanguage := map[string]PlaybackSubtitle{}
+	for _, track := range payload.Data.Subtitles {
+		byLanguage[track.Language] = track
+	}
+	srt := byLanguage["zh-Hans"]
+	assert.Equal(t, "简体中文 (SRT)", srt.Label)
+	assert.True(t, srt.Playable)
+
+	recorder = getLocalStream(router, cookie, srt.URL, "")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.Equal(t, "text/vtt; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "WEBVTT\n\n1\n00:00:01.000 --> 00:00:02.000\n你好\n", recorder.Body.String())
+
+	recorder = getLocalStream(router, cookie, byLanguage["zh-Hant"].URL, "")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.Contains(t, recorder.Body.String(), "00:00:01.000 --> 00:00:02.000\n你好")
+
+	recorder = getLocalStream(router, cookie, fmt.Sprintf("/api/v1/local-anime/episodes/%d/subtitles/ext-999999", episode.ID), "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	recorder = getLocalStream(router, cookie, fmt.Sprintf("/api/v1/local-anime/episodes/%d/subtitles/nope", episode.ID), "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// 被篡改到根目录之外的字幕路径不能读取
+	outside := filepath.Join(t.TempDir(), "secret.srt")
+	require.NoError(t, os.WriteFile(outside, []byte("secret"), 0o644))
+	require.NoError(t, db.DB.Model(&model.LocalSubtitle{}).Where("local_episode_id = ? AND language = ?", episode.ID, "zh-Hans").Update("path", outside).Error)
+	recorder = getLocalStream(router, cookie, srt.URL, "")
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = getLocalStream(router, cookie, fmt.Sprintf("/api/v1/local-anime/episodes/%d/subtitles", episode.ID), "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"source":"external"`)
+}
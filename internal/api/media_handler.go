@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,8 +11,10 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pokerjest/animateAutoTool/internal/db"
 	mediaprovider "github.com/pokerjest/animateAutoTool/internal/media"
 	"github.com/pokerjest/animateAutoTool/internal/model"
+	"gorm.io/gorm"
 )
 
 type mediaLibraryResponse struct {
@@ -72,7 +75,10 @@ type mediaStateInput struct {
 	Favorite *bool `json:"favorite"`
 }
 
-func resolveMediaProvider(name string) (mediaprovider.MediaProvider, error) {
+func resolveMediaProvider(c *gin.Context, name string) (mediaprovider.MediaProvider, error) {
+	if strings.EqualFold(strings.TrimSpace(name), mediaprovider.LocalProviderID) {
+		return resolveLocalMediaProvider(c)
+	}
 	if !strings.EqualFold(strings.TrimSpace(name), "jellyfin") {
 		return nil, fmt.Errorf("不支持的媒体提供商：%s", name)
 	}
@@ -87,6 +93,34 @@ func resolveMediaProvider(name string) (mediaprovider.MediaProvider, error) {
 	return mediaprovider.NewJellyfinProvider(client, directURL, configValue(model.ConfigKeyJellyfinApiKey)), nil
 }
 
+// resolveLocalMediaProvider scopes the scanned library to the signed-in user,
+// whose playback history and favorites back the provider's user state.
+func resolveLocalMediaProvider(c *gin.Context) (mediaprovider.MediaProvider, error) {
+	if db.DB == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	userID, err := currentSessionUserID(c)
+	if err != nil {
+		return nil, err
+	}
+	return mediaprovider.NewLocalProvider(db.DB, userID, func(_ context.Context, episodeID uint, input mediaprovider.ProgressInput) error {
+		_, _, err := recordPlaybackProgress(userID, PlaybackProgressInput{
+			EpisodeID: episodeID, Event: input.Event, Ticks: input.Ticks, DurationTicks: input.DurationTicks,
+		})
+		return err
+	}), nil
+}
+
+// localMediaConfigured reports whether at least one local directory is registered.
+func localMediaConfigured() bool {
+	laStore := localAnimeStore()
+	if laStore == nil {
+		return false
+	}
+	dirs, err := laStore.ListDirectories()
+	return err == nil && len(dirs) > 0
+}
+
 func mediaImageURL(provider, itemID string) string {
 	return "/api/v1/media/providers/" + url.PathEscape(provider) + "/items/" + url.PathEscape(itemID) + "/image"
 }
@@ -138,7 +172,7 @@ func V1MediaProvidersHandler(c *gin.Context) {
 	var client mediaprovider.MediaProvider
 	var err error
 	if configured {
-		client, err = resolveMediaProvider("jellyfin")
+		client, err = resolveMediaProvider(c, "jellyfin")
 	}
 	connected := configured && err == nil && client != nil
 	detail := ""
@@ -150,15 +184,25 @@ func V1MediaProvidersHandler(c *gin.Context) {
 	} else {
 		capabilities = client.Capabilities()
 	}
+	localConfigured := localMediaConfigured()
+	localDetail := ""
+	if !localConfigured {
+		localDetail = "尚未添加本地番剧目录"
+	}
 	v1Data(c, http.StatusOK, gin.H{"providers": []gin.H{{
 		"id": "jellyfin", "name": "Jellyfin", "configured": configured,
 		"connected": connected, "detail": detail,
 		"capabilities": capabilities,
+	}, {
+		"id": mediaprovider.LocalProviderID, "name": "本地媒体库", "configured": localConfigured,
+		"connected": localConfigured, "detail": localDetail,
+		"capabilities": mediaprovider.ProviderCapabilities{Libraries: true, Search: true, Episodes: true, Progress: true, Favorites: true, Images: true},
 	}}})
 }
 
 func V1MediaLibrariesHandler(c *gin.Context) {
-	client, err := resolveMediaProvider(c.Param("provider"))
+	provider := strings.TrimSpace(c.Param("provider"))
+	client, err := resolveMediaProvider(c, provider)
 	if err != nil {
 		v1Error(c, http.StatusServiceUnavailable, "media_provider_unavailable", "媒体提供商暂时不可用")
 		return
@@ -168,7 +212,10 @@ func V1MediaLibrariesHandler(c *gin.Context) {
 		v1Error(c, http.StatusBadGateway, "media_libraries_failed", "读取媒体库失败")
 		return
 	}
-	selected := configuredLibrarySet()
+	selected := map[string]bool{}
+	if !isLocalMediaProvider(provider) {
+		selected = configuredLibrarySet()
+	}
 	items := make([]mediaLibraryResponse, 0, len(libraries))
 	for _, library := range libraries {
 		items = append(items, mediaLibraryResponse{
@@ -181,7 +228,7 @@ func V1MediaLibrariesHandler(c *gin.Context) {
 
 func V1MediaItemsHandler(c *gin.Context) {
 	provider := strings.TrimSpace(c.Param("provider"))
-	client, err := resolveMediaProvider(provider)
+	client, err := resolveMediaProvider(c, provider)
 	if err != nil {
 		v1Error(c, http.StatusServiceUnavailable, "media_provider_unavailable", "媒体提供商暂时不可用")
 		return
@@ -218,7 +265,10 @@ func V1MediaItemsHandler(c *gin.Context) {
 	if sortOrder == "" {
 		sortOrder = "Ascending"
 	}
-	libraryIDs := configuredJellyfinLibraryIDs()
+	var libraryIDs []string
+	if !isLocalMediaProvider(provider) {
+		libraryIDs = configuredJellyfinLibraryIDs()
+	}
 	if libraryID != "" && libraryID != "all" {
 		libraryIDs = []string{libraryID}
 	}
@@ -272,7 +322,7 @@ func V1MediaItemsHandler(c *gin.Context) {
 
 func V1MediaItemHandler(c *gin.Context) {
 	provider := strings.TrimSpace(c.Param("provider"))
-	client, err := resolveMediaProvider(provider)
+	client, err := resolveMediaProvider(c, provider)
 	if err != nil {
 		v1Error(c, http.StatusServiceUnavailable, "media_provider_unavailable", "媒体提供商暂时不可用")
 		return
@@ -287,7 +337,7 @@ func V1MediaItemHandler(c *gin.Context) {
 
 func V1MediaChildrenHandler(c *gin.Context) {
 	provider := strings.TrimSpace(c.Param("provider"))
-	client, err := resolveMediaProvider(provider)
+	client, err := resolveMediaProvider(c, provider)
 	if err != nil {
 		v1Error(c, http.StatusServiceUnavailable, "media_provider_unavailable", "媒体提供商暂时不可用")
 		return
@@ -306,7 +356,7 @@ func V1MediaChildrenHandler(c *gin.Context) {
 
 func V1MediaContinueHandler(c *gin.Context) {
 	provider := strings.TrimSpace(c.Param("provider"))
-	client, err := resolveMediaProvider(provider)
+	client, err := resolveMediaProvider(c, provider)
 	if err != nil {
 		v1Error(c, http.StatusServiceUnavailable, "media_provider_unavailable", "媒体提供商暂时不可用")
 		return
@@ -321,7 +371,7 @@ func V1MediaContinueHandler(c *gin.Context) {
 }
 
 func V1MediaImageHandler(c *gin.Context) {
-	client, err := resolveMediaProvider(c.Param("provider"))
+	client, err := resolveMediaProvider(c, c.Param("provider"))
 	if err != nil {
 		c.Status(http.StatusServiceUnavailable)
 		return
@@ -338,7 +388,7 @@ func V1MediaImageHandler(c *gin.Context) {
 func V1MediaPlayHandler(c *gin.Context) {
 	provider := strings.TrimSpace(c.Param("provider"))
 	itemID := strings.TrimSpace(c.Param("id"))
-	client, err := resolveMediaProvider(provider)
+	client, err := resolveMediaProvider(c, provider)
 	if err != nil {
 		v1Error(c, http.StatusServiceUnavailable, "media_provider_unavailable", "媒体提供商暂时不可用")
 		return
@@ -367,6 +417,10 @@ func V1MediaPlayHandler(c *gin.Context) {
 }
 
 func MediaStreamHandler(c *gin.Context) {
+	if isLocalMediaProvider(c.Param("provider")) {
+		serveLocalMediaItem(c, c.Param("id"))
+		return
+	}
 	if !strings.EqualFold(c.Param("provider"), "jellyfin") {
 		c.Status(http.StatusNotFound)
 		return
@@ -374,17 +428,41 @@ func MediaStreamHandler(c *gin.Context) {
 	proxyVideoForJellyfinItem(c, c.Param("id"))
 }
 
+func isLocalMediaProvider(provider string) bool {
+	return strings.EqualFold(strings.TrimSpace(provider), mediaprovider.LocalProviderID)
+}
+
+// serveLocalMediaItem streams a local provider episode item with the same
+// root-directory guard as the local-anime stream endpoint.
+func serveLocalMediaItem(c *gin.Context, itemID string) {
+	episodeID, ok := mediaprovider.ParseLocalEpisodeItemID(itemID)
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	episode, path, ok := loadStreamableLocalEpisodeByID(c, episodeID)
+	if !ok {
+		return
+	}
+	serveLocalEpisodeFile(c, episode, path)
+}
+
+func supportedMediaProvider(provider string) bool {
+	return strings.EqualFold(provider, "jellyfin") || isLocalMediaProvider(provider)
+}
+
 func V1MediaProgressHandler(c *gin.Context) {
-	if !strings.EqualFold(c.Param("provider"), "jellyfin") {
+	if !supportedMediaProvider(c.Param("provider")) {
 		v1Error(c, http.StatusNotFound, "media_provider_not_found", "未找到媒体提供商")
 		return
 	}
 	var input mediaProgressInput
-	if err := c.ShouldBindJSON(&input); err != nil || input.Ticks < 0 || input.DurationTicks < 0 {
+	if err := c.ShouldBindJSON(&input); err != nil || input.Ticks < 0 || input.DurationTicks < 0 ||
+		(isLocalMediaProvider(c.Param("provider")) && !validPlaybackEvent(input.Event)) {
 		v1Error(c, http.StatusBadRequest, "invalid_media_progress", "播放进度格式不正确")
 		return
 	}
-	client, err := resolveMediaProvider(c.Param("provider"))
+	client, err := resolveMediaProvider(c, c.Param("provider"))
 	if err != nil {
 		v1Error(c, http.StatusServiceUnavailable, "media_provider_unavailable", "媒体提供商暂时不可用")
 		return
@@ -392,6 +470,10 @@ func V1MediaProgressHandler(c *gin.Context) {
 	err = client.UpdateProgress(c.Request.Context(), c.Param("id"), mediaprovider.ProgressInput{
 		Event: input.Event, Ticks: input.Ticks, DurationTicks: input.DurationTicks,
 	})
+	if errors.Is(err, mediaprovider.ErrItemNotFound) {
+		v1Error(c, http.StatusNotFound, "media_item_not_found", "未找到媒体项目")
+		return
+	}
 	if err != nil {
 		v1Error(c, http.StatusBadGateway, "media_progress_failed", "同步播放进度失败")
 		return
@@ -400,7 +482,7 @@ func V1MediaProgressHandler(c *gin.Context) {
 }
 
 func V1MediaStateHandler(c *gin.Context) {
-	if !strings.EqualFold(c.Param("provider"), "jellyfin") {
+	if !supportedMediaProvider(c.Param("provider")) {
 		v1Error(c, http.StatusNotFound, "media_provider_not_found", "未找到媒体提供商")
 		return
 	}
@@ -409,13 +491,17 @@ func V1MediaStateHandler(c *gin.Context) {
 		v1Error(c, http.StatusBadRequest, "invalid_media_state", "至少需要提交已看或收藏状态")
 		return
 	}
-	client, err := resolveMediaProvider(c.Param("provider"))
+	client, err := resolveMediaProvider(c, c.Param("provider"))
 	if err != nil {
 		v1Error(c, http.StatusServiceUnavailable, "media_provider_unavailable", "媒体提供商暂时不可用")
 		return
 	}
 	itemID := c.Param("id")
 	err = client.UpdateUserState(c.Request.Context(), itemID, mediaprovider.UserStateInput{Played: input.Played, Favorite: input.Favorite})
+	if errors.Is(err, mediaprovider.ErrItemNotFound) {
+		v1Error(c, http.StatusNotFound, "media_item_not_found", "未找到媒体项目")
+		return
+	}
 	if err != nil {
 		v1Error(c, http.StatusBadGateway, "media_state_failed", "同步媒体状态失败")
 		return
@@ -7,6 +7,8 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -219,3 +221,72 @@ func TestMediaHandlersExposeProviderNeutralJellyfinCatalog(t *testing.T) {
 	require.NoError(t, json.NewDecoder(bytes.NewBuffer(play.Body.Bytes())).Decode(&payload))
 	assert.NotNil(t, payload["data"], fmt.Sprintf("unexpected response: %s", play.Body.String()))
 }
+
+func TestLocalMediaProviderServesScannedLibrary(t *testing.T) {
+	resetAuthFixtures(t)
+	router := setupRouter()
+	cookie, _ := loginCookie(t, router, "admin")
+	request := func(method, target, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Cookie", cookie)
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		markLocalRequest(req)
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	root := t.TempDir()
+	videoPath := filepath.Join(root, "Show", "Show - 01.mp4")
+	require.NoError(t, os.MkdirAll(filepath.Dir(videoPath), 0o755))
+	require.NoError(t, os.WriteFile(videoPath, []byte("0123456789"), 0o644))
+	episode := seedStreamEpisode(t, root, videoPath)
+	t.Cleanup(func() { _ = db.DB.Where("item_id LIKE ?", "series-%").Delete(&model.LocalMediaFavorite{}).Error })
+	episodeID := fmt.Sprintf("episode-%d", episode.ID)
+	seriesID := fmt.Sprintf("series-%d", episode.LocalAnimeID)
+
+	providers := request(http.MethodGet, "/api/v1/media/providers", "")
+	require.Equal(t, http.StatusOK, providers.Code, providers.Body.String())
+	assert.Contains(t, providers.Body.String(), `"id":"local"`)
+
+	libraries := request(http.MethodGet, "/api/v1/media/providers/local/libraries", "")
+	require.Equal(t, http.StatusOK, libraries.Code, libraries.Body.String())
+	assert.Contains(t, libraries.Body.String(), `"selected":true`)
+
+	items := request(http.MethodGet, "/api/v1/media/providers/local/items?library_id=all&q=直连", "")
+	require.Equal(t, http.StatusOK, items.Code, items.Body.String())
+	assert.Contains(t, items.Body.String(), `"id":"`+seriesID+`"`)
+	assert.Contains(t, items.Body.String(), `"provider":"local"`)
+
+	children := request(http.MethodGet, "/api/v1/media/providers/local/items/"+seriesID+"/children?type=episode", "")
+	require.Equal(t, http.StatusOK, children.Code, children.Body.String())
+	assert.Contains(t, children.Body.String(), `"id":"`+episodeID+`"`)
+
+	play := request(http.MethodGet, "/api/v1/media/providers/local/items/"+episodeID+"/play", "")
+	require.Equal(t, http.StatusOK, play.Code, play.Body.String())
+	assert.Contains(t, play.Body.String(), `"stream_url":"/api/v1/media/providers/local/items/`+episodeID+`/stream"`)
+
+	stream := request(http.MethodGet, "/api/v1/media/providers/local/items/"+episodeID+"/stream", "")
+	require.Equal(t, http.StatusOK, stream.Code, stream.Body.String())
+	assert.Equal(t, "0123456789", stream.Body.String())
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/api/v1/media/providers/local/items/"+seriesID+"/stream", "").Code)
+
+	progress := request(http.MethodPost, "/api/v1/media/providers/local/items/"+episodeID+"/progress", `{"event":"pause","ticks":300,"duration_ticks":1200}`)
+	require.Equal(t, http.StatusOK, progress.Code, progress.Body.String())
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/api/v1/media/providers/local/items/"+episodeID+"/progress", `{"event":"bogus"}`).Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodPost, "/api/v1/media/providers/local/items/episode-999999/progress", `{"event":"pause"}`).Code)
+
+	resume := request(http.MethodGet, "/api/v1/media/providers/local/continue", "")
+	require.Equal(t, http.StatusOK, resume.Code, resume.Body.String())
+	assert.Contains(t, resume.Body.String(), `"resume_ticks":300`)
+
+	state := request(http.MethodPut, "/api/v1/media/providers/local/items/"+seriesID+"/user-state", `{"favorite":true}`)
+	require.Equal(t, http.StatusOK, state.Code, state.Body.String())
+	assert.Contains(t, state.Body.String(), `"favorite":true`)
+	favorites := request(http.MethodGet, "/api/v1/media/providers/local/items?section=favorites", "")
+	require.Equal(t, http.StatusOK, favorites.Code, favorites.Body.String())
+	assert.Contains(t, favorites.Body.String(), `"id":"`+seriesID+`"`)
+}
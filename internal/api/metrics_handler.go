@@ -0,0 +1,164 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/metrics"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/qbutil"
+	"github.com/pokerjest/animateAutoTool/internal/scheduler"
+	"github.com/pokerjest/animateAutoTool/internal/version"
+)
+
+const minMetricsTokenLength = 16
+
+// MetricsHandler serves GET /metrics in the Prometheus text format. The
+// endpoint answers 404 until metrics_enabled is true; after that a scraper
+// needs `Authorization: Bearer <metrics_token>` or an address in the login IP
+// allowlist.
+func MetricsHandler(c *gin.Context) {
+	if !strings.EqualFold(configValue(model.ConfigKeyMetricsEnabled), ValueTrue) {
+		c.String(http.StatusNotFound, "指标接口未启用")
+		return
+	}
+	if !metricsRequestAuthorized(c) {
+		c.Header("WWW-Authenticate", `Bearer realm="metrics"`)
+		c.String(http.StatusUnauthorized, "需要指标令牌或来自 IP 白名单的请求")
+		return
+	}
+
+	c.Header("Content-Type", metrics.ContentType)
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+	if err := metrics.Default.WriteText(c.Writer, scrapeTimeMetrics()...); err != nil {
+		log.Printf("WARN: Metrics: failed to write scrape response: %v", err)
+	}
+}
+
+func metricsRequestAuthorized(c *gin.Context) bool {
+	if secret, ok := bearerToken(c.Request); ok {
+		expected := configValue(model.ConfigKeyMetricsToken)
+		return expected != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(expected)) == 1
+	}
+	return requestMatchesAuthIPAllowlist(c)
+}
+
+func normalizeMetricsToken(value string) (string, error) {
+	if value != "" && len(value) < minMetricsTokenLength {
+		return "", errors.New("指标令牌至少需要 16 个字符")
+	}
+	return value, nil
+}
+
+// scrapeTimeMetrics reads the values that live in the database or in status
+// trackers rather than in counters.
+func scrapeTimeMetrics() []metrics.Family {
+	families := []metrics.Family{{
+		Name:    "animate_build_info",
+		Help:    "Build information; the value is always 1.",
+		Type:    metrics.TypeGauge,
+		Samples: []metrics.Sample{{Labels: []metrics.Label{{Name: "version", Value: version.AppVersion}}, Value: 1}},
+	}}
+	families = append(families, schedulerStatusMetrics()...)
+	families = append(families, downloaderReachabilityMetric())
+	if db.DB == nil {
+		return families
+	}
+	families = append(families, subscriptionSuccessAgeMetric(time.Now()), subscriptionResourceStateMetric())
+	return families
+}
+
+func schedulerStatusMetrics() []metrics.Family {
+	status := scheduler.GlobalRunStatus.Snapshot()
+	running := 0.0
+	if status.IsRunning {
+		running = 1
+	}
+	families := []metrics.Family{{
+		Name:    "animate_scheduler_running",
+		Help:    "Whether a subscription check run is in progress.",
+		Type:    metrics.TypeGauge,
+		Samples: []metrics.Sample{{Value: running}},
+	}}
+	if status.LastFinishedAt != nil {
+		families = append(families, metrics.Family{
+			Name:    "animate_scheduler_last_run_finished_timestamp_seconds",
+			Help:    "Unix time the last subscription check run finished or was skipped.",
+			Type:    metrics.TypeGauge,
+			Samples: []metrics.Sample{{Value: float64(status.LastFinishedAt.Unix())}},
+		})
+	}
+	return families
+}
+
+func subscriptionSuccessAgeMetric(now time.Time) metrics.Family {
+	family := metrics.Family{
+		Name: "animate_subscription_last_success_age_seconds",
+		Help: "Seconds since each active subscription last checked its feed successfully. Subscriptions that never succeeded are omitted.",
+		Type: metrics.TypeGauge,
+	}
+	var subs []model.Subscription
+	if err := db.DB.Select("id", "title", "last_success_at").Where("is_active = ? AND last_success_at IS NOT NULL", true).Order("id").Find(&subs).Error; err != nil {
+		log.Printf("WARN: Metrics: failed to read subscriptions: %v", err)
+		return family
+	}
+	for _, sub := range subs {
+		family.Samples = append(family.Samples, metrics.Sample{
+			Labels: []metrics.Label{{Name: "subscription_id", Value: strconv.FormatUint(uint64(sub.ID), 10)}, {Name: "title", Value: sub.Title}},
+			Value:  max(now.Sub(*sub.LastSuccessAt).Seconds(), 0),
+		})
+	}
+	return family
+}
+
+func subscriptionResourceStateMetric() metrics.Family {
+	family := metrics.Family{
+		Name: "animate_subscription_resources",
+		Help: "Subscription resources by lifecycle state.",
+		Type: metrics.TypeGauge,
+	}
+	var rows []struct {
+		State string
+		Count int64
+	}
+	if err := db.DB.Model(&model.SubscriptionResource{}).Select("state, COUNT(*) AS count").Group("state").Order("state").Scan(&rows).Error; err != nil {
+		log.Printf("WARN: Metrics: failed to count subscription resources: %v", err)
+		return family
+	}
+	for _, row := range rows {
+		family.Samples = append(family.Samples, metrics.Sample{
+			Labels: []metrics.Label{{Name: "state", Value: row.State}},
+			Value:  float64(row.Count),
+		})
+	}
+	return family
+}
+
+// downloaderReachabilityMetric reuses the cached connection probe of the
+// settings page so frequent scrapes do not log in to the downloader each time.
+func downloaderReachabilityMetric() metrics.Family {
+	qbCfg := qbutil.LoadConfig()
+	up := 0.0
+	probe := newConnectionProbe("qb", qbCfg.Backend, qbCfg.Mode, qbCfg.URL, qbCfg.Username, qbCfg.Password)
+	if stat, ok := probe.load(); ok {
+		if stat.Success {
+			up = 1
+		}
+	} else if storeQBStatusProbe(probe, evaluateQBStatus(qbCfg)) {
+		up = 1
+	}
+	return metrics.Family{
+		Name:    "animate_downloader_up",
+		Help:    "Whether the configured downloader accepted a login during the last probe.",
+		Type:    metrics.TypeGauge,
+		Samples: []metrics.Sample{{Labels: []metrics.Label{{Name: "backend", Value: qbCfg.Backend}}, Value: up}},
+	}
+}
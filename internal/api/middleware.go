@@ -102,7 +102,7 @@ func requestRequiresSameOrigin(method string) bool {
 
 func SameOriginMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if !requestRequiresSameOrigin(c.Request.Method) {
+		if _, viaToken := requestAPIToken(c); viaToken || !requestRequiresSameOrigin(c.Request.Method) {
 			c.Next()
 			return
 		}
@@ -151,8 +151,8 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 		path := c.Request.URL.Path
 
-		if userID == nil {
-			if _, passwordless := passwordlessAdminForRequest(c); !passwordless {
+		if _, viaToken := requestAPIToken(c); userID == nil && !viaToken {
+			if _, _, passwordless := passwordlessUserForRequest(c); !passwordless {
 				if isAPIRequestPath(path) {
 					if isV1APIRequestPath(path) {
 						v1Error(c, http.StatusUnauthorized, "unauthorized", "请先登录")
@@ -136,7 +136,7 @@ func NetBirdProxyMediaHandler(c *gin.Context) {
 		c.Status(http.StatusBadRequest)
 		return
 	}
-	if !strings.EqualFold(provider, "jellyfin") {
+	if !supportedMediaProvider(provider) {
 		c.Status(http.StatusNotFound)
 		return
 	}
@@ -144,5 +144,9 @@ func NetBirdProxyMediaHandler(c *gin.Context) {
 		c.Status(http.StatusUnauthorized)
 		return
 	}
+	if isLocalMediaProvider(provider) {
+		serveLocalMediaItem(c, itemID)
+		return
+	}
 	proxyVideoForJellyfinItem(c, itemID)
 }
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/notify"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"gorm.io/gorm"
+)
+
+const notificationTestTimeout = 30 * time.Second
+
+// notificationChannelView 是返回给前端的渠道，凭据只报告是否已保存。
+type notificationChannelView struct {
+	ID         uint                 `json:"id"`
+	Name       string               `json:"name"`
+	Type       string               `json:"type"`
+	Enabled    bool                 `json:"enabled"`
+	Events     []string             `json:"events"`
+	Template   string               `json:"template"`
+	Config     notify.ChannelConfig `json:"config"`
+	SecretsSet []string             `json:"secrets_set"`
+	CreatedAt  time.Time            `json:"created_at"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+}
+
+type notificationChannelInput struct {
+	Name     string               `json:"name"`
+	Type     string               `json:"type"`
+	Enabled  *bool                `json:"enabled"`
+	Events   []string             `json:"events"`
+	Template string               `json:"template"`
+	Config   notify.ChannelConfig `json:"config"`
+}
+
+func newNotificationChannelView(channel model.NotificationChannel) notificationChannelView {
+	cfg, _ := notify.ParseChannelConfig(channel.Config)
+	events := notify.ParseEvents(channel.Events)
+	if events == nil {
+		events = []string{}
+	}
+	return notificationChannelView{
+		ID:         channel.ID,
+		Name:       channel.Name,
+		Type:       channel.Type,
+		Enabled:    channel.Enabled,
+		Events:     events,
+		Template:   channel.Template,
+		Config:     cfg.Masked(),
+		SecretsSet: cfg.SecretsSet(),
+		CreatedAt:  channel.CreatedAt,
+		UpdatedAt:  channel.UpdatedAt,
+	}
+}
+
+func V1NotificationChannelsHandler(c *gin.Context) {
+	channels, err := store.NewNotificationStore(db.DB).ListChannels()
+	if err != nil {
+		v1Error(c, http.StatusInternalServerError, "notifications_unavailable", "无法读取通知渠道")
+		return
+	}
+	items := make([]notificationChannelView, 0, len(channels))
+	for _, channel := range channels {
+		items = append(items, newNotificationChannelView(channel))
+	}
+	v1Data(c, http.StatusOK, gin.H{
+		"items":         items,
+		"channel_types": notify.ChannelTypes,
+		"events":        notify.EventOptions,
+	})
+}
+
+func V1CreateNotificationChannelHandler(c *gin.Context) {
+	var channel model.NotificationChannel
+	channel.Enabled = true
+	if !bindNotificationChannel(c, &channel) {
+		return
+	}
+	if err := store.NewNotificationStore(db.DB).SaveChannel(&channel); err != nil {
+		v1Error(c, http.StatusInternalServerError, "notification_save_failed", "保存通知渠道失败")
+		return
+	}
+	recordNotificationAudit(c, service.AuditActionNotificationUpdate, channel)
+	v1Message(c, http.StatusCreated, "通知渠道已创建", newNotificationChannelView(channel))
+}
+
+func V1UpdateNotificationChannelHandler(c *gin.Context) {
+	channel, ok := loadNotificationChannel(c)
+	if !ok {
+		return
+	}
+	if !bindNotificationChannel(c, channel) {
+		return
+	}
+	if err := store.NewNotificationStore(db.DB).SaveChannel(channel); err != nil {
+		v1Error(c, http.StatusInternalServerError, "notification_save_failed", "保存通知渠道失败")
+		return
+	}
+	recordNotificationAudit(c, service.AuditActionNotificationUpdate, *channel)
+	v1Message(c, http.StatusOK, "通知渠道已保存", newNotificationChannelView(*channel))
+}
+
+func V1DeleteNotificationChannelHandler(c *gin.Context) {
+	channel, ok := loadNotificationChannel(c)
+	if !ok {
+		return
+	}
+	if err := store.NewNotificationStore(db.DB).DeleteChannel(channel.ID); err != nil {
+		v1Error(c, http.StatusInternalServerError, "notification_delete_failed", "删除通知渠道失败")
+		return
+	}
+	recordNotificationAudit(c, service.AuditActionNotificationDelete, *channel)
+	v1Message(c, http.StatusOK, "通知渠道已删除", nil)
+}
+
+// V1TestNotificationChannelHandler 立即向渠道发送一条测试消息，结果同样写入投递历史。
+func V1TestNotificationChannelHandler(c *gin.Context) {
+	channel, ok := loadNotificationChannel(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), notificationTestTimeout)
+	defer cancel()
+	delivery := notify.Default().SendTest(ctx, *channel)
+	if delivery.Status != notify.DeliveryStatusSent {
+		v1Error(c, http.StatusBadGateway, "notification_test_failed", "测试通知发送失败: "+delivery.Error)
+		return
+	}
+	v1Message(c, http.StatusOK, "测试通知已发送", delivery)
+}
+
+func V1NotificationDeliveriesHandler(c *gin.Context) {
+	channelID, _ := strconv.ParseUint(c.Query("channel_id"), 10, 64)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	rows, err := store.NewNotificationStore(db.DB).ListDeliveries(uint(channelID), limit)
+	if err != nil {
+		v1Error(c, http.StatusInternalServerError, "notification_deliveries_unavailable", "无法读取通知投递记录")
+		return
+	}
+	v1Data(c, http.StatusOK, gin.H{"items": rows})
+}
+
+func loadNotificationChannel(c *gin.Context) (*model.NotificationChannel, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		v1Error(c, http.StatusBadRequest, "invalid_id", "无效的通知渠道 ID")
+		return nil, false
+	}
+	channel, err := store.NewNotificationStore(db.DB).GetChannel(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		v1Error(c, http.StatusNotFound, "notification_not_found", "未找到通知渠道")
+		return nil, false
+	}
+	if err != nil {
+		v1Error(c, http.StatusInternalServerError, "notifications_unavailable", "无法读取通知渠道")
+		return nil, false
+	}
+	return channel, true
+}
+
+// bindNotificationChannel 校验请求并写入 channel。更新时留空的凭据沿用已保存的值。
+func bindNotificationChannel(c *gin.Context, channel *model.NotificationChannel) bool {
+	var input notificationChannelInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		v1Error(c, http.StatusBadRequest, "invalid_request", "通知渠道格式不正确")
+		return false
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len([]rune(name)) > 64 {
+		v1Error(c, http.StatusBadRequest, "invalid_notification", "渠道名称不能为空且不超过 64 个字符")
+		return false
+	}
+	channelType := strings.ToLower(strings.TrimSpace(input.Type))
+	if channel.ID != 0 && channelType != channel.Type {
+		v1Error(c, http.StatusBadRequest, "invalid_notification", "不能修改已有渠道的类型")
+		return false
+	}
+	for _, key := range input.Events {
+		if !notify.IsKnownEvent(strings.TrimSpace(key)) {
+			v1Error(c, http.StatusBadRequest, "invalid_notification", "未知的通知事件: "+key)
+			return false
+		}
+	}
+	if err := notify.ValidateTemplate(input.Template); err != nil {
+		v1Error(c, http.StatusBadRequest, "invalid_notification", err.Error())
+		return false
+	}
+	cfg := input.Config
+	if channel.ID != 0 {
+		previous, _ := notify.ParseChannelConfig(channel.Config)
+		cfg = cfg.KeepSecrets(previous)
+	}
+	if err := cfg.Validate(channelType); err != nil {
+		v1Error(c, http.StatusBadRequest, "invalid_notification", err.Error())
+		return false
+	}
+
+	channel.Name = name
+	channel.Type = channelType
+	if input.Enabled != nil {
+		channel.Enabled = *input.Enabled
+	}
+	channel.Events = strings.Join(notify.ParseEvents(strings.Join(input.Events, ",")), ",")
+	channel.Template = strings.TrimSpace(input.Template)
+	channel.Config = cfg.Encode()
+	return true
+}
+
+func recordNotificationAudit(c *gin.Context, action string, channel model.NotificationChannel) {
+	service.RecordAudit(buildAuditContext(c), service.AuditEntry{
+		Action: action, Outcome: service.AuditOutcomeSuccess,
+		TargetType: "notification_channel", TargetID: strconv.FormatUint(uint64(channel.ID), 10),
+		Details: map[string]any{"name": channel.Name, "type": channel.Type},
+	})
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestV1NotificationChannelsMaskSecretsAndSendTest(t *testing.T) {
+	resetAuthFixtures(t)
+	var authHeaders []string
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer stub.Close()
+
+	r := setupRouter()
+	cookie, _ := loginCookie(t, r, "admin")
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Cookie", cookie)
+		markLocalRequest(req)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/api/v1/settings/notifications", `{"name":"hook","type":"webhook","events":["download_ready"],"config":{"url":"`+stub.URL+`","token":"hook-secret"}}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.NotContains(t, w.Body.String(), "hook-secret")
+	var created struct {
+		Data notificationChannelView `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, []string{"token"}, created.Data.SecretsSet)
+	id := strconv.FormatUint(uint64(created.Data.ID), 10)
+
+	w = do(http.MethodPost, "/api/v1/settings/notifications", `{"name":"bad","type":"webhook","events":["nope"],"config":{"url":"`+stub.URL+`"}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 留空的 token 保持原值
+	w = do(http.MethodPut, "/api/v1/settings/notifications/"+id, `{"name":"renamed","type":"webhook","events":[],"config":{"url":"`+stub.URL+`"}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var stored model.NotificationChannel
+	require.NoError(t, db.DB.First(&stored, created.Data.ID).Error)
+	assert.Equal(t, "renamed", stored.Name)
+	assert.Contains(t, stored.Config, "hook-secret")
+
+	w = do(http.MethodGet, "/api/v1/settings/notifications", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "hook-secret")
+	assert.Contains(t, w.Body.String(), `"channel_types"`)
+
+	w = do(http.MethodPost, "/api/v1/settings/notifications/"+id+"/test", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []string{"Bearer hook-secret"}, authHeaders)
+
+	w = do(http.MethodGet, "/api/v1/settings/notifications/deliveries?channel_id="+id, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"sent"`)
+	assert.Contains(t, w.Body.String(), `"test":true`)
+
+	w = do(http.MethodDelete, "/api/v1/settings/notifications/"+id, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	w = do(http.MethodPost, "/api/v1/settings/notifications/"+id+"/test", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -10,10 +10,18 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/pokerjest/animateAutoTool/internal/metrics"
 )
 
 const slowHTTPRequestThreshold = 5 * time.Second
 
+var httpRequestDuration = metrics.NewHistogramVec(
+	"animate_http_request_duration_seconds",
+	"HTTP request latency by method, route template and status class. Requests that match no route share the route label \"unmatched\".",
+	nil,
+	"method", "route", "status",
+)
+
 // RequestLoggingMiddleware records enough context to diagnose failed or slow
 // requests without persisting query strings, request bodies, cookies, or
 // authorization headers.
@@ -32,6 +40,11 @@ func RequestLoggingMiddleware() gin.HandlerFunc {
 		duration := time.Since(startedAt)
 		status := c.Writer.Status()
 		route := strings.TrimSpace(c.FullPath())
+		metricRoute := route
+		if metricRoute == "" {
+			metricRoute = "unmatched"
+		}
+		httpRequestDuration.Observe(duration.Seconds(), c.Request.Method, metricRoute, metrics.StatusClass(status))
 		if route == "" && c.Request != nil && c.Request.URL != nil {
 			route = c.Request.URL.EscapedPath()
 		}
@@ -29,6 +29,7 @@ func TestRequestLoggingMiddlewareOmitsQueryAndSensitiveHeaders(t *testing.T) {
 		c.Status(http.StatusInternalServerError)
 	})
 
+	observedBefore := httpRequestDuration.Count(http.MethodGet, "/probe/:id", "5xx")
 	request := httptest.NewRequest(http.MethodGet, "/probe/42?token=top-secret&password=hidden", nil)
 	request.Header.Set("Authorization", "Bearer should-not-appear")
 	request.Header.Set("Cookie", "session=should-not-appear")
@@ -56,6 +57,9 @@ func TestRequestLoggingMiddlewareOmitsQueryAndSensitiveHeaders(t *testing.T) {
 			t.Fatalf("request log missing %q: %s", expected, logged)
 		}
 	}
+	if got := httpRequestDuration.Count(http.MethodGet, "/probe/:id", "5xx") - observedBefore; got != 1 {
+		t.Fatalf("expected the request latency under its route template, got %d observations", got)
+	}
 }
 
 func TestRecoveryLoggingMiddlewareRecordsRequestAndStack(t *testing.T) {
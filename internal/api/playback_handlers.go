@@ -145,9 +145,43 @@ func syncEndedEpisodeToBangumi(bangumiID, episodeNumber int) {
 	}
 }
 
+// recordPlaybackProgress stores progress locally, then mirrors it to
+// Jellyfin when configured or marks ended episodes on Bangumi otherwise.
+// Ended episodes are also pushed to AniList when list sync is enabled.
+func recordPlaybackProgress(userID uint, input PlaybackProgressInput) (*model.PlaybackHistory, bool, error) {
+	history, episode, anime, err := persistPlaybackProgress(userID, input)
+	if err != nil {
+		return nil, false, err
+	}
+	jellyfinSynced := false
+	if jellyfinPlaybackConfigured() {
+		if err := syncPlaybackProgressToJellyfin(input, episode, anime); err != nil {
+			log.Printf("playback progress saved locally but Jellyfin sync failed: %v", err)
+		} else {
+			jellyfinSynced = true
+		}
+	} else if input.Event == playbackEventEnded && anime.Metadata != nil && anime.Metadata.BangumiID != 0 {
+		// Local-only playback has no Jellyfin MarkPlayed hook to piggyback on.
+		bangumiID := anime.Metadata.BangumiID
+		episodeNumber := episode.EpisodeNum
+		GoBackground(func(context.Context) {
+			syncEndedEpisodeToBangumi(bangumiID, episodeNumber)
+		})
+	}
+	if input.Event == playbackEventEnded && anime.Metadata != nil && anime.Metadata.AniListID != 0 && isAniListSyncUser(userID) {
+		// Jellyfin does not write to AniList, so this runs in both modes.
+		// Only the account linked to AniList updates its list.
+		localAnimeID := anime.ID
+		GoBackground(func(ctx context.Context) {
+			syncEndedEpisodeToAniList(ctx, userID, localAnimeID)
+		})
+	}
+	return history, jellyfinSynced, nil
+}
+
 // ReportProgressHandler stores progress locally before best-effort Jellyfin
 // synchronization. It serves both the new playback endpoint and the legacy
-// Jellyfin progress endpoint.
+// Jellyfin progress endpoint; without Jellyfin only the local history is kept.
 func ReportProgressHandler(c *gin.Context) {
 	var input PlaybackProgressInput
 	if err := c.ShouldBindJSON(&input); err != nil || !validPlaybackEvent(input.Event) || input.Ticks < 0 || input.DurationTicks < 0 {
@@ -159,7 +193,7 @@ func ReportProgressHandler(c *gin.Context) {
 		v1Error(c, http.StatusUnauthorized, "unauthorized", "请先登录")
 		return
 	}
-	history, episode, anime, err := persistPlaybackProgress(userID, input)
+	history, jellyfinSynced, err := recordPlaybackProgress(userID, input)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			v1Error(c, http.StatusNotFound, "episode_not_found", "未找到对应的剧集")
@@ -168,11 +202,6 @@ func ReportProgressHandler(c *gin.Context) {
 		v1Error(c, http.StatusInternalServerError, "playback_progress_failed", "保存播放进度失败")
 		return
 	}
-	jellyfinSynced := true
-	if err := syncPlaybackProgressToJellyfin(input, episode, anime); err != nil {
-		jellyfinSynced = false
-		log.Printf("playback progress saved locally but Jellyfin sync failed: %v", err)
-	}
 	v1Data(c, http.StatusOK, gin.H{
 		"position_ticks": history.PositionTicks, "duration_ticks": history.DurationTicks,
 		"completed": history.Completed, "updated_at": history.LastPlayedAt, "jellyfin_synced": jellyfinSynced,
@@ -280,10 +309,19 @@ func continueWatchingItem(userID uint, history model.PlaybackHistory) (*Continue
 		Image: image, Season: episode.SeasonNum, Episode: episode.EpisodeNum,
 		PositionTicks: history.PositionTicks, DurationTicks: history.DurationTicks,
 		ProgressPercent: progress, RemainingSeconds: remaining, UpdatedAt: history.LastPlayedAt,
-		StreamURL: fmt.Sprintf("/api/v1/jellyfin/stream/%d", episode.ID),
+		StreamURL: playbackStreamURL(episode),
 	}, nil
 }
 
+// playbackStreamURL prefers the Jellyfin proxy when it is configured and
+// falls back to the built-in direct-play stream for local-only setups.
+func playbackStreamURL(episode model.LocalEpisode) string {
+	if jellyfinPlaybackConfigured() {
+		return fmt.Sprintf("/api/v1/jellyfin/stream/%d", episode.ID)
+	}
+	return localEpisodeStreamURL(episode.ID)
+}
+
 func ContinueWatchingHandler(c *gin.Context) {
 	userID, err := currentSessionUserID(c)
 	if err != nil {
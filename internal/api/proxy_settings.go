@@ -12,6 +12,7 @@ import (
 	"github.com/pokerjest/animateAutoTool/internal/model"
 	"github.com/pokerjest/animateAutoTool/internal/parser"
 	"github.com/pokerjest/animateAutoTool/internal/safeio"
+	"github.com/pokerjest/animateAutoTool/internal/service"
 )
 
 var proxyProbeURL = "https://api.bgm.tv/calendar"
@@ -30,13 +31,7 @@ func configuredProxyURL(flagKey string) string {
 }
 
 func newConfiguredMikanParser() *parser.MikanParser {
-	client := parser.NewMikanParser()
-	if proxyURL := configuredProxyURL(model.ConfigKeyProxyMikan); proxyURL != "" {
-		if err := client.SetProxy(proxyURL); err != nil {
-			log.Printf("Failed to configure Mikan proxy: %v", err)
-		}
-	}
-	return client
+	return service.NewConfiguredMikanParser()
 }
 
 func newConfiguredJellyfinClient(baseURL, apiKey string) *jellyfin.Client {
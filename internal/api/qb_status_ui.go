@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/pokerjest/animateAutoTool/internal/config"
-	"github.com/pokerjest/animateAutoTool/internal/downloader"
 	"github.com/pokerjest/animateAutoTool/internal/qbutil"
 )
 
@@ -42,7 +41,7 @@ func evaluateQBStatus(cfg qbutil.Config) qbStatusView {
 		}
 	}
 
-	client := downloader.NewQBittorrentClient(cfg.URL)
+	client := qbutil.NewClient(cfg)
 	if err := client.Login(cfg.Username, cfg.Password); err != nil {
 		return qbStatusView{
 			Kind:    qbStatusOffline,
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -13,12 +14,9 @@ import (
 	"sync"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	awsconfig "github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/pokerjest/animateAutoTool/internal/backupdest"
 	"github.com/pokerjest/animateAutoTool/internal/db"
 	"github.com/pokerjest/animateAutoTool/internal/httpx"
 	"github.com/pokerjest/animateAutoTool/internal/model"
@@ -52,11 +50,16 @@ const (
 
 var r2ProgressJanitorOnce sync.Once
 
-// Cache for R2 Backups
+// remoteBackupPrefix is the key prefix shared by every archive the cloud
+// backup flows create.
+const remoteBackupPrefix = "animate_backup_"
+
+// Cache for the backup list of the configured destination
 var (
-	r2BackupCache     []R2BackupFile
-	r2BackupCacheTime time.Time
-	r2BackupCacheLock sync.RWMutex
+	remoteBackupCache     []R2BackupFile
+	remoteBackupCacheKind string
+	remoteBackupCacheTime time.Time
+	remoteBackupCacheLock sync.RWMutex
 )
 
 // CountingReader tracks read progress
@@ -113,47 +116,6 @@ type R2Config struct {
 	Bucket    string `json:"bucket"`
 }
 
-func getR2Client(ctx context.Context) (*s3.Client, string, error) {
-	return getR2ClientWithHTTPClient(ctx, httpx.NewHTTPClient(r2RequestTimeout))
-}
-
-func getR2ClientWithHTTPClient(ctx context.Context, httpClient *http.Client) (*s3.Client, string, error) {
-	debugLog("DEBUG: getR2Client called")
-	var endpoint, accessKey, secretKey, bucket string
-
-	endpoint = configValue(model.ConfigKeyR2Endpoint)
-	accessKey = configValue(model.ConfigKeyR2AccessKey)
-	secretKey = configValue(model.ConfigKeyR2SecretKey)
-	bucket = configValue(model.ConfigKeyR2Bucket)
-
-	debugLog("DEBUG: getR2Client Config Loaded: IsEndpointEmpty=%v, IsAccessKeyEmpty=%v, IsSecretKeyEmpty=%v, Bucket=%s",
-		endpoint == "", accessKey == "", secretKey == "", bucket)
-
-	if endpoint == "" || accessKey == "" || secretKey == "" || bucket == "" {
-		return nil, "", fmt.Errorf("R2 configuration is incomplete")
-	}
-
-	cfg, err := awsconfig.LoadDefaultConfig(ctx,
-		awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(accessKey, secretKey, "")),
-		awsconfig.WithRegion("us-east-1"), // R2 generally requires us-east-1 or auto, but S3 clients often prefer us-east-1
-		awsconfig.WithHTTPClient(httpClient),
-	)
-	if err != nil {
-		debugLog("DEBUG: getR2Client LoadDefaultConfig error: %v", err)
-		return nil, "", err
-	}
-
-	// Override endpoint resolver
-	// Cloudflare R2 endpoint format: https://<accountid>.r2.cloudflarestorage.com
-	// aws-sdk-v2 requires a custom endpoint resolver for non-AWS S3
-	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
-		o.BaseEndpoint = aws.String(endpoint)
-		o.UsePathStyle = true // R2 compatibility: PathStyle is often safer for custom endpoints
-	})
-
-	return client, bucket, nil
-}
-
 func GetR2ConfigHandler(c *gin.Context) {
 	var endpoint, accessKey, secretKey, bucket string
 
@@ -230,28 +192,33 @@ func UploadToR2Handler(c *gin.Context) {
 		jsonBadRequest(c, err.Error())
 		return
 	}
-	taskID, err := startR2UploadTask(c.Request.Context(), password)
+	taskID, err := startRemoteUploadTask(taskInitiator(c), password)
 	if err != nil {
-		jsonBadRequest(c, "R2 配置有误: "+err.Error())
+		jsonBadRequest(c, "云备份配置有误: "+err.Error())
 		return
 	}
 	c.JSON(http.StatusAccepted, gin.H{"task_id": taskID, "status": "running"})
 }
 
-// startR2UploadTask creates a full backup and uploads it without tying the
-// operation to the lifetime of the initiating HTTP request.
-func startR2UploadTask(ctx context.Context, password string) (string, error) {
-	client, bucket, err := getR2ClientWithHTTPClient(ctx, httpx.NewHTTPClient(0))
+// startRemoteUploadTask creates a full backup and uploads it to the
+// configured destination without tying the operation to the lifetime of the
+// initiating HTTP request.
+func startRemoteUploadTask(initiator taskstate.Initiator, password string) (string, error) {
+	kind := configuredBackupDestinationKind()
+	destination, err := openBackupDestination(kind, nil, httpx.NewHTTPClient(0))
 	if err != nil {
-		return "", err
+		return "", errors.New(backupDestinationErrorMessage(kind, err))
 	}
+	label := backupdest.Label(kind)
 
 	taskID := uuid.New().String()
 	ensureR2ProgressJanitor()
 	progressMap.Store(taskID, &DownloadProgress{TaskID: taskID, Status: "pending", UpdatedAt: time.Now()})
-	taskstate.Global.Start(taskID, "backup", "R2 云备份", "正在准备完整备份")
+	taskstate.Global.StartBy(initiator, taskID, "backup", label+" 云备份", "正在准备完整备份")
 
 	GoBackground(func(appCtx context.Context) {
+		ctx, cancelTask := taskstate.Global.WithCancel(appCtx, taskID)
+		defer cancelTask()
 		defer func() {
 			if recovered := recover(); recovered != nil {
 				updateProgress(taskID, "error", fmt.Sprintf("上传任务异常: %v", recovered), 0, 0, nil)
@@ -272,8 +239,8 @@ func startR2UploadTask(ctx context.Context, password string) (string, error) {
 		}
 		defer safeio.Remove(databasePath)
 
-		if err := appCtx.Err(); err != nil {
-			updateProgress(taskID, "error", err.Error(), 0, 0, nil)
+		if ctx.Err() != nil {
+			updateProgress(taskID, "error", "任务已取消", 0, 0, nil)
 			return
 		}
 		if err := service.CreateBackupFile(databasePath, service.BackupModeFull); err != nil {
@@ -299,6 +266,10 @@ func startR2UploadTask(ctx context.Context, password string) (string, error) {
 			return
 		}
 		safeio.Remove(databasePath)
+		if ctx.Err() != nil {
+			updateProgress(taskID, "error", "任务已取消", 0, 0, nil)
+			return
+		}
 
 		file, err := os.Open(filepath.Clean(archivePath)) //nolint:gosec // archivePath is created by this task.
 		if err != nil {
@@ -316,30 +287,26 @@ func startR2UploadTask(ctx context.Context, password string) (string, error) {
 		updateProgress(taskID, "uploading", "", total, 0, nil)
 		key := service.R2BackupObjectKey(service.BackupModeFull, time.Now())
 		reader := &CountingReader{Reader: file, Total: total, TaskID: taskID}
-		bgCtx, cancel := context.WithTimeout(appCtx, r2UploadTimeout)
+		bgCtx, cancel := context.WithTimeout(ctx, r2UploadTimeout)
 		defer cancel()
-		if _, err := client.PutObject(bgCtx, &s3.PutObjectInput{
-			Bucket:        aws.String(bucket),
-			Key:           aws.String(key),
-			Body:          reader,
-			ContentLength: aws.Int64(total),
-			ContentType:   aws.String("application/zip"),
-		}); err != nil {
-			updateProgress(taskID, "error", "上传备份到 R2 失败: "+err.Error(), total, 0, nil)
+		if err := destination.Upload(bgCtx, key, reader, total); err != nil {
+			message := "上传备份到 " + label + " 失败: " + backupDestinationErrorMessage(kind, err)
+			if ctx.Err() != nil {
+				message = "上传已取消"
+			}
+			updateProgress(taskID, "error", message, total, 0, nil)
 			return
 		}
 
-		r2BackupCacheLock.Lock()
-		r2BackupCache = nil
-		r2BackupCacheTime = time.Time{}
-		r2BackupCacheLock.Unlock()
-		updateProgress(taskID, r2ProgressStatusCompleted, "", total, total, gin.H{"key": key})
+		invalidateRemoteBackupCache()
+		updateProgress(taskID, r2ProgressStatusCompleted, "", total, total, gin.H{"key": key, "destination": kind})
 	})
 
 	return taskID, nil
 }
 
-// InitR2Cache starts a background goroutine to pre-fetch R2 backup list.
+// InitR2Cache pre-fetches the backup list of the configured destination in
+// the background.
 func InitR2Cache() {
 	GoBackground(func(appCtx context.Context) {
 		timer := time.NewTimer(100 * time.Millisecond)
@@ -349,130 +316,119 @@ func InitR2Cache() {
 		case <-appCtx.Done():
 			return
 		}
+		if !backupDestinationConfigured(configuredBackupDestinationKind()) {
+			return
+		}
 		debugLog("DEBUG: InitR2Cache - Starting pre-fetch...")
 
 		ctx, cancel := context.WithTimeout(appCtx, 30*time.Second)
 		defer cancel()
-
-		client, bucket, err := getR2Client(ctx)
-		if err != nil {
-			debugLog("DEBUG: InitR2Cache - Config Error: %v", err)
-			return
-		}
-
-		output, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
-			Bucket: aws.String(bucket),
-			Prefix: aws.String("animate_backup_"),
-		})
+		backups, err := listRemoteBackups(ctx, true)
 		if err != nil {
 			debugLog("DEBUG: InitR2Cache - List Error: %v", err)
 			return
 		}
-
-		var backups []R2BackupFile
-		for _, obj := range output.Contents {
-			backups = append(backups, R2BackupFile{
-				Key:          *obj.Key,
-				Size:         *obj.Size,
-				LastModified: obj.LastModified.Format("2006-01-02 15:04:05"),
-			})
-		}
-		sort.Slice(backups, func(i, j int) bool {
-			return backups[i].LastModified > backups[j].LastModified
-		})
-
-		r2BackupCacheLock.Lock()
-		r2BackupCache = backups
-		r2BackupCacheTime = time.Now()
-		r2BackupCacheLock.Unlock()
 		debugLog("DEBUG: InitR2Cache - Pre-fetch complete. Cached %d files.", len(backups))
 	})
 }
 
+// invalidateRemoteBackupCache makes the next listing fetch from the
+// destination again.
+func invalidateRemoteBackupCache() {
+	remoteBackupCacheLock.Lock()
+	remoteBackupCache = nil
+	remoteBackupCacheTime = time.Time{}
+	remoteBackupCacheLock.Unlock()
+}
+
 type R2BackupFile struct {
 	Key          string `json:"key"`
 	Size         int64  `json:"size"`
 	LastModified string `json:"last_modified"`
 }
 
-var r2FetchLock sync.Mutex
+var remoteBackupFetchLock sync.Mutex
 
-const r2BackupCacheTTL = 5 * time.Minute
+const remoteBackupCacheTTL = 5 * time.Minute
 
-// listR2Backups returns a coherent list for both the dedicated R2 endpoint
-// and the backup overview endpoint. Mutations invalidate the cache, so the
-// next overview request repopulates it instead of displaying an empty list.
-func listR2Backups(ctx context.Context, forceRefresh bool) ([]R2BackupFile, error) {
-	r2BackupCacheLock.RLock()
-	if !forceRefresh && time.Since(r2BackupCacheTime) < r2BackupCacheTTL {
-		files := append([]R2BackupFile(nil), r2BackupCache...)
-		r2BackupCacheLock.RUnlock()
-		debugLog("DEBUG: listR2Backups - Returning cached result (%d files)", len(files))
-		return files, nil
+// cachedRemoteBackups returns the cached list and when it was fetched. The
+// list is empty when it belongs to a different destination than kind.
+func cachedRemoteBackups(kind string) ([]R2BackupFile, time.Time) {
+	remoteBackupCacheLock.RLock()
+	defer remoteBackupCacheLock.RUnlock()
+	if remoteBackupCacheKind != kind {
+		return nil, time.Time{}
+	}
+	return append([]R2BackupFile(nil), remoteBackupCache...), remoteBackupCacheTime
+}
+
+// listRemoteBackups returns a coherent list for both the dedicated listing
+// endpoint and the backup overview endpoint. Mutations invalidate the cache,
+// so the next overview request repopulates it instead of displaying an empty
+// list. Switching destinations also bypasses the cache.
+func listRemoteBackups(ctx context.Context, forceRefresh bool) ([]R2BackupFile, error) {
+	kind := configuredBackupDestinationKind()
+	if !forceRefresh {
+		if files, fetchedAt := cachedRemoteBackups(kind); time.Since(fetchedAt) < remoteBackupCacheTTL {
+			debugLog("DEBUG: listRemoteBackups - Returning cached result (%d files)", len(files))
+			return files, nil
+		}
 	}
-	r2BackupCacheLock.RUnlock()
 
-	r2FetchLock.Lock()
-	defer r2FetchLock.Unlock()
+	remoteBackupFetchLock.Lock()
+	defer remoteBackupFetchLock.Unlock()
 
-	r2BackupCacheLock.RLock()
-	if !forceRefresh && time.Since(r2BackupCacheTime) < r2BackupCacheTTL {
-		files := append([]R2BackupFile(nil), r2BackupCache...)
-		r2BackupCacheLock.RUnlock()
-		debugLog("DEBUG: listR2Backups - Returning cached result after wait (%d files)", len(files))
-		return files, nil
+	if !forceRefresh {
+		if files, fetchedAt := cachedRemoteBackups(kind); time.Since(fetchedAt) < remoteBackupCacheTTL {
+			debugLog("DEBUG: listRemoteBackups - Returning cached result after wait (%d files)", len(files))
+			return files, nil
+		}
 	}
-	r2BackupCacheLock.RUnlock()
 
-	debugLog("DEBUG: listR2Backups - Fetching from R2 (Force: %v)", forceRefresh)
+	debugLog("DEBUG: listRemoteBackups - Fetching from %s (Force: %v)", kind, forceRefresh)
 	requestCtx, cancel := context.WithTimeout(ctx, r2RequestTimeout)
 	defer cancel()
 
-	client, bucket, err := getR2Client(requestCtx)
+	destination, err := openBackupDestination(kind, nil, httpx.NewHTTPClient(r2RequestTimeout))
 	if err != nil {
 		return nil, err
 	}
-
-	output, err := client.ListObjectsV2(requestCtx, &s3.ListObjectsV2Input{
-		Bucket: aws.String(bucket),
-		Prefix: aws.String("animate_backup_"),
-	})
+	objects, err := destination.List(requestCtx, remoteBackupPrefix)
 	if err != nil {
 		return nil, err
 	}
 
-	backups := make([]R2BackupFile, 0, len(output.Contents))
-	for _, obj := range output.Contents {
-		if obj.Key == nil || obj.Size == nil || obj.LastModified == nil {
-			continue
-		}
+	backups := make([]R2BackupFile, 0, len(objects))
+	for _, object := range objects {
 		backups = append(backups, R2BackupFile{
-			Key:          *obj.Key,
-			Size:         *obj.Size,
-			LastModified: obj.LastModified.Format("2006-01-02 15:04:05"),
+			Key:          object.Key,
+			Size:         object.Size,
+			LastModified: object.LastModified.Format("2006-01-02 15:04:05"),
 		})
 	}
 	sort.Slice(backups, func(i, j int) bool {
 		return backups[i].LastModified > backups[j].LastModified
 	})
 
-	r2BackupCacheLock.Lock()
-	r2BackupCache = append([]R2BackupFile(nil), backups...)
-	r2BackupCacheTime = time.Now()
-	r2BackupCacheLock.Unlock()
+	remoteBackupCacheLock.Lock()
+	remoteBackupCache = append([]R2BackupFile(nil), backups...)
+	remoteBackupCacheKind = kind
+	remoteBackupCacheTime = time.Now()
+	remoteBackupCacheLock.Unlock()
 	return backups, nil
 }
 func ListR2BackupsHandler(c *gin.Context) {
-	backups, err := listR2Backups(c.Request.Context(), c.Query("force") == ValueTrue)
+	kind := configuredBackupDestinationKind()
+	backups, err := listRemoteBackups(c.Request.Context(), c.Query("force") == ValueTrue)
 	if err != nil {
-		if strings.Contains(err.Error(), "configuration is incomplete") {
-			c.JSON(http.StatusOK, gin.H{"backups": []R2BackupFile{}, "error": "R2 配置有误: " + err.Error()})
+		if errors.Is(err, backupdest.ErrIncompleteConfig) {
+			c.JSON(http.StatusOK, gin.H{"backups": []R2BackupFile{}, "destination": kind, "error": backupDestinationErrorMessage(kind, err)})
 			return
 		}
-		jsonServerError(c, "读取 R2 备份列表", err)
+		jsonServerError(c, "读取 "+backupdest.Label(kind)+" 备份列表", err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"backups": backups})
+	c.JSON(http.StatusOK, gin.H{"backups": backups, "destination": kind})
 }
 
 func GetR2ProgressHandler(c *gin.Context) {
@@ -493,9 +449,10 @@ func StageR2BackupHandler(c *gin.Context) {
 		return
 	}
 
-	client, bucket, err := getR2ClientWithHTTPClient(c.Request.Context(), httpx.NewHTTPClient(0))
+	kind := configuredBackupDestinationKind()
+	destination, err := openBackupDestination(kind, nil, httpx.NewHTTPClient(0))
 	if err != nil {
-		jsonBadRequest(c, "R2 配置有误: "+err.Error())
+		jsonBadRequest(c, "云备份配置有误: "+backupDestinationErrorMessage(kind, err))
 		return
 	}
 
@@ -508,7 +465,7 @@ func StageR2BackupHandler(c *gin.Context) {
 		Downloaded: 0,
 		UpdatedAt:  time.Now(),
 	})
-	taskstate.Global.Start(taskID, "backup", "云备份恢复", "正在准备下载云备份")
+	taskstate.Global.StartBy(taskInitiator(c), taskID, "backup", "云备份恢复", "正在准备下载云备份")
 	passwordRequest := backupPasswordRequestFromForm(c)
 	password := passwordRequest.Password
 	if password == "" {
@@ -526,19 +483,16 @@ func StageR2BackupHandler(c *gin.Context) {
 		bgCtx, cancel := context.WithTimeout(appCtx, r2StageDownloadTimeout)
 		defer cancel()
 
-		resp, err := client.GetObject(bgCtx, &s3.GetObjectInput{
-			Bucket: aws.String(bucket),
-			Key:    aws.String(key),
-		})
+		body, size, err := destination.Download(bgCtx, key)
 		if err != nil {
-			updateProgress(taskID, "error", "Download init failed: "+err.Error(), 0, 0, nil)
+			updateProgress(taskID, "error", "Download init failed: "+backupDestinationErrorMessage(kind, err), 0, 0, nil)
 			return
 		}
-		defer safeio.Close(resp.Body)
+		defer safeio.Close(body)
 
 		total := int64(0)
-		if resp.ContentLength != nil && *resp.ContentLength > 0 {
-			total = *resp.ContentLength
+		if size > 0 {
+			total = size
 		}
 		if total > maxBackupFileBytes {
 			updateProgress(taskID, "error", errBackupFileTooLarge.Error(), total, 0, nil)
@@ -559,7 +513,7 @@ func StageR2BackupHandler(c *gin.Context) {
 			}
 		}()
 
-		reader := &CountingReader{Reader: io.LimitReader(resp.Body, maxBackupFileBytes+1), Total: total, TaskID: taskID}
+		reader := &CountingReader{Reader: io.LimitReader(body, maxBackupFileBytes+1), Total: total, TaskID: taskID}
 		written, copyErr := io.Copy(tempFile, reader)
 		if copyErr != nil {
 			safeio.Close(tempFile)
@@ -632,7 +586,7 @@ func updateProgress(taskID, status, errStr string, total, downloaded int64, resu
 			"pending":     "正在等待任务开始",
 			"preparing":   "正在创建完整备份",
 			"compressing": "正在压缩并加密备份",
-			"uploading":   "正在上传到 R2",
+			"uploading":   "正在上传到云端存储",
 			"downloading": "正在下载云备份",
 			"decrypting":  "正在解密并校验备份",
 			"analyzing":   "正在校验备份",
@@ -700,43 +654,37 @@ func DeleteR2BackupHandler(c *gin.Context) {
 	}
 
 	auditCtx := buildAuditContext(c)
-	client, bucket, err := getR2Client(c.Request.Context())
+	kind := configuredBackupDestinationKind()
+	destination, err := openBackupDestination(kind, nil, httpx.NewHTTPClient(r2RequestTimeout))
 	if err != nil {
-		jsonBadRequest(c, "R2 配置有误: "+err.Error())
+		jsonBadRequest(c, "云备份配置有误: "+backupDestinationErrorMessage(kind, err))
 		return
 	}
 
-	debugLog("DEBUG: Deleting backup Key=%s from Bucket=%s", key, bucket)
-	_, err = client.DeleteObject(c.Request.Context(), &s3.DeleteObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(key),
-	})
-	if err != nil {
-		debugLog("DEBUG: DeleteObject error: %v", err)
+	location := destination.Location(key)
+	debugLog("DEBUG: Deleting backup Key=%s from %s", key, location)
+	if err := destination.Delete(c.Request.Context(), key); err != nil {
+		debugLog("DEBUG: Delete error: %v", err)
 		service.RecordAudit(auditCtx, service.AuditEntry{
 			Action:     service.AuditActionR2BackupDelete,
 			Outcome:    service.AuditOutcomeFailure,
-			TargetType: "r2_object",
+			TargetType: kind + "_object",
 			TargetID:   key,
-			Details:    map[string]string{"bucket": bucket, "error": err.Error()},
+			Details:    map[string]string{"destination": kind, "location": location, "error": err.Error()},
 		})
-		jsonServerError(c, "删除 R2 备份", err)
+		jsonServerError(c, "删除 "+backupdest.Label(kind)+" 备份", err)
 		return
 	}
 
 	debugLog("DEBUG: Delete successful")
-	// Invalidate Cache
-	r2BackupCacheLock.Lock()
-	r2BackupCache = nil
-	r2BackupCacheTime = time.Time{}
-	r2BackupCacheLock.Unlock()
+	invalidateRemoteBackupCache()
 
 	service.RecordAudit(auditCtx, service.AuditEntry{
 		Action:     service.AuditActionR2BackupDelete,
 		Outcome:    service.AuditOutcomeSuccess,
-		TargetType: "r2_object",
+		TargetType: kind + "_object",
 		TargetID:   key,
-		Details:    map[string]string{"bucket": bucket},
+		Details:    map[string]string{"destination": kind, "location": location},
 	})
 	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
 }
@@ -749,97 +697,69 @@ func r2ConnectionTestKey() string {
 	return ".animate-auto-tool/connection-tests/" + uuid.NewString() + ".txt"
 }
 
+// BackupDestinationTestRequest checks a destination before its settings are
+// saved. Values uses the setting keys of the chosen destination; blank or
+// masked values fall back to the saved settings. The R2Config fields are the
+// original R2-only form and are still accepted.
+type BackupDestinationTestRequest struct {
+	R2Config
+	Destination string            `json:"destination"`
+	Values      map[string]string `json:"values"`
+}
+
 func TestR2ConnectionHandler(c *gin.Context) {
 	debugLog("DEBUG: TestR2ConnectionHandler called")
-	var req R2Config
+	var req BackupDestinationTestRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		debugLog("DEBUG: BindJSON error: %v", err)
-		jsonBadRequest(c, "R2 连通性测试请求格式不正确: "+err.Error())
-		return
-	}
-	if strings.TrimSpace(req.Endpoint) == "" {
-		req.Endpoint = configValue(model.ConfigKeyR2Endpoint)
-	}
-	if strings.TrimSpace(req.Bucket) == "" {
-		req.Bucket = configValue(model.ConfigKeyR2Bucket)
-	}
-	if strings.TrimSpace(req.AccessKey) == "" || isMasked(req.AccessKey) {
-		req.AccessKey = configValue(model.ConfigKeyR2AccessKey)
-	}
-	debugLog("DEBUG: Testing R2 Config: Endpoint=%s, Bucket=%s, AccessKey=%s", req.Endpoint, req.Bucket, maskSecret(req.AccessKey))
-
-	// Validate inputs
-	if req.Endpoint == "" || req.AccessKey == "" || req.Bucket == "" {
-		debugLog("DEBUG: Validation failed - missing fields")
-		jsonBadRequest(c, "Endpoint、AccessKey 和 Bucket 都是必填项")
+		jsonBadRequest(c, "云备份连通性测试请求格式不正确: "+err.Error())
 		return
 	}
-
-	// For secret key, if it's masked or empty, try to load from DB
-	secretKey := req.SecretKey
-	if secretKey == "" || isMasked(secretKey) {
-		secretKey = configValue(model.ConfigKeyR2SecretKey)
-		if secretKey == "" {
-			debugLog("DEBUG: Secret Key missing from DB")
-			jsonBadRequest(c, "缺少 Secret Key")
+	legacyR2 := req.Endpoint != "" || req.AccessKey != "" || req.SecretKey != "" || req.Bucket != ""
+	kind := configuredBackupDestinationKind()
+	switch {
+	case strings.TrimSpace(req.Destination) != "":
+		if !backupdest.IsKnownKind(req.Destination) {
+			jsonBadRequest(c, "云备份存储只支持 r2、s3、webdav 或 sftp")
 			return
 		}
+		kind = backupdest.NormalizeKind(req.Destination)
+	case legacyR2:
+		kind = backupdest.KindR2
 	}
 
-	// Create temporary client
-	cfg, err := awsconfig.LoadDefaultConfig(c.Request.Context(),
-		awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(req.AccessKey, secretKey, "")),
-		awsconfig.WithRegion("us-east-1"),
-		awsconfig.WithHTTPClient(httpx.NewHTTPClient(r2RequestTimeout)),
-	)
-	if err != nil {
-		debugLog("DEBUG: LoadDefaultConfig error: %v", err)
-		jsonServerError(c, "加载 R2 配置", err)
-		return
+	overrides := map[string]string{}
+	for _, key := range backupDestinationSettingKeys[kind] {
+		overrides[key] = req.Values[key]
 	}
-
-	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
-		o.BaseEndpoint = aws.String(req.Endpoint)
-		o.UsePathStyle = true
-	})
-
-	// Try ListObject (limit 1) to verify Read permission
-	_, err = client.ListObjectsV2(c.Request.Context(), &s3.ListObjectsV2Input{
-		Bucket:  aws.String(req.Bucket),
-		MaxKeys: aws.Int32(1), // Minimize data transfer
-	})
-
-	if err != nil {
-		debugLog("DEBUG: ListObjectsV2 error: %v", err)
-		jsonBadRequest(c, "读取校验失败: "+err.Error())
-		return
+	if kind == backupdest.KindR2 && legacyR2 {
+		overrides[model.ConfigKeyR2Endpoint] = req.Endpoint
+		overrides[model.ConfigKeyR2AccessKey] = req.AccessKey
+		overrides[model.ConfigKeyR2SecretKey] = req.SecretKey
+		overrides[model.ConfigKeyR2Bucket] = req.Bucket
 	}
 
-	// Use an isolated random key so the connection check can never overwrite a user object.
-	testKey := r2ConnectionTestKey()
-	testContent := "ok"
-	_, err = client.PutObject(c.Request.Context(), &s3.PutObjectInput{
-		Bucket: aws.String(req.Bucket),
-		Key:    aws.String(testKey),
-		Body:   strings.NewReader(testContent),
-	})
+	destination, err := openBackupDestination(kind, overrides, httpx.NewHTTPClient(r2RequestTimeout))
 	if err != nil {
-		debugLog("DEBUG: PutObject error: %v", err)
-		jsonBadRequest(c, "写入校验失败: "+err.Error())
+		debugLog("DEBUG: Destination config error: %v", err)
+		jsonBadRequest(c, backupDestinationErrorMessage(kind, err))
 		return
 	}
+	debugLog("DEBUG: Testing backup destination %s at %s", kind, destination.Location(""))
 
-	// Clean up (Delete Check)
-	_, err = client.DeleteObject(c.Request.Context(), &s3.DeleteObjectInput{
-		Bucket: aws.String(req.Bucket),
-		Key:    aws.String(testKey),
-	})
-	if err != nil {
-		debugLog("DEBUG: DeleteObject error: %v", err)
-		jsonBadRequest(c, "删除校验失败: "+err.Error())
+	// Use an isolated random key so the connection check can never overwrite a user object.
+	if err := backupdest.Check(c.Request.Context(), destination, r2ConnectionTestKey()); err != nil {
+		debugLog("DEBUG: Connection check error: %v", err)
+		step := map[string]string{"list": "读取校验失败: ", "write": "写入校验失败: ", "delete": "删除校验失败: "}
+		message := backupDestinationErrorMessage(kind, err)
+		var checkErr *backupdest.CheckError
+		if errors.As(err, &checkErr) {
+			message = step[checkErr.Step] + backupDestinationErrorMessage(kind, checkErr.Err)
+		}
+		jsonBadRequest(c, message)
 		return
 	}
 
 	debugLog("DEBUG: Connection successful (Read/Write/Delete verified)")
-	c.JSON(http.StatusOK, gin.H{"status": "ok", "message": "连接成功（读写校验通过）"})
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "destination": kind, "message": backupdest.Label(kind) + " 连接成功（读写校验通过）"})
 }
@@ -61,7 +61,8 @@ func LocalResetAdminPasswordHandler(c *gin.Context) {
 		IP:        requestClientIP(c),
 		UserAgent: c.Request.UserAgent(),
 	}
-	if err := authService.ResetPasswordByUsername(req.Username, req.Password); err != nil {
+	twoFactorCleared, err := authService.ResetPasswordByUsername(req.Username, req.Password)
+	if err != nil {
 		service.RecordAudit(auditCtx, service.AuditEntry{
 			Action:  service.AuditActionPasswordRecoveryLoc,
 			Outcome: service.AuditOutcomeFailure,
@@ -75,6 +76,7 @@ func LocalResetAdminPasswordHandler(c *gin.Context) {
 	service.RecordAudit(auditCtx, service.AuditEntry{
 		Action:  service.AuditActionPasswordRecoveryLoc,
 		Outcome: service.AuditOutcomeSuccess,
+		Details: map[string]bool{"two_factor_cleared": twoFactorCleared},
 	})
 
 	c.JSON(http.StatusOK, gin.H{
@@ -1,6 +1,9 @@
 package api
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+)
 
 // initLegacyAPICompat keeps the pre-v1 API available throughout the 1.x
 // compatibility window. The handlers are shared with the current application;
@@ -18,7 +21,9 @@ func initLegacyAPICompatProduction(r *gin.Engine) {
 	recovery.POST("/api/recovery/reset-admin", LocalResetAdminPasswordHandler)
 
 	authorized := r.Group("/")
-	authorized.Use(deprecated, AuthMiddleware(), SameOriginMiddleware())
+	// The pre-v1 API predates roles and mixes settings with everyday actions,
+	// so only admins may use it.
+	authorized.Use(deprecated, AuthMiddleware(), SameOriginMiddleware(), RequireRoleMiddleware(model.UserRoleAdmin))
 	{
 		authorized.POST("/api/change-password", ChangePasswordHandler)
 		authorized.POST("/api/change-username", ChangeUsernameHandler)
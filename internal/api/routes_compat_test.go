@@ -5,6 +5,7 @@ import (
 	"html/template"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pokerjest/animateAutoTool/internal/model"
 )
 
 func renderTemplateToString(name string, data interface{}) (string, error) {
@@ -44,7 +45,7 @@ func initLegacyAPICompat(r *gin.Engine) {
 	recovery.POST("/api/recovery/reset-admin", LocalResetAdminPasswordHandler)
 
 	authorized := r.Group("/")
-	authorized.Use(deprecated, AuthMiddleware(), SameOriginMiddleware())
+	authorized.Use(deprecated, AuthMiddleware(), SameOriginMiddleware(), RequireRoleMiddleware(model.UserRoleAdmin))
 	{
 		authorized.POST("/api/change-password", ChangePasswordHandler)
 		authorized.POST("/api/change-username", ChangeUsernameHandler)
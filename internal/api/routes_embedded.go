@@ -51,6 +51,7 @@ func InitRoutes(r *gin.Engine) {
 	// the SPA has completed session discovery.
 	r.GET("/api/tmdb/image", ProxyTMDBImageHandler)
 	r.GET("/healthz/ready", DirectLocalOnlyMiddleware(), ReadinessHandler)
+	r.GET("/metrics", MetricsHandler)
 	initV1Routes(r)
 	if gin.Mode() != gin.TestMode {
 		initLegacyAPICompatProduction(r)
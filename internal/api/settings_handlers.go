@@ -11,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/downloader"
 	"github.com/pokerjest/animateAutoTool/internal/model"
 	"github.com/pokerjest/animateAutoTool/internal/qbutil"
 	"github.com/pokerjest/animateAutoTool/internal/store"
@@ -86,8 +87,14 @@ func normalizedQBValues(mode, url, username, password string) map[string]string
 }
 
 func normalizedQBFormValues(c *gin.Context) map[string]string {
+	mode := c.PostForm(model.ConfigKeyQBMode)
+	// Only qBittorrent ships a managed instance, so other backends always need
+	// the external WebUI/RPC address that the managed mode would clear.
+	if downloader.NormalizeBackend(c.PostForm(model.ConfigKeyDownloaderBackend)) != downloader.BackendQBittorrent {
+		mode = qbutil.ModeExternal
+	}
 	return normalizedQBValues(
-		c.PostForm(model.ConfigKeyQBMode),
+		mode,
 		c.PostForm(model.ConfigKeyQBUrl),
 		c.PostForm(model.ConfigKeyQBUsername),
 		c.PostForm(model.ConfigKeyQBPassword),
@@ -97,6 +104,7 @@ func normalizedQBFormValues(c *gin.Context) map[string]string {
 func qbConfigFromForm(c *gin.Context) qbutil.Config {
 	values := normalizedQBFormValues(c)
 	cfg := qbutil.Config{
+		Backend:  downloader.NormalizeBackend(c.PostForm(model.ConfigKeyDownloaderBackend)),
 		Mode:     values[model.ConfigKeyQBMode],
 		URL:      values[model.ConfigKeyQBUrl],
 		Username: values[model.ConfigKeyQBUsername],
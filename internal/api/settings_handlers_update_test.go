@@ -9,6 +9,7 @@ import (
 
 	"github.com/pokerjest/animateAutoTool/internal/db"
 	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/store"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -35,12 +36,11 @@ func TestUpdateSettingsMediaScopeSavesJellyfinAPIKey(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	var apiKeyCfg model.GlobalConfig
-	err := db.DB.Where("key = ?", model.ConfigKeyJellyfinApiKey).First(&apiKeyCfg).Error
+	apiKey, err := store.NewConfigStore(db.DB).Get(model.ConfigKeyJellyfinApiKey)
 	if err != nil {
 		t.Fatalf("expected jellyfin api key to be persisted, got error: %v", err)
 	}
-	assert.Equal(t, "test-api-key-123", apiKeyCfg.Value)
+	assert.Equal(t, "test-api-key-123", apiKey)
 }
 
 func TestUpdateSettingsMediaScopeNormalizesJellyfinURLs(t *testing.T) {
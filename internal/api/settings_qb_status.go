@@ -8,6 +8,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/pokerjest/animateAutoTool/internal/config"
+	"github.com/pokerjest/animateAutoTool/internal/downloader"
 	"github.com/pokerjest/animateAutoTool/internal/model"
 	"github.com/pokerjest/animateAutoTool/internal/qbutil"
 )
@@ -16,11 +17,12 @@ import (
 func QBSaveAndTestHandler(c *gin.Context) {
 	qbValues := normalizedQBFormValues(c)
 	if err := persistGlobalConfigs(map[string]string{
-		model.ConfigKeyQBMode:     qbValues[model.ConfigKeyQBMode],
-		model.ConfigKeyQBUrl:      qbValues[model.ConfigKeyQBUrl],
-		model.ConfigKeyQBUsername: qbValues[model.ConfigKeyQBUsername],
-		model.ConfigKeyQBPassword: qbValues[model.ConfigKeyQBPassword],
-		model.ConfigKeyBaseDir:    strings.TrimSpace(c.PostForm(model.ConfigKeyBaseDir)),
+		model.ConfigKeyDownloaderBackend: downloader.NormalizeBackend(c.PostForm(model.ConfigKeyDownloaderBackend)),
+		model.ConfigKeyQBMode:            qbValues[model.ConfigKeyQBMode],
+		model.ConfigKeyQBUrl:             qbValues[model.ConfigKeyQBUrl],
+		model.ConfigKeyQBUsername:        qbValues[model.ConfigKeyQBUsername],
+		model.ConfigKeyQBPassword:        qbValues[model.ConfigKeyQBPassword],
+		model.ConfigKeyBaseDir:           strings.TrimSpace(c.PostForm(model.ConfigKeyBaseDir)),
 	}); err != nil {
 		c.String(http.StatusInternalServerError, renderSettingsSaveError(fmt.Sprintf("保存 qB 配置失败: %v", err)))
 		return
@@ -59,7 +61,7 @@ func GetQBStatusHandler(c *gin.Context) {
 		return
 	}
 
-	probe := newConnectionProbe("qb", qbCfg.Mode, qbCfg.URL, qbCfg.Username, qbCfg.Password)
+	probe := newConnectionProbe("qb", qbCfg.Backend, qbCfg.Mode, qbCfg.URL, qbCfg.Username, qbCfg.Password)
 	if stat, ok := probe.load(); ok {
 		if stat.Success {
 			c.String(http.StatusOK, fmt.Sprintf(`<div class="text-emerald-600 bg-emerald-50 px-3 py-2 rounded-lg text-sm font-medium flex items-center gap-2 border border-emerald-200">qB 已连接（缓存）%s</div>`, stat.Msg))
@@ -69,7 +71,14 @@ func GetQBStatusHandler(c *gin.Context) {
 		return
 	}
 
-	status := evaluateQBStatus(qbCfg)
+	storeQBStatusProbe(probe, evaluateQBStatus(qbCfg))
+
+	c.String(http.StatusOK, renderQBStatusMessage(qbCfg))
+}
+
+// storeQBStatusProbe caches a live downloader check and reports whether it
+// connected.
+func storeQBStatusProbe(probe connectionProbe, status qbStatusView) bool {
 	switch status.Kind {
 	case qbStatusConnected:
 		probe.store(true, fmt.Sprintf("(version: %s)", status.Version), "", 5*time.Minute)
@@ -78,6 +87,5 @@ func GetQBStatusHandler(c *gin.Context) {
 	case qbStatusOffline:
 		probe.store(false, strings.TrimPrefix(status.Message, "qB 连接失败: "), "", 1*time.Minute)
 	}
-
-	c.String(http.StatusOK, renderQBStatusMessage(qbCfg))
+	return status.Kind == qbStatusConnected
 }
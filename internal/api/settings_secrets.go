@@ -0,0 +1,43 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+)
+
+func V1SettingsSecretsHandler(c *gin.Context) {
+	status, err := service.LoadSettingsSecretsStatus()
+	if err != nil {
+		log.Printf("ERROR: SettingsSecrets: status failed error=%v", err)
+		v1Error(c, http.StatusInternalServerError, "settings_secrets_failed", "读取凭据加密状态失败")
+		return
+	}
+	v1Data(c, http.StatusOK, status)
+}
+
+func V1RotateSettingsKeyHandler(c *gin.Context) {
+	result, err := service.RotateSettingsKey()
+	if err != nil {
+		service.RecordAudit(buildAuditContext(c), service.AuditEntry{
+			Action:  service.AuditActionSettingsKeyRotate,
+			Outcome: service.AuditOutcomeFailure,
+			Details: map[string]any{"error": err.Error()},
+		})
+		v1Error(c, http.StatusInternalServerError, "settings_key_rotation_failed", "轮换凭据加密密钥失败，原有密钥仍然保留")
+		return
+	}
+	service.RecordAudit(buildAuditContext(c), service.AuditEntry{
+		Action:  service.AuditActionSettingsKeyRotate,
+		Outcome: service.AuditOutcomeSuccess,
+		Details: map[string]any{
+			"previous_key_id": result.PreviousKeyID,
+			"key_id":          result.KeyID,
+			"resealed":        result.Resealed,
+			"unreadable_keys": result.UnreadableKeys,
+		},
+	})
+	v1Message(c, http.StatusOK, "凭据加密密钥已轮换", result)
+}
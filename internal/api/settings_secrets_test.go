@@ -0,0 +1,51 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestV1SettingsSecretsRotateReencryptsAndAudits(t *testing.T) {
+	resetAuthFixtures(t)
+	r := setupRouter()
+	cookie, _ := loginCookie(t, r, "admin")
+	require.NoError(t, db.SaveGlobalConfig(model.ConfigKeyTMDBToken, "tmdb-before-rotation"))
+
+	w := serveAs(r, cookie, http.MethodGet, "/api/v1/settings/secrets", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NotContains(t, w.Body.String(), "tmdb-before-rotation")
+	var status struct {
+		Data service.SettingsSecretsStatus `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	require.NotEmpty(t, status.Data.KeyID)
+
+	w = serveAs(r, cookie, http.MethodPost, "/api/v1/settings/secrets/rotate", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var rotated struct {
+		Data service.SettingsKeyRotation `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rotated))
+	assert.Equal(t, status.Data.KeyID, rotated.Data.PreviousKeyID)
+	assert.NotEqual(t, status.Data.KeyID, rotated.Data.KeyID)
+
+	var row model.GlobalConfig
+	require.NoError(t, db.DB.First(&row, "key = ?", model.ConfigKeyTMDBToken).Error)
+	assert.True(t, strings.Contains(row.Value, ":"+rotated.Data.KeyID+":"), row.Value)
+	token, err := store.NewConfigStore(db.DB).Get(model.ConfigKeyTMDBToken)
+	require.NoError(t, err)
+	assert.Equal(t, "tmdb-before-rotation", token)
+
+	var audits int64
+	require.NoError(t, db.DB.Model(&model.AuditLog{}).Where("action = ?", service.AuditActionSettingsKeyRotate).Count(&audits).Error)
+	assert.GreaterOrEqual(t, audits, int64(1))
+}
@@ -34,17 +34,23 @@ func resolveSettingsScopeSpec(scope string) settingsScopeSpec {
 		return settingsScopeSpec{
 			keys: []string{
 				model.ConfigKeyQBMode,
+				model.ConfigKeyDownloaderBackend,
 				model.ConfigKeyQBUrl,
 				model.ConfigKeyQBUsername,
 				model.ConfigKeyQBPassword,
+				model.ConfigKeyDownloaderPathMappings,
 				model.ConfigKeyBaseDir,
 				model.ConfigKeyAutoRenameEnabled,
 				model.ConfigKeyMediaNamingPreset,
 				model.ConfigKeyAutoRenameSeriesTemplate,
 				model.ConfigKeyAutoRenameEpisodeTemplate,
 				model.ConfigKeyIncrementalScanEnabled,
+				model.ConfigKeyMediaProbeEnabled,
 				model.ConfigKeyWriteNFOEnabled,
 				model.ConfigKeyWriteImagesEnabled,
+				model.ConfigKeySchedulerIntervalMinutes,
+				model.ConfigKeySchedulerQuietHours,
+				model.ConfigKeySchedulerSmartPolling,
 			},
 		}
 	case "data-sources":
@@ -71,11 +77,13 @@ func resolveSettingsScopeSpec(scope string) settingsScopeSpec {
 			checkboxes: []string{
 				model.ConfigKeyProxyBangumi,
 				model.ConfigKeyProxyMikan,
+				model.ConfigKeyProxyRSS,
 				model.ConfigKeyProxyTMDB,
 				model.ConfigKeyProxyAniList,
 				model.ConfigKeyProxyJellyfin,
 				model.ConfigKeyProxyAI,
 				model.ConfigKeyProxyUpdater,
+				model.ConfigKeyProxyNotify,
 				model.ConfigKeyRepoUpdateEnabled,
 				model.ConfigKeyRepoAutoPullEnabled,
 				model.ConfigKeyRepoRequireChecksum,
@@ -105,17 +113,23 @@ func resolveSettingsScopeSpec(scope string) settingsScopeSpec {
 		return settingsScopeSpec{
 			keys: []string{
 				model.ConfigKeyQBMode,
+				model.ConfigKeyDownloaderBackend,
 				model.ConfigKeyQBUrl,
 				model.ConfigKeyQBUsername,
 				model.ConfigKeyQBPassword,
+				model.ConfigKeyDownloaderPathMappings,
 				model.ConfigKeyBaseDir,
 				model.ConfigKeyAutoRenameEnabled,
 				model.ConfigKeyMediaNamingPreset,
 				model.ConfigKeyAutoRenameSeriesTemplate,
 				model.ConfigKeyAutoRenameEpisodeTemplate,
 				model.ConfigKeyIncrementalScanEnabled,
+				model.ConfigKeyMediaProbeEnabled,
 				model.ConfigKeyWriteNFOEnabled,
 				model.ConfigKeyWriteImagesEnabled,
+				model.ConfigKeySchedulerIntervalMinutes,
+				model.ConfigKeySchedulerQuietHours,
+				model.ConfigKeySchedulerSmartPolling,
 				model.ConfigKeyBangumiRefreshToken,
 				model.ConfigKeyBangumiAccessToken,
 				model.ConfigKeyTMDBToken,
@@ -125,10 +139,12 @@ func resolveSettingsScopeSpec(scope string) settingsScopeSpec {
 				model.ConfigKeyProxyURL,
 				model.ConfigKeyProxyBangumi,
 				model.ConfigKeyProxyMikan,
+				model.ConfigKeyProxyRSS,
 				model.ConfigKeyProxyTMDB,
 				model.ConfigKeyProxyAniList,
 				model.ConfigKeyProxyAI,
 				model.ConfigKeyProxyUpdater,
+				model.ConfigKeyProxyNotify,
 				model.ConfigKeyRepoUpdateEnabled,
 				model.ConfigKeyRepoAutoPullEnabled,
 				model.ConfigKeyRepoUpdateIntervalMinutes,
@@ -150,11 +166,13 @@ func resolveSettingsScopeSpec(scope string) settingsScopeSpec {
 			checkboxes: []string{
 				model.ConfigKeyProxyBangumi,
 				model.ConfigKeyProxyMikan,
+				model.ConfigKeyProxyRSS,
 				model.ConfigKeyProxyTMDB,
 				model.ConfigKeyProxyAniList,
 				model.ConfigKeyProxyJellyfin,
 				model.ConfigKeyProxyAI,
 				model.ConfigKeyProxyUpdater,
+				model.ConfigKeyProxyNotify,
 				model.ConfigKeyRepoUpdateEnabled,
 				model.ConfigKeyRepoAutoPullEnabled,
 				model.ConfigKeyRepoRequireChecksum,
@@ -191,6 +209,13 @@ func persistSettingsScope(c *gin.Context, scope string) error {
 				}
 				val = normalized
 			}
+			if key == model.ConfigKeySchedulerIntervalMinutes || key == model.ConfigKeySchedulerQuietHours || key == model.ConfigKeySchedulerSmartPolling {
+				normalized, err := v1SettingNormalizers[key].normalize(val)
+				if err != nil {
+					return err
+				}
+				val = normalized
+			}
 			if err := persistGlobalConfig(key, val); err != nil {
 				return err
 			}
@@ -344,6 +369,7 @@ func loadSettingsViewData() (map[string]string, string, any) {
 
 	qbCfg := qbutil.LoadConfig()
 	configMap[model.ConfigKeyQBMode] = qbCfg.Mode
+	configMap[model.ConfigKeyDownloaderBackend] = qbCfg.Backend
 	if qbutil.UsesManagedInstance(qbCfg) {
 		configMap[model.ConfigKeyQBUrl] = ""
 		configMap[model.ConfigKeyQBUsername] = ""
@@ -367,7 +393,7 @@ func loadSettingsViewData() (map[string]string, string, any) {
 	if strings.TrimSpace(configMap[model.ConfigKeyMetadataOverwritePolicy]) == "" {
 		configMap[model.ConfigKeyMetadataOverwritePolicy] = metadataOverwriteFieldLayered
 	}
-	for _, key := range []string{model.ConfigKeyIncrementalScanEnabled, model.ConfigKeyWriteNFOEnabled, model.ConfigKeyWriteImagesEnabled} {
+	for _, key := range []string{model.ConfigKeyIncrementalScanEnabled, model.ConfigKeyMediaProbeEnabled, model.ConfigKeyWriteNFOEnabled, model.ConfigKeyWriteImagesEnabled, model.ConfigKeySchedulerSmartPolling} {
 		if strings.TrimSpace(configMap[key]) == "" {
 			configMap[key] = model.ConfigValueTrue
 		}
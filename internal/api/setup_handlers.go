@@ -22,6 +22,7 @@ import (
 type BootstrapSetupRequest struct {
 	NewPassword string `json:"new_password" binding:"required"`
 	Confirm     string `json:"confirm_password" binding:"required"`
+	Backend     string `json:"downloader_backend"`
 	QBMode      string `json:"qb_mode"`
 	QBURL       string `json:"qb_url"`
 	QBUsername  string `json:"qb_username"`
@@ -128,9 +129,14 @@ func CompleteBootstrapSetupHandler(c *gin.Context) {
 		return
 	}
 
-	qbValues := normalizedQBValues(req.QBMode, req.QBURL, req.QBUsername, req.QBPassword)
+	backend := downloader.NormalizeBackend(req.Backend)
+	qbMode := req.QBMode
+	if backend != downloader.BackendQBittorrent {
+		qbMode = qbutil.ModeExternal
+	}
+	qbValues := normalizedQBValues(qbMode, req.QBURL, req.QBUsername, req.QBPassword)
 	if qbValues[model.ConfigKeyQBMode] == qbutil.ModeExternal && qbValues[model.ConfigKeyQBUrl] == "" {
-		jsonBadRequest(c, "外部 qBittorrent 模式需要填写 WebUI 地址")
+		jsonBadRequest(c, "外部下载器需要填写 WebUI / RPC 地址")
 		return
 	}
 
@@ -141,11 +147,12 @@ func CompleteBootstrapSetupHandler(c *gin.Context) {
 	}
 
 	if err := persistGlobalConfigs(map[string]string{
-		model.ConfigKeyQBMode:     qbValues[model.ConfigKeyQBMode],
-		model.ConfigKeyQBUrl:      qbValues[model.ConfigKeyQBUrl],
-		model.ConfigKeyQBUsername: qbValues[model.ConfigKeyQBUsername],
-		model.ConfigKeyQBPassword: qbValues[model.ConfigKeyQBPassword],
-		model.ConfigKeyBaseDir:    strings.TrimSpace(req.BaseDir),
+		model.ConfigKeyDownloaderBackend: backend,
+		model.ConfigKeyQBMode:            qbValues[model.ConfigKeyQBMode],
+		model.ConfigKeyQBUrl:             qbValues[model.ConfigKeyQBUrl],
+		model.ConfigKeyQBUsername:        qbValues[model.ConfigKeyQBUsername],
+		model.ConfigKeyQBPassword:        qbValues[model.ConfigKeyQBPassword],
+		model.ConfigKeyBaseDir:           strings.TrimSpace(req.BaseDir),
 	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("保存初始化下载配置失败: %v", err)})
 		return
@@ -157,6 +164,7 @@ func CompleteBootstrapSetupHandler(c *gin.Context) {
 		Action:  service.AuditActionBootstrapComplete,
 		Outcome: service.AuditOutcomeSuccess,
 		Details: map[string]string{
+			"backend":  backend,
 			"qb_mode":  qbValues[model.ConfigKeyQBMode],
 			"base_dir": strings.TrimSpace(req.BaseDir),
 		},
@@ -241,7 +249,7 @@ func buildQBReadinessStatus() SetupReadinessStatus {
 		}
 	}
 
-	client := downloader.NewQBittorrentClient(cfg.URL)
+	client := qbutil.NewClient(cfg)
 	if err := client.Login(cfg.Username, cfg.Password); err != nil {
 		return SetupReadinessStatus{
 			Key:      "qb",
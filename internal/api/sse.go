@@ -3,97 +3,187 @@ package api
 import (
 	"encoding/json"
 	"log"
+	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
-	"github.com/pokerjest/animateAutoTool/internal/event"
+	"github.com/pokerjest/animateAutoTool/internal/eventlog"
+)
+
+const (
+	// sseResyncEvent 通知客户端游标之后的部分事件已经丢失，需要重新拉取完整状态。
+	sseResyncEvent = "resync"
+	// sseReplayPageSize 是补发事件时每次从事件日志读取的条数。
+	sseReplayPageSize = 200
+
+	defaultEventHistoryLimit = 100
 )
 
 // SSEHandler 处理 Server-Sent Events 连接
+// 事件来自 eventlog 事件日志，每条都带 id。浏览器断线重连时会自动带上 Last-Event-ID，
+// 服务端据此补发错过的事件。
 func SSEHandler(c *gin.Context) {
+	eventlog.Start()
+	journal := eventlog.Default()
+
 	// 1. 设置 Header
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
 	c.Header("Transfer-Encoding", "chunked")
 
-	// 2. 创建一个 Channel 来接收事件
-	// 注意：EventBus 是多播的，我们需要一个由 EventBus 驱动的临时 Bridge
-	// 这里为了简单，我们让 GlobalBus 支持 SubscribeChannel 或者我们在内部搞一个转发
-	// 但我们的 InMemoryBus 目前是 callback 模式。
-	// 我们可以创建一个 Callback，将收到的消息写入 clientChan
+	// 2. 先监听新事件再确定游标，避免两步之间写入的事件被漏掉
+	wake, stopWatching := journal.Watch()
+	defer func() {
+		stopWatching()
+		log.Println("SSE Client disconnected")
+	}()
+
+	cursor, resume := sseResumeCursor(c)
+	if !resume {
+		cursor = journal.Latest()
+	}
 
-	clientChan := make(chan event.Event, 10)
-	done := make(chan struct{})
+	// 3. 发送初始连接成功消息 (可选)
+	c.SSEvent("message", "connected")
+	c.Writer.Flush()
 
-	// 定义一个闭包 Handler，用于转发消息
-	bridgeHandler := func(e event.Event) {
-		select {
-		case <-done:
-			return
-		default:
-		}
+	// 4. 补发 Last-Event-ID 之后的事件
+	if resume && !streamJournal(c, journal, &cursor) {
+		return
+	}
 
-		// 非阻塞发送，避免慢客户端阻塞总线
+	// 5. 循环推送
+	// 事件日志有新事件时读取 cursor 之后的事件，慢客户端不会丢事件，只会晚一些收到
+	for {
 		select {
-		case clientChan <- e:
-		case <-done:
-		default:
-			// Client channel full, drop message or log?
+		case <-wake:
+			if !streamJournal(c, journal, &cursor) {
+				return
+			}
+		case <-c.Request.Context().Done():
+			return
 		}
 	}
+}
 
-	// 3. 订阅感兴趣的事件
-	// 目前订阅所有核心事件
-	topics := []event.EventType{
-		event.EventScanProgress,
-		event.EventScanComplete,
-		event.EventMetadataUpdated,
-		event.EventDownloadProgress,
-		event.EventDownloadReady,
-		event.EventLibraryIssue,
-		event.EventScanRun,
-		event.EventSubscriptionRun,
-		event.EventSchedulerRun,
-		event.EventTaskUpdate,
+// sseResumeCursor 读取客户端上次收到的事件 ID。浏览器重连时通过 Last-Event-ID 请求头发送，
+// 无法设置请求头的客户端可以使用 last_event_id 查询参数。
+func sseResumeCursor(c *gin.Context) (uint64, bool) {
+	raw := strings.TrimSpace(c.GetHeader("Last-Event-ID"))
+	if raw == "" {
+		raw = strings.TrimSpace(c.Query("last_event_id"))
 	}
-
-	var subIDs = make(map[event.EventType]string)
-
-	for _, t := range topics {
-		id := event.GlobalBus.Subscribe(t, bridgeHandler)
-		subIDs[t] = id
+	if raw == "" {
+		return 0, false
 	}
+	cursor, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return cursor, true
+}
 
-	// 4. 发送初始连接成功消息 (可选)
-	c.SSEvent("message", "connected")
-	c.Writer.Flush()
-
-	// 5. 循环推送
-	// 监听 clientChan 和 Context.Done (客户端断开)
-	defer func() {
-		// 清理 Subscription
-		for t, id := range subIDs {
-			event.GlobalBus.Unsubscribe(t, id)
-		}
-		close(done)
-		log.Println("SSE Client disconnected")
-	}()
-
+// streamJournal 推送 cursor 之后的全部事件并前移 cursor。游标之后的事件已经丢失时先发送 resync。
+// 返回 false 表示事件日志不可读，连接应当结束，客户端会带着最后的 ID 重连。
+func streamJournal(c *gin.Context, journal *eventlog.Journal, cursor *uint64) bool {
 	for {
-		select {
-		case evt := <-clientChan:
-			// 序列化 Payload
-			data, err := json.Marshal(evt.Payload)
-			if err != nil {
-				log.Printf("SSE JSON Marshal error: %v", err)
-				continue
-			}
-			// 发送事件，事件名即为 Topic
-			c.SSEvent(string(evt.Type), string(data))
+		page, err := journal.Since(*cursor, sseReplayPageSize, nil)
+		if err != nil {
+			log.Printf("WARN: SSE event replay failed cursor=%d error=%v", *cursor, err)
+			return false
+		}
+		if page.Truncated {
+			data, _ := json.Marshal(gin.H{"latest_id": page.Latest})
+			c.Render(-1, sse.Event{Id: strconv.FormatUint(page.Latest, 10), Event: sseResyncEvent, Data: string(data)})
+			c.Writer.Flush()
+			*cursor = page.Latest
+			continue
+		}
+		for _, entry := range page.Items {
+			c.Render(-1, sse.Event{Id: strconv.FormatUint(entry.ID, 10), Event: entry.Type, Data: string(entry.Data)})
+			*cursor = entry.ID
+		}
+		if len(page.Items) > 0 {
 			c.Writer.Flush()
+		}
+		if !page.HasMore {
+			return true
+		}
+	}
+}
 
-		case <-c.Request.Context().Done():
+// V1EventHistoryHandler 按游标返回事件日志，供不保持 SSE 连接的客户端轮询。
+func V1EventHistoryHandler(c *gin.Context) {
+	var after uint64
+	if raw := strings.TrimSpace(c.Query("after")); raw != "" {
+		value, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			v1Error(c, http.StatusBadRequest, "invalid_cursor", "after 必须是非负整数")
 			return
 		}
+		after = value
+	}
+	limit := defaultEventHistoryLimit
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 || value > eventlog.MaxPageSize {
+			v1Error(c, http.StatusBadRequest, "invalid_limit", "limit 必须在 1 到 "+strconv.Itoa(eventlog.MaxPageSize)+" 之间")
+			return
+		}
+		limit = value
+	}
+	var types []string
+	for _, part := range strings.Split(c.Query("types"), ",") {
+		eventType := strings.TrimSpace(part)
+		if eventType == "" {
+			continue
+		}
+		if !isJournalTopic(eventType) {
+			v1Error(c, http.StatusBadRequest, "invalid_event_type", "不支持的事件类型："+eventType)
+			return
+		}
+		types = append(types, eventType)
+	}
+
+	eventlog.Start()
+	page, err := eventlog.Default().Since(after, limit, types)
+	if err != nil {
+		log.Printf("WARN: Event history query failed after=%d error=%v", after, err)
+		v1Error(c, http.StatusInternalServerError, "events_unavailable", "无法读取事件日志")
+		return
+	}
+	items := page.Items
+	if items == nil {
+		items = []eventlog.Entry{}
+	}
+	// 没有更多事件时直接把游标推进到最新 ID，按类型过滤的客户端不必重复扫描其他类型的事件。
+	next := after
+	if len(items) > 0 {
+		next = items[len(items)-1].ID
+	}
+	if !page.HasMore && page.Latest > next {
+		next = page.Latest
+	}
+	if page.Truncated && len(items) == 0 {
+		next = page.Latest
+	}
+	v1Data(c, http.StatusOK, gin.H{
+		"items":       items,
+		"next_cursor": next,
+		"latest_id":   page.Latest,
+		"has_more":    page.HasMore,
+		"truncated":   page.Truncated,
+	})
+}
+
+func isJournalTopic(eventType string) bool {
+	for _, topic := range eventlog.Topics {
+		if string(topic) == eventType {
+			return true
+		}
 	}
+	return false
 }
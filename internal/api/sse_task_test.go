@@ -3,13 +3,16 @@ package api
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pokerjest/animateAutoTool/internal/eventlog"
 	"github.com/pokerjest/animateAutoTool/internal/taskstate"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -55,3 +58,143 @@ func TestSSEStreamsTypedTaskUpdate(t *testing.T) {
 	assert.Contains(t, stream.String(), "event:task_update")
 	assert.Contains(t, stream.String(), `"status":"running"`)
 }
+
+// openSSEStream connects to SSEHandler with the given Last-Event-ID and
+// returns a reader positioned after the initial "connected" message.
+func openSSEStream(t *testing.T, lastEventID string) *bufio.Reader {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/events", SSEHandler)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	t.Cleanup(cancel)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events", nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		request.Header.Set("Last-Event-ID", lastEventID)
+	}
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, response.Body.Close())
+	})
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	reader := bufio.NewReader(response.Body)
+	for {
+		line, readErr := reader.ReadString('\n')
+		require.NoError(t, readErr)
+		if line == "\n" || line == "\r\n" {
+			return reader
+		}
+	}
+}
+
+func readSSEUntil(t *testing.T, reader *bufio.Reader, marker string) string {
+	t.Helper()
+	var stream strings.Builder
+	for !strings.Contains(stream.String(), marker) {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		stream.WriteString(line)
+	}
+	return stream.String()
+}
+
+func TestSSEReplaysEventsAfterLastEventID(t *testing.T) {
+	journal := eventlog.Default()
+	journal.Reset()
+	t.Cleanup(journal.Reset)
+	missed := journal.Append("scan_complete", json.RawMessage(`{"dir":"/anime"}`))
+	journal.Append("download_ready", json.RawMessage(`{"title":"missed"}`))
+	seen := journal.Append("task_update", json.RawMessage(`{"task_id":"seen"}`))
+
+	reader := openSSEStream(t, strconv.FormatUint(missed.ID, 10))
+	stream := readSSEUntil(t, reader, `"task_id":"seen"`)
+
+	assert.NotContains(t, stream, `"dir":"/anime"`)
+	assert.Contains(t, stream, "event:download_ready")
+	assert.Contains(t, stream, "id:"+strconv.FormatUint(seen.ID, 10))
+
+	live := journal.Append("task_update", json.RawMessage(`{"task_id":"live"}`))
+	stream = readSSEUntil(t, reader, `"task_id":"live"`)
+	assert.Contains(t, stream, "id:"+strconv.FormatUint(live.ID, 10))
+}
+
+func TestSSESendsResyncForUnknownCursor(t *testing.T) {
+	journal := eventlog.Default()
+	journal.Reset()
+	t.Cleanup(journal.Reset)
+	latest := journal.Append("task_update", json.RawMessage(`{"task_id":"current"}`))
+
+	reader := openSSEStream(t, strconv.FormatUint(latest.ID+1000, 10))
+	stream := readSSEUntil(t, reader, `"latest_id"`)
+	assert.Contains(t, stream, "event:resync")
+	assert.Contains(t, stream, "id:"+strconv.FormatUint(latest.ID, 10))
+	assert.NotContains(t, stream, `"task_id":"current"`)
+}
+
+func TestV1EventHistoryPagesByCursor(t *testing.T) {
+	resetAuthFixtures(t)
+	journal := eventlog.Default()
+	journal.Reset()
+	t.Cleanup(journal.Reset)
+	r := setupRouter()
+	cookie, _ := loginCookie(t, r, "admin")
+
+	first := journal.Append("task_update", json.RawMessage(`{"task_id":"a"}`))
+	second := journal.Append("scan_complete", json.RawMessage(`{"dir":"/anime"}`))
+	third := journal.Append("task_update", json.RawMessage(`{"task_id":"b"}`))
+
+	type historyPage struct {
+		Data struct {
+			Items []struct {
+				ID   uint64          `json:"id"`
+				Type string          `json:"type"`
+				Data json.RawMessage `json:"data"`
+			} `json:"items"`
+			NextCursor uint64 `json:"next_cursor"`
+			LatestID   uint64 `json:"latest_id"`
+			HasMore    bool   `json:"has_more"`
+			Truncated  bool   `json:"truncated"`
+		} `json:"data"`
+	}
+	fetch := func(query string) historyPage {
+		t.Helper()
+		w := serveAs(r, cookie, http.MethodGet, "/api/v1/events/history"+query, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page historyPage
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page
+	}
+
+	page := fetch("?after=" + strconv.FormatUint(first.ID, 10) + "&limit=1")
+	require.Len(t, page.Data.Items, 1)
+	assert.Equal(t, second.ID, page.Data.Items[0].ID)
+	assert.JSONEq(t, `{"dir":"/anime"}`, string(page.Data.Items[0].Data))
+	assert.True(t, page.Data.HasMore)
+	assert.Equal(t, second.ID, page.Data.NextCursor)
+
+	page = fetch("?after=" + strconv.FormatUint(first.ID, 10) + "&types=task_update")
+	require.Len(t, page.Data.Items, 1)
+	assert.Equal(t, third.ID, page.Data.Items[0].ID)
+	assert.False(t, page.Data.HasMore)
+	assert.Equal(t, third.ID, page.Data.NextCursor)
+	assert.Equal(t, third.ID, page.Data.LatestID)
+
+	page = fetch("?after=" + strconv.FormatUint(third.ID+10, 10))
+	assert.True(t, page.Data.Truncated)
+	assert.Empty(t, page.Data.Items)
+	assert.Equal(t, third.ID, page.Data.NextCursor)
+
+	w := serveAs(r, cookie, http.MethodGet, "/api/v1/events/history?types=updater_status", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"invalid_event_type"`)
+	w = serveAs(r, cookie, http.MethodGet, "/api/v1/events/history?limit=0", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = serveAs(r, "", http.MethodGet, "/api/v1/events/history", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -36,7 +36,7 @@ func enrichSubscriptionDownloadProgress(ctx context.Context, logs []model.Downlo
 	progressCtx, cancel := context.WithTimeout(ctx, subscriptionProgressTimeout)
 	defer cancel()
 
-	client := downloader.NewQBittorrentClient(qbCfg.URL)
+	client := qbutil.NewClient(qbCfg)
 	if err := client.LoginContext(progressCtx, qbCfg.Username, qbCfg.Password); err != nil {
 		return
 	}
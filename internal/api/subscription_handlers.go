@@ -14,7 +14,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/pokerjest/animateAutoTool/internal/bangumi"
 	"github.com/pokerjest/animateAutoTool/internal/config"
-	"github.com/pokerjest/animateAutoTool/internal/downloader"
 	"github.com/pokerjest/animateAutoTool/internal/model"
 	"github.com/pokerjest/animateAutoTool/internal/parser"
 	"github.com/pokerjest/animateAutoTool/internal/qbutil"
@@ -130,7 +129,7 @@ var runSubscriptionCheck = func(sub *model.Subscription, source string) error {
 		return fmt.Errorf("已启用外部 qBittorrent 模式，但 WebUI 地址还是空的")
 	}
 
-	qbt := downloader.NewQBittorrentClient(qbCfg.URL)
+	qbt := qbutil.NewClient(qbCfg)
 	if err := qbt.Login(qbCfg.Username, qbCfg.Password); err != nil {
 		return err
 	}
@@ -199,10 +198,32 @@ func CreateSubscriptionHandler(c *gin.Context) {
 }
 
 func createSubscriptionInternal(sub *model.Subscription) error {
+	if err := storeNewSubscription(sub, true); err != nil {
+		return err
+	}
+
+	// Trigger run asynchronously
+	GoBackground(func(context.Context) {
+		log.Printf("DEBUG: Async ProcessSubscription started for %s", sub.Title)
+		if err := runSubscriptionCheck(sub, "create"); err != nil {
+			log.Printf("WARN: Skipping async subscription run for %s: %v", sub.Title, err)
+		}
+	})
+
+	return nil
+}
+
+// storeNewSubscription normalizes sub and inserts it, or restores a
+// soft-deleted subscription with the same RSS URL, without starting a check.
+// enrich looks the title up on the metadata providers.
+func storeNewSubscription(sub *model.Subscription, enrich bool) error {
 	normalizeMikanAssociation(sub)
 	if err := normalizeSubscriptionReleaseFilters(sub); err != nil {
 		return err
 	}
+	if err := normalizeSubscriptionParser(sub); err != nil {
+		return err
+	}
 	normalizeSubscriptionStrategy(sub)
 
 	if sub.Metadata == nil {
@@ -213,7 +234,9 @@ func createSubscriptionInternal(sub *model.Subscription) error {
 	}
 
 	// Enrich Metadata (Bangumi & TMDB)
-	enrichSubscriptionMetadata(sub.Metadata, sub.Title)
+	if enrich {
+		enrichSubscriptionMetadata(sub.Metadata, sub.Title)
+	}
 
 	sub.IsActive = true
 
@@ -241,10 +264,12 @@ func createSubscriptionInternal(sub *model.Subscription) error {
 			existing.ResolutionFilter = sub.ResolutionFilter
 			existing.SubtitleLanguage = sub.SubtitleLanguage
 			existing.BackupRSSUrl = sub.BackupRSSUrl
+			existing.Parser = sub.Parser
 			existing.ExpectedEpisodes = sub.ExpectedEpisodes
 			existing.AutoDisableOnDone = sub.AutoDisableOnDone
 			existing.AllowMultiSubgroup = sub.AllowMultiSubgroup
 			existing.StaleAfterHours = sub.StaleAfterHours
+			existing.CheckIntervalMinutes = sub.CheckIntervalMinutes
 			existing.IsActive = true
 			if err := s.Save(existing); err != nil {
 				return fmt.Errorf("failed to restore: %v", err)
@@ -259,15 +284,6 @@ func createSubscriptionInternal(sub *model.Subscription) error {
 			return fmt.Errorf("failed to create: %v", err)
 		}
 	}
-
-	// Trigger run asynchronously
-	GoBackground(func(context.Context) {
-		log.Printf("DEBUG: Async ProcessSubscription started for %s", sub.Title)
-		if err := runSubscriptionCheck(sub, "create"); err != nil {
-			log.Printf("WARN: Skipping async subscription run for %s: %v", sub.Title, err)
-		}
-	})
-
 	return nil
 }
 
@@ -300,6 +316,44 @@ func normalizeSubscriptionStrategy(sub *model.Subscription) {
 	if sub.StaleAfterHours == 0 {
 		sub.StaleAfterHours = 168
 	}
+	if sub.CheckIntervalMinutes < 0 {
+		sub.CheckIntervalMinutes = 0
+	}
+}
+
+var errUnknownSubscriptionParser = errors.New("不支持的 RSS 解析器")
+
+// normalizeSubscriptionParser rejects unknown RSS parsers and stores the
+// registered spelling. An empty parser keeps the Mikan default.
+func normalizeSubscriptionParser(sub *model.Subscription) error {
+	if sub == nil {
+		return nil
+	}
+	name := strings.TrimSpace(sub.Parser)
+	if name == "" {
+		sub.Parser = ""
+		return nil
+	}
+	canonical, ok := parser.CanonicalParserName(name)
+	if !ok {
+		return fmt.Errorf("%w: %s", errUnknownSubscriptionParser, name)
+	}
+	sub.Parser = canonical
+	return nil
+}
+
+// subscriptionFeedParser resolves the parser used to preview or validate a
+// feed before the subscription exists. Only a known name builds the parsers.
+func subscriptionFeedParser(name string) (parser.ContextRSSParser, bool) {
+	if _, ok := parser.CanonicalParserName(name); !ok {
+		return nil, false
+	}
+	rssParser, ok := service.NewRSSParserRegistry().Resolve(name)
+	if !ok {
+		return nil, false
+	}
+	ctxParser, ok := rssParser.(parser.ContextRSSParser)
+	return ctxParser, ok
 }
 
 func normalizeSubscriptionReleaseFilters(sub *model.Subscription) error {
@@ -439,8 +493,13 @@ func DeleteSubscriptionHandler(c *gin.Context) {
 		return
 	}
 	var subTitle string
-	if existing, err := s.GetByID(uint(id)); err == nil && existing != nil {
+	if existing, err := s.GetByIDWithMetadata(uint(id)); err == nil && existing != nil {
 		subTitle = existing.Title
+		if err := service.RecordBangumiSyncDeletion(existing); err != nil {
+			log.Printf("ERROR: recording Bangumi sync deletion failed for subID %d: %v", id, err)
+			subscriptionSaveError(c, "删除订阅", err)
+			return
+		}
 	}
 
 	if err := s.DeleteCascade(uint(id)); err != nil {
@@ -536,17 +595,19 @@ func UpdateSubscriptionHandler(c *gin.Context) {
 	}
 
 	var input struct {
-		Title              string `form:"Title" binding:"required"`
-		RSSUrl             string `form:"RSSUrl" binding:"required"`
-		FilterRule         string `form:"FilterRule"`
-		ExcludeRule        string `form:"ExcludeRule"`
-		ResolutionFilter   string `form:"ResolutionFilter"`
-		SubtitleLanguage   string `form:"SubtitleLanguage"`
-		BackupRSSUrl       string `form:"BackupRSSUrl"`
-		ExpectedEpisodes   int    `form:"ExpectedEpisodes"`
-		AllowMultiSubgroup bool   `form:"AllowMultiSubgroup"`
-		AutoDisableOnDone  bool   `form:"AutoDisableOnDone"`
-		StaleAfterHours    int    `form:"StaleAfterHours"`
+		Title                string `form:"Title" binding:"required"`
+		RSSUrl               string `form:"RSSUrl" binding:"required"`
+		FilterRule           string `form:"FilterRule"`
+		ExcludeRule          string `form:"ExcludeRule"`
+		ResolutionFilter     string `form:"ResolutionFilter"`
+		SubtitleLanguage     string `form:"SubtitleLanguage"`
+		BackupRSSUrl         string `form:"BackupRSSUrl"`
+		Parser               string `form:"Parser"`
+		ExpectedEpisodes     int    `form:"ExpectedEpisodes"`
+		AllowMultiSubgroup   bool   `form:"AllowMultiSubgroup"`
+		AutoDisableOnDone    bool   `form:"AutoDisableOnDone"`
+		StaleAfterHours      int    `form:"StaleAfterHours"`
+		CheckIntervalMinutes int    `form:"CheckIntervalMinutes"`
 	}
 
 	if err := c.ShouldBind(&input); err != nil {
@@ -575,14 +636,20 @@ func UpdateSubscriptionHandler(c *gin.Context) {
 	sub.ResolutionFilter = input.ResolutionFilter
 	sub.SubtitleLanguage = input.SubtitleLanguage
 	sub.BackupRSSUrl = input.BackupRSSUrl
+	sub.Parser = input.Parser
 	sub.ExpectedEpisodes = input.ExpectedEpisodes
 	sub.AllowMultiSubgroup = input.AllowMultiSubgroup
 	sub.AutoDisableOnDone = input.AutoDisableOnDone
 	sub.StaleAfterHours = input.StaleAfterHours
+	sub.CheckIntervalMinutes = input.CheckIntervalMinutes
 	if err := normalizeSubscriptionReleaseFilters(sub); err != nil {
 		subscriptionBadRequest(c, err.Error())
 		return
 	}
+	if err := normalizeSubscriptionParser(sub); err != nil {
+		subscriptionBadRequest(c, err.Error())
+		return
+	}
 	normalizeSubscriptionStrategy(sub)
 
 	if err := saveSubscription(sub); err != nil {
@@ -623,7 +690,15 @@ func ValidateSubscriptionRSSHandler(c *gin.Context) {
 		return
 	}
 
-	parserClient := newConfiguredMikanParser()
+	var parserClient parser.ContextRSSParser = newConfiguredMikanParser()
+	if name := strings.TrimSpace(c.Query("parser")); name != "" {
+		selected, ok := subscriptionFeedParser(name)
+		if !ok {
+			subscriptionJSONBadRequest(c, fmt.Sprintf("%v: %s", errUnknownSubscriptionParser, name))
+			return
+		}
+		parserClient = selected
+	}
 	primaryEpisodes, primaryErr := parserClient.ParseContext(c.Request.Context(), sub.RSSUrl)
 	response := RSSValidationResponse{}
 	if primaryErr == nil {
@@ -8,7 +8,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/pokerjest/animateAutoTool/internal/config"
-	"github.com/pokerjest/animateAutoTool/internal/downloader"
 	"github.com/pokerjest/animateAutoTool/internal/qbutil"
 	"github.com/pokerjest/animateAutoTool/internal/runtimejournal"
 	"github.com/pokerjest/animateAutoTool/internal/scheduler"
@@ -30,9 +29,9 @@ var runSubscriptionRefreshNow = func(
 		return service.SubscriptionRefreshResult{}, fmt.Errorf("外部 qBittorrent 模式缺少 WebUI 地址")
 	}
 
-	client := downloader.NewQBittorrentClient(qbCfg.URL)
+	client := qbutil.NewClient(qbCfg)
 	if err := client.LoginContext(ctx, qbCfg.Username, qbCfg.Password); err != nil {
-		return service.SubscriptionRefreshResult{}, fmt.Errorf("qBittorrent 登录失败: %w", err)
+		return service.SubscriptionRefreshResult{}, fmt.Errorf("下载器登录失败: %w", err)
 	}
 	return service.RefreshAndRepairSubscriptions(ctx, client, report)
 }
@@ -55,8 +54,10 @@ func V1RefreshSubscriptionsHandler(c *gin.Context) {
 		return
 	}
 
-	taskstate.Global.Start(subscriptionRefreshTaskID, "subscription-refresh", "刷新并修复订阅", "正在核对下载器和订阅状态")
-	GoBackground(func(ctx context.Context) {
+	taskstate.Global.StartBy(taskInitiator(c), subscriptionRefreshTaskID, "subscription-refresh", "刷新并修复订阅", "正在核对下载器和订阅状态")
+	GoBackground(func(appCtx context.Context) {
+		ctx, cancel := taskstate.Global.WithCancel(appCtx, subscriptionRefreshTaskID)
+		defer cancel()
 		result, err := runSubscriptionRefreshNow(ctx, func(progress service.SubscriptionRefreshProgress) {
 			taskstate.Global.Progress(subscriptionRefreshTaskID, progress.Message, progress.Current, progress.Total)
 		})
@@ -0,0 +1,462 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/downloader"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/qbutil"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"gorm.io/gorm"
+)
+
+const (
+	subscriptionTransferJSON = "json"
+	subscriptionTransferOPML = "opml"
+
+	maxSubscriptionImportBytes = 16 << 20
+
+	subscriptionImportCreate  = "create"
+	subscriptionImportRestore = "restore"
+	subscriptionImportSkip    = "skip"
+	subscriptionImportFailed  = "failed"
+)
+
+// subscriptionImportItem is one row of an import preview or result.
+type subscriptionImportItem struct {
+	Index          int    `json:"index"`
+	Title          string `json:"title"`
+	RSSUrl         string `json:"rss_url"`
+	Season         string `json:"season,omitempty"`
+	Action         string `json:"action"`
+	Reason         string `json:"reason,omitempty"`
+	Duplicate      bool   `json:"duplicate"`
+	ExistingID     uint   `json:"existing_id,omitempty"`
+	Resources      int    `json:"resources"`
+	SubscriptionID uint   `json:"subscription_id,omitempty"`
+	// SourceName and Notes are only set when previewing another tool's
+	// rules.
+	SourceName string   `json:"source_name,omitempty"`
+	Notes      []string `json:"notes,omitempty"`
+}
+
+type subscriptionImportSummary struct {
+	Total      int `json:"total"`
+	Create     int `json:"create"`
+	Restore    int `json:"restore"`
+	Skip       int `json:"skip"`
+	Failed     int `json:"failed"`
+	Duplicates int `json:"duplicates"`
+	Resources  int `json:"resources"`
+}
+
+// newSubscriptionSourceResolvers returns the clients used to find Mikan
+// feeds for entries imported from other tools. Tests replace it.
+var newSubscriptionSourceResolvers = func() (service.BangumiSubjectSearcher, service.MikanSubjectResolver) {
+	return service.NewConfiguredBangumiClient(), newConfiguredMikanParser()
+}
+
+var errSourceNotQBittorrent = errors.New("configured downloader is not qBittorrent")
+
+// listQBittorrentRSSRules reads the RSS auto-download rules from the
+// configured qBittorrent. Tests replace it.
+var listQBittorrentRSSRules = func(ctx context.Context) (map[string]downloader.RSSAutoDownloadRule, error) {
+	cfg := qbutil.LoadConfig()
+	if downloader.NormalizeBackend(cfg.Backend) != downloader.BackendQBittorrent || strings.TrimSpace(cfg.URL) == "" {
+		return nil, errSourceNotQBittorrent
+	}
+	client, ok := qbutil.NewClient(cfg).(*downloader.QBittorrentClient)
+	if !ok {
+		return nil, errSourceNotQBittorrent
+	}
+	if err := client.LoginContext(ctx, cfg.Username, cfg.Password); err != nil {
+		return nil, err
+	}
+	return client.ListRSSRulesContext(ctx)
+}
+
+func V1ExportSubscriptionsHandler(c *gin.Context) {
+	format := strings.ToLower(strings.TrimSpace(c.DefaultQuery("format", subscriptionTransferJSON)))
+	if format != subscriptionTransferJSON && format != subscriptionTransferOPML {
+		v1Error(c, http.StatusBadRequest, "invalid_export_format", "导出格式只能是 json 或 opml")
+		return
+	}
+	now := time.Now()
+	includeResources := format == subscriptionTransferJSON && c.Query("include_resources") == ValueTrue
+	bundle, err := service.ExportSubscriptionBundle(includeResources, now)
+	if err != nil {
+		log.Printf("WARN: Subscription export failed: %v", err)
+		v1Error(c, http.StatusInternalServerError, "subscriptions_unavailable", "无法读取订阅")
+		return
+	}
+
+	var (
+		data        []byte
+		contentType string
+	)
+	if format == subscriptionTransferOPML {
+		data, err = service.EncodeSubscriptionOPML(bundle)
+		contentType = "text/x-opml; charset=utf-8"
+	} else {
+		data, err = json.MarshalIndent(bundle, "", "  ")
+		contentType = "application/json; charset=utf-8"
+	}
+	if err != nil {
+		v1Error(c, http.StatusInternalServerError, "subscription_export_failed", "生成导出文件失败")
+		return
+	}
+	filename := fmt.Sprintf("subscriptions-%s.%s", now.Format("20060102-150405"), format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// V1ImportSubscriptionsHandler imports a JSON bundle or an OPML file sent as
+// the raw request body. dry_run=true only returns the plan; with
+// include_resources=true completed episodes in a JSON bundle are imported so
+// they are not downloaded again.
+func V1ImportSubscriptionsHandler(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSubscriptionImportBytes)
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			v1Error(c, http.StatusRequestEntityTooLarge, "import_too_large", "导入文件不能超过 16 MB")
+			return
+		}
+		v1Error(c, http.StatusBadRequest, "invalid_import", "无法读取导入文件")
+		return
+	}
+	items, format, err := parseSubscriptionImport(c.Query("format"), body)
+	if err != nil {
+		v1Error(c, http.StatusBadRequest, "invalid_import", err.Error())
+		return
+	}
+	dryRun := c.Query("dry_run") == ValueTrue
+	includeResources := c.Query("include_resources") == ValueTrue
+
+	plan, err := planSubscriptionImport(items, includeResources)
+	if err != nil {
+		log.Printf("WARN: Subscription import planning failed: %v", err)
+		v1Error(c, http.StatusInternalServerError, "subscriptions_unavailable", "无法读取现有订阅")
+		return
+	}
+	if dryRun {
+		v1Data(c, http.StatusOK, gin.H{"dry_run": true, "format": format, "items": plan, "summary": summarizeSubscriptionImport(plan)})
+		return
+	}
+
+	applySubscriptionImport(items, plan, includeResources)
+	summary := summarizeSubscriptionImport(plan)
+	outcome := service.AuditOutcomeSuccess
+	if summary.Failed > 0 {
+		outcome = service.AuditOutcomeFailure
+	}
+	service.RecordAudit(buildAuditContext(c), service.AuditEntry{
+		Action:     service.AuditActionSubscriptionImport,
+		Outcome:    outcome,
+		TargetType: "subscription",
+		Details:    map[string]any{"format": format, "include_resources": includeResources, "summary": summary},
+	})
+	v1Message(c, http.StatusOK, fmt.Sprintf("已导入 %d 个订阅，跳过 %d 个", summary.Create+summary.Restore, summary.Skip), gin.H{
+		"dry_run": false,
+		"format":  format,
+		"items":   plan,
+		"summary": summary,
+	})
+}
+
+// V1PreviewSourceSubscriptionsHandler maps another tool's rules onto
+// subscriptions and returns the import plan together with a bundle. Nothing
+// is created here: the reviewed bundle is sent to the bundle import.
+// AutoBangumi and Sonarr files are the raw request body; qBittorrent rules
+// are read from the configured downloader.
+func V1PreviewSourceSubscriptionsHandler(c *gin.Context) {
+	source := strings.ToLower(strings.TrimSpace(c.Param("source")))
+	var (
+		entries []service.ExternalSubscription
+		err     error
+	)
+	switch source {
+	case service.SubscriptionSourceAutoBangumi, service.SubscriptionSourceSonarr:
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSubscriptionImportBytes)
+		body, readErr := io.ReadAll(c.Request.Body)
+		if readErr != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(readErr, &maxBytesErr) {
+				v1Error(c, http.StatusRequestEntityTooLarge, "import_too_large", "导入文件不能超过 16 MB")
+				return
+			}
+			v1Error(c, http.StatusBadRequest, "invalid_import", "无法读取导入文件")
+			return
+		}
+		if source == service.SubscriptionSourceAutoBangumi {
+			entries, err = service.ParseAutoBangumiImport(body)
+			if err != nil {
+				log.Printf("WARN: AutoBangumi import rejected: %v", err)
+				v1Error(c, http.StatusBadRequest, "invalid_import", "不是有效的 AutoBangumi 数据库或导出文件")
+				return
+			}
+		} else {
+			entries, err = service.ParseSonarrSeries(body)
+			if err != nil {
+				v1Error(c, http.StatusBadRequest, "invalid_import", "不是有效的 Sonarr 剧集列表（/api/v3/series 的返回内容）")
+				return
+			}
+		}
+	case service.SubscriptionSourceQBittorrent:
+		rules, listErr := listQBittorrentRSSRules(c.Request.Context())
+		if errors.Is(listErr, errSourceNotQBittorrent) {
+			v1Error(c, http.StatusBadRequest, "qbittorrent_not_configured", "当前下载器不是 qBittorrent")
+			return
+		}
+		if listErr != nil {
+			log.Printf("WARN: qBittorrent RSS rule import failed: %v", listErr)
+			v1Error(c, http.StatusBadGateway, "qbittorrent_unavailable", "无法读取 qBittorrent 的 RSS 下载规则")
+			return
+		}
+		entries = service.QBittorrentRSSRuleEntries(rules)
+	default:
+		v1Error(c, http.StatusBadRequest, "invalid_import_source", "导入来源只能是 autobangumi、sonarr 或 qbittorrent")
+		return
+	}
+
+	subjects, mikan := newSubscriptionSourceResolvers()
+	service.ResolveExternalSubscriptions(c.Request.Context(), entries, subjects, mikan)
+	bundle := service.ExternalSubscriptionBundle(entries, time.Now())
+	plan, err := planSubscriptionImport(bundle.Subscriptions, false)
+	if err != nil {
+		log.Printf("WARN: Subscription source planning failed: %v", err)
+		v1Error(c, http.StatusInternalServerError, "subscriptions_unavailable", "无法读取现有订阅")
+		return
+	}
+	for i := range plan {
+		plan[i].SourceName = entries[i].SourceName
+		plan[i].Notes = entries[i].Notes
+	}
+	v1Data(c, http.StatusOK, gin.H{
+		"source":  source,
+		"items":   plan,
+		"summary": summarizeSubscriptionImport(plan),
+		"bundle":  bundle,
+	})
+}
+
+// parseSubscriptionImport decodes body as the requested format, or guesses
+// it from the first non-blank byte when format is empty.
+func parseSubscriptionImport(format string, body []byte) ([]service.SubscriptionBundleItem, string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, "", errors.New("导入文件为空")
+	}
+	if format == "" {
+		format = subscriptionTransferJSON
+		if trimmed[0] == '<' {
+			format = subscriptionTransferOPML
+		}
+	}
+	switch format {
+	case subscriptionTransferJSON:
+		bundle, err := service.ParseSubscriptionBundle(trimmed)
+		if errors.Is(err, service.ErrSubscriptionBundleVersion) {
+			return nil, format, errors.New("导入文件来自更新的版本，请先升级")
+		}
+		if err != nil {
+			return nil, format, errors.New("不是有效的订阅导出文件")
+		}
+		return bundle.Subscriptions, format, nil
+	case subscriptionTransferOPML:
+		items, err := service.ParseSubscriptionOPML(trimmed)
+		if err != nil {
+			return nil, format, errors.New("不是有效的 OPML 文件")
+		}
+		return items, format, nil
+	default:
+		return nil, format, errors.New("导入格式只能是 json 或 opml")
+	}
+}
+
+// planSubscriptionImport decides what happens to each item without writing
+// anything. Items whose RSS URL is already subscribed, or repeated earlier
+// in the same file, are flagged as duplicates and skipped.
+func planSubscriptionImport(items []service.SubscriptionBundleItem, includeResources bool) ([]subscriptionImportItem, error) {
+	subs := subscriptionStore()
+	if subs == nil {
+		return nil, errors.New("database not initialized")
+	}
+	plan := make([]subscriptionImportItem, 0, len(items))
+	seen := make(map[string]int, len(items))
+	for index, item := range items {
+		sub := item.Subscription()
+		row := subscriptionImportItem{Index: index, Title: sub.Title, RSSUrl: sub.RSSUrl, Season: strings.TrimSpace(sub.Season), Action: subscriptionImportCreate}
+		if includeResources {
+			row.Resources = len(item.Resources)
+		}
+		if reason := validateImportedSubscription(&sub); reason != "" {
+			row.Action, row.Reason, row.Resources = subscriptionImportSkip, reason, 0
+			plan = append(plan, row)
+			continue
+		}
+		if first, ok := seen[sub.RSSUrl]; ok {
+			row.Action, row.Reason, row.Duplicate, row.Resources = subscriptionImportSkip, fmt.Sprintf("与第 %d 项的 RSS 地址重复", first+1), true, 0
+			plan = append(plan, row)
+			continue
+		}
+		seen[sub.RSSUrl] = index
+		existing, found, err := subs.FindByRSSURLUnscoped(sub.RSSUrl)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			row.ExistingID = existing.ID
+			if existing.DeletedAt.Valid {
+				row.Action = subscriptionImportRestore
+				row.Reason = "恢复已删除的同 RSS 订阅"
+			} else {
+				row.Action, row.Reason, row.Duplicate, row.Resources = subscriptionImportSkip, "已存在相同 RSS 地址的订阅", true, 0
+			}
+		}
+		plan = append(plan, row)
+	}
+	return plan, nil
+}
+
+// validateImportedSubscription runs the same checks as manual creation on a
+// copy and returns a reason when the item cannot be imported.
+func validateImportedSubscription(sub *model.Subscription) string {
+	if sub.Title == "" {
+		return "缺少番剧名称"
+	}
+	if sub.RSSUrl == "" {
+		return "缺少 RSS 地址"
+	}
+	parsed, err := url.Parse(sub.RSSUrl)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "RSS 地址必须是 http 或 https 链接"
+	}
+	probe := *sub
+	if err := normalizeSubscriptionReleaseFilters(&probe); err != nil {
+		return err.Error()
+	}
+	if err := normalizeSubscriptionParser(&probe); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// applySubscriptionImport carries out plan and records the outcome on each
+// row. Completed episodes are written before the first check is started so
+// that check already skips them.
+func applySubscriptionImport(items []service.SubscriptionBundleItem, plan []subscriptionImportItem, includeResources bool) {
+	for i := range plan {
+		row := &plan[i]
+		if row.Action != subscriptionImportCreate && row.Action != subscriptionImportRestore {
+			continue
+		}
+		item := items[row.Index]
+		sub := item.Subscription()
+		enrich := linkImportedMetadata(&sub, item.Metadata)
+		if err := storeNewSubscription(&sub, enrich); err != nil {
+			log.Printf("WARN: Subscription import failed title=%q rss=%s error=%v", sub.Title, sub.RSSUrl, err)
+			row.Action, row.Reason, row.Resources = subscriptionImportFailed, humanizeOperationError(err.Error()), 0
+			continue
+		}
+		row.SubscriptionID = sub.ID
+
+		// The restore path only copies rule fields, and creation always
+		// activates the subscription, so apply the remaining settings here.
+		wanted := item.Subscription()
+		if sub.SavePath != wanted.SavePath || sub.RenameEnabled != wanted.RenameEnabled || sub.Offset != wanted.Offset || sub.IsActive != wanted.IsActive {
+			sub.SavePath, sub.RenameEnabled, sub.Offset, sub.IsActive = wanted.SavePath, wanted.RenameEnabled, wanted.Offset, wanted.IsActive
+			if err := saveSubscription(&sub); err != nil {
+				log.Printf("WARN: Subscription import could not save settings id=%d error=%v", sub.ID, err)
+			}
+		}
+
+		if includeResources && len(item.Resources) > 0 {
+			count, err := service.ImportSubscriptionResources(sub.ID, item.Resources)
+			if err != nil {
+				log.Printf("WARN: Subscription import could not record completed episodes id=%d error=%v", sub.ID, err)
+				row.Reason = "订阅已导入，但完成记录写入失败"
+			}
+			row.Resources = count
+		} else {
+			row.Resources = 0
+		}
+
+		if sub.IsActive {
+			imported := sub
+			GoBackground(func(context.Context) {
+				if err := runSubscriptionCheck(&imported, "import"); err != nil {
+					log.Printf("WARN: Skipping async subscription run for %s: %v", imported.Title, err)
+				}
+			})
+		}
+	}
+}
+
+// linkImportedMetadata points sub at the metadata row that already owns one
+// of the exported provider IDs, restoring it when it was soft deleted.
+// Otherwise it seeds new metadata with those IDs and reports that the
+// providers should be queried.
+func linkImportedMetadata(sub *model.Subscription, metadata *service.SubscriptionBundleMetadata) bool {
+	if metadata == nil || (metadata.BangumiID == 0 && metadata.TMDBID == 0 && metadata.AniListID == 0) {
+		return true
+	}
+	if db.DB != nil {
+		metadataStore := store.NewAnimeMetadataStore(db.DB)
+		existing, err := metadataStore.FindByExternalIDsIncludingDeleted(metadata.BangumiID, metadata.TMDBID, metadata.AniListID)
+		if err == nil && existing.ID != 0 {
+			if existing.DeletedAt.Valid {
+				existing.DeletedAt = gorm.DeletedAt{}
+				if err := metadataStore.SaveIncludingDeleted(existing); err != nil {
+					log.Printf("WARN: Subscription import could not restore metadata id=%d error=%v", existing.ID, err)
+				}
+			}
+			sub.Metadata = existing
+			sub.MetadataID = &existing.ID
+			return false
+		}
+	}
+	sub.Metadata = &model.AnimeMetadata{
+		Title:     strings.TrimSpace(metadata.Title),
+		BangumiID: metadata.BangumiID,
+		TMDBID:    metadata.TMDBID,
+		AniListID: metadata.AniListID,
+	}
+	return true
+}
+
+func summarizeSubscriptionImport(plan []subscriptionImportItem) subscriptionImportSummary {
+	summary := subscriptionImportSummary{Total: len(plan)}
+	for _, row := range plan {
+		switch row.Action {
+		case subscriptionImportCreate:
+			summary.Create++
+		case subscriptionImportRestore:
+			summary.Restore++
+		case subscriptionImportSkip:
+			summary.Skip++
+		case subscriptionImportFailed:
+			summary.Failed++
+		}
+		if row.Duplicate {
+			summary.Duplicates++
+		}
+		summary.Resources += row.Resources
+	}
+	return summary
+}
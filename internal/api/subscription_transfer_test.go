@@ -0,0 +1,275 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/bangumi"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/downloader"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/parser"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubSubscriptionImportSideEffects(t *testing.T) chan string {
+	t.Helper()
+	previousEnrich := enrichSubscriptionMetadata
+	previousRun := runSubscriptionCheck
+	enrichSubscriptionMetadata = func(_ *model.AnimeMetadata, _ string) {}
+	checked := make(chan string, 8)
+	runSubscriptionCheck = func(sub *model.Subscription, source string) error {
+		checked <- source + ":" + sub.Title
+		return nil
+	}
+	t.Cleanup(func() {
+		enrichSubscriptionMetadata = previousEnrich
+		runSubscriptionCheck = previousRun
+	})
+	return checked
+}
+
+func clearSubscriptionTransferTables(t *testing.T) {
+	t.Helper()
+	for _, table := range []string{"subscription_resources", "subscriptions", "anime_metadata"} {
+		require.NoError(t, db.DB.Exec("DELETE FROM "+table).Error)
+	}
+}
+
+func TestV1SubscriptionImportPreviewFlagsDuplicatesAndImportsState(t *testing.T) {
+	resetAuthFixtures(t)
+	clearSubscriptionTransferTables(t)
+	t.Cleanup(func() { clearSubscriptionTransferTables(t) })
+	checked := stubSubscriptionImportSideEffects(t)
+
+	existing := model.Subscription{Title: "Already Here", RSSUrl: "https://example.test/existing", IsActive: true}
+	require.NoError(t, db.DB.Create(&existing).Error)
+	metadata := model.AnimeMetadata{Title: "Shared Show", BangumiID: 9001}
+	require.NoError(t, db.DB.Create(&metadata).Error)
+
+	bundle := `{
+		"format": "animateautotool.subscriptions",
+		"version": 1,
+		"exported_at": "2026-10-17T08:00:00Z",
+		"subscriptions": [
+			{"title": "Already Here", "rss_url": "https://example.test/existing", "is_active": true},
+			{
+				"title": "Imported Show", "rss_url": "https://example.test/imported", "is_active": false,
+				"filter_rule": "Group", "exclude_rule": "720p", "resolution_filter": "1080p", "subtitle_language": "chs",
+				"backup_rss_url": "https://example.test/imported-backup", "save_path": "/downloads/imported", "offset": 12,
+				"metadata": {"title": "Shared Show", "bangumi_id": 9001},
+				"resources": [{"fingerprint": "eeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee", "canonical_key": "episode:1:1", "episode": "01", "season_val": "S01"}]
+			},
+			{"title": "Imported Twice", "rss_url": "https://example.test/imported", "is_active": true},
+			{"title": "Broken Rule", "rss_url": "https://example.test/broken", "filter_rule": "[未闭合", "is_active": true}
+		]
+	}`
+
+	r := setupRouter()
+	cookie, _ := loginCookie(t, r, "admin")
+	w := serveAs(r, cookie, http.MethodPost, "/api/v1/subscriptions/import?dry_run=true&include_resources=true", bundle)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var preview struct {
+		Data struct {
+			DryRun  bool                      `json:"dry_run"`
+			Format  string                    `json:"format"`
+			Items   []subscriptionImportItem  `json:"items"`
+			Summary subscriptionImportSummary `json:"summary"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &preview))
+	assert.True(t, preview.Data.DryRun)
+	assert.Equal(t, "json", preview.Data.Format)
+	require.Len(t, preview.Data.Items, 4)
+	assert.Equal(t, subscriptionImportSkip, preview.Data.Items[0].Action)
+	assert.True(t, preview.Data.Items[0].Duplicate)
+	assert.Equal(t, existing.ID, preview.Data.Items[0].ExistingID)
+	assert.Equal(t, subscriptionImportCreate, preview.Data.Items[1].Action)
+	assert.Equal(t, 1, preview.Data.Items[1].Resources)
+	assert.True(t, preview.Data.Items[2].Duplicate, "a repeated RSS URL inside the file is a duplicate too")
+	assert.Equal(t, subscriptionImportSkip, preview.Data.Items[3].Action)
+	assert.Contains(t, preview.Data.Items[3].Reason, "包含规则")
+	assert.Equal(t, subscriptionImportSummary{Total: 4, Create: 1, Skip: 3, Duplicates: 2, Resources: 1}, preview.Data.Summary)
+
+	var count int64
+	require.NoError(t, db.DB.Model(&model.Subscription{}).Count(&count).Error)
+	assert.EqualValues(t, 1, count, "dry run must not write anything")
+
+	w = serveAs(r, cookie, http.MethodPost, "/api/v1/subscriptions/import?include_resources=true", bundle)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "已导入 1 个订阅")
+
+	var imported model.Subscription
+	require.NoError(t, db.DB.Where("rss_url = ?", "https://example.test/imported").First(&imported).Error)
+	assert.Equal(t, "Group", imported.FilterRule)
+	assert.Equal(t, "720p", imported.ExcludeRule)
+	assert.Equal(t, "1080p", imported.ResolutionFilter)
+	assert.Equal(t, "chs", imported.SubtitleLanguage)
+	assert.Equal(t, "https://example.test/imported-backup", imported.BackupRSSUrl)
+	assert.Equal(t, "/downloads/imported", imported.SavePath)
+	assert.Equal(t, 12, imported.Offset)
+	assert.False(t, imported.IsActive)
+	require.NotNil(t, imported.MetadataID)
+	assert.Equal(t, metadata.ID, *imported.MetadataID, "provider IDs must link the existing metadata row")
+	assert.Empty(t, checked, "inactive imports must not be checked")
+
+	var resource model.SubscriptionResource
+	require.NoError(t, db.DB.Where("subscription_id = ?", imported.ID).First(&resource).Error)
+	assert.Equal(t, service.SubscriptionResourceStateCompleted, resource.State)
+	assert.Equal(t, "01", resource.Episode)
+}
+
+func TestV1SubscriptionExportAndOPMLImport(t *testing.T) {
+	resetAuthFixtures(t)
+	clearSubscriptionTransferTables(t)
+	t.Cleanup(func() { clearSubscriptionTransferTables(t) })
+	checked := stubSubscriptionImportSideEffects(t)
+
+	source := model.Subscription{Title: "Export Show", RSSUrl: "https://example.test/export", Season: "2026 秋季番组", FilterRule: "Group", IsActive: true}
+	require.NoError(t, db.DB.Create(&source).Error)
+
+	r := setupRouter()
+	cookie, _ := loginCookie(t, r, "admin")
+	w := serveAs(r, cookie, http.MethodGet, "/api/v1/subscriptions/export", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Disposition"), ".json")
+	bundle, err := service.ParseSubscriptionBundle(w.Body.Bytes())
+	require.NoError(t, err)
+	require.Len(t, bundle.Subscriptions, 1)
+	assert.Equal(t, "Group", bundle.Subscriptions[0].FilterRule)
+
+	w = serveAs(r, cookie, http.MethodGet, "/api/v1/subscriptions/export?format=opml", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/x-opml"))
+	assert.Contains(t, w.Body.String(), `xmlUrl="https://example.test/export"`)
+
+	w = serveAs(r, cookie, http.MethodGet, "/api/v1/subscriptions/export?format=csv", "")
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"code":"invalid_export_format"`)
+
+	opml := `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0"><body>
+	<outline text="2026 秋季番组">
+		<outline type="rss" text="Export Show" xmlUrl="https://example.test/export"/>
+		<outline type="rss" text="OPML Show" xmlUrl="https://example.test/opml"/>
+	</outline>
+</body></opml>`
+	w = serveAs(r, cookie, http.MethodPost, "/api/v1/subscriptions/import", opml)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"format":"opml"`)
+	select {
+	case source := <-checked:
+		assert.Equal(t, "import:OPML Show", source)
+	case <-time.After(5 * time.Second):
+		t.Fatal("imported subscription was not checked")
+	}
+
+	var created model.Subscription
+	require.NoError(t, db.DB.Where("rss_url = ?", "https://example.test/opml").First(&created).Error)
+	assert.Equal(t, "OPML Show", created.Title)
+	assert.Equal(t, "2026 秋季番组", created.Season)
+	assert.True(t, created.IsActive)
+
+	w = serveAs(r, cookie, http.MethodPost, "/api/v1/subscriptions/import?format=json", "not json")
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"code":"invalid_import"`)
+}
+
+type stubSourceSubjects map[string]*bangumi.SearchResult
+
+func (s stubSourceSubjects) SearchSubjectContext(_ context.Context, keyword string) (*bangumi.SearchResult, error) {
+	return s[keyword], nil
+}
+
+type stubSourceMikan map[string][]parser.SearchResult
+
+func (s stubSourceMikan) ResolveBangumiSubjectContext(_ context.Context, subjectID, _ string) ([]parser.SearchResult, error) {
+	return s[subjectID], nil
+}
+
+func (s stubSourceMikan) GetSubgroupsContext(context.Context, string) ([]parser.Subgroup, error) {
+	return nil, nil
+}
+
+func TestV1PreviewSourceSubscriptionsResolvesFeedsWithoutWriting(t *testing.T) {
+	resetAuthFixtures(t)
+	clearSubscriptionTransferTables(t)
+	t.Cleanup(func() { clearSubscriptionTransferTables(t) })
+	stubSubscriptionImportSideEffects(t)
+
+	previousResolvers := newSubscriptionSourceResolvers
+	previousRules := listQBittorrentRSSRules
+	newSubscriptionSourceResolvers = func() (service.BangumiSubjectSearcher, service.MikanSubjectResolver) {
+		return stubSourceSubjects{"Frieren: Beyond Journey's End": {ID: 400602, NameCN: "葬送的芙莉莲"}},
+			stubSourceMikan{"400602": {{MikanID: "3141", Title: "葬送的芙莉莲"}}}
+	}
+	rulesErr := error(nil)
+	listQBittorrentRSSRules = func(context.Context) (map[string]downloader.RSSAutoDownloadRule, error) {
+		if rulesErr != nil {
+			return nil, rulesErr
+		}
+		return map[string]downloader.RSSAutoDownloadRule{
+			"Existing": {Enabled: true, MustContain: "Existing", AffectedFeeds: []string{"https://example.test/existing"}},
+		}, nil
+	}
+	t.Cleanup(func() {
+		newSubscriptionSourceResolvers = previousResolvers
+		listQBittorrentRSSRules = previousRules
+	})
+
+	existing := model.Subscription{Title: "Existing", RSSUrl: "https://example.test/existing", IsActive: true}
+	require.NoError(t, db.DB.Create(&existing).Error)
+
+	r := setupRouter()
+	cookie, _ := loginCookie(t, r, "admin")
+	sonarr := `[{"title": "Frieren: Beyond Journey's End", "monitored": true, "seriesType": "anime"}, {"title": "Unknown", "monitored": true, "seriesType": "anime"}]`
+	w := serveAs(r, cookie, http.MethodPost, "/api/v1/subscriptions/import/sonarr", sonarr)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var preview struct {
+		Data struct {
+			Source  string                     `json:"source"`
+			Items   []subscriptionImportItem   `json:"items"`
+			Summary subscriptionImportSummary  `json:"summary"`
+			Bundle  service.SubscriptionBundle `json:"bundle"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &preview))
+	assert.Equal(t, "sonarr", preview.Data.Source)
+	require.Len(t, preview.Data.Items, 2)
+	assert.Equal(t, subscriptionImportCreate, preview.Data.Items[0].Action)
+	assert.Equal(t, "https://mikanani.me/RSS/Bangumi?bangumiId=3141", preview.Data.Items[0].RSSUrl)
+	assert.Equal(t, subscriptionImportSkip, preview.Data.Items[1].Action)
+	assert.NotEmpty(t, preview.Data.Items[1].Notes)
+	require.Len(t, preview.Data.Bundle.Subscriptions, 2)
+	assert.Equal(t, service.SubscriptionBundleFormat, preview.Data.Bundle.Format)
+
+	var count int64
+	require.NoError(t, db.DB.Model(&model.Subscription{}).Count(&count).Error)
+	assert.EqualValues(t, 1, count, "a source preview must not create subscriptions")
+
+	w = serveAs(r, cookie, http.MethodPost, "/api/v1/subscriptions/import/qbittorrent", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &preview))
+	require.Len(t, preview.Data.Items, 1)
+	assert.True(t, preview.Data.Items[0].Duplicate)
+	assert.Equal(t, "Existing", preview.Data.Items[0].SourceName)
+
+	rulesErr = errSourceNotQBittorrent
+	w = serveAs(r, cookie, http.MethodPost, "/api/v1/subscriptions/import/qbittorrent", "")
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"code":"qbittorrent_not_configured"`)
+
+	w = serveAs(r, cookie, http.MethodPost, "/api/v1/subscriptions/import/autobangumi", `{"hello": "world"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"code":"invalid_import"`)
+
+	w = serveAs(r, cookie, http.MethodPost, "/api/v1/subscriptions/import/medusa", "")
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"code":"invalid_import_source"`)
+}
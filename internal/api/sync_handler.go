@@ -11,7 +11,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/pokerjest/animateAutoTool/internal/config"
-	"github.com/pokerjest/animateAutoTool/internal/downloader"
 	"github.com/pokerjest/animateAutoTool/internal/qbutil"
 	"github.com/pokerjest/animateAutoTool/internal/runtimejournal"
 	"github.com/pokerjest/animateAutoTool/internal/scheduler"
@@ -43,7 +42,7 @@ var runDashboardSyncNow = func(ctx context.Context) error {
 
 	qbCfg := qbutil.LoadConfig()
 	if !qbutil.ManagedBinaryMissing(qbCfg, config.BinDir()) && !qbutil.MissingExternalURL(qbCfg) && strings.TrimSpace(qbCfg.URL) != "" {
-		client := downloader.NewQBittorrentClient(qbCfg.URL)
+		client := qbutil.NewClient(qbCfg)
 		if err := client.LoginContext(ctx, qbCfg.Username, qbCfg.Password); err != nil {
 			errs = append(errs, fmt.Sprintf("下载状态同步失败: %v", err))
 		} else {
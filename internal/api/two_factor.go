@@ -0,0 +1,262 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/pokerjest/animateAutoTool/internal/authsession"
+	"github.com/pokerjest/animateAutoTool/internal/bootstrap"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+)
+
+// A correct password on an account with two-factor login only earns a short
+// pending state in the session cookie; user_id is set once the second step
+// passes.
+const (
+	twoFactorPendingUserKey       = "pending_2fa_user_id"
+	twoFactorPendingExpiresKey    = "pending_2fa_expires_at"
+	twoFactorPendingRememberKey   = "pending_2fa_remember_me"
+	twoFactorPendingGenerationKey = "pending_2fa_auth_generation"
+	twoFactorPendingTTL           = 5 * time.Minute
+)
+
+type TwoFactorLoginRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type twoFactorPasswordRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// saveLoginSession turns the cookie into a logged-in session for user and
+// drops any pending second-factor state.
+func saveLoginSession(c *gin.Context, user *model.User, rememberMe bool) error {
+	session := sessions.Default(c)
+	clearTwoFactorChallenge(session)
+	session.Set("user_id", user.ID)
+	session.Set("auth_generation", authsession.Current())
+	maxAge := 0
+	if rememberMe {
+		maxAge = 3600 * 24 * 30
+	}
+	session.Options(sessionCookieOptions(c, maxAge))
+	return session.Save()
+}
+
+func startTwoFactorChallenge(c *gin.Context, user *model.User, rememberMe bool) error {
+	session := sessions.Default(c)
+	session.Delete("user_id")
+	session.Delete("auth_generation")
+	session.Set(twoFactorPendingUserKey, user.ID)
+	session.Set(twoFactorPendingExpiresKey, time.Now().Add(twoFactorPendingTTL).Unix())
+	session.Set(twoFactorPendingRememberKey, rememberMe)
+	session.Set(twoFactorPendingGenerationKey, authsession.Current())
+	session.Options(sessionCookieOptions(c, 0))
+	return session.Save()
+}
+
+func clearTwoFactorChallenge(session sessions.Session) {
+	for _, key := range []string{twoFactorPendingUserKey, twoFactorPendingExpiresKey, twoFactorPendingRememberKey, twoFactorPendingGenerationKey} {
+		session.Delete(key)
+	}
+}
+
+// pendingTwoFactorUser returns the account waiting for its second step, as
+// long as the challenge has not expired and no global logout happened since.
+func pendingTwoFactorUser(session sessions.Session) (*model.User, bool, bool) {
+	userID, ok := sessionUint(session.Get(twoFactorPendingUserKey))
+	if !ok || userID == 0 || db.DB == nil {
+		return nil, false, false
+	}
+	expiresAt, ok := session.Get(twoFactorPendingExpiresKey).(int64)
+	if !ok || time.Now().Unix() > expiresAt {
+		return nil, false, false
+	}
+	if authsession.Required() && !authsession.Valid(session.Get(twoFactorPendingGenerationKey)) {
+		return nil, false, false
+	}
+	user, err := store.NewUserStore(db.DB).GetByID(userID)
+	if err != nil || !service.TwoFactorEnabled(user) {
+		return nil, false, false
+	}
+	rememberMe, _ := session.Get(twoFactorPendingRememberKey).(bool)
+	return user, rememberMe, true
+}
+
+// V1LoginTwoFactorHandler completes a login started by V1LoginHandler. Wrong
+// codes count towards the same per-IP lockout as wrong passwords.
+func V1LoginTwoFactorHandler(c *gin.Context) {
+	var req TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		v1Error(c, http.StatusBadRequest, "invalid_request", "请输入验证码或恢复码")
+		return
+	}
+	clientIP := requestClientIP(c)
+	if retryAfter, blocked := checkLoginThrottle(clientIP); blocked {
+		c.Header("Retry-After", strconv.Itoa(max(1, int(retryAfter.Seconds()))))
+		v1Error(c, http.StatusTooManyRequests, "login_throttled", "登录尝试过于频繁，请稍后再试")
+		return
+	}
+	session := sessions.Default(c)
+	user, rememberMe, ok := pendingTwoFactorUser(session)
+	if !ok {
+		v1Error(c, http.StatusUnauthorized, "two_factor_expired", "两步验证已过期，请重新输入密码登录")
+		return
+	}
+	method, err := service.NewAuthService().VerifySecondFactor(user, req.Code)
+	if err != nil {
+		if !errors.Is(err, service.ErrTwoFactorCode) {
+			log.Printf("two-factor login for user %d failed: %v", user.ID, err)
+			v1Error(c, http.StatusInternalServerError, "two_factor_failed", "两步验证暂时不可用，请稍后再试")
+			return
+		}
+		registerFailedLoginAttempt(clientIP)
+		service.RecordAudit(auditContextForLogin(c, user.Username), service.AuditEntry{Action: service.AuditActionLoginFailure, Outcome: service.AuditOutcomeFailure, Details: map[string]string{"reason": "invalid_second_factor"}})
+		v1Error(c, http.StatusUnauthorized, "invalid_two_factor_code", err.Error())
+		return
+	}
+	clearFailedLoginAttempts(clientIP)
+	if err := saveLoginSession(c, user, rememberMe); err != nil {
+		v1Error(c, http.StatusInternalServerError, "session_save_failed", "无法保存登录状态")
+		return
+	}
+	auditCtx := auditContextForLogin(c, user.Username)
+	auditCtx.UserID = user.ID
+	service.RecordAudit(auditCtx, service.AuditEntry{Action: service.AuditActionLoginSuccess, Outcome: service.AuditOutcomeSuccess, Details: map[string]any{"remember_me": rememberMe, "second_factor": method}})
+	v1Message(c, http.StatusOK, "登录成功", gin.H{"setup_pending": bootstrap.BootstrapSetupPending(), "second_factor": method})
+}
+
+func V1TwoFactorStatusHandler(c *gin.Context) {
+	user, ok := twoFactorSessionUser(c)
+	if !ok {
+		return
+	}
+	status, err := service.NewAuthService().TwoFactorStatus(user)
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+	v1Data(c, http.StatusOK, gin.H{
+		"enabled":                  status.Enabled,
+		"enabled_at":               user.TOTPEnabledAt,
+		"pending":                  status.Pending,
+		"recovery_codes_remaining": status.RecoveryCodesRemaining,
+	})
+}
+
+func V1TwoFactorEnrollHandler(c *gin.Context) {
+	user, ok := twoFactorSessionUser(c)
+	if !ok {
+		return
+	}
+	secret, uri, err := service.NewAuthService().BeginTOTPEnrollment(user)
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+	v1Data(c, http.StatusOK, gin.H{"secret": secret, "otpauth_uri": uri})
+}
+
+func V1TwoFactorConfirmHandler(c *gin.Context) {
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		v1Error(c, http.StatusBadRequest, "invalid_request", "请输入验证器显示的 6 位验证码")
+		return
+	}
+	user, ok := twoFactorSessionUser(c)
+	if !ok {
+		return
+	}
+	codes, err := service.NewAuthService().ConfirmTOTP(user, req.Code)
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+	recordTwoFactorAudit(c, user, service.AuditActionTwoFactorEnable)
+	v1Message(c, http.StatusOK, "两步验证已启用，请妥善保存恢复码", gin.H{"recovery_codes": codes})
+}
+
+func V1TwoFactorRecoveryCodesHandler(c *gin.Context) {
+	var req twoFactorPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		v1Error(c, http.StatusBadRequest, "invalid_request", "请输入当前密码")
+		return
+	}
+	user, ok := twoFactorSessionUser(c)
+	if !ok {
+		return
+	}
+	codes, err := service.NewAuthService().RegenerateRecoveryCodes(user, req.Password)
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+	recordTwoFactorAudit(c, user, service.AuditActionRecoveryCodesRenew)
+	v1Message(c, http.StatusOK, "已生成新的恢复码，旧恢复码全部失效", gin.H{"recovery_codes": codes})
+}
+
+func V1TwoFactorDisableHandler(c *gin.Context) {
+	var req twoFactorPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		v1Error(c, http.StatusBadRequest, "invalid_request", "请输入当前密码")
+		return
+	}
+	user, ok := twoFactorSessionUser(c)
+	if !ok {
+		return
+	}
+	if err := service.NewAuthService().DisableTOTP(user, req.Password); err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+	recordTwoFactorAudit(c, user, service.AuditActionTwoFactorDisable)
+	v1Message(c, http.StatusOK, "两步验证已关闭", nil)
+}
+
+func twoFactorSessionUser(c *gin.Context) (*model.User, bool) {
+	user, err := currentSessionUser(c)
+	if err != nil {
+		v1Error(c, http.StatusUnauthorized, "unauthorized", "当前登录状态已失效")
+		return nil, false
+	}
+	return user, true
+}
+
+func recordTwoFactorAudit(c *gin.Context, user *model.User, action string) {
+	service.RecordAudit(buildAuditContext(c), service.AuditEntry{
+		Action:     action,
+		Outcome:    service.AuditOutcomeSuccess,
+		TargetType: "user",
+		TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+	})
+}
+
+func writeTwoFactorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrTwoFactorAlreadyEnabled):
+		v1Error(c, http.StatusConflict, "two_factor_enabled", err.Error())
+	case errors.Is(err, service.ErrTwoFactorNotEnabled):
+		v1Error(c, http.StatusConflict, "two_factor_disabled", err.Error())
+	case errors.Is(err, service.ErrTwoFactorNotPending):
+		v1Error(c, http.StatusConflict, "two_factor_not_pending", err.Error())
+	case errors.Is(err, service.ErrTwoFactorCode):
+		v1Error(c, http.StatusBadRequest, "invalid_two_factor_code", err.Error())
+	case errors.Is(err, service.ErrTwoFactorPassword):
+		v1Error(c, http.StatusBadRequest, "invalid_current_password", err.Error())
+	default:
+		log.Printf("two-factor settings update failed: %v", err)
+		v1Error(c, http.StatusInternalServerError, "two_factor_failed", "两步验证设置失败")
+	}
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/security"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// enableTestTwoFactor enrolls the logged-in account and returns its secret,
+// the step of the code used to confirm it and the recovery codes.
+func enableTestTwoFactor(t *testing.T, r *gin.Engine, cookie string) (string, int64, []string) {
+	t.Helper()
+	w := serveAs(r, cookie, http.MethodPost, "/api/v1/session/2fa/enroll", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var enroll struct {
+		Data struct {
+			Secret     string `json:"secret"`
+			OTPAuthURI string `json:"otpauth_uri"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &enroll))
+	assert.True(t, strings.HasPrefix(enroll.Data.OTPAuthURI, "otpauth://totp/"))
+
+	step := security.TOTPStep(time.Now())
+	code, err := security.TOTPCode(enroll.Data.Secret, step)
+	require.NoError(t, err)
+	w = serveAs(r, cookie, http.MethodPost, "/api/v1/session/2fa/confirm", `{"code":"`+code+`"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var confirm struct {
+		Data struct {
+			RecoveryCodes []string `json:"recovery_codes"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &confirm))
+	require.Len(t, confirm.Data.RecoveryCodes, 10)
+	return enroll.Data.Secret, step, confirm.Data.RecoveryCodes
+}
+
+func sessionCookie(w *httptest.ResponseRecorder) string {
+	return strings.SplitN(w.Header().Get("Set-Cookie"), ";", 2)[0]
+}
+
+func TestV1LoginRequiresSecondFactorWhenEnabled(t *testing.T) {
+	resetAuthFixtures(t)
+	r := setupRouter()
+	cookie, _ := loginCookie(t, r, "admin")
+	secret, step, recoveryCodes := enableTestTwoFactor(t, r, cookie)
+
+	w := serveAs(r, cookie, http.MethodGet, "/api/v1/session/2fa", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"enabled":true`)
+	assert.Contains(t, w.Body.String(), `"recovery_codes_remaining":10`)
+
+	w = serveAs(r, "", http.MethodPost, "/api/v1/session/login", `{"username":"admin","password":"admin"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"two_factor_required":true`)
+	pending := sessionCookie(w)
+	assert.Equal(t, http.StatusUnauthorized, serveAs(r, pending, http.MethodGet, "/api/v1/settings", "").Code)
+
+	w = serveAs(r, pending, http.MethodPost, "/api/v1/session/login/2fa", `{"code":"000000"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"invalid_two_factor_code"`)
+	var failures int64
+	require.NoError(t, db.DB.Model(&model.AuditLog{}).Where("action = ? AND details LIKE ?", service.AuditActionLoginFailure, "%invalid_second_factor%").Count(&failures).Error)
+	assert.EqualValues(t, 1, failures)
+
+	code, err := security.TOTPCode(secret, step+1)
+	require.NoError(t, err)
+	w = serveAs(r, pending, http.MethodPost, "/api/v1/session/login/2fa", `{"code":"`+code+`"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"second_factor":"totp"`)
+	assert.Equal(t, http.StatusOK, serveAs(r, sessionCookie(w), http.MethodGet, "/api/v1/settings", "").Code)
+
+	// A recovery code stands in for the authenticator, once.
+	w = serveAs(r, "", http.MethodPost, "/api/v1/session/login", `{"username":"admin","password":"admin"}`)
+	pending = sessionCookie(w)
+	w = serveAs(r, pending, http.MethodPost, "/api/v1/session/login/2fa", `{"code":"`+strings.ToUpper(recoveryCodes[0])+`"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"second_factor":"recovery_code"`)
+
+	// The legacy endpoint has no second step and must not bypass it.
+	w = serveAs(r, "", http.MethodPost, "/api/login", `{"username":"admin","password":"admin"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"two_factor_required":true`)
+
+	w = serveAs(r, "", http.MethodPost, "/api/v1/session/login/2fa", `{"code":"`+code+`"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"two_factor_expired"`)
+}
+
+func TestV1SecondFactorFailuresShareLoginLockout(t *testing.T) {
+	resetAuthFixtures(t)
+	r := setupRouter()
+	cookie, _ := loginCookie(t, r, "admin")
+	enableTestTwoFactor(t, r, cookie)
+
+	w := serveAs(r, "", http.MethodPost, "/api/v1/session/login", `{"username":"admin","password":"admin"}`)
+	pending := sessionCookie(w)
+	for range loginBackoffThreshold {
+		assert.Equal(t, http.StatusUnauthorized, serveAs(r, pending, http.MethodPost, "/api/v1/session/login/2fa", `{"code":"000000"}`).Code)
+	}
+	w = serveAs(r, pending, http.MethodPost, "/api/v1/session/login/2fa", `{"code":"000000"}`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, http.StatusTooManyRequests, serveAs(r, "", http.MethodPost, "/api/v1/session/login", `{"username":"admin","password":"admin"}`).Code)
+}
+
+func TestV1TwoFactorDisableAndLocalRecovery(t *testing.T) {
+	resetAuthFixtures(t)
+	r := setupRouter()
+	countAudits := func() int64 {
+		var count int64
+		require.NoError(t, db.DB.Model(&model.AuditLog{}).Where("action IN ?", []string{service.AuditActionTwoFactorEnable, service.AuditActionTwoFactorDisable}).Count(&count).Error)
+		return count
+	}
+	auditsBefore := countAudits()
+	cookie, _ := loginCookie(t, r, "admin")
+	enableTestTwoFactor(t, r, cookie)
+
+	w := serveAs(r, cookie, http.MethodPost, "/api/v1/session/2fa/enroll", "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = serveAs(r, cookie, http.MethodPost, "/api/v1/session/2fa/disable", `{"password":"wrong"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"invalid_current_password"`)
+	require.Equal(t, http.StatusOK, serveAs(r, cookie, http.MethodPost, "/api/v1/session/2fa/disable", `{"password":"admin"}`).Code)
+	assert.NotContains(t, serveAs(r, "", http.MethodPost, "/api/v1/session/login", `{"username":"admin","password":"admin"}`).Body.String(), "two_factor_required")
+
+	// Local recovery is for a lost authenticator as much as a lost password.
+	enableTestTwoFactor(t, r, cookie)
+	w = serveAs(r, "", http.MethodPost, "/api/v1/recovery/reset",
+		`{"username":"admin","password":"`+testRecoveryPassword+`","confirm_password":"`+testRecoveryPassword+`"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"two_factor_cleared":true`)
+	w = serveAs(r, "", http.MethodPost, "/api/v1/session/login", `{"username":"admin","password":"`+testRecoveryPassword+`"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "two_factor_required")
+
+	assert.EqualValues(t, 3, countAudits()-auditsBefore)
+}
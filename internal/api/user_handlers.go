@@ -0,0 +1,160 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+)
+
+type userView struct {
+	ID               uint      `json:"id"`
+	Username         string    `json:"username"`
+	Role             string    `json:"role"`
+	Current          bool      `json:"current"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type userRequest struct {
+	Username *string `json:"username"`
+	Password *string `json:"password"`
+	Role     *string `json:"role"`
+}
+
+func (r userRequest) input() service.UserInput {
+	return service.UserInput{Username: r.Username, Password: r.Password, Role: r.Role}
+}
+
+func newUserView(user *model.User, currentID uint) userView {
+	return userView{
+		ID:               user.ID,
+		Username:         user.Username,
+		Role:             service.EffectiveUserRole(user),
+		Current:          user.ID == currentID,
+		TwoFactorEnabled: service.TwoFactorEnabled(user),
+		CreatedAt:        user.CreatedAt,
+		UpdatedAt:        user.UpdatedAt,
+	}
+}
+
+func requestUserID(c *gin.Context) uint {
+	if user, ok := requestUser(c); ok {
+		return user.ID
+	}
+	return 0
+}
+
+func V1UsersHandler(c *gin.Context) {
+	users, err := service.NewAuthService().ListUsers()
+	if err != nil {
+		v1Error(c, http.StatusInternalServerError, "users_unavailable", "无法读取账户列表")
+		return
+	}
+	currentID := requestUserID(c)
+	items := make([]userView, 0, len(users))
+	for i := range users {
+		items = append(items, newUserView(&users[i], currentID))
+	}
+	v1Data(c, http.StatusOK, gin.H{"items": items, "roles": []string{model.UserRoleAdmin, model.UserRoleMember, model.UserRoleViewer}})
+}
+
+func V1CreateUserHandler(c *gin.Context) {
+	var req userRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		v1Error(c, http.StatusBadRequest, "invalid_user", "账户请求格式不正确")
+		return
+	}
+	user, err := service.NewAuthService().CreateManagedUser(req.input())
+	if err != nil {
+		writeUserError(c, err)
+		return
+	}
+	service.RecordAudit(buildAuditContext(c), service.AuditEntry{
+		Action:     service.AuditActionUserCreate,
+		TargetType: "user",
+		TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+		Outcome:    service.AuditOutcomeSuccess,
+		Details:    map[string]string{"username": user.Username, "role": user.Role},
+	})
+	v1Data(c, http.StatusCreated, newUserView(user, requestUserID(c)))
+}
+
+func V1UpdateUserHandler(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	var req userRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		v1Error(c, http.StatusBadRequest, "invalid_user", "账户请求格式不正确")
+		return
+	}
+	user, err := service.NewAuthService().UpdateManagedUser(id, req.input())
+	if err != nil {
+		writeUserError(c, err)
+		return
+	}
+	details := map[string]any{"username": user.Username, "role": user.Role, "password_changed": req.Password != nil}
+	service.RecordAudit(buildAuditContext(c), service.AuditEntry{
+		Action:     service.AuditActionUserUpdate,
+		TargetType: "user",
+		TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+		Outcome:    service.AuditOutcomeSuccess,
+		Details:    details,
+	})
+	v1Data(c, http.StatusOK, newUserView(user, requestUserID(c)))
+}
+
+func V1DeleteUserHandler(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	user, err := service.NewAuthService().DeleteManagedUser(id, requestUserID(c))
+	if err != nil {
+		writeUserError(c, err)
+		return
+	}
+	service.RecordAudit(buildAuditContext(c), service.AuditEntry{
+		Action:     service.AuditActionUserDelete,
+		TargetType: "user",
+		TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+		Outcome:    service.AuditOutcomeSuccess,
+		Details:    map[string]string{"username": user.Username},
+	})
+	v1Message(c, http.StatusOK, "账户已删除", nil)
+}
+
+func userIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		v1Error(c, http.StatusBadRequest, "invalid_user_id", "账户 ID 不正确")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func writeUserError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		v1Error(c, http.StatusNotFound, "user_not_found", err.Error())
+	case errors.Is(err, service.ErrUsernameTaken):
+		v1Error(c, http.StatusConflict, "username_taken", err.Error())
+	case errors.Is(err, service.ErrLastAdmin):
+		v1Error(c, http.StatusConflict, "last_admin", err.Error())
+	case errors.Is(err, service.ErrDeleteSelf):
+		v1Error(c, http.StatusConflict, "delete_self", err.Error())
+	case errors.Is(err, service.ErrInvalidUserRole):
+		v1Error(c, http.StatusBadRequest, "invalid_role", err.Error())
+	case errors.Is(err, service.ErrWeakUserPassword):
+		v1Error(c, http.StatusBadRequest, "invalid_password", err.Error())
+	default:
+		v1Error(c, http.StatusBadRequest, "invalid_user", err.Error())
+	}
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveAs(r *gin.Engine, cookie, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if cookie != "" {
+		req.Header.Set("Cookie", cookie)
+	}
+	markLocalRequest(req)
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func v1LoginCookie(t *testing.T, r *gin.Engine, username, password string) string {
+	t.Helper()
+	body, err := json.Marshal(map[string]string{"username": username, "password": password})
+	require.NoError(t, err)
+	w := serveAs(r, "", http.MethodPost, "/api/v1/session/login", string(body))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	return strings.SplitN(w.Header().Get("Set-Cookie"), ";", 2)[0]
+}
+
+func createTestUser(t *testing.T, r *gin.Engine, adminCookie, username, role string) uint {
+	t.Helper()
+	w := serveAs(r, adminCookie, http.MethodPost, "/api/v1/users",
+		`{"username":"`+username+`","password":"household-pass","role":"`+role+`"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var resp struct {
+		Data userView `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, role, resp.Data.Role)
+	return resp.Data.ID
+}
+
+func TestV1RolesRestrictProtectedRoutes(t *testing.T) {
+	resetAuthFixtures(t)
+	r := setupRouter()
+	adminCookie, _ := loginCookie(t, r, "admin")
+	createTestUser(t, r, adminCookie, "viewer", model.UserRoleViewer)
+	createTestUser(t, r, adminCookie, "member", model.UserRoleMember)
+	viewer := v1LoginCookie(t, r, "viewer", "household-pass")
+	member := v1LoginCookie(t, r, "member", "household-pass")
+
+	w := serveAs(r, viewer, http.MethodGet, "/api/v1/session", "")
+	assert.Contains(t, w.Body.String(), `"role":"viewer"`)
+
+	assert.Equal(t, http.StatusOK, serveAs(r, viewer, http.MethodGet, "/api/v1/library", "").Code)
+	assert.Equal(t, http.StatusOK, serveAs(r, viewer, http.MethodGet, "/api/v1/playback/continue", "").Code)
+	w = serveAs(r, viewer, http.MethodDelete, "/api/v1/subscriptions/999", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"forbidden"`)
+	assert.Equal(t, http.StatusForbidden, serveAs(r, viewer, http.MethodPut, "/api/v1/settings", `{"values":{}}`).Code)
+
+	assert.NotEqual(t, http.StatusForbidden, serveAs(r, member, http.MethodDelete, "/api/v1/subscriptions/999", "").Code)
+	for _, route := range [][2]string{
+		{http.MethodGet, "/api/v1/settings"},
+		{http.MethodPost, "/api/v1/backup/restore"},
+		{http.MethodPost, "/api/v1/settings/updater/check"},
+		{http.MethodGet, "/api/v1/users"},
+	} {
+		assert.Equal(t, http.StatusForbidden, serveAs(r, member, route[0], route[1], "{}").Code, route[1])
+	}
+	assert.Equal(t, http.StatusOK, serveAs(r, adminCookie, http.MethodGet, "/api/v1/settings", "").Code)
+
+	// The pre-v1 API has no role checks of its own, so only admins may use it.
+	for _, cookie := range []string{viewer, member} {
+		assert.Equal(t, http.StatusForbidden, serveAs(r, cookie, http.MethodGet, "/api/settings/qb-status", "").Code)
+		assert.Equal(t, http.StatusForbidden, serveAs(r, cookie, http.MethodDelete, "/api/subscriptions/999", "").Code)
+	}
+	assert.NotEqual(t, http.StatusForbidden, serveAs(r, adminCookie, http.MethodGet, "/api/settings/qb-status", "").Code)
+}
+
+func TestV1UserManagementKeepsAnAdmin(t *testing.T) {
+	resetAuthFixtures(t)
+	r := setupRouter()
+	adminCookie, _ := loginCookie(t, r, "admin")
+	admin, err := store.NewUserStore(db.DB).GetByUsername("admin")
+	require.NoError(t, err)
+	adminPath := "/api/v1/users/" + strconv.FormatUint(uint64(admin.ID), 10)
+
+	w := serveAs(r, adminCookie, http.MethodPut, adminPath, `{"role":"member"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"last_admin"`)
+	w = serveAs(r, adminCookie, http.MethodDelete, adminPath, "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"delete_self"`)
+
+	w = serveAs(r, adminCookie, http.MethodPost, "/api/v1/users", `{"username":"admin","password":"household-pass","role":"viewer"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = serveAs(r, adminCookie, http.MethodPost, "/api/v1/users", `{"username":"guest","password":"household-pass","role":"owner"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"invalid_role"`)
+
+	id := createTestUser(t, r, adminCookie, "partner", model.UserRoleMember)
+	partnerPath := "/api/v1/users/" + strconv.FormatUint(uint64(id), 10)
+	partner := v1LoginCookie(t, r, "partner", "household-pass")
+	assert.Equal(t, http.StatusForbidden, serveAs(r, partner, http.MethodGet, "/api/v1/settings", "").Code)
+
+	// 角色变更立即生效，无需重新登录
+	w = serveAs(r, adminCookie, http.MethodPut, partnerPath, `{"role":"admin","password":"partner-new-pass"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusOK, serveAs(r, partner, http.MethodGet, "/api/v1/settings", "").Code)
+	v1LoginCookie(t, r, "partner", "partner-new-pass")
+
+	// 已有第二个管理员后，原管理员可以降级
+	w = serveAs(r, adminCookie, http.MethodPut, adminPath, `{"role":"viewer"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = serveAs(r, adminCookie, http.MethodGet, "/api/v1/users", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = serveAs(r, partner, http.MethodDelete, adminPath, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusUnauthorized, serveAs(r, adminCookie, http.MethodGet, "/api/v1/library", "").Code)
+}
+
+func TestV1LocalRecoveryOnlyResetsAdmins(t *testing.T) {
+	resetAuthFixtures(t)
+	r := setupRouter()
+	adminCookie, _ := loginCookie(t, r, "admin")
+	createTestUser(t, r, adminCookie, "viewer", model.UserRoleViewer)
+
+	w := serveAs(r, "", http.MethodPost, "/api/v1/recovery/reset",
+		`{"username":"viewer","password":"`+testRecoveryPassword+`","confirm_password":"`+testRecoveryPassword+`"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "管理员")
+}
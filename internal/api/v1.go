@@ -17,15 +17,19 @@ import (
 	"github.com/google/uuid"
 	"github.com/pokerjest/animateAutoTool/internal/ai"
 	"github.com/pokerjest/animateAutoTool/internal/authsession"
+	"github.com/pokerjest/animateAutoTool/internal/backupdest"
 	"github.com/pokerjest/animateAutoTool/internal/bangumi"
 	"github.com/pokerjest/animateAutoTool/internal/bootstrap"
 	"github.com/pokerjest/animateAutoTool/internal/config"
 	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/downloader"
 	"github.com/pokerjest/animateAutoTool/internal/httpx"
 	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/pathutil"
 	"github.com/pokerjest/animateAutoTool/internal/renamer"
 	"github.com/pokerjest/animateAutoTool/internal/runtimejournal"
 	"github.com/pokerjest/animateAutoTool/internal/safeio"
+	"github.com/pokerjest/animateAutoTool/internal/scheduler"
 	"github.com/pokerjest/animateAutoTool/internal/service"
 	"github.com/pokerjest/animateAutoTool/internal/store"
 	"github.com/pokerjest/animateAutoTool/internal/taskstate"
@@ -116,6 +120,7 @@ func initV1Routes(r *gin.Engine) {
 	{
 		v1.GET("/session", V1SessionHandler)
 		v1.POST("/session/login", SameOriginMiddleware(), V1LoginHandler)
+		v1.POST("/session/login/2fa", SameOriginMiddleware(), V1LoginTwoFactorHandler)
 		v1.POST("/session/bootstrap", DirectLocalOnlyMiddleware(), SameOriginMiddleware(), V1BootstrapSessionHandler)
 		v1.GET("/netbird/jellyfin/stream/:id", NetBirdProxyVideoHandler)
 		v1.GET("/netbird/media/:provider/stream/:id", NetBirdProxyMediaHandler)
@@ -127,27 +132,52 @@ func initV1Routes(r *gin.Engine) {
 		recovery.POST("/reset", V1RecoveryHandler)
 	}
 
+	// Every signed-in account may browse and play. Members also manage
+	// subscriptions and the library; admins own settings, backups, updates,
+	// diagnostics and accounts.
 	protected := v1.Group("")
-	protected.Use(AuthMiddleware(), SameOriginMiddleware())
+	protected.Use(APITokenMiddleware(), AuthMiddleware(), SameOriginMiddleware(), RequireRoleMiddleware(model.UserRoleViewer))
+	member := protected.Group("")
+	member.Use(RequireRoleMiddleware(model.UserRoleMember))
+	admin := protected.Group("")
+	admin.Use(RequireRoleMiddleware(model.UserRoleAdmin))
 	{
 		protected.POST("/session/logout", V1LogoutHandler)
 		protected.POST("/session/change-password", V1ChangePasswordHandler)
 		protected.POST("/session/change-username", V1ChangeUsernameHandler)
+		protected.GET("/session/2fa", V1TwoFactorStatusHandler)
+		protected.POST("/session/2fa/enroll", V1TwoFactorEnrollHandler)
+		protected.POST("/session/2fa/confirm", V1TwoFactorConfirmHandler)
+		protected.POST("/session/2fa/recovery-codes", V1TwoFactorRecoveryCodesHandler)
+		protected.POST("/session/2fa/disable", V1TwoFactorDisableHandler)
+		protected.GET("/tokens", V1APITokensHandler)
+		protected.POST("/tokens", V1CreateAPITokenHandler)
+		protected.DELETE("/tokens/:id", V1RevokeAPITokenHandler)
 		protected.GET("/events", SSEHandler)
+		protected.GET("/events/history", V1EventHistoryHandler)
 		protected.GET("/tasks", V1TasksHandler)
 		protected.GET("/tasks/:task_id", V1TaskHandler)
+		member.POST("/tasks/:task_id/cancel", V1CancelTaskHandler)
 
-		protected.GET("/setup/readiness", V1SetupReadinessHandler)
-		protected.POST("/setup/bootstrap", V1SetupBootstrapHandler)
-		protected.POST("/system/pick-directory", V1PickDirectoryHandler)
+		admin.GET("/setup/readiness", V1SetupReadinessHandler)
+		admin.POST("/setup/bootstrap", V1SetupBootstrapHandler)
+		admin.POST("/system/pick-directory", V1PickDirectoryHandler)
 
 		protected.GET("/dashboard", V1DashboardHandler)
-		protected.POST("/tasks/sync", V1SyncHandler)
+		member.POST("/tasks/sync", V1SyncHandler)
 		protected.GET("/subscriptions", V1SubscriptionsHandler)
-		protected.POST("/subscriptions", V1CreateSubscriptionHandler)
-		protected.POST("/subscriptions/batch", V1BatchCreateHandler)
-		protected.POST("/subscriptions/batch-preview", V1BatchPreviewHandler)
+		member.POST("/subscriptions", V1CreateSubscriptionHandler)
+		member.POST("/subscriptions/batch", V1BatchCreateHandler)
+		member.POST("/subscriptions/batch-preview", V1BatchPreviewHandler)
+		protected.GET("/subscriptions/export", V1ExportSubscriptionsHandler)
+		member.POST("/subscriptions/import", V1ImportSubscriptionsHandler)
+		member.POST("/subscriptions/import/:source", V1PreviewSourceSubscriptionsHandler)
+		protected.GET("/subscriptions/bangumi-sync", V1BangumiSyncHandler)
+		member.POST("/subscriptions/bangumi-sync/run", V1RunBangumiSyncHandler)
+		member.POST("/subscriptions/bangumi-sync/:id/accept", V1BangumiSyncActionHandler("accept"))
+		member.POST("/subscriptions/bangumi-sync/:id/dismiss", V1BangumiSyncActionHandler("dismiss"))
 		protected.GET("/subscriptions/validate-rss", V1ValidateRSSHandler)
+		protected.GET("/subscriptions/parsers", V1SubscriptionParsersHandler)
 		protected.GET("/subscriptions/search", V1MikanSearchHandler)
 		protected.GET("/subscriptions/rss-preview", V1RSSPreviewHandler)
 		protected.GET("/subscriptions/mikan/dashboard", V1MikanDashboardHandler)
@@ -156,24 +186,24 @@ func initV1Routes(r *gin.Engine) {
 		protected.GET("/subscriptions/mikan/resolve", V1MikanResolveHandler)
 		protected.GET("/subscriptions/mikan/subgroups", V1MikanSubgroupsHandler)
 		protected.GET("/subscriptions/:id/poster", V1SubscriptionPosterHandler)
-		protected.POST("/subscriptions/refresh", V1RefreshSubscriptionsHandler)
-		protected.PUT("/subscriptions/:id", V1UpdateSubscriptionHandler)
-		protected.POST("/subscriptions/:id/toggle", V1SubscriptionActionHandler("toggle"))
-		protected.POST("/subscriptions/:id/run", V1SubscriptionActionHandler("run"))
-		protected.POST("/subscriptions/:id/repair/:action", V1SubscriptionRepairHandler)
-		protected.POST("/subscriptions/:id/refresh-metadata", V1RefreshSubscriptionMetadataHandler)
-		protected.POST("/subscriptions/:id/source", V1SubscriptionSourceHandler)
-		protected.DELETE("/subscriptions/:id", V1DeleteSubscriptionHandler)
+		member.POST("/subscriptions/refresh", V1RefreshSubscriptionsHandler)
+		member.PUT("/subscriptions/:id", V1UpdateSubscriptionHandler)
+		member.POST("/subscriptions/:id/toggle", V1SubscriptionActionHandler("toggle"))
+		member.POST("/subscriptions/:id/run", V1SubscriptionActionHandler("run"))
+		member.POST("/subscriptions/:id/repair/:action", V1SubscriptionRepairHandler)
+		member.POST("/subscriptions/:id/refresh-metadata", V1RefreshSubscriptionMetadataHandler)
+		member.POST("/subscriptions/:id/source", V1SubscriptionSourceHandler)
+		member.DELETE("/subscriptions/:id", V1DeleteSubscriptionHandler)
 		protected.GET("/subscriptions/:id/history", V1SubscriptionHistoryHandler)
 		protected.GET("/subscriptions/:id/resources", V1SubscriptionResourcesHandler)
-		protected.POST("/subscriptions/:id/resources/:resource_id/:action", V1SubscriptionResourceActionHandler)
+		member.POST("/subscriptions/:id/resources/:resource_id/:action", V1SubscriptionResourceActionHandler)
 
 		protected.GET("/calendar", V1CalendarHandler)
 		protected.GET("/calendar/posters/:id", V1CalendarPosterHandler)
 		protected.GET("/library", V1LibraryHandler)
-		protected.POST("/library/refresh", V1RefreshLibraryHandler)
-		protected.POST("/library/metadata/:id/refresh", V1RefreshMetadataItemHandler)
-		protected.POST("/library/fix-match", V1FixMatchHandler)
+		member.POST("/library/refresh", V1RefreshLibraryHandler)
+		member.POST("/library/metadata/:id/refresh", V1RefreshMetadataItemHandler)
+		member.POST("/library/fix-match", V1FixMatchHandler)
 		protected.GET("/metadata/search", V1MetadataSearchHandler)
 		protected.GET("/metadata/match-search", V1MetadataMatchSearchHandler)
 		protected.GET("/media/providers", V1MediaProvidersHandler)
@@ -192,15 +222,19 @@ func initV1Routes(r *gin.Engine) {
 		protected.GET("/local-anime", V1LocalAnimeHandler)
 		protected.GET("/local-anime/:id/episodes", V1LocalAnimeEpisodesHandler)
 		protected.GET("/local-anime/:id/files", V1LocalAnimeFilesHandler)
-		protected.POST("/local-anime/scan", V1LocalScanHandler)
-		protected.POST("/local-directories", V1AddLocalDirectoryHandler)
-		protected.DELETE("/local-directories/:id", V1DeleteLocalDirectoryHandler)
-		protected.POST("/local-anime/:id/refresh-metadata", V1RefreshLocalMetadataHandler)
-		protected.POST("/local-anime/:id/source", V1LocalAnimeSourceHandler)
-		protected.POST("/local-anime/organize/preview", V1PreviewLocalOrganizeHandler)
-		protected.POST("/local-anime/organize", V1ApplyLocalOrganizeHandler)
-		protected.POST("/local-directories/:id/rename-preview", V1RenamePreviewHandler)
-		protected.POST("/local-directories/:id/rename", V1RenameApplyHandler)
+		protected.GET("/local-anime/episodes/:id/play", V1LocalEpisodePlayInfoHandler)
+		protected.GET("/local-anime/episodes/:id/stream", V1LocalEpisodeStreamHandler)
+		protected.GET("/local-anime/episodes/:id/subtitles", V1LocalEpisodeSubtitlesHandler)
+		protected.GET("/local-anime/episodes/:id/subtitles/:track", V1LocalEpisodeSubtitleHandler)
+		member.POST("/local-anime/scan", V1LocalScanHandler)
+		admin.POST("/local-directories", V1AddLocalDirectoryHandler)
+		admin.DELETE("/local-directories/:id", V1DeleteLocalDirectoryHandler)
+		member.POST("/local-anime/:id/refresh-metadata", V1RefreshLocalMetadataHandler)
+		member.POST("/local-anime/:id/source", V1LocalAnimeSourceHandler)
+		member.POST("/local-anime/organize/preview", V1PreviewLocalOrganizeHandler)
+		member.POST("/local-anime/organize", V1ApplyLocalOrganizeHandler)
+		member.POST("/local-directories/:id/rename-preview", V1RenamePreviewHandler)
+		member.POST("/local-directories/:id/rename", V1RenameApplyHandler)
 		protected.GET("/jellyfin/stream/:id", ProxyVideoHandler)
 		protected.GET("/media/providers/:provider/items/:id/stream", MediaStreamHandler)
 		protected.GET("/jellyfin/play/:id", GetPlayInfoHandler)
@@ -209,51 +243,68 @@ func initV1Routes(r *gin.Engine) {
 		protected.PUT("/jellyfin/episodes/:id/user-state", UpdateJellyfinEpisodeStateHandler)
 		protected.PUT("/jellyfin/series/:id/user-state", UpdateJellyfinSeriesStateHandler)
 		protected.POST("/jellyfin/progress", ReportProgressHandler)
-		protected.POST("/bangumi/subject/:id/collection", V1BangumiCollectionHandler)
-		protected.POST("/bangumi/subject/:id/progress", V1BangumiProgressHandler)
-
-		protected.GET("/backup", V1BackupHandler)
-		protected.POST("/backup/export", ExportBackupHandler)
-		protected.POST("/backup/analyze", V1AnalyzeBackupHandler)
-		protected.POST("/backup/restore", V1RestoreBackupHandler)
-		protected.GET("/backup/r2/list", V1R2ListHandler)
-		protected.POST("/backup/r2/upload", V1R2UploadHandler)
-		protected.POST("/backup/r2/stage", V1R2StageHandler)
-		protected.GET("/backup/r2/progress/:taskId", V1R2ProgressHandler)
-		protected.POST("/backup/r2/test", V1R2TestHandler)
-		protected.POST("/backup/r2/delete", V1DeleteR2Handler)
-
-		protected.GET("/health", V1HealthHandler)
-		protected.GET("/runtime", V1RuntimeHandler)
-		protected.GET("/diagnostics/logs/export", V1ExportDiagnosticLogsHandler)
-		protected.GET("/diagnostics/health/export", V1ExportHealthDiagnosticsHandler)
-		protected.GET("/audit-logs", V1AuditLogsHandler)
-		protected.GET("/settings", V1SettingsHandler)
-		protected.PUT("/settings", V1UpdateSettingsHandler)
-		protected.POST("/settings/proxy/test", V1ProxyTestHandler)
-		protected.GET("/settings/connections/:provider", V1ConnectionStatusHandler)
-		protected.GET("/settings/maintenance", V1MaintenanceHandler)
-		protected.GET("/settings/updater/releases", V1UpdaterReleasesHandler)
-		protected.GET("/settings/updater/snapshots", V1UpdaterSnapshotsHandler)
-		protected.POST("/settings/updater/rollback", V1UpdaterRollbackHandler)
-		protected.POST("/settings/updater/:action", V1UpdaterActionHandler)
-		protected.GET("/settings/ai", V1AIStatusHandler)
-		protected.GET("/settings/ai/models", V1AIModelsHandler)
-		protected.POST("/settings/ai/models", V1AIModelsPostHandler)
-		protected.POST("/settings/ai/test", V1AITestHandler)
-		protected.GET("/assistant/messages", V1AssistantMessagesHandler)
-		protected.POST("/assistant/messages", V1AssistantMessageHandler)
-		protected.DELETE("/assistant/messages", V1AssistantClearHandler)
-		protected.POST("/ai/filename-resolutions", V1AIFilenameResolutionHandler)
-		protected.POST("/ai/health/analyze", V1AIHealthAnalyzeHandler)
-		protected.POST("/ai/library-issues/:id/analyze", V1AILibraryIssueAnalyzeHandler)
-		protected.POST("/ai/metadata/local-anime/:id/suggest", V1AIMetadataSuggestHandler)
-		protected.POST("/ai/subscriptions/:id/rules/suggest", V1AISubscriptionRulesSuggestHandler)
-		protected.GET("/ai/proposals/:id", V1AIProposalHandler)
-		protected.POST("/ai/proposals/:id/confirm", V1AIProposalConfirmHandler)
-		protected.POST("/ai/proposals/:id/apply", V1AIProposalApplyHandler)
-		protected.POST("/ai/proposals/:id/dismiss", V1AIProposalDismissHandler)
-		protected.GET("/ai/tool-runs", V1AIToolRunsHandler)
+		member.POST("/bangumi/subject/:id/collection", V1BangumiCollectionHandler)
+		member.POST("/bangumi/subject/:id/progress", V1BangumiProgressHandler)
+		admin.POST("/anilist/media/:id/entry", V1AniListEntryHandler)
+		admin.POST("/anilist/sync", V1RunAniListSyncHandler)
+
+		admin.GET("/backup", V1BackupHandler)
+		admin.POST("/backup/export", ExportBackupHandler)
+		admin.POST("/backup/analyze", V1AnalyzeBackupHandler)
+		admin.POST("/backup/restore", V1RestoreBackupHandler)
+		admin.GET("/backup/r2/list", V1R2ListHandler)
+		admin.POST("/backup/r2/upload", V1R2UploadHandler)
+		admin.POST("/backup/r2/stage", V1R2StageHandler)
+		admin.GET("/backup/r2/progress/:taskId", V1R2ProgressHandler)
+		admin.POST("/backup/r2/test", V1R2TestHandler)
+		admin.POST("/backup/r2/delete", V1DeleteR2Handler)
+		admin.GET("/backup/schedule", V1BackupScheduleHandler)
+		admin.POST("/backup/schedule/run", V1RunBackupScheduleHandler)
+
+		admin.GET("/health", V1HealthHandler)
+		admin.GET("/runtime", V1RuntimeHandler)
+		admin.GET("/diagnostics/logs/export", V1ExportDiagnosticLogsHandler)
+		admin.GET("/diagnostics/health/export", V1ExportHealthDiagnosticsHandler)
+		admin.GET("/audit-logs", V1AuditLogsHandler)
+		admin.GET("/users", V1UsersHandler)
+		admin.POST("/users", V1CreateUserHandler)
+		admin.PUT("/users/:id", V1UpdateUserHandler)
+		admin.DELETE("/users/:id", V1DeleteUserHandler)
+		admin.GET("/settings", V1SettingsHandler)
+		admin.PUT("/settings", V1UpdateSettingsHandler)
+		admin.GET("/settings/secrets", V1SettingsSecretsHandler)
+		admin.POST("/settings/secrets/rotate", V1RotateSettingsKeyHandler)
+		admin.POST("/settings/proxy/test", V1ProxyTestHandler)
+		admin.POST("/settings/downloader/path-mappings/test", V1DownloaderPathMappingTestHandler)
+		admin.GET("/settings/connections/:provider", V1ConnectionStatusHandler)
+		admin.GET("/settings/notifications", V1NotificationChannelsHandler)
+		admin.POST("/settings/notifications", V1CreateNotificationChannelHandler)
+		admin.GET("/settings/notifications/deliveries", V1NotificationDeliveriesHandler)
+		admin.PUT("/settings/notifications/:id", V1UpdateNotificationChannelHandler)
+		admin.DELETE("/settings/notifications/:id", V1DeleteNotificationChannelHandler)
+		admin.POST("/settings/notifications/:id/test", V1TestNotificationChannelHandler)
+		admin.GET("/settings/maintenance", V1MaintenanceHandler)
+		admin.GET("/settings/updater/releases", V1UpdaterReleasesHandler)
+		admin.GET("/settings/updater/snapshots", V1UpdaterSnapshotsHandler)
+		admin.POST("/settings/updater/rollback", V1UpdaterRollbackHandler)
+		admin.POST("/settings/updater/:action", V1UpdaterActionHandler)
+		admin.GET("/settings/ai", V1AIStatusHandler)
+		admin.GET("/settings/ai/models", V1AIModelsHandler)
+		admin.POST("/settings/ai/models", V1AIModelsPostHandler)
+		admin.POST("/settings/ai/test", V1AITestHandler)
+		member.GET("/assistant/messages", V1AssistantMessagesHandler)
+		member.POST("/assistant/messages", V1AssistantMessageHandler)
+		member.DELETE("/assistant/messages", V1AssistantClearHandler)
+		member.POST("/ai/filename-resolutions", V1AIFilenameResolutionHandler)
+		member.POST("/ai/health/analyze", V1AIHealthAnalyzeHandler)
+		member.POST("/ai/library-issues/:id/analyze", V1AILibraryIssueAnalyzeHandler)
+		member.POST("/ai/metadata/local-anime/:id/suggest", V1AIMetadataSuggestHandler)
+		member.POST("/ai/subscriptions/:id/rules/suggest", V1AISubscriptionRulesSuggestHandler)
+		member.GET("/ai/proposals/:id", V1AIProposalHandler)
+		member.POST("/ai/proposals/:id/confirm", V1AIProposalConfirmHandler)
+		member.POST("/ai/proposals/:id/apply", V1AIProposalApplyHandler)
+		member.POST("/ai/proposals/:id/dismiss", V1AIProposalDismissHandler)
+		member.GET("/ai/tool-runs", V1AIToolRunsHandler)
 	}
 }
 
@@ -270,6 +321,55 @@ func V1TaskHandler(c *gin.Context) {
 	v1Data(c, http.StatusOK, task)
 }
 
+// V1CancelTaskHandler asks a running task to stop. Admins may cancel any
+// task; members only the ones they started.
+func V1CancelTaskHandler(c *gin.Context) {
+	taskID := c.Param("task_id")
+	task, ok := taskstate.Global.Get(taskID)
+	if !ok {
+		v1Error(c, http.StatusNotFound, "task_not_found", "未找到对应任务")
+		return
+	}
+	user, _ := requestUser(c)
+	if user != nil && !service.UserRoleAllows(user, model.UserRoleAdmin) && task.UserID != user.ID {
+		v1Error(c, http.StatusForbidden, "forbidden", "只能取消自己发起的任务")
+		return
+	}
+	task, err := taskstate.Global.Cancel(taskID)
+	switch {
+	case errors.Is(err, taskstate.ErrTaskNotFound):
+		v1Error(c, http.StatusNotFound, "task_not_found", "未找到对应任务")
+		return
+	case errors.Is(err, taskstate.ErrTaskFinished):
+		v1Error(c, http.StatusConflict, "task_finished", "任务已经结束")
+		return
+	case errors.Is(err, taskstate.ErrTaskNotCancellable):
+		v1Error(c, http.StatusConflict, "task_not_cancellable", "该任务不支持取消")
+		return
+	case err != nil:
+		v1Error(c, http.StatusInternalServerError, "task_cancel_failed", err.Error())
+		return
+	}
+	service.RecordAudit(buildAuditContext(c), service.AuditEntry{
+		Action:     service.AuditActionTaskCancel,
+		TargetType: "task",
+		TargetID:   task.TaskID,
+		Details:    map[string]any{"kind": task.Kind, "title": task.Title},
+	})
+	v1Message(c, http.StatusAccepted, "已请求取消任务", task)
+}
+
+// taskInitiator records the account behind the request on tasks it starts.
+// It reads the user RequireRoleMiddleware already loaded for the route.
+func taskInitiator(c *gin.Context) taskstate.Initiator {
+	value, _ := c.Get(requestUserContextKey)
+	user, ok := value.(*model.User)
+	if !ok || user == nil {
+		return taskstate.Initiator{}
+	}
+	return taskstate.Initiator{UserID: user.ID, Username: user.Username}
+}
+
 func V1SessionHandler(c *gin.Context) {
 	setupPending := bootstrap.BootstrapSetupPending()
 	data := gin.H{
@@ -284,11 +384,13 @@ func V1SessionHandler(c *gin.Context) {
 	if user, err := currentSessionUser(c); err == nil && user != nil {
 		data["authenticated"] = true
 		data["username"] = user.Username
+		data["role"] = service.EffectiveUserRole(user)
 		data["auth_mode"] = "session"
-	} else if user, passwordless := passwordlessAdminForRequest(c); passwordless {
+	} else if user, mode, passwordless := passwordlessUserForRequest(c); passwordless {
 		data["authenticated"] = true
 		data["username"] = user.Username
-		data["auth_mode"] = "ip_allowlist"
+		data["role"] = service.EffectiveUserRole(user)
+		data["auth_mode"] = mode
 	}
 	v1Data(c, http.StatusOK, data)
 }
@@ -345,16 +447,18 @@ func V1LoginHandler(c *gin.Context) {
 		v1Error(c, http.StatusUnauthorized, "invalid_credentials", "用户名或密码不正确")
 		return
 	}
-	clearFailedLoginAttempts(clientIP)
-	session := sessions.Default(c)
-	session.Set("user_id", user.ID)
-	session.Set("auth_generation", authsession.Current())
-	maxAge := 0
-	if req.RememberMe {
-		maxAge = 3600 * 24 * 30
+	if service.TwoFactorEnabled(user) {
+		// Failed attempts are only cleared once the second step passes, so a
+		// known password does not reset the lockout for guessing codes.
+		if err := startTwoFactorChallenge(c, user, req.RememberMe); err != nil {
+			v1Error(c, http.StatusInternalServerError, "session_save_failed", "无法保存登录状态")
+			return
+		}
+		v1Message(c, http.StatusOK, "请输入两步验证码", gin.H{"two_factor_required": true})
+		return
 	}
-	session.Options(sessionCookieOptions(c, maxAge))
-	if err := session.Save(); err != nil {
+	clearFailedLoginAttempts(clientIP)
+	if err := saveLoginSession(c, user, req.RememberMe); err != nil {
 		v1Error(c, http.StatusInternalServerError, "session_save_failed", "无法保存登录状态")
 		return
 	}
@@ -448,14 +552,19 @@ func V1RecoveryHandler(c *gin.Context) {
 		v1Error(c, http.StatusBadRequest, "bootstrap_user_mismatch", "首次初始化期间只能重置 bootstrap 管理员")
 		return
 	}
-	if err := service.NewAuthService().ResetPasswordByUsername(req.Username, req.Password); err != nil {
+	twoFactorCleared, err := service.NewAuthService().ResetPasswordByUsername(req.Username, req.Password)
+	if err != nil {
 		service.RecordAudit(auditContextForLogin(c, req.Username), service.AuditEntry{Action: service.AuditActionPasswordRecoveryLoc, Outcome: service.AuditOutcomeFailure, Details: map[string]string{"error": err.Error()}})
 		v1Error(c, http.StatusBadRequest, "password_reset_failed", err.Error())
 		return
 	}
 	clearFailedLoginAttempts(requestClientIP(c))
-	service.RecordAudit(auditContextForLogin(c, req.Username), service.AuditEntry{Action: service.AuditActionPasswordRecoveryLoc, Outcome: service.AuditOutcomeSuccess})
-	v1Message(c, http.StatusOK, "密码重置成功", nil)
+	service.RecordAudit(auditContextForLogin(c, req.Username), service.AuditEntry{Action: service.AuditActionPasswordRecoveryLoc, Outcome: service.AuditOutcomeSuccess, Details: map[string]bool{"two_factor_cleared": twoFactorCleared}})
+	message := "密码重置成功"
+	if twoFactorCleared {
+		message = "密码重置成功，两步验证已关闭，请登录后重新绑定"
+	}
+	v1Message(c, http.StatusOK, message, gin.H{"two_factor_cleared": twoFactorCleared})
 }
 
 func V1SetupReadinessHandler(c *gin.Context) {
@@ -499,7 +608,7 @@ func V1SyncHandler(c *gin.Context) {
 		return
 	}
 	const taskID = "manual-sync"
-	taskstate.Global.Start(taskID, "sync", "立即同步", "正在同步订阅、本地媒体和下载状态")
+	taskstate.Global.StartBy(taskInitiator(c), taskID, "sync", "立即同步", "正在同步订阅、本地媒体和下载状态")
 	GoBackground(func(ctx context.Context) {
 		if err := runDashboardSyncNow(ctx); err != nil {
 			log.Printf("manual dashboard sync failed: %v", err)
@@ -559,6 +668,10 @@ func V1CreateSubscriptionHandler(c *gin.Context) {
 		return
 	}
 	if err := createSubscriptionInternal(&sub); err != nil {
+		if errors.Is(err, errUnknownSubscriptionParser) {
+			v1Error(c, http.StatusBadRequest, "invalid_subscription_parser", err.Error())
+			return
+		}
 		status := http.StatusInternalServerError
 		if err.Error() == "exists" {
 			status = http.StatusConflict
@@ -585,7 +698,7 @@ func V1SubscriptionActionHandler(action string) gin.HandlerFunc {
 			}
 		case "run":
 			taskID := "subscription-" + c.Param("id")
-			taskstate.Global.Start(taskID, "subscription", "订阅检查", "正在检查 "+sub.Title)
+			taskstate.Global.StartBy(taskInitiator(c), taskID, "subscription", "订阅检查", "正在检查 "+sub.Title)
 			GoBackground(func(ctx context.Context) {
 				checkErr := runSubscriptionCheck(sub, "manual")
 				if reconcileErr := reconcileSubscriptionLibraryState(ctx); reconcileErr != nil {
@@ -618,8 +731,12 @@ func V1DeleteSubscriptionHandler(c *gin.Context) {
 		return
 	}
 	subTitle := ""
-	if existing, err := s.GetByID(uint(id)); err == nil && existing != nil {
+	if existing, err := s.GetByIDWithMetadata(uint(id)); err == nil && existing != nil {
 		subTitle = existing.Title
+		if err := service.RecordBangumiSyncDeletion(existing); err != nil {
+			v1Error(c, http.StatusInternalServerError, "subscription_delete_failed", err.Error())
+			return
+		}
 	}
 	if err := s.DeleteCascade(uint(id)); err != nil {
 		v1Error(c, http.StatusInternalServerError, "subscription_delete_failed", err.Error())
@@ -815,8 +932,10 @@ func V1LocalScanHandler(c *gin.Context) {
 		return
 	}
 	const taskID = "local-scan"
-	taskstate.Global.Start(taskID, "scan", "本地扫描", "正在扫描本地媒体目录")
-	GoBackground(func(ctx context.Context) {
+	taskstate.Global.StartBy(taskInitiator(c), taskID, "scan", "本地扫描", "正在扫描本地媒体目录")
+	GoBackground(func(appCtx context.Context) {
+		ctx, cancel := taskstate.Global.WithCancel(appCtx, taskID)
+		defer cancel()
 		scanner := service.NewScannerService()
 		if err := scanner.ScanAllWithProgressContext(ctx, func(progress service.ScanProgress) {
 			reportLocalScanProgress(taskID, progress)
@@ -826,7 +945,7 @@ func V1LocalScanHandler(c *gin.Context) {
 			return
 		}
 		startLocalMetadataProgress(taskID)
-		repairResult, repairErr := runLocalMetadataPhase(taskID)
+		repairResult, repairErr := runLocalMetadataPhase(ctx, taskID)
 		if repairErr != nil {
 			taskstate.Global.Fail(taskID, repairErr)
 			return
@@ -858,8 +977,10 @@ func V1AddLocalDirectoryHandler(c *gin.Context) {
 		return
 	}
 	const taskID = "local-scan"
-	taskstate.Global.Start(taskID, "scan", "本地扫描", "目录已添加，正在扫描本地媒体")
-	GoBackground(func(ctx context.Context) {
+	taskstate.Global.StartBy(taskInitiator(c), taskID, "scan", "本地扫描", "目录已添加，正在扫描本地媒体")
+	GoBackground(func(appCtx context.Context) {
+		ctx, cancel := taskstate.Global.WithCancel(appCtx, taskID)
+		defer cancel()
 		if err := service.NewScannerService().ScanAllWithProgressContext(ctx, func(progress service.ScanProgress) {
 			reportLocalScanProgress(taskID, progress)
 		}); err != nil {
@@ -867,7 +988,7 @@ func V1AddLocalDirectoryHandler(c *gin.Context) {
 			return
 		}
 		startLocalMetadataProgress(taskID)
-		repairResult, repairErr := runLocalMetadataPhase(taskID)
+		repairResult, repairErr := runLocalMetadataPhase(ctx, taskID)
 		if repairErr != nil {
 			taskstate.Global.Fail(taskID, repairErr)
 			return
@@ -916,8 +1037,8 @@ func rejectRuntimeRecoveryTask(c *gin.Context) bool {
 	}
 }
 
-func runLocalMetadataPhase(taskID string) (service.MetadataIssueRepairResult, error) {
-	return service.NewAgentService().RunAgentForLibraryWithRepair(func(progress service.MetadataIssueRepairProgress) {
+func runLocalMetadataPhase(ctx context.Context, taskID string) (service.MetadataIssueRepairResult, error) {
+	return service.NewAgentService().RunAgentForLibraryWithRepairContext(ctx, func(progress service.MetadataIssueRepairProgress) {
 		phase := strings.TrimSpace(progress.Phase)
 		if phase == "" {
 			phase = "metadata"
@@ -944,24 +1065,26 @@ func appendMetadataRepairSummary(summary string, result service.MetadataIssueRep
 
 func V1BackupHandler(c *gin.Context) {
 	stats := getDBStats(db.DB, db.CurrentDBPath)
-	configured := configValue(model.ConfigKeyR2Endpoint) != "" && configValue(model.ConfigKeyR2Bucket) != ""
+	kind := configuredBackupDestinationKind()
+	configured := backupDestinationConfigured(kind)
 	files := []R2BackupFile{}
 	if configured {
 		// The overview is also the first request after an upload/delete. Use
 		// the shared cache loader so an invalidated cache is repopulated from
-		// R2 instead of making the cloud section appear to lose all backups.
-		if loaded, err := listR2Backups(c.Request.Context(), false); err == nil {
+		// the destination instead of making the cloud section appear to lose
+		// all backups.
+		if loaded, err := listRemoteBackups(c.Request.Context(), false); err == nil {
 			files = loaded
 		} else {
-			debugLog("DEBUG: V1BackupHandler - Unable to refresh R2 list: %v", err)
-			r2BackupCacheLock.RLock()
-			files = append(files, r2BackupCache...)
-			r2BackupCacheLock.RUnlock()
+			debugLog("DEBUG: V1BackupHandler - Unable to refresh %s list: %v", kind, err)
+			if cached, _ := cachedRemoteBackups(kind); cached != nil {
+				files = cached
+			}
 		}
 	}
 	v1Data(c, http.StatusOK, gin.H{
 		"stats": gin.H{"subscription_count": stats.SubscriptionCount, "download_log_count": stats.DownloadLogCount, "local_anime_count": stats.LocalAnimeCount, "user_count": stats.UserCount, "global_config_count": stats.GlobalConfigCount, "database_size": stats.DatabaseSize, "last_modified": stats.LastModified},
-		"r2":    gin.H{"configured": configured, "files": files},
+		"r2":    gin.H{"configured": configured, "files": files, "destination": kind, "label": backupdest.Label(kind)},
 	})
 }
 
@@ -1069,6 +1192,9 @@ var v1SecretConfigKeys = map[string]bool{
 	model.ConfigKeyJellyfinPassword: true, model.ConfigKeyJellyfinApiKey: true, model.ConfigKeyAListToken: true, model.ConfigKeyAIApiKey: true,
 	model.ConfigKeyAIOpenAIAPIKey: true, model.ConfigKeyAIGeminiAPIKey: true, model.ConfigKeyAIClaudeAPIKey: true,
 	model.ConfigKeyR2AccessKey: true, model.ConfigKeyR2SecretKey: true, model.ConfigKeyPikPakPassword: true, model.ConfigKeyPikPakRefreshToken: true,
+	model.ConfigKeyBackupSchedulePassword: true, model.ConfigKeyS3AccessKey: true, model.ConfigKeyS3SecretKey: true,
+	model.ConfigKeyWebDAVPassword: true, model.ConfigKeySFTPPassword: true, model.ConfigKeySFTPPrivateKey: true,
+	model.ConfigKeyMetricsToken: true,
 }
 
 type v1URLSettingNormalizer struct {
@@ -1077,6 +1203,137 @@ type v1URLSettingNormalizer struct {
 }
 
 var v1SettingNormalizers = map[string]v1URLSettingNormalizer{
+	model.ConfigKeyDownloaderBackend: {
+		errorCode: "invalid_downloader_backend",
+		normalize: func(value string) (string, error) {
+			if !downloader.IsKnownBackend(value) {
+				return "", errors.New("下载器只支持 qbittorrent、transmission 或 aria2")
+			}
+			return downloader.NormalizeBackend(value), nil
+		},
+	},
+	model.ConfigKeyDownloaderPathMappings: {
+		errorCode: "invalid_downloader_path_mappings",
+		normalize: func(value string) (string, error) {
+			mappings, err := pathutil.ParseMappings(value)
+			if err != nil {
+				return "", err
+			}
+			return pathutil.FormatMappings(mappings), nil
+		},
+	},
+	model.ConfigKeySchedulerIntervalMinutes: {
+		errorCode: "invalid_scheduler_interval",
+		normalize: scheduler.NormalizeIntervalMinutes,
+	},
+	model.ConfigKeySchedulerQuietHours: {
+		errorCode: "invalid_scheduler_quiet_hours",
+		normalize: func(value string) (string, error) {
+			quiet, err := scheduler.ParseQuietHours(value)
+			if err != nil {
+				return "", err
+			}
+			return quiet.String(), nil
+		},
+	},
+	model.ConfigKeySchedulerSmartPolling: {
+		errorCode: "invalid_scheduler_smart_polling",
+		normalize: func(value string) (string, error) {
+			value = strings.ToLower(value)
+			if value != model.ConfigValueTrue && value != ValueFalse {
+				return "", errors.New("智能轮询开关必须为 true 或 false")
+			}
+			return value, nil
+		},
+	},
+	model.ConfigKeyBackupScheduleEnabled: {
+		errorCode: "invalid_backup_schedule",
+		normalize: func(value string) (string, error) {
+			value = strings.ToLower(value)
+			if value != model.ConfigValueTrue && value != ValueFalse {
+				return "", errors.New("定时备份开关必须为 true 或 false")
+			}
+			return value, nil
+		},
+	},
+	model.ConfigKeyBackupScheduleCron:     {errorCode: "invalid_backup_schedule", normalize: normalizeBackupScheduleCron},
+	model.ConfigKeyBackupScheduleMode:     {errorCode: "invalid_backup_schedule", normalize: normalizeBackupScheduleMode},
+	model.ConfigKeyBackupScheduleTargets:  {errorCode: "invalid_backup_schedule", normalize: normalizeBackupScheduleTargets},
+	model.ConfigKeyBackupScheduleLocalDir: {errorCode: "invalid_backup_schedule", normalize: normalizeBackupScheduleLocalDir},
+	model.ConfigKeyBackupSchedulePassword: {errorCode: "invalid_backup_schedule", normalize: normalizeBackupSchedulePassword},
+	model.ConfigKeyBackupRetentionLast:    {errorCode: "invalid_backup_retention", normalize: normalizeBackupRetentionCount},
+	model.ConfigKeyBackupRetentionDaily:   {errorCode: "invalid_backup_retention", normalize: normalizeBackupRetentionCount},
+	model.ConfigKeyBackupRetentionWeekly:  {errorCode: "invalid_backup_retention", normalize: normalizeBackupRetentionCount},
+	model.ConfigKeyBackupRetentionMonthly: {errorCode: "invalid_backup_retention", normalize: normalizeBackupRetentionCount},
+	model.ConfigKeyBackupDestination:      {errorCode: "invalid_backup_destination", normalize: normalizeBackupDestination},
+	model.ConfigKeyS3Endpoint:             {errorCode: "invalid_backup_destination", normalize: normalizeBackupDestinationURL},
+	model.ConfigKeyS3PathStyle:            {errorCode: "invalid_backup_destination", normalize: normalizeS3PathStyle},
+	model.ConfigKeyWebDAVURL:              {errorCode: "invalid_backup_destination", normalize: normalizeBackupDestinationURL},
+	model.ConfigKeySFTPHost:               {errorCode: "invalid_backup_destination", normalize: normalizeSFTPHost},
+	model.ConfigKeySFTPPrivateKey:         {errorCode: "invalid_backup_destination", normalize: normalizeSFTPPrivateKey},
+	model.ConfigKeySFTPHostFingerprint:    {errorCode: "invalid_backup_destination", normalize: normalizeSFTPHostFingerprint},
+	model.ConfigKeySFTPDir:                {errorCode: "invalid_backup_destination", normalize: normalizeSFTPDir},
+	model.ConfigKeyBangumiSyncEnabled: {
+		errorCode: "invalid_bangumi_sync",
+		normalize: func(value string) (string, error) {
+			value = strings.ToLower(value)
+			if value != model.ConfigValueTrue && value != ValueFalse {
+				return "", errors.New("Bangumi 收藏同步开关必须为 true 或 false")
+			}
+			return value, nil
+		},
+	},
+	model.ConfigKeyBangumiSyncTypes:     {errorCode: "invalid_bangumi_sync", normalize: normalizeBangumiSyncTypes},
+	model.ConfigKeyBangumiSyncMode:      {errorCode: "invalid_bangumi_sync", normalize: normalizeBangumiSyncMode},
+	model.ConfigKeyBangumiSyncSubgroups: {errorCode: "invalid_bangumi_sync", normalize: normalizeBangumiSyncSubgroups},
+	model.ConfigKeyAniListSyncEnabled: {
+		errorCode: "invalid_anilist_sync",
+		normalize: func(value string) (string, error) {
+			value = strings.ToLower(value)
+			if value != model.ConfigValueTrue && value != ValueFalse {
+				return "", errors.New("AniList 同步开关必须为 true 或 false")
+			}
+			return value, nil
+		},
+	},
+	model.ConfigKeyAniListSyncPolicy: {errorCode: "invalid_anilist_sync", normalize: normalizeAniListSyncPolicy},
+	model.ConfigKeyMetricsEnabled: {
+		errorCode: "invalid_metrics",
+		normalize: func(value string) (string, error) {
+			value = strings.ToLower(value)
+			if value != model.ConfigValueTrue && value != ValueFalse {
+				return "", errors.New("指标接口开关必须为 true 或 false")
+			}
+			return value, nil
+		},
+	},
+	model.ConfigKeyMetricsToken: {errorCode: "invalid_metrics", normalize: normalizeMetricsToken},
+	model.ConfigKeyAuthForwardEnabled: {
+		errorCode: "invalid_forward_auth",
+		normalize: func(value string) (string, error) {
+			value = strings.ToLower(value)
+			if value != model.ConfigValueTrue && value != ValueFalse {
+				return "", errors.New("反向代理登录开关必须为 true 或 false")
+			}
+			return value, nil
+		},
+	},
+	model.ConfigKeyAuthForwardHeader: {
+		errorCode: "invalid_forward_auth",
+		normalize: normalizeForwardAuthHeader,
+	},
+	model.ConfigKeyAuthForwardUserMap: {
+		errorCode: "invalid_forward_auth",
+		normalize: normalizeForwardAuthUserMap,
+	},
+	model.ConfigKeyAuthForwardProvisionRole: {
+		errorCode: "invalid_forward_auth",
+		normalize: normalizeForwardAuthProvisionRole,
+	},
+	model.ConfigKeyAuthForwardJWKSURL: {
+		errorCode: "invalid_forward_auth",
+		normalize: normalizeForwardAuthJWKSURL,
+	},
 	model.ConfigKeyJellyfinDirectUrl: {
 		errorCode: "invalid_jellyfin_direct_url",
 		normalize: normalizeJellyfinBaseURL,
@@ -1171,7 +1428,7 @@ func V1UpdateSettingsHandler(c *gin.Context) {
 		return
 	}
 	allowed := map[string]bool{}
-	for _, key := range []string{model.ConfigKeyQBMode, model.ConfigKeyQBUrl, model.ConfigKeyQBUsername, model.ConfigKeyQBPassword, model.ConfigKeyBaseDir, model.ConfigKeyAutoRenameEnabled, model.ConfigKeyMediaNamingPreset, model.ConfigKeyAutoRenameSeriesTemplate, model.ConfigKeyAutoRenameEpisodeTemplate, model.ConfigKeyMetadataSourceOrder, model.ConfigKeyMetadataOverwritePolicy, model.ConfigKeyWriteNFOEnabled, model.ConfigKeyWriteImagesEnabled, model.ConfigKeyIncrementalScanEnabled, model.ConfigKeyBangumiAppID, model.ConfigKeyBangumiAppSecret, model.ConfigKeyBangumiAccessToken, model.ConfigKeyBangumiRefreshToken, model.ConfigKeyTMDBToken, model.ConfigKeyAniListToken, model.ConfigKeyProxyURL, model.ConfigKeyProxyBangumi, model.ConfigKeyProxyMikan, model.ConfigKeyProxyTMDB, model.ConfigKeyProxyAniList, model.ConfigKeyProxyJellyfin, model.ConfigKeyProxyAI, model.ConfigKeyProxyUpdater, model.ConfigKeyAuthIPAllowlistEnabled, model.ConfigKeyAuthIPAllowlist, model.ConfigKeyJellyfinUrl, model.ConfigKeyJellyfinDirectUrl, model.ConfigKeyNetBirdProxyURL, model.ConfigKeyJellyfinLibraryIDs, model.ConfigKeyJellyfinUsername, model.ConfigKeyJellyfinPassword, model.ConfigKeyJellyfinApiKey, model.ConfigKeyAListUrl, model.ConfigKeyAListToken, model.ConfigKeyPikPakUsername, model.ConfigKeyPikPakPassword, model.ConfigKeyPikPakRefreshToken, model.ConfigKeyAIProvider, model.ConfigKeyAIBaseURL, model.ConfigKeyAIModel, model.ConfigKeyAIApiKey, model.ConfigKeyAIOpenAIBaseURL, model.ConfigKeyAIOpenAIModel, model.ConfigKeyAIOpenAIAPIKey, model.ConfigKeyAIGeminiBaseURL, model.ConfigKeyAIGeminiModel, model.ConfigKeyAIGeminiAPIKey, model.ConfigKeyAIGeminiFormat, model.ConfigKeyAIClaudeBaseURL, model.ConfigKeyAIClaudeModel, model.ConfigKeyAIClaudeAPIKey, model.ConfigKeyAIClaudeFormat, model.ConfigKeyR2Endpoint, model.ConfigKeyR2Bucket, model.ConfigKeyR2AccessKey, model.ConfigKeyR2SecretKey, model.ConfigKeyRepoUpdateEnabled, model.ConfigKeyRepoAutoPullEnabled, model.ConfigKeyRepoUpdateIntervalMinutes, model.ConfigKeyRepoUpdateOwner, model.ConfigKeyRepoUpdateName, model.ConfigKeyRepoRequireChecksum} {
+	for _, key := range []string{model.ConfigKeyQBMode, model.ConfigKeyDownloaderBackend, model.ConfigKeyQBUrl, model.ConfigKeyQBUsername, model.ConfigKeyQBPassword, model.ConfigKeyDownloaderPathMappings, model.ConfigKeyBaseDir, model.ConfigKeyAutoRenameEnabled, model.ConfigKeyMediaNamingPreset, model.ConfigKeyAutoRenameSeriesTemplate, model.ConfigKeyAutoRenameEpisodeTemplate, model.ConfigKeyMetadataSourceOrder, model.ConfigKeyMetadataOverwritePolicy, model.ConfigKeyWriteNFOEnabled, model.ConfigKeyWriteImagesEnabled, model.ConfigKeyIncrementalScanEnabled, model.ConfigKeyMediaProbeEnabled, model.ConfigKeySchedulerIntervalMinutes, model.ConfigKeySchedulerQuietHours, model.ConfigKeySchedulerSmartPolling, model.ConfigKeyBangumiAppID, model.ConfigKeyBangumiAppSecret, model.ConfigKeyBangumiAccessToken, model.ConfigKeyBangumiRefreshToken, model.ConfigKeyBangumiSyncEnabled, model.ConfigKeyBangumiSyncTypes, model.ConfigKeyBangumiSyncMode, model.ConfigKeyBangumiSyncSubgroups, model.ConfigKeyTMDBToken, model.ConfigKeyAniListToken, model.ConfigKeyAniListSyncEnabled, model.ConfigKeyAniListSyncPolicy, model.ConfigKeyProxyURL, model.ConfigKeyProxyBangumi, model.ConfigKeyProxyMikan, model.ConfigKeyProxyRSS, model.ConfigKeyProxyTMDB, model.ConfigKeyProxyAniList, model.ConfigKeyProxyJellyfin, model.ConfigKeyProxyAI, model.ConfigKeyProxyUpdater, model.ConfigKeyProxyNotify, model.ConfigKeyAuthIPAllowlistEnabled, model.ConfigKeyAuthIPAllowlist, model.ConfigKeyAuthForwardEnabled, model.ConfigKeyAuthForwardHeader, model.ConfigKeyAuthForwardUserMap, model.ConfigKeyAuthForwardProvisionRole, model.ConfigKeyAuthForwardJWKSURL, model.ConfigKeyAuthForwardAudience, model.ConfigKeyJellyfinUrl, model.ConfigKeyJellyfinDirectUrl, model.ConfigKeyNetBirdProxyURL, model.ConfigKeyJellyfinLibraryIDs, model.ConfigKeyJellyfinUsername, model.ConfigKeyJellyfinPassword, model.ConfigKeyJellyfinApiKey, model.ConfigKeyAListUrl, model.ConfigKeyAListToken, model.ConfigKeyPikPakUsername, model.ConfigKeyPikPakPassword, model.ConfigKeyPikPakRefreshToken, model.ConfigKeyAIProvider, model.ConfigKeyAIBaseURL, model.ConfigKeyAIModel, model.ConfigKeyAIApiKey, model.ConfigKeyAIOpenAIBaseURL, model.ConfigKeyAIOpenAIModel, model.ConfigKeyAIOpenAIAPIKey, model.ConfigKeyAIGeminiBaseURL, model.ConfigKeyAIGeminiModel, model.ConfigKeyAIGeminiAPIKey, model.ConfigKeyAIGeminiFormat, model.ConfigKeyAIClaudeBaseURL, model.ConfigKeyAIClaudeModel, model.ConfigKeyAIClaudeAPIKey, model.ConfigKeyAIClaudeFormat, model.ConfigKeyR2Endpoint, model.ConfigKeyR2Bucket, model.ConfigKeyR2AccessKey, model.ConfigKeyR2SecretKey, model.ConfigKeyBackupDestination, model.ConfigKeyS3Endpoint, model.ConfigKeyS3Region, model.ConfigKeyS3Bucket, model.ConfigKeyS3AccessKey, model.ConfigKeyS3SecretKey, model.ConfigKeyS3PathStyle, model.ConfigKeyWebDAVURL, model.ConfigKeyWebDAVUsername, model.ConfigKeyWebDAVPassword, model.ConfigKeySFTPHost, model.ConfigKeySFTPUsername, model.ConfigKeySFTPPassword, model.ConfigKeySFTPPrivateKey, model.ConfigKeySFTPHostFingerprint, model.ConfigKeySFTPDir, model.ConfigKeyBackupScheduleEnabled, model.ConfigKeyBackupScheduleCron, model.ConfigKeyBackupScheduleMode, model.ConfigKeyBackupScheduleTargets, model.ConfigKeyBackupScheduleLocalDir, model.ConfigKeyBackupSchedulePassword, model.ConfigKeyBackupRetentionLast, model.ConfigKeyBackupRetentionDaily, model.ConfigKeyBackupRetentionWeekly, model.ConfigKeyBackupRetentionMonthly, model.ConfigKeyMetricsEnabled, model.ConfigKeyMetricsToken, model.ConfigKeyRepoUpdateEnabled, model.ConfigKeyRepoAutoPullEnabled, model.ConfigKeyRepoUpdateIntervalMinutes, model.ConfigKeyRepoUpdateOwner, model.ConfigKeyRepoUpdateName, model.ConfigKeyRepoRequireChecksum} {
 		allowed[key] = true
 	}
 	updates := map[string]string{}
@@ -1225,10 +1482,10 @@ func V1UpdateSettingsHandler(c *gin.Context) {
 			v1Error(c, http.StatusBadRequest, "invalid_media_naming_preset", "媒体命名预设只支持 jellyfin-emby 或 custom")
 			return
 		}
-		if key == model.ConfigKeyWriteNFOEnabled || key == model.ConfigKeyWriteImagesEnabled || key == model.ConfigKeyIncrementalScanEnabled {
+		if key == model.ConfigKeyWriteNFOEnabled || key == model.ConfigKeyWriteImagesEnabled || key == model.ConfigKeyIncrementalScanEnabled || key == model.ConfigKeyMediaProbeEnabled {
 			value = strings.ToLower(value)
 			if value != model.ConfigValueTrue && value != ValueFalse {
-				v1Error(c, http.StatusBadRequest, "invalid_media_setting", "媒体写入和增量扫描开关必须为 true 或 false")
+				v1Error(c, http.StatusBadRequest, "invalid_media_setting", "媒体写入、增量扫描和媒体探测开关必须为 true 或 false")
 				return
 			}
 		}
@@ -1272,6 +1529,25 @@ func V1UpdateSettingsHandler(c *gin.Context) {
 		v1Error(c, http.StatusBadRequest, "invalid_auth_ip_allowlist", "启用免密登录前请至少填写一个 IP 或 CIDR 网段")
 		return
 	}
+	jwksURL := config.SystemSetting(model.ConfigKeyAuthForwardJWKSURL)
+	if value, ok := updates[model.ConfigKeyAuthForwardJWKSURL]; ok {
+		jwksURL = value
+	}
+	audience := config.SystemSetting(model.ConfigKeyAuthForwardAudience)
+	if value, ok := updates[model.ConfigKeyAuthForwardAudience]; ok {
+		audience = value
+	}
+	if strings.TrimSpace(jwksURL) != "" && strings.TrimSpace(audience) == "" {
+		v1Error(c, http.StatusBadRequest, "invalid_forward_auth", "填写 JWKS 地址时必须同时填写 Audience（Cloudflare Access 应用的 AUD 标签），否则同一团队下其他应用签发的令牌也会被接受")
+		return
+	}
+	if _, ok := updates[model.ConfigKeyAniListToken]; ok {
+		// The AniList list belongs to whoever linked it, so only that
+		// account's playback is pushed.
+		if userID, err := currentSessionUserID(c); err == nil && userID != 0 {
+			updates[model.ConfigKeyAniListSyncUserID] = strconv.FormatUint(uint64(userID), 10)
+		}
+	}
 	if err := store.NewConfigStore(db.DB).SetMany(updates); err != nil {
 		v1Error(c, http.StatusInternalServerError, "settings_save_failed", err.Error())
 		return
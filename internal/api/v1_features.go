@@ -39,6 +39,7 @@ func V1LocalAnimeFilesHandler(c *gin.Context) { v1RunJSONHandler(c, GetLocalAnim
 func V1PickDirectoryHandler(c *gin.Context)   { v1RunJSONHandler(c, PickDirectoryHandler) }
 
 type v1MikanClient interface {
+	Name() string
 	ParseContext(context.Context, string) ([]parser.Episode, error)
 	SearchContext(context.Context, string) ([]parser.SearchResult, error)
 	ResolveBangumiSubjectContext(context.Context, string, string) ([]parser.SearchResult, error)
@@ -227,7 +228,16 @@ func V1RSSPreviewHandler(c *gin.Context) {
 		v1Error(c, http.StatusBadRequest, "rss_required", "请输入 RSS 地址")
 		return
 	}
-	episodes, err := newV1MikanClient().ParseContext(c.Request.Context(), rssURL)
+	var feedParser parser.ContextRSSParser = newV1MikanClient()
+	if name := strings.TrimSpace(c.Query("parser")); name != "" {
+		selected, ok := subscriptionFeedParser(name)
+		if !ok {
+			v1Error(c, http.StatusBadRequest, "invalid_subscription_parser", "不支持的 RSS 解析器")
+			return
+		}
+		feedParser = selected
+	}
+	episodes, err := feedParser.ParseContext(c.Request.Context(), rssURL)
 	if err != nil {
 		v1Error(c, http.StatusBadGateway, "rss_preview_failed", humanizeOperationError(err.Error()))
 		return
@@ -238,6 +248,14 @@ func V1RSSPreviewHandler(c *gin.Context) {
 	v1Data(c, http.StatusOK, gin.H{"items": episodes, "total": len(episodes)})
 }
 
+// V1SubscriptionParsersHandler lists the RSS parsers a subscription can use.
+func V1SubscriptionParsersHandler(c *gin.Context) {
+	v1Data(c, http.StatusOK, gin.H{
+		"items":   parser.ParserNames(),
+		"default": parser.MikanParserName,
+	})
+}
+
 func V1MikanDashboardHandler(c *gin.Context) {
 	year := strings.TrimSpace(c.Query("year"))
 	season := strings.TrimSpace(c.Query("season"))
@@ -335,21 +353,23 @@ func V1UpdateSubscriptionHandler(c *gin.Context) {
 		return
 	}
 	var input struct {
-		Title              string `json:"title"`
-		RSSURL             string `json:"rss_url"`
-		MikanID            string `json:"mikan_id"`
-		Image              string `json:"image"`
-		SubtitleGroup      string `json:"subtitle_group"`
-		Season             string `json:"season"`
-		FilterRule         string `json:"filter_rule"`
-		ExcludeRule        string `json:"exclude_rule"`
-		ResolutionFilter   string `json:"resolution_filter"`
-		SubtitleLanguage   string `json:"subtitle_language"`
-		BackupRSSURL       string `json:"backup_rss_url"`
-		ExpectedEpisodes   int    `json:"expected_episodes"`
-		AllowMultiSubgroup bool   `json:"allow_multi_subgroup"`
-		AutoDisableOnDone  bool   `json:"auto_disable_on_done"`
-		StaleAfterHours    int    `json:"stale_after_hours"`
+		Title                string `json:"title"`
+		RSSURL               string `json:"rss_url"`
+		MikanID              string `json:"mikan_id"`
+		Image                string `json:"image"`
+		SubtitleGroup        string `json:"subtitle_group"`
+		Season               string `json:"season"`
+		FilterRule           string `json:"filter_rule"`
+		ExcludeRule          string `json:"exclude_rule"`
+		ResolutionFilter     string `json:"resolution_filter"`
+		SubtitleLanguage     string `json:"subtitle_language"`
+		BackupRSSURL         string `json:"backup_rss_url"`
+		Parser               string `json:"parser"`
+		ExpectedEpisodes     int    `json:"expected_episodes"`
+		AllowMultiSubgroup   bool   `json:"allow_multi_subgroup"`
+		AutoDisableOnDone    bool   `json:"auto_disable_on_done"`
+		StaleAfterHours      int    `json:"stale_after_hours"`
+		CheckIntervalMinutes int    `json:"check_interval_minutes"`
 	}
 	if err := c.ShouldBindJSON(&input); err != nil || strings.TrimSpace(input.Title) == "" || strings.TrimSpace(input.RSSURL) == "" {
 		v1Error(c, http.StatusBadRequest, "invalid_subscription", "番剧名称和 RSS 地址不能为空")
@@ -366,14 +386,20 @@ func V1UpdateSubscriptionHandler(c *gin.Context) {
 	sub.ResolutionFilter = strings.TrimSpace(input.ResolutionFilter)
 	sub.SubtitleLanguage = strings.TrimSpace(input.SubtitleLanguage)
 	sub.BackupRSSUrl = strings.TrimSpace(input.BackupRSSURL)
+	sub.Parser = input.Parser
 	sub.ExpectedEpisodes = input.ExpectedEpisodes
 	sub.AllowMultiSubgroup = input.AllowMultiSubgroup
 	sub.AutoDisableOnDone = input.AutoDisableOnDone
 	sub.StaleAfterHours = input.StaleAfterHours
+	sub.CheckIntervalMinutes = input.CheckIntervalMinutes
 	if err := normalizeSubscriptionReleaseFilters(sub); err != nil {
 		v1Error(c, http.StatusBadRequest, "invalid_subscription_filter", err.Error())
 		return
 	}
+	if err := normalizeSubscriptionParser(sub); err != nil {
+		v1Error(c, http.StatusBadRequest, "invalid_subscription_parser", err.Error())
+		return
+	}
 	normalizeMikanAssociation(sub)
 	normalizeSubscriptionStrategy(sub)
 	if err := saveSubscription(sub); err != nil {
@@ -397,7 +423,7 @@ func V1SubscriptionRepairHandler(c *gin.Context) {
 		return
 	}
 	taskID := fmt.Sprintf("subscription-%d-%s", sub.ID, action)
-	taskstate.Global.Start(taskID, "subscription-repair", "订阅修复", "正在修复 "+sub.Title)
+	taskstate.Global.StartBy(taskInitiator(c), taskID, "subscription-repair", "订阅修复", "正在修复 "+sub.Title)
 	GoBackground(func(appCtx context.Context) {
 		var runErr error
 		switch action {
@@ -452,7 +478,7 @@ func V1RefreshSubscriptionMetadataHandler(c *gin.Context) {
 		return
 	}
 	taskID := "subscription-metadata-" + c.Param("id")
-	taskstate.Global.Start(taskID, "metadata", "刷新订阅元数据", "正在刷新 "+sub.Title)
+	taskstate.Global.StartBy(taskInitiator(c), taskID, "metadata", "刷新订阅元数据", "正在刷新 "+sub.Title)
 	GoBackground(func(context.Context) {
 		service.NewMetadataService().EnrichMetadata(sub.Metadata, sub.Title)
 		if err := saveSubscription(sub); err != nil {
@@ -520,7 +546,7 @@ func V1RefreshMetadataItemHandler(c *gin.Context) {
 		return
 	}
 	taskID := "metadata-" + c.Param("id")
-	taskstate.Global.Start(taskID, "metadata", "刷新元数据", "正在刷新单条元数据")
+	taskstate.Global.StartBy(taskInitiator(c), taskID, "metadata", "刷新元数据", "正在刷新单条元数据")
 	GoBackground(func(context.Context) {
 		if err := service.NewMetadataService().RefreshSingleMetadata(uint(id)); err != nil {
 			log.Printf("metadata refresh failed for %d: %v", id, err)
@@ -560,7 +586,7 @@ func V1RefreshLocalMetadataHandler(c *gin.Context) {
 		return
 	}
 	taskID := "local-metadata-" + c.Param("id")
-	taskstate.Global.Start(taskID, "metadata", "刷新本地番剧元数据", "正在刷新 "+anime.Title)
+	taskstate.Global.StartBy(taskInitiator(c), taskID, "metadata", "刷新本地番剧元数据", "正在刷新 "+anime.Title)
 	GoBackground(func(context.Context) {
 		if err := enrichV1LocalAnime(anime); err != nil {
 			log.Printf("local metadata refresh failed for %d: %v", anime.ID, err)
@@ -808,7 +834,7 @@ func V1UpdaterActionHandler(c *gin.Context) {
 		v1Error(c, http.StatusBadRequest, "invalid_update_action", "不支持的更新操作")
 		return
 	}
-	taskstate.Global.Start(taskID, "updater", title, title+"进行中")
+	taskstate.Global.StartBy(taskInitiator(c), taskID, "updater", title, title+"进行中")
 	GoBackground(func(context.Context) {
 		var status updater.Status
 		if action == "check" {
@@ -839,9 +865,9 @@ func V1R2UploadHandler(c *gin.Context) {
 		v1Error(c, http.StatusBadRequest, "backup_password_invalid", err.Error())
 		return
 	}
-	taskID, err := startR2UploadTask(c.Request.Context(), password)
+	taskID, err := startRemoteUploadTask(taskInitiator(c), password)
 	if err != nil {
-		v1Error(c, http.StatusBadRequest, "r2_not_configured", "R2 配置有误: "+err.Error())
+		v1Error(c, http.StatusBadRequest, "r2_not_configured", "云备份配置有误: "+err.Error())
 		return
 	}
 	v1Message(c, http.StatusAccepted, "云备份上传任务已经启动", gin.H{"task_id": taskID, "status": "running"})
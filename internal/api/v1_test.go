@@ -42,6 +42,8 @@ type fakeV1MikanClient struct {
 	lastRSSURL       string
 }
 
+func (f *fakeV1MikanClient) Name() string { return parser.MikanParserName }
+
 func (f *fakeV1MikanClient) ParseContext(_ context.Context, rssURL string) ([]parser.Episode, error) {
 	f.lastRSSURL = rssURL
 	return f.episodes, nil
@@ -308,6 +310,52 @@ func TestV1TaskSnapshotEndpointsRequireAuthAndReturnTypedState(t *testing.T) {
 	assert.Contains(t, missing.Body.String(), `"code":"task_not_found"`)
 }
 
+func TestV1CancelTaskStopsCancellableTasksOnly(t *testing.T) {
+	resetAuthFixtures(t)
+	taskstate.Global.Reset()
+	t.Cleanup(taskstate.Global.Reset)
+	r := setupRouter()
+	adminCookie, _ := loginCookie(t, r, "admin")
+	memberID := createTestUser(t, r, adminCookie, "member", model.UserRoleMember)
+	member := v1LoginCookie(t, r, "member", "household-pass")
+
+	taskstate.Global.Start("fixed-task", "updater", "检查更新", "正在检查")
+	taskstate.Global.Start("admin-scan", "scan", "本地扫描", "正在扫描")
+	adminCtx, releaseAdmin := taskstate.Global.WithCancel(context.Background(), "admin-scan")
+	defer releaseAdmin()
+	taskstate.Global.StartBy(taskstate.Initiator{UserID: memberID, Username: "member"}, "member-organize", "organize", "整理本地番剧", "正在整理")
+	memberCtx, releaseMember := taskstate.Global.WithCancel(context.Background(), "member-organize")
+	defer releaseMember()
+
+	w := serveAs(r, adminCookie, http.MethodPost, "/api/v1/tasks/missing/cancel", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = serveAs(r, adminCookie, http.MethodPost, "/api/v1/tasks/fixed-task/cancel", "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"task_not_cancellable"`)
+
+	w = serveAs(r, member, http.MethodPost, "/api/v1/tasks/admin-scan/cancel", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.NoError(t, adminCtx.Err())
+
+	w = serveAs(r, member, http.MethodPost, "/api/v1/tasks/member-organize/cancel", "")
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"cancel_requested":true`)
+	assert.ErrorIs(t, memberCtx.Err(), context.Canceled)
+
+	w = serveAs(r, adminCookie, http.MethodPost, "/api/v1/tasks/admin-scan/cancel", "")
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	taskstate.Global.Fail("admin-scan", adminCtx.Err())
+	w = serveAs(r, adminCookie, http.MethodGet, "/api/v1/tasks/admin-scan", "")
+	assert.Contains(t, w.Body.String(), `"status":"cancelled"`)
+	w = serveAs(r, adminCookie, http.MethodPost, "/api/v1/tasks/admin-scan/cancel", "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"task_finished"`)
+
+	var audits []model.AuditLog
+	require.NoError(t, db.DB.Where("action = ?", service.AuditActionTaskCancel).Find(&audits).Error)
+	assert.Len(t, audits, 2)
+}
+
 func TestLocalScanTaskSwitchesFromScanToMetadataPhase(t *testing.T) {
 	taskstate.Global.Reset()
 	t.Cleanup(taskstate.Global.Reset)
@@ -470,7 +518,9 @@ func TestV1SettingsSaveMirrorsValuesToConfigFile(t *testing.T) {
 	data, err := os.ReadFile(config.ConfigFilePath())
 	require.NoError(t, err)
 	assert.Contains(t, string(data), "qb_url: http://local-qb:8080")
-	assert.Contains(t, string(data), "qb_password: mirror-secret")
+	assert.Contains(t, string(data), "qb_password: enc:v1:")
+	assert.NotContains(t, string(data), "mirror-secret")
+	assert.Equal(t, "mirror-secret", config.SystemSetting(model.ConfigKeyQBPassword))
 }
 
 func TestV1SettingsNormalizeAndPersistProxyOptions(t *testing.T) {
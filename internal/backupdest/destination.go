@@ -0,0 +1,164 @@
+// Package backupdest stores offsite backup archives. Every supported service
+// (Cloudflare R2, S3-compatible storage, WebDAV and SFTP) implements
+// Destination, so the upload, listing, staging, deletion and connection test
+// flows do not need to know where archives end up.
+package backupdest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	KindR2     = "r2"
+	KindS3     = "s3"
+	KindWebDAV = "webdav"
+	KindSFTP   = "sftp"
+)
+
+var (
+	_ Destination = (*S3Destination)(nil)
+	_ Destination = (*WebDAVDestination)(nil)
+	_ Destination = (*SFTPDestination)(nil)
+)
+
+// ErrIncompleteConfig is returned by constructors when a required setting is
+// missing.
+var ErrIncompleteConfig = errors.New("backup destination configuration is incomplete")
+
+// ErrInvalidKey is returned for object keys that are empty, absolute or try
+// to leave the destination directory.
+var ErrInvalidKey = errors.New("invalid backup object key")
+
+// Object is one stored archive. Keys are relative to the destination root and
+// always use forward slashes.
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// Destination is an offsite place for backup archives.
+type Destination interface {
+	// Kind is one of the Kind constants.
+	Kind() string
+	// Location describes where key is stored, for logs and audit entries.
+	Location(key string) string
+	// List returns the objects whose key starts with prefix. A prefix may
+	// name a sub directory ("dir/name_"); listing is never recursive.
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// Upload stores size bytes from body under key, replacing any existing
+	// object. body must support rewinding for retries.
+	Upload(ctx context.Context, key string, body io.ReadSeeker, size int64) error
+	// Download opens key for reading and reports its size, or -1 when the
+	// service does not tell.
+	Download(ctx context.Context, key string) (io.ReadCloser, int64, error)
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// NormalizeKind maps user input onto a supported kind. Unknown or empty
+// values fall back to R2, which remains the default.
+func NormalizeKind(raw string) string {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case KindS3, "minio":
+		return KindS3
+	case KindWebDAV, "dav":
+		return KindWebDAV
+	case KindSFTP:
+		return KindSFTP
+	default:
+		return KindR2
+	}
+}
+
+// IsKnownKind reports whether raw names a kind NormalizeKind understands.
+func IsKnownKind(raw string) bool {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", KindR2, KindS3, "minio", KindWebDAV, "dav", KindSFTP:
+		return true
+	default:
+		return false
+	}
+}
+
+// Label is the name of kind shown to users.
+func Label(kind string) string {
+	switch kind {
+	case KindS3:
+		return "S3 兼容存储"
+	case KindWebDAV:
+		return "WebDAV"
+	case KindSFTP:
+		return "SFTP"
+	default:
+		return "R2"
+	}
+}
+
+// CheckError reports which step of Check failed.
+type CheckError struct {
+	Step string // "list", "write" or "delete"
+	Err  error
+}
+
+func (e *CheckError) Error() string { return e.Step + " check failed: " + e.Err.Error() }
+func (e *CheckError) Unwrap() error { return e.Err }
+
+// Check verifies read, write and delete access by listing the destination,
+// then writing and removing probeKey. Callers pick a unique probeKey so a
+// check can never overwrite a real archive.
+func Check(ctx context.Context, destination Destination, probeKey string) error {
+	if _, err := destination.List(ctx, ""); err != nil {
+		return &CheckError{Step: "list", Err: err}
+	}
+	const payload = "ok"
+	if err := destination.Upload(ctx, probeKey, strings.NewReader(payload), int64(len(payload))); err != nil {
+		return &CheckError{Step: "write", Err: err}
+	}
+	if err := destination.Delete(ctx, probeKey); err != nil {
+		return &CheckError{Step: "delete", Err: err}
+	}
+	return nil
+}
+
+// cleanKey validates key and returns it in canonical form.
+func cleanKey(key string) (string, error) {
+	key = strings.TrimSpace(key)
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return key, nil
+}
+
+// splitPrefix splits a listing prefix into the directory to read and the
+// name prefix to match inside it. dir is "" for the destination root.
+func splitPrefix(prefix string) (dir, namePrefix string, err error) {
+	if prefix == "" {
+		return "", "", nil
+	}
+	dir, namePrefix = path.Split(prefix)
+	if dir == "" {
+		return "", namePrefix, nil
+	}
+	dir, err = cleanKey(strings.TrimSuffix(dir, "/"))
+	return dir, namePrefix, err
+}
+
+// joinKey prefixes name with dir when dir is not the root.
+func joinKey(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
@@ -0,0 +1,157 @@
+package backupdest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const defaultS3Region = "us-east-1"
+
+// S3Config configures an S3-compatible bucket. Endpoint may be empty for AWS
+// itself; every other service (R2, MinIO, ...) needs it.
+type S3Config struct {
+	Endpoint   string
+	Region     string
+	Bucket     string
+	AccessKey  string
+	SecretKey  string
+	PathStyle  bool
+	HTTPClient *http.Client
+}
+
+// S3Destination stores archives as objects in one bucket.
+type S3Destination struct {
+	kind   string
+	client *s3.Client
+	bucket string
+}
+
+// NewR2 connects to a Cloudflare R2 bucket. R2 accepts the us-east-1 region
+// and path-style requests, so only the endpoint, credentials and bucket are
+// configurable.
+func NewR2(cfg S3Config) (*S3Destination, error) {
+	if strings.TrimSpace(cfg.Endpoint) == "" {
+		return nil, ErrIncompleteConfig
+	}
+	cfg.Region = defaultS3Region
+	cfg.PathStyle = true
+	return newS3Destination(KindR2, cfg)
+}
+
+// NewS3 connects to an S3-compatible bucket such as MinIO or AWS S3.
+func NewS3(cfg S3Config) (*S3Destination, error) {
+	return newS3Destination(KindS3, cfg)
+}
+
+func newS3Destination(kind string, cfg S3Config) (*S3Destination, error) {
+	cfg.Bucket = strings.TrimSpace(cfg.Bucket)
+	if cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, ErrIncompleteConfig
+	}
+	if strings.TrimSpace(cfg.Region) == "" {
+		cfg.Region = defaultS3Region
+	}
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	options := s3.Options{
+		Region:       strings.TrimSpace(cfg.Region),
+		Credentials:  aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretKey, "")),
+		HTTPClient:   httpClient,
+		UsePathStyle: cfg.PathStyle,
+		// Many S3-compatible servers reject the streaming checksums newer
+		// SDKs add by default; only send them when an operation needs one.
+		RequestChecksumCalculation: aws.RequestChecksumCalculationWhenRequired,
+		ResponseChecksumValidation: aws.ResponseChecksumValidationWhenRequired,
+	}
+	if endpoint := strings.TrimSpace(cfg.Endpoint); endpoint != "" {
+		options.BaseEndpoint = aws.String(endpoint)
+	}
+	return &S3Destination{kind: kind, client: s3.New(options), bucket: cfg.Bucket}, nil
+}
+
+func (d *S3Destination) Kind() string { return d.kind }
+
+func (d *S3Destination) Location(key string) string { return d.bucket + "/" + key }
+
+func (d *S3Destination) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	paginator := s3.NewListObjectsV2Paginator(d.client, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(d.bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range page.Contents {
+			if object.Key == nil {
+				continue
+			}
+			item := Object{Key: *object.Key}
+			if object.Size != nil {
+				item.Size = *object.Size
+			}
+			if object.LastModified != nil {
+				item.LastModified = *object.LastModified
+			}
+			objects = append(objects, item)
+		}
+	}
+	return objects, nil
+}
+
+func (d *S3Destination) Upload(ctx context.Context, key string, body io.ReadSeeker, size int64) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = d.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(d.bucket),
+		Key:           aws.String(key),
+		Body:          body,
+		ContentLength: aws.Int64(size),
+		ContentType:   aws.String("application/zip"),
+	})
+	return err
+}
+
+func (d *S3Destination) Download(ctx context.Context, key string) (io.ReadCloser, int64, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	output, err := d.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(d.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	size := int64(-1)
+	if output.ContentLength != nil {
+		size = *output.ContentLength
+	}
+	return output.Body, size, nil
+}
+
+func (d *S3Destination) Delete(ctx context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = d.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(d.bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
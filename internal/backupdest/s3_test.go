@@ -0,0 +1,223 @@
+package backupdest
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a path-style, single-bucket S3 server with just enough of the
+// API for Destination: PutObject, GetObject, DeleteObject and a paginated
+// ListObjectsV2.
+type fakeS3 struct {
+	t        *testing.T
+	bucket   string
+	pageSize int
+	mu       sync.Mutex
+	objects  map[string][]byte
+}
+
+func newFakeS3(t *testing.T, bucket string) (*fakeS3, *httptest.Server) {
+	t.Helper()
+	fake := &fakeS3{t: t, bucket: bucket, pageSize: 1000, objects: map[string][]byte{}}
+	server := httptest.NewServer(http.HandlerFunc(fake.serve))
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+type fakeS3ListResult struct {
+	XMLName               xml.Name `xml:"ListBucketResult"`
+	Name                  string   `xml:"Name"`
+	Prefix                string   `xml:"Prefix"`
+	KeyCount              int      `xml:"KeyCount"`
+	IsTruncated           bool     `xml:"IsTruncated"`
+	NextContinuationToken string   `xml:"NextContinuationToken,omitempty"`
+	Contents              []struct {
+		Key          string `xml:"Key"`
+		Size         int64  `xml:"Size"`
+		LastModified string `xml:"LastModified"`
+	} `xml:"Contents"`
+}
+
+func (f *fakeS3) serve(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Authorization"), "Credential=access/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != f.bucket {
+		f.t.Errorf("unexpected bucket in %s", r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && key == "" && r.URL.Query().Get("list-type") == "2":
+		f.list(w, r)
+	case r.Method == http.MethodPut && key != "":
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			f.t.Errorf("read put body: %v", err)
+		}
+		if r.ContentLength != int64(len(data)) {
+			f.t.Errorf("content length %d, body %d bytes", r.ContentLength, len(data))
+		}
+		f.objects[key] = data
+	case r.Method == http.MethodGet && key != "":
+		data, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `<Error><Code>NoSuchKey</Code></Error>`)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		_, _ = w.Write(data)
+	case r.Method == http.MethodDelete && key != "":
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL.String())
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	prefix := query.Get("prefix")
+	var keys []string
+	for key := range f.objects {
+		rest, ok := strings.CutPrefix(key, prefix)
+		if ok && (query.Get("delimiter") != "/" || !strings.Contains(rest, "/")) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	start := 0
+	if token := query.Get("continuation-token"); token != "" {
+		start, _ = strconv.Atoi(token)
+	}
+	result := fakeS3ListResult{Name: f.bucket, Prefix: prefix}
+	end := min(start+f.pageSize, len(keys))
+	for _, key := range keys[start:end] {
+		result.Contents = append(result.Contents, struct {
+			Key          string `xml:"Key"`
+			Size         int64  `xml:"Size"`
+			LastModified string `xml:"LastModified"`
+		}{Key: key, Size: int64(len(f.objects[key])), LastModified: "2026-10-16T03:00:00.000Z"})
+	}
+	result.KeyCount = len(result.Contents)
+	if end < len(keys) {
+		result.IsTruncated = true
+		result.NextContinuationToken = strconv.Itoa(end)
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(result)
+}
+
+func TestS3DestinationRoundTrip(t *testing.T) {
+	fake, server := newFakeS3(t, "backups")
+	fake.pageSize = 2
+	destination, err := NewS3(S3Config{
+		Endpoint:   server.URL,
+		Bucket:     "backups",
+		AccessKey:  "access",
+		SecretKey:  "secret",
+		PathStyle:  true,
+		HTTPClient: server.Client(),
+	})
+	if err != nil {
+		t.Fatalf("new s3 destination: %v", err)
+	}
+	ctx := context.Background()
+
+	for _, key := range []string{"animate_backup_a.zip", "animate_backup_b.zip", "animate_backup_c.zip", "other.zip", "nested/animate_backup_d.zip"} {
+		if err := destination.Upload(ctx, key, strings.NewReader("payload-"+key), int64(len("payload-"+key))); err != nil {
+			t.Fatalf("upload %s: %v", key, err)
+		}
+	}
+	objects, err := destination.List(ctx, "animate_backup_")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(objects) != 3 {
+		t.Fatalf("expected 3 objects across pages, got %+v", objects)
+	}
+	if want := time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC); !objects[0].LastModified.Equal(want) || objects[0].Size != int64(len("payload-animate_backup_a.zip")) {
+		t.Fatalf("unexpected object metadata %+v", objects[0])
+	}
+
+	body, size, err := destination.Download(ctx, "animate_backup_b.zip")
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	_ = body.Close()
+	if string(data) != "payload-animate_backup_b.zip" || size != int64(len(data)) {
+		t.Fatalf("download = %q (%d bytes)", data, size)
+	}
+
+	if err := destination.Delete(ctx, "animate_backup_b.zip"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok := fake.objects["animate_backup_b.zip"]; ok {
+		t.Fatal("expected object to be deleted")
+	}
+	if location := destination.Location("animate_backup_a.zip"); location != "backups/animate_backup_a.zip" {
+		t.Fatalf("location = %q", location)
+	}
+	if err := Check(ctx, destination, ".animate-auto-tool/connection-tests/probe.txt"); err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if len(fake.objects) != 4 {
+		t.Fatalf("check must clean up its probe, objects=%d", len(fake.objects))
+	}
+}
+
+func TestS3DestinationCheckReportsFailingStep(t *testing.T) {
+	_, server := newFakeS3(t, "backups")
+	destination, err := NewR2(S3Config{Endpoint: server.URL, Bucket: "backups", AccessKey: "wrong", SecretKey: "secret", HTTPClient: server.Client()})
+	if err != nil {
+		t.Fatalf("new r2 destination: %v", err)
+	}
+	err = Check(context.Background(), destination, "probe.txt")
+	checkErr, ok := err.(*CheckError)
+	if !ok || checkErr.Step != "list" {
+		t.Fatalf("expected list check error, got %v", err)
+	}
+	if destination.Kind() != KindR2 {
+		t.Fatalf("kind = %q", destination.Kind())
+	}
+
+	if _, err := NewR2(S3Config{Bucket: "backups", AccessKey: "a", SecretKey: "b"}); err != ErrIncompleteConfig {
+		t.Fatalf("r2 without endpoint: %v", err)
+	}
+	if _, err := NewS3(S3Config{Bucket: "backups"}); err != ErrIncompleteConfig {
+		t.Fatalf("s3 without credentials: %v", err)
+	}
+}
+
+func TestNormalizeKind(t *testing.T) {
+	for raw, want := range map[string]string{"": KindR2, "R2": KindR2, "minio": KindS3, " WebDAV ": KindWebDAV, "dav": KindWebDAV, "sftp": KindSFTP} {
+		if got := NormalizeKind(raw); got != want || !IsKnownKind(raw) {
+			t.Fatalf("NormalizeKind(%q) = %q known=%v, want %q", raw, got, IsKnownKind(raw), want)
+		}
+	}
+	if IsKnownKind("ftp") {
+		t.Fatal("ftp must not be a known kind")
+	}
+	for _, key := range []string{"", "/abs.zip", "../up.zip", "a//b.zip", `a\b.zip`} {
+		if _, err := cleanKey(key); err == nil {
+			t.Fatalf("expected key %q to be rejected", key)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package backupdest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	defaultSFTPPort    = "22"
+	defaultSFTPTimeout = 15 * time.Second
+)
+
+// SFTPConfig configures an SFTP directory. Addr is host or host:port. At
+// least one of Password and PrivateKey is required. HostFingerprint pins the
+// server key ("SHA256:..." as printed by ssh-keygen -lf); connections are
+// refused until it is set, and the error reports the key the server offered.
+type SFTPConfig struct {
+	Addr            string
+	Username        string
+	Password        string
+	PrivateKey      string
+	HostFingerprint string
+	Dir             string
+	Timeout         time.Duration
+}
+
+// HostKeyError is returned when the server key is not the pinned one, or when
+// no key has been pinned yet (Expected is empty).
+type HostKeyError struct {
+	Fingerprint string
+	Expected    string
+}
+
+func (e *HostKeyError) Error() string {
+	if e.Expected == "" {
+		return "sftp host key is not trusted yet, server fingerprint is " + e.Fingerprint
+	}
+	return fmt.Sprintf("sftp host key mismatch: server offered %s, expected %s", e.Fingerprint, e.Expected)
+}
+
+// SFTPDestination stores archives as files in one remote directory. Every
+// operation opens its own connection; backups are rare enough that keeping
+// a session alive is not worth the reconnect logic.
+type SFTPDestination struct {
+	addr    string
+	dir     string
+	timeout time.Duration
+	config  *ssh.ClientConfig
+}
+
+func NewSFTP(cfg SFTPConfig) (*SFTPDestination, error) {
+	addr := strings.TrimSpace(cfg.Addr)
+	if addr == "" || strings.TrimSpace(cfg.Username) == "" || (cfg.Password == "" && strings.TrimSpace(cfg.PrivateKey) == "") {
+		return nil, ErrIncompleteConfig
+	}
+	addr, err := NormalizeSFTPAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	expected, err := NormalizeHostFingerprint(cfg.HostFingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	var auth []ssh.AuthMethod
+	if strings.TrimSpace(cfg.PrivateKey) != "" {
+		signer, err := ssh.ParsePrivateKey([]byte(strings.TrimSpace(cfg.PrivateKey) + "\n"))
+		if err != nil {
+			return nil, fmt.Errorf("parse sftp private key: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if cfg.Password != "" {
+		password := cfg.Password
+		auth = append(auth, ssh.Password(password), ssh.KeyboardInteractive(func(_, _ string, questions []string, _ []bool) ([]string, error) {
+			answers := make([]string, len(questions))
+			for i := range answers {
+				answers[i] = password
+			}
+			return answers, nil
+		}))
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultSFTPTimeout
+	}
+	dir := path.Clean(strings.TrimSpace(cfg.Dir))
+	if dir == "" {
+		dir = "."
+	}
+	return &SFTPDestination{
+		addr:    addr,
+		dir:     dir,
+		timeout: timeout,
+		config: &ssh.ClientConfig{
+			User:    strings.TrimSpace(cfg.Username),
+			Auth:    auth,
+			Timeout: timeout,
+			HostKeyCallback: func(_ string, _ net.Addr, key ssh.PublicKey) error {
+				fingerprint := ssh.FingerprintSHA256(key)
+				if expected == "" || fingerprint != expected {
+					return &HostKeyError{Fingerprint: fingerprint, Expected: expected}
+				}
+				return nil
+			},
+		},
+	}, nil
+}
+
+// NormalizeSFTPAddr adds the default port to a bare host.
+func NormalizeSFTPAddr(raw string) (string, error) {
+	raw = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(raw), "sftp://"))
+	if raw == "" {
+		return "", nil
+	}
+	if _, _, err := net.SplitHostPort(raw); err != nil {
+		raw = net.JoinHostPort(strings.Trim(raw, "[]"), defaultSFTPPort)
+	}
+	host, port, err := net.SplitHostPort(raw)
+	if err != nil || host == "" || port == "" || strings.ContainsAny(host, "/ ") {
+		return "", fmt.Errorf("invalid sftp address %q", raw)
+	}
+	return raw, nil
+}
+
+// NormalizeHostFingerprint returns fingerprint in the "SHA256:<base64>" form
+// ssh.FingerprintSHA256 produces. The prefix and base64 padding are optional
+// in the input.
+func NormalizeHostFingerprint(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+	encoded := strings.TrimRight(strings.TrimPrefix(raw, "SHA256:"), "=")
+	if len(encoded) != 43 || strings.Trim(encoded, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/") != "" {
+		return "", fmt.Errorf("invalid sha256 host key fingerprint %q", raw)
+	}
+	return "SHA256:" + encoded, nil
+}
+
+func (d *SFTPDestination) Kind() string { return KindSFTP }
+
+func (d *SFTPDestination) Location(key string) string {
+	return "sftp://" + d.config.User + "@" + d.addr + "/" + strings.TrimPrefix(d.remotePath(key), "/")
+}
+
+func (d *SFTPDestination) remotePath(key string) string {
+	if key == "" {
+		return d.dir
+	}
+	return path.Join(d.dir, key)
+}
+
+// connect opens an SFTP session. close releases it and must be called once.
+// Cancelling ctx closes the underlying connection, which aborts any
+// operation in flight.
+func (d *SFTPDestination) connect(ctx context.Context) (client *sftp.Client, closeFn func(), err error) {
+	dialer := net.Dialer{Timeout: d.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", d.addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	if err := conn.SetDeadline(time.Now().Add(d.timeout)); err != nil {
+		stop()
+		_ = conn.Close()
+		return nil, nil, err
+	}
+	sshConn, channels, requests, err := ssh.NewClientConn(conn, d.addr, d.config)
+	if err != nil {
+		stop()
+		_ = conn.Close()
+		var hostKeyErr *HostKeyError
+		if errors.As(err, &hostKeyErr) {
+			return nil, nil, hostKeyErr
+		}
+		return nil, nil, err
+	}
+	// The deadline only guards the handshake; uploads may take much longer.
+	_ = conn.SetDeadline(time.Time{})
+	sshClient := ssh.NewClient(sshConn, channels, requests)
+	client, err = sftp.NewClient(sshClient)
+	if err != nil {
+		stop()
+		_ = sshClient.Close()
+		return nil, nil, err
+	}
+	return client, func() {
+		stop()
+		_ = client.Close()
+		_ = sshClient.Close()
+	}, nil
+}
+
+func (d *SFTPDestination) List(ctx context.Context, prefix string) ([]Object, error) {
+	dir, namePrefix, err := splitPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	client, closeFn, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFn()
+
+	entries, err := client.ReadDir(d.remotePath(dir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// The directory is created by the first upload.
+			return nil, nil
+		}
+		return nil, err
+	}
+	var objects []Object
+	for _, entry := range entries {
+		if !entry.Mode().IsRegular() || !strings.HasPrefix(entry.Name(), namePrefix) {
+			continue
+		}
+		objects = append(objects, Object{Key: joinKey(dir, entry.Name()), Size: entry.Size(), LastModified: entry.ModTime().UTC()})
+	}
+	return objects, nil
+}
+
+func (d *SFTPDestination) Upload(ctx context.Context, key string, body io.ReadSeeker, size int64) (retErr error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	client, closeFn, err := d.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	target := d.remotePath(key)
+	if err := client.MkdirAll(path.Dir(target)); err != nil {
+		return fmt.Errorf("create sftp directory: %w", err)
+	}
+	file, err := client.Create(target)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if retErr != nil {
+			_ = client.Remove(target)
+		}
+	}()
+	written, err := io.Copy(file, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return err
+	}
+	if written != size {
+		return fmt.Errorf("sftp upload of %s wrote %d of %d bytes", key, written, size)
+	}
+	return nil
+}
+
+func (d *SFTPDestination) Download(ctx context.Context, key string) (io.ReadCloser, int64, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	client, closeFn, err := d.connect(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	file, err := client.Open(d.remotePath(key))
+	if err != nil {
+		closeFn()
+		return nil, 0, err
+	}
+	size := int64(-1)
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
+	}
+	return &sftpReadCloser{File: file, closeFn: closeFn}, size, nil
+}
+
+func (d *SFTPDestination) Delete(ctx context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	client, closeFn, err := d.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+	if err := client.Remove(d.remotePath(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// sftpReadCloser keeps the session open until the download is closed.
+type sftpReadCloser struct {
+	*sftp.File
+	closeFn func()
+}
+
+func (r *sftpReadCloser) Close() error {
+	err := r.File.Close()
+	r.closeFn()
+	return err
+}
@@ -0,0 +1,205 @@
+package backupdest
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// fakeSFTPServer serves an in-memory file system over SSH. All connections
+// share the same files.
+type fakeSFTPServer struct {
+	addr        string
+	fingerprint string
+	clientKey   string
+}
+
+func newFakeSFTPServer(t *testing.T) *fakeSFTPServer {
+	t.Helper()
+	_, hostPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate host key: %v", err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostPrivate)
+	if err != nil {
+		t.Fatalf("host signer: %v", err)
+	}
+	clientPublic, clientPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate client key: %v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(clientPrivate, "")
+	if err != nil {
+		t.Fatalf("marshal client key: %v", err)
+	}
+	authorized, err := ssh.NewPublicKey(clientPublic)
+	if err != nil {
+		t.Fatalf("client public key: %v", err)
+	}
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if meta.User() == "backup" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, errors.New("bad password")
+		},
+		PublicKeyCallback: func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if meta.User() == "backup" && string(key.Marshal()) == string(authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("unknown key")
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	handlers := sftp.InMemHandler()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveFakeSFTP(conn, config, handlers)
+		}
+	}()
+	return &fakeSFTPServer{
+		addr:        listener.Addr().String(),
+		fingerprint: ssh.FingerprintSHA256(hostSigner.PublicKey()),
+		clientKey:   string(pem.EncodeToMemory(block)),
+	}
+}
+
+func serveFakeSFTP(conn net.Conn, config *ssh.ServerConfig, handlers sftp.Handlers) {
+	defer func() { _ = conn.Close() }()
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range channelRequests {
+				ok := req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+				_ = req.Reply(ok, nil)
+				if ok {
+					server := sftp.NewRequestServer(channel, handlers)
+					_ = server.Serve()
+					_ = server.Close()
+					return
+				}
+			}
+		}()
+	}
+}
+
+func TestSFTPDestinationRoundTrip(t *testing.T) {
+	server := newFakeSFTPServer(t)
+	destination, err := NewSFTP(SFTPConfig{
+		Addr:            server.addr,
+		Username:        "backup",
+		Password:        "secret",
+		HostFingerprint: strings.TrimPrefix(server.fingerprint, "SHA256:"),
+		Dir:             "/srv/backups",
+	})
+	if err != nil {
+		t.Fatalf("new sftp destination: %v", err)
+	}
+	ctx := context.Background()
+
+	if objects, err := destination.List(ctx, "animate_backup_"); err != nil || len(objects) != 0 {
+		t.Fatalf("list before upload = %+v, %v", objects, err)
+	}
+	for _, key := range []string{"animate_backup_a.zip", "animate_backup_b.zip", "notes.txt"} {
+		if err := destination.Upload(ctx, key, strings.NewReader("payload-"+key), int64(len("payload-"+key))); err != nil {
+			t.Fatalf("upload %s: %v", key, err)
+		}
+	}
+	objects, err := destination.List(ctx, "animate_backup_")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(objects) != 2 || objects[0].Size != int64(len("payload-animate_backup_a.zip")) {
+		t.Fatalf("unexpected listing %+v", objects)
+	}
+
+	body, size, err := destination.Download(ctx, "animate_backup_b.zip")
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	if err := body.Close(); err != nil {
+		t.Fatalf("close download: %v", err)
+	}
+	if string(data) != "payload-animate_backup_b.zip" || size != int64(len(data)) {
+		t.Fatalf("download = %q (%d)", data, size)
+	}
+
+	if err := destination.Delete(ctx, "animate_backup_b.zip"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := destination.Delete(ctx, "animate_backup_b.zip"); err != nil {
+		t.Fatalf("deleting a missing file must succeed: %v", err)
+	}
+	if objects, _ := destination.List(ctx, "animate_backup_"); len(objects) != 1 {
+		t.Fatalf("expected one archive after delete, got %+v", objects)
+	}
+	if location := destination.Location("animate_backup_a.zip"); location != "sftp://backup@"+server.addr+"/srv/backups/animate_backup_a.zip" {
+		t.Fatalf("location = %q", location)
+	}
+
+	keyDestination, err := NewSFTP(SFTPConfig{Addr: server.addr, Username: "backup", PrivateKey: server.clientKey, HostFingerprint: server.fingerprint, Dir: "/srv/backups"})
+	if err != nil {
+		t.Fatalf("new sftp destination with key: %v", err)
+	}
+	if err := Check(ctx, keyDestination, ".animate-auto-tool/connection-tests/probe.txt"); err != nil {
+		t.Fatalf("check with private key: %v", err)
+	}
+}
+
+func TestSFTPDestinationRequiresPinnedHostKey(t *testing.T) {
+	server := newFakeSFTPServer(t)
+	for _, expected := range []string{"", "SHA256:" + strings.Repeat("A", 43)} {
+		destination, err := NewSFTP(SFTPConfig{Addr: server.addr, Username: "backup", Password: "secret", HostFingerprint: expected})
+		if err != nil {
+			t.Fatalf("new sftp destination: %v", err)
+		}
+		_, err = destination.List(context.Background(), "")
+		var hostKeyErr *HostKeyError
+		if !errors.As(err, &hostKeyErr) || hostKeyErr.Fingerprint != server.fingerprint || hostKeyErr.Expected != expected {
+			t.Fatalf("expected host key error for %q, got %v", expected, err)
+		}
+	}
+
+	if _, err := NewSFTP(SFTPConfig{Addr: server.addr, Username: "backup"}); !errors.Is(err, ErrIncompleteConfig) {
+		t.Fatalf("expected incomplete config without credentials, got %v", err)
+	}
+	if _, err := NewSFTP(SFTPConfig{Addr: server.addr, Username: "backup", Password: "x", HostFingerprint: "md5:aa"}); err == nil {
+		t.Fatal("expected malformed fingerprint to be rejected")
+	}
+	if addr, err := NormalizeSFTPAddr("nas.local"); err != nil || addr != "nas.local:22" {
+		t.Fatalf("NormalizeSFTPAddr = %q, %v", addr, err)
+	}
+}
@@ -0,0 +1,308 @@
+package backupdest
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pokerjest/animateAutoTool/internal/safeio"
+)
+
+const webDAVPropfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/><d:getcontentlength/><d:getlastmodified/></d:prop></d:propfind>`
+
+// WebDAVConfig configures a WebDAV folder such as a Nextcloud directory
+// (https://cloud.example.com/remote.php/dav/files/<user>/Backups) or an AList
+// mount (http://alist:5244/dav/backups).
+type WebDAVConfig struct {
+	URL        string
+	Username   string
+	Password   string
+	HTTPClient *http.Client
+}
+
+// WebDAVDestination stores archives as files in one WebDAV collection. Missing
+// collections are created on the first upload.
+type WebDAVDestination struct {
+	base       *url.URL
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+func NewWebDAV(cfg WebDAVConfig) (*WebDAVDestination, error) {
+	raw := strings.TrimSpace(cfg.URL)
+	if raw == "" {
+		return nil, ErrIncompleteConfig
+	}
+	base, err := url.Parse(raw)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid webdav url %q", raw)
+	}
+	base.RawQuery, base.Fragment = "", ""
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+	base.RawPath = ""
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &WebDAVDestination{base: base, username: cfg.Username, password: cfg.Password, httpClient: httpClient}, nil
+}
+
+func (d *WebDAVDestination) Kind() string { return KindWebDAV }
+
+func (d *WebDAVDestination) Location(key string) string { return d.resolve(key).String() }
+
+// resolve returns the URL of key, or of the root collection when key is "".
+func (d *WebDAVDestination) resolve(key string) *url.URL {
+	target := *d.base
+	target.Path = d.base.Path + key
+	return &target
+}
+
+func (d *WebDAVDestination) newRequest(ctx context.Context, method string, target *url.URL, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if d.username != "" || d.password != "" {
+		req.SetBasicAuth(d.username, d.password)
+	}
+	return req, nil
+}
+
+func (d *WebDAVDestination) do(req *http.Request) (*http.Response, error) {
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		drainAndClose(resp)
+		return nil, fmt.Errorf("webdav %s %s: authentication failed (%s)", req.Method, req.URL.Path, resp.Status)
+	}
+	return resp, nil
+}
+
+type webDAVMultistatus struct {
+	Responses []struct {
+		Href      string `xml:"href"`
+		Propstats []struct {
+			Status string `xml:"status"`
+			Prop   struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"collection"`
+				} `xml:"resourcetype"`
+				ContentLength string `xml:"getcontentlength"`
+				LastModified  string `xml:"getlastmodified"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+func (d *WebDAVDestination) List(ctx context.Context, prefix string) ([]Object, error) {
+	dir, namePrefix, err := splitPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	collection := d.resolve(dir)
+	if dir != "" {
+		collection.Path += "/"
+	}
+	req, err := d.newRequest(ctx, "PROPFIND", collection, strings.NewReader(webDAVPropfindBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Depth", "1")
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	resp, err := d.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer safeio.Close(resp.Body)
+	if resp.StatusCode == http.StatusNotFound {
+		// The collection is created by the first upload.
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("webdav PROPFIND %s: unexpected status %s", collection.Path, resp.Status)
+	}
+
+	var status webDAVMultistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("webdav PROPFIND %s: decode response: %w", collection.Path, err)
+	}
+	var objects []Object
+	for _, item := range status.Responses {
+		href, err := url.Parse(item.Href)
+		if err != nil {
+			continue
+		}
+		name, ok := strings.CutPrefix(href.Path, collection.Path)
+		if !ok || name == "" || strings.Contains(name, "/") || !strings.HasPrefix(name, namePrefix) {
+			// Skips the collection itself and sub collections.
+			continue
+		}
+		object := Object{Key: joinKey(dir, name)}
+		for _, propstat := range item.Propstats {
+			if propstat.Status != "" && !strings.Contains(propstat.Status, " 200 ") {
+				continue
+			}
+			if propstat.Prop.ResourceType.Collection != nil {
+				object.Key = ""
+				break
+			}
+			if size, err := strconv.ParseInt(strings.TrimSpace(propstat.Prop.ContentLength), 10, 64); err == nil {
+				object.Size = size
+			}
+			if modified, err := http.ParseTime(strings.TrimSpace(propstat.Prop.LastModified)); err == nil {
+				object.LastModified = modified.UTC()
+			}
+		}
+		if object.Key != "" {
+			objects = append(objects, object)
+		}
+	}
+	return objects, nil
+}
+
+func (d *WebDAVDestination) Upload(ctx context.Context, key string, body io.ReadSeeker, size int64) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	status, err := d.put(ctx, key, body, size)
+	if err != nil {
+		return err
+	}
+	// Servers answer 409 (RFC 4918) or 404 when a parent collection is
+	// missing. Create the parents once and retry.
+	if status == http.StatusConflict || status == http.StatusNotFound {
+		if err := d.makeCollections(ctx, path.Dir(key)); err != nil {
+			return err
+		}
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("rewind upload body: %w", err)
+		}
+		if status, err = d.put(ctx, key, body, size); err != nil {
+			return err
+		}
+	}
+	if status < 200 || status > 299 {
+		return fmt.Errorf("webdav PUT %s: unexpected status %d", key, status)
+	}
+	return nil
+}
+
+func (d *WebDAVDestination) put(ctx context.Context, key string, body io.ReadSeeker, size int64) (int, error) {
+	req, err := d.newRequest(ctx, http.MethodPut, d.resolve(key), io.NopCloser(body))
+	if err != nil {
+		return 0, err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/zip")
+	resp, err := d.do(req)
+	if err != nil {
+		return 0, err
+	}
+	drainAndClose(resp)
+	return resp.StatusCode, nil
+}
+
+// makeCollections creates dir (relative to the root collection) and any
+// missing collection above it, including the root itself.
+func (d *WebDAVDestination) makeCollections(ctx context.Context, dir string) error {
+	if dir == "." {
+		dir = ""
+	}
+	target := d.resolve(dir)
+	if !strings.HasSuffix(target.Path, "/") {
+		target.Path += "/"
+	}
+	return d.makeCollection(ctx, target)
+}
+
+// makeCollection issues MKCOL for target. A 409 means the parent is missing
+// (RFC 4918), so the parent is created first and the request retried.
+// Existing collections answer 405, which is fine.
+func (d *WebDAVDestination) makeCollection(ctx context.Context, target *url.URL) error {
+	for attempt := 0; ; attempt++ {
+		req, err := d.newRequest(ctx, "MKCOL", target, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := d.do(req)
+		if err != nil {
+			return err
+		}
+		drainAndClose(resp)
+		switch {
+		case resp.StatusCode == http.StatusMethodNotAllowed || (resp.StatusCode >= 200 && resp.StatusCode <= 299):
+			return nil
+		case resp.StatusCode == http.StatusConflict && attempt == 0:
+			parentPath := path.Dir(strings.TrimSuffix(target.Path, "/"))
+			if parentPath == "/" || parentPath == "." {
+				return fmt.Errorf("webdav MKCOL %s: unexpected status %s", target.Path, resp.Status)
+			}
+			parent := *target
+			parent.Path = parentPath + "/"
+			if err := d.makeCollection(ctx, &parent); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("webdav MKCOL %s: unexpected status %s", target.Path, resp.Status)
+		}
+	}
+}
+
+func (d *WebDAVDestination) Download(ctx context.Context, key string) (io.ReadCloser, int64, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	req, err := d.newRequest(ctx, http.MethodGet, d.resolve(key), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := d.do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		drainAndClose(resp)
+		return nil, 0, fmt.Errorf("webdav GET %s: unexpected status %s", key, resp.Status)
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+func (d *WebDAVDestination) Delete(ctx context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	req, err := d.newRequest(ctx, http.MethodDelete, d.resolve(key), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := d.do(req)
+	if err != nil {
+		return err
+	}
+	drainAndClose(resp)
+	if resp.StatusCode == http.StatusNotFound || (resp.StatusCode >= 200 && resp.StatusCode <= 299) {
+		return nil
+	}
+	return fmt.Errorf("webdav DELETE %s: unexpected status %s", key, resp.Status)
+}
+
+func drainAndClose(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	safeio.Close(resp.Body)
+}
@@ -0,0 +1,105 @@
+package backupdest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/webdav"
+)
+
+func newFakeWebDAV(t *testing.T) (webdav.FileSystem, *httptest.Server) {
+	t.Helper()
+	fs := webdav.NewMemFS()
+	handler := &webdav.Handler{Prefix: "/dav", FileSystem: fs, LockSystem: webdav.NewMemLS()}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return fs, server
+}
+
+func TestWebDAVDestinationRoundTrip(t *testing.T) {
+	fs, server := newFakeWebDAV(t)
+	destination, err := NewWebDAV(WebDAVConfig{URL: server.URL + "/dav/team/My Backups", Username: "admin", Password: "secret", HTTPClient: server.Client()})
+	if err != nil {
+		t.Fatalf("new webdav destination: %v", err)
+	}
+	ctx := context.Background()
+
+	// The collection does not exist yet; listing is empty until the first
+	// upload creates it.
+	if objects, err := destination.List(ctx, "animate_backup_"); err != nil || len(objects) != 0 {
+		t.Fatalf("list before upload = %+v, %v", objects, err)
+	}
+	for _, key := range []string{"animate_backup_a.zip", "animate_backup_b.zip", "notes.txt", "nested/animate_backup_c.zip"} {
+		if err := destination.Upload(ctx, key, strings.NewReader("payload-"+key), int64(len("payload-"+key))); err != nil {
+			t.Fatalf("upload %s: %v", key, err)
+		}
+	}
+	if _, err := fs.Stat(ctx, "/team/My Backups/nested/animate_backup_c.zip"); err != nil {
+		t.Fatalf("expected nested upload on the server: %v", err)
+	}
+
+	objects, err := destination.List(ctx, "animate_backup_")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(objects) != 2 || objects[0].Key == objects[1].Key {
+		t.Fatalf("expected the two top-level archives, got %+v", objects)
+	}
+	for _, object := range objects {
+		if object.Size != int64(len("payload-"+object.Key)) || object.LastModified.IsZero() {
+			t.Fatalf("unexpected metadata %+v", object)
+		}
+	}
+	if nested, err := destination.List(ctx, "nested/animate_"); err != nil || len(nested) != 1 || nested[0].Key != "nested/animate_backup_c.zip" {
+		t.Fatalf("list nested = %+v, %v", nested, err)
+	}
+
+	body, size, err := destination.Download(ctx, "animate_backup_a.zip")
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	_ = body.Close()
+	if string(data) != "payload-animate_backup_a.zip" || size != int64(len(data)) {
+		t.Fatalf("download = %q (%d)", data, size)
+	}
+
+	if err := destination.Delete(ctx, "animate_backup_a.zip"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := destination.Delete(ctx, "animate_backup_a.zip"); err != nil {
+		t.Fatalf("deleting a missing file must succeed: %v", err)
+	}
+	if err := Check(ctx, destination, ".animate-auto-tool/connection-tests/probe.txt"); err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if location := destination.Location("animate_backup_b.zip"); location != server.URL+"/dav/team/My%20Backups/animate_backup_b.zip" {
+		t.Fatalf("location = %q", location)
+	}
+}
+
+func TestWebDAVDestinationRejectsBadCredentials(t *testing.T) {
+	_, server := newFakeWebDAV(t)
+	destination, err := NewWebDAV(WebDAVConfig{URL: server.URL + "/dav/", Username: "admin", Password: "wrong", HTTPClient: server.Client()})
+	if err != nil {
+		t.Fatalf("new webdav destination: %v", err)
+	}
+	var checkErr *CheckError
+	if err := Check(context.Background(), destination, "probe.txt"); !errors.As(err, &checkErr) || checkErr.Step != "list" || !strings.Contains(err.Error(), "authentication failed") {
+		t.Fatalf("expected authentication failure, got %v", err)
+	}
+	if _, err := NewWebDAV(WebDAVConfig{URL: "ftp://example.com/"}); err == nil {
+		t.Fatal("expected non-http url to be rejected")
+	}
+}
@@ -34,11 +34,13 @@ func normalizeBangumiImageURL(raw string) string {
 }
 
 func NewClient(appID, appSecret, redirectURI string) *Client {
+	client := httpx.NewRestyClient(10*time.Second, "", nil)
+	httpx.ObserveMetadataProvider(client, "bangumi")
 	return &Client{
 		AppID:       appID,
 		AppSecret:   appSecret,
 		RedirectURI: redirectURI,
-		client:      httpx.NewRestyClient(10*time.Second, "", nil),
+		client:      client,
 	}
 }
 
@@ -453,10 +455,14 @@ type UserCollectionItem struct {
 // Actually for v0, 'me' might be supported. Let's try or use ID.
 // collectionType: 3 = Watching
 func (c *Client) GetUserCollection(accessToken string, username string, collectionType int, limit int, offset int) ([]UserCollectionItem, error) {
+	return c.GetUserCollectionContext(context.Background(), accessToken, username, collectionType, limit, offset)
+}
+
+func (c *Client) GetUserCollectionContext(ctx context.Context, accessToken string, username string, collectionType int, limit int, offset int) ([]UserCollectionItem, error) {
 	// GET https://api.bgm.tv/v0/users/{username}/collections
 	u := fmt.Sprintf("https://api.bgm.tv/v0/users/%s/collections", username)
 
-	resp, err := c.client.R().
+	resp, err := httpx.NewRequest(ctx, c.client).
 		SetHeader("Authorization", "Bearer "+accessToken).
 		SetHeader("User-Agent", "pokerjest/animateAutoTool/1.0 (https://github.com/pokerjest/animateAutoTool)").
 		SetQueryParams(map[string]string{
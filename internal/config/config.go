@@ -134,6 +134,15 @@ func loadConfig(configPath string, allowWrites bool) error {
 		return fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	keyCreated, err := loadSettingsKeys(allowWrites)
+	if err != nil {
+		return fmt.Errorf("failed to resolve settings encryption key: %w", err)
+	}
+	if keyCreated {
+		fmt.Printf("Generated a settings encryption key at %s; encrypted full backups carry a copy, copy it yourself when moving the raw database\n", settingsKeyPath)
+	}
+	decryptSettings(AppConfig.SystemSettings)
+
 	if AppConfig.Database.Path == "" {
 		AppConfig.Database.Path = filepath.Join(AppPaths.DataDir, defaultDatabaseFileName())
 	} else if !filepath.IsAbs(AppConfig.Database.Path) {
@@ -419,6 +428,7 @@ system_settings:
   write_nfo_enabled: "true"
   write_images_enabled: "true"
   incremental_scan_enabled: "true"
+  media_probe_enabled: "true"
 `) + "\n"
 
 	return os.WriteFile(AppPaths.ConfigFile, []byte(content), 0600)
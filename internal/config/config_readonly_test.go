@@ -17,7 +17,9 @@ func preserveConfigState(t *testing.T) {
 	previousExecutablePathFunc := executablePathFunc
 	previousUserConfigDirFunc := userConfigDirFunc
 	previousGOOSOverride := goosOverride
+	previousSettingsKeys, previousSettingsKeyPath, previousSettingsKeyPersistent := settingsKeys, settingsKeyPath, settingsKeyPersistent
 	t.Cleanup(func() {
+		installSettingsKeys(previousSettingsKeyPath, previousSettingsKeys, previousSettingsKeyPersistent)
 		AppConfig = previousAppConfig
 		AppPaths = previousAppPaths
 		ConfigAutoCreated = previousAutoCreated
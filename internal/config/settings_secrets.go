@@ -0,0 +1,409 @@
+package config
+
+import (
+	"bytes"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pokerjest/animateAutoTool/internal/security"
+)
+
+const (
+	encryptedSettingPrefix = "enc:v1:"
+	settingsKeyFileName    = "settings_key"
+	settingsKeyInfo        = "animateAutoTool system settings v1"
+	// settingsKeyRetention keeps the previous key after a rotation so full
+	// backups taken just before the rotation can still be restored.
+	settingsKeyRetention = 2
+)
+
+// ErrSettingUndecryptable is returned for encrypted settings whose key is no
+// longer (or was never) present in the local key file, for example after the
+// database was copied to another machine without data/bootstrap/settings_key.
+var ErrSettingUndecryptable = errors.New("setting was encrypted with a key that is not available")
+
+type settingsKey struct {
+	id     string
+	master []byte
+	key    []byte
+}
+
+// SettingsKeyInfo describes the local settings encryption key file.
+type SettingsKeyInfo struct {
+	KeyID          string
+	RetainedKeyIDs []string
+	Path           string
+	Persistent     bool
+}
+
+var (
+	settingsKeyMu         sync.RWMutex
+	settingsKeys          []settingsKey
+	settingsKeyPath       string
+	settingsKeyPersistent bool
+	settingsKeyRotateMu   sync.Mutex
+)
+
+// IsSensitiveSettingKey reports whether a system setting holds a credential.
+// Such settings are encrypted in the database and in the config.yaml mirror,
+// and are left out of selective backups.
+func IsSensitiveSettingKey(key string) bool {
+	lowerKey := strings.ToLower(key)
+	return strings.Contains(lowerKey, "password") ||
+		strings.Contains(lowerKey, "secret") ||
+		strings.Contains(lowerKey, "token") ||
+		strings.Contains(lowerKey, "key") ||
+		strings.Contains(lowerKey, "credential")
+}
+
+// IsEncryptedSetting reports whether value was produced by EncryptSetting.
+func IsEncryptedSetting(value string) bool {
+	return strings.HasPrefix(value, encryptedSettingPrefix)
+}
+
+// EncryptSetting encrypts value with the current settings key when key is
+// sensitive. Empty values, non-sensitive keys and values that are already
+// encrypted are returned unchanged, so every write path can call it blindly.
+func EncryptSetting(key, value string) (string, error) {
+	if value == "" || !IsSensitiveSettingKey(key) || IsEncryptedSetting(value) {
+		return value, nil
+	}
+	keys, err := activeSettingsKeys()
+	if err != nil {
+		return "", err
+	}
+	current := keys[0]
+	sealed, err := security.Seal(current.key, []byte(value), settingAdditionalData(key))
+	if err != nil {
+		return "", fmt.Errorf("encrypt setting %s: %w", key, err)
+	}
+	return encryptedSettingPrefix + current.id + ":" + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSetting returns the plaintext of an encrypted setting. Plaintext
+// values written by older versions, or typed into config.yaml by hand, are
+// returned unchanged.
+func DecryptSetting(key, value string) (string, error) {
+	if !IsEncryptedSetting(value) {
+		return value, nil
+	}
+	keyID, sealed, err := splitEncryptedSetting(key, value)
+	if err != nil {
+		return "", err
+	}
+	keys, err := activeSettingsKeys()
+	if err != nil {
+		return "", err
+	}
+	return openSetting(keys, key, keyID, sealed)
+}
+
+// SettingKeyID returns the id of the key an encrypted setting was sealed
+// with, or "" for plaintext and malformed values.
+func SettingKeyID(value string) string {
+	if !IsEncryptedSetting(value) {
+		return ""
+	}
+	keyID, _, ok := strings.Cut(strings.TrimPrefix(value, encryptedSettingPrefix), ":")
+	if !ok {
+		return ""
+	}
+	return keyID
+}
+
+func splitEncryptedSetting(key, value string) (string, []byte, error) {
+	keyID, payload, ok := strings.Cut(strings.TrimPrefix(value, encryptedSettingPrefix), ":")
+	if !ok {
+		return "", nil, fmt.Errorf("%w: malformed value for %s", ErrSettingUndecryptable, key)
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: malformed value for %s", ErrSettingUndecryptable, key)
+	}
+	return keyID, sealed, nil
+}
+
+func openSetting(keys []settingsKey, key, keyID string, sealed []byte) (string, error) {
+	for _, candidate := range keys {
+		if candidate.id != keyID {
+			continue
+		}
+		plaintext, err := security.Open(candidate.key, sealed, settingAdditionalData(key))
+		if err != nil {
+			return "", fmt.Errorf("%w: %s does not authenticate with key %s", ErrSettingUndecryptable, key, keyID)
+		}
+		return string(plaintext), nil
+	}
+	return "", fmt.Errorf("%w: %s uses unknown key %s", ErrSettingUndecryptable, key, keyID)
+}
+
+// SettingsKeyRing is a set of settings keys carried outside the local key
+// file, such as the copy stored inside an encrypted full backup.
+type SettingsKeyRing struct {
+	keys []settingsKey
+}
+
+// ExportSettingsKeys returns the local key ring in the key file format. It is
+// only meant to be written into password-protected backup archives, so a
+// full backup can restore its credentials on a machine that lost the file.
+func ExportSettingsKeys() ([]byte, []string, error) {
+	keys, err := activeSettingsKeys()
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := make([]string, 0, len(keys))
+	for _, key := range keys {
+		ids = append(ids, key.id)
+	}
+	return formatSettingsKeyFile(keys), ids, nil
+}
+
+// ParseSettingsKeyRing reads key file content produced by ExportSettingsKeys.
+func ParseSettingsKeyRing(data []byte) (SettingsKeyRing, error) {
+	keys, err := parseSettingsKeys(data, "backup archive")
+	if err != nil {
+		return SettingsKeyRing{}, err
+	}
+	return SettingsKeyRing{keys: keys}, nil
+}
+
+// IDs returns the ids of the keys in the ring, current key first.
+func (r SettingsKeyRing) IDs() []string {
+	ids := make([]string, 0, len(r.keys))
+	for _, key := range r.keys {
+		ids = append(ids, key.id)
+	}
+	return ids
+}
+
+// Decrypt behaves like DecryptSetting but only uses the keys in the ring.
+func (r SettingsKeyRing) Decrypt(key, value string) (string, error) {
+	if !IsEncryptedSetting(value) {
+		return value, nil
+	}
+	keyID, sealed, err := splitEncryptedSetting(key, value)
+	if err != nil {
+		return "", err
+	}
+	return openSetting(r.keys, key, keyID, sealed)
+}
+
+// SettingsKeyStatus returns the current key id and the ids that can still be
+// decrypted.
+func SettingsKeyStatus() (SettingsKeyInfo, error) {
+	keys, err := activeSettingsKeys()
+	if err != nil {
+		return SettingsKeyInfo{}, err
+	}
+	settingsKeyMu.RLock()
+	defer settingsKeyMu.RUnlock()
+	info := SettingsKeyInfo{KeyID: keys[0].id, Path: settingsKeyPath, Persistent: settingsKeyPersistent}
+	for _, key := range keys {
+		info.RetainedKeyIDs = append(info.RetainedKeyIDs, key.id)
+	}
+	return info, nil
+}
+
+// RotateSettingsKey makes a freshly generated key current and calls
+// reencrypt to rewrite every stored secret with it. The new key is persisted
+// before reencrypt runs and older keys are only dropped after it succeeds, so
+// a crash or error part-way through never leaves a value without its key.
+func RotateSettingsKey(reencrypt func() error) (string, error) {
+	settingsKeyRotateMu.Lock()
+	defer settingsKeyRotateMu.Unlock()
+
+	current, err := activeSettingsKeys()
+	if err != nil {
+		return "", err
+	}
+	next, err := newSettingsKey()
+	if err != nil {
+		return "", err
+	}
+	candidate := append([]settingsKey{next}, current...)
+	if err := persistSettingsKeys(candidate); err != nil {
+		return "", err
+	}
+	if reencrypt != nil {
+		if err := reencrypt(); err != nil {
+			return "", err
+		}
+	}
+	if len(candidate) > settingsKeyRetention {
+		candidate = candidate[:settingsKeyRetention]
+	}
+	if err := persistSettingsKeys(candidate); err != nil {
+		return "", err
+	}
+	return next.id, nil
+}
+
+func settingAdditionalData(key string) []byte {
+	return []byte(strings.ToLower(strings.TrimSpace(key)))
+}
+
+// loadSettingsKeys reads data/bootstrap/settings_key, creating it when writes
+// are allowed. The key is deliberately separate from auth.secret_key: that
+// value lives in config.yaml next to the ciphertext it would protect, and
+// changing it must only sign users out.
+func loadSettingsKeys(allowWrites bool) (created bool, err error) {
+	path := filepath.Join(AppPaths.DataDir, "bootstrap", settingsKeyFileName)
+	keys, err := readSettingsKeyFile(path)
+	switch {
+	case err == nil && len(keys) > 0:
+		installSettingsKeys(path, keys, true)
+		return false, nil
+	case err != nil && !os.IsNotExist(err):
+		return false, err
+	}
+
+	if !allowWrites {
+		// Read-only diagnostics fall back to a process-local key; encrypted
+		// values simply stay unreadable instead of creating the file.
+		installSettingsKeys(path, nil, false)
+		return false, nil
+	}
+	key, err := newSettingsKey()
+	if err != nil {
+		return false, err
+	}
+	if err := writeSettingsKeyFile(path, []settingsKey{key}); err != nil {
+		return false, err
+	}
+	installSettingsKeys(path, []settingsKey{key}, true)
+	return true, nil
+}
+
+func readSettingsKeyFile(path string) ([]settingsKey, error) {
+	data, err := os.ReadFile(filepath.Clean(path)) //nolint:gosec // path is derived from the app-controlled data directory.
+	if err != nil {
+		return nil, err
+	}
+	return parseSettingsKeys(data, path)
+}
+
+func parseSettingsKeys(data []byte, source string) ([]settingsKey, error) {
+	var keys []settingsKey
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		master, err := hex.DecodeString(line)
+		if err != nil || len(master) < 32 {
+			return nil, fmt.Errorf("parse settings key file %s: invalid key line", source)
+		}
+		key, err := deriveSettingsKey(master)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func formatSettingsKeyFile(keys []settingsKey) []byte {
+	var content bytes.Buffer
+	content.WriteString("# Settings encryption keys. The first key is current; older keys decrypt\n")
+	content.WriteString("# values written before a rotation. Keep this file together with backups.\n")
+	for _, key := range keys {
+		content.WriteString(hex.EncodeToString(key.master))
+		content.WriteByte('\n')
+	}
+	return content.Bytes()
+}
+
+func writeSettingsKeyFile(path string, keys []settingsKey) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, formatSettingsKeyFile(keys), 0o600); err != nil {
+		return fmt.Errorf("write settings key file: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("replace settings key file: %w", err)
+	}
+	return nil
+}
+
+func persistSettingsKeys(keys []settingsKey) error {
+	settingsKeyMu.RLock()
+	path, persistent := settingsKeyPath, settingsKeyPersistent
+	settingsKeyMu.RUnlock()
+	if persistent {
+		if err := writeSettingsKeyFile(path, keys); err != nil {
+			return err
+		}
+	}
+	installSettingsKeys(path, keys, persistent)
+	return nil
+}
+
+func installSettingsKeys(path string, keys []settingsKey, persistent bool) {
+	settingsKeyMu.Lock()
+	defer settingsKeyMu.Unlock()
+	settingsKeyPath = path
+	settingsKeys = append([]settingsKey(nil), keys...)
+	settingsKeyPersistent = persistent
+}
+
+// activeSettingsKeys returns the key ring, current key first. Processes that
+// never loaded the config (tests, offline helpers) get an ephemeral key.
+func activeSettingsKeys() ([]settingsKey, error) {
+	settingsKeyMu.RLock()
+	keys := settingsKeys
+	settingsKeyMu.RUnlock()
+	if len(keys) > 0 {
+		return keys, nil
+	}
+
+	settingsKeyMu.Lock()
+	defer settingsKeyMu.Unlock()
+	if len(settingsKeys) == 0 {
+		key, err := newSettingsKey()
+		if err != nil {
+			return nil, err
+		}
+		settingsKeys = []settingsKey{key}
+		settingsKeyPersistent = false
+	}
+	return settingsKeys, nil
+}
+
+func newSettingsKey() (settingsKey, error) {
+	master := make([]byte, 32)
+	if _, err := rand.Read(master); err != nil {
+		return settingsKey{}, err
+	}
+	return deriveSettingsKey(master)
+}
+
+func deriveSettingsKey(master []byte) (settingsKey, error) {
+	key, err := hkdf.Key(sha256.New, master, nil, settingsKeyInfo, 32)
+	if err != nil {
+		return settingsKey{}, fmt.Errorf("derive settings key: %w", err)
+	}
+	digest := sha256.Sum256(master)
+	return settingsKey{id: hex.EncodeToString(digest[:4]), master: master, key: key}, nil
+}
+
+// decryptSettings replaces encrypted values in place. Values that cannot be
+// decrypted are kept as ciphertext so rewriting the mirror does not lose them.
+func decryptSettings(values map[string]string) {
+	for key, value := range values {
+		if plaintext, err := DecryptSetting(key, value); err == nil {
+			values[key] = plaintext
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigEncryptsMirrorWithPersistedSettingsKey(t *testing.T) {
+	preserveConfigState(t)
+	root := t.TempDir()
+	appRootOverride = ""
+	authSecretFallbackPathOverride = ""
+	configData := "system_settings:\n  qb_password: typed-by-hand\n  qb_url: http://qb:8080\n"
+	if err := os.WriteFile(filepath.Join(root, defaultConfigFileName), []byte(configData), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	if err := LoadConfig(root); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	keyPath := filepath.Join(root, "data", "bootstrap", settingsKeyFileName)
+	info, err := os.Stat(keyPath)
+	if err != nil {
+		t.Fatalf("expected settings key file: %v", err)
+	}
+	if runtime.GOOS != goosWindows && info.Mode().Perm() != 0o600 {
+		t.Fatalf("settings key permissions = %o, want 600", info.Mode().Perm())
+	}
+	if AppConfig.SystemSettings["qb_password"] != "typed-by-hand" {
+		t.Fatalf("hand-written plaintext should load unchanged, got %q", AppConfig.SystemSettings["qb_password"])
+	}
+
+	if err := ReplaceSystemSettings(AppConfig.SystemSettings); err != nil {
+		t.Fatalf("ReplaceSystemSettings: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(root, defaultConfigFileName))
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if strings.Contains(string(data), "typed-by-hand") || !strings.Contains(string(data), "qb_url: http://qb:8080") {
+		t.Fatalf("expected only the secret to be encrypted:\n%s", data)
+	}
+
+	// A second process start must decrypt the mirror with the same key file.
+	if err := LoadConfig(root); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if AppConfig.SystemSettings["qb_password"] != "typed-by-hand" {
+		t.Fatalf("expected encrypted mirror to decrypt after reload, got %q", AppConfig.SystemSettings["qb_password"])
+	}
+}
+
+func TestLoadConfigReadOnlyDoesNotCreateSettingsKey(t *testing.T) {
+	preserveConfigState(t)
+	root := t.TempDir()
+	appRootOverride = ""
+	authSecretFallbackPathOverride = ""
+
+	if err := LoadConfigReadOnly(root); err != nil {
+		t.Fatalf("LoadConfigReadOnly: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "data", "bootstrap", settingsKeyFileName)); !os.IsNotExist(err) {
+		t.Fatalf("read-only load created the settings key: %v", err)
+	}
+	status, err := SettingsKeyStatus()
+	if err != nil || status.Persistent || status.KeyID == "" {
+		t.Fatalf("expected an in-memory key, got %#v %v", status, err)
+	}
+}
+
+func TestRotateSettingsKeyKeepsPreviousKeyForOlderValues(t *testing.T) {
+	preserveConfigState(t)
+	root := t.TempDir()
+	appRootOverride = ""
+	authSecretFallbackPathOverride = ""
+	if err := LoadConfig(root); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	original, _ := SettingsKeyStatus()
+	first, err := EncryptSetting("r2_secret_key", "s3cr3t")
+	if err != nil {
+		t.Fatalf("EncryptSetting: %v", err)
+	}
+
+	failing := errors.New("database locked")
+	if _, err := RotateSettingsKey(func() error { return failing }); !errors.Is(err, failing) {
+		t.Fatalf("expected reencrypt error, got %v", err)
+	}
+	if plaintext, err := DecryptSetting("r2_secret_key", first); err != nil || plaintext != "s3cr3t" {
+		t.Fatalf("failed rotation must keep old values readable: %q %v", plaintext, err)
+	}
+
+	var resealed string
+	newID, err := RotateSettingsKey(func() error {
+		plaintext, err := DecryptSetting("r2_secret_key", first)
+		if err != nil {
+			return err
+		}
+		resealed, err = EncryptSetting("r2_secret_key", plaintext)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("RotateSettingsKey: %v", err)
+	}
+	if !strings.Contains(resealed, ":"+newID+":") {
+		t.Fatalf("expected value resealed with %s, got %q", newID, resealed)
+	}
+	status, _ := SettingsKeyStatus()
+	if status.KeyID != newID || len(status.RetainedKeyIDs) != settingsKeyRetention {
+		t.Fatalf("unexpected key status after rotation: %#v", status)
+	}
+
+	// The file survives a restart and the key from before the failed attempt
+	// has now been pruned, so values sealed with it are reported unreadable.
+	if err := LoadConfig(root); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if plaintext, err := DecryptSetting("r2_secret_key", resealed); err != nil || plaintext != "s3cr3t" {
+		t.Fatalf("resealed value after reload: %q %v", plaintext, err)
+	}
+	if _, err := DecryptSetting("r2_secret_key", first); !errors.Is(err, ErrSettingUndecryptable) || original.KeyID == newID {
+		t.Fatalf("expected the pruned key to be unavailable, got %v", err)
+	}
+}
+
+func TestDecryptSettingRejectsValueMovedToAnotherKey(t *testing.T) {
+	sealed, err := EncryptSetting("qb_password", "hunter2")
+	if err != nil {
+		t.Fatalf("EncryptSetting: %v", err)
+	}
+	if _, err := DecryptSetting("jellyfin_password", sealed); !errors.Is(err, ErrSettingUndecryptable) {
+		t.Fatalf("expected copied ciphertext to fail, got %v", err)
+	}
+	if value, _ := EncryptSetting("qb_url", "http://qb"); value != "http://qb" {
+		t.Fatalf("non-sensitive settings must stay plaintext, got %q", value)
+	}
+	if value, _ := EncryptSetting("qb_password", sealed); value != sealed {
+		t.Fatal("encrypting an encrypted value must be a no-op")
+	}
+}
+
+func TestExportedSettingsKeyRingDecryptsAfterTheLocalKeyIsGone(t *testing.T) {
+	preserveConfigState(t)
+	root := t.TempDir()
+	appRootOverride = ""
+	authSecretFallbackPathOverride = ""
+	if err := LoadConfig(root); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	sealed, err := EncryptSetting("qb_password", "archived")
+	if err != nil {
+		t.Fatalf("EncryptSetting: %v", err)
+	}
+	exported, ids, err := ExportSettingsKeys()
+	if err != nil {
+		t.Fatalf("ExportSettingsKeys: %v", err)
+	}
+	if len(ids) != 1 || SettingKeyID(sealed) != ids[0] {
+		t.Fatalf("expected %q to be sealed with the exported key %v", sealed, ids)
+	}
+
+	// Simulate a new machine: a fresh data directory with its own key.
+	if err := LoadConfig(t.TempDir()); err != nil {
+		t.Fatalf("LoadConfig on new machine: %v", err)
+	}
+	if _, err := DecryptSetting("qb_password", sealed); !errors.Is(err, ErrSettingUndecryptable) {
+		t.Fatalf("expected the local ring to miss the old key, got %v", err)
+	}
+	ring, err := ParseSettingsKeyRing(exported)
+	if err != nil {
+		t.Fatalf("ParseSettingsKeyRing: %v", err)
+	}
+	if plaintext, err := ring.Decrypt("qb_password", sealed); err != nil || plaintext != "archived" {
+		t.Fatalf("archived ring decrypt = %q %v", plaintext, err)
+	}
+	if _, err := ring.Decrypt("r2_secret_key", sealed); !errors.Is(err, ErrSettingUndecryptable) {
+		t.Fatalf("archived ring must still bind values to their key name, got %v", err)
+	}
+}
@@ -109,6 +109,7 @@ func settingsFromDocument(doc *yaml.Node) (map[string]string, error) {
 		if err := root.Content[i+1].Decode(&settings); err != nil {
 			return nil, fmt.Errorf("decode %s: %w", systemSettingsYAMLKey, err)
 		}
+		decryptSettings(settings)
 		return settings, nil
 	}
 	return map[string]string{}, nil
@@ -116,7 +117,10 @@ func settingsFromDocument(doc *yaml.Node) (map[string]string, error) {
 
 func writeSystemSettingsDocument(doc *yaml.Node, settings map[string]string) error {
 	root := doc.Content[0]
-	settingsNode := buildSettingsNode(settings)
+	settingsNode, err := buildSettingsNode(settings)
+	if err != nil {
+		return err
+	}
 	found := false
 	for i := 0; i+1 < len(root.Content); i += 2 {
 		if root.Content[i].Value == systemSettingsYAMLKey {
@@ -130,7 +134,7 @@ func writeSystemSettingsDocument(doc *yaml.Node, settings map[string]string) err
 			Kind:        yaml.ScalarNode,
 			Tag:         "!!str",
 			Value:       systemSettingsYAMLKey,
-			HeadComment: "Web settings mirror. May contain service passwords and API keys.",
+			HeadComment: "Web settings mirror. Passwords and API keys are encrypted with data/bootstrap/settings_key.",
 		}
 		root.Content = append(root.Content, keyNode, settingsNode)
 	}
@@ -154,7 +158,9 @@ func writeSystemSettingsDocument(doc *yaml.Node, settings map[string]string) err
 	return nil
 }
 
-func buildSettingsNode(settings map[string]string) *yaml.Node {
+// buildSettingsNode encrypts sensitive values on their way to disk; the
+// in-memory mirror keeps plaintext.
+func buildSettingsNode(settings map[string]string) (*yaml.Node, error) {
 	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
 	keys := make([]string, 0, len(settings))
 	for key := range settings {
@@ -162,12 +168,16 @@ func buildSettingsNode(settings map[string]string) *yaml.Node {
 	}
 	sort.Strings(keys)
 	for _, key := range keys {
+		value, err := EncryptSetting(key, settings[key])
+		if err != nil {
+			return nil, err
+		}
 		node.Content = append(node.Content,
 			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
-			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: settings[key]},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
 		)
 	}
-	return node
+	return node, nil
 }
 
 func writePrivateConfigFile(data []byte) error {
@@ -20,7 +20,7 @@ func TestSystemSettingsMirrorPreservesConfigAndSecuresFile(t *testing.T) {
 		AppConfig = previousConfig
 	})
 
-	initial := []byte("# keep this comment\nserver:\n  port: 8306\nsystem_settings:\n  old_key: old-value\n")
+	initial := []byte("# keep this comment\nserver:\n  port: 8306\nsystem_settings:\n  old_setting: old-value\n")
 	if err := os.WriteFile(ConfigFilePath(), initial, 0o600); err != nil {
 		t.Fatalf("write fixture: %v", err)
 	}
@@ -48,9 +48,16 @@ func TestSystemSettingsMirrorPreservesConfigAndSecuresFile(t *testing.T) {
 	if document.Server.Port != 8306 {
 		t.Fatalf("server config was not preserved: %#v", document.Server)
 	}
-	if document.SystemSettings["old_key"] != "old-value" || document.SystemSettings["qb_password"] != "local secret" || document.SystemSettings["enabled"] != "true" {
+	if document.SystemSettings["old_setting"] != "old-value" || document.SystemSettings["enabled"] != "true" {
 		t.Fatalf("unexpected mirrored settings: %#v", document.SystemSettings)
 	}
+	mirrored := document.SystemSettings["qb_password"]
+	if !IsEncryptedSetting(mirrored) {
+		t.Fatalf("expected the password to be encrypted on disk, got %q", mirrored)
+	}
+	if plaintext, err := DecryptSetting("qb_password", mirrored); err != nil || plaintext != "local secret" {
+		t.Fatalf("mirrored password did not decrypt: %q %v", plaintext, err)
+	}
 	if AppConfig.SystemSettings["qb_password"] != "local secret" {
 		t.Fatalf("in-memory settings were not refreshed: %#v", AppConfig.SystemSettings)
 	}
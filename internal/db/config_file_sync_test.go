@@ -59,9 +59,20 @@ system_settings:
 	if err := yaml.Unmarshal(data, &document); err != nil {
 		t.Fatalf("parse exported config: %v", err)
 	}
-	if document.SystemSettings[model.ConfigKeyAIModel] != "db-model" || document.SystemSettings[model.ConfigKeyQBPassword] != "yaml-secret" {
+	if document.SystemSettings[model.ConfigKeyAIModel] != "db-model" {
 		t.Fatalf("unexpected exported settings: %#v", document.SystemSettings)
 	}
+	if mirrored, err := config.DecryptSetting(model.ConfigKeyQBPassword, document.SystemSettings[model.ConfigKeyQBPassword]); err != nil ||
+		mirrored != "yaml-secret" || !config.IsEncryptedSetting(document.SystemSettings[model.ConfigKeyQBPassword]) {
+		t.Fatalf("expected the exported password to be encrypted, got %q", document.SystemSettings[model.ConfigKeyQBPassword])
+	}
+	var password model.GlobalConfig
+	if err := DB.First(&password, "key = ?", model.ConfigKeyQBPassword).Error; err != nil {
+		t.Fatalf("read imported password: %v", err)
+	}
+	if !config.IsEncryptedSetting(password.Value) {
+		t.Fatalf("expected the imported password to be encrypted at rest, got %q", password.Value)
+	}
 
 	if err := SaveGlobalConfig(model.ConfigKeyAIModel, "new-model"); err != nil {
 		t.Fatalf("SaveGlobalConfig: %v", err)
@@ -77,3 +88,49 @@ system_settings:
 		t.Fatalf("single setting was not mirrored: %#v", document.SystemSettings)
 	}
 }
+
+func TestResealSensitiveConfigsEncryptsLegacyPlaintext(t *testing.T) {
+	InitDB(sqliteMemoryPath)
+	t.Cleanup(func() {
+		_ = CloseDB()
+		DB = nil
+	})
+	foreign := "enc:v1:00000000:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	for key, value := range map[string]string{
+		model.ConfigKeyTMDBToken:   "legacy-token",
+		model.ConfigKeyQBUrl:       "http://qb:8080",
+		model.ConfigKeyR2SecretKey: foreign,
+	} {
+		if err := DB.Create(&model.GlobalConfig{Key: key, Value: value}).Error; err != nil {
+			t.Fatalf("seed %s: %v", key, err)
+		}
+	}
+
+	resealed, unreadable, err := ResealSensitiveConfigs(DB)
+	if err != nil {
+		t.Fatalf("ResealSensitiveConfigs: %v", err)
+	}
+	if resealed != 1 || len(unreadable) != 1 || unreadable[0] != model.ConfigKeyR2SecretKey {
+		t.Fatalf("unexpected reseal result: %d %v", resealed, unreadable)
+	}
+	var rows []model.GlobalConfig
+	if err := DB.Order("key").Find(&rows).Error; err != nil {
+		t.Fatalf("read rows: %v", err)
+	}
+	for _, row := range rows {
+		switch row.Key {
+		case model.ConfigKeyTMDBToken:
+			if plaintext, err := config.DecryptSetting(row.Key, row.Value); err != nil || plaintext != "legacy-token" || row.Value == plaintext {
+				t.Fatalf("token was not encrypted: %q", row.Value)
+			}
+		case model.ConfigKeyQBUrl:
+			if row.Value != "http://qb:8080" {
+				t.Fatalf("non-sensitive setting changed: %q", row.Value)
+			}
+		case model.ConfigKeyR2SecretKey:
+			if row.Value != foreign {
+				t.Fatalf("unreadable value must be left untouched: %q", row.Value)
+			}
+		}
+	}
+}
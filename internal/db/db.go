@@ -184,8 +184,12 @@ func CheckIntegrity(target *gorm.DB) error {
 
 // SaveGlobalConfig helper to upsert config
 func SaveGlobalConfig(key string, value string) error {
+	stored, err := config.EncryptSetting(key, value)
+	if err != nil {
+		return err
+	}
 	var conf model.GlobalConfig
-	if err := DB.Where(model.GlobalConfig{Key: key}).Assign(model.GlobalConfig{Value: value}).FirstOrCreate(&conf).Error; err != nil {
+	if err := DB.Where(model.GlobalConfig{Key: key}).Assign(model.GlobalConfig{Value: stored}).FirstOrCreate(&conf).Error; err != nil {
 		return err
 	}
 	return config.UpdateSystemSettings(map[string]string{key: value})
@@ -202,9 +206,13 @@ func SyncGlobalConfigsWithConfigFile() error {
 	if len(config.AppConfig.SystemSettings) > 0 {
 		if err := DB.Transaction(func(tx *gorm.DB) error {
 			for key, value := range config.AppConfig.SystemSettings {
+				stored, err := config.EncryptSetting(key, value)
+				if err != nil {
+					return err
+				}
 				var conf model.GlobalConfig
 				if err := tx.Where(model.GlobalConfig{Key: key}).
-					Assign(model.GlobalConfig{Value: value}).
+					Assign(model.GlobalConfig{Value: stored}).
 					FirstOrCreate(&conf).Error; err != nil {
 					return err
 				}
@@ -219,6 +227,8 @@ func SyncGlobalConfigsWithConfigFile() error {
 
 // ExportGlobalConfigsToConfigFile replaces the YAML mirror with the current
 // database values. It is also called after restoring system settings.
+// Secrets are re-encrypted by the mirror writer; values this device cannot
+// decrypt are copied through as ciphertext.
 func ExportGlobalConfigsToConfigFile() error {
 	if config.AppConfig == nil || config.AppPaths.ConfigFile == "" {
 		return nil
@@ -229,11 +239,45 @@ func ExportGlobalConfigsToConfigFile() error {
 	}
 	values := make(map[string]string, len(configs))
 	for _, item := range configs {
-		values[item.Key] = item.Value
+		value, err := config.DecryptSetting(item.Key, item.Value)
+		if err != nil {
+			value = item.Value
+		}
+		values[item.Key] = value
 	}
 	return config.ReplaceSystemSettings(values)
 }
 
+// ResealSensitiveConfigs encrypts every sensitive setting with the current
+// settings key: plaintext rows from older versions are encrypted and rows
+// sealed with a previous key are re-encrypted. Rows no retained key can open
+// are left untouched and reported by key name.
+func ResealSensitiveConfigs(target *gorm.DB) (resealed int, unreadable []string, err error) {
+	var configs []model.GlobalConfig
+	if err := target.Find(&configs).Error; err != nil {
+		return 0, nil, err
+	}
+	for _, item := range configs {
+		if item.Value == "" || !config.IsSensitiveSettingKey(item.Key) {
+			continue
+		}
+		plaintext, err := config.DecryptSetting(item.Key, item.Value)
+		if err != nil {
+			unreadable = append(unreadable, item.Key)
+			continue
+		}
+		stored, err := config.EncryptSetting(item.Key, plaintext)
+		if err != nil {
+			return resealed, unreadable, err
+		}
+		if err := target.Model(&model.GlobalConfig{}).Where("key = ?", item.Key).Update("value", stored).Error; err != nil {
+			return resealed, unreadable, err
+		}
+		resealed++
+	}
+	return resealed, unreadable, nil
+}
+
 func isInMemoryDB(storagePath string) bool {
 	return storagePath == sqliteMemoryPath || strings.HasPrefix(storagePath, "file::memory:")
 }
@@ -163,6 +163,194 @@ var migrations = []migration{
 		Fingerprint: "c5ff054ac73a3cdb1e192b86c20fb9dd3fa4cc3c8bf4a6820724f2b4f3cde9f0",
 		Apply:       migrateLocalAnimeIdentity,
 	},
+	{
+		ID:          "016_download_log_task_ids",
+		Description: "Record downloader task ids for downloads that have no info hash",
+		Fingerprint: "e2d002093d377625757aefd717eebac76ee1d2a80031d703600af3d2927e75a6",
+		Apply: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&model.DownloadLog{})
+		},
+	},
+	{
+		ID:          "017_subscription_parser",
+		Description: "Let each subscription select its RSS parser",
+		Fingerprint: "3b41a0ad868551d49da65a0b0f4ffada4f49bffe98f37681fbcba43d221fb657",
+		Apply: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&model.Subscription{})
+		},
+	},
+	{
+		ID:          "018_subscription_check_interval",
+		Description: "Add a per-subscription minimum check interval",
+		Fingerprint: "7cd71e4a36aa4478a1c20d1e44beee3d4bfc2557a640651ca33ce90d7b5a88e4",
+		Apply: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasTable(&model.Subscription{}) {
+				return tx.AutoMigrate(&model.Subscription{})
+			}
+			if tx.Migrator().HasColumn(&model.Subscription{}, "CheckIntervalMinutes") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&model.Subscription{}, "CheckIntervalMinutes")
+		},
+	},
+	{
+		ID:          "019_subscription_run_schedule_reason",
+		Description: "Record why the scheduler ran or skipped a subscription check",
+		Fingerprint: "5ceb5031e24ddc3c7d12dd543cb50463be602ba651e8ab2a50051d4fc493864f",
+		Apply: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasTable(&model.SubscriptionRunLog{}) {
+				return tx.AutoMigrate(&model.SubscriptionRunLog{})
+			}
+			if tx.Migrator().HasColumn(&model.SubscriptionRunLog{}, "ScheduleReason") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&model.SubscriptionRunLog{}, "ScheduleReason")
+		},
+	},
+	{
+		ID:          "020_notifications",
+		Description: "Add notification channels and delivery history",
+		Fingerprint: "0ad8c36d0814f3d5390ce0f281889cec4979518ebc506b385d4a3b128a698fe8",
+		Apply: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&model.NotificationChannel{}, &model.NotificationDelivery{})
+		},
+	},
+	{
+		ID:          "021_local_subtitles",
+		Description: "Index sidecar subtitles for local episodes",
+		Fingerprint: "a873577725185ee082a0fe3ab36c5d9234ce7c2833d593b6bf8f2a65f438bbb7",
+		Apply: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&model.LocalSubtitle{})
+		},
+	},
+	{
+		ID:          "022_local_media_favorites",
+		Description: "Store per-user favorites for the local media provider",
+		Fingerprint: "b7dc41ba0e2783cbec85a5ce453e643585b655ae9c560855ccecaaacacd99d5c",
+		Apply: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&model.LocalMediaFavorite{})
+		},
+	},
+	{
+		ID:          "023_media_probes",
+		Description: "Cache ffprobe results by scan fingerprint and record episode durations",
+		Fingerprint: "32241ab1ff0c7b3be0b5c1c9637dcb59159abff9583d34b6827296d80c3c12bb",
+		Apply: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&model.MediaProbe{}); err != nil {
+				return err
+			}
+			// The core schema migration creates local_episodes with every column.
+			if !tx.Migrator().HasTable(&model.LocalEpisode{}) || tx.Migrator().HasColumn(&model.LocalEpisode{}, "DurationTicks") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&model.LocalEpisode{}, "DurationTicks")
+		},
+	},
+	{
+		ID:          "024_user_roles",
+		Description: "Add account roles and keep existing accounts as admins",
+		Fingerprint: "df556ab229236985a7a10849c48496a7edd61d4b5bb3ce55b22e311f9d079589",
+		Apply: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasTable(&model.User{}) {
+				return nil
+			}
+			if !tx.Migrator().HasColumn(&model.User{}, "Role") {
+				if err := tx.Migrator().AddColumn(&model.User{}, "Role"); err != nil {
+					return err
+				}
+			}
+			return tx.Model(&model.User{}).Unscoped().
+				Where("role IS NULL OR role = ''").
+				Update("role", model.UserRoleAdmin).Error
+		},
+	},
+	{
+		ID:          "025_api_tokens",
+		Description: "Add hashed personal API tokens",
+		Fingerprint: "ef98d79d4652bc2f9a4c616cbc9cda91f3e4180813cb856bb8a07eb134bdc06f",
+		Apply: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&model.APIToken{})
+		},
+	},
+	{
+		ID:          "026_user_totp",
+		Description: "Add TOTP two-factor columns and hashed recovery codes",
+		Fingerprint: "100cf15445e12fb478e48557951710edb477224f3f1c7987b2939d2a90e3a32f",
+		Apply: func(tx *gorm.DB) error {
+			if tx.Migrator().HasTable(&model.User{}) {
+				for _, column := range []string{"TOTPSecret", "TOTPPendingSecret", "TOTPEnabledAt", "TOTPLastStep"} {
+					if tx.Migrator().HasColumn(&model.User{}, column) {
+						continue
+					}
+					if err := tx.Migrator().AddColumn(&model.User{}, column); err != nil {
+						return err
+					}
+				}
+			}
+			return tx.AutoMigrate(&model.UserRecoveryCode{})
+		},
+	},
+	{
+		ID:          "027_encrypt_config_secrets",
+		Description: "Encrypt stored credentials with the local settings key",
+		Fingerprint: "0cf0656c89296a4e71e96efd1c2d252dd4a0d3608713c06650e5926503a0d636",
+		Apply: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasTable(&model.GlobalConfig{}) {
+				return nil
+			}
+			resealed, unreadable, err := ResealSensitiveConfigs(tx)
+			if err != nil {
+				return err
+			}
+			if resealed > 0 || len(unreadable) > 0 {
+				log.Printf("DatabaseMigration: encrypted stored credentials resealed=%d unreadable=%d", resealed, len(unreadable))
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "028_task_history",
+		Description: "Persist background task history with initiator and timing",
+		Fingerprint: "7b6b0e9ef2b5b3fbcee52f2990e67809a7b318579a85f6a46a48b3cdddd72112",
+		Apply: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&model.TaskRecord{})
+		},
+	},
+	{
+		ID:          "029_event_journal",
+		Description: "Persist SSE events in a bounded journal for Last-Event-ID replay",
+		Fingerprint: "021843ae6b672d014ca50f57b96c1cbcc5bacb7b46cbb0daaef694e14d5260f9",
+		Apply: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&model.EventRecord{})
+		},
+	},
+	{
+		ID:          "030_bangumi_collection_sync",
+		Description: "Record what the Bangumi collection sync did for each subject",
+		Fingerprint: "5d1c6a0f3e8b47a29c0d7e61b4f2a3958c7e0d16a2b9f4c3e8d71a05b6c2f947",
+		Apply: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&model.BangumiCollectionSync{})
+		},
+	},
+	{
+		ID:          "031_bangumi_sync_deletion_tombstones",
+		Description: "Allow Bangumi sync deletion records keyed only by Mikan ID",
+		Fingerprint: "e0c5b3fdf5e920756cbd360e8a54cf7a9f882e0eec5183d1a9917d97b60c70e1",
+		Apply: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasTable(&model.BangumiCollectionSync{}) {
+				return nil
+			}
+			if err := tx.Exec("DROP INDEX IF EXISTS idx_bangumi_collection_syncs_subject_id").Error; err != nil {
+				return err
+			}
+			if err := tx.Exec(
+				"CREATE UNIQUE INDEX idx_bangumi_collection_syncs_subject_id ON bangumi_collection_syncs(subject_id) WHERE subject_id != 0",
+			).Error; err != nil {
+				return err
+			}
+			return tx.AutoMigrate(&model.BangumiCollectionSync{})
+		},
+	},
 }
 
 const (
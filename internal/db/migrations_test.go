@@ -449,6 +449,45 @@ func TestRunMigrationsUpgradesLegacySubscriptionSchema(t *testing.T) {
 	}
 }
 
+func TestUserRoleMigrationKeepsExistingAccountsAdmin(t *testing.T) {
+	target, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "legacy.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite db: %v", err)
+	}
+	t.Cleanup(func() {
+		closeTestDB(t, target)
+	})
+
+	if err := target.Exec(`
+		CREATE TABLE users (
+			id integer primary key autoincrement,
+			created_at datetime,
+			updated_at datetime,
+			deleted_at datetime,
+			username text,
+			password_hash text,
+			memo text
+		)
+	`).Error; err != nil {
+		t.Fatalf("create legacy users table: %v", err)
+	}
+	if err := target.Exec(`INSERT INTO users (username, password_hash) VALUES ('owner', 'hash')`).Error; err != nil {
+		t.Fatalf("seed legacy user: %v", err)
+	}
+
+	if err := RunMigrations(target); err != nil {
+		t.Fatalf("run migrations on legacy schema: %v", err)
+	}
+
+	var user model.User
+	if err := target.Where("username = ?", "owner").First(&user).Error; err != nil {
+		t.Fatalf("load migrated user: %v", err)
+	}
+	if user.Role != model.UserRoleAdmin {
+		t.Fatalf("expected legacy account to become admin, got %q", user.Role)
+	}
+}
+
 func TestMikanIDMigrationBackfillsOnlyMissingOfficialRSSAssociations(t *testing.T) {
 	target, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "mikan-backfill.db")), &gorm.Config{})
 	if err != nil {
@@ -0,0 +1,349 @@
+package downloader
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/pokerjest/animateAutoTool/internal/httpx"
+)
+
+const (
+	aria2RPCPath = "/jsonrpc"
+	// aria2 keeps at most max-download-result stopped entries (1000 by
+	// default), so one page covers every task it still remembers.
+	aria2PageSize = 1000
+)
+
+// aria2 task statuses as reported by aria2.tellStatus. They are passed through
+// unchanged in TorrentInfo.State; the download log synchronizer understands
+// both these and the qBittorrent state names.
+const (
+	Aria2StatusActive   = "active"
+	Aria2StatusWaiting  = "waiting"
+	Aria2StatusPaused   = "paused"
+	Aria2StatusError    = "error"
+	Aria2StatusComplete = "complete"
+	Aria2StatusRemoved  = "removed"
+	// Aria2StatusSeeding is not an aria2 status. aria2 keeps finished
+	// BitTorrent tasks "active" while seeding; they are reported with this
+	// state so they are not mistaken for running downloads.
+	Aria2StatusSeeding = "seeding"
+)
+
+var aria2StatusKeys = []string{
+	"gid", "status", "totalLength", "completedLength", "downloadSpeed", "dir",
+	"files", "bittorrent", "infoHash", "followedBy", "following", "seeder",
+	"errorMessage",
+}
+
+// Aria2Client talks to aria2's JSON-RPC interface. It handles magnets,
+// .torrent uploads and plain HTTP(S) resources, so RSS items that link a media
+// file directly can be fetched as well.
+type Aria2Client struct {
+	client *resty.Client
+	rpcURL string
+	secret string
+	nextID atomic.Int64
+}
+
+type aria2Request struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      string        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type aria2Response struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type aria2Task struct {
+	GID             string   `json:"gid"`
+	Status          string   `json:"status"`
+	TotalLength     string   `json:"totalLength"`
+	CompletedLength string   `json:"completedLength"`
+	DownloadSpeed   string   `json:"downloadSpeed"`
+	Dir             string   `json:"dir"`
+	InfoHash        string   `json:"infoHash"`
+	FollowedBy      []string `json:"followedBy"`
+	Following       string   `json:"following"`
+	Seeder          string   `json:"seeder"`
+	ErrorMessage    string   `json:"errorMessage"`
+	Files           []struct {
+		Path   string `json:"path"`
+		Length string `json:"length"`
+	} `json:"files"`
+	BitTorrent *struct {
+		Info *struct {
+			Name string `json:"name"`
+		} `json:"info"`
+	} `json:"bittorrent"`
+}
+
+// NewAria2Client accepts either the RPC origin (http://host:6800) or the full
+// JSON-RPC URL. A bare origin is completed with /jsonrpc.
+func NewAria2Client(baseURL string) *Aria2Client {
+	client := httpx.NewRestyClient(10*time.Second, "", nil)
+	client.SetRetryCount(2).SetRetryWaitTime(2 * time.Second)
+	return &Aria2Client{client: client, rpcURL: aria2RPCURL(baseURL)}
+}
+
+func aria2RPCURL(raw string) string {
+	raw = strings.TrimRight(strings.TrimSpace(raw), "/")
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return raw + aria2RPCPath
+	}
+	if !strings.HasSuffix(parsed.Path, aria2RPCPath) {
+		parsed.Path = strings.TrimRight(parsed.Path, "/") + aria2RPCPath
+	}
+	return parsed.String()
+}
+
+func (a *Aria2Client) Login(username, password string) error {
+	return a.LoginContext(context.Background(), username, password)
+}
+
+// LoginContext stores the rpc-secret. aria2 has no user accounts, so the
+// username is ignored and the password field carries the secret token.
+func (a *Aria2Client) LoginContext(ctx context.Context, _ string, password string) error {
+	a.secret = strings.TrimSpace(password)
+	if err := a.PingContext(ctx); err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "unauthorized") {
+			return errors.New("login failed: invalid rpc secret")
+		}
+		return err
+	}
+	return nil
+}
+
+func (a *Aria2Client) AddTorrent(torrentURL, savePath, category string, paused bool) error {
+	return a.AddTorrentContext(context.Background(), torrentURL, savePath, category, paused)
+}
+
+// AddTorrentContext adds a magnet, a remote .torrent or a direct HTTP(S)
+// resource. aria2 follows .torrent downloads into the BitTorrent task itself.
+func (a *Aria2Client) AddTorrentContext(ctx context.Context, torrentURL, savePath, category string, paused bool) error {
+	_, err := a.AddTaskContext(ctx, torrentURL, savePath, category, paused)
+	return err
+}
+
+// AddTaskContext is AddTorrentContext returning the gid of the new task.
+func (a *Aria2Client) AddTaskContext(ctx context.Context, torrentURL, savePath, _ string, paused bool) (string, error) {
+	torrentURL = strings.TrimSpace(torrentURL)
+	if torrentURL == "" {
+		return "", errors.New("failed to add torrent: url is empty")
+	}
+	var gid string
+	if err := a.call(ctx, "aria2.addUri", []interface{}{[]string{torrentURL}, a.taskOptions(savePath, paused)}, &gid); err != nil {
+		return "", fmt.Errorf("failed to add torrent: %w", err)
+	}
+	return gid, nil
+}
+
+func (a *Aria2Client) AddTorrentFileContext(ctx context.Context, filename string, data []byte, savePath, category string, paused bool) error {
+	_, err := a.AddTaskFileContext(ctx, filename, data, savePath, category, paused)
+	return err
+}
+
+// AddTaskFileContext is AddTorrentFileContext returning the gid of the new
+// task.
+func (a *Aria2Client) AddTaskFileContext(ctx context.Context, filename string, data []byte, savePath, _ string, paused bool) (string, error) {
+	if strings.TrimSpace(filename) == "" {
+		return "", errors.New("failed to add torrent file: filename is empty")
+	}
+	if len(data) == 0 {
+		return "", errors.New("failed to add torrent file: file is empty")
+	}
+	var gid string
+	params := []interface{}{base64.StdEncoding.EncodeToString(data), []string{}, a.taskOptions(savePath, paused)}
+	if err := a.call(ctx, "aria2.addTorrent", params, &gid); err != nil {
+		return "", fmt.Errorf("failed to add torrent file: %w", err)
+	}
+	return gid, nil
+}
+
+// AcceptsDirectURL reports that aria2 can fetch plain HTTP(S) resources.
+func (a *Aria2Client) AcceptsDirectURL() bool {
+	return true
+}
+
+func (a *Aria2Client) taskOptions(savePath string, paused bool) map[string]string {
+	options := map[string]string{}
+	if value := strings.TrimSpace(savePath); value != "" {
+		options["dir"] = value
+	}
+	if paused {
+		options["pause"] = "true"
+	}
+	return options
+}
+
+func (a *Aria2Client) Ping() error {
+	return a.PingContext(context.Background())
+}
+
+func (a *Aria2Client) PingContext(ctx context.Context) error {
+	_, err := a.GetVersionContext(ctx)
+	return err
+}
+
+func (a *Aria2Client) GetVersion() (string, error) {
+	return a.GetVersionContext(context.Background())
+}
+
+func (a *Aria2Client) GetVersionContext(ctx context.Context) (string, error) {
+	var result struct {
+		Version string `json:"version"`
+	}
+	if err := a.call(ctx, "aria2.getVersion", nil, &result); err != nil {
+		return "", fmt.Errorf("ping failed: %w", err)
+	}
+	return strings.TrimSpace(result.Version), nil
+}
+
+func (a *Aria2Client) ListTorrents() ([]TorrentInfo, error) {
+	return a.ListTorrentsContext(context.Background())
+}
+
+// ListTorrentsContext merges active, waiting and stopped tasks. Metadata
+// tasks (magnet resolution or a fetched .torrent) that were followed by the
+// real download are dropped so every download appears exactly once.
+func (a *Aria2Client) ListTorrentsContext(ctx context.Context) ([]TorrentInfo, error) {
+	var tasks []aria2Task
+	calls := []struct {
+		method string
+		params []interface{}
+	}{
+		{"aria2.tellActive", []interface{}{aria2StatusKeys}},
+		{"aria2.tellWaiting", []interface{}{0, aria2PageSize, aria2StatusKeys}},
+		{"aria2.tellStopped", []interface{}{0, aria2PageSize, aria2StatusKeys}},
+	}
+	for _, call := range calls {
+		var page []aria2Task
+		if err := a.call(ctx, call.method, call.params, &page); err != nil {
+			return nil, fmt.Errorf("list torrents failed: %w", err)
+		}
+		tasks = append(tasks, page...)
+	}
+
+	torrents := make([]TorrentInfo, 0, len(tasks))
+	for _, task := range tasks {
+		if len(task.FollowedBy) > 0 {
+			continue
+		}
+		torrents = append(torrents, task.torrentInfo())
+	}
+	return torrents, nil
+}
+
+// RenameFile is not available over aria2 RPC.
+func (a *Aria2Client) RenameFile(_, _, _ string) error {
+	return fmt.Errorf("aria2 rename file: %w", ErrUnsupportedOperation)
+}
+
+// SetLocation is not available over aria2 RPC; aria2 can only change the
+// directory of tasks that have not started writing yet.
+func (a *Aria2Client) SetLocation(_, _ string) error {
+	return fmt.Errorf("aria2 set location: %w", ErrUnsupportedOperation)
+}
+
+func (a *Aria2Client) call(ctx context.Context, method string, params []interface{}, out interface{}) error {
+	if a.secret != "" {
+		params = append([]interface{}{"token:" + a.secret}, params...)
+	}
+	if params == nil {
+		params = []interface{}{}
+	}
+	request := aria2Request{
+		JSONRPC: "2.0",
+		ID:      strconv.FormatInt(a.nextID.Add(1), 10),
+		Method:  method,
+		Params:  params,
+	}
+	resp, err := httpx.NewRequest(ctx, a.client).
+		SetHeader("Content-Type", "application/json").
+		SetBody(request).
+		Post(a.rpcURL)
+	if err != nil {
+		return err
+	}
+
+	var decoded aria2Response
+	if jsonErr := json.Unmarshal(resp.Body(), &decoded); jsonErr != nil {
+		if resp.StatusCode() != http.StatusOK {
+			return fmt.Errorf("unexpected status %s, body: %s", resp.Status(), strings.TrimSpace(resp.String()))
+		}
+		return fmt.Errorf("decode %s response: %w", method, jsonErr)
+	}
+	if decoded.Error != nil {
+		return fmt.Errorf("%s: %s (code %d)", method, decoded.Error.Message, decoded.Error.Code)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status())
+	}
+	if out == nil || len(decoded.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(decoded.Result, out); err != nil {
+		return fmt.Errorf("decode %s result: %w", method, err)
+	}
+	return nil
+}
+
+func (task aria2Task) torrentInfo() TorrentInfo {
+	total, _ := strconv.ParseInt(task.TotalLength, 10, 64)
+	completed, _ := strconv.ParseInt(task.CompletedLength, 10, 64)
+	speed, _ := strconv.ParseInt(task.DownloadSpeed, 10, 64)
+
+	info := TorrentInfo{
+		// BitTorrent tasks are identified by info hash, matching magnets and
+		// qBittorrent. Plain HTTP tasks only carry their gid in TaskID.
+		Hash:          strings.ToLower(strings.TrimSpace(task.InfoHash)),
+		State:         task.Status,
+		SavePath:      task.Dir,
+		Size:          total,
+		Completed:     completed,
+		DownloadSpeed: speed,
+		TaskID:        strings.TrimSpace(task.GID),
+	}
+	// Magnets and remote .torrent links first create a metadata task that is
+	// followed by the real download. Report the gid returned by addUri so the
+	// caller's recorded id keeps matching.
+	if following := strings.TrimSpace(task.Following); following != "" {
+		info.TaskID = following
+	}
+	if task.Status == Aria2StatusActive && task.Seeder == "true" {
+		info.State = Aria2StatusSeeding
+	}
+	if total > 0 {
+		info.Progress = float64(completed) / float64(total)
+	}
+
+	if task.BitTorrent != nil && task.BitTorrent.Info != nil && strings.TrimSpace(task.BitTorrent.Info.Name) != "" {
+		info.Name = task.BitTorrent.Info.Name
+		if task.Dir != "" {
+			info.ContentPath = joinDownloaderPath(task.Dir, info.Name)
+		}
+	} else if len(task.Files) > 0 && strings.TrimSpace(task.Files[0].Path) != "" {
+		info.ContentPath = task.Files[0].Path
+		info.Name = path.Base(filepath.ToSlash(task.Files[0].Path))
+	}
+	return info
+}
@@ -0,0 +1,188 @@
+package downloader
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const aria2TestSecret = "rpc-secret"
+
+type fakeAria2 struct {
+	t        *testing.T
+	mu       sync.Mutex
+	requests []aria2Request
+	handlers map[string]func(params []interface{}) interface{}
+}
+
+func newFakeAria2(t *testing.T) (*fakeAria2, *httptest.Server) {
+	t.Helper()
+	fake := &fakeAria2{t: t, handlers: map[string]func([]interface{}) interface{}{}}
+	server := httptest.NewServer(http.HandlerFunc(fake.serve))
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeAria2) serve(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != aria2RPCPath {
+		f.t.Errorf("unexpected path: %s", r.URL.Path)
+		http.NotFound(w, r)
+		return
+	}
+	var req aria2Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		f.t.Errorf("decode rpc request: %v", err)
+		return
+	}
+	if len(req.Params) == 0 || req.Params[0] != "token:"+aria2TestSecret {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0", "id": req.ID,
+			"error": map[string]interface{}{"code": 1, "message": "Unauthorized"},
+		})
+		return
+	}
+	req.Params = req.Params[1:]
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	handler := f.handlers[req.Method]
+	f.mu.Unlock()
+	var result interface{} = "OK"
+	if handler != nil {
+		result = handler(req.Params)
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+}
+
+func (f *fakeAria2) lastParams(method string) []interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := len(f.requests) - 1; i >= 0; i-- {
+		if f.requests[i].Method == method {
+			return f.requests[i].Params
+		}
+	}
+	f.t.Fatalf("no %s request recorded", method)
+	return nil
+}
+
+func TestAria2ClientLoginAddAndList(t *testing.T) {
+	t.Parallel()
+
+	fake, server := newFakeAria2(t)
+	fake.handlers["aria2.getVersion"] = func([]interface{}) interface{} {
+		return map[string]interface{}{"version": "1.37.0"}
+	}
+	fake.handlers["aria2.addUri"] = func([]interface{}) interface{} { return "2089b05ecca3d829" }
+	fake.handlers["aria2.tellActive"] = func([]interface{}) interface{} {
+		return []map[string]interface{}{{
+			"gid": "a1", "status": "active", "totalLength": "200", "completedLength": "50",
+			"downloadSpeed": "10", "dir": "/downloads", "infoHash": "ABCDEF", "following": "meta",
+			"bittorrent": map[string]interface{}{"info": map[string]interface{}{"name": "[Group] Show - 01.mkv"}},
+		}, {
+			"gid": "s1", "status": "active", "totalLength": "100", "completedLength": "100",
+			"dir": "/downloads", "infoHash": "123456", "seeder": "true",
+		}}
+	}
+	fake.handlers["aria2.tellWaiting"] = func([]interface{}) interface{} { return []map[string]interface{}{} }
+	fake.handlers["aria2.tellStopped"] = func([]interface{}) interface{} {
+		return []map[string]interface{}{
+			{"gid": "meta", "status": "complete", "infoHash": "abcdef", "followedBy": []string{"a1"}},
+			{
+				"gid": "h1", "status": "complete", "totalLength": "100", "completedLength": "100", "dir": "/downloads",
+				"files": []map[string]interface{}{{"path": "/downloads/Show - 02.mp4", "length": "100"}},
+			},
+		}
+	}
+
+	client := NewAria2Client(server.URL)
+	if err := client.Login("ignored", aria2TestSecret); err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if version, err := client.GetVersion(); err != nil || version != "1.37.0" {
+		t.Fatalf("unexpected version %q err=%v", version, err)
+	}
+	gid, err := client.AddTaskContext(context.Background(), "magnet:?xt=urn:btih:abcdef", "/downloads/anime", "anime", true)
+	if err != nil || gid != "2089b05ecca3d829" {
+		t.Fatalf("add torrent failed: gid=%q err=%v", gid, err)
+	}
+	params := fake.lastParams("aria2.addUri")
+	if uris, _ := params[0].([]interface{}); len(uris) != 1 || uris[0] != "magnet:?xt=urn:btih:abcdef" {
+		t.Fatalf("unexpected uris: %+v", params)
+	}
+	if options, _ := params[1].(map[string]interface{}); options["dir"] != "/downloads/anime" || options["pause"] != "true" {
+		t.Fatalf("unexpected add options: %+v", params[1])
+	}
+
+	torrents, err := client.ListTorrents()
+	if err != nil {
+		t.Fatalf("list torrents failed: %v", err)
+	}
+	if len(torrents) != 3 {
+		t.Fatalf("expected metadata task to be skipped, got %+v", torrents)
+	}
+	if got := torrents[0]; got.Hash != "abcdef" || got.TaskID != "meta" || got.State != Aria2StatusActive || got.Progress != 0.25 ||
+		got.ContentPath != "/downloads/[Group] Show - 01.mkv" || got.DownloadSpeed != 10 {
+		t.Fatalf("unexpected bittorrent task mapping: %+v", got)
+	}
+	if got := torrents[1]; got.State != Aria2StatusSeeding || got.TaskID != "s1" {
+		t.Fatalf("expected seeding task to be reported as seeding: %+v", got)
+	}
+	if got := torrents[2]; got.Hash != "" || got.TaskID != "h1" || got.Name != "Show - 02.mp4" || got.ContentPath != "/downloads/Show - 02.mp4" || !got.Released() {
+		t.Fatalf("unexpected http task mapping: %+v", got)
+	}
+}
+
+func TestAria2ClientUploadsTorrentFile(t *testing.T) {
+	t.Parallel()
+
+	fake, server := newFakeAria2(t)
+	client := NewAria2Client(server.URL + "/jsonrpc")
+	client.secret = aria2TestSecret
+	data := []byte("d4:infod4:name12:episode.mkvee")
+	if err := client.AddTorrentFileContext(context.Background(), "episode.torrent", data, "", "", false); err != nil {
+		t.Fatalf("upload torrent file: %v", err)
+	}
+	params := fake.lastParams("aria2.addTorrent")
+	if params[0] != base64.StdEncoding.EncodeToString(data) {
+		t.Fatalf("unexpected torrent payload: %+v", params[0])
+	}
+	if options, _ := params[2].(map[string]interface{}); len(options) != 0 {
+		t.Fatalf("empty save path should not send options: %+v", options)
+	}
+}
+
+func TestAria2ClientRejectsInvalidSecret(t *testing.T) {
+	t.Parallel()
+
+	_, server := newFakeAria2(t)
+	client := NewAria2Client(server.URL)
+	err := client.Login("", "wrong")
+	if err == nil || !strings.Contains(err.Error(), "invalid rpc secret") {
+		t.Fatalf("expected invalid secret error, got %v", err)
+	}
+}
+
+func TestAria2ClientReportsUnsupportedRenameAndMove(t *testing.T) {
+	t.Parallel()
+
+	client := NewAria2Client("http://aria2:6800")
+	if err := client.RenameFile("abc", "old.mkv", "new.mkv"); !errors.Is(err, ErrUnsupportedOperation) {
+		t.Fatalf("expected unsupported rename, got %v", err)
+	}
+	if err := client.SetLocation("abc", "/media"); !errors.Is(err, ErrUnsupportedOperation) {
+		t.Fatalf("expected unsupported move, got %v", err)
+	}
+	if _, ok := NewClient("aria2c", "http://aria2:6800").(*Aria2Client); !ok {
+		t.Fatal("expected aria2 backend")
+	}
+	if got := aria2RPCURL("http://aria2:6800/"); got != "http://aria2:6800/jsonrpc" {
+		t.Fatalf("unexpected rpc url: %q", got)
+	}
+}
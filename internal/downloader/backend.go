@@ -0,0 +1,64 @@
+package downloader
+
+import "strings"
+
+const (
+	BackendQBittorrent  = "qbittorrent"
+	BackendTransmission = "transmission"
+	BackendAria2        = "aria2"
+)
+
+var (
+	_ Client = (*QBittorrentClient)(nil)
+	_ Client = (*TransmissionClient)(nil)
+	_ Client = (*Aria2Client)(nil)
+
+	_ TaskAdder = (*Aria2Client)(nil)
+)
+
+// NormalizeBackend maps user input onto a supported backend name. Unknown or
+// empty values fall back to qBittorrent, which remains the default.
+func NormalizeBackend(raw string) string {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case BackendTransmission, "tr":
+		return BackendTransmission
+	case BackendAria2, "aria2c":
+		return BackendAria2
+	default:
+		return BackendQBittorrent
+	}
+}
+
+// IsKnownBackend reports whether raw names a backend that NewClient can build.
+func IsKnownBackend(raw string) bool {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", BackendQBittorrent, BackendTransmission, "tr", BackendAria2, "aria2c":
+		return true
+	default:
+		return false
+	}
+}
+
+// NewClient builds the downloader client for backend at baseURL.
+func NewClient(backend, baseURL string) Client {
+	switch NormalizeBackend(backend) {
+	case BackendTransmission:
+		return NewTransmissionClient(baseURL)
+	case BackendAria2:
+		return NewAria2Client(baseURL)
+	default:
+		return NewQBittorrentClient(baseURL)
+	}
+}
+
+// Released reports whether the backend has let go of the task's files, so
+// they can be moved directly on disk. Only aria2 reports such terminal
+// states; qBittorrent and Transmission keep every listed task attached.
+func (t TorrentInfo) Released() bool {
+	switch t.State {
+	case Aria2StatusComplete, Aria2StatusRemoved:
+		return true
+	default:
+		return false
+	}
+}
@@ -1,6 +1,13 @@
 package downloader
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// ErrUnsupportedOperation is returned by backends that cannot perform an
+// optional operation such as renaming files inside a task.
+var ErrUnsupportedOperation = errors.New("operation not supported by this downloader")
 
 // Downloader 定义下载器通用接口
 type Downloader interface {
@@ -39,3 +46,31 @@ type TorrentLister interface {
 type ContextTorrentLister interface {
 	ListTorrentsContext(ctx context.Context) ([]TorrentInfo, error)
 }
+
+// DirectURLDownloader is implemented by backends that fetch plain HTTP(S)
+// resources themselves. Callers hand them such links unchanged instead of
+// downloading a .torrent first.
+type DirectURLDownloader interface {
+	AcceptsDirectURL() bool
+}
+
+// TaskAdder is implemented by backends that return their own task id when a
+// task is added. Plain HTTP downloads have no info hash, so callers record the
+// id to find the task again in TorrentInfo.TaskID.
+type TaskAdder interface {
+	AddTaskContext(ctx context.Context, url, savePath, category string, paused bool) (string, error)
+	AddTaskFileContext(ctx context.Context, filename string, data []byte, savePath, category string, paused bool) (string, error)
+}
+
+// Client is the full capability set used by the scheduler, the download log
+// worker and the local organizer. Every selectable backend implements it.
+type Client interface {
+	Downloader
+	ContextDownloader
+	TorrentFileDownloader
+	TorrentLister
+	ContextTorrentLister
+	GetVersion() (string, error)
+	RenameFile(hash, oldPath, newPath string) error
+	SetLocation(hash, location string) error
+}
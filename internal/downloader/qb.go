@@ -24,6 +24,8 @@ type QBittorrentClient struct {
 
 var ErrTorrentRejected = errors.New("qBittorrent rejected the torrent (Fails.)")
 
+// TorrentInfo is one downloader task in qBittorrent's vocabulary. Hash is the
+// BitTorrent info hash; aria2 HTTP tasks have none and report their gid.
 type TorrentInfo struct {
 	Hash          string  `json:"hash"`
 	Name          string  `json:"name"`
@@ -34,6 +36,10 @@ type TorrentInfo struct {
 	Size          int64   `json:"size"`
 	Completed     int64   `json:"completed"`
 	DownloadSpeed int64   `json:"dlspeed"`
+	// TaskID is the backend's own task identifier for backends that do not
+	// key every task by info hash (the aria2 gid). It matches the id returned
+	// by TaskAdder and is empty for qBittorrent and Transmission.
+	TaskID string `json:"task_id,omitempty"`
 }
 
 func NewQBittorrentClient(baseURL string) *QBittorrentClient {
@@ -317,3 +323,54 @@ func (q *QBittorrentClient) RenameFileContext(ctx context.Context, hash, oldPath
 	}
 	return nil
 }
+
+// RSSAutoDownloadRule is one rule of qBittorrent's RSS auto-downloader as
+// returned by /api/v2/rss/rules. qBittorrent 4.6 moved the save path and
+// category into torrentParams; both layouts are accepted.
+type RSSAutoDownloadRule struct {
+	Enabled          bool     `json:"enabled"`
+	MustContain      string   `json:"mustContain"`
+	MustNotContain   string   `json:"mustNotContain"`
+	UseRegex         bool     `json:"useRegex"`
+	EpisodeFilter    string   `json:"episodeFilter"`
+	SmartFilter      bool     `json:"smartFilter"`
+	AffectedFeeds    []string `json:"affectedFeeds"`
+	SavePath         string   `json:"savePath"`
+	AssignedCategory string   `json:"assignedCategory"`
+	TorrentParams    struct {
+		SavePath string `json:"save_path"`
+		Category string `json:"category"`
+	} `json:"torrentParams"`
+}
+
+// RuleSavePath returns the save path from whichever layout the rule uses.
+func (r RSSAutoDownloadRule) RuleSavePath() string {
+	if path := strings.TrimSpace(r.TorrentParams.SavePath); path != "" {
+		return path
+	}
+	return strings.TrimSpace(r.SavePath)
+}
+
+// ListRSSRulesContext returns qBittorrent's RSS auto-download rules keyed by
+// rule name.
+func (q *QBittorrentClient) ListRSSRulesContext(ctx context.Context) (map[string]RSSAutoDownloadRule, error) {
+	req := httpx.NewRequest(ctx, q.client).
+		SetResult(&map[string]RSSAutoDownloadRule{})
+	if len(q.cookies) > 0 {
+		req.SetCookies(q.cookies)
+	}
+
+	resp, err := req.Get("/api/v2/rss/rules")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("list RSS rules failed: %s, body: %s", resp.Status(), resp.String())
+	}
+
+	result, ok := resp.Result().(*map[string]RSSAutoDownloadRule)
+	if !ok || result == nil {
+		return nil, fmt.Errorf("list RSS rules failed: unexpected response payload")
+	}
+	return *result, nil
+}
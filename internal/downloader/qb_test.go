@@ -136,6 +136,36 @@ func TestQBittorrentClientSetLocation(t *testing.T) {
 	}
 }
 
+func TestQBittorrentClientListsRSSRulesInBothLayouts(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/rss/rules" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"Old": {"enabled": true, "mustContain": "1080p", "affectedFeeds": ["https://example.test/old"], "savePath": "/downloads/old"},
+			"New": {"enabled": false, "useRegex": true, "affectedFeeds": [], "savePath": "", "torrentParams": {"save_path": "/downloads/new", "category": "anime"}}
+		}`))
+	}))
+	defer server.Close()
+
+	rules, err := NewQBittorrentClient(server.URL).ListRSSRulesContext(context.Background())
+	if err != nil {
+		t.Fatalf("list RSS rules failed: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("expected two rules, got %+v", rules)
+	}
+	if rule := rules["Old"]; !rule.Enabled || rule.MustContain != "1080p" || rule.RuleSavePath() != "/downloads/old" || len(rule.AffectedFeeds) != 1 {
+		t.Fatalf("unexpected legacy rule: %+v", rule)
+	}
+	if rule := rules["New"]; rule.Enabled || !rule.UseRegex || rule.RuleSavePath() != "/downloads/new" || rule.TorrentParams.Category != "anime" {
+		t.Fatalf("unexpected torrentParams rule: %+v", rule)
+	}
+}
+
 func TestQBittorrentClientLoginFailure(t *testing.T) {
 	t.Parallel()
 
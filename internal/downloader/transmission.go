@@ -0,0 +1,397 @@
+package downloader
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/pokerjest/animateAutoTool/internal/httpx"
+)
+
+const (
+	transmissionRPCPath         = "/transmission/rpc"
+	transmissionSessionIDHeader = "X-Transmission-Session-Id"
+)
+
+// Transmission torrent status codes as reported by torrent-get.
+const (
+	transmissionStatusStopped      = 0
+	transmissionStatusCheckWait    = 1
+	transmissionStatusCheck        = 2
+	transmissionStatusDownloadWait = 3
+	transmissionStatusDownload     = 4
+	transmissionStatusSeedWait     = 5
+	transmissionStatusSeed         = 6
+)
+
+// transmissionErrorLocal is the torrent-get error code for local failures.
+// Codes 1 and 2 are tracker warnings and errors, which do not stop the
+// transfer and therefore must not mark the torrent as failed.
+const transmissionErrorLocal = 3
+
+var transmissionTorrentFields = []string{
+	"hashString", "name", "status", "error", "errorString", "percentDone",
+	"totalSize", "sizeWhenDone", "haveValid", "downloadDir", "rateDownload",
+}
+
+// TransmissionClient talks to the Transmission RPC endpoint. Torrent states are
+// translated into qBittorrent state names so the status synchronizer, the
+// automatic renamer and the local organizer can stay backend agnostic.
+type TransmissionClient struct {
+	client   *resty.Client
+	rpcURL   string
+	username string
+	password string
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+type transmissionRequest struct {
+	Method    string      `json:"method"`
+	Arguments interface{} `json:"arguments,omitempty"`
+}
+
+type transmissionResponse struct {
+	Result    string          `json:"result"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type transmissionTorrent struct {
+	HashString   string  `json:"hashString"`
+	Name         string  `json:"name"`
+	Status       int     `json:"status"`
+	Error        int     `json:"error"`
+	ErrorString  string  `json:"errorString"`
+	PercentDone  float64 `json:"percentDone"`
+	TotalSize    int64   `json:"totalSize"`
+	SizeWhenDone int64   `json:"sizeWhenDone"`
+	HaveValid    int64   `json:"haveValid"`
+	DownloadDir  string  `json:"downloadDir"`
+	RateDownload int64   `json:"rateDownload"`
+}
+
+// NewTransmissionClient accepts either the WebUI origin (http://nas:9091) or
+// the full RPC URL. A bare origin is completed with /transmission/rpc.
+func NewTransmissionClient(baseURL string) *TransmissionClient {
+	client := httpx.NewRestyClient(10*time.Second, "", nil)
+	client.SetRetryCount(2).SetRetryWaitTime(2 * time.Second)
+	return &TransmissionClient{
+		client: client,
+		rpcURL: transmissionRPCURL(baseURL),
+	}
+}
+
+func transmissionRPCURL(raw string) string {
+	raw = strings.TrimRight(strings.TrimSpace(raw), "/")
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return raw + transmissionRPCPath
+	}
+	if !strings.HasSuffix(parsed.Path, "/rpc") {
+		parsed.Path = strings.TrimRight(parsed.Path, "/") + transmissionRPCPath
+	}
+	return parsed.String()
+}
+
+func (t *TransmissionClient) Login(username, password string) error {
+	return t.LoginContext(context.Background(), username, password)
+}
+
+// LoginContext stores the RPC credentials and performs the session-id
+// handshake. Transmission has no login endpoint; a rejected basic auth header
+// surfaces as 401 on the first call.
+func (t *TransmissionClient) LoginContext(ctx context.Context, username, password string) error {
+	t.username = strings.TrimSpace(username)
+	t.password = password
+	if err := t.PingContext(ctx); err != nil {
+		if errors.Is(err, errTransmissionUnauthorized) {
+			return errors.New("login failed: invalid credentials")
+		}
+		return err
+	}
+	return nil
+}
+
+func (t *TransmissionClient) AddTorrent(torrentURL, savePath, category string, paused bool) error {
+	return t.AddTorrentContext(context.Background(), torrentURL, savePath, category, paused)
+}
+
+func (t *TransmissionClient) AddTorrentContext(ctx context.Context, torrentURL, savePath, category string, paused bool) error {
+	if strings.TrimSpace(torrentURL) == "" {
+		return errors.New("failed to add torrent: url is empty")
+	}
+	args := t.torrentOptions(savePath, category, paused)
+	args["filename"] = strings.TrimSpace(torrentURL)
+	return t.addTorrent(ctx, args)
+}
+
+func (t *TransmissionClient) AddTorrentFileContext(ctx context.Context, filename string, data []byte, savePath, category string, paused bool) error {
+	if strings.TrimSpace(filename) == "" {
+		return errors.New("failed to add torrent file: filename is empty")
+	}
+	if len(data) == 0 {
+		return errors.New("failed to add torrent file: file is empty")
+	}
+	args := t.torrentOptions(savePath, category, paused)
+	args["metainfo"] = base64.StdEncoding.EncodeToString(data)
+	return t.addTorrent(ctx, args)
+}
+
+func (t *TransmissionClient) torrentOptions(savePath, category string, paused bool) map[string]interface{} {
+	args := map[string]interface{}{"paused": paused}
+	if value := strings.TrimSpace(savePath); value != "" {
+		args["download-dir"] = value
+	}
+	if value := strings.TrimSpace(category); value != "" {
+		args["labels"] = []string{value}
+	}
+	return args
+}
+
+func (t *TransmissionClient) addTorrent(ctx context.Context, args map[string]interface{}) error {
+	var result struct {
+		Added     *transmissionTorrent `json:"torrent-added"`
+		Duplicate *transmissionTorrent `json:"torrent-duplicate"`
+	}
+	if err := t.call(ctx, "torrent-add", args, &result); err != nil {
+		return fmt.Errorf("failed to add torrent: %w", err)
+	}
+	// A duplicate is the same outcome qBittorrent reports as an idempotent
+	// add: the task already exists and reconciliation will pick it up.
+	if result.Added == nil && result.Duplicate == nil {
+		return errors.New("failed to add torrent: transmission returned no torrent")
+	}
+	return nil
+}
+
+func (t *TransmissionClient) Ping() error {
+	return t.PingContext(context.Background())
+}
+
+func (t *TransmissionClient) PingContext(ctx context.Context) error {
+	_, err := t.GetVersionContext(ctx)
+	return err
+}
+
+func (t *TransmissionClient) GetVersion() (string, error) {
+	return t.GetVersionContext(context.Background())
+}
+
+func (t *TransmissionClient) GetVersionContext(ctx context.Context) (string, error) {
+	var session struct {
+		Version string `json:"version"`
+	}
+	if err := t.call(ctx, "session-get", map[string]interface{}{"fields": []string{"version"}}, &session); err != nil {
+		return "", fmt.Errorf("ping failed: %w", err)
+	}
+	return strings.TrimSpace(session.Version), nil
+}
+
+func (t *TransmissionClient) ListTorrents() ([]TorrentInfo, error) {
+	return t.ListTorrentsContext(context.Background())
+}
+
+func (t *TransmissionClient) ListTorrentsContext(ctx context.Context) ([]TorrentInfo, error) {
+	var result struct {
+		Torrents []transmissionTorrent `json:"torrents"`
+	}
+	if err := t.call(ctx, "torrent-get", map[string]interface{}{"fields": transmissionTorrentFields}, &result); err != nil {
+		return nil, fmt.Errorf("list torrents failed: %w", err)
+	}
+	torrents := make([]TorrentInfo, 0, len(result.Torrents))
+	for _, item := range result.Torrents {
+		torrents = append(torrents, item.torrentInfo())
+	}
+	return torrents, nil
+}
+
+func (t *TransmissionClient) RenameFile(hash, oldPath, newPath string) error {
+	return t.RenameFileContext(context.Background(), hash, oldPath, newPath)
+}
+
+// RenameFileContext maps qBittorrent's renameFile onto torrent-rename-path.
+// Transmission only renames the last path component, so a rename that would
+// move the file into another directory inside the torrent is rejected.
+func (t *TransmissionClient) RenameFileContext(ctx context.Context, hash, oldPath, newPath string) error {
+	if strings.TrimSpace(hash) == "" {
+		return errors.New("rename file failed: missing torrent hash")
+	}
+	oldPath = path.Clean(filepath.ToSlash(strings.TrimSpace(oldPath)))
+	newPath = path.Clean(filepath.ToSlash(strings.TrimSpace(newPath)))
+	if oldPath == "." || newPath == "." {
+		return errors.New("rename file failed: missing old or new path")
+	}
+	if path.Dir(oldPath) != path.Dir(newPath) {
+		return fmt.Errorf("rename file failed: transmission cannot move %q to another directory", oldPath)
+	}
+	args := map[string]interface{}{
+		"ids":  []string{strings.TrimSpace(hash)},
+		"path": oldPath,
+		"name": path.Base(newPath),
+	}
+	if err := t.call(ctx, "torrent-rename-path", args, nil); err != nil {
+		return fmt.Errorf("rename file failed: %w", err)
+	}
+	return nil
+}
+
+// SetLocation moves the torrent data through Transmission so seeding continues
+// from the new directory.
+func (t *TransmissionClient) SetLocation(hash, location string) error {
+	if strings.TrimSpace(hash) == "" {
+		return errors.New("set location failed: missing torrent hash")
+	}
+	if strings.TrimSpace(location) == "" {
+		return errors.New("set location failed: missing destination")
+	}
+	args := map[string]interface{}{
+		"ids":      []string{strings.TrimSpace(hash)},
+		"location": strings.TrimSpace(location),
+		"move":     true,
+	}
+	if err := t.call(context.Background(), "torrent-set-location", args, nil); err != nil {
+		return fmt.Errorf("set location failed: %w", err)
+	}
+	return nil
+}
+
+var errTransmissionUnauthorized = errors.New("transmission rejected the RPC credentials")
+
+// call performs one RPC request, repeating it once when Transmission answers
+// 409 with a fresh CSRF session id.
+func (t *TransmissionClient) call(ctx context.Context, method string, args interface{}, out interface{}) error {
+	body, err := json.Marshal(transmissionRequest{Method: method, Arguments: args})
+	if err != nil {
+		return err
+	}
+	for attempt := 0; attempt < 2; attempt++ {
+		req := httpx.NewRequest(ctx, t.client).
+			SetHeader("Content-Type", "application/json").
+			SetBody(body)
+		if t.username != "" || t.password != "" {
+			req.SetBasicAuth(t.username, t.password)
+		}
+		if sessionID := t.currentSessionID(); sessionID != "" {
+			req.SetHeader(transmissionSessionIDHeader, sessionID)
+		}
+		resp, err := req.Post(t.rpcURL)
+		if err != nil {
+			return err
+		}
+		switch resp.StatusCode() {
+		case http.StatusConflict:
+			sessionID := strings.TrimSpace(resp.Header().Get(transmissionSessionIDHeader))
+			if sessionID == "" {
+				return errors.New("transmission returned 409 without a session id")
+			}
+			t.setSessionID(sessionID)
+			continue
+		case http.StatusUnauthorized:
+			return errTransmissionUnauthorized
+		case http.StatusOK:
+		default:
+			return fmt.Errorf("unexpected status %s, body: %s", resp.Status(), strings.TrimSpace(resp.String()))
+		}
+
+		var decoded transmissionResponse
+		if err := json.Unmarshal(resp.Body(), &decoded); err != nil {
+			return fmt.Errorf("decode %s response: %w", method, err)
+		}
+		if decoded.Result != "success" {
+			return fmt.Errorf("%s: %s", method, decoded.Result)
+		}
+		if out == nil || len(decoded.Arguments) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(decoded.Arguments, out); err != nil {
+			return fmt.Errorf("decode %s arguments: %w", method, err)
+		}
+		return nil
+	}
+	return errors.New("transmission session id handshake did not converge")
+}
+
+func (t *TransmissionClient) currentSessionID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessionID
+}
+
+func (t *TransmissionClient) setSessionID(value string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sessionID = value
+}
+
+func (item transmissionTorrent) torrentInfo() TorrentInfo {
+	size := item.SizeWhenDone
+	if size <= 0 {
+		size = item.TotalSize
+	}
+	info := TorrentInfo{
+		Hash:          strings.ToLower(strings.TrimSpace(item.HashString)),
+		Name:          item.Name,
+		State:         item.qbState(),
+		SavePath:      item.DownloadDir,
+		Progress:      item.PercentDone,
+		Size:          size,
+		Completed:     item.HaveValid,
+		DownloadSpeed: item.RateDownload,
+	}
+	if item.DownloadDir != "" && item.Name != "" {
+		info.ContentPath = joinDownloaderPath(item.DownloadDir, item.Name)
+	}
+	return info
+}
+
+// qbState translates Transmission's numeric status into the qBittorrent state
+// vocabulary understood by the download log synchronizer.
+func (item transmissionTorrent) qbState() string {
+	done := item.PercentDone >= 1
+	if item.Error == transmissionErrorLocal {
+		return "error"
+	}
+	switch item.Status {
+	case transmissionStatusStopped:
+		if done {
+			return "pausedUP"
+		}
+		return "pausedDL"
+	case transmissionStatusCheckWait, transmissionStatusCheck:
+		if done {
+			return "checkingUP"
+		}
+		return "checkingDL"
+	case transmissionStatusDownloadWait:
+		return "queuedDL"
+	case transmissionStatusDownload:
+		return "downloading"
+	case transmissionStatusSeedWait:
+		return "queuedUP"
+	case transmissionStatusSeed:
+		return "uploading"
+	default:
+		return "unknown"
+	}
+}
+
+// joinDownloaderPath joins a remote directory and a torrent name using the
+// separator style of the remote directory, which may differ from the local OS.
+func joinDownloaderPath(dir, name string) string {
+	dir = strings.TrimRight(dir, `/\`)
+	if strings.Contains(dir, `\`) && !strings.Contains(dir, "/") {
+		return dir + `\` + name
+	}
+	return dir + "/" + name
+}
@@ -0,0 +1,228 @@
+package downloader
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const trTestSessionID = "session-123"
+
+type fakeTransmission struct {
+	t        *testing.T
+	mu       sync.Mutex
+	requests []transmissionRequest
+	handlers map[string]func(args map[string]interface{}) (string, interface{})
+}
+
+func newFakeTransmission(t *testing.T) (*fakeTransmission, *httptest.Server) {
+	t.Helper()
+	fake := &fakeTransmission{t: t, handlers: map[string]func(map[string]interface{}) (string, interface{}){}}
+	server := httptest.NewServer(http.HandlerFunc(fake.serve))
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeTransmission) serve(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != transmissionRPCPath {
+		f.t.Errorf("unexpected path: %s", r.URL.Path)
+		http.NotFound(w, r)
+		return
+	}
+	if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.Header.Get(transmissionSessionIDHeader) != trTestSessionID {
+		w.Header().Set(transmissionSessionIDHeader, trTestSessionID)
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	var req struct {
+		Method    string                 `json:"method"`
+		Arguments map[string]interface{} `json:"arguments"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		f.t.Errorf("decode rpc request: %v", err)
+		return
+	}
+	f.mu.Lock()
+	f.requests = append(f.requests, transmissionRequest{Method: req.Method, Arguments: req.Arguments})
+	handler := f.handlers[req.Method]
+	f.mu.Unlock()
+	result, args := "success", interface{}(map[string]interface{}{})
+	if handler != nil {
+		result, args = handler(req.Arguments)
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"result": result, "arguments": args})
+}
+
+func (f *fakeTransmission) lastRequest(method string) map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := len(f.requests) - 1; i >= 0; i-- {
+		if f.requests[i].Method == method {
+			args, _ := f.requests[i].Arguments.(map[string]interface{})
+			return args
+		}
+	}
+	f.t.Fatalf("no %s request recorded", method)
+	return nil
+}
+
+func TestTransmissionClientHandshakeAddAndList(t *testing.T) {
+	t.Parallel()
+
+	fake, server := newFakeTransmission(t)
+	fake.handlers["session-get"] = func(map[string]interface{}) (string, interface{}) {
+		return "success", map[string]interface{}{"version": "4.0.5"}
+	}
+	fake.handlers["torrent-add"] = func(map[string]interface{}) (string, interface{}) {
+		return "success", map[string]interface{}{"torrent-added": map[string]interface{}{"hashString": "ABC"}}
+	}
+	fake.handlers["torrent-get"] = func(map[string]interface{}) (string, interface{}) {
+		return "success", map[string]interface{}{"torrents": []map[string]interface{}{
+			{"hashString": "ABC", "name": "episode.mkv", "status": 6, "percentDone": 1.0, "sizeWhenDone": 100, "haveValid": 100, "downloadDir": "/downloads"},
+			{"hashString": "def", "name": "next.mkv", "status": 4, "percentDone": 0.5, "sizeWhenDone": 100, "haveValid": 50, "downloadDir": "/downloads", "rateDownload": 42},
+			{"hashString": "bad", "name": "broken.mkv", "status": 0, "error": 3, "percentDone": 1.0, "downloadDir": "/downloads"},
+			{"hashString": "warn", "name": "warned.mkv", "status": 6, "error": 1, "errorString": "announce timed out", "percentDone": 1.0, "downloadDir": "/downloads"},
+			{"hashString": "trk", "name": "tracker.mkv", "status": 4, "error": 2, "errorString": "torrent not registered", "percentDone": 0.3, "downloadDir": "/downloads"},
+		}}
+	}
+
+	client := NewTransmissionClient(server.URL)
+	if err := client.Login("admin", "secret"); err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if version, err := client.GetVersion(); err != nil || version != "4.0.5" {
+		t.Fatalf("unexpected version %q err=%v", version, err)
+	}
+	if err := client.AddTorrent("magnet:?xt=urn:btih:test", "/downloads/anime", "anime", true); err != nil {
+		t.Fatalf("add torrent failed: %v", err)
+	}
+	args := fake.lastRequest("torrent-add")
+	if args["filename"] != "magnet:?xt=urn:btih:test" || args["download-dir"] != "/downloads/anime" || args["paused"] != true {
+		t.Fatalf("unexpected add arguments: %+v", args)
+	}
+	if labels, _ := args["labels"].([]interface{}); len(labels) != 1 || labels[0] != "anime" {
+		t.Fatalf("expected category to become a label, got %+v", args["labels"])
+	}
+
+	torrents, err := client.ListTorrents()
+	if err != nil {
+		t.Fatalf("list torrents failed: %v", err)
+	}
+	if len(torrents) != 5 {
+		t.Fatalf("unexpected torrents: %+v", torrents)
+	}
+	if got := torrents[0]; got.Hash != "abc" || got.State != "uploading" || got.ContentPath != "/downloads/episode.mkv" || got.Completed != 100 {
+		t.Fatalf("unexpected seeding torrent mapping: %+v", got)
+	}
+	if got := torrents[1]; got.State != "downloading" || got.Progress != 0.5 || got.DownloadSpeed != 42 {
+		t.Fatalf("unexpected downloading torrent mapping: %+v", got)
+	}
+	if got := torrents[2]; got.State != "error" {
+		t.Fatalf("expected error state to win over progress, got %+v", got)
+	}
+	if got := torrents[3]; got.State != "uploading" {
+		t.Fatalf("expected tracker warning to keep seeding state, got %+v", got)
+	}
+	if got := torrents[4]; got.State != "downloading" {
+		t.Fatalf("expected tracker error to keep downloading state, got %+v", got)
+	}
+}
+
+func TestTransmissionClientUploadsTorrentFileAndAcceptsDuplicate(t *testing.T) {
+	t.Parallel()
+
+	fake, server := newFakeTransmission(t)
+	fake.handlers["torrent-add"] = func(map[string]interface{}) (string, interface{}) {
+		return "success", map[string]interface{}{"torrent-duplicate": map[string]interface{}{"hashString": "abc"}}
+	}
+
+	client := NewTransmissionClient(server.URL + "/transmission/rpc")
+	client.username, client.password = "admin", "secret"
+	data := []byte("d4:infod4:name12:episode.mkvee")
+	if err := client.AddTorrentFileContext(context.Background(), "episode.torrent", data, "/downloads", "", false); err != nil {
+		t.Fatalf("upload torrent file: %v", err)
+	}
+	args := fake.lastRequest("torrent-add")
+	if args["metainfo"] != base64.StdEncoding.EncodeToString(data) {
+		t.Fatalf("unexpected metainfo: %+v", args["metainfo"])
+	}
+	if _, ok := args["labels"]; ok {
+		t.Fatalf("empty category should not send labels: %+v", args)
+	}
+}
+
+func TestTransmissionClientRenameAndSetLocation(t *testing.T) {
+	t.Parallel()
+
+	fake, server := newFakeTransmission(t)
+	client := NewTransmissionClient(server.URL)
+	client.username, client.password = "admin", "secret"
+
+	if err := client.RenameFile("abc", "Show/old.mkv", "Show/new.mkv"); err != nil {
+		t.Fatalf("rename file failed: %v", err)
+	}
+	args := fake.lastRequest("torrent-rename-path")
+	if args["path"] != "Show/old.mkv" || args["name"] != "new.mkv" {
+		t.Fatalf("unexpected rename arguments: %+v", args)
+	}
+	if err := client.RenameFile("abc", "Show/old.mkv", "Other/new.mkv"); err == nil {
+		t.Fatal("expected cross-directory rename to be rejected")
+	}
+
+	if err := client.SetLocation("abc", "/media/Show/Season 01"); err != nil {
+		t.Fatalf("set location failed: %v", err)
+	}
+	args = fake.lastRequest("torrent-set-location")
+	if args["location"] != "/media/Show/Season 01" || args["move"] != true {
+		t.Fatalf("unexpected set location arguments: %+v", args)
+	}
+}
+
+func TestTransmissionClientLoginFailure(t *testing.T) {
+	t.Parallel()
+
+	_, server := newFakeTransmission(t)
+	client := NewTransmissionClient(server.URL)
+	err := client.Login("admin", "wrong")
+	if err == nil || !strings.Contains(err.Error(), "invalid credentials") {
+		t.Fatalf("expected invalid credentials error, got %v", err)
+	}
+}
+
+func TestTransmissionClientReportsRPCFailure(t *testing.T) {
+	t.Parallel()
+
+	fake, server := newFakeTransmission(t)
+	fake.handlers["torrent-add"] = func(map[string]interface{}) (string, interface{}) {
+		return "invalid or corrupt torrent file", nil
+	}
+	client := NewTransmissionClient(server.URL)
+	client.username, client.password = "admin", "secret"
+	err := client.AddTorrent("http://example.invalid/a.torrent", "", "", false)
+	if err == nil || !strings.Contains(err.Error(), "corrupt") {
+		t.Fatalf("expected rpc result error, got %v", err)
+	}
+}
+
+func TestNewClientSelectsBackend(t *testing.T) {
+	t.Parallel()
+
+	if _, ok := NewClient("Transmission", "http://nas:9091").(*TransmissionClient); !ok {
+		t.Fatal("expected transmission backend")
+	}
+	if _, ok := NewClient("", "http://localhost:8080").(*QBittorrentClient); !ok {
+		t.Fatal("expected qBittorrent to remain the default backend")
+	}
+	if got := transmissionRPCURL("http://nas:9091/"); got != "http://nas:9091/transmission/rpc" {
+		t.Fatalf("unexpected rpc url: %q", got)
+	}
+}
@@ -23,6 +23,7 @@ const (
 	EventSubscriptionRun  EventType = "subscription_run"
 	EventSchedulerRun     EventType = "scheduler_run"
 	EventTaskUpdate       EventType = "task_update"
+	EventUpdaterStatus    EventType = "updater_status"
 )
 
 // Event 代表一个系统事件
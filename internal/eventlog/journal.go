@@ -0,0 +1,402 @@
+// Package eventlog 把推送给前端的总线事件写进有界的事件日志。每条事件分配一个单调递增的 ID，
+// 重连的 SSE 客户端可以按 Last-Event-ID 补发错过的事件，其他客户端可以按游标轮询。
+package eventlog
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/event"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+)
+
+// Topics 是写入事件日志并通过 SSE 推送的事件类型。
+var Topics = []event.EventType{
+	event.EventScanProgress,
+	event.EventScanComplete,
+	event.EventMetadataUpdated,
+	event.EventDownloadProgress,
+	event.EventDownloadReady,
+	event.EventLibraryIssue,
+	event.EventScanRun,
+	event.EventSubscriptionRun,
+	event.EventSchedulerRun,
+	event.EventTaskUpdate,
+}
+
+// transientTopics 是只反映当前进度的事件，例如扫描时每个目录一条的 scan_progress。
+// 它们照常推送，但不落盘，内存里每个任务只保留最新一条，不会把 scan_complete 等
+// 重要事件挤出事件日志。
+var transientTopics = map[string]bool{
+	string(event.EventScanProgress): true,
+}
+
+const (
+	// ringSize 是内存中至少保留的最近事件数，大多数重连只需要读内存。
+	ringSize = 1000
+	// MaxPersisted 是数据库中保留的事件数，更早的事件在每次落盘后删除。
+	MaxPersisted = 5000
+	// MaxPageSize 是 Since 单次返回的最大事件数。
+	MaxPageSize = 500
+
+	flushInterval = time.Second
+	// flushThreshold 条待写事件会立即触发落盘，不等下一个周期。
+	flushThreshold = 200
+)
+
+// Entry 是事件日志中的一条事件，Data 是事件发布时的 JSON。
+type Entry struct {
+	ID        uint64          `json:"id"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+
+	// task 是临时事件所属的任务，同一任务的新事件会替换旧的。
+	task string
+}
+
+// Page 是 Since 的查询结果。Truncated 表示游标之后的部分事件已经不在日志里
+// （被清理，或游标来自重启前未落盘的事件），客户端应当重新拉取完整状态。
+type Page struct {
+	Items     []Entry
+	Latest    uint64
+	Truncated bool
+	HasMore   bool
+}
+
+// Journal 在内存里保留最近的事件，并按批写入 event_records 表。
+type Journal struct {
+	mu     sync.Mutex
+	lastID uint64
+	seeded bool
+	ring   []Entry
+	// ringStart 是内存完整覆盖的最早 ID：之后的事件除了被替换的临时事件都还在 ring 里。
+	ringStart   uint64
+	pending     []model.EventRecord
+	watchers    map[int]chan struct{}
+	nextWatcher int
+
+	flushMu   sync.Mutex
+	kick      chan struct{}
+	now       func() time.Time
+	persisted func() *store.EventRecordStore
+}
+
+func New() *Journal {
+	return &Journal{
+		watchers:  make(map[int]chan struct{}),
+		kick:      make(chan struct{}, 1),
+		now:       time.Now,
+		persisted: recordStore,
+	}
+}
+
+var (
+	defaultJournal = New()
+	startOnce      sync.Once
+	stopOnce       sync.Once
+	stopCh         = make(chan struct{})
+	stoppedCh      = make(chan struct{})
+)
+
+// Default 返回进程内共享的事件日志。
+func Default() *Journal {
+	return defaultJournal
+}
+
+// Start 订阅 Topics 并启动后台落盘，多次调用只会启动一次。
+func Start() {
+	startOnce.Do(func() {
+		for _, topic := range Topics {
+			event.GlobalBus.Subscribe(topic, defaultJournal.HandleEvent)
+		}
+		go defaultJournal.run(stopCh, stoppedCh)
+	})
+}
+
+// Stop 停止后台落盘并写入剩余事件。应在事件总线 Wait 之后、关闭数据库之前调用，之后不能再 Start。
+func Stop() {
+	started := true
+	startOnce.Do(func() { started = false })
+	if started {
+		stopOnce.Do(func() { close(stopCh) })
+		<-stoppedCh
+	}
+	if err := defaultJournal.Flush(); err != nil {
+		log.Printf("WARN: Event journal final flush failed: %v", err)
+	}
+}
+
+func recordStore() *store.EventRecordStore {
+	if db.DB == nil {
+		return nil
+	}
+	return store.NewEventRecordStore(db.DB)
+}
+
+// HandleEvent 把总线事件追加到日志，事件总线已经在独立的 goroutine 里调用 Handler。
+func (j *Journal) HandleEvent(evt event.Event) {
+	data, err := json.Marshal(evt.Payload)
+	if err != nil {
+		log.Printf("WARN: Event journal: failed to encode event type=%s error=%v", evt.Type, err)
+		return
+	}
+	j.Append(string(evt.Type), data)
+}
+
+// Append 给事件分配 ID 并写入日志。ID 取当前时间的微秒数，与上一个 ID 冲突时递增，
+// 这样即使上次进程退出前有事件没来得及落盘，重启后的新 ID 也不会与客户端持有的旧 ID 重复。
+func (j *Journal) Append(eventType string, data json.RawMessage) Entry {
+	now := j.now().UTC()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.seedLocked()
+
+	id := uint64(now.UnixMicro())
+	if id <= j.lastID {
+		id = j.lastID + 1
+	}
+	j.lastID = id
+	entry := Entry{ID: id, Type: eventType, Data: data, CreatedAt: now}
+	transient := transientTopics[eventType]
+	if transient {
+		entry.task = transientTask(data)
+		j.dropTransientLocked(eventType, entry.task)
+	}
+
+	if j.ringStart == 0 {
+		j.ringStart = id
+	}
+	j.ring = append(j.ring, entry)
+	// 超出四分之一后再整体裁剪，避免每次追加都搬动整个切片。
+	if len(j.ring) > ringSize+ringSize/4 {
+		j.ring = append([]Entry(nil), j.ring[len(j.ring)-ringSize:]...)
+		j.ringStart = j.ring[0].ID
+	}
+
+	if !transient && j.persisted() != nil {
+		j.pending = append(j.pending, model.EventRecord{ID: id, Type: eventType, Payload: string(data), CreatedAt: now})
+		if len(j.pending) >= flushThreshold {
+			select {
+			case j.kick <- struct{}{}:
+			default:
+			}
+		}
+	}
+
+	for _, ch := range j.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	return entry
+}
+
+// dropTransientLocked 从 ring 中移除同一任务上一条同类临时事件。调用方必须持有 j.mu。
+func (j *Journal) dropTransientLocked(eventType, task string) {
+	for i := len(j.ring) - 1; i >= 0; i-- {
+		if j.ring[i].Type == eventType && j.ring[i].task == task {
+			j.ring = append(j.ring[:i], j.ring[i+1:]...)
+			return
+		}
+	}
+}
+
+// transientTask 从临时事件的 JSON 中取出任务标识：优先 task_id，其次扫描目录 dir。
+func transientTask(data json.RawMessage) string {
+	var payload struct {
+		TaskID string `json:"task_id"`
+		Dir    string `json:"dir"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return ""
+	}
+	if payload.TaskID != "" {
+		return "task:" + payload.TaskID
+	}
+	return "dir:" + payload.Dir
+}
+
+// Latest 返回最新事件的 ID，日志为空时返回 0。
+func (j *Journal) Latest() uint64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.seedLocked()
+	return j.lastID
+}
+
+// Watch 返回一个在有新事件写入时收到信号的 channel，信号会合并，收到后应通过 Since 读取。
+// 不再需要时调用返回的函数。
+func (j *Journal) Watch() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	j.mu.Lock()
+	id := j.nextWatcher
+	j.nextWatcher++
+	j.watchers[id] = ch
+	j.mu.Unlock()
+	return ch, func() {
+		j.mu.Lock()
+		delete(j.watchers, id)
+		j.mu.Unlock()
+	}
+}
+
+// Since 按 ID 顺序返回 after 之后最多 limit 条事件，types 为空时不过滤类型。
+// 内存里的事件足以覆盖游标时不访问数据库。
+func (j *Journal) Since(after uint64, limit int, types []string) (Page, error) {
+	if limit <= 0 || limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+	wanted := make(map[string]struct{}, len(types))
+	for _, eventType := range types {
+		wanted[eventType] = struct{}{}
+	}
+
+	j.mu.Lock()
+	j.seedLocked()
+	latest := j.lastID
+	ringOldest := j.ringStart
+	persisted := j.persisted()
+	if persisted == nil || (len(j.ring) > 0 && after >= ringOldest) {
+		page := Page{Latest: latest, Truncated: cursorTruncated(after, ringOldest, latest)}
+		for _, entry := range j.ring {
+			if entry.ID <= after || !typeWanted(wanted, entry.Type) {
+				continue
+			}
+			if len(page.Items) == limit {
+				page.HasMore = true
+				break
+			}
+			page.Items = append(page.Items, entry)
+		}
+		j.mu.Unlock()
+		return page, nil
+	}
+	j.mu.Unlock()
+
+	if err := j.Flush(); err != nil {
+		return Page{}, err
+	}
+	oldest, _, err := persisted.Bounds()
+	if err != nil {
+		return Page{}, err
+	}
+	if oldest == 0 || (ringOldest > 0 && ringOldest < oldest) {
+		oldest = ringOldest
+	}
+	records, err := persisted.ListAfter(after, types, limit+1)
+	if err != nil {
+		return Page{}, err
+	}
+	page := Page{Latest: latest, Truncated: cursorTruncated(after, oldest, latest)}
+	if len(records) > limit {
+		records = records[:limit]
+		page.HasMore = true
+	}
+	page.Items = make([]Entry, 0, len(records))
+	for _, record := range records {
+		page.Items = append(page.Items, Entry{ID: record.ID, Type: record.Type, Data: json.RawMessage(record.Payload), CreatedAt: record.CreatedAt})
+	}
+	return page, nil
+}
+
+// Flush 把待写事件写入数据库并清理超出 MaxPersisted 的旧事件。写入失败的事件留在队列里等下次重试。
+func (j *Journal) Flush() error {
+	j.flushMu.Lock()
+	defer j.flushMu.Unlock()
+
+	j.mu.Lock()
+	batch := j.pending
+	j.pending = nil
+	j.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+	persisted := j.persisted()
+	if persisted == nil {
+		return nil
+	}
+	if err := persisted.Insert(batch); err != nil {
+		j.mu.Lock()
+		j.pending = append(batch, j.pending...)
+		if len(j.pending) > MaxPersisted {
+			j.pending = j.pending[len(j.pending)-MaxPersisted:]
+		}
+		j.mu.Unlock()
+		return err
+	}
+	_, err := persisted.Prune(MaxPersisted)
+	return err
+}
+
+// Reset 清空内存中的事件和游标，仅供测试使用。
+func (j *Journal) Reset() {
+	j.flushMu.Lock()
+	defer j.flushMu.Unlock()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.lastID = 0
+	j.seeded = false
+	j.ring = nil
+	j.ringStart = 0
+	j.pending = nil
+}
+
+func (j *Journal) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-j.kick:
+		case <-stop:
+			return
+		}
+		if err := j.Flush(); err != nil {
+			log.Printf("WARN: Event journal flush failed: %v", err)
+		}
+	}
+}
+
+// seedLocked 从数据库读取上次进程写入的最大 ID，保证新 ID 继续递增。调用方必须持有 j.mu。
+func (j *Journal) seedLocked() {
+	if j.seeded {
+		return
+	}
+	persisted := j.persisted()
+	if persisted == nil {
+		return
+	}
+	_, latest, err := persisted.Bounds()
+	if err != nil {
+		log.Printf("WARN: Event journal: failed to read latest persisted id: %v", err)
+		return
+	}
+	if latest > j.lastID {
+		j.lastID = latest
+	}
+	j.seeded = true
+}
+
+// cursorTruncated 判断 after 之后的事件是否可能已经丢失：游标早于日志中最旧的事件，
+// 或者晚于最新事件（来自重启前未落盘的事件）。
+func cursorTruncated(after, oldest, latest uint64) bool {
+	if after == 0 {
+		return false
+	}
+	return after < oldest || after > latest
+}
+
+func typeWanted(wanted map[string]struct{}, eventType string) bool {
+	if len(wanted) == 0 {
+		return true
+	}
+	_, ok := wanted[eventType]
+	return ok
+}
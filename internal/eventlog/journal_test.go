@@ -0,0 +1,145 @@
+package eventlog
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+)
+
+func fixedClock(at time.Time) func() time.Time {
+	return func() time.Time { return at }
+}
+
+func memoryJournal(at time.Time) *Journal {
+	journal := New()
+	journal.now = fixedClock(at)
+	journal.persisted = func() *store.EventRecordStore { return nil }
+	return journal
+}
+
+func TestJournalAssignsIncreasingIDsAndPagesFromCursor(t *testing.T) {
+	at := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+	journal := memoryJournal(at)
+
+	first := journal.Append("task_update", json.RawMessage(`{"n":1}`))
+	second := journal.Append("scan_complete", json.RawMessage(`{"n":2}`))
+	third := journal.Append("task_update", json.RawMessage(`{"n":3}`))
+	if first.ID != uint64(at.UnixMicro()) || second.ID != first.ID+1 || third.ID != second.ID+1 {
+		t.Fatalf("unexpected ids %d %d %d", first.ID, second.ID, third.ID)
+	}
+
+	page, err := journal.Since(first.ID, 1, nil)
+	if err != nil || len(page.Items) != 1 || page.Items[0].ID != second.ID || !page.HasMore || page.Truncated {
+		t.Fatalf("Since(first, 1) = %+v, %v", page, err)
+	}
+	page, err = journal.Since(first.ID, 10, []string{"task_update"})
+	if err != nil || len(page.Items) != 1 || page.Items[0].ID != third.ID || page.HasMore || page.Latest != third.ID {
+		t.Fatalf("Since filtered = %+v, %v", page, err)
+	}
+
+	// 游标晚于最新事件，说明它来自重启前的进程。
+	page, err = journal.Since(third.ID+50, 10, nil)
+	if err != nil || !page.Truncated || len(page.Items) != 0 {
+		t.Fatalf("Since(future) = %+v, %v", page, err)
+	}
+}
+
+func TestJournalReportsTruncationWhenRingDropsCursor(t *testing.T) {
+	journal := memoryJournal(time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC))
+	first := journal.Append("task_update", json.RawMessage(`{}`))
+	for i := 0; i < ringSize+ringSize/4; i++ {
+		journal.Append("task_update", json.RawMessage(`{}`))
+	}
+	page, err := journal.Since(first.ID, 10, nil)
+	if err != nil || !page.Truncated || len(page.Items) != 10 {
+		t.Fatalf("Since(dropped cursor) = truncated:%v items:%d err:%v", page.Truncated, len(page.Items), err)
+	}
+}
+
+func TestJournalPersistsAndResumesAfterRestart(t *testing.T) {
+	db.InitDB(":memory:")
+	t.Cleanup(func() {
+		_ = db.CloseDB()
+		db.DB = nil
+	})
+	at := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+	journal := New()
+	journal.now = fixedClock(at)
+
+	var ids []uint64
+	for i := 0; i < 3; i++ {
+		ids = append(ids, journal.Append("download_ready", json.RawMessage(`{"title":"Frieren"}`)).ID)
+	}
+	if err := journal.Flush(); err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+
+	// 时钟回拨后重启，新 ID 仍然接在已落盘的最大 ID 之后。
+	restarted := New()
+	restarted.now = fixedClock(at.Add(-time.Hour))
+	if latest := restarted.Latest(); latest != ids[2] {
+		t.Fatalf("Latest after restart = %d, want %d", latest, ids[2])
+	}
+	page, err := restarted.Since(ids[0], 10, nil)
+	if err != nil || page.Truncated || len(page.Items) != 2 || page.Items[0].ID != ids[1] {
+		t.Fatalf("Since after restart = %+v, %v", page, err)
+	}
+	if string(page.Items[0].Data) != `{"title":"Frieren"}` {
+		t.Fatalf("payload = %s", page.Items[0].Data)
+	}
+	next := restarted.Append("task_update", json.RawMessage(`{}`))
+	if next.ID != ids[2]+1 {
+		t.Fatalf("next id = %d, want %d", next.ID, ids[2]+1)
+	}
+
+	if _, err := store.NewEventRecordStore(db.DB).Prune(2); err != nil {
+		t.Fatalf("Prune returned error: %v", err)
+	}
+	fresh := New()
+	page, err = fresh.Since(ids[0], 10, nil)
+	if err != nil || !page.Truncated {
+		t.Fatalf("Since(pruned cursor) = %+v, %v", page, err)
+	}
+}
+
+func TestJournalKeepsOnlyTheLatestScanProgressPerScan(t *testing.T) {
+	db.InitDB(":memory:")
+	t.Cleanup(func() {
+		_ = db.CloseDB()
+		db.DB = nil
+	})
+	journal := New()
+	journal.now = fixedClock(time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC))
+
+	start := journal.Append("task_update", json.RawMessage(`{}`))
+	complete := journal.Append("scan_complete", json.RawMessage(`{"dir":"/anime/a"}`))
+	cursor := journal.Append("scan_progress", json.RawMessage(`{"type":"progress","current":1,"dir":"/anime/a"}`)).ID
+	for i := 2; i <= ringSize*2; i++ {
+		journal.Append("scan_progress", json.RawMessage(`{"type":"progress","current":`+strconv.Itoa(i)+`,"dir":"/anime/a"}`))
+	}
+	other := journal.Append("scan_progress", json.RawMessage(`{"type":"progress","current":1,"dir":"/anime/b"}`))
+
+	page, err := journal.Since(start.ID, 10, nil)
+	if err != nil || page.Truncated || len(page.Items) != 3 {
+		t.Fatalf("Since = %+v, %v", page, err)
+	}
+	if page.Items[0].ID != complete.ID || string(page.Items[1].Data) != `{"type":"progress","current":2000,"dir":"/anime/a"}` || page.Items[2].ID != other.ID {
+		t.Fatalf("scan_complete should survive and progress should be coalesced per scan: %+v", page.Items)
+	}
+	// 被替换的进度事件不算丢失，持有它的客户端不需要重新同步。
+	if page, err = journal.Since(cursor, 10, nil); err != nil || page.Truncated || len(page.Items) != 2 {
+		t.Fatalf("Since(replaced cursor) = %+v, %v", page, err)
+	}
+
+	if err := journal.Flush(); err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+	records, err := store.NewEventRecordStore(db.DB).ListAfter(0, nil, 10)
+	if err != nil || len(records) != 2 || records[1].Type != "scan_complete" {
+		t.Fatalf("scan_progress should not be persisted: %+v, %v", records, err)
+	}
+}
@@ -2,6 +2,7 @@ package httpx
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/go-resty/resty/v2"
+	"github.com/pokerjest/animateAutoTool/internal/metrics"
 )
 
 const DefaultUserAgent = "AnimateAutoTool/0.5.0 (+https://github.com/pokerjest/animateAutoTool)"
@@ -62,6 +64,46 @@ func NewRestyClient(timeout time.Duration, proxyURL string, headers map[string]s
 	return client
 }
 
+var (
+	metadataRequestDuration = metrics.NewHistogramVec("animate_metadata_request_duration_seconds", "Latency of metadata provider API requests.", nil, "provider")
+	metadataRequestErrors   = metrics.NewCounterVec("animate_metadata_request_errors_total", "Failed metadata provider API requests by reason (4xx, 5xx, timeout, canceled, network, invalid_response).", "provider", "reason")
+)
+
+// ObserveMetadataProvider records the latency and failures of every request
+// sent through client under the provider label.
+func ObserveMetadataProvider(client *resty.Client, provider string) {
+	client.OnSuccess(func(_ *resty.Client, resp *resty.Response) {
+		metadataRequestDuration.Observe(resp.Time().Seconds(), provider)
+		if resp.StatusCode() >= http.StatusBadRequest {
+			metadataRequestErrors.Inc(provider, metrics.StatusClass(resp.StatusCode()))
+		}
+	})
+	client.OnError(func(req *resty.Request, err error) {
+		var responseErr *resty.ResponseError
+		if errors.As(err, &responseErr) && responseErr.Response != nil && responseErr.Response.RawResponse != nil {
+			metadataRequestDuration.Observe(responseErr.Response.Time().Seconds(), provider)
+			reason := "invalid_response"
+			if responseErr.Response.StatusCode() >= http.StatusBadRequest {
+				reason = metrics.StatusClass(responseErr.Response.StatusCode())
+			}
+			metadataRequestErrors.Inc(provider, reason)
+			return
+		}
+		if !req.Time.IsZero() {
+			metadataRequestDuration.Observe(time.Since(req.Time).Seconds(), provider)
+		}
+		reason := "network"
+		var netErr net.Error
+		switch {
+		case errors.Is(err, context.Canceled):
+			reason = "canceled"
+		case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+			reason = "timeout"
+		}
+		metadataRequestErrors.Inc(provider, reason)
+	})
+}
+
 func NewRequest(ctx context.Context, client *resty.Client) *resty.Request {
 	req := client.R()
 	if ctx != nil {
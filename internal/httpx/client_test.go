@@ -1,7 +1,9 @@
 package httpx
 
 import (
+	"context"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 	"time"
@@ -107,3 +109,38 @@ func TestNewRestyClientUsesExplicitProxy(t *testing.T) {
 		t.Fatalf("expected explicit Resty proxy, got %v", proxyURL)
 	}
 }
+
+func TestObserveMetadataProviderRecordsLatencyAndErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	client := NewRestyClient(5*time.Second, "", nil)
+	ObserveMetadataProvider(client, "test-provider")
+	requestsBefore := metadataRequestDuration.Count("test-provider")
+	notFoundBefore := metadataRequestErrors.Value("test-provider", "4xx")
+	canceledBefore := metadataRequestErrors.Value("test-provider", "canceled")
+
+	if _, err := NewRequest(context.Background(), client).Get(server.URL + "/ok"); err != nil {
+		t.Fatalf("ok request: %v", err)
+	}
+	if _, err := NewRequest(context.Background(), client).Get(server.URL + "/missing"); err != nil {
+		t.Fatalf("missing request: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewRequest(ctx, client).Get(server.URL + "/ok"); err == nil {
+		t.Fatal("expected canceled request to fail")
+	}
+
+	if got := metadataRequestDuration.Count("test-provider") - requestsBefore; got != 3 {
+		t.Fatalf("observed %d requests, want 3", got)
+	}
+	if metadataRequestErrors.Value("test-provider", "4xx")-notFoundBefore != 1 || metadataRequestErrors.Value("test-provider", "canceled")-canceledBefore != 1 {
+		t.Fatal("expected one 4xx and one canceled error")
+	}
+}
@@ -0,0 +1,824 @@
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"gorm.io/gorm"
+)
+
+const (
+	LocalProviderID = "local"
+
+	localLibraryPrefix = "library-"
+	localSeriesPrefix  = "series-"
+	localSeasonPrefix  = "season-"
+	localEpisodePrefix = "episode-"
+
+	localContinueScanLimit = 250
+)
+
+// metadataBlobColumns 是列表查询时不需要读出的图片缓存列。
+var metadataBlobColumns = []string{"bangumi_image_raw", "tmdb_image_raw", "tmdb_backdrop_raw", "ani_list_image_raw"}
+
+// ProgressRecorder 保存一次本地剧集的播放进度。由 API 层注入，
+// 以便和 /api/v1/playback/progress 共用同一套进度与同步逻辑。
+type ProgressRecorder func(ctx context.Context, episodeID uint, input ProgressInput) error
+
+// LocalProvider 把扫描出的本地番剧库暴露为 MediaProvider：每个本地目录是一个媒体库，
+// LocalAnime 是剧集，按季拆出 Season，LocalEpisode 是单集。进度和收藏按用户保存在本地数据库。
+type LocalProvider struct {
+	db             *gorm.DB
+	userID         uint
+	recordProgress ProgressRecorder
+}
+
+func NewLocalProvider(database *gorm.DB, userID uint, recordProgress ProgressRecorder) *LocalProvider {
+	return &LocalProvider{db: database, userID: userID, recordProgress: recordProgress}
+}
+
+// LocalEpisodeItemID 返回本地剧集在本地提供商中的条目 ID。
+func LocalEpisodeItemID(episodeID uint) string {
+	return localEpisodePrefix + strconv.FormatUint(uint64(episodeID), 10)
+}
+
+// ParseLocalEpisodeItemID 从 episode-<id> 形式的条目 ID 中取出剧集 ID。
+func ParseLocalEpisodeItemID(itemID string) (uint, bool) {
+	ref, ok := parseLocalItemID(itemID)
+	if !ok || ref.kind != localEpisodePrefix {
+		return 0, false
+	}
+	return ref.id, true
+}
+
+type localItemRef struct {
+	kind   string
+	id     uint
+	season int
+}
+
+func parseLocalItemID(itemID string) (localItemRef, bool) {
+	itemID = strings.TrimSpace(itemID)
+	for _, kind := range []string{localLibraryPrefix, localSeriesPrefix, localSeasonPrefix, localEpisodePrefix} {
+		rest, found := strings.CutPrefix(itemID, kind)
+		if !found {
+			continue
+		}
+		ref := localItemRef{kind: kind}
+		if kind == localSeasonPrefix {
+			var seasonPart string
+			rest, seasonPart, found = strings.Cut(rest, "-")
+			season, err := strconv.Atoi(seasonPart)
+			if !found || err != nil || season < 0 {
+				return localItemRef{}, false
+			}
+			ref.season = season
+		}
+		id, err := strconv.ParseUint(rest, 10, 32)
+		if err != nil || id == 0 {
+			return localItemRef{}, false
+		}
+		ref.id = uint(id)
+		return ref, true
+	}
+	return localItemRef{}, false
+}
+
+func localSeasonItemID(animeID uint, season int) string {
+	return fmt.Sprintf("%s%d-%d", localSeasonPrefix, animeID, season)
+}
+
+func localIDString(prefix string, id uint) string {
+	return prefix + strconv.FormatUint(uint64(id), 10)
+}
+
+func (p *LocalProvider) ID() string {
+	return LocalProviderID
+}
+
+func (p *LocalProvider) Capabilities() ProviderCapabilities {
+	return ProviderCapabilities{Libraries: true, Search: true, Episodes: true, Progress: true, Favorites: true, Images: true}
+}
+
+func (p *LocalProvider) conn(ctx context.Context) (*gorm.DB, error) {
+	if p == nil || p.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	return p.db.WithContext(ctx), nil
+}
+
+func (p *LocalProvider) ListLibraries(ctx context.Context) ([]Library, error) {
+	tx, err := p.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dirs, err := store.NewLocalAnimeStore(tx).ListDirectories()
+	if err != nil {
+		return nil, err
+	}
+	var counts []struct {
+		DirectoryID uint
+		Total       int
+	}
+	if err := tx.Model(&model.LocalAnime{}).Select("directory_id, COUNT(*) AS total").Group("directory_id").Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	countByDir := make(map[uint]int, len(counts))
+	for _, row := range counts {
+		countByDir[row.DirectoryID] = row.Total
+	}
+	result := make([]Library, 0, len(dirs))
+	for _, dir := range dirs {
+		result = append(result, Library{
+			ID: localIDString(localLibraryPrefix, dir.ID), Name: localLibraryName(dir),
+			CollectionType: "tvshows", ItemCount: countByDir[dir.ID],
+		})
+	}
+	return result, nil
+}
+
+func (p *LocalProvider) ListItems(ctx context.Context, query Query) (Page, error) {
+	var directoryID uint
+	if query.ParentID != "" {
+		ref, ok := parseLocalItemID(query.ParentID)
+		if !ok {
+			return Page{}, ErrItemNotFound
+		}
+		switch ref.kind {
+		case localLibraryPrefix:
+			directoryID = ref.id
+		case localSeriesPrefix, localSeasonPrefix:
+			page, err := p.ListChildren(ctx, query.ParentID, query.IncludeItemTypes)
+			if err != nil {
+				return Page{}, err
+			}
+			page.Items = paginateItems(page.Items, query.StartIndex, query.Limit)
+			return page, nil
+		default:
+			return Page{}, ErrItemNotFound
+		}
+	}
+	tx, err := p.conn(ctx)
+	if err != nil {
+		return Page{}, err
+	}
+	animes, err := p.loadSeries(tx, directoryID)
+	if err != nil {
+		return Page{}, err
+	}
+	if term := strings.ToLower(strings.TrimSpace(query.SearchTerm)); term != "" {
+		filtered := animes[:0]
+		for _, anime := range animes {
+			if localSeriesMatches(&anime, term) {
+				filtered = append(filtered, anime)
+			}
+		}
+		animes = filtered
+	}
+	sortLocalSeries(animes, query.SortBy, query.SortOrder)
+	total := len(animes)
+	start := min(max(query.StartIndex, 0), total)
+	end := total
+	if query.Limit > 0 {
+		end = min(start+query.Limit, total)
+	}
+	items, err := p.seriesItems(tx, animes[start:end])
+	if err != nil {
+		return Page{}, err
+	}
+	return Page{Items: items, Total: total}, nil
+}
+
+func (p *LocalProvider) GetItem(ctx context.Context, itemID string) (*Item, error) {
+	ref, ok := parseLocalItemID(itemID)
+	if !ok {
+		return nil, ErrItemNotFound
+	}
+	tx, err := p.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch ref.kind {
+	case localLibraryPrefix:
+		dir, err := store.NewLocalAnimeStore(tx).GetDirectory(ref.id)
+		if err != nil {
+			return nil, notFound(err)
+		}
+		var count int64
+		if err := tx.Model(&model.LocalAnime{}).Where("directory_id = ?", dir.ID).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		return &Item{ID: localIDString(localLibraryPrefix, dir.ID), Name: localLibraryName(*dir), Type: "CollectionFolder", ChildCount: int(count)}, nil
+	case localSeriesPrefix:
+		anime, err := p.loadAnime(tx, ref.id)
+		if err != nil {
+			return nil, err
+		}
+		items, err := p.seriesItems(tx, []model.LocalAnime{*anime})
+		if err != nil {
+			return nil, err
+		}
+		return &items[0], nil
+	case localSeasonPrefix:
+		anime, err := p.loadAnime(tx, ref.id)
+		if err != nil {
+			return nil, err
+		}
+		seasons, err := p.seasonItems(tx, anime)
+		if err != nil {
+			return nil, err
+		}
+		for _, season := range seasons {
+			if season.IndexNumber == ref.season {
+				return &season, nil
+			}
+		}
+		return nil, ErrItemNotFound
+	default:
+		episode, err := store.NewLocalAnimeStore(tx).GetEpisode(ref.id)
+		if err != nil {
+			return nil, notFound(err)
+		}
+		anime, err := p.loadAnime(tx, episode.LocalAnimeID)
+		if err != nil {
+			return nil, err
+		}
+		items, err := p.episodeItems(tx, anime, []model.LocalEpisode{*episode})
+		if err != nil {
+			return nil, err
+		}
+		return &items[0], nil
+	}
+}
+
+func (p *LocalProvider) ListChildren(ctx context.Context, itemID string, includeTypes []string) (Page, error) {
+	ref, ok := parseLocalItemID(itemID)
+	if !ok {
+		return Page{}, ErrItemNotFound
+	}
+	if ref.kind == localLibraryPrefix {
+		return p.ListItems(ctx, Query{ParentID: itemID, SortBy: "SortName", SortOrder: "Ascending"})
+	}
+	if ref.kind == localEpisodePrefix {
+		return Page{Items: []Item{}}, nil
+	}
+	tx, err := p.conn(ctx)
+	if err != nil {
+		return Page{}, err
+	}
+	anime, err := p.loadAnime(tx, ref.id)
+	if err != nil {
+		return Page{}, err
+	}
+	if ref.kind == localSeriesPrefix && includesType(includeTypes, "Season") {
+		seasons, err := p.seasonItems(tx, anime)
+		if err != nil {
+			return Page{}, err
+		}
+		return Page{Items: seasons, Total: len(seasons)}, nil
+	}
+	episodes, err := store.NewLocalAnimeStore(tx).ListEpisodesByAnimeIDOrdered(anime.ID)
+	if err != nil {
+		return Page{}, err
+	}
+	if ref.kind == localSeasonPrefix {
+		filtered := episodes[:0]
+		for _, episode := range episodes {
+			if localEpisodeSeason(anime, &episode) == ref.season {
+				filtered = append(filtered, episode)
+			}
+		}
+		episodes = filtered
+	}
+	items, err := p.episodeItems(tx, anime, episodes)
+	if err != nil {
+		return Page{}, err
+	}
+	return Page{Items: items, Total: len(items)}, nil
+}
+
+func (p *LocalProvider) GetPlayback(ctx context.Context, itemID string) (*PlaybackInfo, error) {
+	if _, ok := ParseLocalEpisodeItemID(itemID); !ok {
+		return nil, ErrItemNotFound
+	}
+	item, err := p.GetItem(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	return &PlaybackInfo{
+		Provider: p.ID(), ItemID: item.ID,
+		ResumeTicks: item.UserState.ResumeTicks, RuntimeTicks: item.RuntimeTicks,
+		Played: item.UserState.Played, Favorite: item.UserState.Favorite,
+	}, nil
+}
+
+// ListContinueWatching 返回每部番剧最近一次未看完的剧集。
+func (p *LocalProvider) ListContinueWatching(ctx context.Context, limit int) (Page, error) {
+	tx, err := p.conn(ctx)
+	if err != nil {
+		return Page{}, err
+	}
+	histories, err := store.NewPlaybackHistoryStore(tx).ListRecent(p.userID, localContinueScanLimit)
+	if err != nil {
+		return Page{}, err
+	}
+	laStore := store.NewLocalAnimeStore(tx)
+	items := make([]Item, 0)
+	seenAnime := make(map[uint]struct{})
+	for _, history := range histories {
+		if limit > 0 && len(items) >= limit {
+			break
+		}
+		if _, seen := seenAnime[history.LocalAnimeID]; seen {
+			continue
+		}
+		seenAnime[history.LocalAnimeID] = struct{}{}
+		if history.Completed || history.PositionTicks <= 0 {
+			continue
+		}
+		episode, err := laStore.GetEpisode(history.LocalEpisodeID)
+		if err != nil {
+			continue
+		}
+		anime, err := p.loadAnime(tx, episode.LocalAnimeID)
+		if err != nil {
+			continue
+		}
+		episodeItems, err := p.episodeItems(tx, anime, []model.LocalEpisode{*episode})
+		if err != nil {
+			return Page{}, err
+		}
+		items = append(items, episodeItems[0])
+	}
+	return Page{Items: items, Total: len(items)}, nil
+}
+
+func (p *LocalProvider) ListFavorites(ctx context.Context, limit int) (Page, error) {
+	tx, err := p.conn(ctx)
+	if err != nil {
+		return Page{}, err
+	}
+	ids, err := store.NewLocalMediaFavoriteStore(tx).ListItemIDs(p.userID, limit)
+	if err != nil {
+		return Page{}, err
+	}
+	items := make([]Item, 0, len(ids))
+	for _, id := range ids {
+		item, err := p.GetItem(ctx, id)
+		if errors.Is(err, ErrItemNotFound) {
+			continue
+		}
+		if err != nil {
+			return Page{}, err
+		}
+		items = append(items, *item)
+	}
+	return Page{Items: items, Total: len(items)}, nil
+}
+
+func (p *LocalProvider) UpdateProgress(ctx context.Context, itemID string, input ProgressInput) error {
+	episodeID, ok := ParseLocalEpisodeItemID(itemID)
+	if !ok {
+		return ErrItemNotFound
+	}
+	if p.recordProgress == nil {
+		return errors.New("local progress recorder is not configured")
+	}
+	return notFound(p.recordProgress(ctx, episodeID, input))
+}
+
+// UpdateUserState 标记已看会作用到条目下的所有剧集；收藏只记录条目本身。
+func (p *LocalProvider) UpdateUserState(ctx context.Context, itemID string, input UserStateInput) error {
+	item, err := p.GetItem(ctx, itemID)
+	if err != nil {
+		return err
+	}
+	if item.Type == "CollectionFolder" {
+		return ErrItemNotFound
+	}
+	tx, err := p.conn(ctx)
+	if err != nil {
+		return err
+	}
+	if input.Played != nil {
+		if err := p.setPlayed(tx, itemID, *input.Played); err != nil {
+			return err
+		}
+	}
+	if input.Favorite != nil {
+		return store.NewLocalMediaFavoriteStore(tx).Set(p.userID, item.ID, *input.Favorite)
+	}
+	return nil
+}
+
+func (p *LocalProvider) setPlayed(tx *gorm.DB, itemID string, played bool) error {
+	ref, _ := parseLocalItemID(itemID)
+	laStore := store.NewLocalAnimeStore(tx)
+	var episodes []model.LocalEpisode
+	if ref.kind == localEpisodePrefix {
+		episode, err := laStore.GetEpisode(ref.id)
+		if err != nil {
+			return notFound(err)
+		}
+		episodes = []model.LocalEpisode{*episode}
+	} else {
+		anime, err := p.loadAnime(tx, ref.id)
+		if err != nil {
+			return err
+		}
+		all, err := laStore.ListEpisodesByAnimeIDOrdered(anime.ID)
+		if err != nil {
+			return err
+		}
+		for _, episode := range all {
+			if ref.kind == localSeriesPrefix || localEpisodeSeason(anime, &episode) == ref.season {
+				episodes = append(episodes, episode)
+			}
+		}
+	}
+	historyStore := store.NewPlaybackHistoryStore(tx)
+	for _, episode := range episodes {
+		duration := episode.DurationTicks
+		history, err := historyStore.Find(p.userID, episode.ID)
+		switch {
+		case err == nil:
+			duration = max(history.DurationTicks, duration)
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		case !played:
+			continue
+		}
+		position, event := int64(0), "unplayed"
+		if played {
+			position, event = duration, "played"
+		}
+		if err := historyStore.Upsert(&model.PlaybackHistory{
+			UserID: p.userID, LocalAnimeID: episode.LocalAnimeID, LocalEpisodeID: episode.ID,
+			PositionTicks: position, DurationTicks: duration, Completed: played, LastEvent: event,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetImage 返回剧集封面的缓存图片；季和单集沿用所属剧集的封面。
+func (p *LocalProvider) GetImage(ctx context.Context, itemID string) ([]byte, error) {
+	ref, ok := parseLocalItemID(itemID)
+	if !ok || ref.kind == localLibraryPrefix {
+		return nil, ErrItemNotFound
+	}
+	tx, err := p.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	animeID := ref.id
+	if ref.kind == localEpisodePrefix {
+		episode, err := store.NewLocalAnimeStore(tx).GetEpisode(ref.id)
+		if err != nil {
+			return nil, notFound(err)
+		}
+		animeID = episode.LocalAnimeID
+	}
+	anime, err := store.NewLocalAnimeStore(tx).GetWithMetadata(animeID)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	data := localPosterBlob(anime.Metadata)
+	if len(data) == 0 {
+		return nil, ErrItemNotFound
+	}
+	return data, nil
+}
+
+func (p *LocalProvider) withMetadata(tx *gorm.DB) *gorm.DB {
+	return tx.Preload("Metadata", func(db *gorm.DB) *gorm.DB {
+		return db.Omit(metadataBlobColumns...)
+	})
+}
+
+func (p *LocalProvider) loadSeries(tx *gorm.DB, directoryID uint) ([]model.LocalAnime, error) {
+	query := p.withMetadata(tx.Model(&model.LocalAnime{}))
+	if directoryID != 0 {
+		query = query.Where("directory_id = ?", directoryID)
+	}
+	var animes []model.LocalAnime
+	if err := query.Find(&animes).Error; err != nil {
+		return nil, err
+	}
+	return animes, nil
+}
+
+func (p *LocalProvider) loadAnime(tx *gorm.DB, id uint) (*model.LocalAnime, error) {
+	var anime model.LocalAnime
+	if err := p.withMetadata(tx).First(&anime, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &anime, nil
+}
+
+func (p *LocalProvider) seriesItems(tx *gorm.DB, animes []model.LocalAnime) ([]Item, error) {
+	items := make([]Item, 0, len(animes))
+	if len(animes) == 0 {
+		return items, nil
+	}
+	animeIDs := make([]uint, 0, len(animes))
+	itemIDs := make([]string, 0, len(animes))
+	for _, anime := range animes {
+		animeIDs = append(animeIDs, anime.ID)
+		itemIDs = append(itemIDs, localIDString(localSeriesPrefix, anime.ID))
+	}
+	type animeCount struct {
+		LocalAnimeID uint
+		Total        int
+	}
+	var episodeCounts, completedCounts []animeCount
+	if err := tx.Model(&model.LocalEpisode{}).Select("local_anime_id, COUNT(*) AS total").
+		Where("local_anime_id IN ?", animeIDs).Group("local_anime_id").Scan(&episodeCounts).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&model.PlaybackHistory{}).Select("local_anime_id, COUNT(*) AS total").
+		Where("user_id = ? AND completed = ? AND local_anime_id IN ?", p.userID, true, animeIDs).
+		Group("local_anime_id").Scan(&completedCounts).Error; err != nil {
+		return nil, err
+	}
+	episodesByAnime := make(map[uint]int, len(episodeCounts))
+	for _, row := range episodeCounts {
+		episodesByAnime[row.LocalAnimeID] = row.Total
+	}
+	completedByAnime := make(map[uint]int, len(completedCounts))
+	for _, row := range completedCounts {
+		completedByAnime[row.LocalAnimeID] = row.Total
+	}
+	favorites, err := store.NewLocalMediaFavoriteStore(tx).Lookup(p.userID, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range animes {
+		anime := &animes[i]
+		id := localIDString(localSeriesPrefix, anime.ID)
+		episodeCount := episodesByAnime[anime.ID]
+		item := localSeriesBase(anime)
+		item.ID, item.Type = id, "Series"
+		item.ParentID = localIDString(localLibraryPrefix, anime.DirectoryID)
+		item.ChildCount = episodeCount
+		item.UserState = UserState{
+			Played:   episodeCount > 0 && completedByAnime[anime.ID] >= episodeCount,
+			Favorite: favorites[id],
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (p *LocalProvider) seasonItems(tx *gorm.DB, anime *model.LocalAnime) ([]Item, error) {
+	episodeItems, err := p.childEpisodes(tx, anime)
+	if err != nil {
+		return nil, err
+	}
+	seriesID := localIDString(localSeriesPrefix, anime.ID)
+	seriesName := localSeriesName(anime)
+	var seasons []Item
+	index := make(map[int]int)
+	for _, episode := range episodeItems {
+		position, exists := index[episode.ParentIndexNumber]
+		if !exists {
+			position = len(seasons)
+			index[episode.ParentIndexNumber] = position
+			seasons = append(seasons, Item{
+				ID: localSeasonItemID(anime.ID, episode.ParentIndexNumber), Name: fmt.Sprintf("第 %d 季", episode.ParentIndexNumber),
+				Type: "Season", IndexNumber: episode.ParentIndexNumber, ParentID: seriesID,
+				SeriesID: seriesID, SeriesName: seriesName, UserState: UserState{Played: true},
+			})
+		}
+		season := &seasons[position]
+		season.ChildCount++
+		season.UserState.Played = season.UserState.Played && episode.UserState.Played
+	}
+	if seasons == nil {
+		seasons = []Item{}
+	}
+	sort.SliceStable(seasons, func(i, j int) bool { return seasons[i].IndexNumber < seasons[j].IndexNumber })
+	return seasons, nil
+}
+
+// childEpisodes 返回番剧下全部剧集的条目，按季、集排序。
+func (p *LocalProvider) childEpisodes(tx *gorm.DB, anime *model.LocalAnime) ([]Item, error) {
+	episodes, err := store.NewLocalAnimeStore(tx).ListEpisodesByAnimeIDOrdered(anime.ID)
+	if err != nil {
+		return nil, err
+	}
+	return p.episodeItems(tx, anime, episodes)
+}
+
+func (p *LocalProvider) episodeItems(tx *gorm.DB, anime *model.LocalAnime, episodes []model.LocalEpisode) ([]Item, error) {
+	items := make([]Item, 0, len(episodes))
+	if len(episodes) == 0 {
+		return items, nil
+	}
+	episodeIDs := make([]uint, 0, len(episodes))
+	itemIDs := make([]string, 0, len(episodes))
+	for _, episode := range episodes {
+		episodeIDs = append(episodeIDs, episode.ID)
+		itemIDs = append(itemIDs, LocalEpisodeItemID(episode.ID))
+	}
+	histories, err := store.NewPlaybackHistoryStore(tx).ListByEpisodes(p.userID, episodeIDs)
+	if err != nil {
+		return nil, err
+	}
+	favorites, err := store.NewLocalMediaFavoriteStore(tx).Lookup(p.userID, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+	seriesID := localIDString(localSeriesPrefix, anime.ID)
+	base := localSeriesBase(anime)
+	for i := range episodes {
+		episode := &episodes[i]
+		id := LocalEpisodeItemID(episode.ID)
+		season := localEpisodeSeason(anime, episode)
+		name := strings.TrimSpace(episode.Title)
+		if name == "" {
+			name = fmt.Sprintf("第 %d 集", episode.EpisodeNum)
+		}
+		item := Item{
+			ID: id, Name: name, Type: "Episode", Overview: episode.Summary,
+			ProductionYear: base.ProductionYear, PremiereDate: base.PremiereDate, CommunityRating: base.CommunityRating,
+			IndexNumber: episode.EpisodeNum, ParentIndexNumber: season,
+			ParentID: localSeasonItemID(anime.ID, season), SeriesID: seriesID, SeriesName: base.Name,
+			Genres: base.Genres, RuntimeTicks: episode.DurationTicks, UserState: UserState{Favorite: favorites[id]},
+		}
+		if history, ok := histories[episode.ID]; ok {
+			if history.DurationTicks > 0 {
+				item.RuntimeTicks = history.DurationTicks
+			}
+			item.UserState.Played = history.Completed
+			if !history.Completed {
+				item.UserState.ResumeTicks = history.PositionTicks
+			}
+		}
+		if item.RuntimeTicks > 0 {
+			item.UserState.ProgressPercent = min(100, float64(item.UserState.ResumeTicks)/float64(item.RuntimeTicks)*100)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func localLibraryName(dir model.LocalAnimeDirectory) string {
+	if name := strings.TrimSpace(dir.Description); name != "" {
+		return name
+	}
+	return filepath.Base(filepath.Clean(dir.Path))
+}
+
+func localSeriesName(anime *model.LocalAnime) string {
+	if anime.Metadata != nil && strings.TrimSpace(anime.Metadata.Title) != "" {
+		return anime.Metadata.Title
+	}
+	return anime.Title
+}
+
+// localSeriesBase 填充剧集、季和单集共用的展示字段。
+func localSeriesBase(anime *model.LocalAnime) Item {
+	item := Item{Name: localSeriesName(anime), Overview: anime.Summary, PremiereDate: anime.AirDate}
+	if metadata := anime.Metadata; metadata != nil {
+		if metadata.Summary != "" {
+			item.Overview = metadata.Summary
+		}
+		if metadata.AirDate != "" {
+			item.PremiereDate = metadata.AirDate
+		}
+		for _, rating := range []float64{metadata.BangumiRating, metadata.TMDBRating, metadata.AniListRating} {
+			if rating > 0 {
+				item.CommunityRating = rating
+				break
+			}
+		}
+		var genres []string
+		if json.Unmarshal([]byte(metadata.Genres), &genres) == nil {
+			item.Genres = genres
+		}
+	}
+	if len(item.PremiereDate) >= 4 {
+		if year, err := strconv.Atoi(item.PremiereDate[:4]); err == nil {
+			item.ProductionYear = year
+		}
+	}
+	return item
+}
+
+func localSeriesMatches(anime *model.LocalAnime, term string) bool {
+	names := []string{anime.Title}
+	if metadata := anime.Metadata; metadata != nil {
+		names = append(names, metadata.Title, metadata.TitleCN, metadata.TitleEN, metadata.TitleJP, metadata.OriginalTitle)
+	}
+	for _, name := range names {
+		if strings.Contains(strings.ToLower(name), term) {
+			return true
+		}
+	}
+	return false
+}
+
+func localSortName(anime *model.LocalAnime) string {
+	if anime.Metadata != nil && strings.TrimSpace(anime.Metadata.SortTitle) != "" {
+		return strings.ToLower(anime.Metadata.SortTitle)
+	}
+	return strings.ToLower(localSeriesName(anime))
+}
+
+// sortLocalSeries 支持与 Jellyfin 相同的排序字段：SortName、DateCreated、ProductionYear、CommunityRating。
+func sortLocalSeries(animes []model.LocalAnime, sortBy, sortOrder string) {
+	bases := make(map[uint]Item, len(animes))
+	for i := range animes {
+		bases[animes[i].ID] = localSeriesBase(&animes[i])
+	}
+	less := func(a, b *model.LocalAnime) bool {
+		switch sortBy {
+		case "DateCreated":
+			return a.CreatedAt.Before(b.CreatedAt)
+		case "ProductionYear":
+			return bases[a.ID].PremiereDate < bases[b.ID].PremiereDate
+		case "CommunityRating":
+			return bases[a.ID].CommunityRating < bases[b.ID].CommunityRating
+		default:
+			return localSortName(a) < localSortName(b)
+		}
+	}
+	descending := strings.EqualFold(sortOrder, "Descending")
+	sort.SliceStable(animes, func(i, j int) bool {
+		if descending {
+			return less(&animes[j], &animes[i])
+		}
+		return less(&animes[i], &animes[j])
+	})
+}
+
+func localEpisodeSeason(anime *model.LocalAnime, episode *model.LocalEpisode) int {
+	switch {
+	case episode.SeasonNum > 0:
+		return episode.SeasonNum
+	case anime.Season > 0:
+		return anime.Season
+	default:
+		return 1
+	}
+}
+
+// localPosterBlob 与海报接口的默认选择一致：优先当前标题对应来源的缓存图，其次任一非空缓存。
+func localPosterBlob(metadata *model.AnimeMetadata) []byte {
+	if metadata == nil {
+		return nil
+	}
+	candidates := []struct {
+		title string
+		data  []byte
+	}{
+		{metadata.BangumiTitle, metadata.BangumiImageRaw},
+		{metadata.TMDBTitle, metadata.TMDBImageRaw},
+		{metadata.AniListTitle, metadata.AniListImageRaw},
+	}
+	for _, candidate := range candidates {
+		if metadata.Title == candidate.title && len(candidate.data) > 0 {
+			return candidate.data
+		}
+	}
+	for _, candidate := range candidates {
+		if len(candidate.data) > 0 {
+			return candidate.data
+		}
+	}
+	return nil
+}
+
+func includesType(types []string, want string) bool {
+	for _, value := range types {
+		if strings.EqualFold(value, want) {
+			return true
+		}
+	}
+	return false
+}
+
+func paginateItems(items []Item, startIndex, limit int) []Item {
+	start := min(max(startIndex, 0), len(items))
+	if limit <= 0 {
+		return items[start:]
+	}
+	return items[start:min(start+limit, len(items))]
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrItemNotFound
+	}
+	return err
+}
@@ -0,0 +1,201 @@
+package media
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type localProviderFixture struct {
+	db       *gorm.DB
+	library  model.LocalAnimeDirectory
+	frieren  model.LocalAnime
+	dungeon  model.LocalAnime
+	episodes []model.LocalEpisode
+}
+
+func newLocalProviderFixture(t *testing.T) localProviderFixture {
+	t.Helper()
+	database, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&model.LocalAnimeDirectory{}, &model.AnimeMetadata{}, &model.LocalAnime{},
+		&model.LocalEpisode{}, &model.PlaybackHistory{}, &model.LocalMediaFavorite{}))
+
+	fixture := localProviderFixture{db: database, library: model.LocalAnimeDirectory{Path: "/anime", Description: "番剧"}}
+	require.NoError(t, database.Create(&fixture.library).Error)
+	metadata := model.AnimeMetadata{
+		Title: "葬送的芙莉莲", BangumiTitle: "葬送的芙莉莲", TitleJP: "葬送のフリーレン", AirDate: "2023-09-29",
+		Summary: "魔法使的旅途", BangumiRating: 8.9, Genres: `["奇幻","冒险"]`,
+		BangumiImageRaw: []byte{0xff, 0xd8, 0xff, 0xd9},
+	}
+	require.NoError(t, database.Create(&metadata).Error)
+	fixture.frieren = model.LocalAnime{DirectoryID: fixture.library.ID, Title: "Frieren", Path: "/anime/Frieren", MetadataID: &metadata.ID}
+	fixture.dungeon = model.LocalAnime{DirectoryID: fixture.library.ID, Title: "Dungeon Meshi", Path: "/anime/Dungeon Meshi", AirDate: "2024-01-04"}
+	require.NoError(t, database.Create(&fixture.frieren).Error)
+	require.NoError(t, database.Create(&fixture.dungeon).Error)
+	fixture.episodes = []model.LocalEpisode{
+		{LocalAnimeID: fixture.frieren.ID, Title: "冒险的结束", SeasonNum: 1, EpisodeNum: 1, Path: "/anime/Frieren/01.mkv"},
+		{LocalAnimeID: fixture.frieren.ID, SeasonNum: 1, EpisodeNum: 2, Path: "/anime/Frieren/02.mkv"},
+		{LocalAnimeID: fixture.frieren.ID, SeasonNum: 2, EpisodeNum: 1, Path: "/anime/Frieren/S2/01.mkv"},
+		{LocalAnimeID: fixture.dungeon.ID, SeasonNum: 1, EpisodeNum: 1, Path: "/anime/Dungeon Meshi/01.mkv"},
+	}
+	require.NoError(t, database.Create(&fixture.episodes).Error)
+	return fixture
+}
+
+func TestLocalProviderBrowsesLibrariesSeriesSeasonsAndEpisodes(t *testing.T) {
+	t.Parallel()
+	fixture := newLocalProviderFixture(t)
+	provider := NewLocalProvider(fixture.db, 1, nil)
+	ctx := context.Background()
+
+	libraries, err := provider.ListLibraries(ctx)
+	require.NoError(t, err)
+	require.Len(t, libraries, 1)
+	libraryID := "library-" + itoa(fixture.library.ID)
+	assert.Equal(t, Library{ID: libraryID, Name: "番剧", CollectionType: "tvshows", ItemCount: 2}, libraries[0])
+
+	page, err := provider.ListItems(ctx, Query{ParentID: libraryID, SortBy: "ProductionYear", SortOrder: "Descending", Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 2, page.Total)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "Dungeon Meshi", page.Items[0].Name)
+
+	page, err = provider.ListItems(ctx, Query{SearchTerm: "フリーレン"})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	series := page.Items[0]
+	assert.Equal(t, "series-"+itoa(fixture.frieren.ID), series.ID)
+	assert.Equal(t, "Series", series.Type)
+	assert.Equal(t, "葬送的芙莉莲", series.Name)
+	assert.Equal(t, 2023, series.ProductionYear)
+	assert.InDelta(t, 8.9, series.CommunityRating, 0.001)
+	assert.Equal(t, []string{"奇幻", "冒险"}, series.Genres)
+	assert.Equal(t, 3, series.ChildCount)
+
+	seasons, err := provider.ListChildren(ctx, series.ID, []string{"Season"})
+	require.NoError(t, err)
+	require.Len(t, seasons.Items, 2)
+	assert.Equal(t, "第 2 季", seasons.Items[1].Name)
+	assert.Equal(t, 1, seasons.Items[1].ChildCount)
+
+	episodes, err := provider.ListChildren(ctx, seasons.Items[0].ID, []string{"Episode"})
+	require.NoError(t, err)
+	require.Len(t, episodes.Items, 2)
+	assert.Equal(t, "冒险的结束", episodes.Items[0].Name)
+	assert.Equal(t, "第 2 集", episodes.Items[1].Name)
+	assert.Equal(t, seasons.Items[0].ID, episodes.Items[1].ParentID)
+
+	season, err := provider.GetItem(ctx, seasons.Items[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, season.IndexNumber)
+
+	_, err = provider.GetItem(ctx, "series-999")
+	assert.ErrorIs(t, err, ErrItemNotFound)
+	_, err = provider.GetItem(ctx, "season-abc")
+	assert.ErrorIs(t, err, ErrItemNotFound)
+
+	image, err := provider.GetImage(ctx, LocalEpisodeItemID(fixture.episodes[0].ID))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xff, 0xd8, 0xff, 0xd9}, image)
+	_, err = provider.GetImage(ctx, "series-"+itoa(fixture.dungeon.ID))
+	assert.ErrorIs(t, err, ErrItemNotFound)
+}
+
+func TestLocalProviderTracksPerUserProgressAndFavorites(t *testing.T) {
+	t.Parallel()
+	fixture := newLocalProviderFixture(t)
+	history := store.NewPlaybackHistoryStore(fixture.db)
+	var recorded []ProgressInput
+	recorder := func(_ context.Context, episodeID uint, input ProgressInput) error {
+		recorded = append(recorded, input)
+		episode := fixture.episodes[0]
+		if episodeID != episode.ID {
+			return gorm.ErrRecordNotFound
+		}
+		return history.Upsert(&model.PlaybackHistory{
+			UserID: 1, LocalAnimeID: episode.LocalAnimeID, LocalEpisodeID: episodeID,
+			PositionTicks: input.Ticks, DurationTicks: input.DurationTicks, LastEvent: input.Event,
+		})
+	}
+	provider := NewLocalProvider(fixture.db, 1, recorder)
+	other := NewLocalProvider(fixture.db, 2, recorder)
+	ctx := context.Background()
+	episodeID := LocalEpisodeItemID(fixture.episodes[0].ID)
+
+	require.NoError(t, provider.UpdateProgress(ctx, episodeID, ProgressInput{Event: "pause", Ticks: 300, DurationTicks: 1200}))
+	assert.Len(t, recorded, 1)
+	assert.ErrorIs(t, provider.UpdateProgress(ctx, LocalEpisodeItemID(fixture.episodes[3].ID), ProgressInput{Event: "pause"}), ErrItemNotFound)
+	assert.ErrorIs(t, provider.UpdateProgress(ctx, "series-"+itoa(fixture.frieren.ID), ProgressInput{Event: "pause"}), ErrItemNotFound)
+
+	playback, err := provider.GetPlayback(ctx, episodeID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(300), playback.ResumeTicks)
+	assert.Equal(t, int64(1200), playback.RuntimeTicks)
+
+	resume, err := provider.ListContinueWatching(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, resume.Items, 1)
+	assert.Equal(t, episodeID, resume.Items[0].ID)
+	assert.InDelta(t, 25, resume.Items[0].UserState.ProgressPercent, 0.001)
+	otherResume, err := other.ListContinueWatching(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, otherResume.Items)
+
+	played, favorite := true, true
+	seasonID := "season-" + itoa(fixture.frieren.ID) + "-1"
+	require.NoError(t, provider.UpdateUserState(ctx, seasonID, UserStateInput{Played: &played}))
+	seriesID := "series-" + itoa(fixture.frieren.ID)
+	require.NoError(t, provider.UpdateUserState(ctx, seriesID, UserStateInput{Favorite: &favorite}))
+
+	season, err := provider.GetItem(ctx, seasonID)
+	require.NoError(t, err)
+	assert.True(t, season.UserState.Played)
+	series, err := provider.GetItem(ctx, seriesID)
+	require.NoError(t, err)
+	assert.False(t, series.UserState.Played, "season 2 is still unwatched")
+	assert.True(t, series.UserState.Favorite)
+	resume, err = provider.ListContinueWatching(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, resume.Items)
+
+	favorites, err := provider.ListFavorites(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, favorites.Items, 1)
+	assert.Equal(t, seriesID, favorites.Items[0].ID)
+	otherFavorites, err := other.ListFavorites(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, otherFavorites.Items)
+
+	played = false
+	require.NoError(t, provider.UpdateUserState(ctx, episodeID, UserStateInput{Played: &played}))
+	episode, err := provider.GetItem(ctx, episodeID)
+	require.NoError(t, err)
+	assert.False(t, episode.UserState.Played)
+	assert.Zero(t, episode.UserState.ResumeTicks)
+	assert.ErrorIs(t, provider.UpdateUserState(ctx, "library-"+itoa(fixture.library.ID), UserStateInput{Favorite: &favorite}), ErrItemNotFound)
+}
+
+func TestParseLocalItemID(t *testing.T) {
+	t.Parallel()
+	id, ok := ParseLocalEpisodeItemID("episode-42")
+	assert.True(t, ok)
+	assert.Equal(t, uint(42), id)
+	for _, value := range []string{"", "episode-", "episode-0", "series-42", "episode-x", "season-1", "season-1-x"} {
+		_, ok := ParseLocalEpisodeItemID(value)
+		assert.False(t, ok, value)
+	}
+	ref, ok := parseLocalItemID("season-7-2")
+	require.True(t, ok)
+	assert.Equal(t, localItemRef{kind: localSeasonPrefix, id: 7, season: 2}, ref)
+}
+
+func itoa(id uint) string {
+	return localIDString("", id)
+}
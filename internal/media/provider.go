@@ -1,6 +1,12 @@
 package media
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// ErrItemNotFound 表示提供商中不存在该条目，或该条目不支持请求的操作。
+var ErrItemNotFound = errors.New("media item not found")
 
 type ProviderCapabilities struct {
 	Libraries bool `json:"libraries"`
@@ -0,0 +1,188 @@
+// Package mediaprobe 用 ffprobe 读取视频文件的真实技术参数（时长、编码、分辨率、
+// 音轨语言和内嵌字幕），用于替代从文件名猜测的结果。
+package mediaprobe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/pokerjest/animateAutoTool/internal/subtitle"
+)
+
+const ticksPerSecond = 10_000_000
+
+// runProbe 执行 ffprobe 并返回 JSON 输出，测试中可替换。
+var runProbe = func(ctx context.Context, ffprobe, path string) ([]byte, error) {
+	return exec.CommandContext(ctx, ffprobe, //nolint:gosec
+		"-v", "error", "-show_format", "-show_streams", "-of", "json", path).Output()
+}
+
+// Stream 是 ffprobe 报告的一条音视频或字幕流。
+type Stream struct {
+	Index    int    `json:"index"`
+	Type     string `json:"type"`
+	Codec    string `json:"codec"`
+	Language string `json:"language,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
+	BitDepth int    `json:"bit_depth,omitempty"`
+	Channels int    `json:"channels,omitempty"`
+	Default  bool   `json:"default,omitempty"`
+}
+
+// Result 是一次探测的汇总结果。技术字段沿用文件名解析的写法（1080p、HEVC、FLAC、10bit），
+// 便于直接覆盖 LocalEpisode 上的同名字段。
+type Result struct {
+	DurationTicks  int64
+	Container      string
+	VideoCodec     string
+	AudioCodec     string
+	Resolution     string
+	BitDepth       string
+	AudioLanguages []string
+	Streams        []Stream
+	Subtitles      []subtitle.Track
+}
+
+// Available 报告是否能找到 ffprobe。
+func Available() bool {
+	return subtitle.ToolPath("ffprobe") != ""
+}
+
+// Probe 探测单个文件。调用方负责通过 ctx 控制超时。
+func Probe(ctx context.Context, path string) (*Result, error) {
+	ffprobe := subtitle.ToolPath("ffprobe")
+	if ffprobe == "" {
+		return nil, subtitle.ErrToolUnavailable
+	}
+	output, err := runProbe(ctx, ffprobe, path)
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %w", err)
+	}
+	return Parse(output)
+}
+
+type probeOutput struct {
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+	} `json:"format"`
+	Streams []struct {
+		Index            int               `json:"index"`
+		CodecType        string            `json:"codec_type"`
+		CodecName        string            `json:"codec_name"`
+		Width            int               `json:"width"`
+		Height           int               `json:"height"`
+		PixFmt           string            `json:"pix_fmt"`
+		BitsPerRawSample string            `json:"bits_per_raw_sample"`
+		Channels         int               `json:"channels"`
+		Duration         string            `json:"duration"`
+		Tags             map[string]string `json:"tags"`
+		Disposition      map[string]int    `json:"disposition"`
+	} `json:"streams"`
+}
+
+// Parse 解析 ffprobe -show_format -show_streams -of json 的输出。
+func Parse(output []byte) (*Result, error) {
+	var probe probeOutput
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("invalid ffprobe output: %w", err)
+	}
+	result := &Result{Container: probe.Format.FormatName, DurationTicks: secondsToTicks(probe.Format.Duration)}
+	seenLanguages := make(map[string]struct{})
+	for _, raw := range probe.Streams {
+		stream := Stream{
+			Index: raw.Index, Type: raw.CodecType, Codec: strings.ToLower(raw.CodecName),
+			Title: strings.TrimSpace(raw.Tags["title"]), Default: raw.Disposition["default"] == 1,
+		}
+		if language, _ := subtitle.Language(raw.Tags["language"]); language != "und" {
+			stream.Language = language
+		}
+		switch raw.CodecType {
+		case "video":
+			if raw.Disposition["attached_pic"] == 1 {
+				continue
+			}
+			stream.Width, stream.Height = raw.Width, raw.Height
+			stream.BitDepth = videoBitDepth(raw.BitsPerRawSample, raw.PixFmt)
+			if result.VideoCodec == "" {
+				result.VideoCodec = strings.ToUpper(stream.Codec)
+				result.Resolution = resolutionLabel(raw.Width, raw.Height)
+				if stream.BitDepth > 0 {
+					result.BitDepth = strconv.Itoa(stream.BitDepth) + "bit"
+				}
+			}
+			if result.DurationTicks == 0 {
+				result.DurationTicks = secondsToTicks(raw.Duration)
+			}
+		case "audio":
+			stream.Channels = raw.Channels
+			if result.AudioCodec == "" || stream.Default {
+				result.AudioCodec = strings.ToUpper(stream.Codec)
+			}
+			if _, seen := seenLanguages[stream.Language]; stream.Language != "" && !seen {
+				seenLanguages[stream.Language] = struct{}{}
+				result.AudioLanguages = append(result.AudioLanguages, stream.Language)
+			}
+		case "subtitle":
+			result.Subtitles = append(result.Subtitles, subtitle.EmbeddedTrack(raw.Index, raw.CodecName, raw.Tags, raw.Disposition))
+		default:
+			continue
+		}
+		result.Streams = append(result.Streams, stream)
+	}
+	return result, nil
+}
+
+func secondsToTicks(value string) int64 {
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return int64(seconds * ticksPerSecond)
+}
+
+// videoBitDepth 优先读取 bits_per_raw_sample，HEVC 等编码常常不填，再从 pix_fmt（如 yuv420p10le）推断。
+func videoBitDepth(rawBits, pixFmt string) int {
+	if bits, err := strconv.Atoi(strings.TrimSpace(rawBits)); err == nil && bits > 0 {
+		return bits
+	}
+	pixFmt = strings.ToLower(pixFmt)
+	switch {
+	case pixFmt == "":
+		return 0
+	case strings.Contains(pixFmt, "p12"):
+		return 12
+	case strings.Contains(pixFmt, "p10"):
+		return 10
+	default:
+		return 8
+	}
+}
+
+// resolutionLabel 按宽高中较高的档位归类，宽银幕片源（如 1920x800）仍算作 1080p。
+func resolutionLabel(width, height int) string {
+	switch {
+	case width <= 0 || height <= 0:
+		return ""
+	case height >= 2000 || width >= 3800:
+		return "2160p"
+	case height >= 1400 || width >= 2500:
+		return "1440p"
+	case height >= 1000 || width >= 1900:
+		return "1080p"
+	case height >= 700 || width >= 1260:
+		return "720p"
+	case height >= 540 || width >= 960:
+		return "540p"
+	case height >= 480:
+		return "480p"
+	default:
+		return strconv.Itoa(height) + "p"
+	}
+}
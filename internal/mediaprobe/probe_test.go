@@ -0,0 +1,69 @@
+package mediaprobe
+
+import (
+	"testing"
+
+	"github.com/pokerjest/animateAutoTool/internal/subtitle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleProbeOutput = `{
+  "streams": [
+    {"index": 0, "codec_type": "video", "codec_name": "hevc", "width": 1920, "height": 800, "pix_fmt": "yuv420p10le", "disposition": {"default": 1}},
+    {"index": 1, "codec_type": "audio", "codec_name": "aac", "channels": 2, "tags": {"language": "eng"}},
+    {"index": 2, "codec_type": "audio", "codec_name": "flac", "channels": 2, "tags": {"language": "jpn", "title": "Original"}, "disposition": {"default": 1}},
+    {"index": 3, "codec_type": "audio", "codec_name": "aac", "channels": 6, "tags": {"language": "jpn"}},
+    {"index": 4, "codec_type": "subtitle", "codec_name": "ass", "tags": {"language": "chi", "title": "简体"}, "disposition": {"default": 1}},
+    {"index": 5, "codec_type": "subtitle", "codec_name": "hdmv_pgs_subtitle", "tags": {"language": "jpn"}},
+    {"index": 6, "codec_type": "attachment", "codec_name": "ttf"},
+    {"index": 7, "codec_type": "video", "codec_name": "mjpeg", "width": 600, "height": 800, "disposition": {"attached_pic": 1}}
+  ],
+  "format": {"format_name": "matroska,webm", "duration": "1420.512000"}
+}`
+
+func TestParseSummarisesStreams(t *testing.T) {
+	t.Parallel()
+	result, err := Parse([]byte(sampleProbeOutput))
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(14_205_120_000), result.DurationTicks)
+	assert.Equal(t, "matroska,webm", result.Container)
+	assert.Equal(t, "HEVC", result.VideoCodec)
+	assert.Equal(t, "1080p", result.Resolution)
+	assert.Equal(t, "10bit", result.BitDepth)
+	assert.Equal(t, "FLAC", result.AudioCodec, "default audio track wins")
+	assert.Equal(t, []string{"en", "ja"}, result.AudioLanguages)
+	require.Len(t, result.Streams, 6)
+	assert.Equal(t, Stream{Index: 2, Type: "audio", Codec: "flac", Language: "ja", Title: "Original", Channels: 2, Default: true}, result.Streams[2])
+
+	require.Len(t, result.Subtitles, 2)
+	assert.Equal(t, subtitle.SourceEmbedded, result.Subtitles[0].Source)
+	assert.Equal(t, 4, result.Subtitles[0].StreamIndex)
+	assert.True(t, result.Subtitles[0].Playable())
+	assert.False(t, result.Subtitles[1].Playable())
+}
+
+func TestParseFallsBackToStreamDuration(t *testing.T) {
+	t.Parallel()
+	result, err := Parse([]byte(`{"streams":[{"index":0,"codec_type":"video","codec_name":"h264","width":1280,"height":720,"pix_fmt":"yuv420p","duration":"24.5"}],"format":{}}`))
+	require.NoError(t, err)
+	assert.Equal(t, int64(245_000_000), result.DurationTicks)
+	assert.Equal(t, "720p", result.Resolution)
+	assert.Equal(t, "8bit", result.BitDepth)
+	assert.Empty(t, result.AudioLanguages)
+
+	_, err = Parse([]byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestResolutionLabel(t *testing.T) {
+	t.Parallel()
+	cases := map[[2]int]string{
+		{3840, 2160}: "2160p", {3840, 1600}: "2160p", {1920, 1080}: "1080p", {1440, 1080}: "1080p",
+		{1280, 720}: "720p", {960, 540}: "540p", {720, 480}: "480p", {640, 360}: "360p", {0, 0}: "",
+	}
+	for size, want := range cases {
+		assert.Equal(t, want, resolutionLabel(size[0], size[1]), size)
+	}
+}
@@ -0,0 +1,388 @@
+// Package metrics keeps in-process counters, gauges and histograms and
+// renders them in the Prometheus text exposition format.
+//
+// Instruments are declared next to the code they measure with the
+// package-level constructors, which register them in Default:
+//
+//	var runs = metrics.NewCounterVec("animate_scheduler_runs_total", "Scheduler runs.", "source", "outcome")
+//	runs.Inc("auto", "success")
+//
+// Values that are cheaper to read at scrape time than to track (row counts,
+// connection state) are passed to WriteText as extra families instead.
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// DefaultDurationBuckets suit operations that take from a few milliseconds to
+// a couple of minutes, in seconds.
+var DefaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// Label is one name/value pair of a sample.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is one line of a family. Suffix is appended to the family name, for
+// example "_bucket" for histograms.
+type Sample struct {
+	Suffix string
+	Labels []Label
+	Value  float64
+}
+
+// Family is a named group of samples sharing help text and type.
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+type collector interface {
+	collect() Family
+}
+
+// Registry holds the instruments rendered by WriteText.
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+	names      map[string]struct{}
+}
+
+// Default is the registry used by the package-level constructors.
+var Default = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{names: map[string]struct{}{}}
+}
+
+func (r *Registry) register(name string, c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.names[name]; exists {
+		panic("metrics: duplicate metric " + name)
+	}
+	r.names[name] = struct{}{}
+	r.collectors = append(r.collectors, c)
+}
+
+// Families snapshots every registered instrument in registration order.
+func (r *Registry) Families() []Family {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+	families := make([]Family, 0, len(collectors))
+	for _, c := range collectors {
+		families = append(families, c.collect())
+	}
+	return families
+}
+
+// WriteText renders the registered instruments followed by extra in the
+// Prometheus text format, version 0.0.4.
+func (r *Registry) WriteText(w io.Writer, extra ...Family) error {
+	families := append(r.Families(), extra...)
+	sort.SliceStable(families, func(i, j int) bool { return families[i].Name < families[j].Name })
+	buf := bufio.NewWriter(w)
+	for _, family := range families {
+		writeFamily(buf, family)
+	}
+	return buf.Flush()
+}
+
+// ContentType is the media type of WriteText's output.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (d desc) pairs(values []string) []Label {
+	if len(values) != len(d.labels) {
+		panic("metrics: " + d.name + " expects " + strconv.Itoa(len(d.labels)) + " label values")
+	}
+	labels := make([]Label, len(values))
+	for i, value := range values {
+		labels[i] = Label{Name: d.labels[i], Value: value}
+	}
+	return labels
+}
+
+func seriesKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+type series struct {
+	labels []Label
+	value  float64
+}
+
+// CounterVec is a set of monotonically increasing counters keyed by labels.
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// NewCounterVec registers a counter in Default.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return Default.NewCounterVec(name, help, labels...)
+}
+
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{desc: desc{name: name, help: help, labels: labels}, series: map[string]*series{}}
+	r.register(name, c)
+	return c
+}
+
+func (c *CounterVec) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+// Add increases the counter by delta. Negative deltas are ignored.
+func (c *CounterVec) Add(delta float64, labels ...string) {
+	if delta < 0 || math.IsNaN(delta) {
+		return
+	}
+	key := seriesKey(labels)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.series[key]
+	if !ok {
+		entry = &series{labels: c.pairs(labels)}
+		c.series[key] = entry
+	}
+	entry.value += delta
+}
+
+// Value returns the current count, mainly for tests.
+func (c *CounterVec) Value(labels ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.series[seriesKey(labels)]; ok {
+		return entry.value
+	}
+	return 0
+}
+
+func (c *CounterVec) collect() Family {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Family{Name: c.name, Help: c.help, Type: TypeCounter, Samples: sortedSamples(c.series)}
+}
+
+// GaugeVec is a set of values that can go up and down.
+type GaugeVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// NewGaugeVec registers a gauge in Default.
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return Default.NewGaugeVec(name, help, labels...)
+}
+
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{desc: desc{name: name, help: help, labels: labels}, series: map[string]*series{}}
+	r.register(name, g)
+	return g
+}
+
+func (g *GaugeVec) Set(value float64, labels ...string) {
+	key := seriesKey(labels)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	entry, ok := g.series[key]
+	if !ok {
+		entry = &series{labels: g.pairs(labels)}
+		g.series[key] = entry
+	}
+	entry.value = value
+}
+
+// Value returns the current value, mainly for tests.
+func (g *GaugeVec) Value(labels ...string) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if entry, ok := g.series[seriesKey(labels)]; ok {
+		return entry.value
+	}
+	return 0
+}
+
+func (g *GaugeVec) collect() Family {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return Family{Name: g.name, Help: g.help, Type: TypeGauge, Samples: sortedSamples(g.series)}
+}
+
+func sortedSamples(all map[string]*series) []Sample {
+	keys := make([]string, 0, len(all))
+	for key := range all {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	samples := make([]Sample, 0, len(keys))
+	for _, key := range keys {
+		samples = append(samples, Sample{Labels: all[key].labels, Value: all[key].value})
+	}
+	return samples
+}
+
+// HistogramVec counts observations into cumulative buckets keyed by labels.
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labels []Label
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec registers a histogram in Default. buckets are upper bounds;
+// nil selects DefaultDurationBuckets.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return Default.NewHistogramVec(name, help, buckets, labels...)
+}
+
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultDurationBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	h := &HistogramVec{desc: desc{name: name, help: help, labels: labels}, buckets: buckets, series: map[string]*histogramSeries{}}
+	r.register(name, h)
+	return h
+}
+
+func (h *HistogramVec) Observe(value float64, labels ...string) {
+	if math.IsNaN(value) {
+		return
+	}
+	key := seriesKey(labels)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entry, ok := h.series[key]
+	if !ok {
+		entry = &histogramSeries{labels: h.pairs(labels), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = entry
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			entry.counts[i]++
+		}
+	}
+	entry.count++
+	entry.sum += value
+}
+
+// Count returns the number of observations, mainly for tests.
+func (h *HistogramVec) Count(labels ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if entry, ok := h.series[seriesKey(labels)]; ok {
+		return entry.count
+	}
+	return 0
+}
+
+func (h *HistogramVec) collect() Family {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	family := Family{Name: h.name, Help: h.help, Type: TypeHistogram}
+	for _, key := range keys {
+		entry := h.series[key]
+		for i, bound := range h.buckets {
+			family.Samples = append(family.Samples, Sample{
+				Suffix: "_bucket",
+				Labels: append(append([]Label(nil), entry.labels...), Label{Name: "le", Value: formatValue(bound)}),
+				Value:  float64(entry.counts[i]),
+			})
+		}
+		family.Samples = append(family.Samples,
+			Sample{Suffix: "_bucket", Labels: append(append([]Label(nil), entry.labels...), Label{Name: "le", Value: "+Inf"}), Value: float64(entry.count)},
+			Sample{Suffix: "_sum", Labels: entry.labels, Value: entry.sum},
+			Sample{Suffix: "_count", Labels: entry.labels, Value: float64(entry.count)},
+		)
+	}
+	return family
+}
+
+func writeFamily(w *bufio.Writer, family Family) {
+	if family.Help != "" {
+		_, _ = w.WriteString("# HELP " + family.Name + " " + helpEscaper.Replace(family.Help) + "\n")
+	}
+	if family.Type != "" {
+		_, _ = w.WriteString("# TYPE " + family.Name + " " + family.Type + "\n")
+	}
+	for _, sample := range family.Samples {
+		_, _ = w.WriteString(family.Name + sample.Suffix)
+		if len(sample.Labels) > 0 {
+			_ = w.WriteByte('{')
+			for i, label := range sample.Labels {
+				if i > 0 {
+					_ = w.WriteByte(',')
+				}
+				_, _ = w.WriteString(label.Name + `="` + labelEscaper.Replace(label.Value) + `"`)
+			}
+			_ = w.WriteByte('}')
+		}
+		_, _ = w.WriteString(" " + formatValue(sample.Value) + "\n")
+	}
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+// StatusClass collapses an HTTP status code into "2xx", "4xx" and so on to
+// keep label cardinality bounded.
+func StatusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "other"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
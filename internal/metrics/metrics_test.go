@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistryWritesPrometheusText(t *testing.T) {
+	registry := NewRegistry()
+	requests := registry.NewCounterVec("test_requests_total", "Requests by route.", "route")
+	inflight := registry.NewGaugeVec("test_inflight", "In-flight work.")
+	latency := registry.NewHistogramVec("test_latency_seconds", "Latency.\nSecond line.", []float64{1, 0.1}, "route")
+
+	requests.Inc(`/a"b`)
+	requests.Add(2, `/a"b`)
+	requests.Add(-5, `/a"b`)
+	inflight.Set(3)
+	latency.Observe(0.05, "/x")
+	latency.Observe(0.5, "/x")
+	latency.Observe(7, "/x")
+
+	var out strings.Builder
+	if err := registry.WriteText(&out, Family{Name: "test_extra", Type: TypeGauge, Samples: []Sample{{Labels: []Label{{Name: "state", Value: "line\nbreak"}}, Value: 1}}}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	want := `# TYPE test_extra gauge
+test_extra{state="line\nbreak"} 1
+# HELP test_inflight In-flight work.
+# TYPE test_inflight gauge
+test_inflight 3
+# HELP test_latency_seconds Latency.\nSecond line.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{route="/x",le="0.1"} 1
+test_latency_seconds_bucket{route="/x",le="1"} 2
+test_latency_seconds_bucket{route="/x",le="+Inf"} 3
+test_latency_seconds_sum{route="/x"} 7.55
+test_latency_seconds_count{route="/x"} 3
+# HELP test_requests_total Requests by route.
+# TYPE test_requests_total counter
+test_requests_total{route="/a\"b"} 3
+`
+	if out.String() != want {
+		t.Fatalf("unexpected exposition:\n%s\nwant:\n%s", out.String(), want)
+	}
+	if requests.Value(`/a"b`) != 3 || latency.Count("/x") != 3 || inflight.Value() != 3 {
+		t.Fatal("accessors disagree with the exposition")
+	}
+}
+
+func TestRegistryRejectsDuplicatesAndWrongLabelCount(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounterVec("dup_total", "Duplicate.", "a")
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected duplicate registration to panic")
+			}
+		}()
+		registry.NewGaugeVec("dup_total", "Duplicate.")
+	}()
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected a missing label value to panic")
+			}
+		}()
+		counter.Inc()
+	}()
+	if StatusClass(204) != "2xx" || StatusClass(0) != "other" {
+		t.Fatalf("StatusClass = %q, %q", StatusClass(204), StatusClass(0))
+	}
+}
@@ -20,17 +20,19 @@ type Subscription struct {
 	ResolutionFilter      string     `json:"resolution_filter" form:"ResolutionFilter"` // 清晰度过滤 (2160p/1080p/720p)
 	SubtitleLanguage      string     `json:"subtitle_language" form:"SubtitleLanguage"` // 字幕语言过滤 (chs/cht/chs_cht)
 	BackupRSSUrl          string     `json:"backup_rss_url" form:"BackupRSSUrl"`        // 备用 RSS
+	Parser                string     `json:"parser" form:"Parser"`                      // RSS 解析器名称，为空时使用蜜柑
 	ExpectedEpisodes      int        `json:"expected_episodes" form:"ExpectedEpisodes"` // 预期总集数
 	AutoDisableOnDone     bool       `json:"auto_disable_on_done" form:"AutoDisableOnDone"`
 	AllowMultiSubgroup    bool       `json:"allow_multi_subgroup" form:"AllowMultiSubgroup"`
-	StaleAfterHours       int        `json:"stale_after_hours" form:"StaleAfterHours"` // 超过多少小时无更新后提示
-	SavePath              string     `json:"save_path"`                                // 保存路径
-	RenameEnabled         bool       `json:"rename_enabled"`                           // 是否启用重命名
-	Offset                int        `json:"offset"`                                   // 偏移
-	LastEp                int        `json:"last_ep"`                                  // 最后集数
-	IsActive              bool       `json:"is_active"`                                // 激活状态
-	Summary               string     `json:"summary"`                                  // 简介
-	DownloadedCount       int64      `json:"downloaded_count" gorm:"-"`                // 已加入下载且未归档的去重集数 (动态计算)
+	StaleAfterHours       int        `json:"stale_after_hours" form:"StaleAfterHours"`           // 超过多少小时无更新后提示
+	CheckIntervalMinutes  int        `json:"check_interval_minutes" form:"CheckIntervalMinutes"` // 自动检查的最小间隔，0 表示跟随全局设置
+	SavePath              string     `json:"save_path"`                                          // 保存路径
+	RenameEnabled         bool       `json:"rename_enabled"`                                     // 是否启用重命名
+	Offset                int        `json:"offset"`                                             // 偏移
+	LastEp                int        `json:"last_ep"`                                            // 最后集数
+	IsActive              bool       `json:"is_active"`                                          // 激活状态
+	Summary               string     `json:"summary"`                                            // 简介
+	DownloadedCount       int64      `json:"downloaded_count" gorm:"-"`                          // 已加入下载且未归档的去重集数 (动态计算)
 	RSSCount              int64      `json:"rss_count" gorm:"-"`
 	CanonicalEpisodeCount int64      `json:"canonical_episode_count" gorm:"-"`
 	ConfirmedCount        int64      `json:"confirmed_count" gorm:"-"`
@@ -56,6 +58,7 @@ type Subscription struct {
 	CanRetryUpgrade       bool       `json:"can_retry_upgrade" gorm:"-"`
 	CanRefreshLibrary     bool       `json:"can_refresh_library" gorm:"-"`
 	HasRepairActions      bool       `json:"has_repair_actions" gorm:"-"`
+	ScheduleReason        string     `json:"-" gorm:"-"` // 调度器本轮安排检查的原因，写入运行记录
 	StrategyHint          string     `json:"strategy_hint" gorm:"-"`
 	LifecycleStage        string     `json:"lifecycle_stage" gorm:"-"`
 	LifecycleTone         string     `json:"lifecycle_tone" gorm:"-"`
@@ -72,11 +75,29 @@ type Subscription struct {
 }
 
 // User 用户表
+// User roles, from least to most privileged. Viewers browse and play,
+// members also manage subscriptions and the library, admins own settings,
+// backups, updates and accounts. Accounts created before roles existed are
+// admins.
+const (
+	UserRoleViewer = "viewer"
+	UserRoleMember = "member"
+	UserRoleAdmin  = "admin"
+)
+
 type User struct {
 	gorm.Model
 	Username     string `json:"username" gorm:"uniqueIndex"`
 	PasswordHash string `json:"-"`    // 存储 bcrypt 哈希
 	Memo         string `json:"memo"` // 备注 (可存储明文恢复密码)
+	Role         string `json:"role" gorm:"size:16;not null;default:admin"`
+
+	// 两步验证（RFC 6238 TOTP）。TOTPPendingSecret 是尚未用验证码确认的新密钥，
+	// 确认后移入 TOTPSecret；TOTPLastStep 记录最近一次通过的时间步，防止同一验证码被重放。
+	TOTPSecret        string     `json:"-" gorm:"size:64"`
+	TOTPPendingSecret string     `json:"-" gorm:"size:64"`
+	TOTPEnabledAt     *time.Time `json:"totp_enabled_at,omitempty"`
+	TOTPLastStep      int64      `json:"-"`
 }
 
 // PlaybackHistory stores the last known playback position for one user and
@@ -167,6 +188,37 @@ type AIToolRun struct {
 	ConfirmationValidated bool      `json:"confirmation_validated"`
 }
 
+// NotificationChannel 是一个外发通知渠道。Config 保存渠道地址和凭据的 JSON，
+// 不会通过 API 原样返回；Events 为逗号分隔的通知事件，为空时接收全部事件。
+type NotificationChannel struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `gorm:"size:64" json:"name"`
+	Type      string    `gorm:"size:16;index" json:"type"` // webhook / ntfy / gotify / smtp / telegram
+	Enabled   bool      `json:"enabled"`
+	Events    string    `gorm:"type:text" json:"events"`
+	Template  string    `gorm:"type:text" json:"template"`
+	Config    string    `gorm:"type:text" json:"-"`
+}
+
+// NotificationDelivery 记录每一次通知投递及其重试结果。
+type NotificationDelivery struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt   time.Time  `gorm:"index" json:"created_at"`
+	ChannelID   uint       `gorm:"index" json:"channel_id"`
+	ChannelName string     `gorm:"size:64" json:"channel_name"`
+	ChannelType string     `gorm:"size:16" json:"channel_type"`
+	Event       string     `gorm:"size:32;index" json:"event"`
+	Title       string     `gorm:"size:256" json:"title"`
+	Body        string     `gorm:"type:text" json:"body"`
+	Status      string     `gorm:"size:16;index" json:"status"` // pending / sent / failed
+	Attempts    int        `json:"attempts"`
+	Error       string     `gorm:"type:text" json:"error,omitempty"`
+	Test        bool       `json:"test"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+}
+
 // AnimeMetadata 统一的番剧元数据表
 type AnimeMetadata struct {
 	gorm.Model
@@ -237,6 +289,7 @@ type DownloadLog struct {
 	SeasonVal      string // 解析出的季度 (如 "S01")
 	Status         string // "downloading", "completed", "failed", "renamed"
 	InfoHash       string // 种子唯一标识 (由于RSS可能拿不到，不设唯一索引)
+	TaskID         string `gorm:"index"` // 下载器自身的任务 ID (aria2 gid)，HTTP 直链任务没有 InfoHash 时用于匹配
 	TargetFile     string // 最终重命名后的文件路径
 
 	// Live qBittorrent progress is populated only for API responses. These
@@ -297,6 +350,7 @@ type SubscriptionRunLog struct {
 	NewDownloads        int
 	FailedDownloads     int
 	LastDownloadedTitle string
+	ScheduleReason      string // 调度器安排或跳过本次检查的原因代码
 }
 
 // GlobalConfig 存储全局配置 (虽是单用户，但也存在DB里方便迁移)
@@ -314,6 +368,8 @@ const (
 	ConfigKeyQBUsername                = "qb_username"
 	ConfigKeyQBPassword                = "qb_password"
 	ConfigKeyQBMode                    = "qb_mode"
+	ConfigKeyDownloaderBackend         = "downloader_backend"
+	ConfigKeyDownloaderPathMappings    = "downloader_path_mappings"
 	ConfigKeyBaseDir                   = "base_download_dir"
 	ConfigKeyAutoRenameEnabled         = "auto_rename_enabled"
 	ConfigKeyMediaNamingPreset         = "media_naming_preset"
@@ -324,6 +380,10 @@ const (
 	ConfigKeyWriteNFOEnabled           = "write_nfo_enabled"
 	ConfigKeyWriteImagesEnabled        = "write_images_enabled"
 	ConfigKeyIncrementalScanEnabled    = "incremental_scan_enabled"
+	ConfigKeyMediaProbeEnabled         = "media_probe_enabled"
+	ConfigKeySchedulerIntervalMinutes  = "scheduler_interval_minutes"
+	ConfigKeySchedulerQuietHours       = "scheduler_quiet_hours"
+	ConfigKeySchedulerSmartPolling     = "scheduler_smart_polling_enabled"
 	ConfigKeyBangumiAppID              = "bangumi_app_id"
 	ConfigKeyBangumiAppSecret          = "bangumi_app_secret" //nolint:gosec
 	ConfigKeyBangumiAccessToken        = "bangumi_access_token"
@@ -332,13 +392,21 @@ const (
 	ConfigKeyProxyURL                  = "proxy_url"
 	ConfigKeyProxyBangumi              = "proxy_bangumi_enabled"
 	ConfigKeyProxyMikan                = "proxy_mikan_enabled"
+	ConfigKeyProxyRSS                  = "proxy_rss_enabled"
 	ConfigKeyProxyTMDB                 = "proxy_tmdb_enabled"
 	ConfigKeyAniListToken              = "anilist_token"
 	ConfigKeyProxyAniList              = "proxy_anilist_enabled"
 	ConfigKeyProxyAI                   = "proxy_ai_enabled"
 	ConfigKeyProxyUpdater              = "proxy_updater_enabled"
+	ConfigKeyProxyNotify               = "proxy_notify_enabled"
 	ConfigKeyAuthIPAllowlistEnabled    = "auth_ip_allowlist_enabled"
 	ConfigKeyAuthIPAllowlist           = "auth_ip_allowlist"
+	ConfigKeyAuthForwardEnabled        = "auth_forward_enabled"
+	ConfigKeyAuthForwardHeader         = "auth_forward_header"
+	ConfigKeyAuthForwardUserMap        = "auth_forward_user_map"
+	ConfigKeyAuthForwardProvisionRole  = "auth_forward_provision_role"
+	ConfigKeyAuthForwardJWKSURL        = "auth_forward_jwks_url"
+	ConfigKeyAuthForwardAudience       = "auth_forward_audience"
 	ConfigKeyRepoUpdateEnabled         = "repo_update_enabled"
 	ConfigKeyRepoAutoPullEnabled       = "repo_auto_pull_enabled"
 	ConfigKeyRepoUpdateIntervalMinutes = "repo_update_interval_minutes"
@@ -367,6 +435,59 @@ const (
 	ConfigKeyR2SecretKey = "r2_secret_key" //nolint:gosec
 	ConfigKeyR2Bucket    = "r2_bucket"
 
+	// Offsite backup destination (r2, s3, webdav or sftp)
+	ConfigKeyBackupDestination = "backup_destination"
+
+	// S3-compatible storage (MinIO, AWS S3, ...)
+	ConfigKeyS3Endpoint  = "s3_endpoint"
+	ConfigKeyS3Region    = "s3_region"
+	ConfigKeyS3Bucket    = "s3_bucket"
+	ConfigKeyS3AccessKey = "s3_access_key"
+	ConfigKeyS3SecretKey = "s3_secret_key" //nolint:gosec
+	ConfigKeyS3PathStyle = "s3_path_style"
+
+	// WebDAV (Nextcloud, AList, ...)
+	ConfigKeyWebDAVURL      = "webdav_url"
+	ConfigKeyWebDAVUsername = "webdav_username"
+	ConfigKeyWebDAVPassword = "webdav_password" //nolint:gosec
+
+	// SFTP
+	ConfigKeySFTPHost            = "sftp_host"
+	ConfigKeySFTPUsername        = "sftp_username"
+	ConfigKeySFTPPassword        = "sftp_password"    //nolint:gosec
+	ConfigKeySFTPPrivateKey      = "sftp_private_key" //nolint:gosec
+	ConfigKeySFTPHostFingerprint = "sftp_host_fingerprint"
+	ConfigKeySFTPDir             = "sftp_dir"
+
+	// Scheduled backups
+	ConfigKeyBackupScheduleEnabled  = "backup_schedule_enabled"
+	ConfigKeyBackupScheduleCron     = "backup_schedule_cron"
+	ConfigKeyBackupScheduleMode     = "backup_schedule_mode"
+	ConfigKeyBackupScheduleTargets  = "backup_schedule_targets"
+	ConfigKeyBackupScheduleLocalDir = "backup_schedule_local_dir"
+	ConfigKeyBackupSchedulePassword = "backup_schedule_password"
+	ConfigKeyBackupRetentionLast    = "backup_retention_keep_last"
+	ConfigKeyBackupRetentionDaily   = "backup_retention_daily"
+	ConfigKeyBackupRetentionWeekly  = "backup_retention_weekly"
+	ConfigKeyBackupRetentionMonthly = "backup_retention_monthly"
+
+	// Bangumi collection sync
+	ConfigKeyBangumiSyncEnabled   = "bangumi_collection_sync_enabled"
+	ConfigKeyBangumiSyncTypes     = "bangumi_collection_sync_types"
+	ConfigKeyBangumiSyncMode      = "bangumi_collection_sync_mode"
+	ConfigKeyBangumiSyncSubgroups = "bangumi_collection_sync_subgroups"
+
+	// AniList list and progress sync
+	ConfigKeyAniListSyncEnabled = "anilist_sync_enabled"
+	ConfigKeyAniListSyncPolicy  = "anilist_sync_policy"
+	// ConfigKeyAniListSyncUserID is the account whose playback history is
+	// pushed to AniList: the admin who last saved the AniList token.
+	ConfigKeyAniListSyncUserID = "anilist_sync_user_id"
+
+	// Prometheus metrics endpoint
+	ConfigKeyMetricsEnabled = "metrics_enabled"
+	ConfigKeyMetricsToken   = "metrics_token" //nolint:gosec
+
 	// AI Assistant
 	ConfigKeyAIProvider      = "ai_provider"
 	ConfigKeyAIBaseURL       = "ai_base_url" // Legacy OpenAI-compatible key.
@@ -447,12 +568,127 @@ type LocalEpisode struct {
 	ParseSource        string  `json:"parse_source"`
 	ParseConfidence    float64 `json:"parse_confidence"`
 	ScanFingerprint    string  `json:"scan_fingerprint" gorm:"size:64;index"`
-	Resolution         string  `json:"resolution"`  // 解析出的分辨率
-	SubGroup           string  `json:"sub_group"`   // 解析出的字幕组
-	VideoCodec         string  `json:"video_codec"` // 视频编码
-	AudioCodec         string  `json:"audio_codec"` // 音频编码
-	BitDepth           string  `json:"bit_depth"`   // 位深
-	Source             string  `json:"source"`      // 来源
+	Resolution         string  `json:"resolution"`     // 解析出的分辨率
+	SubGroup           string  `json:"sub_group"`      // 解析出的字幕组
+	VideoCodec         string  `json:"video_codec"`    // 视频编码
+	AudioCodec         string  `json:"audio_codec"`    // 音频编码
+	BitDepth           string  `json:"bit_depth"`      // 位深
+	Source             string  `json:"source"`         // 来源
+	DurationTicks      int64   `json:"duration_ticks"` // ffprobe 探测到的时长，未探测时为 0
+}
+
+// LocalSubtitle 是扫描时为 LocalEpisode 索引的外挂字幕文件。内嵌字幕记录在 MediaProbe 中。
+type LocalSubtitle struct {
+	ID             uint      `gorm:"primarykey" json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	LocalEpisodeID uint      `gorm:"index" json:"local_episode_id"`
+	Path           string    `json:"path"`
+	Format         string    `gorm:"size:16" json:"format"`   // ass, ssa, srt, vtt
+	Language       string    `gorm:"size:32" json:"language"` // zh-Hans, ja, ...
+	Label          string    `gorm:"size:128" json:"label"`
+	Default        bool      `json:"default"`
+	Forced         bool      `json:"forced"`
+}
+
+// LocalMediaFavorite 记录用户在本地媒体提供商里收藏的条目。ItemID 是提供商的条目 ID（如 series-12、episode-34）。
+type LocalMediaFavorite struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uint      `gorm:"uniqueIndex:idx_local_media_favorite_user_item" json:"user_id"`
+	ItemID    string    `gorm:"size:64;uniqueIndex:idx_local_media_favorite_user_item" json:"item_id"`
+}
+
+// MediaProbe 缓存一个媒体文件的 ffprobe 结果，按扫描指纹索引，文件内容不变时不会重复探测。
+// 探测失败同样记录（Error 非空），避免每次扫描都对坏文件重试。
+type MediaProbe struct {
+	ID             uint      `gorm:"primarykey" json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Fingerprint    string    `gorm:"size:64;uniqueIndex" json:"fingerprint"`
+	DurationTicks  int64     `json:"duration_ticks"`
+	Container      string    `gorm:"size:64" json:"container"`
+	VideoCodec     string    `gorm:"size:32" json:"video_codec"`
+	AudioCodec     string    `gorm:"size:32" json:"audio_codec"`
+	Resolution     string    `gorm:"size:16" json:"resolution"`
+	BitDepth       string    `gorm:"size:16" json:"bit_depth"`
+	AudioLanguages string    `json:"audio_languages"` // JSON 字符串数组
+	Streams        string    `json:"streams"`         // JSON，mediaprobe.Stream 列表
+	Subtitles      string    `json:"subtitles"`       // JSON，内嵌字幕 subtitle.Track 列表
+	Error          string    `json:"error"`
+}
+
+// APIToken 是供脚本和自动化使用的个人访问令牌。只保存令牌的 SHA-256 哈希，明文只在创建时返回一次；
+// Prefix 是明文开头的几位，用于在列表里辨认。AuthGeneration 记录签发时的 authsession 代数，
+// 全局注销（如恢复备份）后令牌随会话一起失效。
+type APIToken struct {
+	gorm.Model
+	UserID         uint       `gorm:"index" json:"user_id"`
+	Name           string     `gorm:"size:64" json:"name"`
+	Prefix         string     `gorm:"size:16" json:"prefix"`
+	TokenHash      string     `gorm:"size:64;uniqueIndex" json:"-"`
+	Scopes         string     `gorm:"size:128" json:"scopes"` // 逗号分隔
+	AuthGeneration uint64     `json:"-"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	LastUsedAt     *time.Time `json:"last_used_at"`
+	LastUsedIP     string     `gorm:"size:64" json:"last_used_ip"`
+	RevokedAt      *time.Time `gorm:"index" json:"revoked_at"`
+}
+
+// UserRecoveryCode 是两步验证的一次性恢复码。只保存 SHA-256 哈希，明文只在生成时展示一次。
+type UserRecoveryCode struct {
+	gorm.Model
+	UserID   uint       `gorm:"index" json:"user_id"`
+	CodeHash string     `gorm:"size:64;index" json:"-"`
+	UsedAt   *time.Time `json:"used_at"`
+}
+
+// TaskRecord 是后台任务的持久化历史。进度更新只保留在内存里，任务开始和结束时各写一次，
+// 重启后任务中心仍能看到谁在什么时候发起了什么任务、结果如何。
+type TaskRecord struct {
+	TaskID     string     `gorm:"primaryKey;size:96" json:"task_id"`
+	Kind       string     `gorm:"size:48;index" json:"kind"`
+	Title      string     `gorm:"size:255" json:"title"`
+	Status     string     `gorm:"size:16;index" json:"status"`
+	Phase      string     `gorm:"size:48" json:"phase"`
+	Message    string     `gorm:"type:text" json:"message"`
+	Current    int64      `json:"current"`
+	Total      int64      `json:"total"`
+	UserID     uint       `gorm:"index" json:"user_id"`
+	Username   string     `gorm:"size:128" json:"username"`
+	StartedAt  time.Time  `gorm:"index" json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	UpdatedAt  time.Time  `gorm:"index;autoUpdateTime:false" json:"updated_at"`
+}
+
+// EventRecord 是事件日志中的一条记录。ID 单调递增但不连续，SSE 的 Last-Event-ID
+// 和 /events/history 的游标都使用它；Payload 保存事件发布时的 JSON。
+type EventRecord struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement:false" json:"id"`
+	Type      string    `gorm:"size:48;index" json:"type"`
+	Payload   string    `gorm:"type:text" json:"payload"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// BangumiCollectionSync 记录 Bangumi 收藏同步对每个条目做了什么。记录不会被删除：
+// 订阅被用户删除时会写入 deleted 记录（按 Bangumi 条目和 Mikan ID），之后的同步
+// 不会再次订阅它。只知道 Mikan ID 的删除记录 SubjectID 为 0。
+type BangumiCollectionSync struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	SubjectID      int        `gorm:"uniqueIndex:idx_bangumi_collection_syncs_subject_id,where:subject_id != 0" json:"subject_id"`
+	Title          string     `gorm:"size:255" json:"title"`
+	Image          string     `json:"image"`
+	CollectionType int        `json:"collection_type"` // 1 想看，3 在看
+	Status         string     `gorm:"size:16;index" json:"status"`
+	MikanID        string     `gorm:"size:32;index" json:"mikan_id"`
+	SubgroupID     string     `gorm:"size:32" json:"subgroup_id"`
+	SubgroupName   string     `gorm:"size:128" json:"subgroup_name"`
+	RSSUrl         string     `json:"rss_url"`
+	SubscriptionID uint       `gorm:"index" json:"subscription_id"`
+	Reason         string     `gorm:"type:text" json:"reason"`
+	LastSeenAt     *time.Time `json:"last_seen_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
 }
 
 type LibraryIssue struct {
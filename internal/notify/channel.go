@@ -0,0 +1,451 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/model"
+)
+
+// 支持的渠道类型。
+const (
+	ChannelWebhook  = "webhook"
+	ChannelNtfy     = "ntfy"
+	ChannelGotify   = "gotify"
+	ChannelSMTP     = "smtp"
+	ChannelTelegram = "telegram"
+)
+
+const (
+	defaultNtfyServer   = "https://ntfy.sh"
+	defaultTelegramAPI  = "https://api.telegram.org"
+	smtpSecurityTLS     = "tls"
+	smtpSecurityStart   = "starttls"
+	smtpSecurityNone    = "none"
+	maxErrorBodyLength  = 256
+	defaultSMTPTimeout  = 20 * time.Second
+	userAgent           = "AnimateAutoTool-Notify/1.0"
+	contentTypeJSON     = "application/json"
+	contentTypeTextUTF8 = "text/plain; charset=utf-8"
+)
+
+// ChannelTypes 按界面展示顺序列出支持的渠道类型。
+var ChannelTypes = []string{ChannelWebhook, ChannelNtfy, ChannelGotify, ChannelSMTP, ChannelTelegram}
+
+// ChannelConfig 是 NotificationChannel.Config 的结构。不同渠道只使用其中一部分字段：
+//
+//   - webhook: URL，可选 Token（作为 Bearer 发送）
+//   - ntfy: URL（服务器，默认 ntfy.sh）、Topic，可选 Token 和 Priority
+//   - gotify: URL（服务器）、Token（应用令牌），可选 Priority
+//   - telegram: Token（Bot Token）、ChatID，可选 URL（兼容 Bot API 的服务地址）
+//   - smtp: Host、Port、Username、Password、From、To（逗号分隔）、Security
+type ChannelConfig struct {
+	URL      string `json:"url,omitempty"`
+	Token    string `json:"token,omitempty"`
+	Topic    string `json:"topic,omitempty"`
+	ChatID   string `json:"chat_id,omitempty"`
+	Priority int    `json:"priority,omitempty"`
+	Host     string `json:"host,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	From     string `json:"from,omitempty"`
+	To       string `json:"to,omitempty"`
+	Security string `json:"security,omitempty"` // starttls / tls / none
+}
+
+// ParseChannelConfig 解析渠道保存的 JSON 配置，空字符串返回零值。
+func ParseChannelConfig(raw string) (ChannelConfig, error) {
+	var cfg ChannelConfig
+	if strings.TrimSpace(raw) == "" {
+		return cfg, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		return cfg, fmt.Errorf("渠道配置格式错误: %w", err)
+	}
+	return cfg, nil
+}
+
+// Encode 返回用于持久化的 JSON。
+func (c ChannelConfig) Encode() string {
+	data, _ := json.Marshal(c.normalized())
+	return string(data)
+}
+
+// Masked 返回去掉凭据的副本，用于 API 响应。
+func (c ChannelConfig) Masked() ChannelConfig {
+	c.Token = ""
+	c.Password = ""
+	return c
+}
+
+// SecretsSet 返回已保存的凭据字段名，前端据此提示“留空保持不变”。
+func (c ChannelConfig) SecretsSet() []string {
+	secrets := []string{}
+	if c.Token != "" {
+		secrets = append(secrets, "token")
+	}
+	if c.Password != "" {
+		secrets = append(secrets, "password")
+	}
+	return secrets
+}
+
+// KeepSecrets 在更新时保留未重新填写的凭据。
+func (c ChannelConfig) KeepSecrets(previous ChannelConfig) ChannelConfig {
+	if strings.TrimSpace(c.Token) == "" {
+		c.Token = previous.Token
+	}
+	if strings.TrimSpace(c.Password) == "" {
+		c.Password = previous.Password
+	}
+	return c
+}
+
+func (c ChannelConfig) normalized() ChannelConfig {
+	c.URL = strings.TrimRight(strings.TrimSpace(c.URL), "/")
+	c.Token = strings.TrimSpace(c.Token)
+	c.Topic = strings.Trim(strings.TrimSpace(c.Topic), "/")
+	c.ChatID = strings.TrimSpace(c.ChatID)
+	c.Host = strings.TrimSpace(c.Host)
+	c.Username = strings.TrimSpace(c.Username)
+	c.From = strings.TrimSpace(c.From)
+	c.To = strings.TrimSpace(c.To)
+	c.Security = strings.ToLower(strings.TrimSpace(c.Security))
+	return c
+}
+
+// Validate 检查渠道配置是否完整。
+func (c ChannelConfig) Validate(channelType string) error {
+	c = c.normalized()
+	switch channelType {
+	case ChannelWebhook:
+		return validateHTTPURL(c.URL, "Webhook 地址")
+	case ChannelNtfy:
+		if c.URL != "" {
+			if err := validateHTTPURL(c.URL, "ntfy 服务器地址"); err != nil {
+				return err
+			}
+		}
+		if c.Topic == "" {
+			return errors.New("ntfy 主题不能为空")
+		}
+	case ChannelGotify:
+		if err := validateHTTPURL(c.URL, "Gotify 服务器地址"); err != nil {
+			return err
+		}
+		if c.Token == "" {
+			return errors.New("Gotify 应用令牌不能为空")
+		}
+	case ChannelTelegram:
+		if c.URL != "" {
+			if err := validateHTTPURL(c.URL, "Telegram API 地址"); err != nil {
+				return err
+			}
+		}
+		if c.Token == "" || c.ChatID == "" {
+			return errors.New("Telegram Bot Token 和 Chat ID 不能为空")
+		}
+	case ChannelSMTP:
+		if c.Host == "" {
+			return errors.New("SMTP 服务器不能为空")
+		}
+		if c.Port < 0 || c.Port > 65535 {
+			return errors.New("SMTP 端口无效")
+		}
+		switch c.Security {
+		case "", smtpSecurityStart, smtpSecurityTLS, smtpSecurityNone:
+		default:
+			return errors.New("SMTP 加密方式只能是 starttls、tls 或 none")
+		}
+		if _, err := mail.ParseAddress(c.From); err != nil {
+			return errors.New("发件人地址无效")
+		}
+		recipients := c.recipients()
+		if len(recipients) == 0 {
+			return errors.New("收件人不能为空")
+		}
+		for _, to := range recipients {
+			if _, err := mail.ParseAddress(to); err != nil {
+				return fmt.Errorf("收件人地址无效: %s", to)
+			}
+		}
+	default:
+		return fmt.Errorf("不支持的通知渠道类型: %s", channelType)
+	}
+	return nil
+}
+
+func validateHTTPURL(raw, label string) error {
+	if raw == "" {
+		return fmt.Errorf("%s不能为空", label)
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%s必须是 http 或 https 地址", label)
+	}
+	return nil
+}
+
+func (c ChannelConfig) recipients() []string {
+	var out []string
+	for _, part := range strings.FieldsFunc(c.To, func(r rune) bool { return r == ',' || r == ';' }) {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// permanentError 表示重试也不会成功的错误，例如配置缺失或被服务端拒绝。
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+func isPermanent(err error) bool {
+	var perm permanentError
+	return errors.As(err, &perm)
+}
+
+// sender 把一条消息投递到具体渠道。
+type sender func(ctx context.Context, client *http.Client, cfg ChannelConfig, msg Message) error
+
+var senders = map[string]sender{
+	ChannelWebhook:  sendWebhook,
+	ChannelNtfy:     sendNtfy,
+	ChannelGotify:   sendGotify,
+	ChannelTelegram: sendTelegram,
+	ChannelSMTP:     sendSMTP,
+}
+
+func sendWebhook(ctx context.Context, client *http.Client, cfg ChannelConfig, msg Message) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"event":      msg.Event,
+		"title":      msg.Title,
+		"body":       msg.Body,
+		"url":        msg.URL,
+		"data":       msg.Data,
+		"created_at": msg.Time.Format(time.RFC3339),
+	})
+	if err != nil {
+		return permanentError{err}
+	}
+	headers := map[string]string{"Content-Type": contentTypeJSON}
+	if cfg.Token != "" {
+		headers["Authorization"] = "Bearer " + cfg.Token
+	}
+	return postHTTP(ctx, client, cfg.URL, headers, payload)
+}
+
+func sendNtfy(ctx context.Context, client *http.Client, cfg ChannelConfig, msg Message) error {
+	server := cfg.URL
+	if server == "" {
+		server = defaultNtfyServer
+	}
+	headers := map[string]string{
+		"Content-Type": contentTypeTextUTF8,
+		// ntfy 的头部只接受 ASCII，标题按 RFC 2047 编码
+		"Title": mimeHeader(msg.Title),
+	}
+	if cfg.Priority > 0 {
+		headers["Priority"] = strconv.Itoa(cfg.Priority)
+	}
+	if link := absoluteLink(msg.URL); link != "" {
+		headers["Click"] = link
+	}
+	if cfg.Token != "" {
+		headers["Authorization"] = "Bearer " + cfg.Token
+	}
+	return postHTTP(ctx, client, server+"/"+url.PathEscape(cfg.Topic), headers, []byte(msg.Body))
+}
+
+func sendGotify(ctx context.Context, client *http.Client, cfg ChannelConfig, msg Message) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"title":    msg.Title,
+		"message":  msg.Body,
+		"priority": cfg.Priority,
+	})
+	if err != nil {
+		return permanentError{err}
+	}
+	return postHTTP(ctx, client, cfg.URL+"/message", map[string]string{
+		"Content-Type":  contentTypeJSON,
+		"X-Gotify-Key":  cfg.Token,
+		"Accept":        contentTypeJSON,
+		"Cache-Control": "no-cache",
+	}, payload)
+}
+
+func sendTelegram(ctx context.Context, client *http.Client, cfg ChannelConfig, msg Message) error {
+	base := cfg.URL
+	if base == "" {
+		base = defaultTelegramAPI
+	}
+	text := msg.Title
+	if msg.Body != "" {
+		text += "\n\n" + msg.Body
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"chat_id":                  cfg.ChatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return permanentError{err}
+	}
+	return postHTTP(ctx, client, base+"/bot"+cfg.Token+"/sendMessage", map[string]string{"Content-Type": contentTypeJSON}, payload)
+}
+
+func postHTTP(ctx context.Context, client *http.Client, target string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return permanentError{err}
+	}
+	req.Header.Set("User-Agent", userAgent)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+	err = fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusRequestTimeout {
+		return permanentError{err}
+	}
+	return err
+}
+
+func sendSMTP(ctx context.Context, _ *http.Client, cfg ChannelConfig, msg Message) error {
+	security := cfg.Security
+	if security == "" {
+		security = smtpSecurityStart
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = 587
+		if security == smtpSecurityTLS {
+			port = 465
+		}
+	}
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+	deadline := time.Now().Add(defaultSMTPTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	dialer := &net.Dialer{Deadline: deadline}
+	tlsConfig := &tls.Config{ServerName: cfg.Host, MinVersion: tls.VersionTLS12}
+
+	var conn net.Conn
+	var err error
+	if security == smtpSecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(deadline)
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if security == smtpSecurityStart {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return permanentError{errors.New("SMTP 服务器不支持 STARTTLS，请改用 tls 或 none")}
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return permanentError{fmt.Errorf("SMTP 认证失败: %w", err)}
+		}
+	}
+	from, _ := mail.ParseAddress(cfg.From)
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	recipients := cfg.recipients()
+	for _, to := range recipients {
+		addr, _ := mail.ParseAddress(to)
+		if err := client.Rcpt(addr.Address); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(buildMail(cfg.From, recipients, msg)); err != nil {
+		_ = writer.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func buildMail(from string, to []string, msg Message) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mimeHeader(msg.Title))
+	fmt.Fprintf(&buf, "Date: %s\r\n", msg.Time.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	body := msg.Body
+	if link := absoluteLink(msg.URL); link != "" {
+		body += "\n\n" + link
+	}
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
+
+func mimeHeader(value string) string {
+	return mime.QEncoding.Encode("UTF-8", value)
+}
+
+// absoluteLink 只返回完整的 http(s) 链接；站内相对路径在外部渠道里没有意义。
+func absoluteLink(raw string) string {
+	if strings.HasPrefix(raw, "http://") || strings.HasPrefix(raw, "https://") {
+		return raw
+	}
+	return ""
+}
+
+// channelSummary 用于日志，避免打印凭据。
+func channelSummary(channel model.NotificationChannel) string {
+	return fmt.Sprintf("id=%d type=%s name=%q", channel.ID, channel.Type, channel.Name)
+}
@@ -0,0 +1,270 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type capturedRequest struct {
+	Path   string
+	Header http.Header
+	Body   string
+}
+
+func newStubServer(t *testing.T, status int) (*httptest.Server, func() []capturedRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []capturedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, capturedRequest{Path: r.URL.Path, Header: r.Header.Clone(), Body: string(body)})
+		mu.Unlock()
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []capturedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]capturedRequest(nil), requests...)
+	}
+}
+
+func testMessage() Message {
+	return Message{
+		Event: EventDownloadReady,
+		Title: "下载完成：葬送的芙莉莲",
+		Body:  "第 1 集",
+		URL:   "https://example.test/release",
+		Data:  map[string]interface{}{"title": "葬送的芙莉莲"},
+		Time:  time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestSendWebhookPostsJSONWithBearerToken(t *testing.T) {
+	server, requests := newStubServer(t, http.StatusOK)
+	cfg := ChannelConfig{URL: server.URL + "/hook", Token: "hook-secret"}
+
+	require.NoError(t, sendWebhook(context.Background(), server.Client(), cfg, testMessage()))
+
+	got := requests()
+	require.Len(t, got, 1)
+	assert.Equal(t, "/hook", got[0].Path)
+	assert.Equal(t, "Bearer hook-secret", got[0].Header.Get("Authorization"))
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(got[0].Body), &payload))
+	assert.Equal(t, EventDownloadReady, payload["event"])
+	assert.Equal(t, "第 1 集", payload["body"])
+	assert.Equal(t, "葬送的芙莉莲", payload["data"].(map[string]interface{})["title"])
+}
+
+func TestSendNtfyUsesTopicAndEncodedTitle(t *testing.T) {
+	server, requests := newStubServer(t, http.StatusOK)
+	cfg := ChannelConfig{URL: server.URL, Topic: "anime", Token: "tk_123", Priority: 4}
+
+	require.NoError(t, sendNtfy(context.Background(), server.Client(), cfg, testMessage()))
+
+	got := requests()
+	require.Len(t, got, 1)
+	assert.Equal(t, "/anime", got[0].Path)
+	assert.Equal(t, "第 1 集", got[0].Body)
+	assert.Equal(t, "4", got[0].Header.Get("Priority"))
+	assert.Equal(t, "https://example.test/release", got[0].Header.Get("Click"))
+	assert.Equal(t, "Bearer tk_123", got[0].Header.Get("Authorization"))
+	assert.True(t, strings.HasPrefix(got[0].Header.Get("Title"), "=?UTF-8?q?"), got[0].Header.Get("Title"))
+}
+
+func TestSendGotifyUsesAppToken(t *testing.T) {
+	server, requests := newStubServer(t, http.StatusOK)
+	cfg := ChannelConfig{URL: server.URL, Token: "app-token", Priority: 5}
+
+	require.NoError(t, sendGotify(context.Background(), server.Client(), cfg, testMessage()))
+
+	got := requests()
+	require.Len(t, got, 1)
+	assert.Equal(t, "/message", got[0].Path)
+	assert.Equal(t, "app-token", got[0].Header.Get("X-Gotify-Key"))
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(got[0].Body), &payload))
+	assert.Equal(t, "下载完成：葬送的芙莉莲", payload["title"])
+	assert.Equal(t, float64(5), payload["priority"])
+}
+
+func TestSendTelegramUsesCompatibleBotAPI(t *testing.T) {
+	server, requests := newStubServer(t, http.StatusOK)
+	cfg := ChannelConfig{URL: server.URL, Token: "123:abc", ChatID: "-100"}
+
+	require.NoError(t, sendTelegram(context.Background(), server.Client(), cfg, testMessage()))
+
+	got := requests()
+	require.Len(t, got, 1)
+	assert.Equal(t, "/bot123:abc/sendMessage", got[0].Path)
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(got[0].Body), &payload))
+	assert.Equal(t, "-100", payload["chat_id"])
+	assert.Equal(t, "下载完成：葬送的芙莉莲\n\n第 1 集", payload["text"])
+}
+
+func TestPostHTTPClassifiesClientErrorsAsPermanent(t *testing.T) {
+	rejected, _ := newStubServer(t, http.StatusUnauthorized)
+	err := postHTTP(context.Background(), rejected.Client(), rejected.URL, nil, nil)
+	require.Error(t, err)
+	assert.True(t, isPermanent(err))
+
+	unavailable, _ := newStubServer(t, http.StatusServiceUnavailable)
+	err = postHTTP(context.Background(), unavailable.Client(), unavailable.URL, nil, nil)
+	require.Error(t, err)
+	assert.False(t, isPermanent(err))
+}
+
+func TestChannelConfigValidateAndSecrets(t *testing.T) {
+	assert.NoError(t, ChannelConfig{URL: "https://hooks.example.test/a"}.Validate(ChannelWebhook))
+	assert.Error(t, ChannelConfig{URL: "ftp://example.test"}.Validate(ChannelWebhook))
+	assert.NoError(t, ChannelConfig{Topic: "anime"}.Validate(ChannelNtfy))
+	assert.Error(t, ChannelConfig{URL: "https://gotify.example.test"}.Validate(ChannelGotify))
+	assert.Error(t, ChannelConfig{Token: "t"}.Validate(ChannelTelegram))
+	assert.NoError(t, ChannelConfig{Host: "smtp.example.test", From: "Bot <bot@example.test>", To: "a@example.test; b@example.test"}.Validate(ChannelSMTP))
+	assert.Error(t, ChannelConfig{Host: "smtp.example.test", From: "bot@example.test", To: "not-an-address"}.Validate(ChannelSMTP))
+	assert.Error(t, ChannelConfig{}.Validate("pager"))
+
+	saved := ChannelConfig{URL: "https://gotify.example.test", Token: "secret"}
+	masked := saved.Masked()
+	assert.Empty(t, masked.Token)
+	assert.Equal(t, []string{"token"}, saved.SecretsSet())
+	assert.Equal(t, "secret", masked.KeepSecrets(saved).Token)
+	assert.Equal(t, "new", ChannelConfig{Token: "new"}.KeepSecrets(saved).Token)
+}
+
+// fakeSMTPServer 实现发送一封邮件所需的最小 SMTP 会话，并记录 AUTH 和 DATA 内容。
+type fakeSMTPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	auth     string
+	rcpts    []string
+	data     string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &fakeSMTPServer{listener: listener}
+	t.Cleanup(func() { _ = listener.Close() })
+	go server.serve()
+	return server
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+	reply("220 fake ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"):
+			reply("250-fake")
+			reply("250 AUTH PLAIN")
+		case strings.HasPrefix(command, "AUTH PLAIN"):
+			decoded, _ := base64.StdEncoding.DecodeString(strings.TrimSpace(line[len("AUTH PLAIN"):]))
+			s.mu.Lock()
+			s.auth = string(decoded)
+			s.mu.Unlock()
+			reply("235 ok")
+		case strings.HasPrefix(command, "MAIL FROM"):
+			reply("250 ok")
+		case strings.HasPrefix(command, "RCPT TO"):
+			s.mu.Lock()
+			s.rcpts = append(s.rcpts, line[len("RCPT TO:"):])
+			s.mu.Unlock()
+			reply("250 ok")
+		case command == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			s.mu.Lock()
+			s.data = data.String()
+			s.mu.Unlock()
+			reply("250 queued")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestSendSMTPDeliversPlainTextMail(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	cfg := ChannelConfig{
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		Username: "bot",
+		Password: "mail-secret",
+		From:     "AnimateAutoTool <bot@example.test>",
+		To:       "me@example.test, you@example.test",
+		Security: smtpSecurityNone,
+	}
+
+	require.NoError(t, sendSMTP(context.Background(), nil, cfg, testMessage()))
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, "\x00bot\x00mail-secret", server.auth)
+	assert.Equal(t, []string{"<me@example.test>", "<you@example.test>"}, server.rcpts)
+	assert.Contains(t, server.data, "Subject: =?UTF-8?q?")
+	assert.Contains(t, server.data, "第 1 集\r\n\r\nhttps://example.test/release")
+}
+
+func TestSendSMTPRequiresAdvertisedStartTLS(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	cfg := ChannelConfig{Host: "127.0.0.1", Port: server.port(), From: "bot@example.test", To: "me@example.test"}
+
+	err := sendSMTP(context.Background(), nil, cfg, testMessage())
+	require.Error(t, err)
+	assert.True(t, isPermanent(err))
+	assert.Contains(t, err.Error(), "STARTTLS")
+}
@@ -0,0 +1,152 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pokerjest/animateAutoTool/internal/event"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+)
+
+// 通知事件，渠道的 Events 字段按这些键路由。
+const (
+	EventDownloadReady      = "download_ready"
+	EventSubscriptionNew    = "subscription_new"
+	EventSubscriptionFailed = "subscription_failed"
+	EventLibraryIssue       = "library_issue"
+	EventUpdateAvailable    = "update_available"
+	EventTest               = "test"
+)
+
+// EventOption 描述一个可订阅的通知事件。
+type EventOption struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+}
+
+// EventOptions 按界面展示顺序列出可订阅的通知事件。
+var EventOptions = []EventOption{
+	{Key: EventDownloadReady, Label: "下载完成入库"},
+	{Key: EventSubscriptionNew, Label: "订阅发现新剧集"},
+	{Key: EventSubscriptionFailed, Label: "订阅检查失败"},
+	{Key: EventLibraryIssue, Label: "媒体库问题"},
+	{Key: EventUpdateAvailable, Label: "发现新版本"},
+}
+
+// EventLabel 返回事件的中文名称。
+func EventLabel(key string) string {
+	if key == EventTest {
+		return "测试通知"
+	}
+	for _, option := range EventOptions {
+		if option.Key == key {
+			return option.Label
+		}
+	}
+	return key
+}
+
+// IsKnownEvent 判断事件键是否可以被渠道订阅。
+func IsKnownEvent(key string) bool {
+	for _, option := range EventOptions {
+		if option.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// messageFor 把总线事件转换成通知消息，不需要通知的事件返回 false。
+func (s *Service) messageFor(evt event.Event) (Message, bool) {
+	data, ok := evt.Payload.(map[string]interface{})
+	if !ok {
+		return Message{}, false
+	}
+	msg := Message{Data: data, Time: s.now()}
+
+	switch evt.Type {
+	case event.EventDownloadReady:
+		msg.Event = EventDownloadReady
+		msg.Title = "下载完成：" + stringValue(data, "title")
+		msg.Body = joinLines(stringValue(data, "episode_title"), stringValue(data, "target_file"))
+		msg.URL = stringValue(data, "url")
+	case event.EventSubscriptionRun:
+		title := stringValue(data, "title")
+		switch {
+		case stringValue(data, "status") == service.SubscriptionRunStatusError:
+			msg.Event = EventSubscriptionFailed
+			msg.Title = "订阅检查失败：" + title
+			msg.Body = joinLines(stringValue(data, "last_error"), stringValue(data, "summary"))
+		case intValue(data, "last_new_downloads") > 0:
+			msg.Event = EventSubscriptionNew
+			msg.Title = fmt.Sprintf("%s 新增 %d 个下载", title, intValue(data, "last_new_downloads"))
+			msg.Body = joinLines(stringValue(data, "last_downloaded_title"), stringValue(data, "summary"))
+		default:
+			return Message{}, false
+		}
+	case event.EventLibraryIssue:
+		if stringValue(data, "status") != service.LibraryIssueStatusOpen {
+			return Message{}, false
+		}
+		msg.Event = EventLibraryIssue
+		msg.Title = "媒体库问题：" + stringValue(data, "title")
+		msg.Body = joinLines(stringValue(data, "message"), stringValue(data, "hint"), stringValue(data, "directoryPath"))
+	case event.EventUpdaterStatus:
+		result := stringValue(data, "result")
+		latest := stringValue(data, "latest")
+		if (result != "behind" && result != "switchable") || latest == "" {
+			return Message{}, false
+		}
+		// 自动检查会反复报告同一个版本，只在版本变化后通知一次。
+		s.mu.Lock()
+		seen := s.lastUpdateVersion == latest
+		s.lastUpdateVersion = latest
+		s.mu.Unlock()
+		if seen {
+			return Message{}, false
+		}
+		msg.Event = EventUpdateAvailable
+		msg.Title = "发现新版本 " + latest
+		msg.Body = joinLines(fmt.Sprintf("当前版本 %s", stringValue(data, "current")), stringValue(data, "message"))
+		msg.URL = stringValue(data, "release_url")
+	default:
+		return Message{}, false
+	}
+	return msg, true
+}
+
+func stringValue(data map[string]interface{}, key string) string {
+	switch v := data[key].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func intValue(data map[string]interface{}, key string) int {
+	switch v := data[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case uint:
+		return int(v)
+	case float64:
+		return int(v)
+	default:
+		return 0
+	}
+}
+
+func joinLines(parts ...string) string {
+	var lines []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			lines = append(lines, part)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,315 @@
+// Package notify 把事件总线上的下载、订阅、媒体库和更新事件推送到外部通知渠道。
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/event"
+	"github.com/pokerjest/animateAutoTool/internal/httpx"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+)
+
+const (
+	DeliveryStatusPending = "pending"
+	DeliveryStatusSent    = "sent"
+	DeliveryStatusFailed  = "failed"
+
+	defaultMaxAttempts = 3
+	defaultBackoff     = 2 * time.Second
+	httpTimeout        = 15 * time.Second
+	dispatchTimeout    = 2 * time.Minute
+	maxTemplateOutput  = 4096
+)
+
+// Message 是一条待发送的通知。Data 保留原始事件字段，供自定义模板和 Webhook 使用。
+type Message struct {
+	Event string
+	Title string
+	Body  string
+	URL   string
+	Data  map[string]interface{}
+	Time  time.Time
+}
+
+// Service 负责把消息路由到启用的渠道，失败时按指数退避重试并记录投递历史。
+type Service struct {
+	maxAttempts int
+	backoff     time.Duration
+	sleep       func(ctx context.Context, d time.Duration) error
+	httpClient  func() *http.Client
+	now         func() time.Time
+
+	mu                sync.Mutex
+	lastUpdateVersion string
+}
+
+func NewService() *Service {
+	return &Service{
+		maxAttempts: defaultMaxAttempts,
+		backoff:     defaultBackoff,
+		sleep:       sleepContext,
+		httpClient:  configuredHTTPClient,
+		now:         time.Now,
+	}
+}
+
+var (
+	defaultService = NewService()
+	startOnce      sync.Once
+)
+
+// Default 返回进程内共享的通知服务。
+func Default() *Service {
+	return defaultService
+}
+
+// Start 订阅需要推送的事件，多次调用只会订阅一次。
+func Start() {
+	startOnce.Do(func() {
+		for _, topic := range []event.EventType{
+			event.EventDownloadReady,
+			event.EventSubscriptionRun,
+			event.EventLibraryIssue,
+			event.EventUpdaterStatus,
+		} {
+			event.GlobalBus.Subscribe(topic, defaultService.HandleEvent)
+		}
+	})
+}
+
+// HandleEvent 把总线事件转换成通知并同步投递。事件总线已经在独立的 goroutine 里调用 Handler。
+func (s *Service) HandleEvent(evt event.Event) {
+	msg, ok := s.messageFor(evt)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dispatchTimeout)
+	defer cancel()
+	s.Dispatch(ctx, msg)
+}
+
+// Dispatch 把消息发送到所有订阅了该事件的启用渠道，返回每个渠道的投递记录。
+func (s *Service) Dispatch(ctx context.Context, msg Message) []model.NotificationDelivery {
+	if db.DB == nil {
+		return nil
+	}
+	channels, err := store.NewNotificationStore(db.DB).ListEnabledChannels()
+	if err != nil {
+		log.Printf("WARN: Notify: failed to list channels event=%s error=%v", msg.Event, err)
+		return nil
+	}
+	var deliveries []model.NotificationDelivery
+	for _, channel := range channels {
+		if !ChannelWantsEvent(channel, msg.Event) {
+			continue
+		}
+		deliveries = append(deliveries, s.deliver(ctx, channel, msg, false))
+	}
+	return deliveries
+}
+
+// SendTest 向指定渠道发送一条测试消息，不受事件路由限制，也不重试。
+func (s *Service) SendTest(ctx context.Context, channel model.NotificationChannel) model.NotificationDelivery {
+	msg := Message{
+		Event: EventTest,
+		Title: "AnimateAutoTool 测试通知",
+		Body:  fmt.Sprintf("渠道「%s」配置正常，这是一条测试消息。", channel.Name),
+		Time:  s.now(),
+		Data:  map[string]interface{}{"channel": channel.Name},
+	}
+	return s.deliver(ctx, channel, msg, true)
+}
+
+func (s *Service) deliver(ctx context.Context, channel model.NotificationChannel, msg Message, test bool) model.NotificationDelivery {
+	if msg.Time.IsZero() {
+		msg.Time = s.now()
+	}
+	delivery := model.NotificationDelivery{
+		ChannelID:   channel.ID,
+		ChannelName: channel.Name,
+		ChannelType: channel.Type,
+		Event:       msg.Event,
+		Title:       truncate(msg.Title, 256),
+		Status:      DeliveryStatusPending,
+		Test:        test,
+	}
+	notifications := store.NewNotificationStore(db.DB)
+
+	body, err := RenderBody(channel.Template, msg)
+	if err == nil {
+		msg.Body = body
+	}
+	delivery.Body = msg.Body
+	if createErr := notifications.CreateDelivery(&delivery); createErr != nil {
+		log.Printf("WARN: Notify: failed to record delivery channel=%s error=%v", channelSummary(channel), createErr)
+	}
+
+	if err == nil {
+		err = s.send(ctx, channel, msg, test, &delivery.Attempts)
+	}
+	now := s.now()
+	if err != nil {
+		delivery.Status = DeliveryStatusFailed
+		delivery.Error = err.Error()
+		log.Printf("WARN: Notify: delivery failed channel=%s event=%s attempts=%d error=%v", channelSummary(channel), msg.Event, delivery.Attempts, err)
+	} else {
+		delivery.Status = DeliveryStatusSent
+		delivery.DeliveredAt = &now
+	}
+	if updateErr := notifications.UpdateDelivery(delivery.ID, map[string]interface{}{
+		"body":         delivery.Body,
+		"status":       delivery.Status,
+		"attempts":     delivery.Attempts,
+		"error":        delivery.Error,
+		"delivered_at": delivery.DeliveredAt,
+	}); updateErr != nil {
+		log.Printf("WARN: Notify: failed to update delivery id=%d error=%v", delivery.ID, updateErr)
+	}
+	return delivery
+}
+
+func (s *Service) send(ctx context.Context, channel model.NotificationChannel, msg Message, test bool, attempts *int) error {
+	send, ok := senders[channel.Type]
+	if !ok {
+		return fmt.Errorf("不支持的通知渠道类型: %s", channel.Type)
+	}
+	cfg, err := ParseChannelConfig(channel.Config)
+	if err != nil {
+		return err
+	}
+	cfg = cfg.normalized()
+	if err := cfg.Validate(channel.Type); err != nil {
+		return err
+	}
+	maxAttempts := s.maxAttempts
+	if test || maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	client := s.httpClient()
+	for attempt := 1; ; attempt++ {
+		*attempts = attempt
+		err = send(ctx, client, cfg, msg)
+		if err == nil || isPermanent(err) || attempt >= maxAttempts {
+			return err
+		}
+		if sleepErr := s.sleep(ctx, s.backoff<<(attempt-1)); sleepErr != nil {
+			return errors.Join(err, sleepErr)
+		}
+	}
+}
+
+// ChannelWantsEvent 判断渠道是否订阅了该事件，Events 为空表示接收全部事件。
+func ChannelWantsEvent(channel model.NotificationChannel, eventKey string) bool {
+	events := ParseEvents(channel.Events)
+	if len(events) == 0 {
+		return true
+	}
+	for _, key := range events {
+		if key == eventKey {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseEvents 拆分逗号分隔的事件列表并去重。
+func ParseEvents(raw string) []string {
+	seen := map[string]struct{}{}
+	var events []string
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if _, ok := seen[part]; ok {
+			continue
+		}
+		seen[part] = struct{}{}
+		events = append(events, part)
+	}
+	return events
+}
+
+// RenderBody 用渠道模板渲染正文，模板为空时使用默认正文。
+func RenderBody(raw string, msg Message) (string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return msg.Body, nil
+	}
+	tmpl, err := parseTemplate(raw)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, map[string]interface{}{
+		"Event":      msg.Event,
+		"EventLabel": EventLabel(msg.Event),
+		"Title":      msg.Title,
+		"Body":       msg.Body,
+		"URL":        msg.URL,
+		"Data":       msg.Data,
+		"Time":       msg.Time.Format("2006-01-02 15:04:05"),
+	}); err != nil {
+		return "", fmt.Errorf("通知模板渲染失败: %w", err)
+	}
+	// Data 是 interface{} 映射，缺失字段会渲染成 "<no value>"，通知里显示为空更合适。
+	rendered := strings.ReplaceAll(buf.String(), "<no value>", "")
+	return truncate(strings.TrimSpace(rendered), maxTemplateOutput), nil
+}
+
+// ValidateTemplate 在保存渠道前检查模板语法。
+func ValidateTemplate(raw string) error {
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+	_, err := parseTemplate(raw)
+	return err
+}
+
+func parseTemplate(raw string) (*template.Template, error) {
+	tmpl, err := template.New("notification").Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("通知模板语法错误: %w", err)
+	}
+	return tmpl, nil
+}
+
+func configuredHTTPClient() *http.Client {
+	proxyURL := ""
+	if db.DB != nil {
+		configs := store.NewConfigStore(db.DB)
+		if configs.GetDefault(model.ConfigKeyProxyNotify, "") == model.ConfigValueTrue {
+			proxyURL = configs.GetDefault(model.ConfigKeyProxyURL, "")
+		}
+	}
+	return httpx.NewHTTPClientWithProxy(httpTimeout, proxyURL)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func truncate(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,169 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/event"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withNotifyTestDB(t *testing.T) {
+	t.Helper()
+	db.InitDB(":memory:")
+	t.Cleanup(func() {
+		_ = db.CloseDB()
+	})
+}
+
+func newTestService(client *http.Client, sleeps *[]time.Duration) *Service {
+	svc := NewService()
+	svc.httpClient = func() *http.Client { return client }
+	svc.sleep = func(_ context.Context, d time.Duration) error {
+		*sleeps = append(*sleeps, d)
+		return nil
+	}
+	svc.now = func() time.Time { return time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC) }
+	return svc
+}
+
+func createChannel(t *testing.T, channel model.NotificationChannel) model.NotificationChannel {
+	t.Helper()
+	require.NoError(t, store.NewNotificationStore(db.DB).SaveChannel(&channel))
+	return channel
+}
+
+func TestDispatchRoutesByEventAndRecordsHistory(t *testing.T) {
+	withNotifyTestDB(t)
+	server, requests := newStubServer(t, http.StatusOK)
+	var sleeps []time.Duration
+	svc := newTestService(server.Client(), &sleeps)
+
+	createChannel(t, model.NotificationChannel{Name: "all", Type: ChannelWebhook, Enabled: true, Config: ChannelConfig{URL: server.URL + "/all"}.Encode()})
+	createChannel(t, model.NotificationChannel{Name: "failures", Type: ChannelWebhook, Enabled: true, Events: EventSubscriptionFailed, Config: ChannelConfig{URL: server.URL + "/failures"}.Encode()})
+	createChannel(t, model.NotificationChannel{Name: "disabled", Type: ChannelWebhook, Enabled: false, Config: ChannelConfig{URL: server.URL + "/disabled"}.Encode()})
+	createChannel(t, model.NotificationChannel{
+		Name: "templated", Type: ChannelNtfy, Enabled: true, Events: EventDownloadReady,
+		Template: "{{.EventLabel}}｜{{.Data.title}}", Config: ChannelConfig{URL: server.URL, Topic: "anime"}.Encode(),
+	})
+
+	deliveries := svc.Dispatch(context.Background(), Message{Event: EventDownloadReady, Title: "下载完成：芙莉莲", Body: "第 1 集", Data: map[string]interface{}{"title": "芙莉莲"}})
+	require.Len(t, deliveries, 2)
+
+	got := requests()
+	require.Len(t, got, 2)
+	assert.Equal(t, "/all", got[0].Path)
+	assert.Equal(t, "/anime", got[1].Path)
+	assert.Equal(t, "下载完成入库｜芙莉莲", got[1].Body)
+
+	history, err := store.NewNotificationStore(db.DB).ListDeliveries(0, 10)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	for _, row := range history {
+		assert.Equal(t, DeliveryStatusSent, row.Status)
+		assert.Equal(t, 1, row.Attempts)
+		assert.NotNil(t, row.DeliveredAt)
+	}
+	assert.Equal(t, "下载完成入库｜芙莉莲", history[0].Body)
+	assert.Empty(t, sleeps)
+}
+
+func TestDispatchRetriesTransientFailuresWithBackoff(t *testing.T) {
+	withNotifyTestDB(t)
+	server, requests := newStubServer(t, http.StatusBadGateway)
+	var sleeps []time.Duration
+	svc := newTestService(server.Client(), &sleeps)
+	createChannel(t, model.NotificationChannel{Name: "flaky", Type: ChannelWebhook, Enabled: true, Config: ChannelConfig{URL: server.URL}.Encode()})
+
+	deliveries := svc.Dispatch(context.Background(), Message{Event: EventLibraryIssue, Title: "媒体库问题"})
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, DeliveryStatusFailed, deliveries[0].Status)
+	assert.Equal(t, 3, deliveries[0].Attempts)
+	assert.Contains(t, deliveries[0].Error, "HTTP 502")
+	assert.Len(t, requests(), 3)
+	assert.Equal(t, []time.Duration{defaultBackoff, 2 * defaultBackoff}, sleeps)
+
+	history, err := store.NewNotificationStore(db.DB).ListDeliveries(deliveries[0].ChannelID, 10)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, DeliveryStatusFailed, history[0].Status)
+	assert.Equal(t, 3, history[0].Attempts)
+}
+
+func TestDispatchDoesNotRetryPermanentFailures(t *testing.T) {
+	withNotifyTestDB(t)
+	server, requests := newStubServer(t, http.StatusForbidden)
+	var sleeps []time.Duration
+	svc := newTestService(server.Client(), &sleeps)
+	createChannel(t, model.NotificationChannel{Name: "denied", Type: ChannelGotify, Enabled: true, Config: ChannelConfig{URL: server.URL, Token: "bad"}.Encode()})
+
+	deliveries := svc.Dispatch(context.Background(), Message{Event: EventUpdateAvailable, Title: "发现新版本"})
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+	assert.Len(t, requests(), 1)
+	assert.Empty(t, sleeps)
+}
+
+func TestSendTestIgnoresRoutingAndMarksDelivery(t *testing.T) {
+	withNotifyTestDB(t)
+	server, requests := newStubServer(t, http.StatusOK)
+	var sleeps []time.Duration
+	svc := newTestService(server.Client(), &sleeps)
+	channel := createChannel(t, model.NotificationChannel{Name: "tg", Type: ChannelTelegram, Enabled: false, Events: EventLibraryIssue, Config: ChannelConfig{URL: server.URL, Token: "1:a", ChatID: "42"}.Encode()})
+
+	delivery := svc.SendTest(context.Background(), channel)
+	assert.Equal(t, DeliveryStatusSent, delivery.Status)
+	assert.True(t, delivery.Test)
+	assert.Equal(t, EventTest, delivery.Event)
+	require.Len(t, requests(), 1)
+	assert.Equal(t, "/bot1:a/sendMessage", requests()[0].Path)
+}
+
+func TestMessageForMapsBusEvents(t *testing.T) {
+	var sleeps []time.Duration
+	svc := newTestService(nil, &sleeps)
+
+	msg, ok := svc.messageFor(event.Event{Type: event.EventSubscriptionRun, Payload: map[string]interface{}{
+		"title": "芙莉莲", "status": "success", "last_new_downloads": 2, "last_downloaded_title": "[SubGroup] 芙莉莲 - 05",
+	}})
+	require.True(t, ok)
+	assert.Equal(t, EventSubscriptionNew, msg.Event)
+	assert.Equal(t, "芙莉莲 新增 2 个下载", msg.Title)
+
+	msg, ok = svc.messageFor(event.Event{Type: event.EventSubscriptionRun, Payload: map[string]interface{}{
+		"title": "芙莉莲", "status": "error", "last_error": "RSS 超时",
+	}})
+	require.True(t, ok)
+	assert.Equal(t, EventSubscriptionFailed, msg.Event)
+	assert.Equal(t, "RSS 超时", msg.Body)
+
+	_, ok = svc.messageFor(event.Event{Type: event.EventSubscriptionRun, Payload: map[string]interface{}{"status": "success"}})
+	assert.False(t, ok, "quiet successful runs should not notify")
+	_, ok = svc.messageFor(event.Event{Type: event.EventLibraryIssue, Payload: map[string]interface{}{"status": "resolved"}})
+	assert.False(t, ok, "resolved issues should not notify")
+
+	update := event.Event{Type: event.EventUpdaterStatus, Payload: map[string]interface{}{"result": "behind", "current": "v1.0.0", "latest": "v1.1.0"}}
+	msg, ok = svc.messageFor(update)
+	require.True(t, ok)
+	assert.Equal(t, EventUpdateAvailable, msg.Event)
+	_, ok = svc.messageFor(update)
+	assert.False(t, ok, "the same release should only be announced once")
+	_, ok = svc.messageFor(event.Event{Type: event.EventUpdaterStatus, Payload: map[string]interface{}{"result": "up_to_date", "latest": "v1.1.0"}})
+	assert.False(t, ok)
+}
+
+func TestRenderBodyRejectsBrokenTemplates(t *testing.T) {
+	assert.Error(t, ValidateTemplate("{{.Title"))
+	body, err := RenderBody("", Message{Body: "默认正文"})
+	require.NoError(t, err)
+	assert.Equal(t, "默认正文", body)
+	body, err = RenderBody("{{.Title}} @ {{.Data.missing}}", Message{Title: "标题", Data: map[string]interface{}{}})
+	require.NoError(t, err)
+	assert.Equal(t, "标题 @", body)
+}
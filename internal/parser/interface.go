@@ -2,9 +2,14 @@ package parser
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+// ErrUnsupported is returned by RSS sources that only provide feeds and have
+// no search, subgroup or seasonal listing of their own.
+var ErrUnsupported = errors.New("not supported by this RSS source")
+
 // Episode 代表从 RSS 解析出的单集信息
 type Episode struct {
 	Title         string    `json:"title"`          // 原始标题
@@ -13,6 +18,8 @@ type Episode struct {
 	Season        string    `json:"season"`         // 季度 S01, S02...
 	Magnet        string    `json:"magnet"`         // 磁力链接
 	TorrentURL    string    `json:"torrent_url"`    // 种子文件链接
+	TorrentType   string    `json:"torrent_type"`   // TorrentURL 的 MIME 类型 (源在 enclosure 中声明时)
+	InfoHash      string    `json:"info_hash"`      // BT info hash (小写十六进制，源未提供时为空)
 	Size          string    `json:"size"`           // 文件大小 (格式化后)
 	PubDate       time.Time `json:"pub_date"`       // 发布时间
 	SubGroup      string    `json:"sub_group"`      // 字幕组
@@ -62,3 +69,10 @@ type ContextRSSParser interface {
 type TorrentFetcher interface {
 	FetchTorrentContext(ctx context.Context, rawURL string) (filename string, data []byte, err error)
 }
+
+// TorrentProber asks the server whether an HTTP link serves torrent metadata
+// without downloading it. Indexer links such as Jackett's /dl/ endpoint carry
+// no .torrent extension, so the URL alone cannot tell.
+type TorrentProber interface {
+	ProbeTorrentContext(ctx context.Context, rawURL string) (bool, error)
+}
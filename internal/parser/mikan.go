@@ -120,19 +120,30 @@ func (p *MikanParser) SetProxy(proxyURL string) error {
 }
 
 func (p *MikanParser) Name() string {
-	return "Mikan Project"
+	return MikanParserName
 }
 
 // FetchTorrentContext downloads a Mikan torrent using the same client as RSS
 // discovery, so a configured Mikan proxy is honored. qBittorrent can then
 // receive the file directly even when its own host cannot reach Mikan.
 func (p *MikanParser) FetchTorrentContext(ctx context.Context, rawURL string) (string, []byte, error) {
+	return fetchTorrentFile(ctx, p.client, rawURL, "mikan.torrent")
+}
+
+func (p *MikanParser) ProbeTorrentContext(ctx context.Context, rawURL string) (bool, error) {
+	return probeTorrentLink(ctx, p.client, rawURL)
+}
+
+// fetchTorrentFile downloads and sanity-checks a .torrent referenced by a feed
+// item. fallbackName is used when neither the response nor the URL carries a
+// usable filename.
+func fetchTorrentFile(ctx context.Context, client *resty.Client, rawURL, fallbackName string) (string, []byte, error) {
 	rawURL = strings.TrimSpace(rawURL)
 	if rawURL == "" {
 		return "", nil, fmt.Errorf("torrent URL is empty")
 	}
 
-	resp, err := httpx.NewRequest(ctx, p.client).
+	resp, err := httpx.NewRequest(ctx, client).
 		SetDoNotParseResponse(true).
 		Get(rawURL)
 	if err != nil {
@@ -143,7 +154,7 @@ func (p *MikanParser) FetchTorrentContext(ctx context.Context, rawURL string) (s
 	}
 	defer func() {
 		if closeErr := resp.RawBody().Close(); closeErr != nil {
-			log.Printf("Mikan torrent response close failed: %v", closeErr)
+			log.Printf("Torrent response close failed: %v", closeErr)
 		}
 	}()
 
@@ -169,10 +180,41 @@ func (p *MikanParser) FetchTorrentContext(ctx context.Context, rawURL string) (s
 		return "", nil, fmt.Errorf("download torrent: response is not a bencoded torrent file")
 	}
 
-	return torrentResponseFilename(rawURL, resp.Header()), data, nil
+	return torrentResponseFilename(rawURL, resp.Header(), fallbackName), data, nil
+}
+
+// probeTorrentLink sends a HEAD request and reports whether the response
+// describes a .torrent, either by Content-Type or by attachment filename.
+func probeTorrentLink(ctx context.Context, client *resty.Client, rawURL string) (bool, error) {
+	resp, err := httpx.NewRequest(ctx, client).Head(strings.TrimSpace(rawURL))
+	if err != nil {
+		return false, fmt.Errorf("probe torrent link: %w", err)
+	}
+	if resp.IsError() {
+		return false, fmt.Errorf("probe torrent link: unexpected status %s", resp.Status())
+	}
+	if IsTorrentMediaType(resp.Header().Get("Content-Type")) {
+		return true, nil
+	}
+	if disposition := resp.Header().Get("Content-Disposition"); disposition != "" {
+		if _, params, err := mime.ParseMediaType(disposition); err == nil {
+			return strings.HasSuffix(strings.ToLower(params["filename"]), ".torrent"), nil
+		}
+	}
+	return false, nil
+}
+
+// IsTorrentMediaType reports whether a MIME type (with optional parameters)
+// denotes torrent metadata.
+func IsTorrentMediaType(raw string) bool {
+	mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(raw))
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(raw))
+	}
+	return mediaType == "application/x-bittorrent"
 }
 
-func torrentResponseFilename(rawURL string, header http.Header) string {
+func torrentResponseFilename(rawURL string, header http.Header, fallbackName string) string {
 	filename := ""
 	if disposition := header.Get("Content-Disposition"); disposition != "" {
 		if _, params, err := mime.ParseMediaType(disposition); err == nil {
@@ -186,7 +228,7 @@ func torrentResponseFilename(rawURL string, header http.Header) string {
 	}
 	filename = path.Base(strings.ReplaceAll(strings.TrimSpace(filename), `\`, "/"))
 	if filename == "" || filename == "." || filename == "/" {
-		filename = "mikan.torrent"
+		filename = fallbackName
 	}
 	if !strings.HasSuffix(strings.ToLower(filename), ".torrent") {
 		filename += ".torrent"
@@ -204,7 +246,8 @@ type MikanRSS struct {
 			Link        string `xml:"link"`
 			Description string `xml:"description"`
 			Enclosure   struct {
-				URL string `xml:"url,attr"`
+				URL  string `xml:"url,attr"`
+				Type string `xml:"type,attr"`
 			} `xml:"enclosure"`
 			Torrent struct {
 				Link          string `xml:"link"`
@@ -236,6 +279,7 @@ func (p *MikanParser) ParseContext(ctx context.Context, url string) ([]Episode,
 		// 这里简单解析一下 Title
 		ep := ParseTitle(item.Title)
 		ep.TorrentURL = item.Enclosure.URL
+		ep.TorrentType = strings.TrimSpace(item.Enclosure.Type)
 
 		// Fix: 如果没有 Magnet，使用 TorrentURL 作为替代下载链接
 		// 很多 RSS 只提供 .torrent 下载地址，PikPak 等工具支持直接传入
@@ -243,16 +287,7 @@ func (p *MikanParser) ParseContext(ctx context.Context, url string) ([]Episode,
 			ep.Magnet = ep.TorrentURL
 		}
 
-		// Calculate Size
-		if item.Torrent.ContentLength > 0 {
-			// Simple formatting
-			sizeMB := float64(item.Torrent.ContentLength) / 1024 / 1024
-			if sizeMB > 1024 {
-				ep.Size = fmt.Sprintf("%.2f GB", sizeMB/1024)
-			} else {
-				ep.Size = fmt.Sprintf("%.2f MB", sizeMB)
-			}
-		}
+		ep.Size = formatEpisodeSize(item.Torrent.ContentLength)
 
 		// 处理时间
 		// Mikan usually puts PubDate inside the torrent element now?
@@ -261,23 +296,7 @@ func (p *MikanParser) ParseContext(ctx context.Context, url string) ([]Episode,
 		if rawDate == "" {
 			rawDate = item.Torrent.PubDate
 		}
-
-		// Try parsing multiple formats
-		formats := []string{
-			time.RFC1123Z,
-			"2006-01-02T15:04:05.999999", // From XML: 2025-10-28T20:40:03.684339
-			"2006-01-02T15:04:05.999",
-			time.RFC3339,
-		}
-
-		var t time.Time
-		for _, f := range formats {
-			if parsed, err := time.Parse(f, rawDate); err == nil {
-				t = parsed
-				break
-			}
-		}
-		ep.PubDate = t
+		ep.PubDate = parseFeedTime(rawDate)
 
 		episodes = append(episodes, ep)
 	}
@@ -285,6 +304,38 @@ func (p *MikanParser) ParseContext(ctx context.Context, url string) ([]Episode,
 	return episodes, nil
 }
 
+// feedTimeFormats covers RSS 2.0 dates, Mikan's zone-less timestamps and the
+// RFC 3339 dates some Torznab indexers emit.
+var feedTimeFormats = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"2006-01-02T15:04:05.999999", // From XML: 2025-10-28T20:40:03.684339
+	"2006-01-02T15:04:05.999",
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+}
+
+func parseFeedTime(raw string) time.Time {
+	raw = strings.TrimSpace(raw)
+	for _, f := range feedTimeFormats {
+		if parsed, err := time.Parse(f, raw); err == nil {
+			return parsed
+		}
+	}
+	return time.Time{}
+}
+
+func formatEpisodeSize(length int64) string {
+	if length <= 0 {
+		return ""
+	}
+	sizeMB := float64(length) / 1024 / 1024
+	if sizeMB > 1024 {
+		return fmt.Sprintf("%.2f GB", sizeMB/1024)
+	}
+	return fmt.Sprintf("%.2f MB", sizeMB)
+}
+
 // 简单的正则解析器 (初步实现，后续需增强)
 // 示例标题: [Moozzi2] Fate/stay night [Unlimited Blade Works] - 25 (BD 1920x1080 x264 Flac) TV-rip
 // [LoliHouse] 葬送的芙莉莲 / Sousou no Frieren - 28 [WebRip 1080p HEVC-10bit AAC][简繁内封字幕]
@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MikanParserName is the registry key of MikanParser and the parser used by
+// subscriptions that do not name one.
+const MikanParserName = "Mikan Project"
+
+// parserNames lists every parser a subscription can select, default first.
+// Keep it in sync with the parsers service.NewRSSParserRegistry registers;
+// validation and listings use it so they do not need to build HTTP clients.
+var parserNames = []string{MikanParserName, TorznabParserName}
+
+// ParserNames returns the selectable parser names, default first.
+func ParserNames() []string {
+	return append([]string(nil), parserNames...)
+}
+
+// CanonicalParserName returns the registered spelling of name, matched the
+// same forgiving way as Registry lookups.
+func CanonicalParserName(name string) (string, bool) {
+	key := registryKey(name)
+	for _, candidate := range parserNames {
+		if registryKey(candidate) == key {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// Registry looks up RSS parsers by their Name(). Lookups ignore case and
+// surrounding whitespace so stored subscription values stay forgiving.
+type Registry struct {
+	mu       sync.RWMutex
+	parsers  map[string]RSSParser
+	fallback string
+}
+
+// NewRegistry registers parsers in order; the first one becomes the fallback
+// for subscriptions without an explicit parser.
+func NewRegistry(parsers ...RSSParser) *Registry {
+	r := &Registry{parsers: make(map[string]RSSParser, len(parsers))}
+	for _, p := range parsers {
+		r.Register(p)
+	}
+	return r
+}
+
+func registryKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Register adds or replaces the parser stored under p.Name().
+func (r *Registry) Register(p RSSParser) {
+	if p == nil {
+		return
+	}
+	key := registryKey(p.Name())
+	if key == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.parsers[key] = p
+	if r.fallback == "" {
+		r.fallback = key
+	}
+}
+
+// Get returns the parser registered under name.
+func (r *Registry) Get(name string) (RSSParser, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.parsers[registryKey(name)]
+	return p, ok
+}
+
+// Resolve returns the parser for name, or the fallback parser when name is
+// empty. Unknown names are reported as missing instead of silently parsing a
+// feed with the wrong format.
+func (r *Registry) Resolve(name string) (RSSParser, bool) {
+	if r == nil {
+		return nil, false
+	}
+	if strings.TrimSpace(name) != "" {
+		return r.Get(name)
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.parsers[r.fallback]
+	return p, ok
+}
+
+// Names lists the registered parser names in a stable order.
+func (r *Registry) Names() []string {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.parsers))
+	for _, p := range r.parsers {
+		names = append(names, p.Name())
+	}
+	sort.Strings(names)
+	return names
+}
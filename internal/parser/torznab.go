@@ -0,0 +1,292 @@
+package parser
+
+import (
+	"context"
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/pokerjest/animateAutoTool/internal/httpx"
+)
+
+// TorznabParserName is the registry key of the generic feed parser.
+const TorznabParserName = "Torznab"
+
+var (
+	hexInfoHashPattern    = regexp.MustCompile(`(?i)^[0-9a-f]{40}$`)
+	base32InfoHashPattern = regexp.MustCompile(`(?i)^[a-z2-7]{32}$`)
+)
+
+// NormalizeInfoHash returns a BitTorrent v1 info hash as lowercase hex, the
+// form qBittorrent and Transmission report. Base32 hashes, which some magnets
+// use, are decoded. Anything else yields an empty string.
+func NormalizeInfoHash(raw string) string {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case hexInfoHashPattern.MatchString(raw):
+		return strings.ToLower(raw)
+	case base32InfoHashPattern.MatchString(raw):
+		decoded, err := base32.StdEncoding.DecodeString(strings.ToUpper(raw))
+		if err != nil {
+			return ""
+		}
+		return hex.EncodeToString(decoded)
+	default:
+		return ""
+	}
+}
+
+// TorznabParser reads plain RSS 2.0 torrent feeds (nyaa.si, acg.rip, dmhy)
+// as well as Torznab/Newznab results from Jackett or Prowlarr. Release titles
+// go through ParseTitle, so episode, season and subgroup detection matches
+// Mikan subscriptions.
+type TorznabParser struct {
+	client *resty.Client
+}
+
+type torznabFeed struct {
+	Channel struct {
+		Items []torznabItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+// torznabItem leaves namespaces off on purpose: encoding/xml then matches
+// torznab:attr and newznab:attr alike, and nyaa:infoHash / nyaa:size by their
+// local names.
+type torznabItem struct {
+	Title     string `xml:"title"`
+	Link      string `xml:"link"`
+	GUID      string `xml:"guid"`
+	PubDate   string `xml:"pubDate"`
+	Enclosure struct {
+		URL    string `xml:"url,attr"`
+		Length int64  `xml:"length,attr"`
+		Type   string `xml:"type,attr"`
+	} `xml:"enclosure"`
+	Attrs []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value,attr"`
+	} `xml:"attr"`
+	InfoHash string   `xml:"infoHash"`
+	Sizes    []string `xml:"size"`
+}
+
+func NewTorznabParser() *TorznabParser {
+	client := httpx.NewRestyClient(15*time.Second, "", nil).
+		SetRetryCount(2).
+		SetRetryWaitTime(250 * time.Millisecond).
+		SetRetryMaxWaitTime(time.Second).
+		AddRetryCondition(func(response *resty.Response, err error) bool {
+			if err != nil {
+				return true
+			}
+			if response == nil {
+				return false
+			}
+			return response.StatusCode() == http.StatusTooManyRequests || response.StatusCode() >= http.StatusInternalServerError
+		})
+	return &TorznabParser{client: client}
+}
+
+// SetProxy sets the proxy used for feed and torrent requests.
+func (p *TorznabParser) SetProxy(proxyURL string) error {
+	normalized, err := httpx.NormalizeProxyURL(proxyURL)
+	if err != nil {
+		return err
+	}
+	if normalized == "" {
+		p.client.RemoveProxy()
+		return nil
+	}
+	p.client.SetProxy(normalized)
+	return nil
+}
+
+func (p *TorznabParser) Name() string {
+	return TorznabParserName
+}
+
+func (p *TorznabParser) Parse(url string) ([]Episode, error) {
+	return p.ParseContext(context.Background(), url)
+}
+
+func (p *TorznabParser) ParseContext(ctx context.Context, feedURL string) ([]Episode, error) {
+	resp, err := httpx.NewRequest(ctx, p.client).Get(feedURL)
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("rss request returned HTTP %d", resp.StatusCode())
+	}
+	return parseTorznabFeed(resp.Body())
+}
+
+func parseTorznabFeed(body []byte) ([]Episode, error) {
+	var feed torznabFeed
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return nil, fmt.Errorf("xml unmarshal error: %v", err)
+	}
+
+	episodes := make([]Episode, 0, len(feed.Channel.Items))
+	for _, item := range feed.Channel.Items {
+		title := strings.TrimSpace(item.Title)
+		if title == "" {
+			continue
+		}
+		ep := ParseTitle(title)
+		ep.Season = SeasonNumberFromTitle(title)
+		ep.PubDate = parseFeedTime(item.PubDate)
+		ep.InfoHash = item.infoHash()
+		ep.Size = item.size()
+
+		magnet, torrentURL := item.links()
+		if magnet == "" && ep.InfoHash != "" {
+			magnet = "magnet:?xt=urn:btih:" + ep.InfoHash + "&dn=" + url.QueryEscape(title)
+		}
+		// An explicit magnet wins over the indexer download link: Jackett and
+		// Prowlarr answer that link with a redirect to the magnet for
+		// magnet-only trackers, which cannot be fetched as a .torrent.
+		if magnet == "" || item.attr("magneturl") == "" {
+			ep.TorrentURL = torrentURL
+			if torrentURL == strings.TrimSpace(item.Enclosure.URL) {
+				ep.TorrentType = strings.TrimSpace(item.Enclosure.Type)
+			}
+		}
+		ep.Magnet = magnet
+		if ep.Magnet == "" {
+			ep.Magnet = ep.TorrentURL
+		}
+		if ep.Magnet == "" {
+			continue
+		}
+		episodes = append(episodes, ep)
+	}
+	return episodes, nil
+}
+
+func (item torznabItem) attr(name string) string {
+	for _, attr := range item.Attrs {
+		if strings.EqualFold(strings.TrimSpace(attr.Name), name) {
+			return strings.TrimSpace(attr.Value)
+		}
+	}
+	return ""
+}
+
+// links returns the magnet and the HTTP torrent link advertised by the item.
+// dmhy puts the magnet into the enclosure, nyaa links the .torrent directly
+// and Torznab carries both the enclosure and a magneturl attribute.
+func (item torznabItem) links() (magnet string, torrentURL string) {
+	magnet = item.attr("magneturl")
+	for _, candidate := range []string{item.Enclosure.URL, item.Link, item.GUID} {
+		candidate = strings.TrimSpace(candidate)
+		switch {
+		case candidate == "":
+		case strings.HasPrefix(strings.ToLower(candidate), "magnet:"):
+			if magnet == "" {
+				magnet = candidate
+			}
+		case torrentURL == "" && isTorrentLink(candidate, candidate == strings.TrimSpace(item.Enclosure.URL)):
+			torrentURL = candidate
+		}
+	}
+	return magnet, torrentURL
+}
+
+// isTorrentLink accepts any HTTP enclosure, but only plain links that clearly
+// point to a .torrent; item links are usually the release's web page.
+func isTorrentLink(raw string, enclosure bool) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return false
+	}
+	return enclosure || strings.HasSuffix(strings.ToLower(parsed.Path), ".torrent")
+}
+
+func (item torznabItem) infoHash() string {
+	for _, candidate := range []string{item.attr("infohash"), item.InfoHash, magnetInfoHash(item.attr("magneturl")), magnetInfoHash(item.Enclosure.URL)} {
+		if hash := NormalizeInfoHash(candidate); hash != "" {
+			return hash
+		}
+	}
+	return ""
+}
+
+func magnetInfoHash(raw string) string {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || !strings.EqualFold(parsed.Scheme, "magnet") {
+		return ""
+	}
+	for _, xt := range parsed.Query()["xt"] {
+		if hash, ok := strings.CutPrefix(strings.ToLower(xt), "urn:btih:"); ok {
+			return hash
+		}
+	}
+	return ""
+}
+
+// size prefers byte counts (Torznab attribute, enclosure length, Jackett's
+// <size>) and falls back to a preformatted string such as nyaa's "1.2 GiB".
+func (item torznabItem) size() string {
+	if value, err := strconv.ParseInt(item.attr("size"), 10, 64); err == nil && value > 0 {
+		return formatEpisodeSize(value)
+	}
+	if item.Enclosure.Length > 0 {
+		return formatEpisodeSize(item.Enclosure.Length)
+	}
+	for _, raw := range item.Sizes {
+		raw = strings.TrimSpace(raw)
+		if value, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			if value > 0 {
+				return formatEpisodeSize(value)
+			}
+			continue
+		}
+		if raw != "" {
+			return raw
+		}
+	}
+	return ""
+}
+
+// FetchTorrentContext downloads a .torrent through the feed client so the
+// configured proxy also applies to indexer download links.
+func (p *TorznabParser) FetchTorrentContext(ctx context.Context, rawURL string) (string, []byte, error) {
+	return fetchTorrentFile(ctx, p.client, rawURL, "release.torrent")
+}
+
+func (p *TorznabParser) ProbeTorrentContext(ctx context.Context, rawURL string) (bool, error) {
+	return probeTorrentLink(ctx, p.client, rawURL)
+}
+
+func (p *TorznabParser) Search(keyword string) ([]SearchResult, error) {
+	return p.SearchContext(context.Background(), keyword)
+}
+
+func (p *TorznabParser) SearchContext(context.Context, string) ([]SearchResult, error) {
+	return nil, fmt.Errorf("torznab search: %w", ErrUnsupported)
+}
+
+func (p *TorznabParser) GetSubgroups(bangumiID string) ([]Subgroup, error) {
+	return p.GetSubgroupsContext(context.Background(), bangumiID)
+}
+
+func (p *TorznabParser) GetSubgroupsContext(context.Context, string) ([]Subgroup, error) {
+	return nil, fmt.Errorf("torznab subgroups: %w", ErrUnsupported)
+}
+
+func (p *TorznabParser) GetDashboard(year, season string) (*MikanDashboard, error) {
+	return p.GetDashboardContext(context.Background(), year, season)
+}
+
+func (p *TorznabParser) GetDashboardContext(context.Context, string, string) (*MikanDashboard, error) {
+	return nil, fmt.Errorf("torznab dashboard: %w", ErrUnsupported)
+}
@@ -0,0 +1,168 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const torznabTestFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:torznab="http://torznab.com/schemas/2015/feed" xmlns:nyaa="https://nyaa.si/xmlns/nyaa">
+	<channel>
+		<item>
+			<title>[SubsPlease] Sousou no Frieren S2 - 05 (1080p) [ABCD1234].mkv</title>
+			<link>https://nyaa.si/download/1900001.torrent</link>
+			<guid isPermaLink="true">https://nyaa.si/view/1900001</guid>
+			<pubDate>Fri, 16 Oct 2026 18:01:02 -0000</pubDate>
+			<nyaa:infoHash>0123456789ABCDEF0123456789ABCDEF01234567</nyaa:infoHash>
+			<nyaa:size>1.4 GiB</nyaa:size>
+		</item>
+		<item>
+			<title>[LoliHouse] 葬送的芙莉莲 / Sousou no Frieren - 28 [WebRip 1080p HEVC-10bit AAC]</title>
+			<link>https://jackett.local/dl/dmhy/?jackett_apikey=k&amp;path=abc</link>
+			<pubDate>Thu, 15 Oct 2026 12:00:00 +0800</pubDate>
+			<enclosure url="https://jackett.local/dl/dmhy/?jackett_apikey=k&amp;path=abc" length="2147483648" type="application/x-bittorrent"/>
+			<torznab:attr name="magneturl" value="magnet:?xt=urn:btih:89abcdef0123456789abcdef0123456789abcdef&amp;dn=frieren"/>
+			<torznab:attr name="size" value="3221225472"/>
+		</item>
+		<item>
+			<title>[ANi] 测试番剧 - 03 [1080P]</title>
+			<link>https://share.dmhy.org/topics/view/1.html</link>
+			<pubDate>Wed, 14 Oct 2026 08:00:00 +0800</pubDate>
+			<enclosure url="magnet:?xt=urn:btih:fedcba9876543210fedcba9876543210fedcba98" length="1" type="application/x-bittorrent"/>
+		</item>
+		<item>
+			<title>[Group] Page Only - 04</title>
+			<link>https://acg.rip/t/1</link>
+		</item>
+	</channel>
+</rss>`
+
+func TestTorznabParseFeed(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		_, _ = w.Write([]byte(torznabTestFeed))
+	}))
+	defer server.Close()
+
+	episodes, err := NewTorznabParser().Parse(server.URL)
+	if err != nil {
+		t.Fatalf("parse feed failed: %v", err)
+	}
+	if len(episodes) != 3 {
+		t.Fatalf("expected items without any download link to be skipped, got %d: %+v", len(episodes), episodes)
+	}
+
+	nyaa := episodes[0]
+	if nyaa.SubGroup != "SubsPlease" || nyaa.EpisodeNum != "05" || nyaa.Season != "2" || nyaa.Resolution != "1080p" {
+		t.Fatalf("unexpected title fields: %+v", nyaa)
+	}
+	if nyaa.InfoHash != "0123456789abcdef0123456789abcdef01234567" || nyaa.Size != "1.4 GiB" {
+		t.Fatalf("unexpected nyaa hash or size: %+v", nyaa)
+	}
+	if nyaa.TorrentURL != "https://nyaa.si/download/1900001.torrent" || !strings.HasPrefix(nyaa.Magnet, "magnet:?xt=urn:btih:0123456789abcdef") {
+		t.Fatalf("expected torrent link plus hash magnet: %+v", nyaa)
+	}
+	if nyaa.PubDate.IsZero() || nyaa.PubDate.Day() != 16 {
+		t.Fatalf("unexpected pub date: %v", nyaa.PubDate)
+	}
+
+	torznab := episodes[1]
+	if torznab.TorrentURL != "" || !strings.Contains(torznab.Magnet, "89abcdef0123456789abcdef0123456789abcdef") {
+		t.Fatalf("expected the magneturl attribute to win over the indexer link: %+v", torznab)
+	}
+	if torznab.InfoHash != "89abcdef0123456789abcdef0123456789abcdef" || torznab.Size != "3.00 GB" || torznab.EpisodeNum != "28" {
+		t.Fatalf("unexpected torznab mapping: %+v", torznab)
+	}
+
+	dmhy := episodes[2]
+	if dmhy.TorrentURL != "" || !strings.HasPrefix(dmhy.Magnet, "magnet:?xt=urn:btih:fedcba") || dmhy.InfoHash != "fedcba9876543210fedcba9876543210fedcba98" {
+		t.Fatalf("expected enclosure magnet to be used: %+v", dmhy)
+	}
+	if dmhy.SubGroup != mikanTestSubgroupANi || dmhy.EpisodeNum != "03" {
+		t.Fatalf("unexpected dmhy title fields: %+v", dmhy)
+	}
+}
+
+func TestNormalizeInfoHashDecodesBase32(t *testing.T) {
+	t.Parallel()
+
+	if got := NormalizeInfoHash("aerukz4jvpg66ajdivtytk6n54asgrlh"); got != "0123456789abcdef0123456789abcdef01234567" {
+		t.Fatalf("expected base32 hash to be converted to hex, got %q", got)
+	}
+	if got := NormalizeInfoHash(" 0123456789ABCDEF0123456789ABCDEF01234567 "); got != "0123456789abcdef0123456789abcdef01234567" {
+		t.Fatalf("expected hex hash to be lowercased, got %q", got)
+	}
+	if got := NormalizeInfoHash("not-a-hash"); got != "" {
+		t.Fatalf("expected invalid hash to be dropped, got %q", got)
+	}
+}
+
+func TestTorznabParseRejectsHTTPErrors(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "invalid api key", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	if _, err := NewTorznabParser().Parse(server.URL); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected HTTP status error, got %v", err)
+	}
+}
+
+func TestTorznabDiscoveryIsUnsupported(t *testing.T) {
+	t.Parallel()
+
+	p := NewTorznabParser()
+	if _, err := p.SearchContext(context.Background(), "frieren"); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected unsupported search, got %v", err)
+	}
+	if _, err := p.GetDashboard("2026", "秋"); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected unsupported dashboard, got %v", err)
+	}
+}
+
+func TestRegistryResolvesByName(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(NewMikanParser(), NewTorznabParser())
+	if p, ok := registry.Resolve(""); !ok || p.Name() != MikanParserName {
+		t.Fatalf("expected Mikan as fallback, got %v", p)
+	}
+	if p, ok := registry.Get(" torznab "); !ok || p.Name() != TorznabParserName {
+		t.Fatalf("expected case-insensitive lookup, got %v", p)
+	}
+	if _, ok := registry.Resolve("unknown"); ok {
+		t.Fatal("unknown parser names must not resolve to the fallback")
+	}
+	if names := registry.Names(); len(names) != 2 || names[0] != MikanParserName || names[1] != TorznabParserName {
+		t.Fatalf("unexpected names: %v", names)
+	}
+}
+
+func TestCanonicalParserNameMatchesRegistry(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(NewMikanParser(), NewTorznabParser())
+	names := ParserNames()
+	if got := registry.Names(); len(got) != len(names) {
+		t.Fatalf("static parser names %v out of sync with registry %v", names, got)
+	}
+	for _, name := range names {
+		if _, ok := registry.Get(name); !ok {
+			t.Fatalf("static parser name %q is not registered", name)
+		}
+	}
+	if name, ok := CanonicalParserName(" TORZNAB "); !ok || name != TorznabParserName {
+		t.Fatalf("unexpected canonical name %q ok=%v", name, ok)
+	}
+	if _, ok := CanonicalParserName("nyaa"); ok {
+		t.Fatal("unknown parser names must be rejected")
+	}
+}
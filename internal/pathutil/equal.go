@@ -11,3 +11,18 @@ func Equal(a, b string) bool {
 	cleanB := filepath.Clean(strings.TrimSpace(b))
 	return equalClean(cleanA, cleanB)
 }
+
+// Within reports whether path is root or lies below it, using the current
+// platform's case rules.
+func Within(path, root string) bool {
+	cleanPath := filepath.Clean(strings.TrimSpace(path))
+	cleanRoot := filepath.Clean(strings.TrimSpace(root))
+	if equalClean(cleanPath, cleanRoot) {
+		return true
+	}
+	prefix := cleanRoot
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+	return len(cleanPath) > len(prefix) && equalClean(cleanPath[:len(prefix)], prefix)
+}
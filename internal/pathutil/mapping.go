@@ -0,0 +1,226 @@
+package pathutil
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const mappingArrow = "=>"
+
+// ErrNoMapping is returned by Match when no configured prefix covers a path.
+var ErrNoMapping = errors.New("no path mapping matches")
+
+// Mapping translates one downloader-side path prefix to the path AnimateTool
+// sees for the same directory, for example a Docker volume or an SMB share.
+type Mapping struct {
+	Remote string `json:"remote"`
+	Local  string `json:"local"`
+}
+
+// Mapper applies remote/local prefix mappings. A nil or empty Mapper leaves
+// every path unchanged, which is the behaviour without any configuration.
+type Mapper struct {
+	byRemote []Mapping
+	byLocal  []Mapping
+}
+
+// ParseMappings reads "remote => local" entries separated by newlines or
+// semicolons. Remote paths may use Windows or POSIX syntax regardless of the
+// host running AnimateTool; local paths must be absolute on this host.
+func ParseMappings(raw string) ([]Mapping, error) {
+	mappings := make([]Mapping, 0)
+	seen := make(map[string]struct{})
+	for _, entry := range strings.FieldsFunc(raw, func(r rune) bool { return r == '\n' || r == ';' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		remote, local, ok := strings.Cut(entry, mappingArrow)
+		if !ok {
+			return nil, fmt.Errorf("路径映射 %q 缺少 =>，格式应为 远程路径 => 本地路径", entry)
+		}
+		mapping := Mapping{Remote: cleanRemote(remote), Local: strings.TrimSpace(local)}
+		if mapping.Remote == "" || mapping.Local == "" {
+			return nil, fmt.Errorf("路径映射 %q 的远程路径和本地路径都不能为空", entry)
+		}
+		if !isAbsoluteRemote(mapping.Remote) {
+			return nil, fmt.Errorf("远程路径 %q 必须是绝对路径", mapping.Remote)
+		}
+		if !filepath.IsAbs(mapping.Local) {
+			return nil, fmt.Errorf("本地路径 %q 必须是绝对路径", mapping.Local)
+		}
+		mapping.Local = filepath.Clean(mapping.Local)
+		key := remoteKey(mapping.Remote, isWindowsRemote(mapping.Remote))
+		if _, exists := seen[key]; exists {
+			return nil, fmt.Errorf("远程路径 %q 重复配置", mapping.Remote)
+		}
+		seen[key] = struct{}{}
+		mappings = append(mappings, mapping)
+	}
+	return mappings, nil
+}
+
+// FormatMappings renders mappings in the form accepted by ParseMappings.
+func FormatMappings(mappings []Mapping) string {
+	entries := make([]string, 0, len(mappings))
+	for _, mapping := range mappings {
+		entries = append(entries, mapping.Remote+" "+mappingArrow+" "+mapping.Local)
+	}
+	return strings.Join(entries, "; ")
+}
+
+// NewMapper orders mappings so the most specific prefix wins in both directions.
+func NewMapper(mappings []Mapping) *Mapper {
+	mapper := &Mapper{
+		byRemote: append([]Mapping(nil), mappings...),
+		byLocal:  append([]Mapping(nil), mappings...),
+	}
+	sort.SliceStable(mapper.byRemote, func(i, j int) bool {
+		return len(mapper.byRemote[i].Remote) > len(mapper.byRemote[j].Remote)
+	})
+	sort.SliceStable(mapper.byLocal, func(i, j int) bool {
+		return len(mapper.byLocal[i].Local) > len(mapper.byLocal[j].Local)
+	})
+	return mapper
+}
+
+// Empty reports whether the mapper has no rules.
+func (m *Mapper) Empty() bool {
+	return m == nil || len(m.byRemote) == 0
+}
+
+// Match returns the mapping whose remote prefix covers remote.
+func (m *Mapper) Match(remote string) (Mapping, error) {
+	if m != nil {
+		for _, mapping := range m.byRemote {
+			if _, ok := trimRemotePrefix(remote, mapping.Remote); ok {
+				return mapping, nil
+			}
+		}
+	}
+	return Mapping{}, ErrNoMapping
+}
+
+// ToLocal turns a path reported by the downloader into the path on this host.
+// Paths outside every mapping are returned unchanged.
+func (m *Mapper) ToLocal(remote string) string {
+	if m.Empty() || strings.TrimSpace(remote) == "" {
+		return remote
+	}
+	for _, mapping := range m.byRemote {
+		rest, ok := trimRemotePrefix(remote, mapping.Remote)
+		if !ok {
+			continue
+		}
+		if rest == "" {
+			return mapping.Local
+		}
+		return filepath.Join(mapping.Local, filepath.FromSlash(rest))
+	}
+	return remote
+}
+
+// ToRemote turns a path on this host into the path the downloader should use.
+// Paths outside every mapping are returned unchanged, so settings that already
+// hold downloader-side paths keep working.
+func (m *Mapper) ToRemote(local string) string {
+	if m.Empty() || strings.TrimSpace(local) == "" {
+		return local
+	}
+	for _, mapping := range m.byLocal {
+		if !Within(local, mapping.Local) {
+			continue
+		}
+		rel, err := filepath.Rel(mapping.Local, filepath.Clean(strings.TrimSpace(local)))
+		if err != nil {
+			continue
+		}
+		if rel == "." {
+			return mapping.Remote
+		}
+		rel = filepath.ToSlash(rel)
+		if isWindowsRemote(mapping.Remote) {
+			return strings.TrimRight(mapping.Remote, `\`) + `\` + strings.ReplaceAll(rel, "/", `\`)
+		}
+		return strings.TrimRight(mapping.Remote, "/") + "/" + rel
+	}
+	return local
+}
+
+// trimRemotePrefix reports whether remote lies under prefix and returns the
+// remaining slash-separated part. Windows-style remotes compare
+// case-insensitively because the downloader's filesystem does.
+func trimRemotePrefix(remote, prefix string) (string, bool) {
+	windows := isWindowsRemote(prefix)
+	path := remoteKey(remote, false)
+	root := remoteKey(prefix, false)
+	if path == "" || root == "" {
+		return "", false
+	}
+	equal := func(a, b string) bool {
+		if windows {
+			return strings.EqualFold(a, b)
+		}
+		return a == b
+	}
+	if equal(path, root) {
+		return "", true
+	}
+	boundary := root
+	if !strings.HasSuffix(boundary, "/") {
+		boundary += "/"
+	}
+	if len(path) > len(boundary) && equal(path[:len(boundary)], boundary) {
+		return path[len(boundary):], true
+	}
+	return "", false
+}
+
+// remoteKey normalises separators and duplicate or trailing slashes. The
+// filesystem root "/" and drive roots such as "C:/" keep their slash.
+func remoteKey(value string, fold bool) string {
+	value = strings.ReplaceAll(strings.TrimSpace(value), `\`, "/")
+	unc := strings.HasPrefix(value, "//")
+	for strings.Contains(value, "//") {
+		value = strings.ReplaceAll(value, "//", "/")
+	}
+	if unc {
+		value = "/" + value
+	}
+	if len(value) > 1 && !(len(value) == 3 && value[1] == ':') {
+		value = strings.TrimRight(value, "/")
+	}
+	if fold {
+		value = strings.ToLower(value)
+	}
+	return value
+}
+
+func cleanRemote(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	if isWindowsRemote(value) {
+		return strings.ReplaceAll(remoteKey(value, false), "/", `\`)
+	}
+	return remoteKey(value, false)
+}
+
+func isWindowsRemote(value string) bool {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, `\\`) {
+		return true
+	}
+	return len(value) >= 2 && value[1] == ':' && (value[0]|0x20 >= 'a' && value[0]|0x20 <= 'z')
+}
+
+func isAbsoluteRemote(value string) bool {
+	if isWindowsRemote(value) {
+		return strings.HasPrefix(value, `\\`) || (len(value) >= 3 && (value[2] == '\\' || value[2] == '/'))
+	}
+	return strings.HasPrefix(value, "/")
+}
@@ -0,0 +1,74 @@
+//go:build !windows
+
+package pathutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMappingsNormalisesEntries(t *testing.T) {
+	mappings, err := ParseMappings("/downloads/ => /mnt/nas/downloads\n C:\\Torrents\\ => /srv/win ; \\\\nas\\anime => /mnt/anime")
+	require.NoError(t, err)
+	assert.Equal(t, []Mapping{
+		{Remote: "/downloads", Local: "/mnt/nas/downloads"},
+		{Remote: `C:\Torrents`, Local: "/srv/win"},
+		{Remote: `\\nas\anime`, Local: "/mnt/anime"},
+	}, mappings)
+	assert.Equal(t, `/downloads => /mnt/nas/downloads; C:\Torrents => /srv/win; \\nas\anime => /mnt/anime`, FormatMappings(mappings))
+
+	empty, err := ParseMappings("  ")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	for _, raw := range []string{
+		"/downloads",
+		"/downloads => ",
+		"downloads => /mnt/downloads",
+		"/downloads => mnt/downloads",
+		"/downloads => /a; /downloads/ => /b",
+		`C:\x => /a; c:/X => /b`,
+	} {
+		_, err := ParseMappings(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestMapperTranslatesBothDirections(t *testing.T) {
+	mappings, err := ParseMappings("/downloads => /mnt/nas/downloads; /downloads/anime => /media/anime; D:\\Anime => /srv/anime")
+	require.NoError(t, err)
+	mapper := NewMapper(mappings)
+
+	assert.Equal(t, "/media/anime/Show/01.mkv", mapper.ToLocal("/downloads/anime/Show/01.mkv"), "longest prefix wins")
+	assert.Equal(t, "/mnt/nas/downloads/other", mapper.ToLocal("/downloads//other/"))
+	assert.Equal(t, "/mnt/nas/downloads", mapper.ToLocal("/downloads"))
+	assert.Equal(t, "/downloadsX/file.mkv", mapper.ToLocal("/downloadsX/file.mkv"), "prefix must end on a separator")
+	assert.Equal(t, "/Downloads/file.mkv", mapper.ToLocal("/Downloads/file.mkv"), "POSIX remotes are case-sensitive")
+	assert.Equal(t, "/srv/anime/Show/S01/01.mkv", mapper.ToLocal(`d:\anime\Show\S01\01.mkv`), "Windows remotes ignore case and separators")
+
+	assert.Equal(t, "/downloads/anime/Show", mapper.ToRemote("/media/anime/Show"))
+	assert.Equal(t, `D:\Anime\Show\S01`, mapper.ToRemote("/srv/anime/Show/S01"))
+	assert.Equal(t, `D:\Anime`, mapper.ToRemote("/srv/anime/"))
+	assert.Equal(t, "/downloads/anime/x", mapper.ToRemote("/downloads/anime/x"), "paths already on the downloader side pass through")
+
+	mapping, err := mapper.Match(`D:/Anime/x.mkv`)
+	require.NoError(t, err)
+	assert.Equal(t, "/srv/anime", mapping.Local)
+	_, err = mapper.Match("/elsewhere")
+	assert.ErrorIs(t, err, ErrNoMapping)
+
+	var identity *Mapper
+	assert.True(t, identity.Empty())
+	assert.Equal(t, `C:\x`, identity.ToLocal(`C:\x`))
+	assert.Equal(t, "/x", identity.ToRemote("/x"))
+}
+
+func TestWithinRespectsSeparatorBoundaries(t *testing.T) {
+	assert.True(t, Within("/media/anime", "/media/anime/"))
+	assert.True(t, Within("/media/anime/Show", "/media/anime"))
+	assert.True(t, Within("/media", "/"))
+	assert.False(t, Within("/media/animeX", "/media/anime"))
+	assert.False(t, Within("/media/Anime/Show", "/media/anime"))
+}
@@ -6,8 +6,10 @@ import (
 
 	"github.com/pokerjest/animateAutoTool/internal/bootstrap"
 	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/downloader"
 	"github.com/pokerjest/animateAutoTool/internal/launcher"
 	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/store"
 	"gorm.io/gorm"
 )
 
@@ -23,6 +25,9 @@ type Config struct {
 	Username string
 	Password string
 	Mode     string
+	// Backend selects the downloader implementation. Only qBittorrent has a
+	// managed instance; every other backend is always external.
+	Backend string
 }
 
 func LoadConfig() Config {
@@ -33,7 +38,8 @@ func LoadConfig() Config {
 // handle. It is used by repair --dry-run with an isolated read-only handle.
 func LoadConfigFromDB(database *gorm.DB) Config {
 	cfg := Config{
-		Mode: ModeManaged,
+		Mode:    ModeManaged,
+		Backend: downloader.BackendQBittorrent,
 	}
 
 	if database == nil {
@@ -42,23 +48,25 @@ func LoadConfigFromDB(database *gorm.DB) Config {
 	}
 
 	rawURL := ""
-	var configs []model.GlobalConfig
-	if err := database.Find(&configs).Error; err != nil {
+	configs, err := store.NewConfigStore(database).ListMap()
+	if err != nil {
 		log.Printf("Error fetching QB config: %v", err)
 		cfg.URL = DefaultURL
 		return cfg
 	}
 
-	for _, item := range configs {
-		switch item.Key {
+	for key, value := range configs {
+		switch key {
 		case model.ConfigKeyQBUrl:
-			rawURL = strings.TrimSpace(item.Value)
+			rawURL = strings.TrimSpace(value)
 		case model.ConfigKeyQBUsername:
-			cfg.Username = strings.TrimSpace(item.Value)
+			cfg.Username = strings.TrimSpace(value)
 		case model.ConfigKeyQBPassword:
-			cfg.Password = strings.TrimSpace(item.Value)
+			cfg.Password = strings.TrimSpace(value)
 		case model.ConfigKeyQBMode:
-			cfg.Mode = NormalizeMode(item.Value)
+			cfg.Mode = NormalizeMode(value)
+		case model.ConfigKeyDownloaderBackend:
+			cfg.Backend = downloader.NormalizeBackend(value)
 		}
 	}
 
@@ -106,7 +114,7 @@ func IsManagedLocalURL(raw string) bool {
 }
 
 func UsesManagedInstance(cfg Config) bool {
-	if NormalizeMode(cfg.Mode) == ModeExternal {
+	if NormalizeMode(cfg.Mode) == ModeExternal || downloader.NormalizeBackend(cfg.Backend) != downloader.BackendQBittorrent {
 		return false
 	}
 
@@ -114,7 +122,12 @@ func UsesManagedInstance(cfg Config) bool {
 }
 
 func MissingExternalURL(cfg Config) bool {
-	return NormalizeMode(cfg.Mode) == ModeExternal && strings.TrimSpace(cfg.URL) == ""
+	return !UsesManagedInstance(cfg) && strings.TrimSpace(cfg.URL) == ""
+}
+
+// NewClient builds the configured downloader backend for cfg.
+func NewClient(cfg Config) downloader.Client {
+	return downloader.NewClient(cfg.Backend, cfg.URL)
 }
 
 func ManagedBinaryMissing(cfg Config, binDir string) bool {
@@ -7,6 +7,7 @@ import (
 	"github.com/pokerjest/animateAutoTool/internal/bootstrap"
 	"github.com/pokerjest/animateAutoTool/internal/config"
 	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/downloader"
 	"github.com/pokerjest/animateAutoTool/internal/model"
 )
 
@@ -105,3 +106,19 @@ func TestLoadConfigUsesManagedBootstrapCredentials(t *testing.T) {
 		t.Fatalf("expected managed bootstrap credentials, got %+v", cfg)
 	}
 }
+
+func TestTransmissionBackendIsAlwaysExternal(t *testing.T) {
+	cfg := Config{Mode: ModeManaged, Backend: "transmission"}
+	if UsesManagedInstance(cfg) {
+		t.Fatal("transmission must never use the managed qBittorrent instance")
+	}
+	if !MissingExternalURL(cfg) {
+		t.Fatal("expected transmission without an RPC URL to be flagged")
+	}
+	if ManagedBinaryMissing(cfg, t.TempDir()) {
+		t.Fatal("transmission should not require the managed qBittorrent binary")
+	}
+	if _, ok := NewClient(Config{Backend: "transmission", URL: "http://nas:9091"}).(*downloader.TransmissionClient); !ok {
+		t.Fatal("expected NewClient to build a transmission client")
+	}
+}
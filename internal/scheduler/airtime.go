@@ -0,0 +1,226 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/bangumi"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+)
+
+// 调度原因代码，写入 SubscriptionRunLog.ScheduleReason。
+const (
+	ScheduleReasonManual          = "manual"
+	ScheduleReasonFirstCheck      = "first_check"
+	ScheduleReasonInterval        = "interval"
+	ScheduleReasonRecentlyChecked = "recently_checked"
+	ScheduleReasonReleaseWindow   = "release_window"
+	ScheduleReasonOffWindow       = "off_window"
+	ScheduleReasonNotAired        = "not_aired"
+	ScheduleReasonFinished        = "finished"
+	ScheduleReasonQuietHours      = "quiet_hours"
+)
+
+const (
+	// releaseWindow 是预计放送后高频检查的时长。日历只提供放送日，深夜档常在次日凌晨播出，
+	// 字幕组也需要时间压制，因此从放送日零点起覆盖一天半。
+	releaseWindow = 36 * time.Hour
+	// offWindowInterval 是非放送时段、已完结和未开播订阅的检查间隔。
+	offWindowInterval = 6 * time.Hour
+	// airDateFallbackWindow 之外的 AirDate 不再用于推算放送日，长期连载往往已经改过档期。
+	airDateFallbackWindow = 366 * 24 * time.Hour
+
+	calendarRefreshInterval = 12 * time.Hour
+	calendarRetryInterval   = time.Hour
+	calendarFetchTimeout    = 10 * time.Second
+
+	airSourceBangumiCalendar = "bangumi_calendar"
+	airSourceAirDate         = "air_date"
+)
+
+// broadcastLocation 是放送日的参考时区，Bangumi 日历和 AirDate 都按日本放送日期记录。
+var broadcastLocation = time.FixedZone("JST", 9*60*60)
+
+var weekdayLabels = [...]string{"周日", "周一", "周二", "周三", "周四", "周五", "周六"}
+
+// AirSlot 是订阅每周的预计放送日。
+type AirSlot struct {
+	Weekday  time.Weekday
+	FirstAir time.Time // 首播日期，未知时为零值
+	Source   string
+}
+
+// lastRelease 返回不晚于 now 的最近一次放送日零点。
+func (s AirSlot) lastRelease(now time.Time) time.Time {
+	local := now.In(broadcastLocation)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, broadcastLocation)
+	back := (int(local.Weekday()) - int(s.Weekday) + 7) % 7
+	return day.AddDate(0, 0, -back)
+}
+
+func (s AirSlot) sourceLabel() string {
+	if s.Source == airSourceBangumiCalendar {
+		return "Bangumi 日历"
+	}
+	return "首播日期"
+}
+
+// scheduleDecision 记录一次调度判断的结果和原因。
+type scheduleDecision struct {
+	Due     bool
+	Reason  string
+	Summary string
+}
+
+// decideSubscription 判断订阅在本轮自动检查中是否需要检查。
+//
+// 没有放送信息时沿用全局间隔；启用智能轮询后，放送日之后的更新窗口内按全局间隔检查，
+// 其余时间、已完结或尚未开播的订阅降为低频检查。订阅自己的最小间隔始终生效。
+// 留出半个全局间隔的余量，避免上一轮稍晚写入的 LastCheckAt 让订阅每隔一轮才被检查。
+func decideSubscription(sub model.Subscription, now time.Time, policy Policy, slot *AirSlot) scheduleDecision {
+	if sub.LastCheckAt == nil || sub.LastCheckAt.IsZero() {
+		return scheduleDecision{Due: true, Reason: ScheduleReasonFirstCheck, Summary: "首次检查"}
+	}
+	global := policy.Interval
+	minInterval := global
+	if perSub := time.Duration(sub.CheckIntervalMinutes) * time.Minute; perSub > minInterval {
+		minInterval = perSub
+	}
+	lowFrequency := minInterval
+	if offWindowInterval > lowFrequency {
+		lowFrequency = offWindowInterval
+	}
+	elapsed := now.Sub(*sub.LastCheckAt)
+	slack := global / 2
+
+	switch {
+	case policy.SmartPolling && sub.ExpectedEpisodes > 0 && sub.LastEp >= sub.ExpectedEpisodes:
+		if elapsed+slack >= lowFrequency {
+			return scheduleDecision{Due: true, Reason: ScheduleReasonFinished, Summary: fmt.Sprintf("已追完 %d 集，按 %s 低频检查", sub.ExpectedEpisodes, formatInterval(lowFrequency))}
+		}
+		return scheduleDecision{Reason: ScheduleReasonFinished, Summary: fmt.Sprintf("已追完 %d 集，每 %s 检查一次", sub.ExpectedEpisodes, formatInterval(lowFrequency))}
+	case policy.SmartPolling && slot != nil && !slot.FirstAir.IsZero() && now.Before(slot.FirstAir):
+		if elapsed+slack >= lowFrequency {
+			return scheduleDecision{Due: true, Reason: ScheduleReasonNotAired, Summary: fmt.Sprintf("尚未开播（%s），按 %s 低频检查", slot.FirstAir.Format("2006-01-02"), formatInterval(lowFrequency))}
+		}
+		return scheduleDecision{Reason: ScheduleReasonNotAired, Summary: fmt.Sprintf("预计 %s 开播，开播前每 %s 检查一次", slot.FirstAir.Format("2006-01-02"), formatInterval(lowFrequency))}
+	case policy.SmartPolling && slot != nil:
+		release := slot.lastRelease(now)
+		weekday := weekdayLabels[slot.Weekday]
+		if now.Sub(release) < releaseWindow {
+			if sub.LastCheckAt.Before(release) || elapsed+slack >= minInterval {
+				return scheduleDecision{Due: true, Reason: ScheduleReasonReleaseWindow, Summary: fmt.Sprintf("处于%s放送后的更新窗口（%s）", weekday, slot.sourceLabel())}
+			}
+			return scheduleDecision{Reason: ScheduleReasonRecentlyChecked, Summary: fmt.Sprintf("更新窗口内 %s 前刚检查过", formatInterval(elapsed))}
+		}
+		if elapsed+slack >= lowFrequency {
+			return scheduleDecision{Due: true, Reason: ScheduleReasonOffWindow, Summary: fmt.Sprintf("非放送时段，按 %s 低频检查（%s放送）", formatInterval(lowFrequency), weekday)}
+		}
+		next := release.AddDate(0, 0, 7)
+		return scheduleDecision{Reason: ScheduleReasonOffWindow, Summary: fmt.Sprintf("非放送时段，下次放送 %s（%s），期间每 %s 检查一次", next.Format("01-02"), weekday, formatInterval(lowFrequency))}
+	}
+
+	if elapsed+slack >= minInterval {
+		return scheduleDecision{Due: true, Reason: ScheduleReasonInterval, Summary: fmt.Sprintf("距上次检查已超过 %s", formatInterval(minInterval))}
+	}
+	return scheduleDecision{Reason: ScheduleReasonRecentlyChecked, Summary: fmt.Sprintf("%s 前刚检查过，未到 %s 的检查间隔", formatInterval(elapsed), formatInterval(minInterval))}
+}
+
+func formatInterval(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return "不到 1 分钟"
+	}
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%d 小时", int(d/time.Hour))
+	}
+	if d > time.Hour {
+		return fmt.Sprintf("%d 小时 %d 分钟", int(d/time.Hour), int(d%time.Hour/time.Minute))
+	}
+	return fmt.Sprintf("%d 分钟", int(d/time.Minute))
+}
+
+// airSlotFor 优先使用 Bangumi 日历里的放送日，其次用 AirDate 推算。
+func airSlotFor(sub model.Subscription, weekdays map[int]time.Weekday, now time.Time) *AirSlot {
+	if sub.Metadata == nil {
+		return nil
+	}
+	firstAir, hasAirDate := parseAirDate(sub.Metadata.AirDate)
+	if weekday, ok := weekdays[sub.Metadata.BangumiID]; ok && sub.Metadata.BangumiID != 0 {
+		slot := &AirSlot{Weekday: weekday, Source: airSourceBangumiCalendar}
+		if hasAirDate {
+			slot.FirstAir = firstAir
+		}
+		return slot
+	}
+	if !hasAirDate || now.Sub(firstAir) > airDateFallbackWindow {
+		return nil
+	}
+	return &AirSlot{Weekday: firstAir.Weekday(), FirstAir: firstAir, Source: airSourceAirDate}
+}
+
+func parseAirDate(raw string) (time.Time, bool) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) > len("2006-01-02") {
+		raw = raw[:len("2006-01-02")]
+	}
+	t, err := time.ParseInLocation("2006-01-02", raw, broadcastLocation)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// broadcastCalendar 缓存 Bangumi 每日放送表，按条目 ID 查询放送日。
+type broadcastCalendar struct {
+	mu          sync.Mutex
+	fetch       func(ctx context.Context) ([]bangumi.CalendarItem, error)
+	weekdays    map[int]time.Weekday
+	nextRefresh time.Time
+}
+
+func newBroadcastCalendar() *broadcastCalendar {
+	return &broadcastCalendar{fetch: fetchBangumiCalendar}
+}
+
+func fetchBangumiCalendar(context.Context) ([]bangumi.CalendarItem, error) {
+	client := service.NewConfiguredBangumiClient()
+	client.SetTimeout(calendarFetchTimeout)
+	return client.GetCalendar()
+}
+
+// Weekdays 返回条目 ID 到放送日的映射。刷新失败时保留上一次的数据，并在一小时后重试。
+func (c *broadcastCalendar) Weekdays(ctx context.Context, now time.Time) map[int]time.Weekday {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fetch == nil || now.Before(c.nextRefresh) {
+		return c.weekdays
+	}
+	items, err := c.fetch(ctx)
+	if err != nil {
+		log.Printf("Scheduler Warning: failed to refresh Bangumi calendar: %v", err)
+		c.nextRefresh = now.Add(calendarRetryInterval)
+		return c.weekdays
+	}
+	weekdays := map[int]time.Weekday{}
+	for _, day := range items {
+		if day.Weekday.ID < 1 || day.Weekday.ID > 7 {
+			continue
+		}
+		// Bangumi 用 1-7 表示周一到周日
+		weekday := time.Weekday(day.Weekday.ID % 7)
+		for _, item := range day.Items {
+			if item.ID != 0 {
+				weekdays[item.ID] = weekday
+			}
+		}
+	}
+	c.weekdays = weekdays
+	c.nextRefresh = now.Add(calendarRefreshInterval)
+	return c.weekdays
+}
@@ -0,0 +1,160 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/bangumi"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+)
+
+// jst builds a fake clock reading in the broadcast time zone. 2026-10-16 is a Friday.
+func jst(day, hour, minute int) time.Time {
+	return time.Date(2026, 10, day, hour, minute, 0, 0, broadcastLocation)
+}
+
+func checkedAt(t time.Time) *time.Time {
+	return &t
+}
+
+func TestAirSlotLastRelease(t *testing.T) {
+	friday := AirSlot{Weekday: time.Friday}
+	if got := friday.lastRelease(jst(16, 23, 30)); !got.Equal(jst(16, 0, 0)) {
+		t.Fatalf("expected release on the same Friday, got %s", got)
+	}
+	if got := friday.lastRelease(jst(20, 9, 0)); !got.Equal(jst(16, 0, 0)) {
+		t.Fatalf("expected the previous Friday, got %s", got)
+	}
+	saturday := AirSlot{Weekday: time.Saturday}
+	if got := saturday.lastRelease(jst(16, 12, 0)); !got.Equal(jst(10, 0, 0)) {
+		t.Fatalf("expected last Saturday, got %s", got)
+	}
+}
+
+func TestDecideSubscriptionPollsAggressivelyAfterRelease(t *testing.T) {
+	policy := Policy{Interval: 15 * time.Minute, SmartPolling: true}
+	slot := &AirSlot{Weekday: time.Friday, Source: airSourceBangumiCalendar}
+
+	// Checked five minutes before the broadcast day starts: the release window
+	// still triggers an immediate check.
+	sub := model.Subscription{LastCheckAt: checkedAt(jst(15, 23, 55))}
+	decision := decideSubscription(sub, jst(16, 0, 1), policy, slot)
+	if !decision.Due || decision.Reason != ScheduleReasonReleaseWindow {
+		t.Fatalf("expected release window check, got %+v", decision)
+	}
+
+	// Inside the window the global interval applies.
+	sub.LastCheckAt = checkedAt(jst(17, 2, 0))
+	if decision := decideSubscription(sub, jst(17, 2, 5), policy, slot); decision.Due || decision.Reason != ScheduleReasonRecentlyChecked {
+		t.Fatalf("expected recent check to be skipped inside the window, got %+v", decision)
+	}
+	if decision := decideSubscription(sub, jst(17, 2, 15), policy, slot); !decision.Due || decision.Reason != ScheduleReasonReleaseWindow {
+		t.Fatalf("expected next tick to check inside the window, got %+v", decision)
+	}
+}
+
+func TestDecideSubscriptionPollsRarelyOutsideReleaseWindow(t *testing.T) {
+	policy := Policy{Interval: 15 * time.Minute, SmartPolling: true}
+	slot := &AirSlot{Weekday: time.Friday, Source: airSourceAirDate}
+
+	// Tuesday is well outside the 36 hour window after Friday's broadcast.
+	sub := model.Subscription{LastCheckAt: checkedAt(jst(20, 9, 0))}
+	decision := decideSubscription(sub, jst(20, 11, 0), policy, slot)
+	if decision.Due || decision.Reason != ScheduleReasonOffWindow {
+		t.Fatalf("expected off window skip, got %+v", decision)
+	}
+	decision = decideSubscription(sub, jst(20, 15, 0), policy, slot)
+	if !decision.Due || decision.Reason != ScheduleReasonOffWindow {
+		t.Fatalf("expected low frequency check after six hours, got %+v", decision)
+	}
+
+	// Without smart polling the same subscription follows the global interval.
+	decision = decideSubscription(sub, jst(20, 11, 0), Policy{Interval: 15 * time.Minute}, slot)
+	if !decision.Due || decision.Reason != ScheduleReasonInterval {
+		t.Fatalf("expected interval check when smart polling is disabled, got %+v", decision)
+	}
+}
+
+func TestDecideSubscriptionSlowsFinishedAndUnairedShows(t *testing.T) {
+	policy := Policy{Interval: 15 * time.Minute, SmartPolling: true}
+	now := jst(16, 12, 0)
+
+	finished := model.Subscription{ExpectedEpisodes: 12, LastEp: 12, LastCheckAt: checkedAt(now.Add(-time.Hour))}
+	slot := &AirSlot{Weekday: time.Friday}
+	if decision := decideSubscription(finished, now, policy, slot); decision.Due || decision.Reason != ScheduleReasonFinished {
+		t.Fatalf("expected finished show to wait, got %+v", decision)
+	}
+
+	unaired := model.Subscription{LastCheckAt: checkedAt(now.Add(-time.Hour))}
+	upcoming := &AirSlot{Weekday: time.Friday, FirstAir: jst(30, 0, 0)}
+	if decision := decideSubscription(unaired, now, policy, upcoming); decision.Due || decision.Reason != ScheduleReasonNotAired {
+		t.Fatalf("expected unaired show to wait, got %+v", decision)
+	}
+
+	// The per-subscription minimum still wins inside the release window.
+	slow := model.Subscription{CheckIntervalMinutes: 120, LastCheckAt: checkedAt(now.Add(-time.Hour))}
+	if decision := decideSubscription(slow, now, policy, slot); decision.Due {
+		t.Fatalf("expected per-subscription minimum to hold, got %+v", decision)
+	}
+}
+
+func TestAirSlotForPrefersBangumiCalendar(t *testing.T) {
+	now := jst(16, 12, 0)
+	sub := model.Subscription{Metadata: &model.AnimeMetadata{BangumiID: 42, AirDate: "2026-10-04"}}
+
+	slot := airSlotFor(sub, map[int]time.Weekday{42: time.Saturday}, now)
+	if slot == nil || slot.Weekday != time.Saturday || slot.Source != airSourceBangumiCalendar {
+		t.Fatalf("expected calendar weekday, got %+v", slot)
+	}
+
+	slot = airSlotFor(sub, nil, now)
+	if slot == nil || slot.Weekday != time.Sunday || slot.Source != airSourceAirDate {
+		t.Fatalf("expected air date weekday, got %+v", slot)
+	}
+
+	sub.Metadata.AirDate = "2019-04-06"
+	if slot := airSlotFor(sub, nil, now); slot != nil {
+		t.Fatalf("expected stale air date to be ignored, got %+v", slot)
+	}
+	if slot := airSlotFor(model.Subscription{}, nil, now); slot != nil {
+		t.Fatalf("expected nil slot without metadata, got %+v", slot)
+	}
+}
+
+func TestBroadcastCalendarCachesAndRetries(t *testing.T) {
+	calls := 0
+	fail := false
+	calendar := &broadcastCalendar{fetch: func(context.Context) ([]bangumi.CalendarItem, error) {
+		calls++
+		if fail {
+			return nil, errors.New("bangumi unavailable")
+		}
+		day := bangumi.CalendarItem{Items: []bangumi.Subject{{ID: 7}}}
+		day.Weekday.ID = 7
+		return []bangumi.CalendarItem{day}, nil
+	}}
+	now := jst(16, 12, 0)
+
+	if got := calendar.Weekdays(context.Background(), now); got[7] != time.Sunday {
+		t.Fatalf("expected Bangumi weekday 7 to map to Sunday, got %v", got)
+	}
+	calendar.Weekdays(context.Background(), now.Add(time.Hour))
+	if calls != 1 {
+		t.Fatalf("expected cached calendar, got %d fetches", calls)
+	}
+
+	fail = true
+	if got := calendar.Weekdays(context.Background(), now.Add(calendarRefreshInterval)); got[7] != time.Sunday {
+		t.Fatalf("expected stale calendar to be kept after a failed refresh, got %v", got)
+	}
+	calendar.Weekdays(context.Background(), now.Add(calendarRefreshInterval+30*time.Minute))
+	if calls != 2 {
+		t.Fatalf("expected failed refresh to wait before retrying, got %d fetches", calls)
+	}
+	calendar.Weekdays(context.Background(), now.Add(calendarRefreshInterval+calendarRetryInterval))
+	if calls != 3 {
+		t.Fatalf("expected retry after an hour, got %d fetches", calls)
+	}
+}
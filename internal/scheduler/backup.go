@@ -0,0 +1,705 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pokerjest/animateAutoTool/internal/backupdest"
+	"github.com/pokerjest/animateAutoTool/internal/config"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/safeio"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"github.com/pokerjest/animateAutoTool/internal/taskstate"
+)
+
+const (
+	BackupTargetLocal  = "local"
+	BackupTargetRemote = "remote"
+	BackupTriggerCron  = "schedule"
+	BackupTriggerAPI   = "manual"
+	BackupRunSuccess   = "success"
+	BackupRunPartial   = "partial"
+	BackupRunFailure   = "failure"
+
+	DefaultBackupCron       = "0 3 * * *"
+	defaultBackupTargets    = BackupTargetLocal
+	maxBackupRetentionCount = 1000
+	MinBackupPassword       = 8
+
+	backupUploadTimeout  = 30 * time.Minute
+	backupRequestTimeout = 15 * time.Second
+)
+
+var defaultBackupRetention = service.BackupRetention{KeepLast: 7, Daily: 7, Weekly: 4, Monthly: 6}
+
+var ErrBackupRunning = errors.New("已有定时备份正在运行")
+
+// BackupPolicy is the parsed form of the backup_schedule_* and
+// backup_retention_* settings.
+type BackupPolicy struct {
+	Enabled   bool
+	Schedule  CronSchedule
+	Mode      string
+	Targets   []string
+	LocalDir  string
+	Password  string
+	Retention service.BackupRetention
+}
+
+// BackupRun records one scheduled or manually triggered run. It is
+// also the details payload of the backup.schedule.run audit entry, which is
+// how the last result survives a restart.
+type BackupRun struct {
+	TaskID     string               `json:"task_id"`
+	Trigger    string               `json:"trigger"`
+	Mode       string               `json:"mode"`
+	Status     string               `json:"status"`
+	Artifact   string               `json:"artifact,omitempty"`
+	Size       int64                `json:"size,omitempty"`
+	StartedAt  time.Time            `json:"started_at"`
+	FinishedAt time.Time            `json:"finished_at"`
+	Targets    []BackupTargetResult `json:"targets"`
+	Error      string               `json:"error,omitempty"`
+}
+
+// BackupTargetResult is the outcome for one destination. PruneError
+// is reported separately because the new archive is already safely stored
+// when pruning fails.
+type BackupTargetResult struct {
+	Target      string   `json:"target"`
+	Destination string   `json:"destination,omitempty"`
+	Location    string   `json:"location,omitempty"`
+	Pruned      []string `json:"pruned"`
+	// Protected lists expired archives that were kept because the settings
+	// key they were sealed with has been rotated out of the local key file.
+	Protected  []string `json:"protected,omitempty"`
+	Error      string   `json:"error,omitempty"`
+	PruneError string   `json:"prune_error,omitempty"`
+}
+
+// BackupRemote is the configured offsite destination of a run.
+type BackupRemote struct {
+	Kind string
+	// Open connects to the destination; its errors are shown as they are.
+	Open func() (backupdest.Destination, error)
+	// Describe turns upload errors into messages a user can act on.
+	Describe func(error) string
+	// Changed is called after an archive was uploaded or deleted.
+	Changed func()
+}
+
+// BackupRunner runs the backup schedule and remembers the last result.
+// Remote returns the offsite destination of the "remote" target; Now
+// defaults to time.Now.
+type BackupRunner struct {
+	Remote func() BackupRemote
+	Now    func() time.Time
+
+	running atomic.Bool
+	mu      sync.Mutex
+	lastRun *BackupRun
+	loaded  bool
+}
+
+func NewBackupRunner(remote func() BackupRemote) *BackupRunner {
+	return &BackupRunner{Remote: remote, Now: time.Now}
+}
+
+func defaultBackupLocalDir() string {
+	return filepath.Join(config.DataDir(), "backups", "scheduled")
+}
+
+// LoadBackupPolicy reads the current settings. The policy is filled
+// in as far as possible even when an error is returned.
+func LoadBackupPolicy() (BackupPolicy, error) {
+	policy := BackupPolicy{
+		Enabled:   strings.EqualFold(readSetting(model.ConfigKeyBackupScheduleEnabled), model.ConfigValueTrue),
+		Mode:      service.NormalizeBackupMode(readSetting(model.ConfigKeyBackupScheduleMode)),
+		LocalDir:  readSetting(model.ConfigKeyBackupScheduleLocalDir),
+		Retention: defaultBackupRetention,
+	}
+	if db.DB != nil {
+		// The password is used exactly as saved.
+		policy.Password = store.NewConfigStore(db.DB).GetDefault(model.ConfigKeyBackupSchedulePassword, "")
+	}
+	if policy.LocalDir == "" {
+		policy.LocalDir = defaultBackupLocalDir()
+	}
+
+	expression := readSetting(model.ConfigKeyBackupScheduleCron)
+	if expression == "" {
+		expression = DefaultBackupCron
+	}
+	schedule, err := ParseCronSchedule(expression)
+	if err != nil {
+		return policy, err
+	}
+	policy.Schedule = schedule
+
+	targets := readSetting(model.ConfigKeyBackupScheduleTargets)
+	if targets == "" {
+		targets = defaultBackupTargets
+	}
+	if policy.Targets, err = ParseBackupTargets(targets); err != nil {
+		return policy, err
+	}
+
+	for _, tier := range []struct {
+		key   string
+		value *int
+	}{
+		{model.ConfigKeyBackupRetentionLast, &policy.Retention.KeepLast},
+		{model.ConfigKeyBackupRetentionDaily, &policy.Retention.Daily},
+		{model.ConfigKeyBackupRetentionWeekly, &policy.Retention.Weekly},
+		{model.ConfigKeyBackupRetentionMonthly, &policy.Retention.Monthly},
+	} {
+		raw := readSetting(tier.key)
+		if raw == "" {
+			continue
+		}
+		if *tier.value, err = ParseBackupRetentionCount(raw); err != nil {
+			return policy, err
+		}
+	}
+	return policy, nil
+}
+
+// Validate reports settings problems that would make a run fail before any
+// work is done.
+func (p BackupPolicy) Validate() error {
+	if len(p.Password) < MinBackupPassword {
+		return fmt.Errorf("定时备份需要先设置至少 %d 个字符的备份压缩包密码", MinBackupPassword)
+	}
+	if len(p.Targets) == 0 {
+		return errors.New("定时备份至少需要一个存储位置")
+	}
+	return nil
+}
+
+// ParseBackupTargets reads a comma separated list of "local" and
+// "remote", sorted and without repeats.
+func ParseBackupTargets(raw string) ([]string, error) {
+	seen := map[string]bool{}
+	var targets []string
+	for _, item := range strings.Split(raw, ",") {
+		target := strings.ToLower(strings.TrimSpace(item))
+		if target == "r2" {
+			// Before WebDAV/SFTP existed the offsite target was called r2.
+			target = BackupTargetRemote
+		}
+		if target == "" || seen[target] {
+			continue
+		}
+		if target != BackupTargetLocal && target != BackupTargetRemote {
+			return nil, fmt.Errorf("未知的备份存储位置 %q，只支持 local 和 remote", target)
+		}
+		seen[target] = true
+		targets = append(targets, target)
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("定时备份至少需要一个存储位置")
+	}
+	sort.Strings(targets)
+	return targets, nil
+}
+
+func ParseBackupRetentionCount(raw string) (int, error) {
+	value, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || value < 0 || value > maxBackupRetentionCount {
+		return 0, fmt.Errorf("备份保留数量必须是 0-%d 之间的整数", maxBackupRetentionCount)
+	}
+	return value, nil
+}
+
+// Loop checks the backup schedule once a minute until ctx is done. Settings
+// are re-read on every check, so edits apply without a restart. Slots that
+// pass while a run is still in progress are skipped rather than queued.
+func (s *BackupRunner) Loop(ctx context.Context) {
+	lastCheck := s.now()
+	lastConfigError := ""
+	for {
+		timer := time.NewTimer(time.Until(lastCheck.Truncate(time.Minute).Add(time.Minute)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		now := s.now()
+		policy, err := LoadBackupPolicy()
+		switch {
+		case err != nil:
+			if policy.Enabled && err.Error() != lastConfigError {
+				log.Printf("WARN: BackupSchedule: settings invalid, runs paused error=%v", err)
+			}
+			lastConfigError = err.Error()
+		case policy.Enabled && BackupDue(policy.Schedule, lastCheck, now):
+			lastConfigError = ""
+			if _, err := s.Run(ctx, policy, BackupTriggerCron, uuid.NewString(), service.AuditContext{Username: "scheduler"}); err != nil && !errors.Is(err, ErrBackupRunning) {
+				log.Printf("ERROR: BackupSchedule: run failed error=%v", err)
+			}
+			now = s.now()
+		default:
+			lastConfigError = ""
+		}
+		lastCheck = now
+	}
+}
+
+// BackupDue reports whether a slot of schedule falls after
+// lastCheck and no later than now.
+func BackupDue(schedule CronSchedule, lastCheck, now time.Time) bool {
+	next := schedule.Next(lastCheck)
+	return !next.IsZero() && !next.After(now)
+}
+
+// Running reports whether a run is in progress.
+func (s *BackupRunner) Running() bool {
+	return s.running.Load()
+}
+
+// NextRun returns the next slot of an enabled policy, or nil.
+func (s *BackupRunner) NextRun(policy BackupPolicy) *time.Time {
+	if !policy.Enabled || policy.Schedule.IsZero() {
+		return nil
+	}
+	next := policy.Schedule.Next(s.now())
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+// Run creates one archive in policy.Mode, stores it in every configured
+// target and prunes each target with the retention policy. The returned
+// error covers the run as a whole; per-target problems are in the result.
+func (s *BackupRunner) Run(ctx context.Context, policy BackupPolicy, trigger, taskID string, auditCtx service.AuditContext) (BackupRun, error) {
+	if !s.running.CompareAndSwap(false, true) {
+		return BackupRun{}, ErrBackupRunning
+	}
+	defer s.running.Store(false)
+
+	startedAt := s.now()
+	run := BackupRun{
+		TaskID:    taskID,
+		Trigger:   trigger,
+		Mode:      policy.Mode,
+		StartedAt: startedAt,
+		Targets:   []BackupTargetResult{},
+	}
+	taskstate.Global.StartBy(taskstate.Initiator{UserID: auditCtx.UserID, Username: auditCtx.Username}, taskID, "backup", "定时备份", "正在创建"+service.BackupModeLabel(policy.Mode))
+	log.Printf("BackupSchedule: run starting task=%s trigger=%s mode=%s targets=%s", taskID, trigger, policy.Mode, strings.Join(policy.Targets, ","))
+
+	err := policy.Validate()
+	if err == nil {
+		err = s.execute(ctx, policy, &run)
+	}
+	run.FinishedAt = s.now()
+	if err != nil {
+		run.Status = BackupRunFailure
+		run.Error = err.Error()
+	} else {
+		run.Status = BackupRunSuccess
+		for _, target := range run.Targets {
+			if target.Error != "" || target.PruneError != "" {
+				run.Status = BackupRunPartial
+			}
+		}
+	}
+	s.finish(run, auditCtx)
+	return run, err
+}
+
+func (s *BackupRunner) execute(ctx context.Context, policy BackupPolicy, run *BackupRun) error {
+	workDir, err := os.MkdirTemp("", "backup_schedule_*")
+	if err != nil {
+		return fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(workDir); err != nil {
+			log.Printf("WARN: BackupSchedule: remove work dir failed path=%s error=%v", workDir, err)
+		}
+	}()
+
+	databasePath := filepath.Join(workDir, "backup.db")
+	if err := service.CreateBackupFile(databasePath, policy.Mode); err != nil {
+		return fmt.Errorf("创建备份文件失败: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	taskstate.Global.ProgressPhase(run.TaskID, "compress", "正在压缩并加密备份", 0, int64(len(policy.Targets)))
+	settingsKeyID := ""
+	if policy.Mode == service.BackupModeFull {
+		keyInfo, err := config.SettingsKeyStatus()
+		if err != nil {
+			return fmt.Errorf("读取设置加密密钥失败: %w", err)
+		}
+		settingsKeyID = keyInfo.KeyID
+	}
+	run.Artifact = service.ScheduledBackupName(policy.Mode, run.StartedAt, settingsKeyID)
+	archivePath := filepath.Join(workDir, run.Artifact)
+	if err := service.CreateEncryptedBackupArchive(databasePath, archivePath, policy.Mode, policy.Password); err != nil {
+		return fmt.Errorf("压缩并加密备份失败: %w", err)
+	}
+	safeio.Remove(databasePath)
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return fmt.Errorf("读取加密备份压缩包信息失败: %w", err)
+	}
+	run.Size = info.Size()
+
+	stored := 0
+	for index, target := range policy.Targets {
+		result := BackupTargetResult{Target: target, Pruned: []string{}}
+		var destination BackupStore = &LocalBackupStore{Dir: policy.LocalDir}
+		if target == BackupTargetRemote {
+			remote := &remoteBackupStore{}
+			if s.Remote != nil {
+				remote.BackupRemote = s.Remote()
+			}
+			result.Destination = backupdest.NormalizeKind(remote.Kind)
+			destination = remote
+		}
+		taskstate.Global.ProgressPhase(run.TaskID, "store", "正在写入"+backupTargetLabel(target, result.Destination), int64(index), int64(len(policy.Targets)))
+		result.Location, err = destination.Put(ctx, run.Artifact, archivePath, run.Size)
+		if err != nil {
+			result.Error = err.Error()
+			log.Printf("ERROR: BackupSchedule: store failed task=%s target=%s error=%v", run.TaskID, target, err)
+			run.Targets = append(run.Targets, result)
+			continue
+		}
+		stored++
+		result.Pruned, result.Protected, err = PruneBackupStore(ctx, destination, policy)
+		if err != nil {
+			result.PruneError = err.Error()
+			log.Printf("WARN: BackupSchedule: prune failed task=%s target=%s error=%v", run.TaskID, target, err)
+		}
+		run.Targets = append(run.Targets, result)
+	}
+	if stored == 0 {
+		return errors.New("备份未能写入任何存储位置")
+	}
+	return nil
+}
+
+func (s *BackupRunner) finish(run BackupRun, auditCtx service.AuditContext) {
+	s.mu.Lock()
+	s.lastRun = &run
+	s.loaded = true
+	s.mu.Unlock()
+
+	outcome := service.AuditOutcomeSuccess
+	if run.Status != BackupRunSuccess {
+		outcome = service.AuditOutcomeFailure
+	}
+	service.RecordAudit(auditCtx, service.AuditEntry{
+		Action:     service.AuditActionBackupScheduleRun,
+		Outcome:    outcome,
+		TargetType: "backup_schedule",
+		TargetID:   run.Artifact,
+		Details:    run,
+	})
+	for _, target := range run.Targets {
+		if len(target.Pruned) == 0 && target.PruneError == "" {
+			continue
+		}
+		entry := service.AuditEntry{
+			Action:     service.AuditActionBackupSchedulePrune,
+			Outcome:    service.AuditOutcomeSuccess,
+			TargetType: "backup_" + target.Target,
+			TargetID:   target.Location,
+			Details:    map[string]any{"deleted": target.Pruned},
+		}
+		if target.PruneError != "" {
+			entry.Outcome = service.AuditOutcomeFailure
+			entry.Details = map[string]any{"deleted": target.Pruned, "error": target.PruneError}
+		}
+		service.RecordAudit(auditCtx, entry)
+	}
+
+	switch run.Status {
+	case BackupRunSuccess:
+		taskstate.Global.Complete(run.TaskID, "定时备份完成: "+run.Artifact)
+	case BackupRunPartial:
+		taskstate.Global.Fail(run.TaskID, fmt.Errorf("定时备份部分失败: %s", BackupRunProblems(run)))
+	default:
+		taskstate.Global.Fail(run.TaskID, errors.New(run.Error))
+	}
+	log.Printf(
+		"BackupSchedule: run finished task=%s trigger=%s status=%s artifact=%s size=%d duration=%s",
+		run.TaskID,
+		run.Trigger,
+		run.Status,
+		run.Artifact,
+		run.Size,
+		run.FinishedAt.Sub(run.StartedAt).Round(time.Millisecond),
+	)
+}
+
+// BackupRunProblems summarizes what went wrong in run.
+func BackupRunProblems(run BackupRun) string {
+	if run.Error != "" {
+		return run.Error
+	}
+	var problems []string
+	for _, target := range run.Targets {
+		if target.Error != "" {
+			problems = append(problems, backupTargetLabel(target.Target, target.Destination)+"写入失败: "+target.Error)
+		}
+		if target.PruneError != "" {
+			problems = append(problems, backupTargetLabel(target.Target, target.Destination)+"清理旧备份失败: "+target.PruneError)
+		}
+	}
+	return strings.Join(problems, "；")
+}
+
+func backupTargetLabel(target, destination string) string {
+	if target == BackupTargetRemote || target == "r2" {
+		return backupdest.Label(backupdest.NormalizeKind(destination))
+	}
+	return "本地目录"
+}
+
+// LastRun returns the most recent run, falling back to the latest audit
+// entry after a restart.
+func (s *BackupRunner) LastRun() *BackupRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loaded {
+		s.loaded = true
+		entries, err := service.ListAuditLogs(store.AuditLogQuery{Action: service.AuditActionBackupScheduleRun, Limit: 1})
+		if err == nil && len(entries) > 0 {
+			var run BackupRun
+			if err := json.Unmarshal([]byte(entries[0].Details), &run); err == nil {
+				s.lastRun = &run
+			}
+		}
+	}
+	if s.lastRun == nil {
+		return nil
+	}
+	run := *s.lastRun
+	return &run
+}
+
+func (s *BackupRunner) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// BackupStore is one destination for scheduled archives.
+type BackupStore interface {
+	Put(ctx context.Context, name, archivePath string, size int64) (string, error)
+	List(ctx context.Context, prefix string) ([]string, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// PruneBackupStore deletes the archives the retention policy no
+// longer keeps and returns the deleted and protected names. Archives sealed
+// with a settings key that has since been rotated out of the local key file
+// are never deleted: they hold the only remaining copy of that key and of
+// the credentials it protects.
+func PruneBackupStore(ctx context.Context, destination BackupStore, policy BackupPolicy) ([]string, []string, error) {
+	pruned := []string{}
+	var protected []string
+	if policy.Retention.IsZero() {
+		return pruned, protected, nil
+	}
+	names, err := destination.List(ctx, service.ScheduledBackupPrefix(policy.Mode))
+	if err != nil {
+		return pruned, protected, fmt.Errorf("列出已有备份失败: %w", err)
+	}
+	keyInfo, err := config.SettingsKeyStatus()
+	if err != nil {
+		return pruned, protected, fmt.Errorf("读取设置加密密钥失败: %w", err)
+	}
+	localKeys := make(map[string]bool, len(keyInfo.RetainedKeyIDs))
+	for _, keyID := range keyInfo.RetainedKeyIDs {
+		localKeys[keyID] = true
+	}
+	artifacts := make([]service.BackupArtifact, 0, len(names))
+	for _, name := range names {
+		if artifact, ok := service.ParseScheduledBackupName(policy.Mode, name); ok {
+			artifacts = append(artifacts, artifact)
+		}
+	}
+	for _, artifact := range service.SelectExpiredBackups(artifacts, policy.Retention) {
+		if artifact.SettingsKeyID != "" && !localKeys[artifact.SettingsKeyID] {
+			log.Printf("BackupSchedule: keeping expired archive sealed with a rotated settings key name=%s key=%s", artifact.Name, artifact.SettingsKeyID)
+			protected = append(protected, artifact.Name)
+			continue
+		}
+		if err := destination.Delete(ctx, artifact.Name); err != nil {
+			return pruned, protected, fmt.Errorf("删除 %s 失败: %w", artifact.Name, err)
+		}
+		pruned = append(pruned, artifact.Name)
+	}
+	return pruned, protected, nil
+}
+
+// LocalBackupStore keeps archives in a local directory.
+type LocalBackupStore struct {
+	Dir string
+}
+
+func (s *LocalBackupStore) Put(_ context.Context, name, archivePath string, _ int64) (string, error) {
+	if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+		return "", fmt.Errorf("创建备份目录失败: %w", err)
+	}
+	destination := filepath.Join(s.Dir, name)
+	if err := copyBackupFile(archivePath, destination); err != nil {
+		return "", err
+	}
+	return destination, nil
+}
+
+func copyBackupFile(source, destination string) (retErr error) {
+	input, err := os.Open(filepath.Clean(source)) //nolint:gosec // source is created by the backup run.
+	if err != nil {
+		return fmt.Errorf("打开加密备份压缩包失败: %w", err)
+	}
+	defer safeio.Close(input)
+
+	tempPath := destination + ".tmp"
+	output, err := os.OpenFile(filepath.Clean(tempPath), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600) //nolint:gosec // destination is inside the configured backup directory.
+	if err != nil {
+		return fmt.Errorf("写入备份目录失败: %w", err)
+	}
+	defer func() {
+		if retErr != nil {
+			safeio.Remove(tempPath)
+		}
+	}()
+	if _, err := io.Copy(output, input); err != nil {
+		_ = output.Close()
+		return fmt.Errorf("写入备份目录失败: %w", err)
+	}
+	if err := output.Close(); err != nil {
+		return fmt.Errorf("写入备份目录失败: %w", err)
+	}
+	if err := os.Rename(tempPath, destination); err != nil {
+		return fmt.Errorf("写入备份目录失败: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalBackupStore) List(_ context.Context, prefix string) ([]string, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.HasPrefix(entry.Name(), prefix) {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+func (s *LocalBackupStore) Delete(_ context.Context, name string) error {
+	return os.Remove(filepath.Join(s.Dir, filepath.Base(name)))
+}
+
+// remoteBackupStore writes to the configured offsite destination. It
+// connects on first use so a broken destination only fails its own target.
+type remoteBackupStore struct {
+	BackupRemote
+	destination backupdest.Destination
+}
+
+func (s *remoteBackupStore) connect() error {
+	if s.destination != nil {
+		return nil
+	}
+	if s.Open == nil {
+		return errors.New("未配置远程备份存储")
+	}
+	destination, err := s.Open()
+	if err != nil {
+		return err
+	}
+	s.destination = destination
+	return nil
+}
+
+func (s *remoteBackupStore) describe(err error) string {
+	if s.Describe == nil {
+		return err.Error()
+	}
+	return s.Describe(err)
+}
+
+func (s *remoteBackupStore) changed() {
+	if s.Changed != nil {
+		s.Changed()
+	}
+}
+
+func (s *remoteBackupStore) Put(ctx context.Context, name, archivePath string, size int64) (string, error) {
+	if err := s.connect(); err != nil {
+		return "", err
+	}
+	file, err := os.Open(filepath.Clean(archivePath)) //nolint:gosec // archivePath is created by the backup run.
+	if err != nil {
+		return "", fmt.Errorf("打开加密备份压缩包失败: %w", err)
+	}
+	defer safeio.Close(file)
+
+	uploadCtx, cancel := context.WithTimeout(ctx, backupUploadTimeout)
+	defer cancel()
+	if err := s.destination.Upload(uploadCtx, name, file, size); err != nil {
+		return "", fmt.Errorf("上传备份到 %s 失败: %s", backupdest.Label(backupdest.NormalizeKind(s.Kind)), s.describe(err))
+	}
+	s.changed()
+	return s.destination.Location(name), nil
+}
+
+func (s *remoteBackupStore) List(ctx context.Context, prefix string) ([]string, error) {
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	requestCtx, cancel := context.WithTimeout(ctx, backupRequestTimeout)
+	defer cancel()
+	objects, err := s.destination.List(requestCtx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(objects))
+	for _, object := range objects {
+		names = append(names, object.Key)
+	}
+	return names, nil
+}
+
+func (s *remoteBackupStore) Delete(ctx context.Context, name string) error {
+	if err := s.connect(); err != nil {
+		return err
+	}
+	requestCtx, cancel := context.WithTimeout(ctx, backupRequestTimeout)
+	defer cancel()
+	if err := s.destination.Delete(requestCtx, name); err != nil {
+		return err
+	}
+	s.changed()
+	return nil
+}
@@ -0,0 +1,133 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/config"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/service"
+	"github.com/pokerjest/animateAutoTool/internal/taskstate"
+)
+
+func TestBackupRunnerStoresLocallyAndPrunesOnlyScheduledArchives(t *testing.T) {
+	db.InitDB(":memory:")
+	t.Cleanup(func() { _ = db.CloseDB() })
+	now := time.Date(2026, 10, 16, 3, 0, 0, 0, time.Local)
+	dir := t.TempDir()
+	for key, value := range map[string]string{
+		model.ConfigKeyBackupScheduleLocalDir: dir,
+		model.ConfigKeyBackupSchedulePassword: "scheduled-secret",
+		model.ConfigKeyBackupRetentionLast:    "2",
+		model.ConfigKeyBackupRetentionDaily:   "0",
+		model.ConfigKeyBackupRetentionWeekly:  "0",
+		model.ConfigKeyBackupRetentionMonthly: "0",
+		model.ConfigKeyBackupScheduleTargets:  "local",
+		model.ConfigKeyBackupScheduleMode:     service.BackupModeFull,
+		model.ConfigKeyBackupScheduleCron:     "0 3 * * *",
+		model.ConfigKeyBackupScheduleEnabled:  model.ConfigValueTrue,
+	} {
+		if err := db.SaveGlobalConfig(key, value); err != nil {
+			t.Fatalf("save %s: %v", key, err)
+		}
+	}
+
+	keyInfo, err := config.SettingsKeyStatus()
+	if err != nil {
+		t.Fatalf("settings key: %v", err)
+	}
+	older := []string{
+		service.ScheduledBackupName(service.BackupModeFull, now.AddDate(0, 0, -4), ""),
+		service.ScheduledBackupName(service.BackupModeFull, now.AddDate(0, 0, -3), keyInfo.KeyID),
+		service.ScheduledBackupName(service.BackupModeFull, now.AddDate(0, 0, -2), keyInfo.KeyID),
+		service.ScheduledBackupName(service.BackupModeFull, now.AddDate(0, 0, -1), keyInfo.KeyID),
+	}
+	// Sealed with a key that is no longer in the local key file.
+	rotated := service.ScheduledBackupName(service.BackupModeFull, now.AddDate(0, 0, -5), "0badc0de")
+	untouched := []string{
+		rotated,
+		service.BackupFilename(service.BackupModeFull, now.AddDate(0, 0, -9)),
+		service.ScheduledBackupName(service.BackupModeSettings, now.AddDate(0, 0, -9), ""),
+	}
+	for _, name := range append(append([]string{}, older...), untouched...) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("old"), 0o600); err != nil {
+			t.Fatalf("seed %s: %v", name, err)
+		}
+	}
+
+	policy, err := LoadBackupPolicy()
+	if err != nil {
+		t.Fatalf("LoadBackupPolicy returned error: %v", err)
+	}
+	runner := &BackupRunner{Now: func() time.Time { return now }}
+	run, err := runner.Run(context.Background(), policy, BackupTriggerAPI, "backup-runner-test", service.AuditContext{Username: "admin"})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	if run.Status != BackupRunSuccess || run.Artifact != service.ScheduledBackupName(service.BackupModeFull, now, keyInfo.KeyID) {
+		t.Fatalf("unexpected run: %+v", run)
+	}
+	if len(run.Targets) != 1 || run.Targets[0].Location != filepath.Join(dir, run.Artifact) {
+		t.Fatalf("unexpected targets: %+v", run.Targets)
+	}
+	if !reflect.DeepEqual(run.Targets[0].Pruned, older[:3]) || !reflect.DeepEqual(run.Targets[0].Protected, []string{rotated}) {
+		t.Fatalf("pruned %v protected %v", run.Targets[0].Pruned, run.Targets[0].Protected)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read backup dir: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	want := append([]string{run.Artifact, older[3]}, untouched...)
+	sort.Strings(names)
+	sort.Strings(want)
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("backup dir = %v, want %v", names, want)
+	}
+	manifest, err := service.ExtractEncryptedBackupArchive(filepath.Join(dir, run.Artifact), "scheduled-secret", filepath.Join(t.TempDir(), "restored.db"))
+	if err != nil {
+		t.Fatalf("extract archive: %v", err)
+	}
+	if manifest.BackupMode != service.BackupModeFull || !reflect.DeepEqual(manifest.SettingsKeyIDs, keyInfo.RetainedKeyIDs) {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+
+	if task, ok := taskstate.Global.Get("backup-runner-test"); !ok || task.Status != taskstate.StatusCompleted {
+		t.Fatalf("unexpected task: %+v", task)
+	}
+	var prunes int64
+	if err := db.DB.Model(&model.AuditLog{}).Where("action = ?", service.AuditActionBackupSchedulePrune).Count(&prunes).Error; err != nil || prunes != 1 {
+		t.Fatalf("prune audit entries = %d, %v", prunes, err)
+	}
+
+	// After a restart the last result comes back from the audit log.
+	restored := (&BackupRunner{}).LastRun()
+	if restored == nil || restored.Artifact != run.Artifact || restored.Status != BackupRunSuccess {
+		t.Fatalf("restored last run = %+v", restored)
+	}
+}
+
+func TestBackupDueSkipsSlotsBeforeLastCheck(t *testing.T) {
+	schedule, err := ParseCronSchedule(DefaultBackupCron)
+	if err != nil {
+		t.Fatalf("ParseCronSchedule returned error: %v", err)
+	}
+	lastCheck := time.Date(2026, 10, 16, 2, 59, 30, 0, time.Local)
+	if !BackupDue(schedule, lastCheck, time.Date(2026, 10, 16, 3, 0, 1, 0, time.Local)) {
+		t.Fatal("the 03:00 slot should be due")
+	}
+	if BackupDue(schedule, time.Date(2026, 10, 16, 3, 0, 1, 0, time.Local), time.Date(2026, 10, 16, 3, 1, 0, 0, time.Local)) {
+		t.Fatal("a slot before the last check must not run again")
+	}
+}
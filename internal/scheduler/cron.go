@@ -0,0 +1,192 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchHorizon 限制 Next 向后查找的范围，足以覆盖 2 月 29 日这类每四年才出现一次的时间点。
+const cronSearchHorizon = 5 * 366 * 24 * time.Hour
+
+var cronMacros = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// CronSchedule 是标准五段式 cron 表达式：分 时 日 月 周，按本地时间计算。
+// 每段支持 *、数字、范围 a-b、步长 */n 或 a-b/n 以及逗号列表；周日可写作 0 或 7。
+// 与常见 cron 实现一致，日和周同时受限时任一匹配即触发。
+type CronSchedule struct {
+	raw      string
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+	dayStar  bool
+	weekStar bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var (
+	cronMinuteField  = cronField{name: "分钟", min: 0, max: 59}
+	cronHourField    = cronField{name: "小时", min: 0, max: 23}
+	cronDayField     = cronField{name: "日期", min: 1, max: 31}
+	cronMonthField   = cronField{name: "月份", min: 1, max: 12}
+	cronWeekdayField = cronField{name: "星期", min: 0, max: 7}
+)
+
+// ParseCronSchedule 解析 cron 表达式，另支持 @hourly、@daily、@weekly、@monthly。
+func ParseCronSchedule(raw string) (CronSchedule, error) {
+	normalized := strings.Join(strings.Fields(raw), " ")
+	if normalized == "" {
+		return CronSchedule{}, errors.New("请填写备份计划的 cron 表达式")
+	}
+	expression := normalized
+	if macro, ok := cronMacros[strings.ToLower(normalized)]; ok {
+		normalized = strings.ToLower(normalized)
+		expression = macro
+	}
+	parts := strings.Fields(expression)
+	if len(parts) != 5 {
+		return CronSchedule{}, fmt.Errorf("cron 表达式应包含 5 段（分 时 日 月 周），当前为 %d 段", len(parts))
+	}
+
+	schedule := CronSchedule{raw: normalized, dayStar: parts[2] == "*", weekStar: parts[4] == "*"}
+	var err error
+	if schedule.minutes, err = parseCronField(parts[0], cronMinuteField); err != nil {
+		return CronSchedule{}, err
+	}
+	if schedule.hours, err = parseCronField(parts[1], cronHourField); err != nil {
+		return CronSchedule{}, err
+	}
+	if schedule.days, err = parseCronField(parts[2], cronDayField); err != nil {
+		return CronSchedule{}, err
+	}
+	if schedule.months, err = parseCronField(parts[3], cronMonthField); err != nil {
+		return CronSchedule{}, err
+	}
+	if schedule.weekdays, err = parseCronField(parts[4], cronWeekdayField); err != nil {
+		return CronSchedule{}, err
+	}
+	if schedule.weekdays&(1<<7) != 0 {
+		schedule.weekdays |= 1
+	}
+	if schedule.Next(time.Date(2000, time.January, 1, 0, 0, 0, 0, time.Local)).IsZero() {
+		return CronSchedule{}, fmt.Errorf("cron 表达式 %q 永远不会触发", normalized)
+	}
+	return schedule, nil
+}
+
+func parseCronField(raw string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(raw, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			value, err := strconv.Atoi(stepPart)
+			if err != nil || value <= 0 {
+				return 0, fmt.Errorf("%s字段的步长 %q 无效", field.name, stepPart)
+			}
+			step = value
+		}
+
+		start, end := field.min, field.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			lowRaw, highRaw, _ := strings.Cut(rangePart, "-")
+			low, err := parseCronValue(lowRaw, field)
+			if err != nil {
+				return 0, err
+			}
+			high, err := parseCronValue(highRaw, field)
+			if err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("%s字段的范围 %q 起点大于终点", field.name, rangePart)
+			}
+			start, end = low, high
+		default:
+			value, err := parseCronValue(rangePart, field)
+			if err != nil {
+				return 0, err
+			}
+			start = value
+			if !hasStep {
+				end = value
+			}
+		}
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+func parseCronValue(raw string, field cronField) (int, error) {
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < field.min || value > field.max {
+		return 0, fmt.Errorf("%s字段的取值 %q 无效，应在 %d-%d 之间", field.name, raw, field.min, field.max)
+	}
+	return value, nil
+}
+
+// String 返回规范化后的表达式，宏保持原样。
+func (s CronSchedule) String() string {
+	return s.raw
+}
+
+// IsZero 判断是否为未解析的空计划。
+func (s CronSchedule) IsZero() bool {
+	return s.raw == ""
+}
+
+// Next 返回严格晚于 after 的下一次触发时间，找不到时返回零值。
+func (s CronSchedule) Next(after time.Time) time.Time {
+	if s.IsZero() {
+		return time.Time{}
+	}
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.Add(cronSearchHorizon)
+	for !t.After(limit) {
+		if s.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s CronSchedule) matchesDay(t time.Time) bool {
+	dayMatch := s.days&(1<<uint(t.Day())) != 0
+	weekMatch := s.weekdays&(1<<uint(t.Weekday())) != 0
+	if s.dayStar || s.weekStar {
+		return dayMatch && weekMatch
+	}
+	return dayMatch || weekMatch
+}
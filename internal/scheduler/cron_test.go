@@ -0,0 +1,50 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	for _, tc := range []struct {
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		{"0 3 * * *", at(10, 16, 2, 59), at(10, 16, 3, 0)},
+		{"0 3 * * *", at(10, 16, 3, 0), at(10, 17, 3, 0)},
+		{"*/15 * * * *", at(10, 16, 8, 7), at(10, 16, 8, 15)},
+		{"30 1-5/2 * * *", at(10, 16, 2, 0), at(10, 16, 3, 30)},
+		// 2026-10-18 是周日，7 与 0 等价。
+		{"0 4 * * 7", at(10, 16, 12, 0), at(10, 18, 4, 0)},
+		// 日和周同时受限时任一匹配即可：10-20 是周二，先于 11-01。
+		{"0 0 1 * 2", at(10, 16, 12, 0), at(10, 20, 0, 0)},
+		{"@monthly", at(10, 16, 12, 0), at(11, 1, 0, 0)},
+		{"0 0 29 2 *", at(10, 16, 12, 0), time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+	} {
+		schedule, err := ParseCronSchedule(tc.expr)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tc.expr, err)
+		}
+		if got := schedule.Next(tc.after); !got.Equal(tc.want) {
+			t.Fatalf("%q.Next(%s) = %s, want %s", tc.expr, tc.after.Format(time.RFC3339), got.Format(time.RFC3339), tc.want.Format(time.RFC3339))
+		}
+	}
+}
+
+func TestParseCronScheduleRejectsInvalidExpressions(t *testing.T) {
+	schedule, err := ParseCronSchedule("  0   3 * *  * ")
+	if err != nil || schedule.String() != "0 3 * * *" {
+		t.Fatalf("expected whitespace to be normalized, got %q err=%v", schedule.String(), err)
+	}
+
+	for _, raw := range []string{"", "0 3 * *", "60 * * * *", "0 5-1 * * *", "*/0 * * * *", "0 0 31 2 *", "@yearly"} {
+		if _, err := ParseCronSchedule(raw); err == nil {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+}
@@ -11,8 +11,8 @@ import (
 
 	"github.com/pokerjest/animateAutoTool/internal/config"
 	"github.com/pokerjest/animateAutoTool/internal/db"
-	"github.com/pokerjest/animateAutoTool/internal/downloader"
 	"github.com/pokerjest/animateAutoTool/internal/event"
+	"github.com/pokerjest/animateAutoTool/internal/model"
 	"github.com/pokerjest/animateAutoTool/internal/qbutil"
 	"github.com/pokerjest/animateAutoTool/internal/runtimejournal"
 	"github.com/pokerjest/animateAutoTool/internal/service"
@@ -20,12 +20,17 @@ import (
 )
 
 type Manager struct {
-	ticker    *time.Ticker
 	quit      chan struct{}
 	ctx       context.Context
 	stopOnce  sync.Once
 	startOnce sync.Once
 	wg        sync.WaitGroup
+
+	calendar *broadcastCalendar
+
+	// now 和 policy 可在测试中替换，用于模拟时钟和调度设置。
+	now    func() time.Time
+	policy func() Policy
 }
 
 var schedulerRunInProgress atomic.Bool
@@ -35,32 +40,36 @@ func NewManager() *Manager {
 }
 
 func NewManagerWithContext(ctx context.Context) *Manager {
-	// 每15分钟检查一次
 	if ctx == nil {
 		ctx = context.Background()
 	}
 	return &Manager{
-		ticker: time.NewTicker(15 * time.Minute),
-		quit:   make(chan struct{}),
-		ctx:    ctx,
+		quit:     make(chan struct{}),
+		ctx:      ctx,
+		calendar: newBroadcastCalendar(),
+		now:      time.Now,
+		policy:   LoadPolicy,
 	}
 }
 
 func (m *Manager) Start() {
 	m.startOnce.Do(func() {
-		log.Println("Scheduler: started interval=15m")
+		log.Printf("Scheduler: started interval=%s", m.currentPolicy().Interval)
 		m.wg.Add(2)
 		go func() {
 			defer m.wg.Done()
-			defer m.ticker.Stop()
 			for {
+				// 每轮重新读取间隔，设置页修改后无需重启即可生效
+				timer := time.NewTimer(m.currentPolicy().Interval)
 				select {
-				case <-m.ticker.C:
+				case <-timer.C:
 					m.runCheckSafely(m.ctx, "periodic")
 				case <-m.ctx.Done():
+					timer.Stop()
 					log.Printf("Scheduler: loop stopped reason=%v", m.ctx.Err())
 					return
 				case <-m.quit:
+					timer.Stop()
 					log.Printf("Scheduler: loop stopped reason=stop_requested")
 					return
 				}
@@ -91,7 +100,7 @@ func (m *Manager) CheckUpdates() {
 
 func (m *Manager) runCheckSafely(ctx context.Context, trigger string) {
 	runSchedulerSafely(trigger, func() {
-		m.CheckUpdatesContext(ctx)
+		m.checkUpdates(ctx, trigger)
 	})
 }
 
@@ -119,23 +128,50 @@ func runSchedulerSafely(trigger string, run func()) {
 	}
 }
 
+// CheckUpdatesContext 手动检查所有活跃订阅，不受静默时段和检查间隔限制。
 func (m *Manager) CheckUpdatesContext(ctx context.Context) {
+	m.checkUpdates(ctx, "manual")
+}
+
+func isManualTrigger(trigger string) bool {
+	return trigger == "manual"
+}
+
+func runSourceForTrigger(trigger string) string {
+	if isManualTrigger(trigger) {
+		return "manual"
+	}
+	return "auto"
+}
+
+func (m *Manager) checkUpdates(ctx context.Context, trigger string) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	source := runSourceForTrigger(trigger)
 	if err := ctx.Err(); err != nil {
 		log.Printf("Scheduler: update check skipped reason=context_canceled error=%v", err)
 		return
 	}
 	if runtimejournal.RecoveryBlocked() {
 		log.Println("Scheduler: Skipping update check because database recovery is blocked.")
-		status := GlobalRunStatus.Skip("auto", "数据库完整性检查失败，自动任务已停用")
+		status := GlobalRunStatus.Skip(source, "数据库完整性检查失败，自动任务已停用")
 		publishSchedulerStatus(status)
 		return
 	}
 	if runtimejournal.RecoveryInProgress() {
 		log.Println("Scheduler: Skipping update check while crash recovery is in progress.")
-		status := GlobalRunStatus.Skip("auto", "异常退出恢复正在运行")
+		status := GlobalRunStatus.Skip(source, "异常退出恢复正在运行")
+		publishSchedulerStatus(status)
+		return
+	}
+	policy := m.currentPolicy()
+	now := m.currentTime()
+	if !isManualTrigger(trigger) && policy.QuietHours.Contains(now) {
+		log.Printf("Scheduler: update check skipped reason=quiet_hours trigger=%s quiet_hours=%s", trigger, policy.QuietHours)
+		summary := fmt.Sprintf("处于静默时段 %s，仅执行手动检查", policy.QuietHours)
+		m.recordQuietHoursSkips(now, summary)
+		status := GlobalRunStatus.Skip(source, summary)
 		publishSchedulerStatus(status)
 		return
 	}
@@ -146,38 +182,46 @@ func (m *Manager) CheckUpdatesContext(ctx context.Context) {
 	defer schedulerRunInProgress.Store(false)
 
 	startedAt := time.Now()
-	log.Println("Scheduler: update check starting")
+	log.Printf("Scheduler: update check starting trigger=%s", trigger)
 	subStore := store.NewSubscriptionStore(db.DB)
-	subs, err := subStore.ListActive()
+	subs, err := subStore.ListActiveWithMetadata()
 	if err != nil {
 		log.Printf("Scheduler Error: Failed to fetch subscriptions: %v", err)
-		status := GlobalRunStatus.Skip("auto", "获取订阅列表失败")
+		status := GlobalRunStatus.Skip(source, "获取订阅列表失败")
 		publishSchedulerStatus(status)
 		return
 	}
 
-	GlobalRunStatus.Begin("auto", len(subs))
+	total := len(subs)
+	subs = m.planSubscriptions(ctx, subs, trigger, now, policy)
+	GlobalRunStatus.Begin(source, total)
 	publishSchedulerStatus(GlobalRunStatus.Snapshot())
+	if len(subs) == 0 {
+		status := GlobalRunStatus.Finish(0, 0, 0, total, source, "")
+		publishSchedulerStatus(status)
+		log.Printf("Scheduler: update check completed subscriptions=%d due=0", total)
+		return
+	}
 
 	qbCfg := qbutil.LoadConfig()
 	if qbutil.ManagedBinaryMissing(qbCfg, config.BinDir()) {
 		log.Printf("Scheduler: Skipping update check because qBittorrent is not installed and no external WebUI is configured.")
-		status := GlobalRunStatus.Skip("auto", "未检测到可用的 qBittorrent 配置")
+		status := GlobalRunStatus.Skip(source, "未检测到可用的 qBittorrent 配置")
 		publishSchedulerStatus(status)
 		return
 	}
 	if qbutil.MissingExternalURL(qbCfg) {
 		log.Printf("Scheduler: Skipping update check because external qBittorrent mode has no WebUI URL configured.")
-		status := GlobalRunStatus.Skip("auto", "外部 qBittorrent 模式缺少 WebUI 地址")
+		status := GlobalRunStatus.Skip(source, "外部 qBittorrent 模式缺少 WebUI 地址")
 		publishSchedulerStatus(status)
 		return
 	}
 
 	// Initialize Service Manager
-	qbt := downloader.NewQBittorrentClient(qbCfg.URL)
+	qbt := qbutil.NewClient(qbCfg)
 	if err := qbt.LoginContext(ctx, qbCfg.Username, qbCfg.Password); err != nil {
-		log.Printf("Scheduler Warning: QB unavailable: %v", err)
-		status := GlobalRunStatus.Skip("auto", "qBittorrent 登录失败")
+		log.Printf("Scheduler Warning: downloader unavailable: %v", err)
+		status := GlobalRunStatus.Skip(source, "下载器登录失败")
 		status.LastError = err.Error()
 		publishSchedulerStatus(status)
 		return // Can't do anything without QB
@@ -198,8 +242,8 @@ func (m *Manager) CheckUpdatesContext(ctx context.Context) {
 	lastErr := ""
 
 	for _, sub := range subs {
-		log.Printf("Scheduler: checking subscription subscription_id=%d title=%q", sub.ID, sub.Title)
-		mgr.ProcessSubscriptionWithSourceContext(ctx, &sub, "auto")
+		log.Printf("Scheduler: checking subscription subscription_id=%d title=%q reason=%s", sub.ID, sub.Title, sub.ScheduleReason)
+		mgr.ProcessSubscriptionWithSourceContext(ctx, &sub, source)
 		switch sub.LastRunStatus {
 		case "success", "idle":
 			successCount++
@@ -218,10 +262,11 @@ func (m *Manager) CheckUpdatesContext(ctx context.Context) {
 		}
 	}
 
-	status := GlobalRunStatus.Finish(successCount, warningCount, errorCount, len(subs), "auto", lastErr)
+	status := GlobalRunStatus.Finish(successCount, warningCount, errorCount, total, source, lastErr)
 	publishSchedulerStatus(status)
 	log.Printf(
-		"Scheduler: update check completed subscriptions=%d success=%d warnings=%d errors=%d duration=%s",
+		"Scheduler: update check completed subscriptions=%d due=%d success=%d warnings=%d errors=%d duration=%s",
+		total,
 		len(subs),
 		successCount,
 		warningCount,
@@ -230,6 +275,65 @@ func (m *Manager) CheckUpdatesContext(ctx context.Context) {
 	)
 }
 
+// planSubscriptions 返回本轮需要检查的订阅，并把跳过原因写入运行记录。
+// 手动触发会检查全部活跃订阅。
+func (m *Manager) planSubscriptions(ctx context.Context, subs []model.Subscription, trigger string, now time.Time, policy Policy) []model.Subscription {
+	if isManualTrigger(trigger) {
+		for i := range subs {
+			subs[i].ScheduleReason = ScheduleReasonManual
+		}
+		return subs
+	}
+
+	var weekdays map[int]time.Weekday
+	if policy.SmartPolling && m.calendar != nil {
+		weekdays = m.calendar.Weekdays(ctx, now)
+	}
+	due := make([]model.Subscription, 0, len(subs))
+	for _, sub := range subs {
+		decision := decideSubscription(sub, now, policy, airSlotFor(sub, weekdays, now))
+		if decision.Due {
+			sub.ScheduleReason = decision.Reason
+			due = append(due, sub)
+			continue
+		}
+		if err := service.RecordSubscriptionScheduleSkip(&sub, now, decision.Reason, decision.Summary); err != nil {
+			log.Printf("Scheduler Warning: failed to record schedule skip subscription_id=%d error=%v", sub.ID, err)
+		}
+	}
+	return due
+}
+
+func (m *Manager) recordQuietHoursSkips(now time.Time, summary string) {
+	if db.DB == nil {
+		return
+	}
+	subs, err := store.NewSubscriptionStore(db.DB).ListActive()
+	if err != nil {
+		log.Printf("Scheduler Warning: failed to list subscriptions for quiet hours: %v", err)
+		return
+	}
+	for i := range subs {
+		if err := service.RecordSubscriptionScheduleSkip(&subs[i], now, ScheduleReasonQuietHours, summary); err != nil {
+			log.Printf("Scheduler Warning: failed to record schedule skip subscription_id=%d error=%v", subs[i].ID, err)
+		}
+	}
+}
+
+func (m *Manager) currentTime() time.Time {
+	if m.now != nil {
+		return m.now()
+	}
+	return time.Now()
+}
+
+func (m *Manager) currentPolicy() Policy {
+	if m.policy != nil {
+		return m.policy()
+	}
+	return LoadPolicy()
+}
+
 func IsRunInProgress() bool {
 	return schedulerRunInProgress.Load()
 }
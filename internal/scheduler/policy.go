@@ -0,0 +1,126 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+)
+
+const (
+	DefaultIntervalMinutes = 15
+	MinIntervalMinutes     = 5
+	MaxIntervalMinutes     = 24 * 60
+)
+
+// Policy 描述自动检查的节奏：全局间隔、可选的静默时段和是否按放送时间智能轮询。
+type Policy struct {
+	Interval     time.Duration
+	QuietHours   QuietHours
+	SmartPolling bool
+}
+
+// QuietHours 是按本地时间计算的每日静默时段，Start 到 End 之间只允许手动触发。
+// End 小于 Start 时表示跨越午夜，例如 23:00-07:00。
+type QuietHours struct {
+	Start time.Duration
+	End   time.Duration
+	Set   bool
+}
+
+// ParseQuietHours 解析 "HH:MM-HH:MM" 格式的静默时段，空字符串表示不启用。
+func ParseQuietHours(raw string) (QuietHours, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return QuietHours{}, nil
+	}
+	startRaw, endRaw, ok := strings.Cut(raw, "-")
+	if !ok {
+		return QuietHours{}, errors.New("静默时段格式应为 HH:MM-HH:MM")
+	}
+	start, err := parseClock(startRaw)
+	if err != nil {
+		return QuietHours{}, err
+	}
+	end, err := parseClock(endRaw)
+	if err != nil {
+		return QuietHours{}, err
+	}
+	if start == end {
+		return QuietHours{}, errors.New("静默时段的开始和结束时间不能相同")
+	}
+	return QuietHours{Start: start, End: end, Set: true}, nil
+}
+
+func parseClock(raw string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(raw))
+	if err != nil {
+		return 0, fmt.Errorf("无法识别的时间 %q，应为 HH:MM", strings.TrimSpace(raw))
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// String 返回规范化后的 "HH:MM-HH:MM"，未启用时返回空字符串。
+func (q QuietHours) String() string {
+	if !q.Set {
+		return ""
+	}
+	return formatClock(q.Start) + "-" + formatClock(q.End)
+}
+
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
+
+// Contains 判断给定时间是否落在静默时段内。
+func (q QuietHours) Contains(now time.Time) bool {
+	if !q.Set {
+		return false
+	}
+	clock := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute + time.Duration(now.Second())*time.Second
+	if q.Start < q.End {
+		return clock >= q.Start && clock < q.End
+	}
+	return clock >= q.Start || clock < q.End
+}
+
+// NormalizeIntervalMinutes 校验全局检查间隔设置，空值表示使用默认值。
+func NormalizeIntervalMinutes(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < MinIntervalMinutes || n > MaxIntervalMinutes {
+		return "", fmt.Errorf("订阅检查间隔必须是 %d 到 %d 之间的分钟数", MinIntervalMinutes, MaxIntervalMinutes)
+	}
+	return strconv.Itoa(n), nil
+}
+
+// LoadPolicy 从系统设置读取调度节奏，无效值回退到默认配置。
+func LoadPolicy() Policy {
+	policy := Policy{Interval: DefaultIntervalMinutes * time.Minute, SmartPolling: true}
+	if raw, err := NormalizeIntervalMinutes(readSetting(model.ConfigKeySchedulerIntervalMinutes)); err == nil && raw != "" {
+		n, _ := strconv.Atoi(raw)
+		policy.Interval = time.Duration(n) * time.Minute
+	}
+	if quiet, err := ParseQuietHours(readSetting(model.ConfigKeySchedulerQuietHours)); err == nil {
+		policy.QuietHours = quiet
+	}
+	if strings.EqualFold(readSetting(model.ConfigKeySchedulerSmartPolling), "false") {
+		policy.SmartPolling = false
+	}
+	return policy
+}
+
+func readSetting(key string) string {
+	if db.DB == nil {
+		return ""
+	}
+	return strings.TrimSpace(store.NewConfigStore(db.DB).GetDefault(key, ""))
+}
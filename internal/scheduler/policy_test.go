@@ -0,0 +1,124 @@
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/model"
+)
+
+func TestParseQuietHours(t *testing.T) {
+	quiet, err := ParseQuietHours(" 23:30 - 7:00 ")
+	if err != nil {
+		t.Fatalf("parse quiet hours: %v", err)
+	}
+	if quiet.String() != "23:30-07:00" {
+		t.Fatalf("unexpected normalized quiet hours: %q", quiet.String())
+	}
+
+	empty, err := ParseQuietHours("")
+	if err != nil || empty.Set {
+		t.Fatalf("expected empty quiet hours to be disabled, got %+v err=%v", empty, err)
+	}
+
+	for _, raw := range []string{"23:00", "25:00-07:00", "08:00-08:00"} {
+		if _, err := ParseQuietHours(raw); err == nil {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+}
+
+func TestQuietHoursContains(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 10, 16, hour, minute, 0, 0, time.Local)
+	}
+
+	overnight, _ := ParseQuietHours("23:00-07:00")
+	for _, tc := range []struct {
+		now  time.Time
+		want bool
+	}{
+		{at(23, 0), true},
+		{at(2, 30), true},
+		{at(6, 59), true},
+		{at(7, 0), false},
+		{at(12, 0), false},
+	} {
+		if got := overnight.Contains(tc.now); got != tc.want {
+			t.Fatalf("overnight.Contains(%s) = %v, want %v", tc.now.Format("15:04"), got, tc.want)
+		}
+	}
+
+	daytime, _ := ParseQuietHours("09:00-18:00")
+	if !daytime.Contains(at(9, 0)) || daytime.Contains(at(18, 0)) || daytime.Contains(at(3, 0)) {
+		t.Fatal("unexpected daytime quiet hours window")
+	}
+	if (QuietHours{}).Contains(at(3, 0)) {
+		t.Fatal("disabled quiet hours should never match")
+	}
+}
+
+func TestNormalizeIntervalMinutes(t *testing.T) {
+	if got, err := NormalizeIntervalMinutes(" 30 "); err != nil || got != "30" {
+		t.Fatalf("expected 30, got %q err=%v", got, err)
+	}
+	if got, err := NormalizeIntervalMinutes(""); err != nil || got != "" {
+		t.Fatalf("expected empty interval to keep the default, got %q err=%v", got, err)
+	}
+	for _, raw := range []string{"1", "abc", "100000"} {
+		if _, err := NormalizeIntervalMinutes(raw); err == nil {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+}
+
+func TestDecideSubscriptionSkipsRecentChecks(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+	subs := []model.Subscription{
+		{Title: "never checked"},
+		{Title: "checked last tick", LastCheckAt: ago(14 * time.Minute)},
+		{Title: "manually checked", LastCheckAt: ago(3 * time.Minute)},
+		{Title: "finished show", CheckIntervalMinutes: 360, LastCheckAt: ago(2 * time.Hour)},
+		{Title: "finished show due", CheckIntervalMinutes: 360, LastCheckAt: ago(6 * time.Hour)},
+	}
+
+	policy := Policy{Interval: 15 * time.Minute}
+	var titles []string
+	for _, sub := range subs {
+		if decideSubscription(sub, now, policy, nil).Due {
+			titles = append(titles, sub.Title)
+		}
+	}
+	if got := strings.Join(titles, ","); got != "never checked,checked last tick,finished show due" {
+		t.Fatalf("unexpected due subscriptions: %s", got)
+	}
+	if decision := decideSubscription(subs[2], now, policy, nil); decision.Reason != ScheduleReasonRecentlyChecked {
+		t.Fatalf("expected recently checked reason, got %+v", decision)
+	}
+}
+
+func TestCheckUpdatesSkipsAutomaticRunsDuringQuietHours(t *testing.T) {
+	t.Cleanup(func() {
+		GlobalRunStatus.Skip("", "")
+	})
+	quiet, _ := ParseQuietHours("01:00-07:00")
+	mgr := NewManagerWithContext(context.Background())
+	mgr.now = func() time.Time { return time.Date(2026, 10, 16, 3, 0, 0, 0, time.Local) }
+	mgr.policy = func() Policy { return Policy{Interval: 15 * time.Minute, QuietHours: quiet} }
+
+	mgr.checkUpdates(context.Background(), "periodic")
+
+	status := GlobalRunStatus.Snapshot()
+	if status.LastRunSource != "auto" || !strings.Contains(status.LastSummary, "静默时段 01:00-07:00") {
+		t.Fatalf("expected quiet hours skip, got %+v", status)
+	}
+	if IsRunInProgress() {
+		t.Fatal("quiet hours skip should not hold the run guard")
+	}
+}
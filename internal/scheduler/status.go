@@ -4,6 +4,14 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/metrics"
+)
+
+var (
+	schedulerRuns             = metrics.NewCounterVec("animate_scheduler_runs_total", "Subscription check runs by trigger source and outcome (success, warning, error, skipped).", "source", "outcome")
+	schedulerRunDuration      = metrics.NewHistogramVec("animate_scheduler_run_duration_seconds", "Duration of completed subscription check runs.", []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800}, "source")
+	schedulerSubscriptionRuns = metrics.NewCounterVec("animate_scheduler_subscription_checks_total", "Subscriptions checked by scheduler runs, by result.", "result")
 )
 
 type RunStatus struct {
@@ -58,6 +66,7 @@ func (t *statusTracker) Skip(source, summary string) RunStatus {
 	t.status.SkippedCount = 0
 	t.status.LastSummary = summary
 	t.status.LastError = ""
+	schedulerRuns.Inc(source, "skipped")
 
 	return t.status
 }
@@ -79,7 +88,11 @@ func (t *statusTracker) Finish(success, warning, failure, total int, source, las
 	t.status.ErrorCount = failure
 	t.status.SkippedCount = max(total-success-warning-failure, 0)
 	t.status.LastError = lastErr
-	t.status.LastSummary = fmt.Sprintf("最近一轮共检查 %d 个订阅：成功 %d，警告 %d，失败 %d", total, success, warning, failure)
+	t.status.LastSummary = fmt.Sprintf("最近一轮共检查 %d 个订阅：成功 %d，警告 %d，失败 %d", success+warning+failure, success, warning, failure)
+	if t.status.SkippedCount > 0 {
+		t.status.LastSummary += fmt.Sprintf("，按调度跳过 %d", t.status.SkippedCount)
+	}
+	recordRunMetrics(t.status, now)
 
 	return t.status
 }
@@ -92,6 +105,26 @@ func (t *statusTracker) Snapshot() RunStatus {
 
 var GlobalRunStatus = &statusTracker{}
 
+// recordRunMetrics 把一轮完成的检查计入 /metrics。有失败时整轮记为 error，
+// 只有警告时记为 warning。
+func recordRunMetrics(status RunStatus, finishedAt time.Time) {
+	outcome := "success"
+	switch {
+	case status.ErrorCount > 0:
+		outcome = "error"
+	case status.WarningCount > 0:
+		outcome = "warning"
+	}
+	schedulerRuns.Inc(status.LastRunSource, outcome)
+	if status.LastStartedAt != nil {
+		schedulerRunDuration.Observe(finishedAt.Sub(*status.LastStartedAt).Seconds(), status.LastRunSource)
+	}
+	schedulerSubscriptionRuns.Add(float64(status.SuccessCount), "success")
+	schedulerSubscriptionRuns.Add(float64(status.WarningCount), "warning")
+	schedulerSubscriptionRuns.Add(float64(status.ErrorCount), "error")
+	schedulerSubscriptionRuns.Add(float64(status.SkippedCount), "skipped")
+}
+
 func max(a, b int) int {
 	if a > b {
 		return a
@@ -9,6 +9,8 @@ import (
 
 func TestStatusTrackerBeginAndFinish(t *testing.T) {
 	tracker := &statusTracker{}
+	errorRunsBefore := schedulerRuns.Value("auto", "error")
+	durationsBefore := schedulerRunDuration.Count("auto")
 
 	tracker.Begin("auto", 5)
 	running := tracker.Snapshot()
@@ -32,6 +34,9 @@ func TestStatusTrackerBeginAndFinish(t *testing.T) {
 	if final.LastError != "sample error" {
 		t.Fatalf("unexpected last error: %q", final.LastError)
 	}
+	if schedulerRuns.Value("auto", "error")-errorRunsBefore != 1 || schedulerRunDuration.Count("auto")-durationsBefore != 1 {
+		t.Fatal("expected the finished run to be counted as an error run with a duration")
+	}
 }
 
 func TestSchedulerRunGuard(t *testing.T) {
@@ -0,0 +1,263 @@
+package security
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	jwksCacheTTL        = time.Hour
+	jwksRefetchInterval = time.Minute
+	jwksMaxBodyBytes    = 1 << 20
+	jwtClockLeeway      = time.Minute
+)
+
+var (
+	ErrJWTMalformed   = errors.New("malformed jwt")
+	ErrJWTAlgorithm   = errors.New("unsupported jwt algorithm")
+	ErrJWTUnknownKey  = errors.New("jwt signed by an unknown key")
+	ErrJWTSignature   = errors.New("invalid jwt signature")
+	ErrJWTExpired     = errors.New("jwt expired or not yet valid")
+	ErrJWTAudience    = errors.New("jwt audience mismatch")
+	ErrJWTIssuer      = errors.New("jwt issuer mismatch")
+	ErrJWKSUnreadable = errors.New("unable to load jwks")
+)
+
+// JWTClaims holds the registered claims plus the email claim that identity
+// proxies such as Cloudflare Access put into their assertions.
+type JWTClaims struct {
+	Subject   string
+	Email     string
+	Issuer    string
+	Audience  []string
+	ExpiresAt time.Time
+	NotBefore time.Time
+}
+
+// JWKSVerifier checks RS256 JWTs against the keys published at a JWKS URL.
+// Keys are cached for an hour; an unknown key ID triggers a refetch at most
+// once a minute so rotated keys are picked up without hammering the issuer.
+type JWKSVerifier struct {
+	url    string
+	client *http.Client
+	now    func() time.Time
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+func NewJWKSVerifier(url string, client *http.Client) *JWKSVerifier {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &JWKSVerifier{url: url, client: client, now: time.Now}
+}
+
+// URL returns the JWKS endpoint the verifier loads keys from.
+func (v *JWKSVerifier) URL() string {
+	return v.url
+}
+
+// Verify validates the signature, lifetime, audience and issuer of token and
+// returns its claims. An identity provider usually signs tokens for all of its
+// applications with the same keys, so the audience is required. The issuer
+// must be the origin of the JWKS URL or a path prefix of it, which covers
+// Cloudflare Access (https://<team>.cloudflareaccess.com) as well as OIDC
+// providers that publish their keys below the issuer URL.
+func (v *JWKSVerifier) Verify(ctx context.Context, token, audience string) (*JWTClaims, error) {
+	if audience == "" {
+		return nil, ErrJWTAudience
+	}
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return nil, ErrJWTMalformed
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Alg != "RS256" {
+		return nil, ErrJWTAlgorithm
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrJWTMalformed
+	}
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, ErrJWTSignature
+	}
+
+	var payload struct {
+		Sub   string          `json:"sub"`
+		Email string          `json:"email"`
+		Iss   string          `json:"iss"`
+		Aud   json.RawMessage `json:"aud"`
+		Exp   *float64        `json:"exp"`
+		Nbf   *float64        `json:"nbf"`
+	}
+	if err := decodeJWTSegment(parts[1], &payload); err != nil {
+		return nil, err
+	}
+	claims := &JWTClaims{Subject: payload.Sub, Email: payload.Email, Issuer: payload.Iss}
+	if len(payload.Aud) > 0 {
+		var single string
+		if err := json.Unmarshal(payload.Aud, &single); err == nil {
+			claims.Audience = []string{single}
+		} else if err := json.Unmarshal(payload.Aud, &claims.Audience); err != nil {
+			return nil, ErrJWTMalformed
+		}
+	}
+	now := v.now()
+	if payload.Exp == nil {
+		return nil, ErrJWTExpired
+	}
+	claims.ExpiresAt = time.Unix(int64(*payload.Exp), 0)
+	if now.After(claims.ExpiresAt.Add(jwtClockLeeway)) {
+		return nil, ErrJWTExpired
+	}
+	if payload.Nbf != nil {
+		claims.NotBefore = time.Unix(int64(*payload.Nbf), 0)
+		if now.Add(jwtClockLeeway).Before(claims.NotBefore) {
+			return nil, ErrJWTExpired
+		}
+	}
+	if !containsString(claims.Audience, audience) {
+		return nil, ErrJWTAudience
+	}
+	if !v.issuerTrusted(claims.Issuer) {
+		return nil, ErrJWTIssuer
+	}
+	return claims, nil
+}
+
+func (v *JWKSVerifier) issuerTrusted(issuer string) bool {
+	if issuer == "" {
+		return false
+	}
+	keys, err := url.Parse(v.url)
+	if err != nil {
+		return false
+	}
+	iss, err := url.Parse(issuer)
+	if err != nil || !strings.EqualFold(iss.Scheme, keys.Scheme) || !strings.EqualFold(iss.Host, keys.Host) {
+		return false
+	}
+	prefix := strings.TrimRight(iss.Path, "/")
+	return prefix == "" || keys.Path == prefix || strings.HasPrefix(keys.Path, prefix+"/")
+}
+
+func (v *JWKSVerifier) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := v.now()
+	fresh := v.keys != nil && now.Sub(v.fetchedAt) < jwksCacheTTL
+	if key, ok := v.keys[kid]; ok && fresh {
+		return key, nil
+	}
+	if v.keys != nil && now.Sub(v.fetchedAt) < jwksRefetchInterval {
+		return nil, ErrJWTUnknownKey
+	}
+	keys, err := v.fetch(ctx)
+	if err != nil {
+		// Keep serving the cached keys if the issuer is briefly unreachable.
+		if key, ok := v.keys[kid]; ok {
+			return key, nil
+		}
+		return nil, err
+	}
+	v.keys = keys
+	v.fetchedAt = now
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, ErrJWTUnknownKey
+}
+
+func (v *JWKSVerifier) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrJWKSUnreadable, err)
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrJWKSUnreadable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", ErrJWKSUnreadable, resp.StatusCode)
+	}
+	var doc struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, jwksMaxBodyBytes)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrJWKSUnreadable, err)
+	}
+	keys := make(map[string]*rsa.PublicKey, len(doc.Keys))
+	for _, entry := range doc.Keys {
+		if entry.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(entry.N)
+		e, errE := base64.RawURLEncoding.DecodeString(entry.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			continue
+		}
+		exponent := 0
+		for _, b := range e {
+			exponent = exponent<<8 | int(b)
+		}
+		keys[entry.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: no RSA keys", ErrJWKSUnreadable)
+	}
+	return keys, nil
+}
+
+func decodeJWTSegment(segment string, target any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return ErrJWTMalformed
+	}
+	if err := json.Unmarshal(raw, target); err != nil {
+		return ErrJWTMalformed
+	}
+	return nil
+}
+
+func containsString(values []string, want string) bool {
+	for _, value := range values {
+		if value == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package security
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func signTestJWT(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func serveTestJWKS(t *testing.T, key *rsa.PublicKey, kid string, fetches *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kid": kid,
+			"kty": "RSA",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestJWKSVerifierChecksSignatureLifetimeAudienceAndIssuer(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	var fetches atomic.Int32
+	server := serveTestJWKS(t, &key.PublicKey, "k1", &fetches)
+	verifier := NewJWKSVerifier(server.URL, server.Client())
+	now := time.Now()
+	claims := map[string]any{"email": "yuki@example.com", "iss": server.URL, "aud": []string{"aud-tag"}, "exp": now.Add(time.Hour).Unix()}
+
+	got, err := verifier.Verify(context.Background(), signTestJWT(t, key, "k1", claims), "aud-tag")
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if got.Email != "yuki@example.com" {
+		t.Fatalf("unexpected claims %+v", got)
+	}
+	if _, err := verifier.Verify(context.Background(), signTestJWT(t, key, "k1", claims), "other"); !errors.Is(err, ErrJWTAudience) {
+		t.Fatalf("expected audience mismatch, got %v", err)
+	}
+	if _, err := verifier.Verify(context.Background(), signTestJWT(t, key, "k1", claims), ""); !errors.Is(err, ErrJWTAudience) {
+		t.Fatalf("an empty audience must be rejected, got %v", err)
+	}
+	for _, issuer := range []string{"", "https://other-team.cloudflareaccess.com", server.URL + "/elsewhere"} {
+		foreign := map[string]any{"email": "yuki@example.com", "iss": issuer, "aud": "aud-tag", "exp": now.Add(time.Hour).Unix()}
+		if _, err := verifier.Verify(context.Background(), signTestJWT(t, key, "k1", foreign), "aud-tag"); !errors.Is(err, ErrJWTIssuer) {
+			t.Fatalf("expected issuer mismatch for %q, got %v", issuer, err)
+		}
+	}
+
+	expired := map[string]any{"email": "yuki@example.com", "exp": now.Add(-time.Hour).Unix()}
+	if _, err := verifier.Verify(context.Background(), signTestJWT(t, key, "k1", expired), "aud-tag"); !errors.Is(err, ErrJWTExpired) {
+		t.Fatalf("expected expired, got %v", err)
+	}
+
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	if _, err := verifier.Verify(context.Background(), signTestJWT(t, other, "k1", claims), "aud-tag"); !errors.Is(err, ErrJWTSignature) {
+		t.Fatalf("expected signature failure, got %v", err)
+	}
+	if _, err := verifier.Verify(context.Background(), signTestJWT(t, key, "k2", claims), "aud-tag"); !errors.Is(err, ErrJWTUnknownKey) {
+		t.Fatalf("expected unknown key, got %v", err)
+	}
+	if fetches.Load() != 1 {
+		t.Fatalf("expected keys to be cached, fetched %d times", fetches.Load())
+	}
+	if _, err := verifier.Verify(context.Background(), "not.a.jwt", "aud-tag"); !errors.Is(err, ErrJWTMalformed) {
+		t.Fatalf("expected malformed, got %v", err)
+	}
+}
+
+func TestJWKSVerifierAcceptsIssuerAboveTheKeysPath(t *testing.T) {
+	verifier := NewJWKSVerifier("https://sso.example.com/realms/home/protocol/openid-connect/certs", nil)
+	for issuer, want := range map[string]bool{
+		"https://sso.example.com":              true,
+		"https://sso.example.com/realms/home":  true,
+		"https://sso.example.com/realms/home/": true,
+		"https://sso.example.com/realms/hom":   false,
+		"http://sso.example.com":               false,
+		"https://evil.example.com":             false,
+	} {
+		if got := verifier.issuerTrusted(issuer); got != want {
+			t.Errorf("issuerTrusted(%q) = %v, want %v", issuer, got, want)
+		}
+	}
+}
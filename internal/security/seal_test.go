@@ -0,0 +1,39 @@
+package security
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSealRoundTripBindsAdditionalData(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	sealed, err := Seal(key, []byte("hunter2"), []byte("qb_password"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	again, _ := Seal(key, []byte("hunter2"), []byte("qb_password"))
+	if bytes.Equal(sealed, again) {
+		t.Fatal("expected a fresh nonce for every seal")
+	}
+
+	plaintext, err := Open(key, sealed, []byte("qb_password"))
+	if err != nil || string(plaintext) != "hunter2" {
+		t.Fatalf("Open: %q %v", plaintext, err)
+	}
+	if _, err := Open(key, sealed, []byte("tmdb_token")); !errors.Is(err, ErrSealedValue) {
+		t.Fatalf("expected additional data mismatch to fail, got %v", err)
+	}
+	if _, err := Open(bytes.Repeat([]byte{8}, 32), sealed, []byte("qb_password")); !errors.Is(err, ErrSealedValue) {
+		t.Fatalf("expected wrong key to fail, got %v", err)
+	}
+	if _, err := Open(key, sealed[:10], []byte("qb_password")); !errors.Is(err, ErrSealedValue) {
+		t.Fatalf("expected truncated value to fail, got %v", err)
+	}
+}
+
+func TestSealRejectsShortKeys(t *testing.T) {
+	if _, err := Seal([]byte("short"), []byte("x"), nil); err == nil {
+		t.Fatal("expected short key to be rejected")
+	}
+}
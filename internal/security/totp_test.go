@@ -0,0 +1,59 @@
+package security
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTPCodeMatchesRFC6238Vectors(t *testing.T) {
+	// RFC 6238 appendix B, SHA-1 seed, truncated to six digits.
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	for unix, want := range map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	} {
+		got, err := TOTPCode(secret, TOTPStep(time.Unix(unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode: %v", err)
+		}
+		if got != want {
+			t.Fatalf("time %d: expected %s, got %s", unix, want, got)
+		}
+	}
+}
+
+func TestVerifyTOTPAllowsOneStepOfClockSkew(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret: %v", err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	step := TOTPStep(now)
+	previous, _ := TOTPCode(secret, step-1)
+	if matched, ok := VerifyTOTP(secret, previous, now); !ok || matched != step-1 {
+		t.Fatalf("expected previous step to verify, got %d %v", matched, ok)
+	}
+	stale, _ := TOTPCode(secret, step-2)
+	if _, ok := VerifyTOTP(secret, stale, now); ok {
+		t.Fatal("expected a code two steps old to be rejected")
+	}
+	if _, ok := VerifyTOTP(secret, "12345", now); ok {
+		t.Fatal("expected a short code to be rejected")
+	}
+}
+
+func TestTOTPURIContainsIssuerAndSecret(t *testing.T) {
+	uri := TOTPURI("animateAutoTool", "admin", "JBSWY3DPEHPK3PXP")
+	if !strings.HasPrefix(uri, "otpauth://totp/animateAutoTool:admin?") {
+		t.Fatalf("unexpected uri %s", uri)
+	}
+	for _, part := range []string{"secret=JBSWY3DPEHPK3PXP", "issuer=animateAutoTool", "digits=6", "period=30"} {
+		if !strings.Contains(uri, part) {
+			t.Fatalf("uri %s is missing %s", uri, part)
+		}
+	}
+}
@@ -82,6 +82,13 @@ func (s *AgentService) RunAgentForAnimeIDs(ids []uint) {
 // contention. The serial pass is intentionally last: it runs after the normal
 // workers have stopped, when the database is least likely to be busy again.
 func (s *AgentService) RunAgentForLibraryWithRepair(report MetadataIssueRepairProgressFunc) (MetadataIssueRepairResult, error) {
+	return s.RunAgentForLibraryWithRepairContext(context.Background(), report)
+}
+
+// RunAgentForLibraryWithRepairContext is RunAgentForLibraryWithRepair with
+// cancellation: once ctx is done no further series are queued, lookups
+// already in flight finish, and ctx.Err() is returned.
+func (s *AgentService) RunAgentForLibraryWithRepairContext(ctx context.Context, report MetadataIssueRepairProgressFunc) (MetadataIssueRepairResult, error) {
 	log.Println("Agent: Starting metadata enrichment (Agent Phase)...")
 	if runtimejournal.RecoveryBlocked() {
 		return MetadataIssueRepairResult{}, runtimejournal.ErrRecoveryBlocked
@@ -156,6 +163,9 @@ func (s *AgentService) RunAgentForLibraryWithRepair(report MetadataIssueRepairPr
 		batchSize := 100
 		err := db.DB.Preload("Metadata").FindInBatches(&animes, batchSize, func(tx *gorm.DB, batch int) error {
 			for _, anime := range animes {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
 				// 1. Level 0: Local Assets (NFO/Images) - Fast, Sync
 				s.scanLocalAssets(&anime)
 
@@ -178,6 +188,9 @@ func (s *AgentService) RunAgentForLibraryWithRepair(report MetadataIssueRepairPr
 	if err := <-producerErr; err != nil {
 		return MetadataIssueRepairResult{}, err
 	}
+	if err := ctx.Err(); err != nil {
+		return MetadataIssueRepairResult{}, err
+	}
 	log.Println("Agent: Metadata enrichment completed.")
 	if report != nil {
 		report(MetadataIssueRepairProgress{
@@ -187,7 +200,7 @@ func (s *AgentService) RunAgentForLibraryWithRepair(report MetadataIssueRepairPr
 			Total:   totalAnimes,
 		})
 	}
-	return s.RepairDatabaseMetadataIssues(context.Background(), report)
+	return s.RepairDatabaseMetadataIssues(ctx, report)
 }
 
 func (s *AgentService) animeNeedsNetwork(anime *model.LocalAnime) bool {
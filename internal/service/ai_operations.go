@@ -15,6 +15,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/pokerjest/animateAutoTool/internal/ai"
 	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/metrics"
 	"github.com/pokerjest/animateAutoTool/internal/model"
 	"gorm.io/gorm"
 )
@@ -327,17 +328,31 @@ func ListAIToolRuns(userID uint, limit int) ([]model.AIToolRun, error) {
 	return rows, err
 }
 
+var (
+	aiToolRuns        = metrics.NewCounterVec("animate_ai_tool_runs_total", "AI assistant tool calls by tool and outcome.", "tool", "outcome")
+	aiToolRunDuration = metrics.NewHistogramVec("animate_ai_tool_run_duration_seconds", "Duration of AI assistant tool calls.", nil, "tool")
+)
+
 func RecordAIToolRun(event ai.ToolRunEvent) {
-	if db.DB == nil || strings.TrimSpace(event.Name) == "" {
+	if strings.TrimSpace(event.Name) == "" {
+		return
+	}
+	toolName := truncateAIValue(event.Name, 96)
+	failed := strings.TrimSpace(event.Error) != ""
+	outcome := AuditOutcomeSuccess
+	if failed {
+		outcome = AuditOutcomeFailure
+	}
+	aiToolRuns.Inc(toolName, outcome)
+	aiToolRunDuration.Observe(event.Duration.Seconds(), toolName)
+	if db.DB == nil {
 		return
 	}
 	arguments := truncateAIValue(SanitizeAIText(event.Arguments), 4000)
 	result := truncateAIValue(SanitizeAIText(event.Result), 4000)
 	argumentHash := sha256.Sum256([]byte(arguments))
-	outcome := AuditOutcomeSuccess
 	errorType := ""
-	if strings.TrimSpace(event.Error) != "" {
-		outcome = AuditOutcomeFailure
+	if failed {
 		errorType = "tool_error"
 		if result == "" {
 			result = truncateAIValue(SanitizeAIText(event.Error), 4000)
@@ -352,7 +367,7 @@ func RecordAIToolRun(event ai.ToolRunEvent) {
 		ProposalID:            truncateAIValue(event.Meta.ProposalID, 36),
 		UserID:                event.Meta.UserID,
 		Username:              truncateAIValue(event.Meta.Username, 128),
-		ToolName:              truncateAIValue(event.Name, 96),
+		ToolName:              toolName,
 		Risk:                  truncateAIValue(string(event.Risk), 16),
 		ArgumentsSummary:      arguments,
 		ArgumentsHash:         hex.EncodeToString(argumentHash[:]),
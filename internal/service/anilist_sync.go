@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/anilist"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+)
+
+// AniList conflict policies. A conflict is an AniList progress that changed
+// since the last sync and disagrees with the local playback history.
+const (
+	// AniListSyncPolicyHighest keeps whichever side has watched further and
+	// never lowers AniList progress.
+	AniListSyncPolicyHighest = "highest"
+	// AniListSyncPolicyLocal always writes the local progress, even when that
+	// lowers AniList progress.
+	AniListSyncPolicyLocal = "local"
+	// AniListSyncPolicyRemote keeps AniList progress whenever it was changed
+	// on AniList; otherwise it behaves like highest.
+	AniListSyncPolicyRemote = "remote"
+)
+
+// Per-media outcomes of a sync.
+const (
+	AniListSyncActionPushed    = "pushed"
+	AniListSyncActionKept      = "kept"
+	AniListSyncActionUnchanged = "unchanged"
+	AniListSyncActionFailed    = "failed"
+)
+
+// AniListListClient reads and writes the AniList list of the configured
+// account. *anilist.Client satisfies it.
+type AniListListClient interface {
+	GetMediaProgressContext(ctx context.Context, mediaID int) (*anilist.Media, error)
+	SaveMediaListEntryContext(ctx context.Context, mediaID int, opts anilist.SaveMediaListEntryOptions) (*anilist.MediaListEntry, error)
+}
+
+// AniListProgressPlan is the decision for one media.
+type AniListProgressPlan struct {
+	Push     bool
+	Progress int
+	Status   string
+	Conflict bool
+}
+
+// AniListSyncOutcome describes what happened to one media.
+type AniListSyncOutcome struct {
+	MediaID  int    `json:"media_id"`
+	Title    string `json:"title"`
+	Local    int    `json:"local"`
+	Remote   int    `json:"remote"`
+	Progress int    `json:"progress"`
+	Status   string `json:"status,omitempty"`
+	Action   string `json:"action"`
+	Conflict bool   `json:"conflict,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// AniListSyncReport summarizes a reconciliation run.
+type AniListSyncReport struct {
+	Trigger    string               `json:"trigger,omitempty"`
+	Policy     string               `json:"policy"`
+	UserID     uint                 `json:"user_id"`
+	StartedAt  time.Time            `json:"started_at"`
+	FinishedAt time.Time            `json:"finished_at"`
+	Checked    int                  `json:"checked"`
+	Pushed     int                  `json:"pushed"`
+	Kept       int                  `json:"kept"`
+	Conflicts  int                  `json:"conflicts"`
+	Failed     int                  `json:"failed"`
+	Items      []AniListSyncOutcome `json:"items,omitempty"`
+	Error      string               `json:"error,omitempty"`
+}
+
+// ParseAniListSyncPolicy validates a policy setting. An empty value means
+// highest.
+func ParseAniListSyncPolicy(raw string) (string, error) {
+	switch value := strings.ToLower(strings.TrimSpace(raw)); value {
+	case "":
+		return AniListSyncPolicyHighest, nil
+	case AniListSyncPolicyHighest, AniListSyncPolicyLocal, AniListSyncPolicyRemote:
+		return value, nil
+	default:
+		return "", fmt.Errorf("unknown AniList sync policy %q", raw)
+	}
+}
+
+// PlanAniListProgress compares the local progress with the AniList entry.
+// synced is the AniList progress seen at the end of the previous sync and
+// episodes the total episode count, 0 when unknown.
+func PlanAniListProgress(local, synced, episodes int, remote *anilist.MediaListEntry, policy string) AniListProgressPlan {
+	remoteProgress, remoteStatus := 0, ""
+	if remote != nil {
+		remoteProgress, remoteStatus = remote.Progress, remote.Status
+	}
+	plan := AniListProgressPlan{
+		Progress: remoteProgress,
+		Status:   remoteStatus,
+		Conflict: remote != nil && remoteProgress != synced && local != remoteProgress,
+	}
+
+	switch policy {
+	case AniListSyncPolicyLocal:
+		plan.Push = local != remoteProgress
+	case AniListSyncPolicyRemote:
+		plan.Push = local > remoteProgress && !plan.Conflict
+	default:
+		plan.Push = local > remoteProgress
+	}
+	finished := episodes > 0 && local >= episodes
+	switch {
+	case plan.Push:
+		plan.Progress = local
+		switch {
+		case finished:
+			plan.Status = anilist.StatusCompleted
+		case remoteStatus != anilist.StatusRepeating:
+			plan.Status = anilist.StatusCurrent
+		}
+	case finished && local == remoteProgress && remoteStatus != anilist.StatusCompleted:
+		// Progress already matches; only the status is behind.
+		plan.Push, plan.Status = true, anilist.StatusCompleted
+	}
+	return plan
+}
+
+// SyncAniListEntry applies PlanAniListProgress to one media.
+func SyncAniListEntry(ctx context.Context, client AniListListClient, mediaID, local, synced int, policy string) (AniListSyncOutcome, error) {
+	outcome := AniListSyncOutcome{MediaID: mediaID, Local: local, Action: AniListSyncActionFailed}
+	media, err := client.GetMediaProgressContext(ctx, mediaID)
+	if err != nil {
+		outcome.Error = err.Error()
+		return outcome, err
+	}
+	if media.MediaListEntry != nil {
+		outcome.Remote = media.MediaListEntry.Progress
+	}
+	plan := PlanAniListProgress(local, synced, media.Episodes, media.MediaListEntry, policy)
+	outcome.Progress, outcome.Status, outcome.Conflict = plan.Progress, plan.Status, plan.Conflict
+
+	switch {
+	case plan.Push:
+		progress := plan.Progress
+		entry, err := client.SaveMediaListEntryContext(ctx, mediaID, anilist.SaveMediaListEntryOptions{Status: plan.Status, Progress: &progress})
+		if err != nil {
+			outcome.Error = err.Error()
+			return outcome, err
+		}
+		outcome.Progress, outcome.Status, outcome.Action = entry.Progress, entry.Status, AniListSyncActionPushed
+	case outcome.Remote != local:
+		outcome.Action = AniListSyncActionKept
+	default:
+		outcome.Action = AniListSyncActionUnchanged
+	}
+	return outcome, nil
+}
+
+// SyncAniListProgress reconciles the completed playback of userID, the
+// account linked to AniList, with AniList for the given local series, or for
+// every series with completed playback when none are given. The AniList progress seen afterwards is cached in
+// AnimeMetadata.AniListWatchedEps and used to detect remote changes next time.
+func SyncAniListProgress(ctx context.Context, client AniListListClient, policy string, userID uint, localAnimeIDs ...uint) (AniListSyncReport, error) {
+	report := AniListSyncReport{Policy: policy, UserID: userID, StartedAt: time.Now().UTC()}
+	finish := func(err error) (AniListSyncReport, error) {
+		report.FinishedAt = time.Now().UTC()
+		if err != nil {
+			report.Error = err.Error()
+		}
+		return report, err
+	}
+
+	progress, err := store.NewPlaybackHistoryStore(db.DB).CompletedProgress(userID, localAnimeIDs...)
+	if err != nil {
+		return finish(err)
+	}
+	if len(progress) == 0 {
+		return finish(nil)
+	}
+	animeIDs := make([]uint, 0, len(progress))
+	for _, item := range progress {
+		animeIDs = append(animeIDs, item.LocalAnimeID)
+	}
+	var animes []model.LocalAnime
+	if err := db.DB.Preload("Metadata").Where("id IN ?", animeIDs).Find(&animes).Error; err != nil {
+		return finish(err)
+	}
+	metadataByAnime := make(map[uint]*model.AnimeMetadata, len(animes))
+	for i := range animes {
+		if animes[i].Metadata != nil && animes[i].Metadata.AniListID != 0 {
+			metadataByAnime[animes[i].ID] = animes[i].Metadata
+		}
+	}
+
+	// Several local folders can point at the same AniList media.
+	type target struct {
+		metadata *model.AnimeMetadata
+		local    int
+	}
+	targets := map[int]*target{}
+	for _, item := range progress {
+		metadata := metadataByAnime[item.LocalAnimeID]
+		if metadata == nil {
+			continue
+		}
+		if existing := targets[metadata.AniListID]; existing != nil {
+			existing.local = max(existing.local, item.Episode)
+			continue
+		}
+		targets[metadata.AniListID] = &target{metadata: metadata, local: item.Episode}
+	}
+	mediaIDs := make([]int, 0, len(targets))
+	for mediaID := range targets {
+		mediaIDs = append(mediaIDs, mediaID)
+	}
+	sort.Ints(mediaIDs)
+
+	for _, mediaID := range mediaIDs {
+		if err := ctx.Err(); err != nil {
+			return finish(err)
+		}
+		item := targets[mediaID]
+		if item.local <= 0 {
+			continue
+		}
+		report.Checked++
+		outcome, err := SyncAniListEntry(ctx, client, mediaID, item.local, item.metadata.AniListWatchedEps, policy)
+		outcome.Title = item.metadata.Title
+		report.Items = append(report.Items, outcome)
+		if outcome.Conflict {
+			report.Conflicts++
+		}
+		if err != nil {
+			report.Failed++
+			continue
+		}
+		switch outcome.Action {
+		case AniListSyncActionPushed:
+			report.Pushed++
+		case AniListSyncActionKept:
+			report.Kept++
+		}
+		if outcome.Progress != item.metadata.AniListWatchedEps {
+			if err := db.DB.Model(&model.AnimeMetadata{}).Where("id = ?", item.metadata.ID).Update("AniListWatchedEps", outcome.Progress).Error; err != nil {
+				return finish(err)
+			}
+		}
+	}
+	return finish(nil)
+}
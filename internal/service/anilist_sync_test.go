@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pokerjest/animateAutoTool/internal/anilist"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+)
+
+// aniListStub is a minimal GraphQL server for the Media and
+// SaveMediaListEntry operations.
+type aniListStub struct {
+	mu        sync.Mutex
+	episodes  map[int]int
+	entries   map[int]*anilist.MediaListEntry
+	mutations []map[string]interface{}
+}
+
+func newAniListStub(t *testing.T) (*aniListStub, *anilist.Client) {
+	t.Helper()
+	stub := &aniListStub{episodes: map[int]int{}, entries: map[int]*anilist.MediaListEntry{}}
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+	client := anilist.NewClient("token", "")
+	client.Endpoint = server.URL
+	return stub, client
+}
+
+func (s *aniListStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if strings.Contains(request.Query, "SaveMediaListEntry") {
+		s.mutations = append(s.mutations, request.Variables)
+		mediaID := int(request.Variables["mediaId"].(float64))
+		entry := s.entries[mediaID]
+		if entry == nil {
+			entry = &anilist.MediaListEntry{ID: mediaID}
+			s.entries[mediaID] = entry
+		}
+		if status, ok := request.Variables["status"].(string); ok {
+			entry.Status = status
+		}
+		if progress, ok := request.Variables["progress"].(float64); ok {
+			entry.Progress = int(progress)
+		}
+		if score, ok := request.Variables["score"].(float64); ok {
+			entry.Score = score
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"SaveMediaListEntry": entry}})
+		return
+	}
+	mediaID := int(request.Variables["id"].(float64))
+	media := map[string]interface{}{"id": mediaID, "episodes": s.episodes[mediaID], "mediaListEntry": s.entries[mediaID]}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"Media": media}})
+}
+
+func TestPlanAniListProgressAppliesConflictPolicies(t *testing.T) {
+	remote := &anilist.MediaListEntry{Progress: 8, Status: anilist.StatusCurrent}
+	tests := []struct {
+		name     string
+		local    int
+		synced   int
+		policy   string
+		push     bool
+		progress int
+		status   string
+		conflict bool
+	}{
+		{name: "highest pushes further local progress", local: 9, synced: 8, policy: AniListSyncPolicyHighest, push: true, progress: 9, status: anilist.StatusCurrent},
+		{name: "highest keeps further remote progress", local: 5, synced: 3, policy: AniListSyncPolicyHighest, progress: 8, status: anilist.StatusCurrent, conflict: true},
+		{name: "local lowers remote progress", local: 5, synced: 3, policy: AniListSyncPolicyLocal, push: true, progress: 5, status: anilist.StatusCurrent, conflict: true},
+		{name: "remote keeps a remote change", local: 10, synced: 3, policy: AniListSyncPolicyRemote, progress: 8, status: anilist.StatusCurrent, conflict: true},
+		{name: "remote pushes when AniList is unchanged", local: 10, synced: 8, policy: AniListSyncPolicyRemote, push: true, progress: 10, status: anilist.StatusCurrent},
+		{name: "finishing the series completes it", local: 12, synced: 8, policy: AniListSyncPolicyHighest, push: true, progress: 12, status: anilist.StatusCompleted},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plan := PlanAniListProgress(test.local, test.synced, 12, remote, test.policy)
+			if plan.Push != test.push || plan.Progress != test.progress || plan.Status != test.status || plan.Conflict != test.conflict {
+				t.Fatalf("plan = %+v", plan)
+			}
+		})
+	}
+
+	if plan := PlanAniListProgress(3, 0, 0, nil, AniListSyncPolicyRemote); !plan.Push || plan.Status != anilist.StatusCurrent || plan.Conflict {
+		t.Fatalf("a media missing from the list should be added: %+v", plan)
+	}
+	done := &anilist.MediaListEntry{Progress: 12, Status: anilist.StatusCurrent}
+	if plan := PlanAniListProgress(12, 12, 12, done, AniListSyncPolicyHighest); !plan.Push || plan.Status != anilist.StatusCompleted {
+		t.Fatalf("a finished series should be marked completed: %+v", plan)
+	}
+	if _, err := ParseAniListSyncPolicy("newest"); err == nil {
+		t.Fatal("unknown policies must be rejected")
+	}
+}
+
+func TestSyncAniListProgressReconcilesPlaybackHistory(t *testing.T) {
+	withServiceTestDB(t)
+	stub, client := newAniListStub(t)
+	stub.episodes[101], stub.episodes[202] = 12, 24
+	stub.entries[202] = &anilist.MediaListEntry{ID: 2, Progress: 10, Status: anilist.StatusCurrent}
+
+	ahead := createAniListTestAnime(t, "Ahead", 101, 0, 3)
+	behind := createAniListTestAnime(t, "Behind", 202, 4, 6)
+	history := store.NewPlaybackHistoryStore(db.DB)
+	for _, anime := range []model.LocalAnime{ahead, behind} {
+		for _, episode := range anime.Episodes {
+			if err := history.Upsert(&model.PlaybackHistory{UserID: 1, LocalAnimeID: anime.ID, LocalEpisodeID: episode.ID, Completed: true}); err != nil {
+				t.Fatalf("seed playback history: %v", err)
+			}
+		}
+	}
+	// An unfinished episode does not count as progress.
+	unfinished := model.LocalEpisode{LocalAnimeID: ahead.ID, EpisodeNum: 4, SeasonNum: 1, Path: "/library/Ahead/04.mkv"}
+	if err := db.DB.Create(&unfinished).Error; err != nil {
+		t.Fatalf("create episode: %v", err)
+	}
+	if err := history.Upsert(&model.PlaybackHistory{UserID: 1, LocalAnimeID: ahead.ID, LocalEpisodeID: unfinished.ID}); err != nil {
+		t.Fatalf("seed playback history: %v", err)
+	}
+
+	// Another account's playback is not part of the linked AniList list.
+	other := createAniListTestAnime(t, "Other", 303, 0, 2)
+	for _, episode := range other.Episodes {
+		if err := history.Upsert(&model.PlaybackHistory{UserID: 2, LocalAnimeID: other.ID, LocalEpisodeID: episode.ID, Completed: true}); err != nil {
+			t.Fatalf("seed playback history: %v", err)
+		}
+	}
+
+	report, err := SyncAniListProgress(context.Background(), client, AniListSyncPolicyHighest, 1)
+	if err != nil {
+		t.Fatalf("SyncAniListProgress returned error: %v", err)
+	}
+	if report.Checked != 2 || report.Pushed != 1 || report.Kept != 1 || report.Conflicts != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if len(stub.mutations) != 1 || stub.mutations[0]["mediaId"] != float64(101) || stub.mutations[0]["progress"] != float64(3) || stub.mutations[0]["status"] != anilist.StatusCurrent {
+		t.Fatalf("unexpected mutations: %v", stub.mutations)
+	}
+	if got := cachedAniListProgress(t, behind); got != 10 {
+		t.Fatalf("the AniList progress should be cached, got %d", got)
+	}
+
+	// Once the remote value is cached, a local-wins policy lowers it and
+	// a remote-wins policy leaves it alone.
+	report, err = SyncAniListProgress(context.Background(), client, AniListSyncPolicyRemote, 1, behind.ID)
+	if err != nil || report.Pushed != 0 || report.Kept != 1 {
+		t.Fatalf("remote policy report = %+v, %v", report, err)
+	}
+	report, err = SyncAniListProgress(context.Background(), client, AniListSyncPolicyLocal, 1, behind.ID)
+	if err != nil || report.Pushed != 1 || stub.entries[202].Progress != 6 {
+		t.Fatalf("local policy report = %+v, %v, entry %+v", report, err, stub.entries[202])
+	}
+	if got := cachedAniListProgress(t, behind); got != 6 {
+		t.Fatalf("the pushed progress should be cached, got %d", got)
+	}
+}
+
+func createAniListTestAnime(t *testing.T, title string, aniListID, watchedEps, episodes int) model.LocalAnime {
+	t.Helper()
+	metadata := model.AnimeMetadata{Title: title, AniListID: aniListID, AniListWatchedEps: watchedEps}
+	if err := db.DB.Create(&metadata).Error; err != nil {
+		t.Fatalf("create metadata: %v", err)
+	}
+	anime := model.LocalAnime{Title: title, MetadataID: &metadata.ID}
+	for number := 1; number <= episodes; number++ {
+		anime.Episodes = append(anime.Episodes, model.LocalEpisode{EpisodeNum: number, SeasonNum: 1, Path: "/library/" + title + "/" + string(rune('0'+number)) + ".mkv"})
+	}
+	if err := db.DB.Create(&anime).Error; err != nil {
+		t.Fatalf("create local anime: %v", err)
+	}
+	return anime
+}
+
+func cachedAniListProgress(t *testing.T, anime model.LocalAnime) int {
+	t.Helper()
+	var metadata model.AnimeMetadata
+	if err := db.DB.First(&metadata, *anime.MetadataID).Error; err != nil {
+		t.Fatalf("load metadata: %v", err)
+	}
+	return metadata.AniListWatchedEps
+}
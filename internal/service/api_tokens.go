@@ -0,0 +1,250 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pokerjest/animateAutoTool/internal/authsession"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"gorm.io/gorm"
+)
+
+// APITokenPrefix marks personal access tokens so they are easy to spot in
+// scripts and secret scanners.
+const APITokenPrefix = "aat_"
+
+const (
+	APITokenScopeRead          = "read"
+	APITokenScopeSubscriptions = "subscriptions"
+	APITokenScopeLibrary       = "library"
+	APITokenScopePlayback      = "playback"
+	APITokenScopeAdmin         = "admin"
+)
+
+const (
+	maxAPITokenNameLength = 64
+	maxAPITokenTTL        = 366 * 24 * time.Hour
+	apiTokenSecretBytes   = 32
+	apiTokenDisplayLength = len(APITokenPrefix) + 6
+)
+
+// APITokenScopes lists every scope in display order.
+var APITokenScopes = []string{
+	APITokenScopeRead,
+	APITokenScopeSubscriptions,
+	APITokenScopeLibrary,
+	APITokenScopePlayback,
+	APITokenScopeAdmin,
+}
+
+var (
+	ErrAPITokenInvalid     = errors.New("API 令牌无效")
+	ErrAPITokenExpired     = errors.New("API 令牌已过期")
+	ErrAPITokenRevoked     = errors.New("API 令牌已被撤销")
+	ErrAPITokenNotFound    = errors.New("API 令牌不存在")
+	ErrAPITokenName        = errors.New("请填写不超过 64 个字符的令牌名称")
+	ErrAPITokenScope       = errors.New("权限范围必须是 read、subscriptions、library、playback 或 admin")
+	ErrAPITokenNoScope     = errors.New("至少需要选择一个权限范围")
+	ErrAPITokenExpiry      = errors.New("有效期必须在 0 到 366 天之间")
+	ErrAPITokenOwnerAbsent = errors.New("API 令牌所属账户已不存在")
+)
+
+func apiTokenStore() *store.APITokenStore {
+	if db.DB == nil {
+		return nil
+	}
+	return store.NewAPITokenStore(db.DB)
+}
+
+// HashAPIToken returns the stored form of a token secret.
+func HashAPIToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// NormalizeAPITokenScopes validates scopes and returns them deduplicated in
+// display order.
+func NormalizeAPITokenScopes(raw []string) ([]string, error) {
+	requested := make(map[string]bool, len(raw))
+	for _, scope := range raw {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if scope == "" {
+			continue
+		}
+		known := false
+		for _, candidate := range APITokenScopes {
+			if scope == candidate {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, ErrAPITokenScope
+		}
+		requested[scope] = true
+	}
+	scopes := make([]string, 0, len(requested))
+	for _, scope := range APITokenScopes {
+		if requested[scope] {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, ErrAPITokenNoScope
+	}
+	return scopes, nil
+}
+
+// APITokenScopeList splits the stored scope column.
+func APITokenScopeList(token *model.APIToken) []string {
+	if token == nil || strings.TrimSpace(token.Scopes) == "" {
+		return []string{}
+	}
+	return strings.Split(token.Scopes, ",")
+}
+
+// APITokenHasScope reports whether token grants scope. The admin scope covers
+// every other scope; the owner's role still limits what the token can reach.
+func APITokenHasScope(token *model.APIToken, scope string) bool {
+	for _, granted := range APITokenScopeList(token) {
+		if granted == scope || granted == APITokenScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// IssueAPIToken creates a token for user and returns its secret. Only the
+// hash is stored, so the secret cannot be shown again. A zero ttl never
+// expires.
+func (s *AuthService) IssueAPIToken(user *model.User, name string, scopes []string, ttl time.Duration) (string, *model.APIToken, error) {
+	if user == nil {
+		return "", nil, ErrUserNotFound
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxAPITokenNameLength {
+		return "", nil, ErrAPITokenName
+	}
+	normalized, err := NormalizeAPITokenScopes(scopes)
+	if err != nil {
+		return "", nil, err
+	}
+	if ttl < 0 || ttl > maxAPITokenTTL {
+		return "", nil, ErrAPITokenExpiry
+	}
+	st := apiTokenStore()
+	if st == nil {
+		return "", nil, errors.New("invalid database")
+	}
+
+	raw := make([]byte, apiTokenSecretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+	secret := APITokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+	token := &model.APIToken{
+		UserID:         user.ID,
+		Name:           name,
+		Prefix:         secret[:apiTokenDisplayLength],
+		TokenHash:      HashAPIToken(secret),
+		Scopes:         strings.Join(normalized, ","),
+		AuthGeneration: authsession.Current(),
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		token.ExpiresAt = &expiresAt
+	}
+	if err := st.Create(token); err != nil {
+		return "", nil, err
+	}
+	return secret, token, nil
+}
+
+// AuthenticateAPIToken resolves a bearer secret to its token and owner.
+// Tokens carry the authsession generation they were issued under, so
+// invalidating every session (for example after a restore) revokes them too.
+func (s *AuthService) AuthenticateAPIToken(secret string) (*model.APIToken, *model.User, error) {
+	secret = strings.TrimSpace(secret)
+	if !strings.HasPrefix(secret, APITokenPrefix) {
+		return nil, nil, ErrAPITokenInvalid
+	}
+	st := apiTokenStore()
+	users := userStore()
+	if st == nil || users == nil {
+		return nil, nil, errors.New("invalid database")
+	}
+	token, err := st.GetByHash(HashAPIToken(secret))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrAPITokenInvalid
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if token.RevokedAt != nil || !authsession.Valid(token.AuthGeneration) {
+		return nil, nil, ErrAPITokenRevoked
+	}
+	if token.ExpiresAt != nil && !time.Now().Before(*token.ExpiresAt) {
+		return nil, nil, ErrAPITokenExpired
+	}
+	user, err := users.GetByID(token.UserID)
+	if err != nil {
+		return nil, nil, ErrAPITokenOwnerAbsent
+	}
+	return token, user, nil
+}
+
+// ListAPITokens returns the tokens owned by userID.
+func (s *AuthService) ListAPITokens(userID uint) ([]model.APIToken, error) {
+	st := apiTokenStore()
+	if st == nil {
+		return nil, errors.New("invalid database")
+	}
+	return st.ListByUser(userID)
+}
+
+// RevokeAPIToken revokes one token. Accounts revoke their own tokens; admins
+// may revoke anyone's.
+func (s *AuthService) RevokeAPIToken(id uint, actor *model.User) (*model.APIToken, error) {
+	st := apiTokenStore()
+	if st == nil {
+		return nil, errors.New("invalid database")
+	}
+	token, err := st.GetByID(id)
+	if err != nil || actor == nil {
+		return nil, ErrAPITokenNotFound
+	}
+	if token.UserID != actor.ID && !UserRoleAllows(actor, model.UserRoleAdmin) {
+		return nil, ErrAPITokenNotFound
+	}
+	now := time.Now()
+	if err := st.Revoke(token.ID, now); err != nil {
+		return nil, err
+	}
+	if token.RevokedAt == nil {
+		token.RevokedAt = &now
+	}
+	return token, nil
+}
+
+// TouchAPIToken records the token's latest use.
+func (s *AuthService) TouchAPIToken(token *model.APIToken, ip string) error {
+	st := apiTokenStore()
+	if st == nil || token == nil {
+		return errors.New("invalid database")
+	}
+	now := time.Now()
+	if err := st.Touch(token.ID, now, ip); err != nil {
+		return err
+	}
+	token.LastUsedAt = &now
+	token.LastUsedIP = ip
+	return nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+)
+
+func TestIssueAPITokenStoresOnlyHash(t *testing.T) {
+	withServiceTestDB(t)
+	auth := NewAuthService()
+	user, err := auth.CreateUser("owner", "owner-pass")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	secret, token, err := auth.IssueAPIToken(user, " cron ", []string{"Playback", "read", "read"}, 0)
+	if err != nil {
+		t.Fatalf("IssueAPIToken: %v", err)
+	}
+	if token.Name != "cron" || token.Scopes != "read,playback" || token.ExpiresAt != nil {
+		t.Fatalf("unexpected token: %+v", token)
+	}
+	var count int64
+	db.DB.Model(&model.APIToken{}).Where("token_hash = ? OR prefix = ?", secret, secret).Count(&count)
+	if count != 0 {
+		t.Fatal("plaintext secret must not be stored")
+	}
+
+	gotToken, gotUser, err := auth.AuthenticateAPIToken(secret)
+	if err != nil || gotToken.ID != token.ID || gotUser.ID != user.ID {
+		t.Fatalf("AuthenticateAPIToken = %+v, %+v, %v", gotToken, gotUser, err)
+	}
+	if !APITokenHasScope(gotToken, APITokenScopePlayback) || APITokenHasScope(gotToken, APITokenScopeLibrary) {
+		t.Fatalf("unexpected scopes %q", gotToken.Scopes)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	db.DB.Model(&model.APIToken{}).Where("id = ?", token.ID).Update("expires_at", past)
+	if _, _, err := auth.AuthenticateAPIToken(secret); !errors.Is(err, ErrAPITokenExpired) {
+		t.Fatalf("expected expired token, got %v", err)
+	}
+	if _, _, err := auth.AuthenticateAPIToken("aat_unknown"); !errors.Is(err, ErrAPITokenInvalid) {
+		t.Fatalf("expected invalid token, got %v", err)
+	}
+}
+
+func TestIssueAPITokenValidatesInput(t *testing.T) {
+	withServiceTestDB(t)
+	auth := NewAuthService()
+	user, err := auth.CreateUser("owner", "owner-pass")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	cases := []struct {
+		name   string
+		scopes []string
+		ttl    time.Duration
+		want   error
+	}{
+		{"", []string{"read"}, 0, ErrAPITokenName},
+		{"ok", nil, 0, ErrAPITokenNoScope},
+		{"ok", []string{"root"}, 0, ErrAPITokenScope},
+		{"ok", []string{"read"}, -time.Hour, ErrAPITokenExpiry},
+		{"ok", []string{"read"}, 400 * 24 * time.Hour, ErrAPITokenExpiry},
+	}
+	for _, tc := range cases {
+		if _, _, err := auth.IssueAPIToken(user, tc.name, tc.scopes, tc.ttl); !errors.Is(err, tc.want) {
+			t.Fatalf("IssueAPIToken(%q, %v, %v) = %v, want %v", tc.name, tc.scopes, tc.ttl, err, tc.want)
+		}
+	}
+
+	username, password, role := "guest", "guest-pass", model.UserRoleViewer
+	other, err := auth.CreateManagedUser(UserInput{Username: &username, Password: &password, Role: &role})
+	if err != nil {
+		t.Fatalf("CreateManagedUser: %v", err)
+	}
+	_, token, err := auth.IssueAPIToken(user, "owner token", []string{"read"}, time.Hour)
+	if err != nil {
+		t.Fatalf("IssueAPIToken: %v", err)
+	}
+	if _, err := auth.RevokeAPIToken(token.ID, other); !errors.Is(err, ErrAPITokenNotFound) {
+		t.Fatalf("expected viewer to be refused, got %v", err)
+	}
+	if _, err := auth.RevokeAPIToken(token.ID, user); err != nil {
+		t.Fatalf("RevokeAPIToken: %v", err)
+	}
+}
@@ -20,14 +20,35 @@ const (
 	AuditActionPasswordRecoveryLoc  = "password.recovery.local"
 	AuditActionBootstrapComplete    = "bootstrap.complete"
 	AuditActionSubscriptionDelete   = "subscription.delete"
+	AuditActionSubscriptionImport   = "subscription.import"
+	AuditActionBangumiSyncRun       = "bangumi_sync.run"
+	AuditActionBangumiSyncAccept    = "bangumi_sync.accept"
+	AuditActionBangumiSyncDismiss   = "bangumi_sync.dismiss"
+	AuditActionAniListSyncRun       = "anilist_sync.run"
+	AuditActionAniListEntryUpdate   = "anilist.entry.update"
 	AuditActionLocalDirectoryDelete = "local_directory.delete"
 	AuditActionBackupRestore        = "backup.restore"
 	AuditActionR2BackupRestore      = "backup.r2.restore"
 	AuditActionR2BackupDelete       = "backup.r2.delete"
+	AuditActionBackupScheduleRun    = "backup.schedule.run"
+	AuditActionBackupSchedulePrune  = "backup.schedule.prune"
 	AuditActionAISettingsUpdate     = "settings.ai.update"
 	AuditActionAIProposalApply      = "ai.proposal.apply"
 	AuditActionAIProposalDismiss    = "ai.proposal.dismiss"
 	AuditActionSettingsUpdate       = "settings.update"
+	AuditActionNotificationUpdate   = "settings.notification.update"
+	AuditActionNotificationDelete   = "settings.notification.delete"
+	AuditActionUserCreate           = "user.create"
+	AuditActionUserUpdate           = "user.update"
+	AuditActionUserDelete           = "user.delete"
+	AuditActionAPITokenCreate       = "api_token.create"
+	AuditActionAPITokenRevoke       = "api_token.revoke"
+	AuditActionAPITokenUse          = "api_token.use"
+	AuditActionTwoFactorEnable      = "two_factor.enable"
+	AuditActionTwoFactorDisable     = "two_factor.disable"
+	AuditActionRecoveryCodesRenew   = "two_factor.recovery_codes"
+	AuditActionSettingsKeyRotate    = "settings.secrets.rotate"
+	AuditActionTaskCancel           = "task.cancel"
 )
 
 const (
@@ -217,22 +217,40 @@ func (s *AuthService) SetPassword(userID uint, newPassword string) error {
 	return s.updatePassword(user, newPassword)
 }
 
-func (s *AuthService) ResetPasswordByUsername(username, newPassword string) error {
+// ResetPasswordByUsername is the local recovery path. Whoever can reach it
+// already has access to the machine, and the usual reason to use it is a
+// lost authenticator, so it also turns two-factor login off and reports
+// whether it did.
+func (s *AuthService) ResetPasswordByUsername(username, newPassword string) (bool, error) {
 	username = strings.TrimSpace(username)
 	if username == "" {
-		return errors.New("user not found")
+		return false, errors.New("user not found")
 	}
 
 	st := userStore()
 	if st == nil {
-		return errors.New("user not found")
+		return false, errors.New("user not found")
 	}
 	user, err := st.GetByUsername(username)
 	if err != nil {
-		return errors.New("user not found")
+		return false, errors.New("user not found")
+	}
+	// Local recovery restores administration; other accounts are reset by an admin.
+	if EffectiveUserRole(user) != model.UserRoleAdmin {
+		return false, errors.New("本机恢复只能重置管理员账户")
 	}
 
-	return s.updatePassword(user, newPassword)
+	if err := s.updatePassword(user, newPassword); err != nil {
+		return false, err
+	}
+	twoFactorWasEnabled := TwoFactorEnabled(user)
+	if !twoFactorWasEnabled && user.TOTPPendingSecret == "" {
+		return false, nil
+	}
+	if err := s.clearTwoFactor(user); err != nil {
+		return false, err
+	}
+	return twoFactorWasEnabled, nil
 }
 
 func (s *AuthService) updatePassword(user *model.User, newPassword string) error {
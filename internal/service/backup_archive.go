@@ -11,17 +11,26 @@ import (
 	"strings"
 	"time"
 
+	"github.com/glebarez/sqlite"
+	"github.com/pokerjest/animateAutoTool/internal/config"
 	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
 	"github.com/pokerjest/animateAutoTool/internal/safeio"
 	appversion "github.com/pokerjest/animateAutoTool/internal/version"
 	securezip "github.com/yeka/zip"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
 const (
 	BackupArchiveFormatVersion = 1
 	backupArchiveDatabaseName  = "database.db"
 	backupArchiveManifestName  = "manifest.json"
-	backupArchiveMaxEntrySize  = int64(8 << 30)
+	// backupArchiveSettingsKeyName carries the settings key ring in full
+	// archives, so credentials survive losing data/bootstrap/settings_key.
+	backupArchiveSettingsKeyName = "settings_key"
+	backupArchiveMaxEntrySize    = int64(8 << 30)
+	backupArchiveMaxKeySize      = int64(64 << 10)
 )
 
 var (
@@ -41,6 +50,9 @@ type BackupArchiveManifest struct {
 	DatabaseSize   int64     `json:"database_size"`
 	CreatedAt      time.Time `json:"created_at"`
 	Encryption     string    `json:"encryption"`
+	// SettingsKeyIDs lists the keys stored in the settings_key entry, current
+	// key first. Archives without credentials leave it empty.
+	SettingsKeyIDs []string `json:"settings_key_ids,omitempty"`
 }
 
 // BackupArchiveFile reports whether a path is an encrypted backup archive.
@@ -143,6 +155,23 @@ func CreateEncryptedBackupArchive(databasePath, archivePath, mode, password stri
 		CreatedAt:      time.Now().UTC(),
 		Encryption:     "AES-256",
 	}
+	if mode == BackupModeFull {
+		keyFile, keyIDs, err := config.ExportSettingsKeys()
+		if err != nil {
+			_ = zipWriter.Close()
+			return fmt.Errorf("读取设置加密密钥: %w", err)
+		}
+		keyWriter, err := zipWriter.Encrypt(backupArchiveSettingsKeyName, password, securezip.AES256Encryption)
+		if err != nil {
+			_ = zipWriter.Close()
+			return fmt.Errorf("创建加密密钥条目: %w", err)
+		}
+		if _, err := keyWriter.Write(keyFile); err != nil {
+			_ = zipWriter.Close()
+			return fmt.Errorf("写入设置加密密钥: %w", err)
+		}
+		manifest.SettingsKeyIDs = keyIDs
+	}
 	manifestBytes, err := json.Marshal(manifest)
 	if err != nil {
 		_ = zipWriter.Close()
@@ -170,7 +199,9 @@ func CreateEncryptedBackupArchive(databasePath, archivePath, mode, password stri
 // ExtractEncryptedBackupArchive decrypts and extracts the database entry to a
 // caller-owned path. It verifies the encrypted manifest and database digest
 // before returning, so a wrong password or damaged archive cannot reach the
-// restore transaction.
+// restore transaction. Secrets sealed with a key carried in the archive are
+// re-encrypted with the local settings key in the extracted copy, so restore
+// does not depend on the key file of the machine that made the backup.
 func ExtractEncryptedBackupArchive(archivePath, password, databasePath string) (manifest BackupArchiveManifest, retErr error) {
 	startedAt := time.Now()
 	archiveLabel := filepath.Base(filepath.Clean(archivePath))
@@ -205,7 +236,7 @@ func ExtractEncryptedBackupArchive(archivePath, password, databasePath string) (
 	}
 	defer safeio.Close(reader)
 
-	databaseEntry, manifestEntry, err := findEncryptedBackupEntries(reader.File)
+	databaseEntry, manifestEntry, keyEntry, err := findEncryptedBackupEntries(reader.File)
 	if err != nil {
 		return BackupArchiveManifest{}, err
 	}
@@ -221,26 +252,41 @@ func ExtractEncryptedBackupArchive(archivePath, password, databasePath string) (
 	if err := extractEncryptedBackupDatabase(databaseEntry, password, databasePath, manifest); err != nil {
 		return BackupArchiveManifest{}, err
 	}
+	if keyEntry != nil {
+		ring, err := readEncryptedBackupSettingsKeys(keyEntry, password)
+		if err == nil {
+			err = resealArchivedSettings(databasePath, ring)
+		}
+		if err != nil {
+			safeio.Remove(databasePath)
+			return BackupArchiveManifest{}, err
+		}
+	}
 	return manifest, nil
 }
 
-func findEncryptedBackupEntries(files []*securezip.File) (*securezip.File, *securezip.File, error) {
-	var databaseEntry, manifestEntry *securezip.File
+func findEncryptedBackupEntries(files []*securezip.File) (*securezip.File, *securezip.File, *securezip.File, error) {
+	var databaseEntry, manifestEntry, keyEntry *securezip.File
 	for _, file := range files {
 		switch filepath.ToSlash(file.Name) {
 		case backupArchiveDatabaseName:
 			databaseEntry = file
 		case backupArchiveManifestName:
 			manifestEntry = file
+		case backupArchiveSettingsKeyName:
+			keyEntry = file
 		}
 	}
 	if databaseEntry == nil || manifestEntry == nil || !databaseEntry.IsEncrypted() || !manifestEntry.IsEncrypted() {
-		return nil, nil, ErrBackupArchiveFormat
+		return nil, nil, nil, ErrBackupArchiveFormat
+	}
+	if keyEntry != nil && (!keyEntry.IsEncrypted() || keyEntry.UncompressedSize64 > uint64(backupArchiveMaxKeySize)) {
+		return nil, nil, nil, ErrBackupArchiveFormat
 	}
 	if databaseEntry.UncompressedSize64 > uint64(backupArchiveMaxEntrySize) {
-		return nil, nil, errors.New("备份数据库解压后超过安全大小限制")
+		return nil, nil, nil, errors.New("备份数据库解压后超过安全大小限制")
 	}
-	return databaseEntry, manifestEntry, nil
+	return databaseEntry, manifestEntry, keyEntry, nil
 }
 
 func validateBackupArchiveManifest(manifest BackupArchiveManifest, databaseEntry *securezip.File) error {
@@ -334,6 +380,72 @@ func readEncryptedBackupManifest(file *securezip.File, password string) (BackupA
 	return manifest, nil
 }
 
+func readEncryptedBackupSettingsKeys(file *securezip.File, password string) (config.SettingsKeyRing, error) {
+	file.SetPassword(password)
+	reader, err := file.Open()
+	if err != nil {
+		if errors.Is(err, securezip.ErrPassword) {
+			return config.SettingsKeyRing{}, ErrBackupArchivePassword
+		}
+		return config.SettingsKeyRing{}, fmt.Errorf("打开加密密钥条目: %w", err)
+	}
+	defer safeio.Close(reader)
+
+	data, err := io.ReadAll(io.LimitReader(reader, backupArchiveMaxKeySize))
+	if err != nil {
+		if errors.Is(err, securezip.ErrPassword) {
+			return config.SettingsKeyRing{}, ErrBackupArchivePassword
+		}
+		return config.SettingsKeyRing{}, fmt.Errorf("读取备份中的设置加密密钥: %w", err)
+	}
+	ring, err := config.ParseSettingsKeyRing(data)
+	if err != nil {
+		return config.SettingsKeyRing{}, fmt.Errorf("%w: %v", ErrBackupArchiveFormat, err)
+	}
+	return ring, nil
+}
+
+// resealArchivedSettings rewrites the secrets in an extracted database with
+// the local settings key. Values the archived ring cannot open are left as
+// they are; restore then reports them as unreadable.
+func resealArchivedSettings(databasePath string, ring config.SettingsKeyRing) error {
+	archiveDB, err := gorm.Open(sqlite.Open(databasePath), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return fmt.Errorf("打开解包数据库: %w", err)
+	}
+	if sqlDB, err := archiveDB.DB(); err == nil {
+		defer safeio.Close(sqlDB)
+	}
+	if !archiveDB.Migrator().HasTable(&model.GlobalConfig{}) {
+		return nil
+	}
+
+	var configs []model.GlobalConfig
+	if err := archiveDB.Find(&configs).Error; err != nil {
+		return fmt.Errorf("读取备份中的系统设置: %w", err)
+	}
+	resealed := 0
+	for _, cfg := range configs {
+		if !config.IsEncryptedSetting(cfg.Value) {
+			continue
+		}
+		plaintext, err := ring.Decrypt(cfg.Key, cfg.Value)
+		if err != nil {
+			continue
+		}
+		stored, err := config.EncryptSetting(cfg.Key, plaintext)
+		if err != nil {
+			return err
+		}
+		if err := archiveDB.Model(&model.GlobalConfig{}).Where("key = ?", cfg.Key).Update("value", stored).Error; err != nil {
+			return fmt.Errorf("更新备份中的系统设置: %w", err)
+		}
+		resealed++
+	}
+	log.Printf("BackupService: archived settings key applied keys=%s resealed=%d", strings.Join(ring.IDs(), ","), resealed)
+	return nil
+}
+
 func isSQLiteFile(path string) bool {
 	file, err := os.Open(filepath.Clean(path))
 	if err != nil {
@@ -6,6 +6,9 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/pokerjest/animateAutoTool/internal/config"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
 	securezip "github.com/yeka/zip"
 )
 
@@ -31,8 +34,8 @@ func TestEncryptedBackupArchiveRoundTrip(t *testing.T) {
 			t.Errorf("close archive: %v", err)
 		}
 	}()
-	if len(reader.File) != 2 {
-		t.Fatalf("archive entry count = %d, want 2", len(reader.File))
+	if len(reader.File) != 3 {
+		t.Fatalf("archive entry count = %d, want 3", len(reader.File))
 	}
 	for _, entry := range reader.File {
 		if !entry.IsEncrypted() {
@@ -45,7 +48,7 @@ func TestEncryptedBackupArchiveRoundTrip(t *testing.T) {
 	if err != nil {
 		t.Fatalf("extract archive: %v", err)
 	}
-	if manifest.Encryption != "AES-256" || manifest.BackupMode != BackupModeFull {
+	if manifest.Encryption != "AES-256" || manifest.BackupMode != BackupModeFull || len(manifest.SettingsKeyIDs) == 0 {
 		t.Fatalf("unexpected manifest: %#v", manifest)
 	}
 	// #nosec G304 -- extractedPath is created under this test's private temporary directory.
@@ -89,3 +92,41 @@ func TestEncryptedBackupArchiveRejectsMissingPassword(t *testing.T) {
 		t.Fatal("expected missing password error")
 	}
 }
+
+func TestEncryptedFullBackupRestoresSecretsAfterTheSettingsKeyIsGone(t *testing.T) {
+	db.InitDB(":memory:")
+	t.Cleanup(func() { _ = db.CloseDB() })
+	if err := db.SaveGlobalConfig(model.ConfigKeyQBPassword, "archived-password"); err != nil {
+		t.Fatalf("seed password: %v", err)
+	}
+	databasePath := filepath.Join(t.TempDir(), "full.db")
+	if err := CreateBackupFile(databasePath, BackupModeFull); err != nil {
+		t.Fatalf("create backup: %v", err)
+	}
+	archivePath := filepath.Join(t.TempDir(), "full.zip")
+	if err := CreateEncryptedBackupArchive(databasePath, archivePath, BackupModeFull, "archive-password"); err != nil {
+		t.Fatalf("create archive: %v", err)
+	}
+
+	// Two rotations push the key the backup was sealed with out of the ring,
+	// which is what a restore on a rebuilt machine looks like.
+	for range 2 {
+		if _, err := config.RotateSettingsKey(nil); err != nil {
+			t.Fatalf("rotate settings key: %v", err)
+		}
+	}
+	if err := db.SaveGlobalConfig(model.ConfigKeyQBPassword, "current-password"); err != nil {
+		t.Fatalf("seed current password: %v", err)
+	}
+
+	extractedPath := filepath.Join(t.TempDir(), "restore.db")
+	if _, err := ExtractEncryptedBackupArchive(archivePath, "archive-password", extractedPath); err != nil {
+		t.Fatalf("extract archive: %v", err)
+	}
+	if err := NewRestoreService().PerformRestore(extractedPath, RestoreOptions{Configs: true}); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if got := testGlobalConfigValue(t, model.ConfigKeyQBPassword); got != "archived-password" {
+		t.Fatalf("expected the archived key to restore the password, got %q", got)
+	}
+}
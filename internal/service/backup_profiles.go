@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/glebarez/sqlite"
+	"github.com/pokerjest/animateAutoTool/internal/config"
 	"github.com/pokerjest/animateAutoTool/internal/db"
 	"github.com/pokerjest/animateAutoTool/internal/model"
 	"github.com/pokerjest/animateAutoTool/internal/safeio"
@@ -413,12 +414,7 @@ func createSelectiveBackupFile(destPath string, mode string) error {
 }
 
 func IsSensitiveConfigKey(key string) bool {
-	lowerKey := strings.ToLower(key)
-	return strings.Contains(lowerKey, "password") ||
-		strings.Contains(lowerKey, "secret") ||
-		strings.Contains(lowerKey, "token") ||
-		strings.Contains(lowerKey, "key") ||
-		strings.Contains(lowerKey, "credential")
+	return config.IsSensitiveSettingKey(key)
 }
 
 func writeSelectiveBackupData(destDB *gorm.DB, mode string) error {
@@ -8,18 +8,20 @@ import (
 	"time"
 
 	"github.com/glebarez/sqlite"
+	"github.com/pokerjest/animateAutoTool/internal/config"
 	"github.com/pokerjest/animateAutoTool/internal/db"
 	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/store"
 	"gorm.io/gorm"
 )
 
 func testGlobalConfigValue(t *testing.T, key string) string {
 	t.Helper()
-	var cfg model.GlobalConfig
-	if err := db.DB.First(&cfg, "key = ?", key).Error; err != nil {
+	value, err := store.NewConfigStore(db.DB).Get(key)
+	if err != nil {
 		t.Fatalf("load config %s: %v", key, err)
 	}
-	return cfg.Value
+	return value
 }
 
 func TestFullBackupRestoresPlaybackHistoryWithLocalLibrary(t *testing.T) {
@@ -165,6 +167,59 @@ func TestCreateSettingsBackupFileIncludesOnlyGlobalConfigs(t *testing.T) {
 	}
 }
 
+func TestFullBackupRestoreKeepsCurrentSecretsItCannotDecrypt(t *testing.T) {
+	db.InitDB(":memory:")
+	t.Cleanup(func() { _ = db.CloseDB() })
+
+	if err := db.SaveGlobalConfig(model.ConfigKeyQBUrl, "http://backup-qb:8080"); err != nil {
+		t.Fatalf("seed backup url: %v", err)
+	}
+	backupPath := filepath.Join(t.TempDir(), "foreign-full.db")
+	if err := CreateBackupFile(backupPath, BackupModeFull); err != nil {
+		t.Fatalf("create full backup: %v", err)
+	}
+	// Simulate a backup from another installation: one secret is sealed with
+	// a key this device never had, another is legacy plaintext.
+	backupDB, err := gorm.Open(sqlite.Open(backupPath), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open backup: %v", err)
+	}
+	foreign := "enc:v1:00000000:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	if err := backupDB.Create([]model.GlobalConfig{
+		{Key: model.ConfigKeyQBPassword, Value: foreign},
+		{Key: model.ConfigKeyTMDBToken, Value: "legacy-plaintext-token"},
+	}).Error; err != nil {
+		t.Fatalf("seed backup secrets: %v", err)
+	}
+	if sqlDB, err := backupDB.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+
+	if err := db.SaveGlobalConfig(model.ConfigKeyQBPassword, "current-password"); err != nil {
+		t.Fatalf("seed current password: %v", err)
+	}
+	if err := NewRestoreService().PerformRestore(backupPath, RestoreOptions{Configs: true}); err != nil {
+		t.Fatalf("restore foreign backup: %v", err)
+	}
+
+	if got := testGlobalConfigValue(t, model.ConfigKeyQBUrl); got != "http://backup-qb:8080" {
+		t.Fatalf("expected backup url, got %q", got)
+	}
+	if got := testGlobalConfigValue(t, model.ConfigKeyQBPassword); got != "current-password" {
+		t.Fatalf("expected current password to survive an unreadable backup secret, got %q", got)
+	}
+	if got := testGlobalConfigValue(t, model.ConfigKeyTMDBToken); got != "legacy-plaintext-token" {
+		t.Fatalf("expected legacy plaintext token to be restored, got %q", got)
+	}
+	var raw model.GlobalConfig
+	if err := db.DB.First(&raw, "key = ?", model.ConfigKeyTMDBToken).Error; err != nil {
+		t.Fatalf("load raw token: %v", err)
+	}
+	if !config.IsEncryptedSetting(raw.Value) {
+		t.Fatalf("expected restored plaintext secret to be encrypted, got %q", raw.Value)
+	}
+}
+
 func TestSettingsBackupOmitsSecretsAndRestorePreservesCurrentCredentials(t *testing.T) {
 	db.InitDB(":memory:")
 	t.Cleanup(func() { _ = db.CloseDB() })
@@ -287,9 +342,7 @@ func TestCloudflareBackupRestoreMergesConfigs(t *testing.T) {
 		t.Fatalf("expected qb config to be preserved, got %q", qbURL)
 	}
 
-	var tmdbToken string
-	db.DB.Model(&model.GlobalConfig{}).Where("key = ?", model.ConfigKeyTMDBToken).Select("value").Scan(&tmdbToken)
-	if tmdbToken != currentTMDBToken {
+	if tmdbToken := testGlobalConfigValue(t, model.ConfigKeyTMDBToken); tmdbToken != currentTMDBToken {
 		t.Fatalf("expected tmdb config to be preserved, got %q", tmdbToken)
 	}
 
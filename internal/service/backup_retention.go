@@ -0,0 +1,145 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const scheduledBackupPrefix = "animate_backup_auto_"
+
+// BackupRetention decides which scheduled backups survive pruning. KeepLast
+// always keeps the newest archives; the tiers then keep the newest archive
+// of each of the most recent Daily days, Weekly ISO weeks and Monthly months.
+type BackupRetention struct {
+	KeepLast int `json:"keep_last"`
+	Daily    int `json:"daily"`
+	Weekly   int `json:"weekly"`
+	Monthly  int `json:"monthly"`
+}
+
+// IsZero reports whether the policy would keep nothing. Such a policy is
+// treated as "keep everything" so a blank setting never wipes the archive.
+func (r BackupRetention) IsZero() bool {
+	return r.KeepLast <= 0 && r.Daily <= 0 && r.Weekly <= 0 && r.Monthly <= 0
+}
+
+// BackupArtifact is one stored archive as seen by the retention policy.
+// SettingsKeyID is the settings key the archive's credentials were sealed
+// with; it is empty for archives without credentials and for names written
+// before the id was recorded.
+type BackupArtifact struct {
+	Name          string
+	CreatedAt     time.Time
+	SettingsKeyID string
+}
+
+// ScheduledBackupPrefix is the name prefix shared by scheduled archives of
+// one mode. Retention only ever looks at names with this prefix, so manual
+// exports and uploads are never pruned.
+func ScheduledBackupPrefix(mode string) string {
+	return scheduledBackupPrefix + NormalizeBackupMode(mode) + "_"
+}
+
+// ScheduledBackupName names a scheduled archive. The same name is used for
+// local files and R2 objects; it still matches the animate_backup_ prefix
+// the R2 listing uses. settingsKeyID is appended when the archive carries
+// credentials, so pruning can tell which key sealed it without the password.
+func ScheduledBackupName(mode string, t time.Time, settingsKeyID string) string {
+	name := ScheduledBackupPrefix(mode) + t.Format("20060102_150405")
+	if settingsKeyID != "" {
+		name += "_" + settingsKeyID
+	}
+	return name + ".zip"
+}
+
+// ParseScheduledBackupName reads a name produced by ScheduledBackupName for
+// mode.
+func ParseScheduledBackupName(mode, name string) (BackupArtifact, bool) {
+	rest, ok := strings.CutPrefix(name, ScheduledBackupPrefix(mode))
+	if !ok {
+		return BackupArtifact{}, false
+	}
+	rest, ok = strings.CutSuffix(rest, ".zip")
+	if !ok {
+		return BackupArtifact{}, false
+	}
+	const timestampLayout = "20060102_150405"
+	if len(rest) < len(timestampLayout) {
+		return BackupArtifact{}, false
+	}
+	timestamp, keyID := rest[:len(timestampLayout)], rest[len(timestampLayout):]
+	if keyID != "" {
+		keyID, ok = strings.CutPrefix(keyID, "_")
+		if !ok || !isSettingsKeyID(keyID) {
+			return BackupArtifact{}, false
+		}
+	}
+	createdAt, err := time.ParseInLocation(timestampLayout, timestamp, time.Local)
+	if err != nil {
+		return BackupArtifact{}, false
+	}
+	return BackupArtifact{Name: name, CreatedAt: createdAt, SettingsKeyID: keyID}, true
+}
+
+func isSettingsKeyID(value string) bool {
+	if len(value) != 8 {
+		return false
+	}
+	for _, r := range value {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// SelectExpiredBackups returns the artifacts the policy does not keep,
+// oldest first.
+func SelectExpiredBackups(artifacts []BackupArtifact, policy BackupRetention) []BackupArtifact {
+	if policy.IsZero() || len(artifacts) == 0 {
+		return nil
+	}
+	sorted := append([]BackupArtifact(nil), artifacts...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedAt.After(sorted[j].CreatedAt) })
+
+	keep := make([]bool, len(sorted))
+	for i := 0; i < len(sorted) && i < policy.KeepLast; i++ {
+		keep[i] = true
+	}
+	keepNewestPerBucket(sorted, keep, policy.Daily, func(t time.Time) string { return t.Format("2006-01-02") })
+	keepNewestPerBucket(sorted, keep, policy.Weekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	})
+	keepNewestPerBucket(sorted, keep, policy.Monthly, func(t time.Time) string { return t.Format("2006-01") })
+
+	var expired []BackupArtifact
+	for i := len(sorted) - 1; i >= 0; i-- {
+		if !keep[i] {
+			expired = append(expired, sorted[i])
+		}
+	}
+	return expired
+}
+
+// keepNewestPerBucket marks the newest artifact of each of the first limit
+// distinct buckets. sorted must be newest first.
+func keepNewestPerBucket(sorted []BackupArtifact, keep []bool, limit int, bucket func(time.Time) string) {
+	if limit <= 0 {
+		return
+	}
+	seen := make(map[string]bool, limit)
+	for i, artifact := range sorted {
+		key := bucket(artifact.CreatedAt)
+		if seen[key] {
+			continue
+		}
+		if len(seen) == limit {
+			return
+		}
+		seen[key] = true
+		keep[i] = true
+	}
+}
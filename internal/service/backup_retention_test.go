@@ -0,0 +1,88 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSelectExpiredBackupsKeepsTieredHistory(t *testing.T) {
+	// One archive per day at 03:00 for 70 days, newest on 2026-10-16.
+	newest := time.Date(2026, 10, 16, 3, 0, 0, 0, time.Local)
+	var artifacts []BackupArtifact
+	for day := 0; day < 70; day++ {
+		createdAt := newest.AddDate(0, 0, -day)
+		artifacts = append(artifacts, BackupArtifact{Name: ScheduledBackupName(BackupModeFull, createdAt, ""), CreatedAt: createdAt})
+	}
+	// An extra archive on the newest day must not consume a daily slot.
+	extra := newest.Add(-2 * time.Hour)
+	artifacts = append(artifacts, BackupArtifact{Name: ScheduledBackupName(BackupModeFull, extra, ""), CreatedAt: extra})
+
+	expired := SelectExpiredBackups(artifacts, BackupRetention{KeepLast: 2, Daily: 3, Weekly: 3, Monthly: 3})
+	kept := map[string]bool{}
+	for _, artifact := range artifacts {
+		kept[artifact.Name] = true
+	}
+	for i, artifact := range expired {
+		if i > 0 && artifact.CreatedAt.Before(expired[i-1].CreatedAt) {
+			t.Fatal("expected expired archives oldest first")
+		}
+		delete(kept, artifact.Name)
+	}
+
+	want := []time.Time{
+		newest, extra, // keep last 2
+		newest.AddDate(0, 0, -1), newest.AddDate(0, 0, -2), // daily (the 16th is already kept)
+		newest.AddDate(0, 0, -5),  // weekly: Sunday 2026-10-11 closes ISO week 41
+		newest.AddDate(0, 0, -12), // weekly: Sunday 2026-10-04 closes ISO week 40
+		newest.AddDate(0, 0, -16), // monthly: 2026-09-30
+		newest.AddDate(0, 0, -46), // monthly: 2026-08-31
+	}
+	if len(kept) != len(want) {
+		t.Fatalf("kept %d archives, want %d: %v", len(kept), len(want), kept)
+	}
+	for _, createdAt := range want {
+		if !kept[ScheduledBackupName(BackupModeFull, createdAt, "")] {
+			t.Fatalf("expected archive from %s to be kept, kept=%v", createdAt.Format(time.DateTime), kept)
+		}
+	}
+}
+
+func TestSelectExpiredBackupsWithEmptyPolicyKeepsEverything(t *testing.T) {
+	artifacts := []BackupArtifact{{Name: "a", CreatedAt: time.Now()}, {Name: "b", CreatedAt: time.Now().Add(-time.Hour)}}
+	if expired := SelectExpiredBackups(artifacts, BackupRetention{}); len(expired) != 0 {
+		t.Fatalf("empty policy must not prune, got %v", expired)
+	}
+}
+
+func TestParseScheduledBackupNameOnlyMatchesOwnMode(t *testing.T) {
+	createdAt := time.Date(2026, 10, 16, 3, 0, 0, 0, time.Local)
+	name := ScheduledBackupName(BackupModeSettings, createdAt, "")
+	if name != "animate_backup_auto_settings_20261016_030000.zip" {
+		t.Fatalf("unexpected name %q", name)
+	}
+	if parsed, ok := ParseScheduledBackupName(BackupModeSettings, name); !ok || !parsed.CreatedAt.Equal(createdAt) || parsed.SettingsKeyID != "" {
+		t.Fatalf("parse %q = %#v %v", name, parsed, ok)
+	}
+	for _, other := range []string{
+		ScheduledBackupName(BackupModeFull, createdAt, ""),
+		R2BackupObjectKey(BackupModeSettings, createdAt),
+		"animate_backup_auto_settings_latest.zip",
+		"animate_backup_auto_settings_20261016_030000_NOTAKEY.zip",
+	} {
+		if _, ok := ParseScheduledBackupName(BackupModeSettings, other); ok {
+			t.Fatalf("expected %q to be ignored", other)
+		}
+	}
+}
+
+func TestScheduledBackupNameRecordsTheSettingsKey(t *testing.T) {
+	createdAt := time.Date(2026, 10, 16, 3, 0, 0, 0, time.Local)
+	name := ScheduledBackupName(BackupModeFull, createdAt, "0a1b2c3d")
+	if name != "animate_backup_auto_full_20261016_030000_0a1b2c3d.zip" {
+		t.Fatalf("unexpected name %q", name)
+	}
+	parsed, ok := ParseScheduledBackupName(BackupModeFull, name)
+	if !ok || !parsed.CreatedAt.Equal(createdAt) || parsed.SettingsKeyID != "0a1b2c3d" || parsed.Name != name {
+		t.Fatalf("parse %q = %#v %v", name, parsed, ok)
+	}
+}
@@ -0,0 +1,459 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/bangumi"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/parser"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"gorm.io/gorm"
+)
+
+// Bangumi collection types read by the sync.
+const (
+	BangumiCollectionWish     = 1
+	BangumiCollectionWatching = 3
+)
+
+const (
+	BangumiSyncModePropose   = "propose"
+	BangumiSyncModeSubscribe = "subscribe"
+)
+
+// Statuses of a BangumiCollectionSync record. Subscribed, existing and
+// proposed records are left alone by later runs; dismissed and deleted ones
+// are never acted on again.
+const (
+	BangumiSyncStatusSubscribed = "subscribed"
+	BangumiSyncStatusProposed   = "proposed"
+	BangumiSyncStatusExisting   = "existing"
+	BangumiSyncStatusDismissed  = "dismissed"
+	BangumiSyncStatusDeleted    = "deleted"
+	BangumiSyncStatusUnresolved = "unresolved"
+	BangumiSyncStatusFailed     = "failed"
+)
+
+const bangumiSyncDeletedReason = "订阅已被删除，不会再自动订阅"
+
+const (
+	bangumiSyncPageSize      = 50
+	bangumiSyncMaxPerType    = 500
+	bangumiSyncLookupTimeout = 30 * time.Second
+)
+
+var ErrBangumiSyncNotAuthorized = errors.New("bangumi account is not connected")
+
+// BangumiSyncOptions is the parsed form of the bangumi_collection_sync_*
+// settings.
+type BangumiSyncOptions struct {
+	Types     []int
+	Mode      string
+	Subgroups []string
+}
+
+// BangumiCollectionReader reads the collections of the authorized account.
+// *bangumi.Client implements it.
+type BangumiCollectionReader interface {
+	GetCurrentUserContext(ctx context.Context, accessToken string) (*bangumi.UserProfile, error)
+	GetUserCollectionContext(ctx context.Context, accessToken, username string, collectionType, limit, offset int) ([]bangumi.UserCollectionItem, error)
+}
+
+// BangumiSyncReport summarizes one sync run. Counts is keyed by the record
+// status each seen subject ended up with.
+type BangumiSyncReport struct {
+	Trigger    string         `json:"trigger"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	Seen       int            `json:"seen"`
+	Counts     map[string]int `json:"counts"`
+	Subscribed []string       `json:"subscribed,omitempty"`
+	Proposed   []string       `json:"proposed,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// ParseBangumiSyncTypes reads a comma separated list of "wish" and
+// "watching". An empty value means both.
+func ParseBangumiSyncTypes(raw string) ([]int, error) {
+	if strings.TrimSpace(raw) == "" {
+		return []int{BangumiCollectionWish, BangumiCollectionWatching}, nil
+	}
+	var types []int
+	seen := map[int]bool{}
+	for _, item := range strings.Split(raw, ",") {
+		var value int
+		switch strings.ToLower(strings.TrimSpace(item)) {
+		case "":
+			continue
+		case "wish":
+			value = BangumiCollectionWish
+		case "watching":
+			value = BangumiCollectionWatching
+		default:
+			return nil, fmt.Errorf("unknown Bangumi collection type %q", strings.TrimSpace(item))
+		}
+		if !seen[value] {
+			seen[value] = true
+			types = append(types, value)
+		}
+	}
+	if len(types) == 0 {
+		return []int{BangumiCollectionWish, BangumiCollectionWatching}, nil
+	}
+	return types, nil
+}
+
+// FormatBangumiSyncTypes is the inverse of ParseBangumiSyncTypes.
+func FormatBangumiSyncTypes(types []int) string {
+	names := make([]string, 0, len(types))
+	for _, value := range types {
+		switch value {
+		case BangumiCollectionWish:
+			names = append(names, "wish")
+		case BangumiCollectionWatching:
+			names = append(names, "watching")
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// ParseBangumiSyncSubgroups splits the preference list on commas, semicolons
+// and newlines, keeping the order.
+func ParseBangumiSyncSubgroups(raw string) []string {
+	return uniqueKeywords(strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == ';' || r == '\n' || r == '，' || r == '；'
+	})...)
+}
+
+// SyncBangumiCollections walks the wish/watching collections of the account
+// behind accessToken and records an outcome for every subject. New subjects
+// are resolved to Mikan; in subscribe mode the ones with a preferred
+// subgroup are passed to create, everything else becomes a proposal. A
+// subject whose subscription has since been deleted is marked deleted and
+// skipped from then on.
+func SyncBangumiCollections(ctx context.Context, reader BangumiCollectionReader, mikan MikanSubjectResolver, accessToken string, opts BangumiSyncOptions, create func(*model.Subscription) error, now func() time.Time) (BangumiSyncReport, error) {
+	if now == nil {
+		now = time.Now
+	}
+	report := BangumiSyncReport{StartedAt: now(), Counts: map[string]int{}}
+	finish := func(err error) (BangumiSyncReport, error) {
+		report.FinishedAt = now()
+		if err != nil {
+			report.Error = err.Error()
+		}
+		return report, err
+	}
+	if strings.TrimSpace(accessToken) == "" {
+		return finish(ErrBangumiSyncNotAuthorized)
+	}
+	if db.DB == nil {
+		return finish(errors.New("database is not initialized"))
+	}
+
+	profile, err := reader.GetCurrentUserContext(ctx, accessToken)
+	if err != nil {
+		return finish(fmt.Errorf("read Bangumi profile: %w", err))
+	}
+	username := profile.Username
+	if username == "" {
+		username = strconv.Itoa(profile.ID)
+	}
+
+	syncer := bangumiCollectionSyncer{
+		records: store.NewBangumiCollectionSyncStore(db.DB),
+		subs:    store.NewSubscriptionStore(db.DB),
+		mikan:   mikan,
+		opts:    opts,
+		create:  create,
+	}
+	seen := map[int]bool{}
+	for _, collectionType := range opts.Types {
+		for offset := 0; offset < bangumiSyncMaxPerType; offset += bangumiSyncPageSize {
+			if err := ctx.Err(); err != nil {
+				return finish(err)
+			}
+			items, err := reader.GetUserCollectionContext(ctx, accessToken, username, collectionType, bangumiSyncPageSize, offset)
+			if err != nil {
+				return finish(fmt.Errorf("read Bangumi collection type %d: %w", collectionType, err))
+			}
+			for _, item := range items {
+				if item.SubjectID == 0 || seen[item.SubjectID] {
+					continue
+				}
+				seen[item.SubjectID] = true
+				if err := ctx.Err(); err != nil {
+					return finish(err)
+				}
+				record, err := syncer.sync(ctx, item, now())
+				if err != nil {
+					return finish(err)
+				}
+				report.Seen++
+				report.Counts[record.Status]++
+				switch {
+				case record.Status == BangumiSyncStatusSubscribed && syncer.changed:
+					report.Subscribed = append(report.Subscribed, record.Title)
+				case record.Status == BangumiSyncStatusProposed && syncer.changed:
+					report.Proposed = append(report.Proposed, record.Title)
+				}
+			}
+			if len(items) < bangumiSyncPageSize {
+				break
+			}
+		}
+	}
+	return finish(nil)
+}
+
+type bangumiCollectionSyncer struct {
+	records *store.BangumiCollectionSyncStore
+	subs    *store.SubscriptionStore
+	mikan   MikanSubjectResolver
+	opts    BangumiSyncOptions
+	create  func(*model.Subscription) error
+	// changed reports whether the last sync call moved the record into its
+	// current status.
+	changed bool
+}
+
+func (s *bangumiCollectionSyncer) sync(ctx context.Context, item bangumi.UserCollectionItem, now time.Time) (*model.BangumiCollectionSync, error) {
+	s.changed = false
+	record, found, err := s.records.GetBySubject(item.SubjectID)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		record = &model.BangumiCollectionSync{SubjectID: item.SubjectID}
+	}
+	record.Title = firstNonEmpty(item.Subject.NameCN, item.Subject.Name, record.Title, strconv.Itoa(item.SubjectID))
+	record.Image = firstNonEmpty(item.Subject.Images.Large, item.Subject.Images.Common, record.Image)
+	record.CollectionType = item.Type
+	record.LastSeenAt = &now
+
+	switch record.Status {
+	case BangumiSyncStatusDismissed, BangumiSyncStatusDeleted:
+		return record, s.records.Save(record)
+	case BangumiSyncStatusProposed:
+		// A proposal stays open until it is confirmed or dismissed, unless
+		// the user subscribed by hand in the meantime.
+		s.linkExisting(record, record.MikanID)
+		return record, s.records.Save(record)
+	case BangumiSyncStatusSubscribed, BangumiSyncStatusExisting:
+		if record.SubscriptionID != 0 {
+			if _, err := s.subs.GetByID(record.SubscriptionID); err == nil {
+				return record, s.records.Save(record)
+			}
+		}
+		s.mark(record, BangumiSyncStatusDeleted, bangumiSyncDeletedReason)
+		return record, s.records.Save(record)
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, bangumiSyncLookupTimeout)
+	defer cancel()
+	s.resolve(lookupCtx, item, record)
+	return record, s.records.Save(record)
+}
+
+// resolve handles a new, unresolved or failed record.
+func (s *bangumiCollectionSyncer) resolve(ctx context.Context, item bangumi.UserCollectionItem, record *model.BangumiCollectionSync) {
+	if s.linkExisting(record, "") {
+		return
+	}
+	if s.mikan == nil {
+		s.mark(record, BangumiSyncStatusUnresolved, "未配置 Mikan 匹配")
+		return
+	}
+
+	subjectID := strconv.Itoa(item.SubjectID)
+	var (
+		results []parser.SearchResult
+		err     error
+	)
+	for _, keyword := range uniqueKeywords(item.Subject.NameCN, item.Subject.Name) {
+		results, err = s.mikan.ResolveBangumiSubjectContext(ctx, subjectID, keyword)
+		if err == nil && len(results) > 0 {
+			break
+		}
+	}
+	if len(results) == 0 {
+		if err != nil {
+			log.Printf("WARN: BangumiSync: Mikan resolution failed subject=%d error=%v", item.SubjectID, err)
+			s.mark(record, BangumiSyncStatusUnresolved, "Mikan 查询失败，下次同步时重试")
+			return
+		}
+		s.mark(record, BangumiSyncStatusUnresolved, "Mikan 上还没有对应番组，下次同步时重试")
+		return
+	}
+	match := results[0]
+	record.MikanID = match.MikanID
+	if record.Image == "" {
+		record.Image = match.Image
+	}
+	if s.linkExisting(record, match.MikanID) {
+		return
+	}
+	if s.deletedByMikanID(record) {
+		return
+	}
+
+	record.RSSUrl = "https://mikanani.me/RSS/Bangumi?bangumiId=" + url.QueryEscape(match.MikanID)
+	record.SubgroupID, record.SubgroupName = "", ""
+	reason := "没有符合偏好的字幕组，确认后订阅全部字幕组"
+	if len(s.opts.Subgroups) == 0 {
+		reason = "未设置字幕组偏好，确认后订阅全部字幕组"
+	} else {
+		groups, err := s.mikan.GetSubgroupsContext(ctx, match.MikanID)
+		if err != nil {
+			log.Printf("WARN: BangumiSync: Mikan subgroups failed mikan_id=%s error=%v", match.MikanID, err)
+			reason = "读取 Mikan 字幕组失败，确认后订阅全部字幕组"
+		} else if group, ok := PreferredSubgroup(groups, s.opts.Subgroups); ok {
+			record.SubgroupID, record.SubgroupName = group.ID, group.Name
+			record.RSSUrl += "&subgroupid=" + url.QueryEscape(group.ID)
+			reason = "等待确认"
+		}
+	}
+
+	if s.opts.Mode != BangumiSyncModeSubscribe || record.SubgroupID == "" || s.create == nil {
+		s.mark(record, BangumiSyncStatusProposed, reason)
+		return
+	}
+	sub := BangumiSyncSubscription(record)
+	if err := s.create(sub); err != nil {
+		log.Printf("WARN: BangumiSync: create subscription failed subject=%d error=%v", record.SubjectID, err)
+		s.mark(record, BangumiSyncStatusFailed, "创建订阅失败："+err.Error())
+		return
+	}
+	record.SubscriptionID = sub.ID
+	s.mark(record, BangumiSyncStatusSubscribed, "已按字幕组偏好自动订阅")
+}
+
+// linkExisting records an existing subscription for the subject (or the
+// Mikan bangumi once known) so the sync never adds a second one.
+func (s *bangumiCollectionSyncer) linkExisting(record *model.BangumiCollectionSync, mikanID string) bool {
+	sub, found, err := s.subs.FindByBangumiSubjectOrMikanID(record.SubjectID, mikanID)
+	if err != nil || !found {
+		return false
+	}
+	record.SubscriptionID = sub.ID
+	if record.RSSUrl == "" {
+		record.RSSUrl = sub.RSSUrl
+	}
+	s.mark(record, BangumiSyncStatusExisting, "已有订阅："+sub.Title)
+	return true
+}
+
+// deletedByMikanID marks record deleted when the user already removed a
+// subscription for its Mikan bangumi. A tombstone that only knows the Mikan
+// ID is taken over by the subject so it is found directly next time.
+func (s *bangumiCollectionSyncer) deletedByMikanID(record *model.BangumiCollectionSync) bool {
+	tombstone, found, err := s.records.FindByMikanID(record.MikanID, BangumiSyncStatusDeleted)
+	if err != nil {
+		log.Printf("WARN: BangumiSync: deletion lookup failed mikan_id=%s error=%v", record.MikanID, err)
+		return false
+	}
+	if !found {
+		return false
+	}
+	if tombstone.SubjectID == 0 && record.ID == 0 {
+		record.ID = tombstone.ID
+		record.CreatedAt = tombstone.CreatedAt
+		record.SubscriptionID = tombstone.SubscriptionID
+	}
+	s.mark(record, BangumiSyncStatusDeleted, bangumiSyncDeletedReason)
+	return true
+}
+
+func (s *bangumiCollectionSyncer) mark(record *model.BangumiCollectionSync, status, reason string) {
+	s.changed = record.Status != status
+	record.Status = status
+	record.Reason = reason
+}
+
+// RecordBangumiSyncDeletion leaves a deleted record for the Bangumi subject
+// and Mikan bangumi of sub, so the collection sync never subscribes to them
+// again. Callers run it before deleting the subscription; it also covers
+// subscriptions the sync has not linked yet.
+func RecordBangumiSyncDeletion(sub *model.Subscription) error {
+	if sub == nil || db.DB == nil {
+		return nil
+	}
+	subjectID := 0
+	if sub.Metadata != nil {
+		subjectID = sub.Metadata.BangumiID
+	} else if sub.MetadataID != nil {
+		metadata, err := store.NewAnimeMetadataStore(db.DB).GetByID(*sub.MetadataID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if metadata != nil {
+			subjectID = metadata.BangumiID
+		}
+	}
+	mikanID := strings.TrimSpace(sub.MikanID)
+	if subjectID == 0 && mikanID == "" {
+		return nil
+	}
+
+	records := store.NewBangumiCollectionSyncStore(db.DB)
+	var (
+		record *model.BangumiCollectionSync
+		found  bool
+		err    error
+	)
+	if subjectID != 0 {
+		record, found, err = records.GetBySubject(subjectID)
+	} else {
+		record, found, err = records.FindByMikanID(mikanID)
+	}
+	if err != nil {
+		return err
+	}
+	if !found {
+		record = &model.BangumiCollectionSync{SubjectID: subjectID}
+	}
+	record.Title = firstNonEmpty(record.Title, sub.Title)
+	record.Image = firstNonEmpty(record.Image, sub.Image)
+	record.MikanID = firstNonEmpty(mikanID, record.MikanID)
+	record.RSSUrl = firstNonEmpty(record.RSSUrl, sub.RSSUrl)
+	record.SubscriptionID = sub.ID
+	record.Status = BangumiSyncStatusDeleted
+	record.Reason = bangumiSyncDeletedReason
+	return records.Save(record)
+}
+
+// PreferredSubgroup returns the first subgroup matching the preference list,
+// trying preferences in order. The "all subgroups" entry has no ID and is
+// never picked.
+func PreferredSubgroup(groups []parser.Subgroup, preferences []string) (parser.Subgroup, bool) {
+	for _, wanted := range preferences {
+		for _, group := range groups {
+			if group.ID != "" && subgroupNameMatches(group.Name, wanted) {
+				return group, true
+			}
+		}
+	}
+	return parser.Subgroup{}, false
+}
+
+// BangumiSyncSubscription builds the subscription proposed by record. Its
+// metadata only carries the subject ID; the caller links it to an existing
+// metadata row before storing the subscription.
+func BangumiSyncSubscription(record *model.BangumiCollectionSync) *model.Subscription {
+	return &model.Subscription{
+		Title:         record.Title,
+		MikanID:       record.MikanID,
+		RSSUrl:        record.RSSUrl,
+		Image:         record.Image,
+		SubtitleGroup: record.SubgroupName,
+		IsActive:      true,
+		Metadata:      &model.AnimeMetadata{Title: record.Title, BangumiID: record.SubjectID},
+	}
+}
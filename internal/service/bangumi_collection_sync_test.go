@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/bangumi"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/parser"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+)
+
+type fakeCollectionReader map[int][]bangumi.UserCollectionItem
+
+func (f fakeCollectionReader) GetCurrentUserContext(context.Context, string) (*bangumi.UserProfile, error) {
+	return &bangumi.UserProfile{ID: 7, Username: "viewer"}, nil
+}
+
+func (f fakeCollectionReader) GetUserCollectionContext(_ context.Context, _ string, username string, collectionType, limit, offset int) ([]bangumi.UserCollectionItem, error) {
+	if username != "viewer" {
+		return nil, nil
+	}
+	items := f[collectionType]
+	if offset >= len(items) {
+		return nil, nil
+	}
+	return items[offset:min(len(items), offset+limit)], nil
+}
+
+func collectionItem(subjectID, collectionType int, name string) bangumi.UserCollectionItem {
+	return bangumi.UserCollectionItem{SubjectID: subjectID, Type: collectionType, Subject: bangumi.Subject{ID: subjectID, NameCN: name}}
+}
+
+func TestSyncBangumiCollectionsSubscribesProposesAndRemembersDeletes(t *testing.T) {
+	withServiceTestDB(t)
+
+	metadata := model.AnimeMetadata{Title: "已订阅", BangumiID: 100}
+	if err := db.DB.Create(&metadata).Error; err != nil {
+		t.Fatalf("create metadata: %v", err)
+	}
+	if err := db.DB.Create(&model.Subscription{Title: "已订阅", RSSUrl: "https://example.test/existing", MetadataID: &metadata.ID, IsActive: true}).Error; err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+
+	reader := fakeCollectionReader{
+		BangumiCollectionWish:     {collectionItem(200, BangumiCollectionWish, "药屋少女的呢喃"), collectionItem(400, BangumiCollectionWish, "还没有资源")},
+		BangumiCollectionWatching: {collectionItem(100, BangumiCollectionWatching, "已订阅"), collectionItem(300, BangumiCollectionWatching, "葬送的芙莉莲")},
+	}
+	mikan := fakeMikanResolver{
+		bySubject: map[string][]parser.SearchResult{
+			"200": {{MikanID: "3160", Title: "药屋少女的呢喃"}},
+			"300": {{MikanID: "3141", Title: "葬送的芙莉莲"}},
+		},
+		subgroups: map[string][]parser.Subgroup{
+			"3160": {{ID: "", Name: "全部 (All)"}, {ID: "370", Name: "LoliHouse"}, {ID: "382", Name: "桜都字幕组"}},
+			"3141": {{ID: "583", Name: "ANi"}},
+		},
+	}
+	opts := BangumiSyncOptions{
+		Types:     []int{BangumiCollectionWish, BangumiCollectionWatching},
+		Mode:      BangumiSyncModeSubscribe,
+		Subgroups: ParseBangumiSyncSubgroups("桜都\nLoliHouse"),
+	}
+	var created []string
+	create := func(sub *model.Subscription) error {
+		created = append(created, sub.RSSUrl)
+		return db.DB.Create(sub).Error
+	}
+
+	report, err := SyncBangumiCollections(context.Background(), reader, mikan, "token", opts, create, nil)
+	if err != nil {
+		t.Fatalf("SyncBangumiCollections returned error: %v", err)
+	}
+	if report.Seen != 4 || report.Counts[BangumiSyncStatusSubscribed] != 1 || report.Counts[BangumiSyncStatusProposed] != 1 ||
+		report.Counts[BangumiSyncStatusExisting] != 1 || report.Counts[BangumiSyncStatusUnresolved] != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if len(created) != 1 || created[0] != "https://mikanani.me/RSS/Bangumi?bangumiId=3160&subgroupid=382" {
+		t.Fatalf("the first matching preference should be subscribed, got %v", created)
+	}
+
+	records := store.NewBangumiCollectionSyncStore(db.DB)
+	proposal, found, err := records.GetBySubject(300)
+	if err != nil || !found || proposal.Status != BangumiSyncStatusProposed || proposal.RSSUrl != "https://mikanani.me/RSS/Bangumi?bangumiId=3141" || !strings.Contains(proposal.Reason, "字幕组") {
+		t.Fatalf("subject without a preferred subgroup should be proposed: %+v, %v", proposal, err)
+	}
+	subscribed, _, _ := records.GetBySubject(200)
+	if subscribed.SubscriptionID == 0 || subscribed.SubgroupName != "桜都字幕组" {
+		t.Fatalf("unexpected subscribed record: %+v", subscribed)
+	}
+
+	// The user deletes the synced subscription; later runs must not bring it back.
+	if err := store.NewSubscriptionStore(db.DB).DeleteCascade(subscribed.SubscriptionID); err != nil {
+		t.Fatalf("delete subscription: %v", err)
+	}
+	report, err = SyncBangumiCollections(context.Background(), reader, mikan, "token", opts, create, func() time.Time { return time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC) })
+	if err != nil {
+		t.Fatalf("second sync returned error: %v", err)
+	}
+	if len(created) != 1 || report.Counts[BangumiSyncStatusDeleted] != 1 || len(report.Subscribed) != 0 || len(report.Proposed) != 0 {
+		t.Fatalf("deleted subscription must not be recreated: created=%v report=%+v", created, report)
+	}
+	deleted, _, _ := records.GetBySubject(200)
+	if deleted.Status != BangumiSyncStatusDeleted || deleted.LastSeenAt == nil || !deleted.LastSeenAt.Equal(time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected record after delete: %+v", deleted)
+	}
+
+	if _, err := SyncBangumiCollections(context.Background(), reader, mikan, "", opts, create, nil); err != ErrBangumiSyncNotAuthorized {
+		t.Fatalf("expected ErrBangumiSyncNotAuthorized, got %v", err)
+	}
+}
+
+func TestParseBangumiSyncSettings(t *testing.T) {
+	types, err := ParseBangumiSyncTypes(" watching , wish,watching")
+	if err != nil || len(types) != 2 || types[0] != BangumiCollectionWatching || FormatBangumiSyncTypes(types) != "watching,wish" {
+		t.Fatalf("ParseBangumiSyncTypes = %v, %v", types, err)
+	}
+	if _, err := ParseBangumiSyncTypes("dropped"); err == nil {
+		t.Fatal("unknown collection types must be rejected")
+	}
+	if got := ParseBangumiSyncSubgroups("LoliHouse，桜都字幕组; ANi\nLoliHouse"); strings.Join(got, "|") != "LoliHouse|桜都字幕组|ANi" {
+		t.Fatalf("ParseBangumiSyncSubgroups = %v", got)
+	}
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"path"
 	"strings"
@@ -27,7 +28,8 @@ type AutoRenameResult struct {
 
 // AutoRenameCompletedDownloads renames completed single-video torrents through
 // qBittorrent itself. This keeps torrent state and seeding intact, including
-// when qBittorrent is running on another machine.
+// when qBittorrent is running on another machine: downloader paths are mapped
+// to local paths for comparison and the new location is mapped back.
 func AutoRenameCompletedDownloads(source TorrentRenameSource) (AutoRenameResult, error) {
 	result := AutoRenameResult{Replacements: map[string]string{}}
 	if source == nil || !autoRenameEnabled() {
@@ -59,6 +61,8 @@ func AutoRenameCompletedDownloads(source TorrentRenameSource) (AutoRenameResult,
 	if err != nil {
 		return result, err
 	}
+	mapper := DownloaderPathMapper()
+	torrents = localTorrentList(mapper, torrents)
 	byHash := make(map[string]downloader.TorrentInfo, len(torrents))
 	byName := make(map[string]downloader.TorrentInfo, len(torrents))
 	for _, torrent := range torrents {
@@ -125,13 +129,13 @@ func AutoRenameCompletedDownloads(source TorrentRenameSource) (AutoRenameResult,
 		}
 		if oldRelative != newRelative {
 			if err := source.RenameFile(torrent.Hash, oldRelative, newRelative); err != nil {
-				result.Failed++
+				countAutoRenameFailure(&result, err)
 				continue
 			}
 		}
 		if !sameTorrentDirectory(torrent.SavePath, targetDir) {
-			if err := source.SetLocation(torrent.Hash, targetDir); err != nil {
-				result.Failed++
+			if err := source.SetLocation(torrent.Hash, mapper.ToRemote(targetDir)); err != nil {
+				countAutoRenameFailure(&result, err)
 				continue
 			}
 		}
@@ -152,6 +156,16 @@ func AutoRenameCompletedDownloads(source TorrentRenameSource) (AutoRenameResult,
 	return result, nil
 }
 
+// countAutoRenameFailure treats a backend that cannot rename or move tasks
+// (aria2) as a skip rather than a failure that would repeat every cycle.
+func countAutoRenameFailure(result *AutoRenameResult, err error) {
+	if errors.Is(err, downloader.ErrUnsupportedOperation) {
+		result.Skipped++
+		return
+	}
+	result.Failed++
+}
+
 func autoRenameEnabled() bool {
 	value := strings.ToLower(strings.TrimSpace(configValue(model.ConfigKeyAutoRenameEnabled)))
 	return value == "" || value == model.ConfigValueTrue || value == "1" || value == "yes" || value == "on"
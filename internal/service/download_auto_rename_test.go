@@ -1,6 +1,9 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+	"runtime"
 	"testing"
 
 	"github.com/pokerjest/animateAutoTool/internal/db"
@@ -118,6 +121,46 @@ func TestAutoRenameCompletedDownloadsDefaultsOnAndSupportsWindowsPaths(t *testin
 	}
 }
 
+func TestAutoRenameCompletedDownloadsAppliesPathMappings(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("mapping targets use POSIX paths")
+	}
+	withServiceTestDB(t)
+	if err := store.NewConfigStore(db.DB).SetMany(map[string]string{
+		model.ConfigKeyBaseDir:                "/mnt/nas/anime",
+		model.ConfigKeyDownloaderPathMappings: "/downloads => /mnt/nas/anime",
+	}); err != nil {
+		t.Fatalf("set config: %v", err)
+	}
+
+	sub := model.Subscription{Title: "Mapped Show", RSSUrl: "https://example.com/mapped"}
+	if err := db.DB.Create(&sub).Error; err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+	entry := model.DownloadLog{SubscriptionID: sub.ID, Title: "release", Episode: "2", Status: downloadLogStatusCompleted, InfoHash: "map"}
+	if err := db.DB.Create(&entry).Error; err != nil {
+		t.Fatalf("create download log: %v", err)
+	}
+
+	source := &fakeTorrentRenameSource{torrents: []downloader.TorrentInfo{{
+		Hash: "MAP", Name: "release", State: "uploading",
+		SavePath: "/downloads/Mapped Show", ContentPath: "/downloads/Mapped Show/release.mkv",
+	}}}
+	result, err := AutoRenameCompletedDownloads(source)
+	if err != nil {
+		t.Fatalf("AutoRenameCompletedDownloads: %v", err)
+	}
+	if result.Renamed != 1 || len(result.Targets) != 1 {
+		t.Fatalf("unexpected result %#v", result)
+	}
+	if result.Targets[0] != "/mnt/nas/anime/Mapped Show/Season 01/Mapped Show - S01E02.mkv" {
+		t.Fatalf("target should be a local path, got %q", result.Targets[0])
+	}
+	if len(source.locations) != 1 || source.locations[0][1] != "/downloads/Mapped Show/Season 01" {
+		t.Fatalf("downloader should receive its own path, got %#v", source.locations)
+	}
+}
+
 func TestAutoRenameCompletedDownloadsPreservesCorrectedReleaseVersion(t *testing.T) {
 	withServiceTestDB(t)
 	if err := store.NewConfigStore(db.DB).SetMany(map[string]string{model.ConfigKeyBaseDir: "/downloads"}); err != nil {
@@ -175,6 +218,15 @@ func TestAutoRenameCompletedDownloadsCanBeDisabledAndSkipsMultiFileRoot(t *testi
 	}
 }
 
+func TestCountAutoRenameFailureSkipsUnsupportedBackends(t *testing.T) {
+	var result AutoRenameResult
+	countAutoRenameFailure(&result, fmt.Errorf("aria2 rename file: %w", downloader.ErrUnsupportedOperation))
+	countAutoRenameFailure(&result, errors.New("qbittorrent rejected rename"))
+	if result.Skipped != 1 || result.Failed != 1 {
+		t.Fatalf("unexpected rename counters: %#v", result)
+	}
+}
+
 func TestMergeCompletedTargetsReplacesOldPathsAndAddsRecoveredRename(t *testing.T) {
 	got := MergeCompletedTargets([]string{"/old/a.mkv", "/keep/b.mkv"}, AutoRenameResult{
 		Targets:      []string{"/new/a.mkv", "/new/c.mkv"},
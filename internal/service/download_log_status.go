@@ -13,6 +13,7 @@ import (
 	"github.com/pokerjest/animateAutoTool/internal/downloader"
 	"github.com/pokerjest/animateAutoTool/internal/model"
 	"github.com/pokerjest/animateAutoTool/internal/parser"
+	"github.com/pokerjest/animateAutoTool/internal/pathutil"
 )
 
 type TorrentStatusSource interface {
@@ -134,6 +135,8 @@ func SyncDownloadLogStatuses(source TorrentStatusSource) (DownloadLogStatusSyncR
 	if err != nil {
 		return DownloadLogStatusSyncResult{}, err
 	}
+	mapper := DownloaderPathMapper()
+	torrents = localTorrentList(mapper, torrents)
 
 	logStore := downloadLogStore()
 	if logStore == nil {
@@ -150,9 +153,7 @@ func SyncDownloadLogStatuses(source TorrentStatusSource) (DownloadLogStatusSyncR
 	byNormalizedName := make(map[string]downloader.TorrentInfo, len(torrents))
 	byEpisode := make(map[string][]downloader.TorrentInfo)
 	for _, torrent := range torrents {
-		if torrent.Hash != "" {
-			addPreferredTorrent(byHash, strings.ToLower(strings.TrimSpace(torrent.Hash)), torrent)
-		}
+		addTorrentIdentities(byHash, torrent)
 		if torrent.Name != "" {
 			addPreferredTorrent(byName, strings.TrimSpace(torrent.Name), torrent)
 			if normalized := parser.NormalizeReleaseTitle(torrent.Name); normalized != "" {
@@ -169,7 +170,7 @@ func SyncDownloadLogStatuses(source TorrentStatusSource) (DownloadLogStatusSyncR
 		torrent, ok := matchTorrentForLogWithNormalized(logEntry, byHash, byName, byNormalizedName)
 		if !ok {
 			var matchedByFallback bool
-			torrent, matchedByFallback = matchTorrentForLogByEpisode(logEntry, subscriptions[logEntry.SubscriptionID], byEpisode)
+			torrent, matchedByFallback = matchTorrentForLogByEpisode(logEntry, subscriptions[logEntry.SubscriptionID], byEpisode, mapper)
 			ok = matchedByFallback
 			if ok {
 				log.Printf("Worker: matched download log by episode/title/path fallback (subscription=%d episode=%s target=%s)",
@@ -194,6 +195,9 @@ func SyncDownloadLogStatuses(source TorrentStatusSource) (DownloadLogStatusSyncR
 		if logEntry.InfoHash == "" && torrent.Hash != "" {
 			updates["info_hash"] = torrent.Hash
 		}
+		if logEntry.TaskID == "" && torrent.TaskID != "" {
+			updates["task_id"] = torrent.TaskID
+		}
 		targetFile := deriveTargetFile(torrent)
 		if targetFile != "" && logEntry.TargetFile != targetFile {
 			updates["target_file"] = targetFile
@@ -292,7 +296,12 @@ func addTorrentEpisodeIdentities(index map[string][]downloader.TorrentInfo, torr
 // related series title, and—when available—the subscription's save directory.
 // This mirrors the directory-first matching used by Jellyfin/Emby while
 // retaining AniRSS-style episode identity as the final deterministic key.
-func matchTorrentForLogByEpisode(logEntry model.DownloadLog, subscription model.Subscription, byEpisode map[string][]downloader.TorrentInfo) (downloader.TorrentInfo, bool) {
+func matchTorrentForLogByEpisode(
+	logEntry model.DownloadLog,
+	subscription model.Subscription,
+	byEpisode map[string][]downloader.TorrentInfo,
+	mapper *pathutil.Mapper,
+) (downloader.TorrentInfo, bool) {
 	season, episode := parser.EpisodeIdentityFromTitle(logEntry.Title)
 	if episode == "" {
 		episode = parser.NormalizeEpisodeNumber(logEntry.Episode)
@@ -309,7 +318,7 @@ func matchTorrentForLogByEpisode(logEntry model.DownloadLog, subscription model.
 
 	expectedPath := ""
 	if subscription.ID != 0 {
-		expectedPath = normalizeTorrentPath(NewSubscriptionManager(nil).resolveSavePath(&subscription, logEntry.SeasonVal))
+		expectedPath = normalizeTorrentPath(mapper.ToLocal(NewSubscriptionManager(nil).resolveSavePath(&subscription, logEntry.SeasonVal)))
 	}
 	if target := strings.TrimSpace(logEntry.TargetFile); target != "" {
 		target = filepath.Dir(filepath.Clean(target))
@@ -437,6 +446,11 @@ func matchTorrentForLog(logEntry model.DownloadLog, byHash map[string]downloader
 }
 
 func matchTorrentForLogWithNormalized(logEntry model.DownloadLog, byHash map[string]downloader.TorrentInfo, byName, byNormalizedName map[string]downloader.TorrentInfo) (downloader.TorrentInfo, bool) {
+	if taskID := strings.TrimSpace(logEntry.TaskID); taskID != "" {
+		if torrent, ok := byHash[torrentTaskKey(taskID)]; ok {
+			return torrent, true
+		}
+	}
 	if hash := strings.ToLower(strings.TrimSpace(logEntry.InfoHash)); hash != "" {
 		if torrent, ok := byHash[hash]; ok {
 			return torrent, true
@@ -459,6 +473,22 @@ func matchTorrentForLogWithNormalized(logEntry model.DownloadLog, byHash map[str
 	return downloader.TorrentInfo{}, false
 }
 
+// addTorrentIdentities indexes a task by info hash and, for backends such as
+// aria2, by its task id. Task ids are prefixed so they never collide with a
+// hash key.
+func addTorrentIdentities(byHash map[string]downloader.TorrentInfo, torrent downloader.TorrentInfo) {
+	if hash := strings.ToLower(strings.TrimSpace(torrent.Hash)); hash != "" {
+		addPreferredTorrent(byHash, hash, torrent)
+	}
+	if taskID := strings.TrimSpace(torrent.TaskID); taskID != "" {
+		addPreferredTorrent(byHash, torrentTaskKey(taskID), torrent)
+	}
+}
+
+func torrentTaskKey(taskID string) string {
+	return "task:" + taskID
+}
+
 func addPreferredTorrent(index map[string]downloader.TorrentInfo, key string, candidate downloader.TorrentInfo) {
 	if strings.TrimSpace(key) == "" {
 		return
@@ -509,11 +539,13 @@ func normalizeTorrentProgress(progress float64) float64 {
 
 func mapTorrentStateToLogStatus(state string) string {
 	switch strings.TrimSpace(state) {
-	case "error", "missingFiles", "unknown":
+	case "error", "missingFiles", "unknown", downloader.Aria2StatusRemoved:
 		return downloadLogStatusFailed
-	case "uploading", "stalledUP", "queuedUP", "pausedUP", "checkingUP", "forcedUP", "allocating", "moving":
+	case "uploading", "stalledUP", "queuedUP", "pausedUP", "checkingUP", "forcedUP", "allocating", "moving",
+		downloader.Aria2StatusComplete, downloader.Aria2StatusSeeding:
 		return downloadLogStatusCompleted
-	case "downloading", "metaDL", "stalledDL", "queuedDL", "pausedDL", "forcedDL", "checkingDL", "checkingResumeData":
+	case "downloading", "metaDL", "stalledDL", "queuedDL", "pausedDL", "forcedDL", "checkingDL", "checkingResumeData",
+		downloader.Aria2StatusActive, downloader.Aria2StatusWaiting, downloader.Aria2StatusPaused:
 		return downloadLogStatusDownloading
 	default:
 		return ""
@@ -521,6 +553,11 @@ func mapTorrentStateToLogStatus(state string) string {
 }
 
 func torrentLogStatus(torrent downloader.TorrentInfo) string {
+	// aria2 reports a finished BitTorrent task the user removed while it was
+	// seeding as "removed"; the bytes on disk are still complete.
+	if torrent.State == downloader.Aria2StatusRemoved && torrent.Size > 0 && torrent.Completed >= torrent.Size {
+		return downloadLogStatusCompleted
+	}
 	mapped := mapTorrentStateToLogStatus(torrent.State)
 	// Explicit qB error states must win even if stale byte counters still
 	// report 100% from an earlier attempt.
@@ -570,7 +607,7 @@ func shouldQueueCompletedTarget(nextStatus string, logEntry model.DownloadLog, t
 	return strings.TrimSpace(logEntry.TargetFile) == ""
 }
 
-func SyncDownloadLogStatusesWithQBClient(client *downloader.QBittorrentClient) (DownloadLogStatusSyncResult, error) {
+func SyncDownloadLogStatusesWithQBClient(client TorrentStatusSource) (DownloadLogStatusSyncResult, error) {
 	if client == nil {
 		return DownloadLogStatusSyncResult{}, nil
 	}
@@ -776,9 +813,7 @@ func ArchiveStaleDownloadLogs(source TorrentStatusSource, maxAge time.Duration)
 			return DownloadLogArchiveResult{}, err
 		}
 		for _, torrent := range torrents {
-			if torrent.Hash != "" {
-				addPreferredTorrent(byHash, strings.ToLower(strings.TrimSpace(torrent.Hash)), torrent)
-			}
+			addTorrentIdentities(byHash, torrent)
 			if torrent.Name != "" {
 				addPreferredTorrent(byName, strings.TrimSpace(torrent.Name), torrent)
 				if normalized := parser.NormalizeReleaseTitle(torrent.Name); normalized != "" {
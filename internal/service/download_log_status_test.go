@@ -99,6 +99,31 @@ func TestDownloadLogStatusKeepsExplicitErrorsFailedAtFullProgress(t *testing.T)
 	}
 }
 
+func TestDownloadLogStatusMapsAria2States(t *testing.T) {
+	cases := map[string]string{
+		downloader.Aria2StatusActive:   downloadLogStatusDownloading,
+		downloader.Aria2StatusWaiting:  downloadLogStatusDownloading,
+		downloader.Aria2StatusPaused:   downloadLogStatusDownloading,
+		downloader.Aria2StatusComplete: downloadLogStatusCompleted,
+		downloader.Aria2StatusError:    downloadLogStatusFailed,
+		downloader.Aria2StatusRemoved:  downloadLogStatusFailed,
+		downloader.Aria2StatusSeeding:  downloadLogStatusCompleted,
+	}
+	for state, want := range cases {
+		torrent := downloader.TorrentInfo{State: state, Size: 1024, Completed: 512}
+		if got := DownloadLogStatusFromTorrent(torrent); got != want {
+			t.Fatalf("DownloadLogStatusFromTorrent(%q) = %q, want %q", state, got, want)
+		}
+	}
+}
+
+func TestDownloadLogStatusTreatsRemovedFinishedAria2TaskAsCompleted(t *testing.T) {
+	torrent := downloader.TorrentInfo{State: downloader.Aria2StatusRemoved, Size: 1024, Completed: 1024}
+	if got := DownloadLogStatusFromTorrent(torrent); got != downloadLogStatusCompleted {
+		t.Fatalf("DownloadLogStatusFromTorrent(%+v) = %q, want completed", torrent, got)
+	}
+}
+
 func TestSyncDownloadLogStatusesReconcilesFullProgressResourceAsCompleted(t *testing.T) {
 	withServiceTestDB(t)
 
@@ -487,6 +512,45 @@ func TestSyncDownloadLogStatusesMarksFailedByInfoHash(t *testing.T) {
 	}
 }
 
+func TestSyncDownloadLogStatusesMatchesDirectDownloadByTaskID(t *testing.T) {
+	withServiceTestDB(t)
+
+	logEntry := model.DownloadLog{
+		SubscriptionID: 2,
+		Title:          "[Group] Direct Show - 04",
+		Status:         downloadLogStatusDownloading,
+		TaskID:         "2089b05ecca3d829",
+	}
+	if err := db.DB.Create(&logEntry).Error; err != nil {
+		t.Fatalf("failed to create log entry: %v", err)
+	}
+
+	result, err := SyncDownloadLogStatuses(fakeTorrentStatusSource{
+		torrents: []downloader.TorrentInfo{{
+			TaskID:      "2089b05ecca3d829",
+			Name:        "direct-04.mp4",
+			State:       downloader.Aria2StatusComplete,
+			ContentPath: "/downloads/direct-04.mp4",
+			Size:        100,
+			Completed:   100,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if result.Completed != 1 {
+		t.Fatalf("unexpected sync result: %#v", result)
+	}
+
+	var updated model.DownloadLog
+	if err := db.DB.First(&updated, logEntry.ID).Error; err != nil {
+		t.Fatalf("failed to reload log entry: %v", err)
+	}
+	if updated.Status != downloadLogStatusCompleted || updated.InfoHash != "" {
+		t.Fatalf("expected completed log without a gid in info_hash, got %+v", updated)
+	}
+}
+
 func TestSyncDownloadLogStatusesBackfillsCompletedTargetForExistingCompletedLog(t *testing.T) {
 	withServiceTestDB(t)
 
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"log"
+
+	"github.com/pokerjest/animateAutoTool/internal/downloader"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/pathutil"
+)
+
+// DownloaderPathMapper loads the configured remote-to-local path mappings.
+// Invalid settings are ignored (and logged) so a typo never moves files to an
+// unexpected place; the settings API rejects them before they are saved.
+func DownloaderPathMapper() *pathutil.Mapper {
+	mappings, err := pathutil.ParseMappings(configValue(model.ConfigKeyDownloaderPathMappings))
+	if err != nil {
+		log.Printf("WARN: Ignoring invalid downloader path mappings: %v", err)
+		return nil
+	}
+	return pathutil.NewMapper(mappings)
+}
+
+// LocalTorrentPaths returns torrent with SavePath and ContentPath translated
+// to paths on this host. Every downloader path that is compared with local
+// paths or turned into a library path goes through here exactly once.
+func LocalTorrentPaths(mapper *pathutil.Mapper, torrent downloader.TorrentInfo) downloader.TorrentInfo {
+	if mapper.Empty() {
+		return torrent
+	}
+	torrent.SavePath = mapper.ToLocal(torrent.SavePath)
+	torrent.ContentPath = mapper.ToLocal(torrent.ContentPath)
+	return torrent
+}
+
+func localTorrentList(mapper *pathutil.Mapper, torrents []downloader.TorrentInfo) []downloader.TorrentInfo {
+	if mapper.Empty() {
+		return torrents
+	}
+	mapped := make([]downloader.TorrentInfo, len(torrents))
+	for i, torrent := range torrents {
+		mapped[i] = LocalTorrentPaths(mapper, torrent)
+	}
+	return mapped
+}
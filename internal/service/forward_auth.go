@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/security"
+	"gorm.io/gorm"
+)
+
+const maxForwardAuthUserMapEntries = 64
+
+var (
+	ErrForwardAuthIdentity    = errors.New("反向代理没有提供登录身份")
+	ErrForwardAuthUnknownUser = errors.New("反向代理提供的身份没有对应账户")
+)
+
+// ForwardAuthOptions controls how an identity asserted by a trusted reverse
+// proxy becomes an account. UserMap keys are lower-cased identities; an empty
+// ProvisionRole disables automatic account creation.
+type ForwardAuthOptions struct {
+	UserMap       map[string]string
+	ProvisionRole string
+}
+
+// ParseForwardAuthUserMap parses "identity => username" lines. Identities are
+// matched case-insensitively because proxies disagree on email casing.
+func ParseForwardAuthUserMap(raw string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, line := range strings.FieldsFunc(raw, func(r rune) bool { return r == '\n' || r == '\r' || r == ';' }) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		identity, username, ok := strings.Cut(line, "=>")
+		identity, username = strings.TrimSpace(identity), strings.TrimSpace(username)
+		if !ok || identity == "" || username == "" {
+			return nil, fmt.Errorf("身份映射格式应为“身份 => 用户名”：%s", line)
+		}
+		if err := validateUsername(username); err != nil {
+			return nil, fmt.Errorf("身份映射 %s：%w", identity, err)
+		}
+		result[strings.ToLower(identity)] = username
+	}
+	if len(result) > maxForwardAuthUserMapEntries {
+		return nil, errors.New("身份映射最多允许 64 项")
+	}
+	return result, nil
+}
+
+// FormatForwardAuthUserMap renders a parsed map in a stable order.
+func FormatForwardAuthUserMap(entries map[string]string) string {
+	lines := make([]string, 0, len(entries))
+	for identity, username := range entries {
+		lines = append(lines, identity+" => "+username)
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// ForwardAuthUser resolves an identity asserted by a trusted proxy to an
+// account: a mapped username first, then an account named after the identity,
+// and finally a new account with opts.ProvisionRole when provisioning is on.
+// The bool result reports whether the account was just created.
+func (s *AuthService) ForwardAuthUser(identity string, opts ForwardAuthOptions) (*model.User, bool, error) {
+	identity = strings.TrimSpace(identity)
+	if identity == "" {
+		return nil, false, ErrForwardAuthIdentity
+	}
+	st := userStore()
+	if st == nil {
+		return nil, false, errors.New("invalid database")
+	}
+	username := identity
+	if mapped, ok := opts.UserMap[strings.ToLower(identity)]; ok {
+		username = mapped
+	}
+	user, err := st.GetByUsername(username)
+	if err == nil {
+		return user, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+	if opts.ProvisionRole == "" {
+		return nil, false, ErrForwardAuthUnknownUser
+	}
+
+	// The account never signs in with a password; a random one keeps the
+	// password login path closed until an admin resets it.
+	password, err := security.RandomPassword(32)
+	if err != nil {
+		return nil, false, err
+	}
+	role := opts.ProvisionRole
+	user, err = s.CreateManagedUser(UserInput{Username: &username, Password: &password, Role: &role})
+	if errors.Is(err, ErrUsernameTaken) {
+		// A concurrent request provisioned the same identity first.
+		user, err = st.GetByUsername(username)
+		return user, false, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return user, true, nil
+}
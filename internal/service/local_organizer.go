@@ -139,6 +139,8 @@ type LocalOrganizer struct {
 	qb       LocalOrganizerQB
 	now      func() time.Time
 	torrents []downloader.TorrentInfo
+	// pathMapper translates qB paths; torrents already hold local paths.
+	pathMapper *pathutil.Mapper
 }
 
 func NewLocalOrganizer(database *gorm.DB, qb LocalOrganizerQB) *LocalOrganizer {
@@ -153,7 +155,7 @@ func NewConfiguredLocalOrganizer() (*LocalOrganizer, error) {
 	if strings.TrimSpace(cfg.URL) == "" {
 		return NewLocalOrganizer(db.DB, nil), nil
 	}
-	client := downloader.NewQBittorrentClient(cfg.URL)
+	client := qbutil.NewClient(cfg)
 	if err := client.Login(cfg.Username, cfg.Password); err != nil {
 		return nil, fmt.Errorf("连接 qBittorrent 失败，已停止整理以保护做种文件: %w", err)
 	}
@@ -197,6 +199,8 @@ func (o *LocalOrganizer) Preview(owner string, request LocalOrganizePreviewReque
 		if err != nil {
 			return nil, fmt.Errorf("读取 qBittorrent 做种状态失败，已停止整理: %w", err)
 		}
+		o.pathMapper = DownloaderPathMapper()
+		o.torrents = localTorrentList(o.pathMapper, o.torrents)
 	}
 
 	directories, err := o.directoryMap(items)
@@ -496,6 +500,11 @@ func (o *LocalOrganizer) classifyQB(change *LocalOrganizeChange) {
 		return
 	}
 	for _, torrent := range o.torrents {
+		if torrent.Released() {
+			// The downloader no longer holds these files (a finished aria2
+			// task), so they can be moved like any other library file.
+			continue
+		}
 		contentPath := filepath.Clean(strings.TrimSpace(torrent.ContentPath))
 		if contentPath == "." || contentPath == "" {
 			continue
@@ -509,8 +518,9 @@ func (o *LocalOrganizer) classifyQB(change *LocalOrganizeChange) {
 			change.qbHash = torrent.Hash
 			change.qbOldRelative = filepath.ToSlash(oldRelative)
 			change.qbNewRelative = filepath.Base(change.Target)
-			change.qbOldDir = filepath.Dir(change.Original)
-			change.qbTargetDir = filepath.Dir(change.Target)
+			// qB locations are stored in the downloader's own path space.
+			change.qbOldDir = o.pathMapper.ToRemote(filepath.Dir(change.Original))
+			change.qbTargetDir = o.pathMapper.ToRemote(filepath.Dir(change.Target))
 			return
 		}
 		if organizerPathWithin(contentPath, change.Original) {
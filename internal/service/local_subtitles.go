@@ -0,0 +1,43 @@
+package service
+
+import (
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"github.com/pokerjest/animateAutoTool/internal/subtitle"
+	"gorm.io/gorm"
+)
+
+// IndexEpisodeSubtitles rediscovers the sidecar subtitles next to an episode
+// and stores them as the episode's subtitle index.
+func IndexEpisodeSubtitles(episode *model.LocalEpisode) ([]model.LocalSubtitle, error) {
+	if db.DB == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	tracks := subtitle.DiscoverSidecars(episode.Path)
+	rows := make([]model.LocalSubtitle, 0, len(tracks))
+	for _, track := range tracks {
+		rows = append(rows, model.LocalSubtitle{
+			LocalEpisodeID: episode.ID, Path: track.Path, Format: track.Format,
+			Language: track.Language, Label: track.Label, Default: track.Default, Forced: track.Forced,
+		})
+	}
+	subtitles := store.NewLocalSubtitleStore(db.DB)
+	if err := subtitles.ReplaceForEpisode(episode.ID, rows); err != nil {
+		return nil, err
+	}
+	return subtitles.ListByEpisode(episode.ID)
+}
+
+// EpisodeSubtitles returns the indexed sidecars for an episode. Episodes
+// scanned before subtitle indexing existed are indexed on first use.
+func EpisodeSubtitles(episode *model.LocalEpisode) ([]model.LocalSubtitle, error) {
+	if db.DB == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	rows, err := store.NewLocalSubtitleStore(db.DB).ListByEpisode(episode.ID)
+	if err != nil || len(rows) > 0 {
+		return rows, err
+	}
+	return IndexEpisodeSubtitles(episode)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"net/url"
+	"path"
 	"strconv"
 	"strings"
 	"sync"
@@ -22,7 +23,10 @@ import (
 )
 
 type SubscriptionManager struct {
-	RSSParser  parser.RSSParser
+	RSSParser parser.RSSParser
+	// Parsers resolves Subscription.Parser. RSSParser stays the default for
+	// subscriptions that do not name a parser.
+	Parsers    *parser.Registry
 	Downloader downloader.Downloader
 	DB         *gorm.DB
 }
@@ -32,6 +36,7 @@ const (
 	SubscriptionRunStatusWarning = "warning"
 	SubscriptionRunStatusError   = "error"
 	SubscriptionRunStatusIdle    = "idle"
+	SubscriptionRunStatusSkipped = "skipped"
 	subscriptionRunSourceManual  = "manual"
 )
 
@@ -48,19 +53,41 @@ func lockSubscriptionRun(subscriptionID uint) func() {
 }
 
 func NewSubscriptionManager(down downloader.Downloader) *SubscriptionManager {
-	rssParser := parser.NewMikanParser()
-	if proxyURL := configuredProxyURL(model.ConfigKeyProxyMikan); proxyURL != "" {
-		if err := rssParser.SetProxy(proxyURL); err != nil {
-			log.Printf("SubscriptionManager: failed to configure Mikan proxy: %v", err)
-		}
-	}
+	parsers := NewRSSParserRegistry()
+	rssParser, _ := parsers.Resolve("")
 	return &SubscriptionManager{
 		RSSParser:  rssParser,
+		Parsers:    parsers,
 		Downloader: down,
 		DB:         db.DB,
 	}
 }
 
+// NewRSSParserRegistry builds every RSS parser a subscription can select, with
+// the configured proxies applied. Mikan is registered first and is therefore
+// the default.
+func NewRSSParserRegistry() *parser.Registry {
+	torznabParser := parser.NewTorznabParser()
+	if proxyURL := configuredProxyURL(model.ConfigKeyProxyRSS); proxyURL != "" {
+		if err := torznabParser.SetProxy(proxyURL); err != nil {
+			log.Printf("SubscriptionManager: failed to configure RSS proxy: %v", err)
+		}
+	}
+	return parser.NewRegistry(NewConfiguredMikanParser(), torznabParser)
+}
+
+// NewConfiguredMikanParser returns a Mikan parser using the proxy when the
+// Mikan proxy toggle is enabled.
+func NewConfiguredMikanParser() *parser.MikanParser {
+	mikanParser := parser.NewMikanParser()
+	if proxyURL := configuredProxyURL(model.ConfigKeyProxyMikan); proxyURL != "" {
+		if err := mikanParser.SetProxy(proxyURL); err != nil {
+			log.Printf("Failed to configure Mikan proxy: %v", err)
+		}
+	}
+	return mikanParser
+}
+
 func RetrySubscriptionsByID(ctx context.Context, down downloader.Downloader, ids []uint, source string) error {
 	if db.DB == nil || len(ids) == 0 {
 		return nil
@@ -368,13 +395,16 @@ func (m *SubscriptionManager) ProcessSubscriptionWithSourceContext(ctx context.C
 		recoveredExisting := false
 		recoveredLocal := false
 		torrentURL := strings.TrimSpace(ep.TorrentURL)
+		torrentType := ep.TorrentType
 		if torrentURL == "" {
 			torrentURL = strings.TrimSpace(ep.Magnet)
+			torrentType = ""
 		}
+		releaseHash := releaseInfoHash(ep, torrentURL)
 
 		// Preflight qB and the local library before submitting. This avoids the
 		// old "send first, recover after Fails." loop when history was missing.
-		existingTorrent, found, lookupErr := m.findExistingTorrent(ctx, sub, ep.Title, seasonVal, episodeNum, identityKey, torrentURL)
+		existingTorrent, found, lookupErr := m.findExistingTorrent(ctx, sub, ep.Title, seasonVal, episodeNum, identityKey, releaseHash)
 		if lookupErr != nil {
 			log.Printf("SubscriptionManager: qB preflight failed for %s - %s: %v", sub.Title, ep.Title, lookupErr)
 		} else if found {
@@ -399,7 +429,10 @@ func (m *SubscriptionManager) ProcessSubscriptionWithSourceContext(ctx context.C
 			}
 		}
 
-		var addErr error
+		var (
+			addErr error
+			taskID string
+		)
 		if !recoveredExisting {
 			if resource != nil && resourceStore != nil {
 				now := time.Now().UTC()
@@ -411,11 +444,12 @@ func (m *SubscriptionManager) ProcessSubscriptionWithSourceContext(ctx context.C
 					"submitted_at":    &now,
 				})
 			}
-			log.Printf("DEBUG: Adding torrent to QB: %s -> %s", ep.Title, savePath)
-			addErr = m.addTorrent(ctx, torrentURL, savePath, "Anime", false)
+			remoteSavePath := DownloaderPathMapper().ToRemote(savePath)
+			log.Printf("DEBUG: Adding torrent to QB: %s -> %s", ep.Title, remoteSavePath)
+			taskID, addErr = m.addTorrent(ctx, sub, torrentURL, torrentType, remoteSavePath, "Anime", false)
 		}
 		if isTorrentRejectedError(addErr) {
-			existingTorrent, found, lookupErr := m.findExistingTorrent(ctx, sub, ep.Title, seasonVal, episodeNum, identityKey, torrentURL)
+			existingTorrent, found, lookupErr := m.findExistingTorrent(ctx, sub, ep.Title, seasonVal, episodeNum, identityKey, releaseHash)
 			if lookupErr != nil {
 				log.Printf("SubscriptionManager: failed to verify rejected qB task for %s - %s: %v", sub.Title, ep.Title, lookupErr)
 			} else if found {
@@ -468,7 +502,7 @@ func (m *SubscriptionManager) ProcessSubscriptionWithSourceContext(ctx context.C
 		}
 		confirmedAdded := false
 		if !recoveredExisting {
-			if confirmed, found, confirmErr := m.findExistingTorrent(ctx, sub, ep.Title, seasonVal, episodeNum, identityKey, torrentURL); confirmErr != nil {
+			if confirmed, found, confirmErr := m.findExistingTorrent(ctx, sub, ep.Title, seasonVal, episodeNum, identityKey, releaseHash); confirmErr != nil {
 				log.Printf("SubscriptionManager: qB confirmation failed for %s - %s: %v", sub.Title, ep.Title, confirmErr)
 			} else if found {
 				matchedTorrent = confirmed
@@ -492,7 +526,7 @@ func (m *SubscriptionManager) ProcessSubscriptionWithSourceContext(ctx context.C
 
 		// 4. 记录日志
 		status := downloadLogStatusDownloading
-		infoHash := torrentInfoHashFromURL(torrentURL)
+		infoHash := releaseHash
 		targetFile := ""
 		if recoveredExisting || confirmedAdded {
 			if mapped := torrentLogStatus(matchedTorrent); mapped != "" {
@@ -501,6 +535,9 @@ func (m *SubscriptionManager) ProcessSubscriptionWithSourceContext(ctx context.C
 			if matchedHash := strings.TrimSpace(matchedTorrent.Hash); matchedHash != "" {
 				infoHash = matchedHash
 			}
+			if taskID == "" {
+				taskID = strings.TrimSpace(matchedTorrent.TaskID)
+			}
 			targetFile = deriveTargetFile(matchedTorrent)
 		}
 		if resource != nil && resourceStore != nil {
@@ -531,6 +568,7 @@ func (m *SubscriptionManager) ProcessSubscriptionWithSourceContext(ctx context.C
 			SeasonVal:      seasonVal,
 			Status:         status,
 			InfoHash:       infoHash,
+			TaskID:         taskID,
 			TargetFile:     targetFile,
 		}
 		var logStore *store.DownloadLogStore
@@ -730,7 +768,7 @@ func (m *SubscriptionManager) findExistingTorrent(
 	seasonValue string,
 	episode string,
 	identityKey string,
-	torrentURL ...string,
+	expectedInfoHash string,
 ) (downloader.TorrentInfo, bool, error) {
 	if m == nil || m.Downloader == nil {
 		return downloader.TorrentInfo{}, false, nil
@@ -753,6 +791,8 @@ func (m *SubscriptionManager) findExistingTorrent(
 		return downloader.TorrentInfo{}, false, err
 	}
 	log.Printf("SubscriptionManager: checking %d qB tasks during reconciliation", len(torrents))
+	mapper := DownloaderPathMapper()
+	torrents = localTorrentList(mapper, torrents)
 
 	matchCtx := existingTorrentMatchContext{
 		releaseTitle:       strings.TrimSpace(releaseTitle),
@@ -762,11 +802,9 @@ func (m *SubscriptionManager) findExistingTorrent(
 		allowMultiSubgroup: sub != nil && sub.AllowMultiSubgroup,
 	}
 	matchCtx.normalizedRelease = parser.NormalizeReleaseTitle(matchCtx.releaseTitle)
-	if len(torrentURL) > 0 {
-		matchCtx.expectedInfoHash = torrentInfoHashFromURL(torrentURL[0])
-	}
+	matchCtx.expectedInfoHash = normalizeTorrentInfoHash(expectedInfoHash)
 	if sub != nil {
-		matchCtx.expectedPath = normalizeTorrentPath(m.resolveSavePath(sub, seasonValue))
+		matchCtx.expectedPath = normalizeTorrentPath(mapper.ToLocal(m.resolveSavePath(sub, seasonValue)))
 		matchCtx.subscriptionTitle = strings.TrimSpace(sub.Title)
 	}
 	if matchCtx.targetEpisode == "" {
@@ -874,6 +912,19 @@ func scoreExistingTorrentEvidence(
 	return 0
 }
 
+// releaseInfoHash prefers the hash advertised by the feed: .torrent download
+// links do not carry one, so deriving it from the URL would leave nyaa and
+// Torznab releases without a hash.
+func releaseInfoHash(ep parser.Episode, torrentURL string) string {
+	if hash := parser.NormalizeInfoHash(ep.InfoHash); hash != "" {
+		return hash
+	}
+	if hash := torrentInfoHashFromURL(torrentURL); hash != "" {
+		return hash
+	}
+	return torrentInfoHashFromURL(ep.Magnet)
+}
+
 func torrentInfoHashFromURL(rawURL string) string {
 	rawURL = strings.TrimSpace(rawURL)
 	if rawURL == "" {
@@ -977,7 +1028,7 @@ func (m *SubscriptionManager) parseRSSWithFallback(
 		return nil, "", false, nil, fmt.Errorf("主 RSS 地址为空")
 	}
 
-	episodes, primaryErr := m.parseRSS(ctx, primary)
+	episodes, primaryErr := m.parseRSS(ctx, sub, primary)
 	if primaryErr == nil && len(episodes) > 0 {
 		return episodes, primary, false, nil, nil
 	}
@@ -1015,7 +1066,7 @@ func (m *SubscriptionManager) parseRSSWithFallback(
 		)
 	}
 
-	backupEpisodes, backupErr := m.parseRSS(ctx, backup)
+	backupEpisodes, backupErr := m.parseRSS(ctx, sub, backup)
 	if backupErr != nil {
 		if primaryErr != nil {
 			combined := fmt.Errorf("主 RSS 请求失败: %v；备用 RSS 请求失败: %w", primaryErr, backupErr)
@@ -1164,6 +1215,7 @@ func (m *SubscriptionManager) appendRunLog(sub *model.Subscription, state subscr
 		NewDownloads:        state.NewDownloads,
 		FailedDownloads:     state.FailedDownloads,
 		LastDownloadedTitle: strings.TrimSpace(state.LastDownloadedTitle),
+		ScheduleReason:      strings.TrimSpace(sub.ScheduleReason),
 	}).Error
 }
 
@@ -1231,7 +1283,7 @@ func (m *SubscriptionManager) diagnoseEmptySubscriptionFeedContext(ctx context.C
 		return ""
 	}
 
-	episodes, err := m.parseRSS(ctx, fallbackURL)
+	episodes, err := m.parseRSS(ctx, sub, fallbackURL)
 	if err != nil || len(episodes) == 0 {
 		return ""
 	}
@@ -1276,33 +1328,94 @@ func (m *SubscriptionManager) loadGlobalConfigValue(key string) string {
 	return store.NewConfigStore(m.DB).GetDefault(key, "")
 }
 
-func (m *SubscriptionManager) parseRSS(ctx context.Context, feedURL string) ([]parser.Episode, error) {
-	if ctxParser, ok := m.RSSParser.(parser.ContextRSSParser); ok {
+// parserFor returns the RSS parser selected by the subscription. Subscriptions
+// without a parser keep using the manager's default (Mikan) parser.
+func (m *SubscriptionManager) parserFor(sub *model.Subscription) (parser.RSSParser, error) {
+	if sub == nil || strings.TrimSpace(sub.Parser) == "" || m.Parsers == nil {
+		return m.RSSParser, nil
+	}
+	rssParser, ok := m.Parsers.Get(sub.Parser)
+	if !ok {
+		return nil, fmt.Errorf("未知的 RSS 解析器: %s", strings.TrimSpace(sub.Parser))
+	}
+	return rssParser, nil
+}
+
+func (m *SubscriptionManager) parseRSS(ctx context.Context, sub *model.Subscription, feedURL string) ([]parser.Episode, error) {
+	rssParser, err := m.parserFor(sub)
+	if err != nil {
+		return nil, err
+	}
+	if ctxParser, ok := rssParser.(parser.ContextRSSParser); ok {
 		return ctxParser.ParseContext(ctx, feedURL)
 	}
-	return m.RSSParser.Parse(feedURL)
+	return rssParser.Parse(feedURL)
 }
 
-func (m *SubscriptionManager) addTorrent(ctx context.Context, torrentURL, savePath, category string, paused bool) error {
+// addTorrent submits a release and returns the downloader task id when the
+// backend reports one (aria2 gid). HTTP links that serve a .torrent are
+// fetched through the RSS client and uploaded, so the downloader host does
+// not need to reach the feed; other HTTP links go to backends able to fetch
+// them directly.
+func (m *SubscriptionManager) addTorrent(ctx context.Context, sub *model.Subscription, torrentURL, torrentType, savePath, category string, paused bool) (string, error) {
 	if isHTTPURL(torrentURL) {
-		fetcher, canFetch := m.RSSParser.(parser.TorrentFetcher)
+		rssParser, _ := m.parserFor(sub)
+		fetcher, canFetch := rssParser.(parser.TorrentFetcher)
 		uploader, canUpload := m.Downloader.(downloader.TorrentFileDownloader)
-		if canFetch && canUpload {
+		if canFetch && canUpload && !m.passDirectURL(ctx, rssParser, torrentURL, torrentType) {
 			filename, data, err := fetcher.FetchTorrentContext(ctx, torrentURL)
 			if err != nil {
-				return fmt.Errorf("fetch torrent file through RSS client: %w", err)
+				return "", fmt.Errorf("fetch torrent file through RSS client: %w", err)
+			}
+			var taskID string
+			if adder, ok := m.Downloader.(downloader.TaskAdder); ok {
+				taskID, err = adder.AddTaskFileContext(ctx, filename, data, savePath, category, paused)
+			} else {
+				err = uploader.AddTorrentFileContext(ctx, filename, data, savePath, category, paused)
 			}
-			if err := uploader.AddTorrentFileContext(ctx, filename, data, savePath, category, paused); err != nil {
-				return fmt.Errorf("upload torrent file to downloader: %w", err)
+			if err != nil {
+				return "", fmt.Errorf("upload torrent file to downloader: %w", err)
 			}
-			return nil
+			return taskID, nil
 		}
 	}
 
+	if adder, ok := m.Downloader.(downloader.TaskAdder); ok {
+		return adder.AddTaskContext(ctx, torrentURL, savePath, category, paused)
+	}
 	if ctxDownloader, ok := m.Downloader.(downloader.ContextDownloader); ok {
-		return ctxDownloader.AddTorrentContext(ctx, torrentURL, savePath, category, paused)
+		return "", ctxDownloader.AddTorrentContext(ctx, torrentURL, savePath, category, paused)
+	}
+	return "", m.Downloader.AddTorrent(torrentURL, savePath, category, paused)
+}
+
+// passDirectURL reports whether an HTTP link should go straight to a backend
+// able to fetch it (aria2) instead of being downloaded and uploaded as torrent
+// metadata. Indexer download links rarely end in .torrent, so the decision
+// uses the feed's enclosure type and otherwise a HEAD request through the RSS
+// client. When neither answers, the link is treated as a torrent.
+func (m *SubscriptionManager) passDirectURL(ctx context.Context, rssParser parser.RSSParser, rawURL, torrentType string) bool {
+	direct, ok := m.Downloader.(downloader.DirectURLDownloader)
+	if !ok || !direct.AcceptsDirectURL() {
+		return false
+	}
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || strings.EqualFold(path.Ext(parsed.Path), ".torrent") {
+		return false
+	}
+	if torrentType = strings.TrimSpace(torrentType); torrentType != "" {
+		return !parser.IsTorrentMediaType(torrentType)
+	}
+	prober, ok := rssParser.(parser.TorrentProber)
+	if !ok {
+		return false
+	}
+	isTorrent, err := prober.ProbeTorrentContext(ctx, rawURL)
+	if err != nil {
+		log.Printf("SubscriptionManager: cannot tell whether %s is a torrent, treating it as one: %v", rawURL, err)
+		return false
 	}
-	return m.Downloader.AddTorrent(torrentURL, savePath, category, paused)
+	return !isTorrent
 }
 
 func isHTTPURL(raw string) bool {
@@ -103,6 +103,34 @@ func (f *fakeTorrentFileDownloader) AddTorrentFileContext(_ context.Context, fil
 	return f.uploadErr
 }
 
+// fakeDirectDownloader mimics aria2: it fetches HTTP links itself and
+// returns a task id for every add.
+type fakeDirectDownloader struct {
+	fakeTorrentFileDownloader
+}
+
+func (f *fakeDirectDownloader) AcceptsDirectURL() bool { return true }
+
+func (f *fakeDirectDownloader) AddTaskContext(_ context.Context, url, _, _ string, _ bool) (string, error) {
+	f.added = append(f.added, url)
+	return "gid-url", nil
+}
+
+func (f *fakeDirectDownloader) AddTaskFileContext(ctx context.Context, filename string, data []byte, savePath, category string, paused bool) (string, error) {
+	return "gid-file", f.AddTorrentFileContext(ctx, filename, data, savePath, category, paused)
+}
+
+type fakeTorrentProber struct {
+	fakeTorrentFetcher
+	isTorrent bool
+	probed    []string
+}
+
+func (f *fakeTorrentProber) ProbeTorrentContext(_ context.Context, rawURL string) (bool, error) {
+	f.probed = append(f.probed, rawURL)
+	return f.isTorrent, nil
+}
+
 func withServiceTestDB(t *testing.T) {
 	t.Helper()
 
@@ -352,7 +380,7 @@ func TestFindExistingTorrentDoesNotCrossEpisodeOrDirectory(t *testing.T) {
 	}}
 	mgr := &SubscriptionManager{Downloader: down}
 
-	got, found, err := mgr.findExistingTorrent(context.Background(), &sub, "[Group] Scoped Show - 01", "S01", "01", "episode:1:1")
+	got, found, err := mgr.findExistingTorrent(context.Background(), &sub, "[Group] Scoped Show - 01", "S01", "01", "episode:1:1", "")
 	if err != nil {
 		t.Fatalf("findExistingTorrent returned error: %v", err)
 	}
@@ -386,7 +414,7 @@ func TestFindExistingTorrentMatchesRejectedMagnetByInfoHash(t *testing.T) {
 		"S01",
 		"03",
 		"episode:1:3",
-		"magnet:?xt=urn:btih:"+infoHash,
+		torrentInfoHashFromURL("magnet:?xt=urn:btih:"+infoHash),
 	)
 	if err != nil {
 		t.Fatalf("findExistingTorrent returned error: %v", err)
@@ -399,6 +427,23 @@ func TestFindExistingTorrentMatchesRejectedMagnetByInfoHash(t *testing.T) {
 	}
 }
 
+func TestReleaseInfoHashPrefersFeedHashOverTorrentLink(t *testing.T) {
+	t.Parallel()
+
+	ep := parser.Episode{
+		TorrentURL: "https://nyaa.si/download/1900001.torrent",
+		Magnet:     "magnet:?xt=urn:btih:ffffffffffffffffffffffffffffffffffffffff",
+		InfoHash:   "0123456789ABCDEF0123456789ABCDEF01234567",
+	}
+	if got := releaseInfoHash(ep, ep.TorrentURL); got != "0123456789abcdef0123456789abcdef01234567" {
+		t.Fatalf("expected feed info hash, got %q", got)
+	}
+	ep.InfoHash = ""
+	if got := releaseInfoHash(ep, ep.TorrentURL); got != "ffffffffffffffffffffffffffffffffffffffff" {
+		t.Fatalf("expected magnet hash fallback, got %q", got)
+	}
+}
+
 func TestTorrentInfoHashFromURLEncodedMagnet(t *testing.T) {
 	const infoHash = "0123456789abcdef0123456789abcdef01234567"
 	got := torrentInfoHashFromURL("magnet:?xt=urn%3Abtih%3A" + infoHash + "&dn=episode")
@@ -407,6 +452,28 @@ func TestTorrentInfoHashFromURLEncodedMagnet(t *testing.T) {
 	}
 }
 
+func TestParseRSSUsesSubscriptionParser(t *testing.T) {
+	t.Parallel()
+
+	selected := fakeRSSParser{episodes: []parser.Episode{{Title: "[Group] Show - 01"}}}
+	mgr := &SubscriptionManager{
+		RSSParser: fakeRSSParser{},
+		Parsers:   parser.NewRegistry(parser.NewMikanParser(), selected),
+	}
+
+	episodes, err := mgr.parseRSS(context.Background(), &model.Subscription{Parser: "FAKE"}, "https://example.com/rss")
+	if err != nil || len(episodes) != 1 {
+		t.Fatalf("expected the selected parser to be used, got %v err=%v", episodes, err)
+	}
+	episodes, err = mgr.parseRSS(context.Background(), &model.Subscription{}, "https://example.com/rss")
+	if err != nil || len(episodes) != 0 {
+		t.Fatalf("expected the default parser without a selection, got %v err=%v", episodes, err)
+	}
+	if _, err := mgr.parseRSS(context.Background(), &model.Subscription{Parser: "missing"}, "https://example.com/rss"); err == nil {
+		t.Fatal("expected an unknown parser to fail instead of falling back")
+	}
+}
+
 func TestAddTorrentFetchesHTTPFileAndUploadsIt(t *testing.T) {
 	t.Parallel()
 
@@ -417,7 +484,7 @@ func TestAddTorrentFetchesHTTPFileAndUploadsIt(t *testing.T) {
 	down := &fakeTorrentFileDownloader{}
 	mgr := &SubscriptionManager{RSSParser: fetcher, Downloader: down}
 
-	err := mgr.addTorrent(context.Background(), "https://mikanani.me/Download/2026/episode.torrent", "/downloads/show", "Anime", true)
+	_, err := mgr.addTorrent(context.Background(), nil, "https://mikanani.me/Download/2026/episode.torrent", "", "/downloads/show", "Anime", true)
 	if err != nil {
 		t.Fatalf("add HTTP torrent: %v", err)
 	}
@@ -443,7 +510,7 @@ func TestAddTorrentKeepsMagnetOnURLPath(t *testing.T) {
 	mgr := &SubscriptionManager{RSSParser: fetcher, Downloader: down}
 
 	magnet := "magnet:?xt=urn:btih:test"
-	if err := mgr.addTorrent(context.Background(), magnet, "/downloads/show", "Anime", false); err != nil {
+	if _, err := mgr.addTorrent(context.Background(), nil, magnet, "", "/downloads/show", "Anime", false); err != nil {
 		t.Fatalf("add magnet: %v", err)
 	}
 	if len(fetcher.fetched) != 0 || down.uploadedFilename != "" {
@@ -454,6 +521,41 @@ func TestAddTorrentKeepsMagnetOnURLPath(t *testing.T) {
 	}
 }
 
+func TestAddTorrentDetectsIndexerTorrentLinksForDirectDownloaders(t *testing.T) {
+	t.Parallel()
+
+	const indexerLink = "https://jackett.local/dl/nyaa/?jackett_apikey=k&path=abc"
+
+	fetcher := &fakeTorrentProber{fakeTorrentFetcher: fakeTorrentFetcher{filename: "release.torrent", data: []byte("d4:infode")}}
+	down := &fakeDirectDownloader{}
+	mgr := &SubscriptionManager{RSSParser: fetcher, Downloader: down}
+
+	taskID, err := mgr.addTorrent(context.Background(), nil, indexerLink, "application/x-bittorrent", "/downloads/show", "Anime", false)
+	if err != nil || taskID != "gid-file" {
+		t.Fatalf("expected torrent enclosure to be uploaded, got task=%q err=%v", taskID, err)
+	}
+	if len(fetcher.fetched) != 1 || len(fetcher.probed) != 0 || len(down.added) != 0 {
+		t.Fatalf("enclosure type should decide without probing: fetched=%v probed=%v added=%v", fetcher.fetched, fetcher.probed, down.added)
+	}
+
+	fetcher.isTorrent = true
+	if _, err := mgr.addTorrent(context.Background(), nil, indexerLink, "", "/downloads/show", "Anime", false); err != nil {
+		t.Fatalf("add probed torrent: %v", err)
+	}
+	if len(fetcher.probed) != 1 || len(fetcher.fetched) != 2 || len(down.added) != 0 {
+		t.Fatalf("expected HEAD probe to route the link through the RSS client: fetched=%v probed=%v added=%v", fetcher.fetched, fetcher.probed, down.added)
+	}
+
+	fetcher.isTorrent = false
+	taskID, err = mgr.addTorrent(context.Background(), nil, "https://cdn.example/Show - 01.mp4", "", "/downloads/show", "Anime", false)
+	if err != nil || taskID != "gid-url" {
+		t.Fatalf("expected direct media link to return the task id, got task=%q err=%v", taskID, err)
+	}
+	if len(down.added) != 1 || len(fetcher.fetched) != 2 {
+		t.Fatalf("expected media link to go to the downloader unchanged: fetched=%v added=%v", fetcher.fetched, down.added)
+	}
+}
+
 func TestConfiguredProxyURLRequiresServiceToggle(t *testing.T) {
 	withServiceTestDB(t)
 	requireConfig := func(key, value string) {
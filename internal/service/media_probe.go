@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/mediaprobe"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"github.com/pokerjest/animateAutoTool/internal/subtitle"
+)
+
+const (
+	mediaProbeTimeout    = 30 * time.Second
+	mediaProbeMaxWorkers = 4
+)
+
+// MediaProbeEnabled reports whether scans run ffprobe on discovered files. It
+// defaults to on; the stage is still skipped when ffprobe cannot be found.
+func MediaProbeEnabled() bool {
+	value := strings.ToLower(strings.TrimSpace(configValue(model.ConfigKeyMediaProbeEnabled)))
+	return value == "" || value == model.ConfigValueTrue
+}
+
+// probeScannedMedia attaches ffprobe results to files. Results are cached by
+// scan fingerprint, so only new or changed files reach ffprobe; cached rows are
+// attached even when ffprobe has since gone missing so rescans stay stable.
+func probeScannedMedia(ctx context.Context, files []scannedMediaFile) {
+	if len(files) == 0 || db.DB == nil || !MediaProbeEnabled() {
+		return
+	}
+	probes := store.NewMediaProbeStore(db.DB)
+	fingerprints := make([]string, 0, len(files))
+	paths := make(map[string]string, len(files))
+	for _, file := range files {
+		if file.Fingerprint == "" {
+			continue
+		}
+		if _, seen := paths[file.Fingerprint]; !seen {
+			paths[file.Fingerprint] = file.Path
+			fingerprints = append(fingerprints, file.Fingerprint)
+		}
+	}
+	cached, err := probes.FindByFingerprints(fingerprints)
+	if err != nil {
+		log.Printf("Scanner: Load media probes failed: %v", err)
+		return
+	}
+
+	pending := make([]string, 0)
+	for _, fingerprint := range fingerprints {
+		if _, ok := cached[fingerprint]; !ok {
+			pending = append(pending, fingerprint)
+		}
+	}
+	if len(pending) > 0 && mediaprobe.Available() {
+		log.Printf("Scanner: Probing %d media files with ffprobe", len(pending))
+		var mu sync.Mutex
+		jobs := make(chan string)
+		var wg sync.WaitGroup
+		for range min(mediaProbeMaxWorkers, runtime.NumCPU(), len(pending)) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for fingerprint := range jobs {
+					row, ok := probeMediaFile(ctx, fingerprint, paths[fingerprint])
+					if !ok {
+						continue
+					}
+					if err := probes.Save(row); err != nil {
+						log.Printf("Scanner: Save media probe failed for %s: %v", paths[fingerprint], err)
+					}
+					mu.Lock()
+					cached[fingerprint] = *row
+					mu.Unlock()
+				}
+			}()
+		}
+	feed:
+		for _, fingerprint := range pending {
+			select {
+			case jobs <- fingerprint:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+		close(jobs)
+		wg.Wait()
+	}
+
+	for i := range files {
+		if row, ok := cached[files[i].Fingerprint]; ok && row.Error == "" {
+			files[i].Probe = &row
+		}
+	}
+}
+
+// probeMediaFile runs ffprobe on one file. Failures are cached too so a broken
+// file is not retried on every scan, except when the scan itself was cancelled.
+func probeMediaFile(ctx context.Context, fingerprint, path string) (*model.MediaProbe, bool) {
+	probeCtx, cancel := context.WithTimeout(ctx, mediaProbeTimeout)
+	defer cancel()
+	result, err := mediaprobe.Probe(probeCtx, path)
+	if ctx.Err() != nil {
+		return nil, false
+	}
+	row := &model.MediaProbe{Fingerprint: fingerprint}
+	if err != nil {
+		if errors.Is(probeCtx.Err(), context.DeadlineExceeded) {
+			err = errors.New("ffprobe timed out")
+		}
+		log.Printf("Scanner: ffprobe failed for %s: %v", path, err)
+		row.Error = err.Error()
+		return row, true
+	}
+	row.DurationTicks = result.DurationTicks
+	row.Container = result.Container
+	row.VideoCodec = result.VideoCodec
+	row.AudioCodec = result.AudioCodec
+	row.Resolution = result.Resolution
+	row.BitDepth = result.BitDepth
+	row.AudioLanguages = marshalProbeJSON(result.AudioLanguages)
+	row.Streams = marshalProbeJSON(result.Streams)
+	row.Subtitles = marshalProbeJSON(result.Subtitles)
+	return row, true
+}
+
+func marshalProbeJSON[T any](values []T) string {
+	if len(values) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// episodeTechnical holds the technical fields stored on LocalEpisode. Probe
+// values win over filename guesses; a field the probe could not read keeps the
+// parsed value.
+type episodeTechnical struct {
+	Resolution    string
+	VideoCodec    string
+	AudioCodec    string
+	BitDepth      string
+	DurationTicks int64
+}
+
+func mediaTechnical(media scannedMediaFile) episodeTechnical {
+	technical := episodeTechnical{
+		Resolution: media.Parsed.Resolution, VideoCodec: media.Parsed.VideoCodec,
+		AudioCodec: media.Parsed.AudioCodec, BitDepth: media.Parsed.BitDepth,
+	}
+	probe := media.Probe
+	if probe == nil {
+		return technical
+	}
+	technical.DurationTicks = probe.DurationTicks
+	if probe.Resolution != "" {
+		technical.Resolution = probe.Resolution
+	}
+	if probe.VideoCodec != "" {
+		technical.VideoCodec = probe.VideoCodec
+	}
+	if probe.AudioCodec != "" {
+		technical.AudioCodec = probe.AudioCodec
+	}
+	if probe.BitDepth != "" {
+		technical.BitDepth = probe.BitDepth
+	}
+	return technical
+}
+
+// CachedEmbeddedSubtitles returns the embedded subtitle tracks recorded by the
+// last scan probe. ok is false when the episode has no usable cached probe.
+func CachedEmbeddedSubtitles(episode *model.LocalEpisode) ([]subtitle.Track, bool) {
+	if episode == nil || episode.ScanFingerprint == "" || db.DB == nil {
+		return nil, false
+	}
+	row, err := store.NewMediaProbeStore(db.DB).FindByFingerprint(episode.ScanFingerprint)
+	if err != nil || row == nil || row.Error != "" {
+		return nil, false
+	}
+	tracks := []subtitle.Track{}
+	if row.Subtitles != "" {
+		if err := json.Unmarshal([]byte(row.Subtitles), &tracks); err != nil {
+			return nil, false
+		}
+	}
+	return tracks, true
+}
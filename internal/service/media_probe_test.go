@@ -0,0 +1,133 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/pokerjest/animateAutoTool/internal/config"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fakeFFProbeOutput = `{"streams":[` +
+	`{"index":0,"codec_type":"video","codec_name":"hevc","width":1920,"height":1080,"pix_fmt":"yuv420p10le"},` +
+	`{"index":1,"codec_type":"audio","codec_name":"flac","tags":{"language":"jpn"}},` +
+	`{"index":2,"codec_type":"subtitle","codec_name":"ass","tags":{"language":"chi"}}],` +
+	`"format":{"format_name":"matroska,webm","duration":"1420.5"}}`
+
+// installFakeFFProbe puts a shell script at BinDir/ffmpeg/ffprobe that logs
+// each probed path and prints fixed JSON, failing for files whose name contains broken.
+func installFakeFFProbe(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ffprobe is a shell script")
+	}
+	binDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(binDir, "ffmpeg"), 0o755))
+	calls := filepath.Join(binDir, "calls.log")
+	script := "#!/bin/sh\n" +
+		"for last; do :; done\n" +
+		"echo \"$last\" >> '" + calls + "'\n" +
+		"case \"$last\" in *broken*) echo 'invalid data' >&2; exit 1;; esac\n" +
+		"echo '" + fakeFFProbeOutput + "'\n"
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "ffmpeg", "ffprobe"), []byte(script), 0o755)) //nolint:gosec
+	original := config.AppPaths.BinDir
+	config.AppPaths.BinDir = binDir
+	t.Cleanup(func() { config.AppPaths.BinDir = original })
+	return calls
+}
+
+func probeCallCount(t *testing.T, calls string) int {
+	t.Helper()
+	data, err := os.ReadFile(calls)
+	if os.IsNotExist(err) {
+		return 0
+	}
+	require.NoError(t, err)
+	return strings.Count(string(data), "\n")
+}
+
+func TestScanProbesMediaOnceAndPrefersProbeValues(t *testing.T) {
+	withServiceTestDB(t)
+	GlobalScanStatus.Skip("")
+	calls := installFakeFFProbe(t)
+
+	root := t.TempDir()
+	show := filepath.Join(root, "Show A")
+	require.NoError(t, os.MkdirAll(show, 0o755))
+	good := filepath.Join(show, "[Group] Show A - 01 [720p][AVC].mkv")
+	broken := filepath.Join(show, "[Group] Show A - 02 broken.mkv")
+	require.NoError(t, os.WriteFile(good, []byte("video one"), 0o600))
+	require.NoError(t, os.WriteFile(broken, []byte("video two"), 0o600))
+	dir := model.LocalAnimeDirectory{Path: root}
+	require.NoError(t, db.DB.Create(&dir).Error)
+
+	scanner := NewScannerService()
+	_, err := scanner.ScanDirectory(&dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, probeCallCount(t, calls))
+
+	var episode model.LocalEpisode
+	require.NoError(t, db.DB.Where("path = ?", good).First(&episode).Error)
+	assert.Equal(t, "1080p", episode.Resolution)
+	assert.Equal(t, "HEVC", episode.VideoCodec)
+	assert.Equal(t, "FLAC", episode.AudioCodec)
+	assert.Equal(t, "10bit", episode.BitDepth)
+	assert.Equal(t, int64(14_205_000_000), episode.DurationTicks)
+
+	row, err := store.NewMediaProbeStore(db.DB).FindByFingerprint(episode.ScanFingerprint)
+	require.NoError(t, err)
+	require.NotNil(t, row)
+	assert.JSONEq(t, `["ja"]`, row.AudioLanguages)
+	tracks, ok := CachedEmbeddedSubtitles(&episode)
+	require.True(t, ok)
+	require.Len(t, tracks, 1)
+	assert.Equal(t, "zh", tracks[0].Language)
+	assert.Equal(t, 2, tracks[0].StreamIndex)
+
+	var failed model.LocalEpisode
+	require.NoError(t, db.DB.Where("path = ?", broken).First(&failed).Error)
+	assert.Zero(t, failed.DurationTicks)
+	_, ok = CachedEmbeddedSubtitles(&failed)
+	assert.False(t, ok)
+
+	// 文件未变化时直接使用缓存，包括失败结果
+	_, err = scanner.ScanDirectory(&dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, probeCallCount(t, calls))
+	require.NoError(t, db.DB.Where("path = ?", good).First(&episode).Error)
+	assert.Equal(t, "HEVC", episode.VideoCodec)
+
+	require.NoError(t, os.WriteFile(good, []byte("video one, re-encoded"), 0o600))
+	_, err = scanner.ScanDirectory(&dir)
+	require.NoError(t, err)
+	assert.Equal(t, 3, probeCallCount(t, calls))
+}
+
+func TestScanSkipsProbeWhenDisabled(t *testing.T) {
+	withServiceTestDB(t)
+	GlobalScanStatus.Skip("")
+	calls := installFakeFFProbe(t)
+	require.NoError(t, store.NewConfigStore(db.DB).Set(model.ConfigKeyMediaProbeEnabled, "false"))
+
+	root := t.TempDir()
+	path := filepath.Join(root, "Show B", "[Group] Show B - 01 [720p][AVC].mkv")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte("video"), 0o600))
+	dir := model.LocalAnimeDirectory{Path: root}
+	require.NoError(t, db.DB.Create(&dir).Error)
+
+	_, err := NewScannerService().ScanDirectory(&dir)
+	require.NoError(t, err)
+	assert.Zero(t, probeCallCount(t, calls))
+	var episode model.LocalEpisode
+	require.NoError(t, db.DB.Where("path = ?", path).First(&episode).Error)
+	assert.Equal(t, "720p", episode.Resolution)
+	assert.Zero(t, episode.DurationTicks)
+}
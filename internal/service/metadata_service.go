@@ -232,12 +232,7 @@ func recoverMetadataProviderPanic(provider string, metadata *model.AnimeMetadata
 
 func (s *MetadataService) initClients() (*bangumi.Client, *tmdb.Client, *anilist.Client) {
 	// Bangumi
-	bgmClient := bangumi.NewClient("", "", "")
-	if proxyURL := configuredProxyURL(model.ConfigKeyProxyBangumi); proxyURL != "" {
-		if err := bgmClient.SetProxy(proxyURL); err != nil {
-			log.Printf("MetadataService: failed to configure Bangumi proxy: %v", err)
-		}
-	}
+	bgmClient := NewConfiguredBangumiClient()
 
 	// TMDB
 	var tmdbClient *tmdb.Client
@@ -3,6 +3,7 @@ package service
 import (
 	"log"
 
+	"github.com/pokerjest/animateAutoTool/internal/bangumi"
 	"github.com/pokerjest/animateAutoTool/internal/httpx"
 	"github.com/pokerjest/animateAutoTool/internal/model"
 )
@@ -18,3 +19,15 @@ func configuredProxyURL(flagKey string) string {
 	}
 	return normalized
 }
+
+// NewConfiguredBangumiClient returns an anonymous Bangumi client using the
+// proxy when the Bangumi proxy toggle is enabled.
+func NewConfiguredBangumiClient() *bangumi.Client {
+	client := bangumi.NewClient("", "", "")
+	if proxyURL := configuredProxyURL(model.ConfigKeyProxyBangumi); proxyURL != "" {
+		if err := client.SetProxy(proxyURL); err != nil {
+			log.Printf("Failed to configure Bangumi proxy: %v", err)
+		}
+	}
+	return client
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/glebarez/sqlite"
 	"github.com/pokerjest/animateAutoTool/internal/authsession"
+	"github.com/pokerjest/animateAutoTool/internal/config"
 	"github.com/pokerjest/animateAutoTool/internal/db"
 	"github.com/pokerjest/animateAutoTool/internal/model"
 	"github.com/pokerjest/animateAutoTool/internal/safeio"
@@ -688,8 +689,19 @@ func (s *RestoreService) writeRestoreData(tx *gorm.DB, d *restoreData, options R
 type restoreBatchWriter func(data any) error
 
 func writeRestoreConfigs(tx *gorm.DB, d *restoreData, desc BackupDescriptor, createBatch restoreBatchWriter) error {
+	configs, unreadable, err := resealRestoreConfigs(d.configs)
+	if err != nil {
+		return err
+	}
+	if len(unreadable) > 0 {
+		log.Printf(
+			"WARN: RestoreService: keeping current values for secrets encrypted with an unavailable settings key count=%d keys=%s",
+			len(unreadable),
+			strings.Join(unreadable, ","),
+		)
+	}
 	if BackupConfigMerges(desc.Mode) {
-		for _, cfg := range d.configs {
+		for _, cfg := range configs {
 			if err := tx.Where(model.GlobalConfig{Key: cfg.Key}).
 				Assign(model.GlobalConfig{Value: cfg.Value}).
 				FirstOrCreate(&model.GlobalConfig{}).Error; err != nil {
@@ -709,12 +721,16 @@ func writeRestoreConfigs(tx *gorm.DB, d *restoreData, desc BackupDescriptor, cre
 			Find(&preserved).Error; err != nil {
 			return err
 		}
+	} else if len(unreadable) > 0 {
+		if err := tx.Where("key IN ?", unreadable).Find(&preserved).Error; err != nil {
+			return err
+		}
 	}
 	if err := tx.Exec("DELETE FROM global_configs").Error; err != nil {
 		return err
 	}
-	if len(d.configs) > 0 {
-		if err := createBatch(&d.configs); err != nil {
+	if len(configs) > 0 {
+		if err := createBatch(&configs); err != nil {
 			return err
 		}
 	}
@@ -724,6 +740,29 @@ func writeRestoreConfigs(tx *gorm.DB, d *restoreData, desc BackupDescriptor, cre
 	return nil
 }
 
+// resealRestoreConfigs re-encrypts backed-up secrets with the current
+// settings key. Full backups carry ciphertext from the device that made them;
+// a secret whose key is not in the local key file cannot be recovered, so it
+// is dropped from the restore set and reported instead of being written as an
+// unreadable value.
+func resealRestoreConfigs(configs []model.GlobalConfig) ([]model.GlobalConfig, []string, error) {
+	kept := make([]model.GlobalConfig, 0, len(configs))
+	var unreadable []string
+	for _, cfg := range configs {
+		plaintext, err := config.DecryptSetting(cfg.Key, cfg.Value)
+		if err != nil {
+			unreadable = append(unreadable, cfg.Key)
+			continue
+		}
+		stored, err := config.EncryptSetting(cfg.Key, plaintext)
+		if err != nil {
+			return nil, nil, err
+		}
+		kept = append(kept, model.GlobalConfig{Key: cfg.Key, Value: stored})
+	}
+	return kept, unreadable, nil
+}
+
 func replaceRestoreRows(hasTable bool, rowCount int, deleteRows func() error, data any, createBatch restoreBatchWriter) error {
 	if !hasTable {
 		return nil
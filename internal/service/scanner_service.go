@@ -16,9 +16,11 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 	"unicode"
 
 	"github.com/pokerjest/animateAutoTool/internal/event"
+	"github.com/pokerjest/animateAutoTool/internal/metrics"
 	"github.com/pokerjest/animateAutoTool/internal/model"
 	"github.com/pokerjest/animateAutoTool/internal/parser"
 	"github.com/pokerjest/animateAutoTool/internal/runtimejournal"
@@ -32,6 +34,13 @@ const (
 	scanEpisodeTypeEpisode    = "episode"
 )
 
+var (
+	scanDuration        = metrics.NewHistogramVec("animate_scan_duration_seconds", "Duration of local library scans; scope is root for whole library roots and targets for incremental scans.", []float64{0.1, 0.5, 1, 5, 15, 30, 60, 300, 900, 1800}, "scope", "outcome")
+	scanFiles           = metrics.NewCounterVec("animate_scan_files_total", "Media files read by local library scans.", "scope")
+	scanSeriesChanges   = metrics.NewCounterVec("animate_scan_series_changes_total", "Local series added, updated or deleted by library scans.", "change")
+	scanDiscoveredFiles = metrics.NewGaugeVec("animate_scan_discovered_files", "Media files found by the most recent full scan of each library root.", "directory")
+)
+
 var (
 	scanRunMu               sync.Mutex
 	scanLeadingGroupPattern = regexp.MustCompile(`^(?:\[[^\]]+\]\s*)+`)
@@ -121,6 +130,7 @@ type scannedMediaFile struct {
 	Loose         bool
 	ParsedSeason  string
 	ParseConflict string
+	Probe         *model.MediaProbe
 }
 
 type scanCandidate struct {
@@ -362,7 +372,8 @@ func (s *ScannerService) scanScope(
 	return s.scanScopeContext(context.Background(), dir, scanRoot, claimedFiles, onDirectory, cleanupWholeDirectory)
 }
 
-//nolint:gocyclo // Scan scope preserves the existing safe scan/update/cleanup phases.
+// scanScopeContext runs one scan and records its duration and file counts
+// for /metrics.
 func (s *ScannerService) scanScopeContext(
 	ctx context.Context,
 	dir *model.LocalAnimeDirectory,
@@ -370,6 +381,37 @@ func (s *ScannerService) scanScopeContext(
 	claimedFiles map[string]struct{},
 	onDirectory func(string),
 	cleanupWholeDirectory bool,
+) (*ScanResult, []uint, error) {
+	startedAt := time.Now()
+	result, removedIDs, err := s.runScanScope(ctx, dir, scanRoot, claimedFiles, onDirectory, cleanupWholeDirectory)
+	scope, outcome := "targets", "success"
+	if cleanupWholeDirectory {
+		scope = "root"
+	}
+	if err != nil {
+		outcome = "error"
+	}
+	scanDuration.Observe(time.Since(startedAt).Seconds(), scope, outcome)
+	if result != nil {
+		scanFiles.Add(float64(result.DiscoveredFiles), scope)
+		scanSeriesChanges.Add(float64(result.Added), "added")
+		scanSeriesChanges.Add(float64(result.Updated), "updated")
+		scanSeriesChanges.Add(float64(result.Deleted), "deleted")
+		if cleanupWholeDirectory && dir != nil {
+			scanDiscoveredFiles.Set(float64(result.DiscoveredFiles), filepath.Clean(dir.Path))
+		}
+	}
+	return result, removedIDs, err
+}
+
+//nolint:gocyclo // Scan scope preserves the existing safe scan/update/cleanup phases.
+func (s *ScannerService) runScanScope(
+	ctx context.Context,
+	dir *model.LocalAnimeDirectory,
+	scanRoot string,
+	claimedFiles map[string]struct{},
+	onDirectory func(string),
+	cleanupWholeDirectory bool,
 ) (*ScanResult, []uint, error) {
 	if ctx == nil {
 		ctx = context.Background()
@@ -402,6 +444,10 @@ func (s *ScannerService) scanScopeContext(
 		_ = reportScanIssue(issueKey, root, errors.Join(walkErrors...))
 	}
 
+	probeScannedMedia(ctx, mediaFiles)
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
 	candidates := buildScanCandidates(root, mediaFiles)
 	st := localAnimeStore()
 	if st == nil {
@@ -1334,6 +1380,7 @@ func (s *ScannerService) syncCandidateEpisodes(st interface {
 				log.Printf("Scanner: Create episode failed for %s: %v", media.Path, createErr)
 				continue
 			}
+			indexScannedSubtitles(episode)
 			changed = true
 			continue
 		}
@@ -1348,11 +1395,21 @@ func (s *ScannerService) syncCandidateEpisodes(st interface {
 			}
 			changed = true
 		}
+		indexScannedSubtitles(episode)
 	}
 	return changed
 }
 
+// indexScannedSubtitles refreshes sidecar subtitles on every scan because
+// subtitle files can appear or disappear without the video changing.
+func indexScannedSubtitles(episode *model.LocalEpisode) {
+	if _, err := IndexEpisodeSubtitles(episode); err != nil {
+		log.Printf("Scanner: Index subtitles failed for %s: %v", episode.Path, err)
+	}
+}
+
 func episodeFromMedia(animeID uint, media scannedMediaFile) *model.LocalEpisode {
+	technical := mediaTechnical(media)
 	return &model.LocalEpisode{
 		LocalAnimeID: animeID, Title: media.Title, EpisodeNum: media.Episode, SeasonNum: media.Season,
 		Path: media.Path, FileSize: media.Size, Container: media.Parsed.Extension,
@@ -1361,13 +1418,14 @@ func episodeFromMedia(animeID uint, media scannedMediaFile) *model.LocalEpisode
 		AbsoluteEpisodeNum: media.Parsed.AbsoluteEpisode, VersionTag: media.Parsed.Version,
 		LanguageTag: media.Parsed.Language, ParseSource: media.Parsed.ParseSource,
 		ParseConfidence: media.Parsed.Confidence, ScanFingerprint: media.Fingerprint,
-		Resolution: media.Parsed.Resolution, SubGroup: media.Parsed.Group,
-		VideoCodec: media.Parsed.VideoCodec, AudioCodec: media.Parsed.AudioCodec,
-		BitDepth: media.Parsed.BitDepth, Source: media.Parsed.Source,
+		Resolution: technical.Resolution, SubGroup: media.Parsed.Group,
+		VideoCodec: technical.VideoCodec, AudioCodec: technical.AudioCodec,
+		BitDepth: technical.BitDepth, Source: media.Parsed.Source, DurationTicks: technical.DurationTicks,
 	}
 }
 
 func updateEpisodeFromMedia(episode *model.LocalEpisode, animeID uint, media scannedMediaFile) bool {
+	technical := mediaTechnical(media)
 	changed := episode.DeletedAt.Valid || episode.LocalAnimeID != animeID || episode.Title != media.Title ||
 		episode.EpisodeNum != media.Episode || episode.SeasonNum != media.Season || episode.FileSize != media.Size ||
 		episode.EpisodeEndNum != media.Parsed.EpisodeEnd || episode.EpisodeType != media.Parsed.EpisodeType ||
@@ -1375,10 +1433,10 @@ func updateEpisodeFromMedia(episode *model.LocalEpisode, animeID uint, media sca
 		episode.LanguageTag != media.Parsed.Language || episode.ParseSource != media.Parsed.ParseSource ||
 		episode.ParseConfidence != media.Parsed.Confidence || episode.ScanFingerprint != media.Fingerprint ||
 		episode.Container != media.Parsed.Extension || episode.ParsedTitle != media.Parsed.Title ||
-		episode.ParsedSeason != media.ParsedSeason || episode.Resolution != media.Parsed.Resolution ||
-		episode.SubGroup != media.Parsed.Group || episode.VideoCodec != media.Parsed.VideoCodec ||
-		episode.AudioCodec != media.Parsed.AudioCodec || episode.BitDepth != media.Parsed.BitDepth ||
-		episode.Source != media.Parsed.Source
+		episode.ParsedSeason != media.ParsedSeason || episode.Resolution != technical.Resolution ||
+		episode.SubGroup != media.Parsed.Group || episode.VideoCodec != technical.VideoCodec ||
+		episode.AudioCodec != technical.AudioCodec || episode.BitDepth != technical.BitDepth ||
+		episode.Source != media.Parsed.Source || episode.DurationTicks != technical.DurationTicks
 	if !changed {
 		return false
 	}
@@ -1399,12 +1457,13 @@ func updateEpisodeFromMedia(episode *model.LocalEpisode, animeID uint, media sca
 	episode.Container = media.Parsed.Extension
 	episode.ParsedTitle = media.Parsed.Title
 	episode.ParsedSeason = media.ParsedSeason
-	episode.Resolution = media.Parsed.Resolution
+	episode.Resolution = technical.Resolution
 	episode.SubGroup = media.Parsed.Group
-	episode.VideoCodec = media.Parsed.VideoCodec
-	episode.AudioCodec = media.Parsed.AudioCodec
-	episode.BitDepth = media.Parsed.BitDepth
+	episode.VideoCodec = technical.VideoCodec
+	episode.AudioCodec = technical.AudioCodec
+	episode.BitDepth = technical.BitDepth
 	episode.Source = media.Parsed.Source
+	episode.DurationTicks = technical.DurationTicks
 	return true
 }
 
@@ -499,3 +499,31 @@ func TestScannerUsesSpecialDirectoryEvidenceForPlainEpisodeNames(t *testing.T) {
 	require.Equal(t, "special", episode.EpisodeType)
 	require.Equal(t, "directory:special", episode.ParseSource)
 }
+
+func TestScannerIndexesSidecarSubtitlesOnEveryScan(t *testing.T) {
+	withServiceTestDB(t)
+	root := t.TempDir()
+	video := filepath.Join(root, "Sub Show", "[ANi] Sub Show - 01 [1080p].mkv")
+	writeScannerFixture(t, video)
+	writeScannerFixture(t, strings.TrimSuffix(video, ".mkv")+".chs.ass")
+	directory := createScannerDirectory(t, root)
+	scanner := NewScannerService()
+
+	_, err := scanner.ScanDirectory(&directory)
+	require.NoError(t, err)
+	var episode model.LocalEpisode
+	require.NoError(t, db.DB.Where("path = ?", video).First(&episode).Error)
+	var subtitles []model.LocalSubtitle
+	require.NoError(t, db.DB.Where("local_episode_id = ?", episode.ID).Find(&subtitles).Error)
+	require.Len(t, subtitles, 1)
+	require.Equal(t, "zh-Hans", subtitles[0].Language)
+
+	// 视频没有变化时，新加入的字幕也要在重新扫描后出现
+	writeScannerFixture(t, strings.TrimSuffix(video, ".mkv")+".cht.srt")
+	_, err = scanner.ScanDirectory(&directory)
+	require.NoError(t, err)
+	subtitles = nil
+	require.NoError(t, db.DB.Where("local_episode_id = ?", episode.ID).Order("path").Find(&subtitles).Error)
+	require.Len(t, subtitles, 2)
+	require.Equal(t, "zh-Hant", subtitles[1].Language)
+}
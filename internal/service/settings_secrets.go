@@ -0,0 +1,118 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/pokerjest/animateAutoTool/internal/config"
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"gorm.io/gorm"
+)
+
+// SettingsSecretsStatus summarizes how stored credentials are protected.
+type SettingsSecretsStatus struct {
+	KeyID          string   `json:"key_id"`
+	RetainedKeyIDs []string `json:"retained_key_ids"`
+	KeyFile        string   `json:"key_file"`
+	Persistent     bool     `json:"persistent"`
+	EncryptedCount int      `json:"encrypted_count"`
+	PlaintextCount int      `json:"plaintext_count"`
+	UnreadableKeys []string `json:"unreadable_keys"`
+}
+
+// SettingsKeyRotation reports the outcome of RotateSettingsKey.
+type SettingsKeyRotation struct {
+	PreviousKeyID  string   `json:"previous_key_id"`
+	KeyID          string   `json:"key_id"`
+	Resealed       int      `json:"resealed"`
+	UnreadableKeys []string `json:"unreadable_keys"`
+}
+
+// LoadSettingsSecretsStatus inspects every sensitive setting without
+// returning any value.
+func LoadSettingsSecretsStatus() (SettingsSecretsStatus, error) {
+	if db.DB == nil {
+		return SettingsSecretsStatus{}, gorm.ErrInvalidDB
+	}
+	info, err := config.SettingsKeyStatus()
+	if err != nil {
+		return SettingsSecretsStatus{}, err
+	}
+	status := SettingsSecretsStatus{
+		KeyID:          info.KeyID,
+		RetainedKeyIDs: info.RetainedKeyIDs,
+		KeyFile:        info.Path,
+		Persistent:     info.Persistent,
+		UnreadableKeys: []string{},
+	}
+
+	var configs []model.GlobalConfig
+	if err := db.DB.Find(&configs).Error; err != nil {
+		return SettingsSecretsStatus{}, err
+	}
+	for _, cfg := range configs {
+		if cfg.Value == "" || !IsSensitiveConfigKey(cfg.Key) {
+			continue
+		}
+		if !config.IsEncryptedSetting(cfg.Value) {
+			status.PlaintextCount++
+			continue
+		}
+		if _, err := config.DecryptSetting(cfg.Key, cfg.Value); err != nil {
+			status.UnreadableKeys = append(status.UnreadableKeys, cfg.Key)
+			continue
+		}
+		status.EncryptedCount++
+	}
+	sort.Strings(status.UnreadableKeys)
+	return status, nil
+}
+
+// RotateSettingsKey generates a new settings key, re-encrypts every stored
+// secret and the config.yaml mirror with it, and keeps the previous key so
+// recent full backups still restore.
+func RotateSettingsKey() (SettingsKeyRotation, error) {
+	if db.DB == nil {
+		return SettingsKeyRotation{}, gorm.ErrInvalidDB
+	}
+	previous, err := config.SettingsKeyStatus()
+	if err != nil {
+		return SettingsKeyRotation{}, err
+	}
+	result := SettingsKeyRotation{PreviousKeyID: previous.KeyID, UnreadableKeys: []string{}}
+	keyID, err := config.RotateSettingsKey(func() error {
+		if err := db.DB.Transaction(func(tx *gorm.DB) error {
+			resealed, unreadable, err := db.ResealSensitiveConfigs(tx)
+			if err != nil {
+				return err
+			}
+			result.Resealed = resealed
+			if unreadable != nil {
+				result.UnreadableKeys = unreadable
+			}
+			return nil
+		}); err != nil {
+			return fmt.Errorf("re-encrypt stored secrets: %w", err)
+		}
+		if err := db.ExportGlobalConfigsToConfigFile(); err != nil {
+			return fmt.Errorf("re-encrypt config mirror: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("ERROR: SettingsSecrets: key rotation failed previous_key=%s error=%v", previous.KeyID, err)
+		return SettingsKeyRotation{}, err
+	}
+	result.KeyID = keyID
+	sort.Strings(result.UnreadableKeys)
+	log.Printf(
+		"SettingsSecrets: key rotated previous_key=%s key=%s resealed=%d unreadable=%d",
+		result.PreviousKeyID,
+		result.KeyID,
+		result.Resealed,
+		len(result.UnreadableKeys),
+	)
+	return result, nil
+}
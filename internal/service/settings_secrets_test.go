@@ -0,0 +1,53 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+)
+
+func TestRotateSettingsKeyReencryptsStoredSecrets(t *testing.T) {
+	withServiceTestDB(t)
+
+	if err := db.SaveGlobalConfig(model.ConfigKeyJellyfinApiKey, "jf-key"); err != nil {
+		t.Fatalf("seed api key: %v", err)
+	}
+	if err := db.DB.Create(&model.GlobalConfig{Key: model.ConfigKeyPikPakPassword, Value: "legacy-plaintext"}).Error; err != nil {
+		t.Fatalf("seed plaintext password: %v", err)
+	}
+	before, err := LoadSettingsSecretsStatus()
+	if err != nil {
+		t.Fatalf("LoadSettingsSecretsStatus: %v", err)
+	}
+	if before.EncryptedCount != 1 || before.PlaintextCount != 1 {
+		t.Fatalf("unexpected status before rotation: %#v", before)
+	}
+
+	result, err := RotateSettingsKey()
+	if err != nil {
+		t.Fatalf("RotateSettingsKey: %v", err)
+	}
+	if result.PreviousKeyID != before.KeyID || result.KeyID == before.KeyID || result.Resealed != 2 {
+		t.Fatalf("unexpected rotation result: %#v", result)
+	}
+
+	var row model.GlobalConfig
+	if err := db.DB.First(&row, "key = ?", model.ConfigKeyJellyfinApiKey).Error; err != nil {
+		t.Fatalf("load raw row: %v", err)
+	}
+	if !strings.Contains(row.Value, ":"+result.KeyID+":") {
+		t.Fatalf("expected api key resealed with %s, got %q", result.KeyID, row.Value)
+	}
+	if got := testGlobalConfigValue(t, model.ConfigKeyPikPakPassword); got != "legacy-plaintext" {
+		t.Fatalf("expected plaintext password to stay readable, got %q", got)
+	}
+	after, err := LoadSettingsSecretsStatus()
+	if err != nil {
+		t.Fatalf("LoadSettingsSecretsStatus: %v", err)
+	}
+	if after.KeyID != result.KeyID || after.EncryptedCount != 2 || after.PlaintextCount != 0 || len(after.UnreadableKeys) != 0 {
+		t.Fatalf("unexpected status after rotation: %#v", after)
+	}
+}
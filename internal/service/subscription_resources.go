@@ -355,7 +355,7 @@ func (m *SubscriptionManager) upsertEpisodeResource(
 		VersionTag:     resourceVersionTag(ep.Title),
 		TorrentURL:     torrentURL,
 		RSSURL:         rssURL,
-		InfoHash:       torrentInfoHashFromURL(torrentURL),
+		InfoHash:       releaseInfoHash(ep, torrentURL),
 		Source:         sourceLabel,
 		State:          strings.TrimSpace(state),
 		StateReason:    strings.TrimSpace(reason),
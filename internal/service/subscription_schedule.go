@@ -0,0 +1,43 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"gorm.io/gorm"
+)
+
+// RecordSubscriptionScheduleSkip appends a skipped run entry explaining why
+// the scheduler did not check a subscription. Consecutive skips for the same
+// reason are recorded once so that every tick does not add a row.
+func RecordSubscriptionScheduleSkip(sub *model.Subscription, checkedAt time.Time, reason, summary string) error {
+	if sub == nil || sub.ID == 0 || db.DB == nil {
+		return nil
+	}
+	reason = strings.TrimSpace(reason)
+
+	var latest model.SubscriptionRunLog
+	err := db.DB.Where("subscription_id = ?", sub.ID).
+		Order("checked_at DESC, id DESC").
+		First(&latest).Error
+	switch {
+	case err == nil:
+		if latest.Status == SubscriptionRunStatusSkipped && latest.ScheduleReason == reason {
+			return nil
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+
+	return db.DB.Create(&model.SubscriptionRunLog{
+		SubscriptionID: sub.ID,
+		CheckedAt:      checkedAt,
+		TriggerSource:  "auto",
+		Status:         SubscriptionRunStatusSkipped,
+		Summary:        strings.TrimSpace(summary),
+		ScheduleReason: reason,
+	}).Error
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+)
+
+func TestRecordSubscriptionScheduleSkipCollapsesRepeatedReasons(t *testing.T) {
+	withServiceTestDB(t)
+
+	sub := model.Subscription{Title: "Quiet Show", RSSUrl: "https://example.test/rss", IsActive: true}
+	if err := db.DB.Create(&sub).Error; err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		if err := RecordSubscriptionScheduleSkip(&sub, now.Add(time.Duration(i)*15*time.Minute), "off_window", "非放送时段"); err != nil {
+			t.Fatalf("record skip: %v", err)
+		}
+	}
+	if err := RecordSubscriptionScheduleSkip(&sub, now.Add(time.Hour), "quiet_hours", "处于静默时段"); err != nil {
+		t.Fatalf("record skip: %v", err)
+	}
+
+	sub.ScheduleReason = "release_window"
+	mgr := &SubscriptionManager{DB: db.DB}
+	if err := mgr.appendRunLog(&sub, subscriptionRunState{Source: "auto", CheckedAt: now.Add(2 * time.Hour), Status: SubscriptionRunStatusIdle}); err != nil {
+		t.Fatalf("append run log: %v", err)
+	}
+	if err := RecordSubscriptionScheduleSkip(&sub, now.Add(3*time.Hour), "off_window", "非放送时段"); err != nil {
+		t.Fatalf("record skip: %v", err)
+	}
+
+	var runs []model.SubscriptionRunLog
+	if err := db.DB.Where("subscription_id = ?", sub.ID).Order("checked_at ASC").Find(&runs).Error; err != nil {
+		t.Fatalf("load run logs: %v", err)
+	}
+	var reasons []string
+	for _, run := range runs {
+		reasons = append(reasons, run.Status+":"+run.ScheduleReason)
+	}
+	want := []string{"skipped:off_window", "skipped:quiet_hours", "idle:release_window", "skipped:off_window"}
+	if len(reasons) != len(want) {
+		t.Fatalf("unexpected run logs: %v", reasons)
+	}
+	for i := range want {
+		if reasons[i] != want[i] {
+			t.Fatalf("unexpected run logs: %v", reasons)
+		}
+	}
+}
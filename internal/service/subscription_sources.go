@@ -0,0 +1,725 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/pokerjest/animateAutoTool/internal/bangumi"
+	"github.com/pokerjest/animateAutoTool/internal/downloader"
+	"github.com/pokerjest/animateAutoTool/internal/parser"
+	appversion "github.com/pokerjest/animateAutoTool/internal/version"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Subscription sources are other tools whose rules can be migrated here.
+// Importing from a source only produces SubscriptionBundleItems; the caller
+// previews them and creates subscriptions through the bundle import path.
+const (
+	SubscriptionSourceAutoBangumi = "autobangumi"
+	SubscriptionSourceSonarr      = "sonarr"
+	SubscriptionSourceQBittorrent = "qbittorrent"
+
+	subscriptionSourceResolveTimeout = 30 * time.Second
+	subscriptionSourceResolveWorkers = 3
+)
+
+var (
+	ErrAutoBangumiFormat = errors.New("not an AutoBangumi database or export")
+	ErrSonarrFormat      = errors.New("not a Sonarr series export")
+)
+
+var sqliteFileHeader = []byte("SQLite format 3\x00")
+
+// ExternalSubscription is one entry read from another tool, already mapped
+// onto subscription fields. Notes are shown to the user next to the preview
+// row and describe anything that could not be carried over exactly.
+type ExternalSubscription struct {
+	Item       SubscriptionBundleItem
+	SourceName string
+	Notes      []string
+
+	// keyword is the title searched on Bangumi and Mikan when the entry has
+	// no per-bangumi Mikan feed.
+	keyword string
+	// subgroup narrows a resolved Mikan feed to the entry's release group.
+	subgroup string
+	// fallbackFilter keeps an aggregate feed usable when resolution fails.
+	fallbackFilter string
+	// sharedFeed is the original feed when other entries use it too.
+	sharedFeed string
+}
+
+func (e *ExternalSubscription) note(format string, args ...any) {
+	e.Notes = append(e.Notes, fmt.Sprintf(format, args...))
+}
+
+// needsMikanFeed reports whether the entry should be pointed at a Mikan
+// per-bangumi feed: it has none at all, only an aggregate Mikan feed such as
+// MyBangumi that would match every show, or a feed shared with other entries
+// (a Nyaa search used by several rules) that the import would skip as a
+// duplicate.
+func (e *ExternalSubscription) needsMikanFeed() bool {
+	if e.keyword == "" {
+		return false
+	}
+	if e.Item.RSSUrl == "" {
+		return true
+	}
+	if _, ok := parser.MikanIDFromRSSURL(e.Item.RSSUrl); ok {
+		return false
+	}
+	return e.sharedFeed != "" || isMikanHost(e.Item.RSSUrl)
+}
+
+var sourceSeasonDirectoryPattern = regexp.MustCompile(`(?i)^(?:season\s*\d{1,2}|s\d{1,2})$`)
+
+// trimSeasonDirectory drops a trailing "Season N" directory from the save
+// path. With auto rename on, downloads already go to a season directory
+// below the save path, so keeping it would nest two of them.
+func (e *ExternalSubscription) trimSeasonDirectory() {
+	if e.Item.SavePath == "" || !autoRenameEnabled() {
+		return
+	}
+	trimmed := strings.TrimRight(e.Item.SavePath, `/\`)
+	cut := strings.LastIndexAny(trimmed, `/\`)
+	if cut <= 0 {
+		return
+	}
+	dir := strings.TrimSpace(trimmed[cut+1:])
+	if !sourceSeasonDirectoryPattern.MatchString(dir) {
+		return
+	}
+	e.Item.SavePath = trimmed[:cut]
+	e.note("保存路径末尾的 %s 已去掉，自动整理会按季数追加季度目录", dir)
+}
+
+func isMikanHost(raw string) bool {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+	return host == "mikanani.me" || host == "www.mikanani.me"
+}
+
+// ExternalSubscriptionBundle wraps the mapped items in a bundle, so the
+// reviewed preview can be sent back to the bundle import unchanged.
+func ExternalSubscriptionBundle(entries []ExternalSubscription, now time.Time) *SubscriptionBundle {
+	bundle := &SubscriptionBundle{
+		Format:        SubscriptionBundleFormat,
+		Version:       SubscriptionBundleVersion,
+		ExportedAt:    now.UTC(),
+		AppVersion:    appversion.AppVersion,
+		Subscriptions: make([]SubscriptionBundleItem, len(entries)),
+	}
+	for i := range entries {
+		bundle.Subscriptions[i] = entries[i].Item
+	}
+	return bundle
+}
+
+// ---- AutoBangumi ----
+
+// autoBangumiList accepts both the comma-joined strings stored by
+// AutoBangumi 3 and the JSON arrays of the 2.x data.json.
+type autoBangumiList []string
+
+func (l *autoBangumiList) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*l = list
+		return nil
+	}
+	var joined *string
+	if err := json.Unmarshal(data, &joined); err != nil {
+		return err
+	}
+	*l = nil
+	if joined == nil {
+		return nil
+	}
+	for _, part := range strings.Split(*joined, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			*l = append(*l, part)
+		}
+	}
+	return nil
+}
+
+// autoBangumiBool accepts JSON booleans and the 0/1 integers SQLite returns.
+type autoBangumiBool bool
+
+func (b *autoBangumiBool) UnmarshalJSON(data []byte) error {
+	switch strings.TrimSpace(string(data)) {
+	case "true", "1":
+		*b = true
+	default:
+		*b = false
+	}
+	return nil
+}
+
+type autoBangumiRecord struct {
+	OfficialTitle string          `json:"official_title"`
+	TitleRaw      string          `json:"title_raw"`
+	GroupName     string          `json:"group_name"`
+	Group         string          `json:"group"`
+	Dpi           string          `json:"dpi"`
+	Subtitle      string          `json:"subtitle"`
+	Offset        int             `json:"offset"`
+	Filter        autoBangumiList `json:"filter"`
+	RSSLink       autoBangumiList `json:"rss_link"`
+	PosterLink    string          `json:"poster_link"`
+	SavePath      string          `json:"save_path"`
+	Deleted       autoBangumiBool `json:"deleted"`
+	Archived      autoBangumiBool `json:"archived"`
+}
+
+// ParseAutoBangumiImport reads an AutoBangumi data.db, the 2.x data.json, or
+// the array returned by /api/v1/bangumi/get/all. Deleted entries are left
+// out.
+func ParseAutoBangumiImport(data []byte) ([]ExternalSubscription, error) {
+	var records []autoBangumiRecord
+	var err error
+	switch {
+	case bytes.HasPrefix(data, sqliteFileHeader):
+		records, err = readAutoBangumiDatabase(data)
+	default:
+		records, err = decodeAutoBangumiJSON(bytes.TrimSpace(data))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]ExternalSubscription, 0, len(records))
+	for _, record := range records {
+		if record.Deleted {
+			continue
+		}
+		entries = append(entries, autoBangumiEntry(record))
+	}
+	return entries, nil
+}
+
+func decodeAutoBangumiJSON(data []byte) ([]autoBangumiRecord, error) {
+	if len(data) == 0 {
+		return nil, ErrAutoBangumiFormat
+	}
+	var records []autoBangumiRecord
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &records); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrAutoBangumiFormat, err)
+		}
+		return records, nil
+	}
+	var legacy struct {
+		BangumiInfo *[]autoBangumiRecord `json:"bangumi_info"`
+	}
+	if err := json.Unmarshal(data, &legacy); err != nil || legacy.BangumiInfo == nil {
+		return nil, ErrAutoBangumiFormat
+	}
+	return *legacy.BangumiInfo, nil
+}
+
+func readAutoBangumiDatabase(data []byte) ([]autoBangumiRecord, error) {
+	file, err := os.CreateTemp("", "autobangumi-*.db")
+	if err != nil {
+		return nil, fmt.Errorf("stage AutoBangumi database: %w", err)
+	}
+	path := file.Name()
+	defer func() { _ = os.Remove(path) }()
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("stage AutoBangumi database: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("stage AutoBangumi database: %w", err)
+	}
+
+	source, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAutoBangumiFormat, err)
+	}
+	if sqlDB, err := source.DB(); err == nil {
+		defer func() { _ = sqlDB.Close() }()
+	}
+	if !source.Migrator().HasTable("bangumi") {
+		return nil, ErrAutoBangumiFormat
+	}
+	var rows []map[string]any
+	if err := source.Table("bangumi").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("read AutoBangumi database: %w", err)
+	}
+	// Round-trip through JSON so database rows and API exports share the
+	// same tolerant decoding.
+	encoded, err := json.Marshal(rows)
+	if err != nil {
+		return nil, fmt.Errorf("read AutoBangumi database: %w", err)
+	}
+	var records []autoBangumiRecord
+	if err := json.Unmarshal(encoded, &records); err != nil {
+		return nil, fmt.Errorf("read AutoBangumi database: %w", err)
+	}
+	return records, nil
+}
+
+func autoBangumiEntry(record autoBangumiRecord) ExternalSubscription {
+	title := strings.TrimSpace(record.OfficialTitle)
+	titleRaw := strings.TrimSpace(record.TitleRaw)
+	if title == "" {
+		title = titleRaw
+	}
+	group := strings.TrimSpace(record.GroupName)
+	if group == "" {
+		group = strings.TrimSpace(record.Group)
+	}
+	entry := ExternalSubscription{
+		SourceName: title,
+		keyword:    title,
+		subgroup:   group,
+		Item: SubscriptionBundleItem{
+			Title:         title,
+			SubtitleGroup: group,
+			SavePath:      strings.TrimSpace(record.SavePath),
+			Offset:        record.Offset,
+			IsActive:      !bool(record.Archived),
+			Image:         autoBangumiPoster(record.PosterLink),
+		},
+	}
+	entry.trimSeasonDirectory()
+	entry.Item.RSSUrl, entry.Item.BackupRSSUrl = pickSourceFeeds(record.RSSLink)
+	if titleRaw != "" {
+		// AutoBangumi matches releases from aggregate feeds by their raw
+		// title; keep that rule in case no per-bangumi feed is found.
+		entry.fallbackFilter = regexp.QuoteMeta(titleRaw)
+	}
+	if resolution, ok := NormalizeResolutionFilter(strings.TrimSpace(record.Dpi)); ok {
+		entry.Item.ResolutionFilter = resolution
+	} else {
+		entry.note("清晰度 %s 无法对应，未设置清晰度筛选", record.Dpi)
+	}
+	entry.Item.SubtitleLanguage = autoBangumiSubtitleLanguage(record.Subtitle)
+	if len(record.Filter) > 0 {
+		entry.Item.ExcludeRule = joinPatterns(record.Filter)
+	}
+	return entry
+}
+
+func autoBangumiPoster(raw string) string {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case strings.HasPrefix(raw, "http://"), strings.HasPrefix(raw, "https://"):
+		return raw
+	case strings.HasPrefix(raw, "/images/"):
+		return "https://mikanani.me" + raw
+	default:
+		// Relative posters live in AutoBangumi's own data directory.
+		return ""
+	}
+}
+
+func autoBangumiSubtitleLanguage(raw string) string {
+	value := strings.ToLower(raw)
+	simplified := strings.Contains(value, "简") || strings.Contains(value, "chs")
+	traditional := strings.Contains(value, "繁") || strings.Contains(value, "cht") || strings.Contains(value, "big5")
+	switch {
+	case simplified && traditional:
+		return languageCHSCHT
+	case simplified:
+		return languageCHS
+	case traditional:
+		return languageCHT
+	default:
+		return ""
+	}
+}
+
+// joinPatterns ORs a list of regular expressions, quoting any entry that is
+// not a valid expression on its own.
+func joinPatterns(patterns []string) string {
+	parts := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			pattern = regexp.QuoteMeta(pattern)
+		}
+		parts = append(parts, pattern)
+	}
+	return strings.Join(parts, "|")
+}
+
+// pickSourceFeeds prefers a Mikan per-bangumi feed as the primary feed and
+// keeps the next feed as the backup.
+func pickSourceFeeds(feeds []string) (string, string) {
+	cleaned := make([]string, 0, len(feeds))
+	for _, feed := range feeds {
+		if feed = strings.TrimSpace(feed); feed != "" {
+			cleaned = append(cleaned, feed)
+		}
+	}
+	if len(cleaned) == 0 {
+		return "", ""
+	}
+	for i, feed := range cleaned {
+		if _, ok := parser.MikanIDFromRSSURL(feed); ok && i > 0 {
+			cleaned[0], cleaned[i] = cleaned[i], cleaned[0]
+			break
+		}
+	}
+	if len(cleaned) == 1 {
+		return cleaned[0], ""
+	}
+	return cleaned[0], cleaned[1]
+}
+
+// ---- Sonarr ----
+
+type sonarrSeries struct {
+	Title      string `json:"title"`
+	Year       int    `json:"year"`
+	Monitored  *bool  `json:"monitored"`
+	SeriesType string `json:"seriesType"`
+	TvdbID     int    `json:"tvdbId"`
+	Images     []struct {
+		CoverType string `json:"coverType"`
+		RemoteURL string `json:"remoteUrl"`
+	} `json:"images"`
+}
+
+// ParseSonarrSeries reads the array returned by Sonarr's /api/v3/series.
+// Sonarr has no feed per series, so every entry is resolved to Mikan by
+// title.
+func ParseSonarrSeries(data []byte) ([]ExternalSubscription, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '[' {
+		return nil, ErrSonarrFormat
+	}
+	var series []sonarrSeries
+	if err := json.Unmarshal(data, &series); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSonarrFormat, err)
+	}
+	entries := make([]ExternalSubscription, 0, len(series))
+	for _, show := range series {
+		title := strings.TrimSpace(show.Title)
+		if title == "" {
+			continue
+		}
+		entry := ExternalSubscription{
+			SourceName: title,
+			keyword:    title,
+			Item: SubscriptionBundleItem{
+				Title:    title,
+				IsActive: show.Monitored == nil || *show.Monitored,
+			},
+		}
+		for _, image := range show.Images {
+			if image.CoverType == "poster" && strings.HasPrefix(image.RemoteURL, "https://") {
+				entry.Item.Image = image.RemoteURL
+				break
+			}
+		}
+		if show.SeriesType != "" && show.SeriesType != "anime" {
+			entry.note("Sonarr 中的类型是 %s，不是动画", show.SeriesType)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// ---- qBittorrent ----
+
+// QBittorrentRSSRuleEntries maps qBittorrent RSS auto-download rules, sorted
+// by rule name.
+func QBittorrentRSSRuleEntries(rules map[string]downloader.RSSAutoDownloadRule) []ExternalSubscription {
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	entries := make([]ExternalSubscription, 0, len(names))
+	for _, name := range names {
+		rule := rules[name]
+		title := strings.TrimSpace(name)
+		entry := ExternalSubscription{
+			SourceName: name,
+			keyword:    title,
+			Item: SubscriptionBundleItem{
+				Title:    title,
+				SavePath: rule.RuleSavePath(),
+				IsActive: rule.Enabled,
+			},
+		}
+		entry.trimSeasonDirectory()
+		entry.Item.RSSUrl, entry.Item.BackupRSSUrl = pickSourceFeeds(rule.AffectedFeeds)
+		if len(rule.AffectedFeeds) > 2 {
+			entry.note("规则关联了 %d 个 RSS，只导入前两个作为主源和备用源", len(rule.AffectedFeeds))
+		}
+		var approximate bool
+		entry.Item.FilterRule, approximate = qbittorrentRulePattern(rule.MustContain, rule.UseRegex)
+		if approximate {
+			entry.note("包含条件中的空格（与）已按出现顺序转换，请确认规则")
+		}
+		entry.Item.ExcludeRule, approximate = qbittorrentRulePattern(rule.MustNotContain, rule.UseRegex)
+		if approximate {
+			entry.note("排除条件中的空格（与）已按出现顺序转换，请确认规则")
+		}
+		if strings.TrimSpace(rule.EpisodeFilter) != "" {
+			entry.note("剧集过滤 %s 没有对应设置，未导入", strings.TrimSpace(rule.EpisodeFilter))
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// qbittorrentRulePattern converts a qBittorrent match expression into the
+// regular expression used by subscription rules. qBittorrent matches case
+// insensitively. In wildcard mode "|" separates alternatives, whitespace
+// joins terms that must all appear, and "*"/"?" are wildcards; RE2 has no
+// lookahead, so terms are joined in the order written and approximate is
+// set when that changes the meaning.
+func qbittorrentRulePattern(expression string, useRegex bool) (pattern string, approximate bool) {
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		return "", false
+	}
+	if useRegex {
+		return "(?i)" + expression, false
+	}
+	alternatives := strings.Split(expression, "|")
+	parts := make([]string, 0, len(alternatives))
+	for _, alternative := range alternatives {
+		terms := strings.Fields(alternative)
+		if len(terms) == 0 {
+			continue
+		}
+		if len(terms) > 1 {
+			approximate = true
+		}
+		for i, term := range terms {
+			quoted := regexp.QuoteMeta(term)
+			quoted = strings.ReplaceAll(quoted, `\*`, ".*")
+			quoted = strings.ReplaceAll(quoted, `\?`, ".")
+			terms[i] = quoted
+		}
+		parts = append(parts, strings.Join(terms, ".*"))
+	}
+	if len(parts) == 0 {
+		return "", false
+	}
+	return "(?i)(?:" + strings.Join(parts, "|") + ")", approximate
+}
+
+// ---- Mikan resolution ----
+
+// BangumiSubjectSearcher finds the bgm.tv subject for a title.
+type BangumiSubjectSearcher interface {
+	SearchSubjectContext(ctx context.Context, keyword string) (*bangumi.SearchResult, error)
+}
+
+// MikanSubjectResolver maps a bgm.tv subject onto Mikan bangumi entries and
+// lists their release groups.
+type MikanSubjectResolver interface {
+	ResolveBangumiSubjectContext(ctx context.Context, subjectID, keyword string) ([]parser.SearchResult, error)
+	GetSubgroupsContext(ctx context.Context, bangumiID string) ([]parser.Subgroup, error)
+}
+
+// ResolveExternalSubscriptions fills in Mikan IDs. Entries with a Mikan
+// per-bangumi feed only get the ID from the URL; the rest are searched on
+// Bangumi and verified on Mikan. An entry that still has no feed afterwards
+// is left without one and skipped by the import plan, as are entries that
+// still share their feed with an earlier one.
+func ResolveExternalSubscriptions(ctx context.Context, entries []ExternalSubscription, subjects BangumiSubjectSearcher, mikan MikanSubjectResolver) {
+	for _, indexes := range groupEntriesByFeed(entries) {
+		if len(indexes) > 1 {
+			for _, index := range indexes {
+				entries[index].sharedFeed = entries[index].Item.RSSUrl
+			}
+		}
+	}
+
+	semaphore := make(chan struct{}, subscriptionSourceResolveWorkers)
+	var wg sync.WaitGroup
+	for i := range entries {
+		entry := &entries[i]
+		if mikanID, ok := parser.MikanIDFromRSSURL(entry.Item.RSSUrl); ok {
+			entry.Item.MikanID = mikanID
+			continue
+		}
+		if !entry.needsMikanFeed() {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+				entry.applyFallback("已取消匹配")
+				return
+			}
+			lookupCtx, cancel := context.WithTimeout(ctx, subscriptionSourceResolveTimeout)
+			defer cancel()
+			resolveExternalSubscription(lookupCtx, entry, subjects, mikan)
+		}()
+	}
+	wg.Wait()
+	noteSharedFeeds(entries)
+}
+
+// groupEntriesByFeed returns the entry indexes using each primary feed, in
+// entry order.
+func groupEntriesByFeed(entries []ExternalSubscription) map[string][]int {
+	groups := make(map[string][]int, len(entries))
+	for i := range entries {
+		if feed := entries[i].Item.RSSUrl; feed != "" {
+			groups[feed] = append(groups[feed], i)
+		}
+	}
+	return groups
+}
+
+// noteSharedFeeds explains what happened to entries that came with a shared
+// feed: moved to their own Mikan feed, or still sharing one and so only
+// imported once.
+func noteSharedFeeds(entries []ExternalSubscription) {
+	groups := groupEntriesByFeed(entries)
+	for i := range entries {
+		entry := &entries[i]
+		if entry.sharedFeed != "" && entry.Item.RSSUrl != entry.sharedFeed && !isMikanHost(entry.sharedFeed) {
+			entry.note("原 RSS 与其他条目共用，已改用该番剧的 Mikan RSS")
+		}
+		indexes := groups[entry.Item.RSSUrl]
+		if len(indexes) < 2 {
+			continue
+		}
+		others := make([]string, 0, len(indexes)-1)
+		for _, index := range indexes {
+			if index != i {
+				others = append(others, fmt.Sprintf("第 %d 项（%s）", index+1, entries[index].SourceName))
+			}
+		}
+		if indexes[0] == i {
+			entry.note("与%s共用同一个 RSS 且未能匹配到各自的 Mikan 番组，只会导入这一项，其余规则需要导入后手动添加", strings.Join(others, "、"))
+		} else {
+			entry.note("与%s共用同一个 RSS 且未能匹配到各自的 Mikan 番组，本项会被跳过，请导入后手动添加", strings.Join(others, "、"))
+		}
+	}
+}
+
+func resolveExternalSubscription(ctx context.Context, entry *ExternalSubscription, subjects BangumiSubjectSearcher, mikan MikanSubjectResolver) {
+	if subjects == nil || mikan == nil {
+		entry.applyFallback("未配置 Mikan 匹配")
+		return
+	}
+	subject, err := subjects.SearchSubjectContext(ctx, entry.keyword)
+	if err != nil {
+		log.Printf("WARN: Subscription source Bangumi search failed title=%q error=%v", entry.keyword, err)
+		entry.applyFallback("Bangumi 搜索失败")
+		return
+	}
+	if subject == nil || subject.ID == 0 {
+		entry.applyFallback("Bangumi 上没有找到这部番剧")
+		return
+	}
+
+	subjectID := strconv.Itoa(subject.ID)
+	var results []parser.SearchResult
+	for _, keyword := range uniqueKeywords(subject.NameCN, entry.keyword, subject.Name) {
+		results, err = mikan.ResolveBangumiSubjectContext(ctx, subjectID, keyword)
+		if err == nil && len(results) > 0 {
+			break
+		}
+	}
+	if len(results) == 0 {
+		if err != nil {
+			log.Printf("WARN: Subscription source Mikan resolution failed subject=%s error=%v", subjectID, err)
+		}
+		entry.Item.Metadata = &SubscriptionBundleMetadata{Title: firstNonEmpty(subject.NameCN, subject.Name), BangumiID: subject.ID}
+		entry.applyFallback("Mikan 上没有找到对应番组")
+		return
+	}
+
+	match := results[0]
+	entry.Item.MikanID = match.MikanID
+	entry.Item.RSSUrl = fmt.Sprintf("https://mikanani.me/RSS/Bangumi?bangumiId=%s", match.MikanID)
+	if entry.Item.Image == "" {
+		entry.Item.Image = match.Image
+	}
+	entry.Item.Metadata = &SubscriptionBundleMetadata{Title: firstNonEmpty(subject.NameCN, subject.Name), BangumiID: subject.ID}
+	if entry.subgroup == "" {
+		return
+	}
+	groups, err := mikan.GetSubgroupsContext(ctx, match.MikanID)
+	if err != nil {
+		entry.note("读取 Mikan 字幕组失败，已订阅全部字幕组")
+		return
+	}
+	for _, group := range groups {
+		if group.ID != "" && subgroupNameMatches(group.Name, entry.subgroup) {
+			entry.Item.RSSUrl += "&subgroupid=" + url.QueryEscape(group.ID)
+			return
+		}
+	}
+	entry.note("Mikan 上没有字幕组 %s，已订阅全部字幕组", entry.subgroup)
+}
+
+// applyFallback records why resolution failed. Entries that came with an
+// aggregate feed keep it, narrowed by the source's title rule; entries
+// without any feed are left for the plan to skip.
+func (e *ExternalSubscription) applyFallback(reason string) {
+	if e.Item.RSSUrl != "" && e.fallbackFilter != "" && e.Item.FilterRule == "" {
+		e.Item.FilterRule = e.fallbackFilter
+		e.note("%s，保留原 RSS 并按原标题过滤", reason)
+		return
+	}
+	if e.Item.RSSUrl != "" {
+		e.note("%s，保留原 RSS", reason)
+		return
+	}
+	e.note("%s", reason)
+}
+
+func subgroupNameMatches(candidate, wanted string) bool {
+	candidate = strings.ToLower(strings.TrimSpace(candidate))
+	wanted = strings.ToLower(strings.TrimSpace(wanted))
+	if candidate == "" || wanted == "" {
+		return false
+	}
+	return candidate == wanted || strings.Contains(candidate, wanted) || strings.Contains(wanted, candidate)
+}
+
+func uniqueKeywords(values ...string) []string {
+	seen := make(map[string]struct{}, len(values))
+	keywords := make([]string, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		keywords = append(keywords, value)
+	}
+	return keywords
+}
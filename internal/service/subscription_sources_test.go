@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/pokerjest/animateAutoTool/internal/bangumi"
+	"github.com/pokerjest/animateAutoTool/internal/downloader"
+	"github.com/pokerjest/animateAutoTool/internal/parser"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type fakeSubjectSearcher map[string]*bangumi.SearchResult
+
+func (f fakeSubjectSearcher) SearchSubjectContext(_ context.Context, keyword string) (*bangumi.SearchResult, error) {
+	return f[keyword], nil
+}
+
+type fakeMikanResolver struct {
+	bySubject map[string][]parser.SearchResult
+	subgroups map[string][]parser.Subgroup
+}
+
+func (f fakeMikanResolver) ResolveBangumiSubjectContext(_ context.Context, subjectID, _ string) ([]parser.SearchResult, error) {
+	return f.bySubject[subjectID], nil
+}
+
+func (f fakeMikanResolver) GetSubgroupsContext(_ context.Context, bangumiID string) ([]parser.Subgroup, error) {
+	return f.subgroups[bangumiID], nil
+}
+
+func TestParseAutoBangumiImportReadsExportsAndDatabase(t *testing.T) {
+	current := `[
+		{"official_title": "葬送的芙莉莲", "title_raw": "Sousou no Frieren", "group_name": "LoliHouse", "dpi": "1080p", "subtitle": "简繁", "offset": -28,
+		 "filter": "720,\\d+-\\d+", "rss_link": "https://mikanani.me/RSS/MyBangumi?token=abc,https://mikanani.me/RSS/Bangumi?bangumiId=3141&subgroupid=370",
+		 "poster_link": "/images/Bangumi/202310/frieren.jpg", "save_path": "/downloads/Frieren/Season 1", "deleted": false},
+		{"official_title": "Removed", "rss_link": "https://example.test/removed", "deleted": true}
+	]`
+	entries, err := ParseAutoBangumiImport([]byte(current))
+	if err != nil {
+		t.Fatalf("ParseAutoBangumiImport returned error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("deleted entries must be skipped, got %+v", entries)
+	}
+	item := entries[0].Item
+	if item.RSSUrl != "https://mikanani.me/RSS/Bangumi?bangumiId=3141&subgroupid=370" || item.BackupRSSUrl != "https://mikanani.me/RSS/MyBangumi?token=abc" {
+		t.Fatalf("the per-bangumi feed should be primary: %+v", item)
+	}
+	if item.ExcludeRule != `720|\d+-\d+` || item.ResolutionFilter != "1080p" || item.SubtitleLanguage != "chs_cht" || item.Offset != -28 {
+		t.Fatalf("unexpected rules: %+v", item)
+	}
+	if item.Image != "https://mikanani.me/images/Bangumi/202310/frieren.jpg" || item.SavePath != "/downloads/Frieren" || !item.IsActive {
+		t.Fatalf("unexpected settings: %+v", item)
+	}
+	if len(entries[0].Notes) != 1 || !strings.Contains(entries[0].Notes[0], "Season 1") {
+		t.Fatalf("dropping the season directory should be noted, got %v", entries[0].Notes)
+	}
+
+	legacy := `{"data_version": 4.0, "bangumi_info": [{"official_title": "Legacy", "group": "ANi", "dpi": "4K", "filter": ["720"], "rss_link": ["https://example.test/legacy"]}]}`
+	entries, err = ParseAutoBangumiImport([]byte(legacy))
+	if err != nil || len(entries) != 1 || entries[0].Item.SubtitleGroup != "ANi" || entries[0].Item.ResolutionFilter != "2160p" || entries[0].Item.ExcludeRule != "720" {
+		t.Fatalf("legacy data.json = %+v, %v", entries, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "data.db")
+	source, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open AutoBangumi fixture: %v", err)
+	}
+	for _, statement := range []string{
+		`CREATE TABLE bangumi (id INTEGER PRIMARY KEY, official_title TEXT, title_raw TEXT, group_name TEXT, dpi TEXT, subtitle TEXT, "offset" INTEGER, filter TEXT, rss_link TEXT, poster_link TEXT, save_path TEXT, deleted BOOLEAN, archived BOOLEAN)`,
+		`INSERT INTO bangumi VALUES (1, '药屋少女的呢喃', 'Kusuriya no Hitorigoto', '桜都字幕组', '1080P', '简日', 0, '720', 'https://mikanani.me/RSS/MyBangumi?token=abc', NULL, '/downloads/Kusuriya', 0, 1)`,
+		`INSERT INTO bangumi VALUES (2, 'Gone', 'Gone', '', '', '', 0, '', 'https://example.test/gone', NULL, '', 1, 0)`,
+	} {
+		if err := source.Exec(statement).Error; err != nil {
+			t.Fatalf("prepare AutoBangumi fixture: %v", err)
+		}
+	}
+	if sqlDB, err := source.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read AutoBangumi fixture: %v", err)
+	}
+	entries, err = ParseAutoBangumiImport(data)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("database import = %+v, %v", entries, err)
+	}
+	item = entries[0].Item
+	if item.Title != "药屋少女的呢喃" || item.SubtitleLanguage != "chs" || item.IsActive || item.RSSUrl != "https://mikanani.me/RSS/MyBangumi?token=abc" {
+		t.Fatalf("unexpected database entry: %+v", item)
+	}
+	if !entries[0].needsMikanFeed() {
+		t.Fatal("an aggregate MyBangumi feed should be resolved to a per-bangumi feed")
+	}
+
+	if _, err := ParseAutoBangumiImport([]byte(`{"hello": "world"}`)); !errors.Is(err, ErrAutoBangumiFormat) {
+		t.Fatalf("expected format error, got %v", err)
+	}
+}
+
+func TestQBittorrentRSSRuleEntriesConvertMatchExpressions(t *testing.T) {
+	rule := downloader.RSSAutoDownloadRule{
+		Enabled:        true,
+		MustContain:    "Frieren 1080p|Sousou*",
+		MustNotContain: "720p",
+		EpisodeFilter:  "1x01-;",
+		AffectedFeeds:  []string{"https://nyaa.example.test/rss"},
+	}
+	rule.TorrentParams.SavePath = "/downloads/frieren"
+	regexRule := downloader.RSSAutoDownloadRule{MustContain: `\[ANi\].*Kusuriya`, UseRegex: true}
+	entries := QBittorrentRSSRuleEntries(map[string]downloader.RSSAutoDownloadRule{"Frieren": rule, "Apothecary": regexRule})
+	if len(entries) != 2 || entries[0].SourceName != "Apothecary" {
+		t.Fatalf("entries should be sorted by rule name: %+v", entries)
+	}
+	frieren := entries[1]
+	if frieren.Item.FilterRule != `(?i)(?:Frieren.*1080p|Sousou.*)` || frieren.Item.ExcludeRule != `(?i)(?:720p)` {
+		t.Fatalf("unexpected wildcard conversion: %+v", frieren.Item)
+	}
+	if frieren.Item.SavePath != "/downloads/frieren" || !frieren.Item.IsActive || frieren.Item.RSSUrl != "https://nyaa.example.test/rss" {
+		t.Fatalf("unexpected rule settings: %+v", frieren.Item)
+	}
+	if len(frieren.Notes) != 2 || !strings.Contains(frieren.Notes[1], "1x01-;") {
+		t.Fatalf("expected notes for the AND approximation and episode filter, got %v", frieren.Notes)
+	}
+	if frieren.needsMikanFeed() {
+		t.Fatal("a non-Mikan feed must be kept as is")
+	}
+	if entries[0].Item.FilterRule != `(?i)\[ANi\].*Kusuriya` || entries[0].Item.IsActive {
+		t.Fatalf("unexpected regex rule: %+v", entries[0].Item)
+	}
+	for _, entry := range entries {
+		if err := ValidateSubscriptionPattern(entry.Item.FilterRule); err != nil {
+			t.Fatalf("converted pattern %q does not compile: %v", entry.Item.FilterRule, err)
+		}
+	}
+}
+
+func TestResolveExternalSubscriptionsPointsEntriesAtMikanFeeds(t *testing.T) {
+	sonarr := `[
+		{"title": "Frieren: Beyond Journey's End", "monitored": true, "seriesType": "anime"},
+		{"title": "Unknown Show", "monitored": false, "seriesType": "standard"}
+	]`
+	entries, err := ParseSonarrSeries([]byte(sonarr))
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("ParseSonarrSeries = %+v, %v", entries, err)
+	}
+	autoBangumi, err := ParseAutoBangumiImport([]byte(`[{"official_title": "药屋少女的呢喃", "title_raw": "Kusuriya no Hitorigoto", "group_name": "桜都字幕组", "rss_link": "https://mikanani.me/RSS/MyBangumi?token=abc"},
+		{"official_title": "Nowhere", "title_raw": "Nowhere [Raw]", "rss_link": "https://mikanani.me/RSS/MyBangumi?token=abc"}]`))
+	if err != nil {
+		t.Fatalf("ParseAutoBangumiImport returned error: %v", err)
+	}
+	entries = append(entries, autoBangumi...)
+
+	subjects := fakeSubjectSearcher{
+		"Frieren: Beyond Journey's End": {ID: 400602, Name: "葬送のフリーレン", NameCN: "葬送的芙莉莲"},
+		"药屋少女的呢喃":                       {ID: 428735, Name: "薬屋のひとりごと", NameCN: "药屋少女的呢喃"},
+	}
+	mikan := fakeMikanResolver{
+		bySubject: map[string][]parser.SearchResult{
+			"400602": {{MikanID: "3141", Title: "葬送的芙莉莲", Image: "https://mikanani.me/images/frieren.jpg"}},
+			"428735": {{MikanID: "3160", Title: "药屋少女的呢喃"}},
+		},
+		subgroups: map[string][]parser.Subgroup{
+			"3160": {{ID: "", Name: "全部 (All)"}, {ID: "382", Name: "桜都字幕组"}},
+		},
+	}
+	ResolveExternalSubscriptions(context.Background(), entries, subjects, mikan)
+
+	frieren := entries[0].Item
+	if frieren.MikanID != "3141" || frieren.RSSUrl != "https://mikanani.me/RSS/Bangumi?bangumiId=3141" || frieren.Metadata == nil || frieren.Metadata.BangumiID != 400602 {
+		t.Fatalf("unexpected Sonarr resolution: %+v", frieren)
+	}
+	if unknown := entries[1]; unknown.Item.RSSUrl != "" || len(unknown.Notes) != 2 {
+		t.Fatalf("an unresolved Sonarr entry should stay without a feed and explain why: %+v", unknown)
+	}
+	if kusuriya := entries[2].Item; kusuriya.RSSUrl != "https://mikanani.me/RSS/Bangumi?bangumiId=3160&subgroupid=382" || kusuriya.FilterRule != "" {
+		t.Fatalf("unexpected AutoBangumi resolution: %+v", kusuriya)
+	}
+	nowhere := entries[3]
+	if nowhere.Item.RSSUrl != "https://mikanani.me/RSS/MyBangumi?token=abc" || nowhere.Item.FilterRule != `Nowhere \[Raw\]` {
+		t.Fatalf("an unresolved aggregate feed should fall back to the raw title filter: %+v", nowhere.Item)
+	}
+}
+
+func TestResolveExternalSubscriptionsSplitsSharedFeeds(t *testing.T) {
+	nyaa := []string{"https://nyaa.example.test/?page=rss&u=ANiTorrent"}
+	entries := QBittorrentRSSRuleEntries(map[string]downloader.RSSAutoDownloadRule{
+		"葬送的芙莉莲":    {Enabled: true, MustContain: "Frieren", AffectedFeeds: nyaa},
+		"Mystery A": {Enabled: true, MustContain: "Mystery A", AffectedFeeds: nyaa},
+		"Mystery B": {Enabled: true, MustContain: "Mystery B", AffectedFeeds: nyaa},
+	})
+	subjects := fakeSubjectSearcher{"葬送的芙莉莲": {ID: 400602, NameCN: "葬送的芙莉莲"}}
+	mikan := fakeMikanResolver{bySubject: map[string][]parser.SearchResult{"400602": {{MikanID: "3141"}}}}
+	ResolveExternalSubscriptions(context.Background(), entries, subjects, mikan)
+
+	// Sorted by rule name: Mystery A, Mystery B, 葬送的芙莉莲.
+	frieren := entries[2]
+	if frieren.Item.RSSUrl != "https://mikanani.me/RSS/Bangumi?bangumiId=3141" || frieren.Item.FilterRule != "(?i)(?:Frieren)" {
+		t.Fatalf("a rule on a shared feed should be moved to its own Mikan feed: %+v", frieren.Item)
+	}
+	if len(frieren.Notes) != 1 || !strings.Contains(frieren.Notes[0], "共用") {
+		t.Fatalf("the switch should be noted, got %v", frieren.Notes)
+	}
+	first, second := entries[0], entries[1]
+	if first.Item.RSSUrl != nyaa[0] || second.Item.RSSUrl != nyaa[0] {
+		t.Fatalf("unresolved rules keep the shared feed: %+v / %+v", first.Item, second.Item)
+	}
+	if note := first.Notes[len(first.Notes)-1]; !strings.Contains(note, "第 2 项（Mystery B）") || !strings.Contains(note, "只会导入这一项") {
+		t.Fatalf("unexpected note for the imported rule: %v", first.Notes)
+	}
+	if note := second.Notes[len(second.Notes)-1]; !strings.Contains(note, "第 1 项（Mystery A）") || !strings.Contains(note, "跳过") {
+		t.Fatalf("unexpected note for the skipped rule: %v", second.Notes)
+	}
+}
@@ -0,0 +1,386 @@
+package service
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	appversion "github.com/pokerjest/animateAutoTool/internal/version"
+	"gorm.io/gorm"
+)
+
+// Subscription bundles move a subscription set between instances. The JSON
+// bundle keeps every rule and optionally which episodes were already
+// downloaded; OPML only carries titles, seasons and feed URLs so other feed
+// readers can open it.
+const (
+	SubscriptionBundleFormat  = "animateautotool.subscriptions"
+	SubscriptionBundleVersion = 1
+
+	subscriptionResourceSourceImport = "import"
+	subscriptionImportedStateReason  = "从其他实例导入的完成记录"
+)
+
+var (
+	ErrSubscriptionBundleFormat  = errors.New("not a subscription bundle")
+	ErrSubscriptionBundleVersion = errors.New("unsupported subscription bundle version")
+	ErrSubscriptionOPML          = errors.New("invalid OPML document")
+)
+
+type SubscriptionBundle struct {
+	Format        string                   `json:"format"`
+	Version       int                      `json:"version"`
+	ExportedAt    time.Time                `json:"exported_at"`
+	AppVersion    string                   `json:"app_version,omitempty"`
+	Subscriptions []SubscriptionBundleItem `json:"subscriptions"`
+}
+
+type SubscriptionBundleItem struct {
+	Title                string                       `json:"title"`
+	RSSUrl               string                       `json:"rss_url"`
+	MikanID              string                       `json:"mikan_id,omitempty"`
+	Image                string                       `json:"image,omitempty"`
+	SubtitleGroup        string                       `json:"subtitle_group,omitempty"`
+	Season               string                       `json:"season,omitempty"`
+	FilterRule           string                       `json:"filter_rule,omitempty"`
+	ExcludeRule          string                       `json:"exclude_rule,omitempty"`
+	ResolutionFilter     string                       `json:"resolution_filter,omitempty"`
+	SubtitleLanguage     string                       `json:"subtitle_language,omitempty"`
+	BackupRSSUrl         string                       `json:"backup_rss_url,omitempty"`
+	Parser               string                       `json:"parser,omitempty"`
+	ExpectedEpisodes     int                          `json:"expected_episodes,omitempty"`
+	AutoDisableOnDone    bool                         `json:"auto_disable_on_done,omitempty"`
+	AllowMultiSubgroup   bool                         `json:"allow_multi_subgroup,omitempty"`
+	StaleAfterHours      int                          `json:"stale_after_hours,omitempty"`
+	CheckIntervalMinutes int                          `json:"check_interval_minutes,omitempty"`
+	SavePath             string                       `json:"save_path,omitempty"`
+	RenameEnabled        bool                         `json:"rename_enabled,omitempty"`
+	Offset               int                          `json:"offset,omitempty"`
+	IsActive             bool                         `json:"is_active"`
+	Metadata             *SubscriptionBundleMetadata  `json:"metadata,omitempty"`
+	Resources            []SubscriptionBundleResource `json:"resources,omitempty"`
+}
+
+// SubscriptionBundleMetadata links a subscription to its metadata by
+// provider ID. The importing instance fetches the details itself.
+type SubscriptionBundleMetadata struct {
+	Title     string `json:"title,omitempty"`
+	BangumiID int    `json:"bangumi_id,omitempty"`
+	TMDBID    int    `json:"tmdb_id,omitempty"`
+	AniListID int    `json:"anilist_id,omitempty"`
+}
+
+func (m *SubscriptionBundleMetadata) hasIDs() bool {
+	return m != nil && (m.BangumiID != 0 || m.TMDBID != 0 || m.AniListID != 0)
+}
+
+// SubscriptionBundleResource is one completed episode. Importing it makes
+// the next RSS check treat the release as already downloaded. Local file
+// paths are not exported because they rarely match on another machine.
+type SubscriptionBundleResource struct {
+	Fingerprint  string     `json:"fingerprint"`
+	CanonicalKey string     `json:"canonical_key,omitempty"`
+	Title        string     `json:"title,omitempty"`
+	Episode      string     `json:"episode,omitempty"`
+	SeasonVal    string     `json:"season_val,omitempty"`
+	Subgroup     string     `json:"subgroup,omitempty"`
+	VersionTag   string     `json:"version_tag,omitempty"`
+	InfoHash     string     `json:"info_hash,omitempty"`
+	TorrentURL   string     `json:"torrent_url,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
+
+// ExportSubscriptionBundle collects every subscription, active or paused.
+// With includeResources the completed episodes of each subscription are
+// added as well.
+func ExportSubscriptionBundle(includeResources bool, now time.Time) (*SubscriptionBundle, error) {
+	if db.DB == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	subs, err := store.NewSubscriptionStore(db.DB).ListWithMetadata()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(subs, func(i, j int) bool { return subs[i].ID < subs[j].ID })
+
+	bundle := &SubscriptionBundle{
+		Format:        SubscriptionBundleFormat,
+		Version:       SubscriptionBundleVersion,
+		ExportedAt:    now.UTC(),
+		AppVersion:    appversion.AppVersion,
+		Subscriptions: make([]SubscriptionBundleItem, 0, len(subs)),
+	}
+	resources := store.NewSubscriptionResourceStore(db.DB)
+	for _, sub := range subs {
+		item := subscriptionBundleItem(sub)
+		if includeResources {
+			rows, err := resources.ListBySubscription(sub.ID)
+			if err != nil {
+				return nil, fmt.Errorf("list resources for subscription %d: %w", sub.ID, err)
+			}
+			for _, row := range rows {
+				if row.State != SubscriptionResourceStateCompleted || strings.TrimSpace(row.Fingerprint) == "" {
+					continue
+				}
+				item.Resources = append(item.Resources, SubscriptionBundleResource{
+					Fingerprint:  row.Fingerprint,
+					CanonicalKey: row.CanonicalKey,
+					Title:        row.Title,
+					Episode:      row.Episode,
+					SeasonVal:    row.SeasonVal,
+					Subgroup:     row.Subgroup,
+					VersionTag:   row.VersionTag,
+					InfoHash:     row.InfoHash,
+					TorrentURL:   row.TorrentURL,
+					CompletedAt:  row.CompletedAt,
+				})
+			}
+		}
+		bundle.Subscriptions = append(bundle.Subscriptions, item)
+	}
+	return bundle, nil
+}
+
+func subscriptionBundleItem(sub model.Subscription) SubscriptionBundleItem {
+	item := SubscriptionBundleItem{
+		Title:                sub.Title,
+		RSSUrl:               sub.RSSUrl,
+		MikanID:              sub.MikanID,
+		Image:                sub.Image,
+		SubtitleGroup:        sub.SubtitleGroup,
+		Season:               sub.Season,
+		FilterRule:           sub.FilterRule,
+		ExcludeRule:          sub.ExcludeRule,
+		ResolutionFilter:     sub.ResolutionFilter,
+		SubtitleLanguage:     sub.SubtitleLanguage,
+		BackupRSSUrl:         sub.BackupRSSUrl,
+		Parser:               sub.Parser,
+		ExpectedEpisodes:     sub.ExpectedEpisodes,
+		AutoDisableOnDone:    sub.AutoDisableOnDone,
+		AllowMultiSubgroup:   sub.AllowMultiSubgroup,
+		StaleAfterHours:      sub.StaleAfterHours,
+		CheckIntervalMinutes: sub.CheckIntervalMinutes,
+		SavePath:             sub.SavePath,
+		RenameEnabled:        sub.RenameEnabled,
+		Offset:               sub.Offset,
+		IsActive:             sub.IsActive,
+	}
+	if sub.Metadata != nil {
+		metadata := &SubscriptionBundleMetadata{
+			Title:     sub.Metadata.Title,
+			BangumiID: sub.Metadata.BangumiID,
+			TMDBID:    sub.Metadata.TMDBID,
+			AniListID: sub.Metadata.AniListID,
+		}
+		if metadata.hasIDs() {
+			item.Metadata = metadata
+		}
+	}
+	return item
+}
+
+// Subscription converts the item back into an unsaved subscription. Linked
+// metadata is resolved by the caller.
+func (item SubscriptionBundleItem) Subscription() model.Subscription {
+	return model.Subscription{
+		Title:                strings.TrimSpace(item.Title),
+		RSSUrl:               strings.TrimSpace(item.RSSUrl),
+		MikanID:              item.MikanID,
+		Image:                item.Image,
+		SubtitleGroup:        item.SubtitleGroup,
+		Season:               item.Season,
+		FilterRule:           item.FilterRule,
+		ExcludeRule:          item.ExcludeRule,
+		ResolutionFilter:     item.ResolutionFilter,
+		SubtitleLanguage:     item.SubtitleLanguage,
+		BackupRSSUrl:         item.BackupRSSUrl,
+		Parser:               item.Parser,
+		ExpectedEpisodes:     item.ExpectedEpisodes,
+		AutoDisableOnDone:    item.AutoDisableOnDone,
+		AllowMultiSubgroup:   item.AllowMultiSubgroup,
+		StaleAfterHours:      item.StaleAfterHours,
+		CheckIntervalMinutes: item.CheckIntervalMinutes,
+		SavePath:             strings.TrimSpace(item.SavePath),
+		RenameEnabled:        item.RenameEnabled,
+		Offset:               item.Offset,
+		IsActive:             item.IsActive,
+	}
+}
+
+// ParseSubscriptionBundle decodes a JSON bundle and rejects other JSON
+// documents and bundles written by a newer version.
+func ParseSubscriptionBundle(data []byte) (*SubscriptionBundle, error) {
+	var bundle SubscriptionBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSubscriptionBundleFormat, err)
+	}
+	if bundle.Format != SubscriptionBundleFormat {
+		return nil, ErrSubscriptionBundleFormat
+	}
+	if bundle.Version < 1 || bundle.Version > SubscriptionBundleVersion {
+		return nil, fmt.Errorf("%w: %d", ErrSubscriptionBundleVersion, bundle.Version)
+	}
+	return &bundle, nil
+}
+
+type opmlDocument struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    opmlHead `xml:"head"`
+	Body    opmlBody `xml:"body"`
+}
+
+type opmlHead struct {
+	Title       string `xml:"title"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type opmlBody struct {
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	Category string        `xml:"category,attr,omitempty"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+// EncodeSubscriptionOPML writes the bundle as OPML 2.0 with one folder per
+// season. Rules and download state are not part of OPML.
+func EncodeSubscriptionOPML(bundle *SubscriptionBundle) ([]byte, error) {
+	doc := opmlDocument{Version: "2.0", Head: opmlHead{Title: "AnimateAutoTool 订阅"}}
+	if bundle == nil {
+		bundle = &SubscriptionBundle{}
+	}
+	if !bundle.ExportedAt.IsZero() {
+		doc.Head.DateCreated = bundle.ExportedAt.UTC().Format(time.RFC1123Z)
+	}
+	folders := make(map[string]int)
+	for _, item := range bundle.Subscriptions {
+		feed := opmlOutline{Text: item.Title, Title: item.Title, Type: "rss", XMLURL: item.RSSUrl}
+		season := strings.TrimSpace(item.Season)
+		if season == "" {
+			doc.Body.Outlines = append(doc.Body.Outlines, feed)
+			continue
+		}
+		feed.Category = season
+		index, ok := folders[season]
+		if !ok {
+			index = len(doc.Body.Outlines)
+			folders[season] = index
+			doc.Body.Outlines = append(doc.Body.Outlines, opmlOutline{Text: season, Title: season})
+		}
+		doc.Body.Outlines[index].Outlines = append(doc.Body.Outlines[index].Outlines, feed)
+	}
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// ParseSubscriptionOPML reads every feed outline, at any depth. A feed
+// without a category takes the enclosing folder's name as its season.
+func ParseSubscriptionOPML(data []byte) ([]SubscriptionBundleItem, error) {
+	var doc opmlDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSubscriptionOPML, err)
+	}
+	var items []SubscriptionBundleItem
+	var walk func(outlines []opmlOutline, folder string)
+	walk = func(outlines []opmlOutline, folder string) {
+		for _, outline := range outlines {
+			feedURL := strings.TrimSpace(outline.XMLURL)
+			if feedURL == "" {
+				walk(outline.Outlines, strings.TrimSpace(outline.Text))
+				continue
+			}
+			title := strings.TrimSpace(outline.Title)
+			if title == "" {
+				title = strings.TrimSpace(outline.Text)
+			}
+			season := strings.TrimSpace(outline.Category)
+			if season == "" {
+				season = folder
+			}
+			items = append(items, SubscriptionBundleItem{Title: title, RSSUrl: feedURL, Season: season, IsActive: true})
+		}
+	}
+	walk(doc.Body.Outlines, "")
+	return items, nil
+}
+
+// ImportSubscriptionResources records resources as completed episodes of
+// the subscription so its next check does not download them again. Rows
+// that already exist for the same fingerprint keep their stronger state.
+// It returns how many resources were written.
+func ImportSubscriptionResources(subscriptionID uint, resources []SubscriptionBundleResource) (int, error) {
+	if db.DB == nil {
+		return 0, gorm.ErrInvalidDB
+	}
+	if subscriptionID == 0 || len(resources) == 0 {
+		return 0, nil
+	}
+	imported := 0
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		rs := store.NewSubscriptionResourceStore(tx)
+		for _, item := range resources {
+			fingerprint := strings.TrimSpace(item.Fingerprint)
+			if fingerprint == "" {
+				continue
+			}
+			existing, err := rs.FindByFingerprint(subscriptionID, fingerprint)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if existing != nil && resourceStateRank(existing.State) >= resourceStateRank(SubscriptionResourceStateCompleted) {
+				continue
+			}
+			completedAt := item.CompletedAt
+			if completedAt == nil {
+				now := time.Now().UTC()
+				completedAt = &now
+			}
+			resource := &model.SubscriptionResource{
+				SubscriptionID: subscriptionID,
+				CanonicalKey:   strings.TrimSpace(item.CanonicalKey),
+				Fingerprint:    fingerprint,
+				Title:          strings.TrimSpace(item.Title),
+				Episode:        strings.TrimSpace(item.Episode),
+				SeasonVal:      strings.TrimSpace(item.SeasonVal),
+				Subgroup:       strings.TrimSpace(item.Subgroup),
+				VersionTag:     strings.TrimSpace(item.VersionTag),
+				InfoHash:       strings.TrimSpace(item.InfoHash),
+				TorrentURL:     strings.TrimSpace(item.TorrentURL),
+				Source:         subscriptionResourceSourceImport,
+				State:          SubscriptionResourceStateCompleted,
+				StateReason:    subscriptionImportedStateReason,
+				Selected:       true,
+				CompletedAt:    completedAt,
+			}
+			if existing != nil {
+				resource.ID = existing.ID
+				resource.CreatedAt = existing.CreatedAt
+			}
+			if err := rs.Upsert(resource); err != nil {
+				return err
+			}
+			imported++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return imported, nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/parser"
+)
+
+func TestSubscriptionBundleCarriesCompletedEpisodesToAnotherInstance(t *testing.T) {
+	withServiceTestDB(t)
+	feed := fakeRSSParser{episodes: []parser.Episode{
+		{Title: "[Group] Transfer Show - 01", EpisodeNum: "01", TorrentURL: "magnet:?xt=urn:btih:transfer-1"},
+		{Title: "[Group] Transfer Show - 02", EpisodeNum: "02", TorrentURL: "magnet:?xt=urn:btih:transfer-2"},
+	}}
+	metadata := model.AnimeMetadata{Title: "Transfer Show", BangumiID: 4242}
+	if err := db.DB.Create(&metadata).Error; err != nil {
+		t.Fatalf("create metadata: %v", err)
+	}
+	source := model.Subscription{
+		Title:       "Transfer Show",
+		RSSUrl:      "https://example.test/transfer",
+		FilterRule:  "Group",
+		ExcludeRule: "720p",
+		SavePath:    "/downloads/transfer",
+		Offset:      12,
+		IsActive:    true,
+		MetadataID:  &metadata.ID,
+	}
+	if err := db.DB.Create(&source).Error; err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+	down := &fakeDownloader{}
+	(&SubscriptionManager{RSSParser: feed, Downloader: down, DB: db.DB}).ProcessSubscription(&source)
+	if len(down.added) != 2 {
+		t.Fatalf("expected both episodes to download on the source instance, got %v", down.added)
+	}
+	if err := db.DB.Model(&model.SubscriptionResource{}).
+		Where("subscription_id = ? AND episode = ?", source.ID, "01").
+		Update("state", SubscriptionResourceStateCompleted).Error; err != nil {
+		t.Fatalf("mark completed: %v", err)
+	}
+
+	bundle, err := ExportSubscriptionBundle(true, time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("ExportSubscriptionBundle returned error: %v", err)
+	}
+	if len(bundle.Subscriptions) != 1 {
+		t.Fatalf("expected one exported subscription, got %d", len(bundle.Subscriptions))
+	}
+	item := bundle.Subscriptions[0]
+	if item.FilterRule != "Group" || item.ExcludeRule != "720p" || item.SavePath != "/downloads/transfer" || item.Offset != 12 {
+		t.Fatalf("rules not exported: %+v", item)
+	}
+	if item.Metadata == nil || item.Metadata.BangumiID != 4242 {
+		t.Fatalf("metadata link not exported: %+v", item.Metadata)
+	}
+	if len(item.Resources) != 1 || item.Resources[0].Episode != "01" {
+		t.Fatalf("expected only the completed episode, got %+v", item.Resources)
+	}
+
+	// 另一台实例：新数据库导入后，只有未完成的第 2 集会被下载。
+	_ = db.CloseDB()
+	db.InitDB(":memory:")
+	imported := item.Subscription()
+	if err := db.DB.Create(&imported).Error; err != nil {
+		t.Fatalf("create imported subscription: %v", err)
+	}
+	count, err := ImportSubscriptionResources(imported.ID, item.Resources)
+	if err != nil || count != 1 {
+		t.Fatalf("ImportSubscriptionResources = %d, %v", count, err)
+	}
+	if count, err := ImportSubscriptionResources(imported.ID, item.Resources); err != nil || count != 0 {
+		t.Fatalf("second import = %d, %v; completed rows must be left alone", count, err)
+	}
+	down = &fakeDownloader{}
+	(&SubscriptionManager{RSSParser: feed, Downloader: down, DB: db.DB}).ProcessSubscription(&imported)
+	if len(down.added) != 1 || !strings.Contains(down.added[0], "transfer-2") {
+		t.Fatalf("expected only episode 2 to download after import, got %v", down.added)
+	}
+}
+
+func TestSubscriptionBundleParsingAndOPML(t *testing.T) {
+	if _, err := ParseSubscriptionBundle([]byte(`{"format":"other","version":1}`)); !errors.Is(err, ErrSubscriptionBundleFormat) {
+		t.Fatalf("expected format error, got %v", err)
+	}
+	if _, err := ParseSubscriptionBundle([]byte(`{"format":"animateautotool.subscriptions","version":9}`)); !errors.Is(err, ErrSubscriptionBundleVersion) {
+		t.Fatalf("expected version error, got %v", err)
+	}
+
+	bundle := &SubscriptionBundle{
+		ExportedAt: time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC),
+		Subscriptions: []SubscriptionBundleItem{
+			{Title: "Autumn A", RSSUrl: "https://example.test/a?x=1&y=2", Season: "2026 秋季番组", FilterRule: "1080p"},
+			{Title: "Loose", RSSUrl: "https://example.test/loose"},
+			{Title: "Autumn B", RSSUrl: "https://example.test/b", Season: "2026 秋季番组"},
+		},
+	}
+	data, err := EncodeSubscriptionOPML(bundle)
+	if err != nil {
+		t.Fatalf("EncodeSubscriptionOPML returned error: %v", err)
+	}
+	if strings.Contains(string(data), "1080p") {
+		t.Fatalf("OPML must not carry rules:\n%s", data)
+	}
+	items, err := ParseSubscriptionOPML(data)
+	if err != nil || len(items) != 3 {
+		t.Fatalf("ParseSubscriptionOPML = %+v, %v", items, err)
+	}
+	if items[0].RSSUrl != "https://example.test/a?x=1&y=2" || items[0].Season != "2026 秋季番组" || items[1].Title != "Autumn B" || items[2].Season != "" {
+		t.Fatalf("unexpected OPML round trip: %+v", items)
+	}
+
+	foreign := `<?xml version="1.0"?><opml version="1.0"><body><outline text="Anime"><outline text="Show C" xmlUrl="https://example.test/c"/></outline></body></opml>`
+	items, err = ParseSubscriptionOPML([]byte(foreign))
+	if err != nil || len(items) != 1 || items[0].Title != "Show C" || items[0].Season != "Anime" || !items[0].IsActive {
+		t.Fatalf("foreign OPML = %+v, %v", items, err)
+	}
+	if _, err := ParseSubscriptionOPML([]byte("not xml")); !errors.Is(err, ErrSubscriptionOPML) {
+		t.Fatalf("expected OPML error, got %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/security"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	TwoFactorIssuer = "animateAutoTool"
+
+	SecondFactorTOTP         = "totp"
+	SecondFactorRecoveryCode = "recovery_code"
+
+	recoveryCodeCount    = 10
+	recoveryCodeLength   = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("两步验证已经启用")
+	ErrTwoFactorNotEnabled     = errors.New("两步验证尚未启用")
+	ErrTwoFactorNotPending     = errors.New("请先生成新的两步验证密钥")
+	ErrTwoFactorCode           = errors.New("验证码或恢复码不正确")
+	ErrTwoFactorPassword       = errors.New("当前密码不正确")
+)
+
+// TwoFactorStatus summarizes a user's second factor for the settings page.
+type TwoFactorStatus struct {
+	Enabled                bool
+	Pending                bool
+	RecoveryCodesRemaining int64
+}
+
+func recoveryCodeStore() *store.RecoveryCodeStore {
+	if db.DB == nil {
+		return nil
+	}
+	return store.NewRecoveryCodeStore(db.DB)
+}
+
+// TwoFactorEnabled reports whether login for user needs a second step.
+func TwoFactorEnabled(user *model.User) bool {
+	return user != nil && user.TOTPEnabledAt != nil && user.TOTPSecret != ""
+}
+
+// HashRecoveryCode normalizes a recovery code (case, spaces and the display
+// hyphen are ignored) and returns its SHA-256 hex digest.
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *AuthService) TwoFactorStatus(user *model.User) (TwoFactorStatus, error) {
+	status := TwoFactorStatus{Enabled: TwoFactorEnabled(user), Pending: user != nil && user.TOTPPendingSecret != ""}
+	if !status.Enabled {
+		return status, nil
+	}
+	remaining, err := recoveryCodeStore().CountUnused(user.ID)
+	if err != nil {
+		return status, err
+	}
+	status.RecoveryCodesRemaining = remaining
+	return status, nil
+}
+
+// BeginTOTPEnrollment stores a new pending secret and returns it together
+// with the otpauth URI. The secret only takes effect once ConfirmTOTP sees a
+// valid code from it, so an abandoned enrollment never locks anyone out.
+func (s *AuthService) BeginTOTPEnrollment(user *model.User) (string, string, error) {
+	if user == nil {
+		return "", "", ErrUserNotFound
+	}
+	if TwoFactorEnabled(user) {
+		return "", "", ErrTwoFactorAlreadyEnabled
+	}
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	user.TOTPPendingSecret = secret
+	if err := userStore().Save(user); err != nil {
+		return "", "", err
+	}
+	return secret, security.TOTPURI(TwoFactorIssuer, user.Username, secret), nil
+}
+
+// ConfirmTOTP enables two-factor login once code matches the pending secret
+// and returns a fresh set of one-time recovery codes.
+func (s *AuthService) ConfirmTOTP(user *model.User, code string) ([]string, error) {
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if TwoFactorEnabled(user) {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPPendingSecret == "" {
+		return nil, ErrTwoFactorNotPending
+	}
+	step, ok := security.VerifyTOTP(user.TOTPPendingSecret, code, timeNow())
+	if !ok {
+		return nil, ErrTwoFactorCode
+	}
+	now := timeNow()
+	user.TOTPSecret = user.TOTPPendingSecret
+	user.TOTPPendingSecret = ""
+	user.TOTPEnabledAt = &now
+	user.TOTPLastStep = step
+	if err := userStore().Save(user); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(user)
+}
+
+// VerifySecondFactor accepts either a current TOTP code or an unused
+// recovery code and returns which one matched.
+func (s *AuthService) VerifySecondFactor(user *model.User, code string) (string, error) {
+	if !TwoFactorEnabled(user) {
+		return "", ErrTwoFactorNotEnabled
+	}
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return "", ErrTwoFactorCode
+	}
+	if step, ok := security.VerifyTOTP(user.TOTPSecret, code, timeNow()); ok {
+		advanced, err := userStore().AdvanceTOTPStep(user.ID, step)
+		if err != nil {
+			return "", err
+		}
+		if !advanced {
+			return "", ErrTwoFactorCode
+		}
+		user.TOTPLastStep = step
+		return SecondFactorTOTP, nil
+	}
+	consumed, err := recoveryCodeStore().Consume(user.ID, HashRecoveryCode(code), timeNow())
+	if err != nil {
+		return "", err
+	}
+	if !consumed {
+		return "", ErrTwoFactorCode
+	}
+	return SecondFactorRecoveryCode, nil
+}
+
+// RegenerateRecoveryCodes replaces every recovery code after re-checking the
+// account password.
+func (s *AuthService) RegenerateRecoveryCodes(user *model.User, password string) ([]string, error) {
+	if !TwoFactorEnabled(user) {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, ErrTwoFactorPassword
+	}
+	return s.replaceRecoveryCodes(user)
+}
+
+// DisableTOTP turns two-factor login off after re-checking the password.
+func (s *AuthService) DisableTOTP(user *model.User, password string) error {
+	if !TwoFactorEnabled(user) {
+		return ErrTwoFactorNotEnabled
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return ErrTwoFactorPassword
+	}
+	return s.clearTwoFactor(user)
+}
+
+func (s *AuthService) clearTwoFactor(user *model.User) error {
+	user.TOTPSecret = ""
+	user.TOTPPendingSecret = ""
+	user.TOTPEnabledAt = nil
+	user.TOTPLastStep = 0
+	if err := userStore().Save(user); err != nil {
+		return err
+	}
+	return recoveryCodeStore().Replace(user.ID, nil)
+}
+
+func (s *AuthService) replaceRecoveryCodes(user *model.User) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		code, err := randomRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+	if err := recoveryCodeStore().Replace(user.ID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// randomRecoveryCode returns a code such as "k7m2q-x9tfa"; the alphabet drops
+// look-alike characters so codes survive being copied from paper.
+func randomRecoveryCode() (string, error) {
+	out := make([]byte, 0, recoveryCodeLength+1)
+	alphabetLen := byte(len(recoveryCodeAlphabet))
+	maxValid := byte((256 / int(alphabetLen)) * int(alphabetLen))
+	for len(out) < recoveryCodeLength+1 {
+		if len(out) == recoveryCodeLength/2 {
+			out = append(out, '-')
+			continue
+		}
+		var single [1]byte
+		if _, err := rand.Read(single[:]); err != nil {
+			return "", err
+		}
+		if single[0] >= maxValid {
+			continue
+		}
+		out = append(out, recoveryCodeAlphabet[single[0]%alphabetLen])
+	}
+	return string(out), nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/security"
+)
+
+func enableTestTOTP(t *testing.T, auth *AuthService, user *model.User) (string, []string) {
+	t.Helper()
+	secret, uri, err := auth.BeginTOTPEnrollment(user)
+	if err != nil {
+		t.Fatalf("BeginTOTPEnrollment: %v", err)
+	}
+	if uri == "" || TwoFactorEnabled(user) {
+		t.Fatalf("pending enrollment must not enable two-factor login: %q", uri)
+	}
+	if _, err := auth.ConfirmTOTP(user, "000000x"); !errors.Is(err, ErrTwoFactorCode) {
+		t.Fatalf("expected invalid code, got %v", err)
+	}
+	code, _ := security.TOTPCode(secret, security.TOTPStep(time.Now()))
+	codes, err := auth.ConfirmTOTP(user, code)
+	if err != nil {
+		t.Fatalf("ConfirmTOTP: %v", err)
+	}
+	return secret, codes
+}
+
+func TestTwoFactorRejectsReplayedCodesAndReusedRecoveryCodes(t *testing.T) {
+	withServiceTestDB(t)
+	auth := NewAuthService()
+	user, err := auth.CreateUser("owner", "owner-pass")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	secret, codes := enableTestTOTP(t, auth, user)
+	if !TwoFactorEnabled(user) || len(codes) != recoveryCodeCount {
+		t.Fatalf("expected two-factor login with %d codes, got %v", recoveryCodeCount, codes)
+	}
+	var stored int64
+	db.DB.Model(&model.UserRecoveryCode{}).Where("code_hash = ?", codes[0]).Count(&stored)
+	if stored != 0 {
+		t.Fatal("plaintext recovery codes must not be stored")
+	}
+
+	// The confirmation code has been used; the next step's code is still in
+	// the skew window and works exactly once.
+	next, _ := security.TOTPCode(secret, user.TOTPLastStep+1)
+	if method, err := auth.VerifySecondFactor(user, next); err != nil || method != SecondFactorTOTP {
+		t.Fatalf("VerifySecondFactor(totp) = %q, %v", method, err)
+	}
+	if _, err := auth.VerifySecondFactor(user, next); !errors.Is(err, ErrTwoFactorCode) {
+		t.Fatalf("expected replayed code to be rejected, got %v", err)
+	}
+
+	if method, err := auth.VerifySecondFactor(user, " "+codes[3]+" "); err != nil || method != SecondFactorRecoveryCode {
+		t.Fatalf("VerifySecondFactor(recovery) = %q, %v", method, err)
+	}
+	if _, err := auth.VerifySecondFactor(user, codes[3]); !errors.Is(err, ErrTwoFactorCode) {
+		t.Fatalf("expected used recovery code to be rejected, got %v", err)
+	}
+	status, err := auth.TwoFactorStatus(user)
+	if err != nil || !status.Enabled || status.RecoveryCodesRemaining != recoveryCodeCount-1 {
+		t.Fatalf("TwoFactorStatus = %+v, %v", status, err)
+	}
+
+	if _, err := auth.RegenerateRecoveryCodes(user, "wrong"); !errors.Is(err, ErrTwoFactorPassword) {
+		t.Fatalf("expected password check, got %v", err)
+	}
+	fresh, err := auth.RegenerateRecoveryCodes(user, "owner-pass")
+	if err != nil || len(fresh) != recoveryCodeCount {
+		t.Fatalf("RegenerateRecoveryCodes = %v, %v", fresh, err)
+	}
+	if _, err := auth.VerifySecondFactor(user, codes[0]); !errors.Is(err, ErrTwoFactorCode) {
+		t.Fatalf("expected old recovery codes to be invalidated, got %v", err)
+	}
+
+	if err := auth.DisableTOTP(user, "wrong"); !errors.Is(err, ErrTwoFactorPassword) {
+		t.Fatalf("expected password check, got %v", err)
+	}
+	if err := auth.DisableTOTP(user, "owner-pass"); err != nil {
+		t.Fatalf("DisableTOTP: %v", err)
+	}
+	reloaded, _ := userStore().GetByID(user.ID)
+	if TwoFactorEnabled(reloaded) || reloaded.TOTPSecret != "" {
+		t.Fatalf("expected two-factor login to be off, got %+v", reloaded)
+	}
+}
+
+func TestResetPasswordByUsernameClearsTwoFactor(t *testing.T) {
+	withServiceTestDB(t)
+	auth := NewAuthService()
+	user, err := auth.CreateUser("owner", "owner-pass")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	enableTestTOTP(t, auth, user)
+
+	cleared, err := auth.ResetPasswordByUsername("owner", "new-owner-pass")
+	if err != nil || !cleared {
+		t.Fatalf("ResetPasswordByUsername = %v, %v", cleared, err)
+	}
+	reloaded, _ := userStore().GetByID(user.ID)
+	if TwoFactorEnabled(reloaded) {
+		t.Fatal("expected local recovery to turn two-factor login off")
+	}
+	var remaining int64
+	db.DB.Model(&model.UserRecoveryCode{}).Where("user_id = ?", user.ID).Count(&remaining)
+	if remaining != 0 {
+		t.Fatalf("expected recovery codes to be removed, got %d", remaining)
+	}
+	if cleared, err := auth.ResetPasswordByUsername("owner", "another-pass"); err != nil || cleared {
+		t.Fatalf("second reset = %v, %v", cleared, err)
+	}
+}
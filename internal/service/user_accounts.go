@@ -0,0 +1,210 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/store"
+	"gorm.io/gorm"
+)
+
+const minUserPasswordLength = 8
+
+var (
+	ErrUserNotFound     = errors.New("用户不存在")
+	ErrInvalidUserRole  = errors.New("角色必须是 admin、member 或 viewer")
+	ErrUsernameTaken    = errors.New("用户名已被使用")
+	ErrWeakUserPassword = errors.New("密码至少需要 8 个字符")
+	ErrLastAdmin        = errors.New("至少需要保留一个管理员账户")
+	ErrDeleteSelf       = errors.New("不能删除当前登录的账户")
+)
+
+var userRoleRank = map[string]int{
+	model.UserRoleViewer: 1,
+	model.UserRoleMember: 2,
+	model.UserRoleAdmin:  3,
+}
+
+// NormalizeUserRole validates a role name from user input.
+func NormalizeUserRole(raw string) (string, error) {
+	role := strings.ToLower(strings.TrimSpace(raw))
+	if _, ok := userRoleRank[role]; !ok {
+		return "", ErrInvalidUserRole
+	}
+	return role, nil
+}
+
+// EffectiveUserRole returns the role enforced for user. Rows that predate
+// roles have an empty value and keep full access.
+func EffectiveUserRole(user *model.User) string {
+	if user == nil {
+		return ""
+	}
+	if _, ok := userRoleRank[user.Role]; ok {
+		return user.Role
+	}
+	if strings.TrimSpace(user.Role) == "" {
+		return model.UserRoleAdmin
+	}
+	return ""
+}
+
+// UserRoleAllows reports whether user holds at least the required role.
+// Unknown roles are denied everything.
+func UserRoleAllows(user *model.User, required string) bool {
+	have := userRoleRank[EffectiveUserRole(user)]
+	return have > 0 && have >= userRoleRank[required]
+}
+
+// UserInput carries the fields an admin may set on an account. Nil fields are
+// left unchanged on update.
+type UserInput struct {
+	Username *string
+	Password *string
+	Role     *string
+}
+
+// ListUsers returns every account.
+func (s *AuthService) ListUsers() ([]model.User, error) {
+	st := userStore()
+	if st == nil {
+		return nil, errors.New("invalid database")
+	}
+	return st.List()
+}
+
+// CreateManagedUser creates an account with an explicit role.
+func (s *AuthService) CreateManagedUser(input UserInput) (*model.User, error) {
+	if input.Username == nil || input.Password == nil || input.Role == nil {
+		return nil, errors.New("请填写用户名、密码和角色")
+	}
+	username := strings.TrimSpace(*input.Username)
+	if err := validateUsername(username); err != nil {
+		return nil, err
+	}
+	role, err := NormalizeUserRole(*input.Role)
+	if err != nil {
+		return nil, err
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(*input.Password)) < minUserPasswordLength {
+		return nil, ErrWeakUserPassword
+	}
+	st := userStore()
+	if st == nil {
+		return nil, errors.New("invalid database")
+	}
+	if _, err := st.GetByUsername(username); err == nil {
+		return nil, ErrUsernameTaken
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	user, err := s.CreateUser(username, strings.TrimSpace(*input.Password))
+	if err != nil {
+		return nil, usernameConflict(err)
+	}
+	if role != user.Role {
+		user.Role = role
+		if err := st.Save(user); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// UpdateManagedUser changes the role, username or password of an account.
+// The last admin can never be demoted, so the instance stays administrable.
+func (s *AuthService) UpdateManagedUser(id uint, input UserInput) (*model.User, error) {
+	st := userStore()
+	if st == nil {
+		return nil, errors.New("invalid database")
+	}
+	user, err := st.GetByID(id)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if input.Role != nil {
+		role, err := NormalizeUserRole(*input.Role)
+		if err != nil {
+			return nil, err
+		}
+		if role != model.UserRoleAdmin && EffectiveUserRole(user) == model.UserRoleAdmin {
+			if err := ensureAnotherAdmin(st); err != nil {
+				return nil, err
+			}
+		}
+		user.Role = role
+	}
+	if input.Username != nil {
+		username := strings.TrimSpace(*input.Username)
+		if err := validateUsername(username); err != nil {
+			return nil, err
+		}
+		if existing, err := st.GetByUsername(username); err == nil && existing.ID != user.ID {
+			return nil, ErrUsernameTaken
+		}
+		user.Username = username
+	}
+	if input.Password != nil {
+		password := strings.TrimSpace(*input.Password)
+		if utf8.RuneCountInString(password) < minUserPasswordLength {
+			return nil, ErrWeakUserPassword
+		}
+		// updatePassword saves every pending field together with the hash.
+		if err := s.updatePassword(user, password); err != nil {
+			return nil, usernameConflict(err)
+		}
+		return user, nil
+	}
+	if err := st.Save(user); err != nil {
+		return nil, usernameConflict(err)
+	}
+	return user, nil
+}
+
+// DeleteManagedUser removes an account other than the caller's own. The last
+// admin cannot be deleted.
+func (s *AuthService) DeleteManagedUser(id, actorID uint) (*model.User, error) {
+	if id == actorID {
+		return nil, ErrDeleteSelf
+	}
+	st := userStore()
+	if st == nil {
+		return nil, errors.New("invalid database")
+	}
+	user, err := st.GetByID(id)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if EffectiveUserRole(user) == model.UserRoleAdmin {
+		if err := ensureAnotherAdmin(st); err != nil {
+			return nil, err
+		}
+	}
+	if err := st.Delete(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func ensureAnotherAdmin(st *store.UserStore) error {
+	admins, err := st.CountByRole(model.UserRoleAdmin)
+	if err != nil {
+		return err
+	}
+	if admins <= 1 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+func usernameConflict(err error) error {
+	lower := strings.ToLower(err.Error())
+	if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(lower, "unique constraint") || strings.Contains(lower, "duplicate") {
+		return ErrUsernameTaken
+	}
+	return err
+}
@@ -11,7 +11,8 @@ import (
 
 	"github.com/pokerjest/animateAutoTool/internal/config"
 	"github.com/pokerjest/animateAutoTool/internal/db"
-	"github.com/pokerjest/animateAutoTool/internal/downloader"
+	"github.com/pokerjest/animateAutoTool/internal/eventlog"
+	"github.com/pokerjest/animateAutoTool/internal/notify"
 	"github.com/pokerjest/animateAutoTool/internal/qbutil"
 	"github.com/pokerjest/animateAutoTool/internal/runtimejournal"
 	"github.com/pokerjest/animateAutoTool/internal/service"
@@ -44,6 +45,15 @@ func Run(parent context.Context) *Lifecycle {
 	ctx, cancel := context.WithCancel(parent)
 	lifecycle := &Lifecycle{ctx: ctx, cancel: cancel}
 
+	eventlog.Start()
+	log.Printf("Startup: event journal started")
+
+	if interrupted, err := taskstate.Global.CloseInterrupted(); err != nil {
+		log.Printf("WARN: Startup: failed to close interrupted task history: %v", err)
+	} else if interrupted > 0 {
+		log.Printf("Startup: marked %d unfinished task(s) from the previous run as interrupted", interrupted)
+	}
+
 	sessionResult, err := runtimejournal.BeginSession(appversion.AppVersion)
 	recovery := completedRecovery(true)
 	if err != nil {
@@ -91,6 +101,8 @@ func Run(parent context.Context) *Lifecycle {
 			service.NewScannerService().CleanupGarbage()
 			worker.StartMetadataWorker()
 			log.Printf("Startup: metadata event worker started")
+			notify.Start()
+			log.Printf("Startup: notification dispatcher started")
 
 			var backgroundWorkers sync.WaitGroup
 			backgroundWorkers.Add(3)
@@ -339,7 +351,7 @@ func recoverInterruptedSubscriptionSync(ctx context.Context) error {
 		if err := ctx.Err(); err != nil {
 			return err
 		}
-		client := downloader.NewQBittorrentClient(qbCfg.URL)
+		client := qbutil.NewClient(qbCfg)
 		loginCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
 		lastErr = client.LoginContext(loginCtx, qbCfg.Username, qbCfg.Password)
 		cancel()
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"gorm.io/gorm"
+)
+
+type APITokenStore struct {
+	db *gorm.DB
+}
+
+func NewAPITokenStore(db *gorm.DB) *APITokenStore {
+	return &APITokenStore{db: db}
+}
+
+func (s *APITokenStore) Create(token *model.APIToken) error {
+	if s == nil || s.db == nil {
+		return gorm.ErrInvalidDB
+	}
+	return s.db.Create(token).Error
+}
+
+// GetByHash looks a token up by the SHA-256 hash of its secret. Revoked and
+// expired tokens are returned too; the caller decides what to reject.
+func (s *APITokenStore) GetByHash(hash string) (*model.APIToken, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	var token model.APIToken
+	if err := s.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (s *APITokenStore) GetByID(id uint) (*model.APIToken, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	var token model.APIToken
+	if err := s.db.First(&token, id).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ListByUser returns the user's tokens, newest first. Revoked tokens stay in
+// the list so their last use remains visible.
+func (s *APITokenStore) ListByUser(userID uint) ([]model.APIToken, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	var tokens []model.APIToken
+	err := s.db.Where("user_id = ?", userID).Order("id DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// Revoke marks a token unusable. Revoking twice keeps the first timestamp.
+func (s *APITokenStore) Revoke(id uint, at time.Time) error {
+	if s == nil || s.db == nil {
+		return gorm.ErrInvalidDB
+	}
+	return s.db.Model(&model.APIToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at).Error
+}
+
+// Touch records the time and client address of the token's latest use
+// without bumping UpdatedAt.
+func (s *APITokenStore) Touch(id uint, at time.Time, ip string) error {
+	if s == nil || s.db == nil {
+		return gorm.ErrInvalidDB
+	}
+	return retrySQLiteBusy(func() error {
+		return s.db.Model(&model.APIToken{}).Where("id = ?", id).
+			UpdateColumns(map[string]any{"last_used_at": at, "last_used_ip": ip}).Error
+	})
+}
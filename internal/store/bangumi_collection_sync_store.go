@@ -0,0 +1,99 @@
+package store
+
+import (
+	"errors"
+
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"gorm.io/gorm"
+)
+
+type BangumiCollectionSyncStore struct {
+	db *gorm.DB
+}
+
+func NewBangumiCollectionSyncStore(db *gorm.DB) *BangumiCollectionSyncStore {
+	return &BangumiCollectionSyncStore{db: db}
+}
+
+// GetBySubject returns the record for a bgm.tv subject; found is false when
+// the sync has never seen it.
+func (s *BangumiCollectionSyncStore) GetBySubject(subjectID int) (*model.BangumiCollectionSync, bool, error) {
+	if s == nil || s.db == nil {
+		return nil, false, gorm.ErrInvalidDB
+	}
+	var record model.BangumiCollectionSync
+	err := s.db.Where("subject_id = ?", subjectID).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &record, true, nil
+}
+
+// FindByMikanID returns the oldest record for a Mikan bangumi with one of
+// statuses (any status when empty).
+func (s *BangumiCollectionSyncStore) FindByMikanID(mikanID string, statuses ...string) (*model.BangumiCollectionSync, bool, error) {
+	if s == nil || s.db == nil {
+		return nil, false, gorm.ErrInvalidDB
+	}
+	if mikanID == "" {
+		return nil, false, nil
+	}
+	query := s.db.Where("mikan_id = ?", mikanID).Order("id ASC")
+	if len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
+	}
+	var record model.BangumiCollectionSync
+	err := query.First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &record, true, nil
+}
+
+func (s *BangumiCollectionSyncStore) GetByID(id uint) (*model.BangumiCollectionSync, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	var record model.BangumiCollectionSync
+	if err := s.db.First(&record, id).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Save inserts a new record or updates an existing one.
+func (s *BangumiCollectionSyncStore) Save(record *model.BangumiCollectionSync) error {
+	if s == nil || s.db == nil {
+		return gorm.ErrInvalidDB
+	}
+	if record == nil {
+		return nil
+	}
+	return retrySQLiteBusy(func() error {
+		return s.db.Save(record).Error
+	})
+}
+
+// List returns records with one of statuses (all when empty), most recently
+// updated first.
+func (s *BangumiCollectionSyncStore) List(statuses []string, limit int) ([]model.BangumiCollectionSync, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	if limit <= 0 {
+		limit = 200
+	}
+	query := s.db.Order("updated_at DESC").Order("id DESC").Limit(limit)
+	if len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
+	}
+	var records []model.BangumiCollectionSync
+	err := query.Find(&records).Error
+	return records, err
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"gorm.io/gorm"
+)
+
+func TestBangumiCollectionSyncStoreSavesAndListsByStatus(t *testing.T) {
+	if _, _, err := NewBangumiCollectionSyncStore(nil).GetBySubject(1); err != gorm.ErrInvalidDB {
+		t.Fatalf("expected ErrInvalidDB, got %v", err)
+	}
+
+	db.InitDB(":memory:")
+	t.Cleanup(func() {
+		_ = db.CloseDB()
+		db.DB = nil
+	})
+	st := NewBangumiCollectionSyncStore(db.DB)
+	if _, found, err := st.GetBySubject(42); err != nil || found {
+		t.Fatalf("GetBySubject on empty table = %v, %v", found, err)
+	}
+	for _, record := range []*model.BangumiCollectionSync{
+		{SubjectID: 42, Title: "Proposed", Status: "proposed"},
+		{SubjectID: 43, Title: "Deleted", Status: "deleted"},
+	} {
+		if err := st.Save(record); err != nil {
+			t.Fatalf("Save returned error: %v", err)
+		}
+	}
+	record, found, err := st.GetBySubject(42)
+	if err != nil || !found || record.Title != "Proposed" {
+		t.Fatalf("GetBySubject = %+v, %v, %v", record, found, err)
+	}
+	record.Status = "dismissed"
+	if err := st.Save(record); err != nil {
+		t.Fatalf("Save of existing record returned error: %v", err)
+	}
+	got, err := st.List([]string{"dismissed"}, 0)
+	if err != nil || len(got) != 1 || got[0].SubjectID != 42 {
+		t.Fatalf("List(dismissed) = %+v, %v", got, err)
+	}
+	if got, err := st.List(nil, 10); err != nil || len(got) != 2 {
+		t.Fatalf("List(all) = %+v, %v", got, err)
+	}
+}
+
+func TestSubscriptionStoreFindsByBangumiSubjectOrMikanID(t *testing.T) {
+	db.InitDB(":memory:")
+	t.Cleanup(func() {
+		_ = db.CloseDB()
+		db.DB = nil
+	})
+	metadata := model.AnimeMetadata{Title: "Linked", BangumiID: 400602}
+	if err := db.DB.Create(&metadata).Error; err != nil {
+		t.Fatalf("create metadata: %v", err)
+	}
+	linked := model.Subscription{Title: "Linked", RSSUrl: "https://example.test/linked", MetadataID: &metadata.ID}
+	mikan := model.Subscription{Title: "Mikan", RSSUrl: "https://example.test/mikan", MikanID: "3160"}
+	for _, sub := range []*model.Subscription{&linked, &mikan} {
+		if err := db.DB.Create(sub).Error; err != nil {
+			t.Fatalf("create subscription: %v", err)
+		}
+	}
+
+	st := NewSubscriptionStore(db.DB)
+	if sub, found, err := st.FindByBangumiSubjectOrMikanID(400602, ""); err != nil || !found || sub.ID != linked.ID {
+		t.Fatalf("find by subject = %+v, %v, %v", sub, found, err)
+	}
+	if sub, found, err := st.FindByBangumiSubjectOrMikanID(1, "3160"); err != nil || !found || sub.ID != mikan.ID {
+		t.Fatalf("find by Mikan ID = %+v, %v, %v", sub, found, err)
+	}
+	if _, found, err := st.FindByBangumiSubjectOrMikanID(1, "9999"); err != nil || found {
+		t.Fatalf("unexpected match: %v, %v", found, err)
+	}
+}
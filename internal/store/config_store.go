@@ -2,6 +2,7 @@ package store
 
 import (
 	"errors"
+	"log"
 	"strings"
 
 	"github.com/pokerjest/animateAutoTool/internal/config"
@@ -29,7 +30,14 @@ func (s *ConfigStore) ListMap() (map[string]string, error) {
 
 	result := make(map[string]string, len(configs))
 	for _, cfg := range configs {
-		result[cfg.Key] = cfg.Value
+		value, err := config.DecryptSetting(cfg.Key, cfg.Value)
+		if err != nil {
+			// Treat the secret as unset so the settings page asks for it again
+			// instead of handing ciphertext to a downloader or API client.
+			log.Printf("WARN: ConfigStore: skipped unreadable secret key=%s error=%v", cfg.Key, err)
+			continue
+		}
+		result[cfg.Key] = value
 	}
 	return result, nil
 }
@@ -47,7 +55,7 @@ func (s *ConfigStore) Get(key string) (string, error) {
 	if result.RowsAffected == 0 {
 		return "", gorm.ErrRecordNotFound
 	}
-	return cfg.Value, nil
+	return config.DecryptSetting(cfg.Key, cfg.Value)
 }
 
 func (s *ConfigStore) GetDefault(key, fallback string) string {
@@ -92,8 +100,12 @@ func (s *ConfigStore) SetMany(values map[string]string) error {
 }
 
 func setConfigValue(database *gorm.DB, key, value string) error {
+	stored, err := config.EncryptSetting(key, value)
+	if err != nil {
+		return err
+	}
 	var conf model.GlobalConfig
 	return database.Where(model.GlobalConfig{Key: key}).
-		Assign(model.GlobalConfig{Value: value}).
+		Assign(model.GlobalConfig{Value: stored}).
 		FirstOrCreate(&conf).Error
 }
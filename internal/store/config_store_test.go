@@ -1,9 +1,12 @@
 package store
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/pokerjest/animateAutoTool/internal/config"
 	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
 	"gorm.io/gorm"
 )
 
@@ -40,3 +43,51 @@ func TestConfigStoreGetDefaultHandlesMissingValue(t *testing.T) {
 		t.Fatalf("expected ErrInvalidDB, got %v", err)
 	}
 }
+
+func TestConfigStoreEncryptsSecretsAtRest(t *testing.T) {
+	db.InitDB(":memory:")
+	t.Cleanup(func() {
+		_ = db.CloseDB()
+		db.DB = nil
+	})
+
+	store := NewConfigStore(db.DB)
+	if err := store.SetMany(map[string]string{
+		model.ConfigKeyQBPassword: "hunter2",
+		model.ConfigKeyQBUrl:      "http://qb:8080",
+	}); err != nil {
+		t.Fatalf("SetMany returned error: %v", err)
+	}
+	var row model.GlobalConfig
+	if err := db.DB.First(&row, "key = ?", model.ConfigKeyQBPassword).Error; err != nil {
+		t.Fatalf("read raw row: %v", err)
+	}
+	if !config.IsEncryptedSetting(row.Value) {
+		t.Fatalf("expected encrypted password row, got %q", row.Value)
+	}
+	if got, err := store.Get(model.ConfigKeyQBPassword); err != nil || got != "hunter2" {
+		t.Fatalf("Get should decrypt transparently, got %q %v", got, err)
+	}
+
+	// A ciphertext from another installation is treated as unset.
+	foreign := "enc:v1:00000000:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	if err := db.DB.Create(&model.GlobalConfig{Key: model.ConfigKeyTMDBToken, Value: foreign}).Error; err != nil {
+		t.Fatalf("seed foreign secret: %v", err)
+	}
+	values, err := store.ListMap()
+	if err != nil {
+		t.Fatalf("ListMap returned error: %v", err)
+	}
+	if values[model.ConfigKeyQBPassword] != "hunter2" || values[model.ConfigKeyQBUrl] != "http://qb:8080" {
+		t.Fatalf("unexpected values: %#v", values)
+	}
+	if _, ok := values[model.ConfigKeyTMDBToken]; ok {
+		t.Fatalf("unreadable secret must be skipped: %#v", values)
+	}
+	if _, err := store.Get(model.ConfigKeyTMDBToken); !errors.Is(err, config.ErrSettingUndecryptable) {
+		t.Fatalf("expected undecryptable error, got %v", err)
+	}
+	if got := store.GetDefault(model.ConfigKeyTMDBToken, "fallback"); got != "fallback" {
+		t.Fatalf("expected fallback for unreadable secret, got %q", got)
+	}
+}
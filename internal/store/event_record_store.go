@@ -0,0 +1,86 @@
+package store
+
+import (
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EventRecordStore struct {
+	db *gorm.DB
+}
+
+func NewEventRecordStore(db *gorm.DB) *EventRecordStore {
+	return &EventRecordStore{db: db}
+}
+
+// Insert writes a batch of journal entries. IDs are assigned by the caller,
+// so a batch retried after a partial failure skips rows it already wrote.
+func (s *EventRecordStore) Insert(records []model.EventRecord) error {
+	if s == nil || s.db == nil {
+		return gorm.ErrInvalidDB
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	return retrySQLiteBusy(func() error {
+		return s.db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(records, 100).Error
+	})
+}
+
+// ListAfter returns up to limit records with an ID greater than after in ID
+// order. An empty types slice matches every event type.
+func (s *EventRecordStore) ListAfter(after uint64, types []string, limit int) ([]model.EventRecord, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	query := s.db.Where("id > ?", after)
+	if len(types) > 0 {
+		query = query.Where("type IN ?", types)
+	}
+	var records []model.EventRecord
+	err := query.Order("id ASC").Limit(limit).Find(&records).Error
+	return records, err
+}
+
+// Bounds returns the oldest and newest stored IDs, or zeros when the journal
+// is empty.
+func (s *EventRecordStore) Bounds() (oldest, latest uint64, err error) {
+	if s == nil || s.db == nil {
+		return 0, 0, gorm.ErrInvalidDB
+	}
+	var row struct {
+		Oldest *uint64
+		Latest *uint64
+	}
+	if err := s.db.Model(&model.EventRecord{}).Select("MIN(id) AS oldest, MAX(id) AS latest").Scan(&row).Error; err != nil {
+		return 0, 0, err
+	}
+	if row.Oldest != nil {
+		oldest = *row.Oldest
+	}
+	if row.Latest != nil {
+		latest = *row.Latest
+	}
+	return oldest, latest, nil
+}
+
+// Prune deletes everything but the newest keep records.
+func (s *EventRecordStore) Prune(keep int) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, gorm.ErrInvalidDB
+	}
+	newest := s.db.Model(&model.EventRecord{}).Select("id").Order("id DESC").Limit(keep)
+	var result *gorm.DB
+	err := retrySQLiteBusy(func() error {
+		result = s.db.Where("id NOT IN (?)", newest).Delete(&model.EventRecord{})
+		return result.Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected, nil
+}
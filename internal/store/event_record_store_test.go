@@ -0,0 +1,57 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"gorm.io/gorm"
+)
+
+func TestEventRecordStoreListsAfterCursorAndPrunesOldest(t *testing.T) {
+	if err := NewEventRecordStore(nil).Insert([]model.EventRecord{{ID: 1}}); err != gorm.ErrInvalidDB {
+		t.Fatalf("expected ErrInvalidDB, got %v", err)
+	}
+
+	db.InitDB(":memory:")
+	t.Cleanup(func() {
+		_ = db.CloseDB()
+		db.DB = nil
+	})
+	st := NewEventRecordStore(db.DB)
+	if oldest, latest, err := st.Bounds(); err != nil || oldest != 0 || latest != 0 {
+		t.Fatalf("Bounds on empty journal = %d, %d, %v", oldest, latest, err)
+	}
+
+	base := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	records := []model.EventRecord{
+		{ID: 10, Type: "task_update", Payload: `{"n":1}`, CreatedAt: base},
+		{ID: 12, Type: "scan_complete", Payload: `{"n":2}`, CreatedAt: base},
+		{ID: 15, Type: "task_update", Payload: `{"n":3}`, CreatedAt: base},
+		{ID: 20, Type: "download_ready", Payload: `{"n":4}`, CreatedAt: base},
+	}
+	if err := st.Insert(records); err != nil {
+		t.Fatalf("Insert returned error: %v", err)
+	}
+	if err := st.Insert(records[2:]); err != nil {
+		t.Fatalf("Insert of existing IDs returned error: %v", err)
+	}
+
+	got, err := st.ListAfter(10, nil, 2)
+	if err != nil || len(got) != 2 || got[0].ID != 12 || got[1].ID != 15 {
+		t.Fatalf("ListAfter(10) = %+v, %v", got, err)
+	}
+	got, err = st.ListAfter(0, []string{"task_update"}, 10)
+	if err != nil || len(got) != 2 || got[0].ID != 10 || got[1].ID != 15 {
+		t.Fatalf("ListAfter filtered = %+v, %v", got, err)
+	}
+
+	removed, err := st.Prune(2)
+	if err != nil || removed != 2 {
+		t.Fatalf("Prune = %d, %v", removed, err)
+	}
+	if oldest, latest, err := st.Bounds(); err != nil || oldest != 15 || latest != 20 {
+		t.Fatalf("Bounds after prune = %d, %d, %v", oldest, latest, err)
+	}
+}
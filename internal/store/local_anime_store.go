@@ -229,6 +229,17 @@ func (s *LocalAnimeStore) ListEpisodesByAnimeID(animeID uint) ([]model.LocalEpis
 	return eps, nil
 }
 
+func (s *LocalAnimeStore) GetEpisode(id any) (*model.LocalEpisode, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	var ep model.LocalEpisode
+	if err := s.db.First(&ep, id).Error; err != nil {
+		return nil, err
+	}
+	return &ep, nil
+}
+
 func (s *LocalAnimeStore) FindAnimeByPath(path string) (*model.LocalAnime, error) {
 	if s == nil || s.db == nil {
 		return nil, gorm.ErrInvalidDB
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LocalMediaFavoriteStore struct {
+	db *gorm.DB
+}
+
+func NewLocalMediaFavoriteStore(db *gorm.DB) *LocalMediaFavoriteStore {
+	return &LocalMediaFavoriteStore{db: db}
+}
+
+// Set adds or removes itemID from the user's favorites. Both directions are idempotent.
+func (s *LocalMediaFavoriteStore) Set(userID uint, itemID string, favorite bool) error {
+	if s == nil || s.db == nil {
+		return gorm.ErrInvalidDB
+	}
+	return retrySQLiteBusy(func() error {
+		if !favorite {
+			return s.db.Where("user_id = ? AND item_id = ?", userID, itemID).Delete(&model.LocalMediaFavorite{}).Error
+		}
+		return s.db.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&model.LocalMediaFavorite{UserID: userID, ItemID: itemID}).Error
+	})
+}
+
+// ListItemIDs returns the user's favorite item IDs, most recently added first.
+func (s *LocalMediaFavoriteStore) ListItemIDs(userID uint, limit int) ([]string, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	var ids []string
+	if err := s.db.Model(&model.LocalMediaFavorite{}).Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").Limit(limit).Pluck("item_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Lookup reports which of itemIDs the user has marked as favorite.
+func (s *LocalMediaFavoriteStore) Lookup(userID uint, itemIDs []string) (map[string]bool, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	result := make(map[string]bool, len(itemIDs))
+	if len(itemIDs) == 0 {
+		return result, nil
+	}
+	var ids []string
+	if err := s.db.Model(&model.LocalMediaFavorite{}).Where("user_id = ? AND item_id IN ?", userID, itemIDs).
+		Pluck("item_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		result[id] = true
+	}
+	return result, nil
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"gorm.io/gorm"
+)
+
+type LocalSubtitleStore struct {
+	db *gorm.DB
+}
+
+func NewLocalSubtitleStore(db *gorm.DB) *LocalSubtitleStore {
+	return &LocalSubtitleStore{db: db}
+}
+
+// ListByEpisode returns the indexed sidecar subtitles for an episode ordered by path.
+func (s *LocalSubtitleStore) ListByEpisode(episodeID uint) ([]model.LocalSubtitle, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	var rows []model.LocalSubtitle
+	if err := s.db.Where("local_episode_id = ?", episodeID).Order("path ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ReplaceForEpisode swaps the episode's subtitle index for rows. It is a
+// no-op when the stored set already matches, so rescans stay write-free.
+func (s *LocalSubtitleStore) ReplaceForEpisode(episodeID uint, rows []model.LocalSubtitle) error {
+	if s == nil || s.db == nil {
+		return gorm.ErrInvalidDB
+	}
+	existing, err := s.ListByEpisode(episodeID)
+	if err != nil {
+		return err
+	}
+	if sameLocalSubtitles(existing, rows) {
+		return nil
+	}
+	return retrySQLiteBusy(func() error {
+		return s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("local_episode_id = ?", episodeID).Delete(&model.LocalSubtitle{}).Error; err != nil {
+				return err
+			}
+			if len(rows) == 0 {
+				return nil
+			}
+			for i := range rows {
+				rows[i].ID = 0
+				rows[i].LocalEpisodeID = episodeID
+			}
+			return tx.Create(&rows).Error
+		})
+	})
+}
+
+func sameLocalSubtitles(existing, next []model.LocalSubtitle) bool {
+	if len(existing) != len(next) {
+		return false
+	}
+	index := make(map[string]model.LocalSubtitle, len(existing))
+	for _, row := range existing {
+		index[row.Path] = row
+	}
+	for _, row := range next {
+		current, ok := index[row.Path]
+		if !ok || current.Format != row.Format || current.Language != row.Language || current.Label != row.Label ||
+			current.Default != row.Default || current.Forced != row.Forced {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestLocalSubtitleStoreReplaceForEpisode(t *testing.T) {
+	t.Parallel()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.LocalSubtitle{}))
+	s := NewLocalSubtitleStore(db)
+
+	rows := []model.LocalSubtitle{
+		{Path: "/anime/ep01.chs.ass", Format: "ass", Language: "zh-Hans", Label: "简体中文 (ASS)"},
+		{Path: "/anime/ep01.cht.srt", Format: "srt", Language: "zh-Hant", Label: "繁体中文 (SRT)"},
+	}
+	require.NoError(t, s.ReplaceForEpisode(7, rows))
+	require.NoError(t, s.ReplaceForEpisode(8, []model.LocalSubtitle{{Path: "/anime/ep02.ass", Format: "ass"}}))
+	first, err := s.ListByEpisode(7)
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	assert.Equal(t, "/anime/ep01.chs.ass", first[0].Path)
+
+	// 内容未变化时保留原有行，字幕 ID 在重新扫描后保持稳定
+	require.NoError(t, s.ReplaceForEpisode(7, []model.LocalSubtitle{rows[1], rows[0]}))
+	again, err := s.ListByEpisode(7)
+	require.NoError(t, err)
+	assert.Equal(t, first[0].ID, again[0].ID)
+
+	require.NoError(t, s.ReplaceForEpisode(7, nil))
+	empty, err := s.ListByEpisode(7)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+	other, err := s.ListByEpisode(8)
+	require.NoError(t, err)
+	assert.Len(t, other, 1)
+}
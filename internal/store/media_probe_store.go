@@ -0,0 +1,79 @@
+package store
+
+import (
+	"errors"
+
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// mediaProbeLookupBatch keeps IN lists well below SQLite's variable limit.
+const mediaProbeLookupBatch = 500
+
+type MediaProbeStore struct {
+	db *gorm.DB
+}
+
+func NewMediaProbeStore(db *gorm.DB) *MediaProbeStore {
+	return &MediaProbeStore{db: db}
+}
+
+// FindByFingerprint returns the cached probe for one fingerprint, or nil when
+// the file has not been probed yet.
+func (s *MediaProbeStore) FindByFingerprint(fingerprint string) (*model.MediaProbe, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	if fingerprint == "" {
+		return nil, nil
+	}
+	var row model.MediaProbe
+	err := s.db.Where("fingerprint = ?", fingerprint).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// FindByFingerprints returns the cached probes keyed by fingerprint. Missing
+// fingerprints are simply absent from the map.
+func (s *MediaProbeStore) FindByFingerprints(fingerprints []string) (map[string]model.MediaProbe, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	result := make(map[string]model.MediaProbe, len(fingerprints))
+	for start := 0; start < len(fingerprints); start += mediaProbeLookupBatch {
+		end := min(start+mediaProbeLookupBatch, len(fingerprints))
+		var rows []model.MediaProbe
+		if err := s.db.Where("fingerprint IN ?", fingerprints[start:end]).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			result[row.Fingerprint] = row
+		}
+	}
+	return result, nil
+}
+
+// Save inserts the probe or replaces the cached result for its fingerprint.
+func (s *MediaProbeStore) Save(row *model.MediaProbe) error {
+	if s == nil || s.db == nil {
+		return gorm.ErrInvalidDB
+	}
+	if row == nil || row.Fingerprint == "" {
+		return errors.New("media probe fingerprint is empty")
+	}
+	return retrySQLiteBusy(func() error {
+		return s.db.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "fingerprint"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"updated_at", "duration_ticks", "container", "video_codec", "audio_codec", "resolution",
+				"bit_depth", "audio_languages", "streams", "subtitles", "error",
+			}),
+		}).Create(row).Error
+	})
+}
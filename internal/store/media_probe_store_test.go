@@ -0,0 +1,39 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestMediaProbeStoreSaveReplacesByFingerprint(t *testing.T) {
+	t.Parallel()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.MediaProbe{}))
+	s := NewMediaProbeStore(db)
+
+	missing, err := s.FindByFingerprint("abc")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	require.NoError(t, s.Save(&model.MediaProbe{Fingerprint: "abc", Error: "ffprobe failed"}))
+	require.NoError(t, s.Save(&model.MediaProbe{Fingerprint: "abc", VideoCodec: "HEVC", DurationTicks: 14_400_000_000}))
+	require.NoError(t, s.Save(&model.MediaProbe{Fingerprint: "def", VideoCodec: "H264"}))
+	assert.Error(t, s.Save(&model.MediaProbe{}))
+
+	rows, err := s.FindByFingerprints([]string{"abc", "def", "ghi"})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "HEVC", rows["abc"].VideoCodec)
+	assert.Empty(t, rows["abc"].Error)
+	assert.Equal(t, int64(14_400_000_000), rows["abc"].DurationTicks)
+
+	var count int64
+	require.NoError(t, db.Model(&model.MediaProbe{}).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+}
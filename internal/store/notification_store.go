@@ -0,0 +1,122 @@
+package store
+
+import (
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"gorm.io/gorm"
+)
+
+// notificationDeliveryRetention 是保留的投递记录条数，超出后删除最旧的记录。
+const notificationDeliveryRetention = 500
+
+type NotificationStore struct {
+	db *gorm.DB
+}
+
+func NewNotificationStore(db *gorm.DB) *NotificationStore {
+	return &NotificationStore{db: db}
+}
+
+func (s *NotificationStore) ListChannels() ([]model.NotificationChannel, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	var channels []model.NotificationChannel
+	if err := s.db.Order("id ASC").Find(&channels).Error; err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+func (s *NotificationStore) ListEnabledChannels() ([]model.NotificationChannel, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	var channels []model.NotificationChannel
+	if err := s.db.Where("enabled = ?", true).Order("id ASC").Find(&channels).Error; err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+func (s *NotificationStore) GetChannel(id uint) (*model.NotificationChannel, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	var channel model.NotificationChannel
+	if err := s.db.First(&channel, id).Error; err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// SaveChannel 创建或整体更新一个渠道。
+func (s *NotificationStore) SaveChannel(channel *model.NotificationChannel) error {
+	if s == nil || s.db == nil {
+		return gorm.ErrInvalidDB
+	}
+	if channel == nil {
+		return nil
+	}
+	return retrySQLiteBusy(func() error { return s.db.Save(channel).Error })
+}
+
+func (s *NotificationStore) DeleteChannel(id uint) error {
+	if s == nil || s.db == nil {
+		return gorm.ErrInvalidDB
+	}
+	return retrySQLiteBusy(func() error {
+		return s.db.Delete(&model.NotificationChannel{}, id).Error
+	})
+}
+
+// CreateDelivery 写入一条投递记录，并裁剪超出保留条数的旧记录。
+func (s *NotificationStore) CreateDelivery(delivery *model.NotificationDelivery) error {
+	if s == nil || s.db == nil {
+		return gorm.ErrInvalidDB
+	}
+	if delivery == nil {
+		return nil
+	}
+	if err := retrySQLiteBusy(func() error { return s.db.Create(delivery).Error }); err != nil {
+		return err
+	}
+	if delivery.ID <= notificationDeliveryRetention {
+		return nil
+	}
+	return retrySQLiteBusy(func() error {
+		return s.db.
+			Where("id <= ?", delivery.ID-notificationDeliveryRetention).
+			Delete(&model.NotificationDelivery{}).Error
+	})
+}
+
+func (s *NotificationStore) UpdateDelivery(id uint, updates map[string]interface{}) error {
+	if s == nil || s.db == nil {
+		return gorm.ErrInvalidDB
+	}
+	if id == 0 || len(updates) == 0 {
+		return nil
+	}
+	return retrySQLiteBusy(func() error {
+		return s.db.Model(&model.NotificationDelivery{}).Where("id = ?", id).Updates(updates).Error
+	})
+}
+
+// ListDeliveries 按时间倒序返回投递记录，channelID 为 0 时不按渠道过滤。
+func (s *NotificationStore) ListDeliveries(channelID uint, limit int) ([]model.NotificationDelivery, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	if limit <= 0 || limit > notificationDeliveryRetention {
+		limit = 100
+	}
+	query := s.db.Order("id DESC").Limit(limit)
+	if channelID != 0 {
+		query = query.Where("channel_id = ?", channelID)
+	}
+	var deliveries []model.NotificationDelivery
+	if err := query.Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
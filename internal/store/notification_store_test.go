@@ -0,0 +1,52 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestNotificationStoreChannelsAndDeliveryRetention(t *testing.T) {
+	t.Parallel()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.NotificationChannel{}, &model.NotificationDelivery{}))
+	s := NewNotificationStore(db)
+
+	enabled := model.NotificationChannel{Name: "hook", Type: "webhook", Enabled: true}
+	disabled := model.NotificationChannel{Name: "mail", Type: "smtp"}
+	require.NoError(t, s.SaveChannel(&enabled))
+	require.NoError(t, s.SaveChannel(&disabled))
+	active, err := s.ListEnabledChannels()
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, enabled.ID, active[0].ID)
+
+	for i := 0; i < notificationDeliveryRetention+5; i++ {
+		channelID := enabled.ID
+		if i%2 == 1 {
+			channelID = disabled.ID
+		}
+		require.NoError(t, s.CreateDelivery(&model.NotificationDelivery{ChannelID: channelID, Status: "sent"}))
+	}
+	var total int64
+	require.NoError(t, db.Model(&model.NotificationDelivery{}).Count(&total).Error)
+	assert.Equal(t, int64(notificationDeliveryRetention), total)
+
+	rows, err := s.ListDeliveries(disabled.ID, 3)
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Greater(t, rows[0].ID, rows[1].ID)
+	for _, row := range rows {
+		assert.Equal(t, disabled.ID, row.ChannelID)
+	}
+
+	require.NoError(t, s.DeleteChannel(disabled.ID))
+	all, err := s.ListChannels()
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+}
@@ -68,3 +68,49 @@ func (s *PlaybackHistoryStore) Count(userID uint) (int64, error) {
 	}
 	return count, nil
 }
+
+// ListByEpisodes returns the user's history rows for the given episodes keyed by episode ID.
+func (s *PlaybackHistoryStore) ListByEpisodes(userID uint, episodeIDs []uint) (map[uint]model.PlaybackHistory, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	result := make(map[uint]model.PlaybackHistory, len(episodeIDs))
+	if len(episodeIDs) == 0 {
+		return result, nil
+	}
+	var histories []model.PlaybackHistory
+	if err := s.db.Where("user_id = ? AND local_episode_id IN ?", userID, episodeIDs).Find(&histories).Error; err != nil {
+		return nil, err
+	}
+	for _, history := range histories {
+		result[history.LocalEpisodeID] = history
+	}
+	return result, nil
+}
+
+// AnimeWatchProgress is the furthest completed regular episode of a series.
+type AnimeWatchProgress struct {
+	LocalAnimeID uint
+	Episode      int
+}
+
+// CompletedProgress returns, per series, the highest episode number userID
+// has finished. Specials (season 0) are ignored. With no IDs every series
+// with completed playback is returned.
+func (s *PlaybackHistoryStore) CompletedProgress(userID uint, localAnimeIDs ...uint) ([]AnimeWatchProgress, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	query := s.db.Table("playback_histories").
+		Select("playback_histories.local_anime_id AS local_anime_id, MAX(local_episodes.episode_num) AS episode").
+		Joins("JOIN local_episodes ON local_episodes.id = playback_histories.local_episode_id AND local_episodes.deleted_at IS NULL").
+		Where("playback_histories.user_id = ? AND playback_histories.completed = ? AND playback_histories.deleted_at IS NULL AND local_episodes.season_num <> 0", userID, true)
+	if len(localAnimeIDs) > 0 {
+		query = query.Where("playback_histories.local_anime_id IN ?", localAnimeIDs)
+	}
+	var progress []AnimeWatchProgress
+	if err := query.Group("playback_histories.local_anime_id").Order("playback_histories.local_anime_id").Scan(&progress).Error; err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
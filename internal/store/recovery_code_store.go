@@ -0,0 +1,58 @@
+package store
+
+import (
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeStore struct {
+	db *gorm.DB
+}
+
+func NewRecoveryCodeStore(db *gorm.DB) *RecoveryCodeStore {
+	return &RecoveryCodeStore{db: db}
+}
+
+// Replace swaps the user's recovery codes for a freshly generated set, so a
+// regenerated batch invalidates every code shown earlier.
+func (s *RecoveryCodeStore) Replace(userID uint, hashes []string) error {
+	if s == nil || s.db == nil {
+		return gorm.ErrInvalidDB
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&model.UserRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(hashes) == 0 {
+			return nil
+		}
+		codes := make([]model.UserRecoveryCode, 0, len(hashes))
+		for _, hash := range hashes {
+			codes = append(codes, model.UserRecoveryCode{UserID: userID, CodeHash: hash})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Consume marks an unused code as used and reports whether one matched. The
+// conditional update makes concurrent attempts with the same code race-free.
+func (s *RecoveryCodeStore) Consume(userID uint, hash string, at time.Time) (bool, error) {
+	if s == nil || s.db == nil {
+		return false, gorm.ErrInvalidDB
+	}
+	result := s.db.Model(&model.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (s *RecoveryCodeStore) CountUnused(userID uint) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, gorm.ErrInvalidDB
+	}
+	var count int64
+	err := s.db.Model(&model.UserRecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
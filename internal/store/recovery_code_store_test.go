@@ -0,0 +1,45 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"gorm.io/gorm"
+)
+
+func TestRecoveryCodeStoreConsumesEachCodeOnce(t *testing.T) {
+	if _, err := NewRecoveryCodeStore(nil).CountUnused(1); err != gorm.ErrInvalidDB {
+		t.Fatalf("expected ErrInvalidDB, got %v", err)
+	}
+
+	db.InitDB(":memory:")
+	t.Cleanup(func() {
+		_ = db.CloseDB()
+		db.DB = nil
+	})
+
+	st := NewRecoveryCodeStore(db.DB)
+	if err := st.Replace(1, []string{"a", "b"}); err != nil {
+		t.Fatalf("Replace returned error: %v", err)
+	}
+	if ok, err := st.Consume(1, "a", time.Now()); err != nil || !ok {
+		t.Fatalf("first Consume = %v, %v", ok, err)
+	}
+	if ok, _ := st.Consume(1, "a", time.Now()); ok {
+		t.Fatal("expected a used code to be rejected")
+	}
+	if ok, _ := st.Consume(2, "b", time.Now()); ok {
+		t.Fatal("expected another user's code to be rejected")
+	}
+	if count, err := st.CountUnused(1); err != nil || count != 1 {
+		t.Fatalf("CountUnused = %d, %v", count, err)
+	}
+
+	if err := st.Replace(1, []string{"c"}); err != nil {
+		t.Fatalf("Replace returned error: %v", err)
+	}
+	if ok, _ := st.Consume(1, "b", time.Now()); ok {
+		t.Fatal("expected codes from the previous batch to be gone")
+	}
+}
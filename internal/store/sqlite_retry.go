@@ -3,6 +3,8 @@ package store
 import (
 	"strings"
 	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/metrics"
 )
 
 const (
@@ -10,6 +12,11 @@ const (
 	sqliteBusyBaseDelay   = 100 * time.Millisecond
 )
 
+var (
+	sqliteBusyRetries   = metrics.NewCounterVec("animate_sqlite_busy_retries_total", "Writes retried because SQLite reported the database as busy or locked.")
+	sqliteBusyExhausted = metrics.NewCounterVec("animate_sqlite_busy_exhausted_total", "Writes that were still busy after the last retry.")
+)
+
 func retrySQLiteBusy(operation func() error) error {
 	return retrySQLiteBusyWithBackoff(operation, sqliteBusyMaxAttempts, sqliteBusyBaseDelay)
 }
@@ -25,10 +32,14 @@ func retrySQLiteBusyWithBackoff(operation func() error, maxAttempts int, baseDel
 		if err == nil || !isSQLiteBusyError(err) {
 			return err
 		}
-		if attempt+1 < maxAttempts && baseDelay > 0 {
-			time.Sleep(baseDelay * time.Duration(1<<attempt))
+		if attempt+1 < maxAttempts {
+			sqliteBusyRetries.Inc()
+			if baseDelay > 0 {
+				time.Sleep(baseDelay * time.Duration(1<<attempt))
+			}
 		}
 	}
+	sqliteBusyExhausted.Inc()
 	return err
 }
 
@@ -6,6 +6,7 @@ import (
 )
 
 func TestRetrySQLiteBusyRetriesTransientLock(t *testing.T) {
+	retriesBefore := sqliteBusyRetries.Value()
 	attempts := 0
 	err := retrySQLiteBusyWithBackoff(func() error {
 		attempts++
@@ -21,6 +22,9 @@ func TestRetrySQLiteBusyRetriesTransientLock(t *testing.T) {
 	if attempts != 3 {
 		t.Fatalf("attempts = %d, want 3", attempts)
 	}
+	if retries := sqliteBusyRetries.Value() - retriesBefore; retries != 2 {
+		t.Fatalf("recorded retries = %v, want 2", retries)
+	}
 }
 
 func TestRetrySQLiteBusyDoesNotRetryOtherErrors(t *testing.T) {
@@ -27,6 +27,19 @@ func (s *SubscriptionStore) ListActive() ([]model.Subscription, error) {
 	return subs, nil
 }
 
+// ListActiveWithMetadata returns active subscriptions with their metadata
+// preloaded, for callers that need air dates or provider IDs.
+func (s *SubscriptionStore) ListActiveWithMetadata() ([]model.Subscription, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	var subs []model.Subscription
+	if err := s.db.Preload("Metadata").Where("is_active = ?", true).Find(&subs).Error; err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
 func (s *SubscriptionStore) ListAll() ([]model.Subscription, error) {
 	if s == nil || s.db == nil {
 		return nil, gorm.ErrInvalidDB
@@ -145,6 +158,35 @@ func (s *SubscriptionStore) FindByRSSURLUnscoped(rssURL string) (*model.Subscrip
 	return &sub, true, nil
 }
 
+// FindByBangumiSubjectOrMikanID returns a subscription whose metadata is the
+// given bgm.tv subject or whose Mikan ID matches. Either key may be empty.
+func (s *SubscriptionStore) FindByBangumiSubjectOrMikanID(subjectID int, mikanID string) (*model.Subscription, bool, error) {
+	if s == nil || s.db == nil {
+		return nil, false, gorm.ErrInvalidDB
+	}
+	if subjectID == 0 && mikanID == "" {
+		return nil, false, nil
+	}
+	query := s.db.Model(&model.Subscription{})
+	switch {
+	case subjectID != 0 && mikanID != "":
+		query = query.Where("mikan_id = ? OR metadata_id IN (?)", mikanID, s.db.Model(&model.AnimeMetadata{}).Select("id").Where("bangumi_id = ?", subjectID))
+	case subjectID != 0:
+		query = query.Where("metadata_id IN (?)", s.db.Model(&model.AnimeMetadata{}).Select("id").Where("bangumi_id = ?", subjectID))
+	default:
+		query = query.Where("mikan_id = ?", mikanID)
+	}
+	var sub model.Subscription
+	err := query.Order("id").First(&sub).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &sub, true, nil
+}
+
 // DeleteCascade hard-deletes a subscription together with its download logs in
 // a single transaction.
 func (s *SubscriptionStore) DeleteCascade(id uint) error {
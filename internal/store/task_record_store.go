@@ -0,0 +1,83 @@
+package store
+
+import (
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TaskRecordStore struct {
+	db *gorm.DB
+}
+
+func NewTaskRecordStore(db *gorm.DB) *TaskRecordStore {
+	return &TaskRecordStore{db: db}
+}
+
+// Save inserts the record or replaces the stored row with the same task ID.
+// Fixed task IDs such as "local-scan" are reused, so a new run overwrites
+// the previous one.
+func (s *TaskRecordStore) Save(record *model.TaskRecord) error {
+	if s == nil || s.db == nil {
+		return gorm.ErrInvalidDB
+	}
+	if record == nil || record.TaskID == "" {
+		return nil
+	}
+	return retrySQLiteBusy(func() error {
+		return s.db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "task_id"}},
+			UpdateAll: true,
+		}).Create(record).Error
+	})
+}
+
+func (s *TaskRecordStore) Get(taskID string) (*model.TaskRecord, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	var record model.TaskRecord
+	if err := s.db.Where("task_id = ?", taskID).First(&record).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// ListRecent returns the most recently updated records, newest first.
+func (s *TaskRecordStore) ListRecent(limit int) ([]model.TaskRecord, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	var records []model.TaskRecord
+	err := s.db.Order("updated_at DESC").Limit(limit).Find(&records).Error
+	return records, err
+}
+
+// CloseUnfinished gives every record without a finish time the supplied
+// status and message. It runs at startup, when no task from the previous
+// process can still be running.
+func (s *TaskRecordStore) CloseUnfinished(at time.Time, status, message string) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, gorm.ErrInvalidDB
+	}
+	result := s.db.Model(&model.TaskRecord{}).
+		Where("finished_at IS NULL").
+		Updates(map[string]any{"status": status, "message": message, "finished_at": at, "updated_at": at})
+	return result.RowsAffected, result.Error
+}
+
+// Prune deletes finished records beyond the newest keep rows. Unfinished
+// records are never removed.
+func (s *TaskRecordStore) Prune(keep int) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, gorm.ErrInvalidDB
+	}
+	newest := s.db.Model(&model.TaskRecord{}).Select("task_id").Order("updated_at DESC").Limit(keep)
+	result := s.db.Where("finished_at IS NOT NULL AND task_id NOT IN (?)", newest).Delete(&model.TaskRecord{})
+	return result.RowsAffected, result.Error
+}
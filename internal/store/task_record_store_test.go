@@ -0,0 +1,56 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"gorm.io/gorm"
+)
+
+func TestTaskRecordStoreReplacesReusedIDsAndPrunesFinishedRows(t *testing.T) {
+	if err := NewTaskRecordStore(nil).Save(&model.TaskRecord{TaskID: "x"}); err != gorm.ErrInvalidDB {
+		t.Fatalf("expected ErrInvalidDB, got %v", err)
+	}
+
+	db.InitDB(":memory:")
+	t.Cleanup(func() {
+		_ = db.CloseDB()
+		db.DB = nil
+	})
+	st := NewTaskRecordStore(db.DB)
+	base := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 4; i++ {
+		finished := base.Add(time.Duration(i) * time.Minute)
+		if err := st.Save(&model.TaskRecord{TaskID: fmt.Sprintf("done-%d", i), Status: "completed", StartedAt: base, FinishedAt: &finished, UpdatedAt: finished}); err != nil {
+			t.Fatalf("Save returned error: %v", err)
+		}
+	}
+	if err := st.Save(&model.TaskRecord{TaskID: "running", Status: "running", StartedAt: base, UpdatedAt: base}); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	if err := st.Save(&model.TaskRecord{TaskID: "done-0", Status: "error", Message: "second run", StartedAt: base, UpdatedAt: base.Add(time.Hour)}); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	record, err := st.Get("done-0")
+	if err != nil || record.Message != "second run" || record.FinishedAt != nil {
+		t.Fatalf("Get after reuse = %+v, %v", record, err)
+	}
+
+	// done-0 and done-3 are the newest rows; the unfinished row always stays.
+	if removed, err := st.Prune(2); err != nil || removed != 2 {
+		t.Fatalf("Prune = %d, %v", removed, err)
+	}
+	records, err := st.ListRecent(10)
+	if err != nil || len(records) != 3 || records[0].TaskID != "done-0" || records[1].TaskID != "done-3" || records[2].TaskID != "running" {
+		t.Fatalf("ListRecent after prune = %+v, %v", records, err)
+	}
+
+	closed, err := st.CloseUnfinished(base.Add(2*time.Hour), "error", "interrupted")
+	if err != nil || closed != 2 {
+		t.Fatalf("CloseUnfinished = %d, %v", closed, err)
+	}
+}
@@ -66,3 +66,68 @@ func (s *UserStore) DeleteByUsername(username string) error {
 	}
 	return s.db.Where("username = ?", username).Delete(&model.User{}).Error
 }
+
+// List returns every account ordered by creation.
+func (s *UserStore) List() ([]model.User, error) {
+	if s == nil || s.db == nil {
+		return nil, gorm.ErrInvalidDB
+	}
+	var users []model.User
+	if err := s.db.Order("id ASC").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// CountByRole counts accounts holding role. Legacy rows without a role count
+// as admins, matching the 024_user_roles migration.
+func (s *UserStore) CountByRole(role string) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, gorm.ErrInvalidDB
+	}
+	query := s.db.Model(&model.User{})
+	if role == model.UserRoleAdmin {
+		query = query.Where("role = ? OR role = '' OR role IS NULL", role)
+	} else {
+		query = query.Where("role = ?", role)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Delete permanently removes the account together with its playback history,
+// API tokens and two-factor recovery codes, so the username can be reused and
+// no orphaned rows remain.
+func (s *UserStore) Delete(user *model.User) error {
+	if s == nil || s.db == nil {
+		return gorm.ErrInvalidDB
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&model.PlaybackHistory{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&model.APIToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&model.UserRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(user).Error
+	})
+}
+
+// AdvanceTOTPStep records the time step of an accepted TOTP code. It only
+// succeeds when step is newer than the stored one, so a code cannot be
+// replayed, not even by two concurrent logins.
+func (s *UserStore) AdvanceTOTPStep(id uint, step int64) (bool, error) {
+	if s == nil || s.db == nil {
+		return false, gorm.ErrInvalidDB
+	}
+	result := s.db.Model(&model.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		UpdateColumn("totp_last_step", step)
+	return result.RowsAffected > 0, result.Error
+}
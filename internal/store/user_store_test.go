@@ -65,3 +65,61 @@ func TestUserStoreCRUDAndNilSafety(t *testing.T) {
 		t.Fatal("expected deleted user lookup to fail")
 	}
 }
+
+func TestUserStoreRolesAndDelete(t *testing.T) {
+	db.InitDB(":memory:")
+	t.Cleanup(func() {
+		_ = db.CloseDB()
+		db.DB = nil
+	})
+
+	st := NewUserStore(db.DB)
+	admin := &model.User{Username: "owner", PasswordHash: "hash"}
+	viewer := &model.User{Username: "kid", PasswordHash: "hash", Role: model.UserRoleViewer}
+	for _, user := range []*model.User{admin, viewer} {
+		if err := st.Create(user); err != nil {
+			t.Fatalf("Create returned error: %v", err)
+		}
+	}
+	if admin.Role != model.UserRoleAdmin {
+		t.Fatalf("expected default admin role, got %q", admin.Role)
+	}
+	if count, err := st.CountByRole(model.UserRoleAdmin); err != nil || count != 1 {
+		t.Fatalf("CountByRole(admin) = %d, %v", count, err)
+	}
+
+	history := model.PlaybackHistory{UserID: viewer.ID, LocalEpisodeID: 7}
+	if err := db.DB.Create(&history).Error; err != nil {
+		t.Fatalf("create playback history: %v", err)
+	}
+	if err := NewAPITokenStore(db.DB).Create(&model.APIToken{UserID: viewer.ID, Name: "ha", TokenHash: "h1"}); err != nil {
+		t.Fatalf("create api token: %v", err)
+	}
+	if err := NewRecoveryCodeStore(db.DB).Replace(viewer.ID, []string{"r1"}); err != nil {
+		t.Fatalf("create recovery code: %v", err)
+	}
+	if err := st.Delete(viewer); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	var remaining int64
+	db.DB.Unscoped().Model(&model.PlaybackHistory{}).Where("user_id = ?", viewer.ID).Count(&remaining)
+	if remaining != 0 {
+		t.Fatalf("expected playback history to be removed, got %d rows", remaining)
+	}
+	db.DB.Unscoped().Model(&model.APIToken{}).Where("user_id = ?", viewer.ID).Count(&remaining)
+	if remaining != 0 {
+		t.Fatalf("expected api tokens to be removed, got %d rows", remaining)
+	}
+	db.DB.Unscoped().Model(&model.UserRecoveryCode{}).Where("user_id = ?", viewer.ID).Count(&remaining)
+	if remaining != 0 {
+		t.Fatalf("expected recovery codes to be removed, got %d rows", remaining)
+	}
+	if err := st.Create(&model.User{Username: "kid", PasswordHash: "hash"}); err != nil {
+		t.Fatalf("expected username to be reusable after delete: %v", err)
+	}
+
+	users, err := st.List()
+	if err != nil || len(users) != 2 || users[0].Username != "owner" {
+		t.Fatalf("List = %#v, %v", users, err)
+	}
+}
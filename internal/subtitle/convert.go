@@ -0,0 +1,162 @@
+package subtitle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const webVTTHeader = "WEBVTT"
+
+var (
+	srtTimestamp    = regexp.MustCompile(`(\d{1,2}:\d{2}:\d{2}),(\d{1,3})`)
+	assOverrideTags = regexp.MustCompile(`\{[^}]*\}`)
+	vttTextEscaper  = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+)
+
+// ToWebVTT 把 SRT、ASS/SSA 字幕转换成 WebVTT；WebVTT 原样返回（仅补齐文件头）。
+// ASS 的样式和特效会被丢弃，只保留对白文本和时间轴。
+func ToWebVTT(format string, data []byte) ([]byte, error) {
+	text := normalizeText(data)
+	switch format {
+	case FormatVTT:
+		if strings.HasPrefix(text, webVTTHeader) {
+			return []byte(text), nil
+		}
+		return []byte(webVTTHeader + "\n\n" + text), nil
+	case FormatSRT:
+		return []byte(srtToWebVTT(text)), nil
+	case FormatASS, FormatSSA:
+		return assToWebVTT(text)
+	default:
+		return nil, fmt.Errorf("不支持转换的字幕格式: %s", format)
+	}
+}
+
+func normalizeText(data []byte) string {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n")
+}
+
+func srtToWebVTT(text string) string {
+	var out strings.Builder
+	out.WriteString(webVTTHeader + "\n\n")
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		if strings.Contains(line, "-->") {
+			line = srtTimestamp.ReplaceAllStringFunc(line, func(match string) string {
+				parts := srtTimestamp.FindStringSubmatch(match)
+				return padHours(parts[1]) + "." + (parts[2] + "00")[:3]
+			})
+		}
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+	return out.String()
+}
+
+func padHours(clock string) string {
+	if len(clock) == len("0:00:00") {
+		return "0" + clock
+	}
+	return clock
+}
+
+func assToWebVTT(text string) ([]byte, error) {
+	var out strings.Builder
+	out.WriteString(webVTTHeader + "\n\n")
+	inEvents := false
+	startField, endField, textField, fieldCount := -1, -1, -1, 0
+	for _, raw := range strings.Split(text, "\n") {
+		line := strings.TrimSpace(raw)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inEvents = strings.EqualFold(line, "[Events]")
+			continue
+		}
+		if !inEvents {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "format":
+			fields := strings.Split(value, ",")
+			fieldCount = len(fields)
+			for index, field := range fields {
+				switch strings.ToLower(strings.TrimSpace(field)) {
+				case "start":
+					startField = index
+				case "end":
+					endField = index
+				case "text":
+					textField = index
+				}
+			}
+		case "dialogue":
+			if fieldCount == 0 || startField < 0 || endField < 0 || textField != fieldCount-1 {
+				continue
+			}
+			fields := strings.SplitN(strings.TrimSpace(value), ",", fieldCount)
+			if len(fields) != fieldCount {
+				continue
+			}
+			start, startErr := assTimestamp(fields[startField])
+			end, endErr := assTimestamp(fields[endField])
+			cue := assCueText(fields[textField])
+			if startErr != nil || endErr != nil || cue == "" {
+				continue
+			}
+			fmt.Fprintf(&out, "%s --> %s\n%s\n\n", start, end, cue)
+		}
+	}
+	if textField < 0 {
+		return nil, errors.New("ASS 字幕缺少 [Events] 格式定义")
+	}
+	return []byte(out.String()), nil
+}
+
+// assTimestamp 把 ASS 的 H:MM:SS.cc 转成 WebVTT 的 HH:MM:SS.mmm。
+func assTimestamp(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	clock, fraction, _ := strings.Cut(value, ".")
+	parts := strings.Split(clock, ":")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("invalid ASS timestamp %q", value)
+	}
+	numbers := make([]int, 3)
+	for index, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return "", fmt.Errorf("invalid ASS timestamp %q", value)
+		}
+		numbers[index] = number
+	}
+	millis := 0
+	if fraction != "" {
+		fraction = (fraction + "000")[:3]
+		parsed, err := strconv.Atoi(fraction)
+		if err != nil {
+			return "", fmt.Errorf("invalid ASS timestamp %q", value)
+		}
+		millis = parsed
+	}
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", numbers[0], numbers[1], numbers[2], millis), nil
+}
+
+func assCueText(value string) string {
+	value = assOverrideTags.ReplaceAllString(value, "")
+	value = strings.NewReplacer(`\N`, "\n", `\n`, "\n", `\h`, " ").Replace(value)
+	lines := strings.Split(value, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			kept = append(kept, vttTextEscaper.Replace(line))
+		}
+	}
+	return strings.Join(kept, "\n")
+}
@@ -0,0 +1,50 @@
+package subtitle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToWebVTTConvertsSRT(t *testing.T) {
+	srt := "\xef\xbb\xbf1\r\n00:00:01,500 --> 0:00:03,04\r\n<i>你好</i>\r\n\r\n2\r\n00:01:00,000 --> 00:01:02,000\r\n第二行\r\n"
+	got, err := ToWebVTT(FormatSRT, []byte(srt))
+	require.NoError(t, err)
+	assert.Equal(t, "WEBVTT\n\n1\n00:00:01.500 --> 00:00:03.040\n<i>你好</i>\n\n2\n00:01:00.000 --> 00:01:02.000\n第二行\n", string(got))
+}
+
+func TestToWebVTTConvertsASSDialogue(t *testing.T) {
+	ass := `[Script Info]
+Title: demo
+
+[V4+ Styles]
+Format: Name, Fontname
+Style: Default,Arial
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Comment: 0,0:00:00.00,0:00:01.00,Default,,0,0,0,,注释不输出
+Dialogue: 0,0:00:01.50,0:00:03.05,Default,,0,0,0,,{\an8\b1}第一行\N第二行, 带逗号 <3
+Dialogue: 0,1:02:03.00,1:02:04.00,Default,,0,0,0,,{\p1}
+`
+	got, err := ToWebVTT(FormatASS, []byte(ass))
+	require.NoError(t, err)
+	assert.Equal(t, "WEBVTT\n\n00:00:01.500 --> 00:00:03.050\n第一行\n第二行, 带逗号 &lt;3\n\n", string(got))
+
+	_, err = ToWebVTT(FormatASS, []byte("[Script Info]\nTitle: empty\n"))
+	assert.Error(t, err)
+}
+
+func TestToWebVTTKeepsVTTAndRejectsUnknownFormats(t *testing.T) {
+	got, err := ToWebVTT(FormatVTT, []byte("WEBVTT\n\n00:00.000 --> 00:01.000\nhi\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "WEBVTT\n\n00:00.000 --> 00:01.000\nhi\n", string(got))
+
+	got, err = ToWebVTT(FormatVTT, []byte("00:00.000 --> 00:01.000\nhi\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "WEBVTT\n\n00:00.000 --> 00:01.000\nhi\n", string(got))
+
+	_, err = ToWebVTT("sup", nil)
+	assert.Error(t, err)
+}
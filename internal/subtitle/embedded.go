@@ -0,0 +1,154 @@
+package subtitle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pokerjest/animateAutoTool/internal/config"
+)
+
+const (
+	probeTimeout   = 15 * time.Second
+	extractTimeout = 2 * time.Minute
+)
+
+// ErrToolUnavailable 表示找不到 ffprobe/ffmpeg，此时只提供外挂字幕。
+var ErrToolUnavailable = errors.New("ffmpeg tools are not available")
+
+// textCodecs 是 ffmpeg 能转换成 WebVTT 的文本字幕编码；PGS、VobSub 等图形字幕无法在浏览器里直接显示。
+var textCodecs = map[string]struct{}{
+	"ass": {}, "ssa": {}, "subrip": {}, "srt": {}, "webvtt": {}, "mov_text": {}, "text": {},
+}
+
+// runTool 执行外部命令并返回标准输出，测试中可替换。
+var runTool = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).Output() //nolint:gosec
+}
+
+// lookupTool 解析工具路径，测试中可替换。
+var lookupTool = defaultToolPath
+
+// ToolPath 优先使用托管目录 bin/ffmpeg 下的 ffmpeg/ffprobe，其次查找 PATH。
+func ToolPath(name string) string {
+	return lookupTool(name)
+}
+
+func defaultToolPath(name string) string {
+	executable := name
+	if runtime.GOOS == "windows" {
+		executable += ".exe"
+	}
+	managed := filepath.Join(config.BinDir(), "ffmpeg", executable)
+	if info, err := os.Stat(managed); err == nil && !info.IsDir() {
+		return managed
+	}
+	if path, err := exec.LookPath(name); err == nil {
+		return path
+	}
+	return ""
+}
+
+// Playable 报告内嵌字幕能否转换成 WebVTT。
+func (t Track) Playable() bool {
+	if t.Source != SourceEmbedded {
+		return true
+	}
+	_, ok := textCodecs[t.Codec]
+	return ok
+}
+
+type probeOutput struct {
+	Streams []struct {
+		Index       int               `json:"index"`
+		CodecName   string            `json:"codec_name"`
+		Tags        map[string]string `json:"tags"`
+		Disposition map[string]int    `json:"disposition"`
+	} `json:"streams"`
+}
+
+// ProbeEmbedded 用 ffprobe 列出视频内的字幕流。
+func ProbeEmbedded(ctx context.Context, video string) ([]Track, error) {
+	ffprobe := ToolPath("ffprobe")
+	if ffprobe == "" {
+		return nil, ErrToolUnavailable
+	}
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	output, err := runTool(ctx, ffprobe,
+		"-v", "error", "-select_streams", "s",
+		"-show_entries", "stream=index,codec_name:stream_tags=language,title:stream_disposition=default,forced",
+		"-of", "json", video)
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %w", err)
+	}
+	return parseProbeOutput(output)
+}
+
+func parseProbeOutput(output []byte) ([]Track, error) {
+	var probe probeOutput
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("invalid ffprobe output: %w", err)
+	}
+	tracks := make([]Track, 0, len(probe.Streams))
+	for _, stream := range probe.Streams {
+		tracks = append(tracks, EmbeddedTrack(stream.Index, stream.CodecName, stream.Tags, stream.Disposition))
+	}
+	return tracks, nil
+}
+
+// EmbeddedTrack 根据 ffprobe 输出的字幕流信息生成字幕轨。
+func EmbeddedTrack(index int, codecName string, tags map[string]string, disposition map[string]int) Track {
+	codec := strings.ToLower(codecName)
+	format := codec
+	if codec == "subrip" {
+		format = FormatSRT
+	}
+	track := Track{
+		Source:      SourceEmbedded,
+		StreamIndex: index,
+		Codec:       codec,
+		Format:      format,
+		Default:     disposition["default"] == 1,
+		Forced:      disposition["forced"] == 1,
+	}
+	var languageLabel string
+	track.Language, languageLabel = Language(tags["language"])
+	if track.Language == "und" {
+		track.Language, languageLabel = "", ""
+	}
+	base := strings.TrimSpace(tags["title"])
+	if base == "" {
+		base = languageLabel
+	}
+	if base == "" {
+		base = "内嵌字幕 #" + strconv.Itoa(index)
+	}
+	track.Label = TrackLabel(base, format, SourceEmbedded)
+	return track
+}
+
+// ExtractWebVTT 用 ffmpeg 把指定的内嵌文本字幕流转换成 WebVTT。
+func ExtractWebVTT(ctx context.Context, video string, streamIndex int) ([]byte, error) {
+	ffmpeg := ToolPath("ffmpeg")
+	if ffmpeg == "" {
+		return nil, ErrToolUnavailable
+	}
+	ctx, cancel := context.WithTimeout(ctx, extractTimeout)
+	defer cancel()
+	output, err := runTool(ctx, ffmpeg,
+		"-nostdin", "-v", "error", "-i", video,
+		"-map", "0:"+strconv.Itoa(streamIndex), "-f", "webvtt", "pipe:1")
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg subtitle extraction failed: %w", err)
+	}
+	return output, nil
+}
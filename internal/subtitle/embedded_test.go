@@ -0,0 +1,75 @@
+package subtitle
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubTools(t *testing.T, available bool, output []byte, calls *[][]string) {
+	t.Helper()
+	previousLookup, previousRun := lookupTool, runTool
+	t.Cleanup(func() { lookupTool, runTool = previousLookup, previousRun })
+	lookupTool = func(name string) string {
+		if !available {
+			return ""
+		}
+		return "/opt/ffmpeg/" + name
+	}
+	runTool = func(_ context.Context, name string, args ...string) ([]byte, error) {
+		*calls = append(*calls, append([]string{name}, args...))
+		return output, nil
+	}
+}
+
+func TestProbeEmbeddedParsesSubtitleStreams(t *testing.T) {
+	var calls [][]string
+	stubTools(t, true, []byte(`{"streams":[
+		{"index":2,"codec_name":"ass","tags":{"language":"chi","title":"简日双语"},"disposition":{"default":1,"forced":0}},
+		{"index":3,"codec_name":"subrip","tags":{"language":"eng"},"disposition":{"default":0,"forced":1}},
+		{"index":4,"codec_name":"hdmv_pgs_subtitle","tags":{"language":"und"},"disposition":{}}
+	]}`), &calls)
+
+	tracks, err := ProbeEmbedded(context.Background(), "/media/show.mkv")
+	require.NoError(t, err)
+	require.Len(t, tracks, 3)
+	require.Len(t, calls, 1)
+	assert.Equal(t, "/opt/ffmpeg/ffprobe", calls[0][0])
+	assert.Equal(t, "/media/show.mkv", calls[0][len(calls[0])-1])
+
+	assert.Equal(t, "简日双语 (ASS)", tracks[0].Label)
+	assert.Equal(t, "zh", tracks[0].Language)
+	assert.True(t, tracks[0].Default)
+	assert.True(t, tracks[0].Playable())
+
+	assert.Equal(t, "英语 (SRT)", tracks[1].Label)
+	assert.True(t, tracks[1].Forced)
+
+	assert.Empty(t, tracks[2].Language)
+	assert.Equal(t, "内嵌字幕 #4 (HDMV_PGS_SUBTITLE)", tracks[2].Label)
+	assert.False(t, tracks[2].Playable(), "image subtitles cannot be converted to WebVTT")
+}
+
+func TestEmbeddedToolsReportUnavailable(t *testing.T) {
+	var calls [][]string
+	stubTools(t, false, nil, &calls)
+	_, err := ProbeEmbedded(context.Background(), "/media/show.mkv")
+	assert.True(t, errors.Is(err, ErrToolUnavailable))
+	_, err = ExtractWebVTT(context.Background(), "/media/show.mkv", 2)
+	assert.True(t, errors.Is(err, ErrToolUnavailable))
+	assert.Empty(t, calls)
+}
+
+func TestExtractWebVTTMapsRequestedStream(t *testing.T) {
+	var calls [][]string
+	stubTools(t, true, []byte("WEBVTT\n"), &calls)
+	body, err := ExtractWebVTT(context.Background(), "/media/show.mkv", 3)
+	require.NoError(t, err)
+	assert.Equal(t, "WEBVTT\n", string(body))
+	require.Len(t, calls, 1)
+	assert.Contains(t, calls[0], "0:3")
+	assert.Contains(t, calls[0], "webvtt")
+}
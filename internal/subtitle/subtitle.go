@@ -0,0 +1,149 @@
+// Package subtitle 发现本地剧集的外挂和内嵌字幕，并把常见文本字幕转换成浏览器可直接加载的 WebVTT。
+package subtitle
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	SourceExternal = "external"
+	SourceEmbedded = "embedded"
+
+	FormatASS = "ass"
+	FormatSSA = "ssa"
+	FormatSRT = "srt"
+	FormatVTT = "vtt"
+)
+
+// Track 描述一条可供播放器选择的字幕轨。外挂字幕使用 Path，内嵌字幕使用 StreamIndex。
+type Track struct {
+	Source      string `json:"source"`
+	Path        string `json:"path,omitempty"`
+	StreamIndex int    `json:"stream_index"`
+	Codec       string `json:"codec,omitempty"`
+	Format      string `json:"format"`
+	Language    string `json:"language"`
+	Label       string `json:"label"`
+	Default     bool   `json:"default"`
+	Forced      bool   `json:"forced"`
+}
+
+var sidecarFormats = map[string]string{
+	".ass": FormatASS,
+	".ssa": FormatSSA,
+	".srt": FormatSRT,
+	".vtt": FormatVTT,
+}
+
+type languageInfo struct {
+	code  string
+	label string
+}
+
+var languageAliases = map[string]languageInfo{}
+
+func init() {
+	register := func(code, label string, aliases ...string) {
+		for _, alias := range aliases {
+			languageAliases[alias] = languageInfo{code: code, label: label}
+		}
+	}
+	register("zh-Hans", "简体中文", "chs", "sc", "gb", "zhs", "zh-hans", "zh-cn", "zh_cn", "chi_sim", "simplified")
+	register("zh-Hant", "繁体中文", "cht", "tc", "big5", "zht", "zh-hant", "zh-tw", "zh_tw", "zh-hk", "chi_tra", "traditional")
+	register("zh-Hans-ja", "简日双语", "chs_jpn", "chs&jpn", "chs-jpn", "jpsc", "scjp", "sc_jp", "sc-jp")
+	register("zh-Hant-ja", "繁日双语", "cht_jpn", "cht&jpn", "cht-jpn", "jptc", "tcjp", "tc_jp", "tc-jp")
+	register("zh", "中文", "zh", "chi", "zho", "chn", "chinese")
+	register("ja", "日语", "ja", "jp", "jpn", "japanese")
+	register("en", "英语", "en", "eng", "english")
+	register("ko", "韩语", "ko", "kor", "korean")
+}
+
+// FormatForPath 返回外挂字幕文件的格式，不支持的扩展名返回空字符串。
+func FormatForPath(path string) string {
+	return sidecarFormats[strings.ToLower(filepath.Ext(path))]
+}
+
+// Language 把 chs、zh-TW、jpn 这类标记规范成 BCP 47 风格的代码和中文名称。
+// 无法识别的标记原样返回，便于用户在列表里辨认。
+func Language(tag string) (string, string) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", ""
+	}
+	if info, ok := languageAliases[tag]; ok {
+		return info.code, info.label
+	}
+	return tag, tag
+}
+
+// DiscoverSidecars 列出与视频同名（忽略大小写）的外挂字幕，从 "Show - 01.chs.default.ass"
+// 这类文件名中解析语言和 default/forced 标记。符号链接会被跳过。
+func DiscoverSidecars(video string) []Track {
+	directory := filepath.Dir(video)
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil
+	}
+	stem := strings.TrimSuffix(filepath.Base(video), filepath.Ext(video))
+	tracks := []Track{}
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Type()&os.ModeSymlink != 0 {
+			continue
+		}
+		name := entry.Name()
+		format := FormatForPath(name)
+		base := strings.TrimSuffix(name, filepath.Ext(name))
+		if format == "" || len(base) < len(stem) || !strings.EqualFold(base[:len(stem)], stem) {
+			continue
+		}
+		middle := base[len(stem):]
+		if middle != "" && middle[0] != '.' {
+			continue
+		}
+		track := Track{Source: SourceExternal, Path: filepath.Join(directory, name), Format: format}
+		applySidecarTags(&track, middle)
+		tracks = append(tracks, track)
+	}
+	sort.Slice(tracks, func(i, j int) bool { return tracks[i].Path < tracks[j].Path })
+	return tracks
+}
+
+func applySidecarTags(track *Track, middle string) {
+	for _, tag := range strings.Split(middle, ".") {
+		tag = strings.TrimSpace(tag)
+		switch strings.ToLower(tag) {
+		case "":
+			continue
+		case "default":
+			track.Default = true
+		case "forced":
+			track.Forced = true
+		case "sdh", "cc", "hi":
+			continue
+		default:
+			if track.Language == "" {
+				track.Language, track.Label = Language(tag)
+			}
+		}
+	}
+	track.Label = TrackLabel(track.Label, track.Format, track.Source)
+}
+
+// TrackLabel 生成字幕列表里展示的名称，例如 "简体中文 (ASS)"。
+func TrackLabel(base, format, source string) string {
+	base = strings.TrimSpace(base)
+	if base == "" {
+		if source == SourceEmbedded {
+			base = "内嵌字幕"
+		} else {
+			base = "外挂字幕"
+		}
+	}
+	if format == "" {
+		return base
+	}
+	return base + " (" + strings.ToUpper(format) + ")"
+}
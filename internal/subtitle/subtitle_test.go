@@ -0,0 +1,61 @@
+package subtitle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoverSidecarsParsesLanguageAndFlags(t *testing.T) {
+	dir := t.TempDir()
+	video := filepath.Join(dir, "Frieren - 01.mkv")
+	for _, name := range []string{
+		"Frieren - 01.mkv",
+		"Frieren - 01.chs.ass",
+		"frieren - 01.CHT.default.srt",
+		"Frieren - 01.vtt",
+		"Frieren - 01.jpn.forced.sdh.ssa",
+		"Frieren - 01.nfo",
+		"Frieren - 010.chs.ass",
+		"Frieren - 01x.ass",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644))
+	}
+
+	tracks := DiscoverSidecars(video)
+	require.Len(t, tracks, 4)
+	byName := map[string]Track{}
+	for _, track := range tracks {
+		assert.Equal(t, SourceExternal, track.Source)
+		byName[filepath.Base(track.Path)] = track
+	}
+
+	chs := byName["Frieren - 01.chs.ass"]
+	assert.Equal(t, "zh-Hans", chs.Language)
+	assert.Equal(t, "简体中文 (ASS)", chs.Label)
+	assert.Equal(t, FormatASS, chs.Format)
+
+	cht := byName["frieren - 01.CHT.default.srt"]
+	assert.Equal(t, "zh-Hant", cht.Language)
+	assert.True(t, cht.Default)
+	assert.Equal(t, FormatSRT, cht.Format)
+
+	plain := byName["Frieren - 01.vtt"]
+	assert.Empty(t, plain.Language)
+	assert.Equal(t, "外挂字幕 (VTT)", plain.Label)
+
+	jpn := byName["Frieren - 01.jpn.forced.sdh.ssa"]
+	assert.Equal(t, "ja", jpn.Language)
+	assert.True(t, jpn.Forced)
+}
+
+func TestLanguageNormalizesCommonTags(t *testing.T) {
+	cases := map[string]string{"SC": "zh-Hans", "zh-TW": "zh-Hant", "jpsc": "zh-Hans-ja", "eng": "en", "fr": "fr", "": ""}
+	for tag, want := range cases {
+		got, _ := Language(tag)
+		assert.Equal(t, want, got, tag)
+	}
+}
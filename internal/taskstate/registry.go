@@ -1,13 +1,18 @@
 package taskstate
 
 import (
+	"context"
+	"errors"
 	"log"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/pokerjest/animateAutoTool/internal/db"
 	"github.com/pokerjest/animateAutoTool/internal/event"
+	"github.com/pokerjest/animateAutoTool/internal/model"
+	"github.com/pokerjest/animateAutoTool/internal/store"
 )
 
 type Status string
@@ -17,18 +22,42 @@ const (
 	StatusRunning   Status = "running"
 	StatusCompleted Status = "completed"
 	StatusError     Status = "error"
+	StatusCancelled Status = "cancelled"
+)
+
+var (
+	ErrTaskNotFound       = errors.New("task not found")
+	ErrTaskFinished       = errors.New("task already finished")
+	ErrTaskNotCancellable = errors.New("task cannot be cancelled")
 )
 
 type Task struct {
-	TaskID    string    `json:"task_id"`
-	Kind      string    `json:"kind"`
-	Title     string    `json:"title"`
-	Status    Status    `json:"status"`
-	Phase     string    `json:"phase,omitempty"`
-	Message   string    `json:"message"`
-	Current   int64     `json:"current,omitempty"`
-	Total     int64     `json:"total,omitempty"`
-	UpdatedAt time.Time `json:"updated_at"`
+	TaskID          string     `json:"task_id"`
+	Kind            string     `json:"kind"`
+	Title           string     `json:"title"`
+	Status          Status     `json:"status"`
+	Phase           string     `json:"phase,omitempty"`
+	Message         string     `json:"message"`
+	Current         int64      `json:"current,omitempty"`
+	Total           int64      `json:"total,omitempty"`
+	UserID          uint       `json:"user_id,omitempty"`
+	Username        string     `json:"username,omitempty"`
+	Cancellable     bool       `json:"cancellable"`
+	CancelRequested bool       `json:"cancel_requested,omitempty"`
+	StartedAt       time.Time  `json:"started_at"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// Initiator identifies the user who started a task. The zero value marks
+// tasks started by the scheduler or another system process.
+type Initiator struct {
+	UserID   uint
+	Username string
+}
+
+func (t Task) finished() bool {
+	return t.Status == StatusCompleted || t.Status == StatusError || t.Status == StatusCancelled
 }
 
 // maxTrackedTasks caps retained task history. Active tasks are never evicted,
@@ -36,28 +65,69 @@ type Task struct {
 // tasks are queued or running.
 const maxTrackedTasks = 200
 
+// maxPersistedTasks caps the finished tasks kept in SQLite.
+const maxPersistedTasks = 1000
+
+const listLimit = 50
+
+// interruptedMessage is stored for tasks that were still running when the
+// previous process exited.
+const interruptedMessage = "服务重启前任务未完成，已中断"
+
+type cancelHandle struct {
+	cancel context.CancelFunc
+}
+
+// Registry tracks task progress in memory and writes each task to the
+// task_records table when it starts and when it finishes, so history
+// survives restarts while progress updates stay cheap.
 type Registry struct {
-	mu    sync.RWMutex
-	tasks map[string]Task
+	mu      sync.RWMutex
+	tasks   map[string]Task
+	cancels map[string]*cancelHandle
 }
 
 func NewRegistry() *Registry {
-	return &Registry{tasks: make(map[string]Task)}
+	return &Registry{tasks: make(map[string]Task), cancels: make(map[string]*cancelHandle)}
 }
 
 var Global = NewRegistry()
 
+func historyStore() *store.TaskRecordStore {
+	if db.DB == nil {
+		return nil
+	}
+	return store.NewTaskRecordStore(db.DB)
+}
+
 func (r *Registry) Start(taskID, kind, title, message string) Task {
-	return r.update(Task{TaskID: taskID, Kind: kind, Title: title, Status: StatusRunning, Message: message})
+	return r.StartBy(Initiator{}, taskID, kind, title, message)
+}
+
+// StartBy starts a task on behalf of initiator. Starting an ID that is
+// already tracked replaces the previous run.
+func (r *Registry) StartBy(initiator Initiator, taskID, kind, title, message string) Task {
+	task := r.update(Task{
+		TaskID:    taskID,
+		Kind:      kind,
+		Title:     title,
+		Status:    StatusRunning,
+		Message:   message,
+		UserID:    initiator.UserID,
+		Username:  initiator.Username,
+		StartedAt: time.Now().UTC(),
+	})
+	persist(task)
+	return task
 }
 
 func (r *Registry) Progress(taskID, message string, current, total int64) Task {
-	previous, _ := r.Get(taskID)
+	previous, _ := r.current(taskID)
 	return r.ProgressPhase(taskID, previous.Phase, message, current, total)
 }
 
 func (r *Registry) ProgressPhase(taskID, phase, message string, current, total int64) Task {
-	previous, _ := r.Get(taskID)
+	previous, _ := r.current(taskID)
 	previous.TaskID = taskID
 	previous.Status = StatusRunning
 	previous.Phase = strings.TrimSpace(phase)
@@ -68,62 +138,182 @@ func (r *Registry) ProgressPhase(taskID, phase, message string, current, total i
 }
 
 func (r *Registry) Complete(taskID, message string) Task {
-	previous, _ := r.Get(taskID)
+	previous, _ := r.current(taskID)
 	previous.TaskID = taskID
 	previous.Status = StatusCompleted
 	previous.Message = message
 	if previous.Total > 0 {
 		previous.Current = previous.Total
 	}
-	return r.update(previous)
+	return r.finish(previous)
 }
 
+// Fail records err as the task result. A task whose cancellation was
+// requested ends as cancelled instead; context.Canceled is reported with a
+// generic message while other errors keep their text so callers can
+// describe partial work.
 func (r *Registry) Fail(taskID string, err error) Task {
 	message := "任务执行失败"
 	if err != nil && strings.TrimSpace(err.Error()) != "" {
 		message = strings.TrimSpace(err.Error())
 	}
-	previous, _ := r.Get(taskID)
+	previous, _ := r.current(taskID)
 	previous.TaskID = taskID
 	previous.Status = StatusError
+	if previous.CancelRequested {
+		previous.Status = StatusCancelled
+		if err == nil || errors.Is(err, context.Canceled) {
+			message = "任务已取消"
+		}
+		log.Printf("Task cancelled: id=%s kind=%s title=%q message=%s", taskID, previous.Kind, previous.Title, message)
+	} else {
+		log.Printf("Task failed: id=%s kind=%s title=%q error=%s", taskID, previous.Kind, previous.Title, message)
+	}
 	previous.Message = message
-	log.Printf("Task failed: id=%s kind=%s title=%q error=%s", taskID, previous.Kind, previous.Title, message)
-	return r.update(previous)
+	return r.finish(previous)
 }
 
-func (r *Registry) Get(taskID string) (Task, bool) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+// WithCancel derives a context that Cancel(taskID) can stop and marks the
+// task as cancellable. Call the returned function once the task's work has
+// returned.
+func (r *Registry) WithCancel(parent context.Context, taskID string) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	handle := &cancelHandle{cancel: cancel}
+	r.mu.Lock()
+	r.cancels[taskID] = handle
 	task, ok := r.tasks[taskID]
-	return task, ok
+	r.mu.Unlock()
+	if ok && !task.finished() {
+		task.Cancellable = true
+		r.update(task)
+	}
+	return ctx, func() {
+		cancel()
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.cancels[taskID] == handle {
+			delete(r.cancels, taskID)
+		}
+	}
+}
+
+// Cancel asks a running task to stop. The task keeps running until its
+// work notices the canceled context and reports back through Fail.
+func (r *Registry) Cancel(taskID string) (Task, error) {
+	r.mu.Lock()
+	task, ok := r.tasks[taskID]
+	handle := r.cancels[taskID]
+	r.mu.Unlock()
+	if !ok {
+		if _, found := r.Get(taskID); found {
+			return Task{}, ErrTaskFinished
+		}
+		return Task{}, ErrTaskNotFound
+	}
+	if task.finished() {
+		return task, ErrTaskFinished
+	}
+	if handle == nil || !task.Cancellable {
+		return task, ErrTaskNotCancellable
+	}
+	if task.CancelRequested {
+		return task, nil
+	}
+	task.CancelRequested = true
+	task.Message = "正在取消任务"
+	task = r.update(task)
+	handle.cancel()
+	log.Printf("Task cancel requested: id=%s kind=%s title=%q", taskID, task.Kind, task.Title)
+	return task, nil
+}
+
+// Get returns the tracked task, falling back to persisted history for tasks
+// that finished before the last restart or were evicted from memory.
+func (r *Registry) Get(taskID string) (Task, bool) {
+	if task, ok := r.current(taskID); ok {
+		return task, true
+	}
+	history := historyStore()
+	if history == nil {
+		return Task{}, false
+	}
+	record, err := history.Get(taskID)
+	if err != nil {
+		return Task{}, false
+	}
+	return taskFromRecord(*record), true
 }
 
+// List returns the most recently updated tasks from memory and history.
 func (r *Registry) List() []Task {
 	r.mu.RLock()
 	items := make([]Task, 0, len(r.tasks))
+	seen := make(map[string]struct{}, len(r.tasks))
 	for _, task := range r.tasks {
 		items = append(items, task)
+		seen[task.TaskID] = struct{}{}
 	}
 	r.mu.RUnlock()
+	if history := historyStore(); history != nil {
+		records, err := history.ListRecent(listLimit)
+		if err != nil {
+			log.Printf("WARN: Task history unavailable: %v", err)
+		}
+		for _, record := range records {
+			if _, ok := seen[record.TaskID]; !ok {
+				items = append(items, taskFromRecord(record))
+			}
+		}
+	}
 	sort.Slice(items, func(i, j int) bool { return items[i].UpdatedAt.After(items[j].UpdatedAt) })
-	if len(items) > 50 {
-		items = items[:50]
+	if len(items) > listLimit {
+		items = items[:listLimit]
 	}
 	return items
 }
 
+// CloseInterrupted marks persisted tasks left unfinished by the previous
+// process as failed. Call it at startup before new tasks begin.
+func (r *Registry) CloseInterrupted() (int64, error) {
+	history := historyStore()
+	if history == nil {
+		return 0, nil
+	}
+	return history.CloseUnfinished(time.Now().UTC(), string(StatusError), interruptedMessage)
+}
+
 func (r *Registry) Reset() {
 	r.mu.Lock()
 	r.tasks = make(map[string]Task)
+	r.cancels = make(map[string]*cancelHandle)
 	r.mu.Unlock()
 }
 
+func (r *Registry) current(taskID string) (Task, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	task, ok := r.tasks[taskID]
+	return task, ok
+}
+
+func (r *Registry) finish(task Task) Task {
+	now := time.Now().UTC()
+	task.FinishedAt = &now
+	task.Cancellable = false
+	task = r.update(task)
+	persist(task)
+	return task
+}
+
 func (r *Registry) update(task Task) Task {
 	task.TaskID = strings.TrimSpace(task.TaskID)
 	if task.TaskID == "" {
 		return task
 	}
 	task.UpdatedAt = time.Now().UTC()
+	if task.StartedAt.IsZero() {
+		task.StartedAt = task.UpdatedAt
+	}
 	r.mu.Lock()
 	r.tasks[task.TaskID] = task
 	if len(r.tasks) > maxTrackedTasks {
@@ -136,14 +326,15 @@ func (r *Registry) update(task Task) Task {
 
 // evictOldestLocked removes the oldest terminal tasks until the registry is at
 // the requested size. Queued and running tasks must remain addressable by ID.
-// Callers must hold r.mu.
+// Evicted tasks stay readable through the persisted history. Callers must
+// hold r.mu.
 func (r *Registry) evictOldestLocked(keep int) {
 	if len(r.tasks) <= keep {
 		return
 	}
 	items := make([]Task, 0, len(r.tasks))
 	for _, task := range r.tasks {
-		if task.Status == StatusCompleted || task.Status == StatusError {
+		if task.finished() {
 			items = append(items, task)
 		}
 	}
@@ -156,3 +347,54 @@ func (r *Registry) evictOldestLocked(keep int) {
 		delete(r.tasks, task.TaskID)
 	}
 }
+
+// persist writes task to the history table. Failures are logged and never
+// interrupt the task itself.
+func persist(task Task) {
+	history := historyStore()
+	if history == nil || task.TaskID == "" {
+		return
+	}
+	record := model.TaskRecord{
+		TaskID:     task.TaskID,
+		Kind:       task.Kind,
+		Title:      task.Title,
+		Status:     string(task.Status),
+		Phase:      task.Phase,
+		Message:    task.Message,
+		Current:    task.Current,
+		Total:      task.Total,
+		UserID:     task.UserID,
+		Username:   task.Username,
+		StartedAt:  task.StartedAt,
+		FinishedAt: task.FinishedAt,
+		UpdatedAt:  task.UpdatedAt,
+	}
+	if err := history.Save(&record); err != nil {
+		log.Printf("WARN: Task history write failed: id=%s error=%v", task.TaskID, err)
+		return
+	}
+	if task.FinishedAt != nil {
+		if _, err := history.Prune(maxPersistedTasks); err != nil {
+			log.Printf("WARN: Task history prune failed: %v", err)
+		}
+	}
+}
+
+func taskFromRecord(record model.TaskRecord) Task {
+	return Task{
+		TaskID:     record.TaskID,
+		Kind:       record.Kind,
+		Title:      record.Title,
+		Status:     Status(record.Status),
+		Phase:      record.Phase,
+		Message:    record.Message,
+		Current:    record.Current,
+		Total:      record.Total,
+		UserID:     record.UserID,
+		Username:   record.Username,
+		StartedAt:  record.StartedAt,
+		FinishedAt: record.FinishedAt,
+		UpdatedAt:  record.UpdatedAt,
+	}
+}
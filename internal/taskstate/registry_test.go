@@ -1,11 +1,13 @@
 package taskstate
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
 	"time"
 
+	"github.com/pokerjest/animateAutoTool/internal/db"
 	"github.com/pokerjest/animateAutoTool/internal/event"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -73,3 +75,75 @@ func TestRegistryEvictionPreservesRunningTasks(t *testing.T) {
 	assert.Equal(t, "长时间备份", updated.Title)
 	assert.LessOrEqual(t, len(registry.tasks), maxTrackedTasks)
 }
+
+func TestRegistryCancelStopsBoundContextAndEndsTaskCancelled(t *testing.T) {
+	registry := NewRegistry()
+	registry.Start("organize-1", "organize", "整理本地番剧", "正在整理")
+
+	_, err := registry.Cancel("organize-1")
+	assert.ErrorIs(t, err, ErrTaskNotCancellable)
+	_, err = registry.Cancel("missing")
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+
+	ctx, release := registry.WithCancel(context.Background(), "organize-1")
+	defer release()
+	task, _ := registry.Get("organize-1")
+	assert.True(t, task.Cancellable)
+
+	requested, err := registry.Cancel("organize-1")
+	require.NoError(t, err)
+	assert.True(t, requested.CancelRequested)
+	assert.Equal(t, StatusRunning, requested.Status)
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("task context was not canceled")
+	}
+
+	cancelled := registry.Fail("organize-1", ctx.Err())
+	assert.Equal(t, StatusCancelled, cancelled.Status)
+	assert.Equal(t, "任务已取消", cancelled.Message)
+	assert.False(t, cancelled.Cancellable)
+	require.NotNil(t, cancelled.FinishedAt)
+
+	_, err = registry.Cancel("organize-1")
+	assert.ErrorIs(t, err, ErrTaskFinished)
+}
+
+func TestRegistryPersistsHistoryAcrossRestarts(t *testing.T) {
+	db.InitDB(":memory:")
+	t.Cleanup(func() {
+		_ = db.CloseDB()
+		db.DB = nil
+	})
+
+	registry := NewRegistry()
+	admin := Initiator{UserID: 7, Username: "admin"}
+	registry.StartBy(admin, "backup-1", "backup", "R2 云备份", "正在准备")
+	registry.Complete("backup-1", "云备份任务完成")
+	registry.StartBy(admin, "scan-1", "scan", "本地扫描", "正在扫描")
+
+	// A fresh registry stands in for the restarted process.
+	restarted := NewRegistry()
+	interrupted, err := restarted.CloseInterrupted()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), interrupted)
+
+	completed, ok := restarted.Get("backup-1")
+	require.True(t, ok)
+	assert.Equal(t, StatusCompleted, completed.Status)
+	assert.Equal(t, "云备份任务完成", completed.Message)
+	assert.Equal(t, "admin", completed.Username)
+	assert.Equal(t, uint(7), completed.UserID)
+	require.NotNil(t, completed.FinishedAt)
+	assert.False(t, completed.FinishedAt.Before(completed.StartedAt))
+
+	scan, ok := restarted.Get("scan-1")
+	require.True(t, ok)
+	assert.Equal(t, StatusError, scan.Status)
+	assert.Equal(t, interruptedMessage, scan.Message)
+	assert.Len(t, restarted.List(), 2)
+
+	_, err = restarted.Cancel("scan-1")
+	assert.ErrorIs(t, err, ErrTaskFinished)
+}
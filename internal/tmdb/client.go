@@ -29,6 +29,7 @@ func NewClient(token string, proxyURL string) *Client {
 	if token != "" {
 		c.SetHeader("Authorization", "Bearer "+token)
 	}
+	httpx.ObserveMetadataProvider(c, "tmdb")
 
 	return &Client{
 		client: c,
@@ -16,6 +16,7 @@ import (
 
 	"github.com/pokerjest/animateAutoTool/internal/config"
 	"github.com/pokerjest/animateAutoTool/internal/db"
+	"github.com/pokerjest/animateAutoTool/internal/event"
 	"github.com/pokerjest/animateAutoTool/internal/httpx"
 	"github.com/pokerjest/animateAutoTool/internal/model"
 	"github.com/pokerjest/animateAutoTool/internal/safeio"
@@ -511,6 +512,16 @@ func (m *Manager) runCheckInternal(source string, applyWhenBehind, alreadyStarte
 				time.Since(startedAt).Round(time.Millisecond),
 			)
 		}
+		event.GlobalBus.Publish(event.EventUpdaterStatus, map[string]interface{}{
+			"source":      source,
+			"result":      result,
+			"message":     message,
+			"error":       strings.TrimSpace(errText),
+			"current":     current,
+			"latest":      latest,
+			"has_update":  hasUpdate,
+			"release_url": releaseURL,
+		})
 		return m.status
 	}
 
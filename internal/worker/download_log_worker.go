@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log"
 	"net/url"
+	"os"
 	"path/filepath"
 	"runtime/debug"
 	"sort"
@@ -15,7 +16,6 @@ import (
 
 	"github.com/pokerjest/animateAutoTool/internal/config"
 	"github.com/pokerjest/animateAutoTool/internal/db"
-	"github.com/pokerjest/animateAutoTool/internal/downloader"
 	"github.com/pokerjest/animateAutoTool/internal/event"
 	"github.com/pokerjest/animateAutoTool/internal/model"
 	"github.com/pokerjest/animateAutoTool/internal/qbutil"
@@ -97,7 +97,7 @@ func syncDownloadLogStatuses(ctx context.Context) {
 		return
 	}
 
-	client := downloader.NewQBittorrentClient(qbCfg.URL)
+	client := qbutil.NewClient(qbCfg)
 	if err := client.LoginContext(ctx, qbCfg.Username, qbCfg.Password); err != nil {
 		log.Printf("ERROR: DownloadLogWorker: qBittorrent login failed recovery_action=retry_next_cycle error=%v", err)
 		return
@@ -265,7 +265,13 @@ func (c *completedDownloadRescanCoordinator) flush() {
 	c.mu.Unlock()
 }
 
-var completedDownloadRescan = newCompletedDownloadRescanCoordinator(downloadLogSyncInterval, runCompletedDownloadPostProcessing)
+var completedDownloadRescan *completedDownloadRescanCoordinator
+
+func init() {
+	// Assigned in init because the post-processing run re-queues targets that
+	// are still being written into the same coordinator.
+	completedDownloadRescan = newCompletedDownloadRescanCoordinator(downloadLogSyncInterval, runCompletedDownloadPostProcessing)
+}
 
 func runCompletedDownloadPostProcessing(ctx context.Context, targets []string, initialIDs []uint) {
 	start := time.Now()
@@ -285,6 +291,10 @@ func runCompletedDownloadPostProcessing(ctx context.Context, targets []string, i
 	}()
 	delayedIDs := autoScanCompletedDownloads(targets)
 	affected := mergeAnimeIDs(initialIDs, delayedIDs)
+	if pending := targetsStillWriting(targets); len(pending) > 0 {
+		log.Printf("DownloadLogWorker: rescan deferred reason=aria2_control_file targets=%d", len(pending))
+		completedDownloadRescan.schedule(ctx, pending, nil)
+	}
 	if _, err := service.ReconcileSubscriptionResourcesFromDownloadLogs(); err != nil {
 		log.Printf("ERROR: DownloadLogWorker: resource reconciliation after delayed scan failed recovery_action=continue_to_jellyfin error=%v", err)
 	}
@@ -399,7 +409,7 @@ func autoScanCompletedDownloads(targets []string) []uint {
 	scanTargets := make(map[uint][]string)
 	for _, target := range targets {
 		target = strings.TrimSpace(target)
-		if target == "" {
+		if target == "" || downloadStillWriting(target) {
 			continue
 		}
 		var selected *model.LocalAnimeDirectory
@@ -446,6 +456,30 @@ func autoScanCompletedDownloads(targets []string) []uint {
 	return sortedUintIDs(affected)
 }
 
+// aria2 keeps "<file>.aria2" next to a download until every byte is on disk,
+// and may report completion a moment before removing it. A control file that
+// has not been touched for a long time belongs to an abandoned download and
+// no longer delays scanning.
+const aria2ControlFileStaleAfter = time.Hour
+
+func downloadStillWriting(target string) bool {
+	info, err := os.Stat(target + ".aria2")
+	if err != nil {
+		return false
+	}
+	return time.Since(info.ModTime()) < aria2ControlFileStaleAfter
+}
+
+func targetsStillWriting(targets []string) []string {
+	var pending []string
+	for _, target := range targets {
+		if target = strings.TrimSpace(target); target != "" && downloadStillWriting(target) {
+			pending = append(pending, target)
+		}
+	}
+	return pending
+}
+
 func pathWithinRoot(path string, root string) bool {
 	path = filepath.Clean(path)
 	root = filepath.Clean(root)
@@ -39,6 +39,27 @@ func TestPathWithinRoot(t *testing.T) {
 	}
 }
 
+func TestTargetsStillWritingHonoursFreshAria2ControlFile(t *testing.T) {
+	dir := t.TempDir()
+	writing := filepath.Join(dir, "writing.mkv")
+	done := filepath.Join(dir, "done.mkv")
+	abandoned := filepath.Join(dir, "abandoned.mkv")
+	for _, path := range []string{writing, done, abandoned, writing + ".aria2", abandoned + ".aria2"} {
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	stale := time.Now().Add(-2 * aria2ControlFileStaleAfter)
+	if err := os.Chtimes(abandoned+".aria2", stale, stale); err != nil {
+		t.Fatalf("age control file: %v", err)
+	}
+
+	pending := targetsStillWriting([]string{writing, done, abandoned, " "})
+	if len(pending) != 1 || pending[0] != writing {
+		t.Fatalf("targetsStillWriting() = %v, want only %s", pending, writing)
+	}
+}
+
 func TestAutoScanCompletedDownloadsEarlyReturns(t *testing.T) {
 	// Empty targets is a no-op even when DB is nil.
 	prev := db.DB
@@ -29,6 +29,7 @@ export interface paths {
         };
         get?: never;
         put?: never;
+        /** @description Verifies the password. For accounts with two-factor login the response carries data.two_factor_required=true and the cookie only holds a five-minute pending state; finish with /session/login/2fa. */
         post: operations["login"];
         delete?: never;
         options?: never;
@@ -36,6 +37,23 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
+    "/session/login/2fa": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        /** @description Completes a pending login with a TOTP code or an unused recovery code. Wrong codes count towards the same per-IP lockout as wrong passwords. Errors are "two_factor_expired" (no pending login) and "invalid_two_factor_code". */
+        post: operations["loginSecondFactor"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
     "/session/bootstrap": {
         parameters: {
             query?: never;
@@ -102,6 +120,91 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
+    "/session/2fa": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        /** @description Returns enabled, enabled_at, pending and recovery_codes_remaining for the current account. */
+        get: operations["getTwoFactorStatus"];
+        put?: never;
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/session/2fa/enroll": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        /** @description Generates a pending TOTP secret and returns it with its otpauth:// URI. Nothing changes at login until /session/2fa/confirm succeeds. */
+        post: operations["enrollTwoFactor"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/session/2fa/confirm": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        /** @description Enables two-factor login with a code from the pending secret and returns ten one-time recovery codes in data.recovery_codes. Only their hashes are stored. */
+        post: operations["confirmTwoFactor"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/session/2fa/recovery-codes": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        /** @description Replaces every recovery code after checking the current password. */
+        post: operations["regenerateRecoveryCodes"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/session/2fa/disable": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        /** @description Turns two-factor login off after checking the current password. */
+        post: operations["disableTwoFactor"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
     "/recovery/reset": {
         parameters: {
             query?: never;
@@ -111,6 +214,7 @@ export interface paths {
         };
         get?: never;
         put?: never;
+        /** @description Local-only password reset for an administrator. It also turns two-factor login off and reports data.two_factor_cleared. */
         post: operations["recoverPassword"];
         delete?: never;
         options?: never;
@@ -214,6 +318,23 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
+    "/tasks/{task_id}/cancel": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        /** @description Ask a running task to stop. Admins may cancel any task; members only tasks they started. The task ends as cancelled once its work notices the request. */
+        post: operations["cancelTask"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
     "/tasks/sync": {
         parameters: {
             query?: never;
@@ -246,6 +367,22 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
+    "/events/history": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get: operations["listEventHistory"];
+        put?: never;
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
     "/subscriptions": {
         parameters: {
             query?: never;
@@ -311,6 +448,118 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
+    "/subscriptions/export": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get: operations["exportSubscriptions"];
+        put?: never;
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/subscriptions/import": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        post: operations["importSubscriptions"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/subscriptions/import/{source}": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        post: operations["previewSourceSubscriptions"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/subscriptions/bangumi-sync": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get: operations["getBangumiSync"];
+        put?: never;
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/subscriptions/bangumi-sync/run": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        post: operations["runBangumiSync"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/subscriptions/bangumi-sync/{id}/accept": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        post: operations["acceptBangumiSyncProposal"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/subscriptions/bangumi-sync/{id}/dismiss": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        post: operations["dismissBangumiSyncRecord"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
     "/subscriptions/validate-rss": {
         parameters: {
             query?: never;
@@ -975,14 +1224,15 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
-    "/jellyfin/play/{id}": {
+    "/local-anime/episodes/{id}/play": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        get: operations["getJellyfinPlayInfo"];
+        /** @description Direct-play info for a scanned episode when Jellyfin is not configured. Resume position comes from the local playback history. */
+        get: operations["getLocalEpisodePlayInfo"];
         put?: never;
         post?: never;
         delete?: never;
@@ -991,15 +1241,15 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
-    "/jellyfin/stream/{id}": {
+    "/local-anime/episodes/{id}/stream": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        /** @description Proxies a Jellyfin static stream while preserving Range requests. */
-        get: operations["streamJellyfinEpisode"];
+        /** @description Serves the scanned episode file from disk with Range support. Paths that resolve outside the registered local directories are rejected. */
+        get: operations["streamLocalEpisode"];
         put?: never;
         post?: never;
         delete?: never;
@@ -1008,18 +1258,15 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
-    "/netbird/jellyfin/stream/{id}": {
+    "/local-anime/episodes/{id}/subtitles": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        /**
-         * @deprecated
-         * @description Deprecated compatibility endpoint. The current frontend no longer generates or selects the NetBird playback route.
-         */
-        get: operations["streamJellyfinEpisodeThroughNetBird"];
+        /** @description Lists indexed sidecar subtitles and, when ffprobe is available, embedded subtitle streams. */
+        get: operations["listLocalEpisodeSubtitles"];
         put?: never;
         post?: never;
         delete?: never;
@@ -1028,18 +1275,15 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
-    "/netbird/media/{provider}/stream/{item_id}": {
+    "/local-anime/episodes/{id}/subtitles/{track}": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        /**
-         * @deprecated
-         * @description Deprecated compatibility endpoint. The current frontend no longer generates or selects the NetBird playback route.
-         */
-        get: operations["streamMediaItemThroughNetBird"];
+        /** @description Serves one subtitle track as WebVTT. SRT and ASS sidecars are converted on the fly; embedded text streams are extracted with ffmpeg. */
+        get: operations["getLocalEpisodeSubtitle"];
         put?: never;
         post?: never;
         delete?: never;
@@ -1048,14 +1292,14 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
-    "/media/providers": {
+    "/jellyfin/play/{id}": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        get: operations["listMediaProviders"];
+        get: operations["getJellyfinPlayInfo"];
         put?: never;
         post?: never;
         delete?: never;
@@ -1064,14 +1308,15 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
-    "/media/providers/{provider}/libraries": {
+    "/jellyfin/stream/{id}": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        get: operations["listMediaLibraries"];
+        /** @description Proxies a Jellyfin static stream while preserving Range requests. */
+        get: operations["streamJellyfinEpisode"];
         put?: never;
         post?: never;
         delete?: never;
@@ -1080,14 +1325,18 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
-    "/media/providers/{provider}/items": {
+    "/netbird/jellyfin/stream/{id}": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        get: operations["listMediaItems"];
+        /**
+         * @deprecated
+         * @description Deprecated compatibility endpoint. The current frontend no longer generates or selects the NetBird playback route.
+         */
+        get: operations["streamJellyfinEpisodeThroughNetBird"];
         put?: never;
         post?: never;
         delete?: never;
@@ -1096,14 +1345,18 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
-    "/media/providers/{provider}/continue": {
+    "/netbird/media/{provider}/stream/{item_id}": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        get: operations["listContinueWatchingMedia"];
+        /**
+         * @deprecated
+         * @description Deprecated compatibility endpoint. The current frontend no longer generates or selects the NetBird playback route.
+         */
+        get: operations["streamMediaItemThroughNetBird"];
         put?: never;
         post?: never;
         delete?: never;
@@ -1112,14 +1365,78 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
-    "/media/providers/{provider}/items/{item_id}": {
+    "/media/providers": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        get: operations["getMediaItem"];
+        get: operations["listMediaProviders"];
+        put?: never;
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/media/providers/{provider}/libraries": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get: operations["listMediaLibraries"];
+        put?: never;
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/media/providers/{provider}/items": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get: operations["listMediaItems"];
+        put?: never;
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/media/providers/{provider}/continue": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get: operations["listContinueWatchingMedia"];
+        put?: never;
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/media/providers/{provider}/items/{item_id}": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get: operations["getMediaItem"];
         put?: never;
         post?: never;
         delete?: never;
@@ -1338,6 +1655,38 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
+    "/anilist/media/{id}/entry": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        post: operations["saveAniListEntry"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/anilist/sync": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        post: operations["runAniListSync"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
     "/diagnostics/logs/export": {
         parameters: {
             query?: never;
@@ -1443,6 +1792,7 @@ export interface paths {
             path?: never;
             cookie?: never;
         };
+        /** @description Lists animate_backup_* archives in the configured offsite destination (backup_destination: r2, s3, webdav or sftp). The /backup/r2/* paths predate the other destinations and serve all of them; data.destination names the one in use. */
         get: operations["listR2Backups"];
         put?: never;
         post?: never;
@@ -1525,6 +1875,7 @@ export interface paths {
         };
         get?: never;
         put?: never;
+        /** @description Checks list, write and delete access to a backup destination with a throwaway object. destination picks r2, s3, webdav or sftp (default: the saved backup_destination); values overrides the settings of that destination by key, and blank or masked values fall back to the saved ones. The legacy endpoint/access_key/secret_key/bucket fields still test R2. An SFTP server without a pinned sftp_host_fingerprint is refused and the error reports its SHA256 fingerprint. */
         post: operations["testR2"];
         delete?: never;
         options?: never;
@@ -1532,6 +1883,40 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
+    "/backup/schedule": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        /** @description Reports the parsed backup_schedule_* and backup_retention_* settings, the next run time, any settings problem and the last scheduled or manual run, including which archives each target pruned and which expired archives it kept because their settings key was rotated away. The archive password is never returned. */
+        get: operations["getBackupSchedule"];
+        put?: never;
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/backup/schedule/run": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        /** @description Runs the configured backup schedule once in the background, even when the schedule is disabled. Progress is published as a backup task. Returns 400 with invalid_backup_schedule when the settings are incomplete and 409 with backup_schedule_running while another run is in progress. */
+        post: operations["runBackupSchedule"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
     "/health": {
         parameters: {
             query?: never;
@@ -1580,23 +1965,25 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
-    "/settings": {
+    "/users": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        get: operations["getSettings"];
-        put: operations["updateSettings"];
-        post?: never;
+        /** @description Admin only. Lists accounts with their effective role. */
+        get: operations["listUsers"];
+        put?: never;
+        /** @description Admin only. Requires username, password (at least 8 characters) and role. */
+        post: operations["createUser"];
         delete?: never;
         options?: never;
         head?: never;
         patch?: never;
         trace?: never;
     };
-    "/settings/proxy/test": {
+    "/users/{id}": {
         parameters: {
             query?: never;
             header?: never;
@@ -1604,56 +1991,60 @@ export interface paths {
             cookie?: never;
         };
         get?: never;
-        put?: never;
-        post: operations["testProxy"];
-        delete?: never;
+        /** @description Admin only. Omitted fields are unchanged. The last admin cannot be demoted. */
+        put: operations["updateUser"];
+        post?: never;
+        /** @description Admin only. Removes the account and its playback history. The caller's own account and the last admin cannot be deleted. */
+        delete: operations["deleteUser"];
         options?: never;
         head?: never;
         patch?: never;
         trace?: never;
     };
-    "/settings/connections/{provider}": {
+    "/tokens": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        get: operations["getConnectionStatus"];
+        /** @description Lists the caller's API tokens, including revoked ones. Secrets are never returned. */
+        get: operations["listAPITokens"];
         put?: never;
-        post?: never;
+        /** @description Issues a token for the caller. The secret is returned once in data.token; only its hash is stored. */
+        post: operations["createAPIToken"];
         delete?: never;
         options?: never;
         head?: never;
         patch?: never;
         trace?: never;
     };
-    "/settings/maintenance": {
+    "/tokens/{id}": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        get: operations["getMaintenance"];
+        get?: never;
         put?: never;
         post?: never;
-        delete?: never;
+        /** @description Revokes one of the caller's tokens; admins may revoke any token. */
+        delete: operations["revokeAPIToken"];
         options?: never;
         head?: never;
         patch?: never;
         trace?: never;
     };
-    "/settings/updater/releases": {
+    "/settings": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        /** @description Lists published GitHub releases for the selected manual update channel. Stable excludes prereleases; beta includes both stable and prerelease builds. */
-        get: operations["listUpdaterReleases"];
-        put?: never;
+        get: operations["getSettings"];
+        put: operations["updateSettings"];
         post?: never;
         delete?: never;
         options?: never;
@@ -1661,15 +2052,15 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
-    "/settings/updater/snapshots": {
+    "/settings/secrets": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        /** @description Lists local update snapshots without exposing filesystem paths. */
-        get: operations["listUpdaterSnapshots"];
+        /** @description Reports the current settings encryption key id, the retained key ids and how many stored credentials are encrypted, still plaintext or unreadable with the local key file. Never returns credential values. */
+        get: operations["getSettingsSecretsStatus"];
         put?: never;
         post?: never;
         delete?: never;
@@ -1678,7 +2069,7 @@ export interface paths {
         patch?: never;
         trace?: never;
     };
-    "/settings/updater/rollback": {
+    "/settings/secrets/rotate": {
         parameters: {
             query?: never;
             header?: never;
@@ -1687,15 +2078,15 @@ export interface paths {
         };
         get?: never;
         put?: never;
-        /** @description Restores a locally stored database and configuration snapshot. If a previous binary is available, the service exits and a local helper replaces the executable before readiness-checking the restored version. */
-        post: operations["restoreUpdaterSnapshot"];
+        /** @description Generates a new settings encryption key, re-encrypts every stored credential and the config.yaml mirror with it, and keeps the previous key so recent full backups still restore. On failure the old key stays in place. */
+        post: operations["rotateSettingsKey"];
         delete?: never;
         options?: never;
         head?: never;
         patch?: never;
         trace?: never;
     };
-    "/settings/updater/{action}": {
+    "/settings/proxy/test": {
         parameters: {
             query?: never;
             header?: never;
@@ -1704,86 +2095,81 @@ export interface paths {
         };
         get?: never;
         put?: never;
-        /** @description Checks for an update or applies one. When action is apply, version can pin a published release; the server re-fetches that release and never trusts a client-supplied asset URL. */
-        post: operations["runUpdaterAction"];
+        post: operations["testProxy"];
         delete?: never;
         options?: never;
         head?: never;
         patch?: never;
         trace?: never;
     };
-    "/settings/ai": {
+    "/settings/downloader/path-mappings/test": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        get: operations["getAiSettings"];
+        get?: never;
         put?: never;
-        post?: never;
+        /** @description Maps one downloader task (the given hash, or the first task) through the mappings in the body, or the saved downloader_path_mappings when omitted, and reports the local paths, the matched rule and whether they exist. */
+        post: operations["testDownloaderPathMappings"];
         delete?: never;
         options?: never;
         head?: never;
         patch?: never;
         trace?: never;
     };
-    "/settings/ai/models": {
+    "/settings/notifications": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        /**
-         * @deprecated
-         * @description Compatibility endpoint. New clients should send provider settings in the POST request body.
-         */
-        get: operations["getAiModels"];
+        /** @description Lists notification channels with credentials masked, plus the supported channel types and events. */
+        get: operations["listNotificationChannels"];
         put?: never;
-        /** @description Lists models using the unsaved provider form. If the provider is rate limited, the response can remain 200 with source=cache/fallback/configured, a warning, and a selectable cached or official fallback catalog. */
-        post: operations["listAiModels"];
+        post: operations["createNotificationChannel"];
         delete?: never;
         options?: never;
         head?: never;
         patch?: never;
         trace?: never;
     };
-    "/settings/ai/test": {
+    "/settings/notifications/deliveries": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        get?: never;
+        get: operations["listNotificationDeliveries"];
         put?: never;
-        /** @description Sends a real minimal user message containing "hi" to the selected provider without loading AnimateTool tools. */
-        post: operations["testAiConnection"];
+        post?: never;
         delete?: never;
         options?: never;
         head?: never;
         patch?: never;
         trace?: never;
     };
-    "/assistant/messages": {
+    "/settings/notifications/{id}": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        /** @description Returns only the current user's displayable user messages and final assistant replies. System prompts, tool calls and tool results are excluded. */
-        get: operations["getAssistantMessages"];
-        put?: never;
-        post: operations["sendAssistantMessage"];
-        delete: operations["clearAssistantMessages"];
+        get?: never;
+        /** @description Blank token or password keeps the stored credential. */
+        put: operations["updateNotificationChannel"];
+        post?: never;
+        delete: operations["deleteNotificationChannel"];
         options?: never;
         head?: never;
         patch?: never;
         trace?: never;
     };
-    "/ai/filename-resolutions": {
+    "/settings/notifications/{id}/test": {
         parameters: {
             query?: never;
             header?: never;
@@ -1792,94 +2178,98 @@ export interface paths {
         };
         get?: never;
         put?: never;
-        post: operations["requestAiFilenameResolution"];
+        /** @description Sends a test message immediately without retries and records it in the delivery history. */
+        post: operations["testNotificationChannel"];
         delete?: never;
         options?: never;
         head?: never;
         patch?: never;
         trace?: never;
     };
-    "/ai/health/analyze": {
+    "/settings/connections/{provider}": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        get?: never;
+        get: operations["getConnectionStatus"];
         put?: never;
-        post: operations["requestAiHealthAnalysis"];
+        post?: never;
         delete?: never;
         options?: never;
         head?: never;
         patch?: never;
         trace?: never;
     };
-    "/ai/library-issues/{id}/analyze": {
+    "/settings/maintenance": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        get?: never;
+        get: operations["getMaintenance"];
         put?: never;
-        post: operations["requestAiLibraryIssueAnalysis"];
+        post?: never;
         delete?: never;
         options?: never;
         head?: never;
         patch?: never;
         trace?: never;
     };
-    "/ai/metadata/local-anime/{id}/suggest": {
+    "/settings/updater/releases": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        get?: never;
+        /** @description Lists published GitHub releases for the selected manual update channel. Stable excludes prereleases; beta includes both stable and prerelease builds. */
+        get: operations["listUpdaterReleases"];
         put?: never;
-        post: operations["requestAiMetadataSuggestion"];
+        post?: never;
         delete?: never;
         options?: never;
         head?: never;
         patch?: never;
         trace?: never;
     };
-    "/ai/subscriptions/{id}/rules/suggest": {
+    "/settings/updater/snapshots": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        get?: never;
+        /** @description Lists local update snapshots without exposing filesystem paths. */
+        get: operations["listUpdaterSnapshots"];
         put?: never;
-        post: operations["requestAiSubscriptionRuleSuggestion"];
+        post?: never;
         delete?: never;
         options?: never;
         head?: never;
         patch?: never;
         trace?: never;
     };
-    "/ai/proposals/{id}": {
+    "/settings/updater/rollback": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        get: operations["getAiProposal"];
+        get?: never;
         put?: never;
-        post?: never;
+        /** @description Restores a locally stored database and configuration snapshot. If a previous binary is available, the service exits and a local helper replaces the executable before readiness-checking the restored version. */
+        post: operations["restoreUpdaterSnapshot"];
         delete?: never;
         options?: never;
         head?: never;
         patch?: never;
         trace?: never;
     };
-    "/ai/proposals/{id}/confirm": {
+    "/settings/updater/{action}": {
         parameters: {
             query?: never;
             header?: never;
@@ -1888,73 +2278,257 @@ export interface paths {
         };
         get?: never;
         put?: never;
-        post: operations["confirmAiProposal"];
+        /** @description Checks for an update or applies one. When action is apply, version can pin a published release; the server re-fetches that release and never trusts a client-supplied asset URL. */
+        post: operations["runUpdaterAction"];
         delete?: never;
         options?: never;
         head?: never;
         patch?: never;
         trace?: never;
     };
-    "/ai/proposals/{id}/apply": {
+    "/settings/ai": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        get?: never;
+        get: operations["getAiSettings"];
         put?: never;
-        post: operations["applyAiProposal"];
+        post?: never;
         delete?: never;
         options?: never;
         head?: never;
         patch?: never;
         trace?: never;
     };
-    "/ai/proposals/{id}/dismiss": {
+    "/settings/ai/models": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        get?: never;
+        /**
+         * @deprecated
+         * @description Compatibility endpoint. New clients should send provider settings in the POST request body.
+         */
+        get: operations["getAiModels"];
         put?: never;
-        post: operations["dismissAiProposal"];
+        /** @description Lists models using the unsaved provider form. If the provider is rate limited, the response can remain 200 with source=cache/fallback/configured, a warning, and a selectable cached or official fallback catalog. */
+        post: operations["listAiModels"];
         delete?: never;
         options?: never;
         head?: never;
         patch?: never;
         trace?: never;
     };
-    "/ai/tool-runs": {
+    "/settings/ai/test": {
         parameters: {
             query?: never;
             header?: never;
             path?: never;
             cookie?: never;
         };
-        get: operations["listAiToolRuns"];
+        get?: never;
         put?: never;
-        post?: never;
+        /** @description Sends a real minimal user message containing "hi" to the selected provider without loading AnimateTool tools. */
+        post: operations["testAiConnection"];
         delete?: never;
         options?: never;
         head?: never;
         patch?: never;
         trace?: never;
     };
-}
-export type webhooks = Record<string, never>;
-export interface components {
-    schemas: {
-        HealthReport: {
-            /** Format: date-time */
-            generated_at: string;
-            configs: {
-                [key: string]: boolean;
-            };
-            /** Format: int64 */
-            subscription_total: number;
+    "/assistant/messages": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        /** @description Returns only the current user's displayable user messages and final assistant replies. System prompts, tool calls and tool results are excluded. */
+        get: operations["getAssistantMessages"];
+        put?: never;
+        post: operations["sendAssistantMessage"];
+        delete: operations["clearAssistantMessages"];
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/ai/filename-resolutions": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        post: operations["requestAiFilenameResolution"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/ai/health/analyze": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        post: operations["requestAiHealthAnalysis"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/ai/library-issues/{id}/analyze": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        post: operations["requestAiLibraryIssueAnalysis"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/ai/metadata/local-anime/{id}/suggest": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        post: operations["requestAiMetadataSuggestion"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/ai/subscriptions/{id}/rules/suggest": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        post: operations["requestAiSubscriptionRuleSuggestion"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/ai/proposals/{id}": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get: operations["getAiProposal"];
+        put?: never;
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/ai/proposals/{id}/confirm": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        post: operations["confirmAiProposal"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/ai/proposals/{id}/apply": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        post: operations["applyAiProposal"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/ai/proposals/{id}/dismiss": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get?: never;
+        put?: never;
+        post: operations["dismissAiProposal"];
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+    "/ai/tool-runs": {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        get: operations["listAiToolRuns"];
+        put?: never;
+        post?: never;
+        delete?: never;
+        options?: never;
+        head?: never;
+        patch?: never;
+        trace?: never;
+    };
+}
+export type webhooks = Record<string, never>;
+export interface components {
+    schemas: {
+        HealthReport: {
+            /** Format: date-time */
+            generated_at: string;
+            configs: {
+                [key: string]: boolean;
+            };
+            /** Format: int64 */
+            subscription_total: number;
             /** Format: int64 */
             subscription_active: number;
             /** Format: int64 */
@@ -1985,6 +2559,13 @@ export interface components {
             subscriptions_pending_sync: number;
             /** Format: int64 */
             stale_subscriptions_72h: number;
+            backup_schedule_enabled?: boolean;
+            /** @enum {string} */
+            backup_schedule_last_status?: "success" | "partial" | "failure";
+            /** Format: date-time */
+            backup_schedule_last_run_at?: string;
+            /** @description Why the last scheduled backup did not fully succeed, or the settings problem that blocks the enabled schedule. */
+            backup_schedule_problem?: string;
             /** @enum {string} */
             health_tone: "emerald" | "amber" | "rose";
             summary: string;
@@ -1997,14 +2578,35 @@ export interface components {
             /** @description True only when this request directly targets localhost from the loopback interface without forwarded headers. */
             local_recovery_available: boolean;
             username?: string;
+            /**
+             * @description Role of the signed-in account. IP-allowlisted access acts as the admin account.
+             * @enum {string}
+             */
+            role?: "admin" | "member" | "viewer";
             /**
              * @description Authentication source for the current request.
              * @enum {string}
              */
-            auth_mode?: "none" | "session" | "ip_allowlist";
+            auth_mode?: "none" | "session" | "ip_allowlist" | "forward_auth";
             version: string;
             recovery_local_only: boolean;
         };
+        UserInput: {
+            username?: string;
+            /** Format: password */
+            password?: string;
+            /** @enum {string} */
+            role?: "admin" | "member" | "viewer";
+        };
+        APITokenInput: {
+            name: string;
+            scopes: ("read" | "subscriptions" | "library" | "playback" | "admin")[];
+            /**
+             * @description 0 never expires.
+             * @default 0
+             */
+            expires_in_days: number;
+        };
         LoginInput: {
             username: string;
             /** Format: password */
@@ -2012,6 +2614,14 @@ export interface components {
             /** @default false */
             remember_me: boolean;
         };
+        TwoFactorCodeInput: {
+            /** @description Six-digit TOTP code, or a recovery code such as k7m2q-x9tfa. */
+            code: string;
+        };
+        TwoFactorPasswordInput: {
+            /** Format: password */
+            password: string;
+        };
         ChangeUsernameInput: {
             /** Format: password */
             current_password: string;
@@ -2031,6 +2641,25 @@ export interface components {
              */
             admin_password?: string;
         };
+        BackupDestinationTestInput: {
+            /** @enum {string} */
+            destination?: "r2" | "s3" | "webdav" | "sftp";
+            /** @description Settings of the chosen destination, e.g. webdav_url or sftp_host_fingerprint. */
+            values?: {
+                [key: string]: string;
+            };
+            /** @description Legacy R2 field. */
+            endpoint?: string;
+            /** @description Legacy R2 field. */
+            access_key?: string;
+            /**
+             * Format: password
+             * @description Legacy R2 field.
+             */
+            secret_key?: string;
+            /** @description Legacy R2 field. */
+            bucket?: string;
+        };
         SubscriptionInput: {
             title: string;
             /** Format: uri */
@@ -2289,7 +2918,10 @@ export interface components {
             netbird_stream_url: string;
             /** Format: int64 */
             resume_ticks: number;
-            /** Format: int64 */
+            /**
+             * Format: int64
+             * @description For local episodes, falls back to the duration ffprobe recorded during the last scan when there is no playback history.
+             */
             runtime_ticks: number;
             played: boolean;
             episode_favorite: boolean;
@@ -2298,8 +2930,26 @@ export interface components {
             poster_url: string;
             title: string;
             episode_title: string;
+            /** @description Subtitle tracks; only returned by the local direct-play endpoint. */
+            subtitles?: components["schemas"]["PlaybackSubtitle"][];
             diagnostic?: components["schemas"]["PlaybackDiagnostic"];
         };
+        PlaybackSubtitle: {
+            /** @description ext-<id> for sidecar files or emb-<stream index> for embedded streams */
+            id: string;
+            label: string;
+            /** @description Normalized language tag such as zh-Hans or ja; empty when unknown */
+            language: string;
+            /** @description Original subtitle format or codec */
+            format: string;
+            /** @enum {string} */
+            source: "external" | "embedded";
+            /** @description WebVTT URL; empty for image-based embedded subtitles */
+            url: string;
+            default: boolean;
+            forced: boolean;
+            playable: boolean;
+        };
         JellyfinMediaInfo: {
             container: string;
             /** Format: int64 */
@@ -2582,42 +3232,241 @@ export interface components {
             kind: string;
             title: string;
             /** @enum {string} */
-            status: "queued" | "running" | "completed" | "error";
+            status: "queued" | "running" | "completed" | "error" | "cancelled";
             phase?: string;
             message: string;
             /** Format: int64 */
             current?: number;
             /** Format: int64 */
             total?: number;
+            /** @description Account that started the task; omitted for system tasks */
+            user_id?: number;
+            username?: string;
+            cancellable?: boolean;
+            cancel_requested?: boolean;
+            /** Format: date-time */
+            started_at?: string;
+            /** Format: date-time */
+            finished_at?: string;
             /** Format: date-time */
             updated_at: string;
         };
-        Error: {
-            error: {
-                code: string;
-                message: string;
-            };
+        JournalEvent: {
+            /** Format: int64 */
+            id: number;
+            /** @enum {string} */
+            type: "scan_progress" | "scan_complete" | "metadata_updated" | "download_progress" | "download_ready" | "library_issue" | "scan_run" | "subscription_run" | "scheduler_run" | "task_update";
+            /** @description Event payload as published */
+            data: unknown;
+            /** Format: date-time */
+            created_at: string;
         };
-    };
-    responses: {
-        /** @description Successful response */
-        Success: {
-            headers: {
-                [name: string]: unknown;
-            };
-            content: {
-                "application/json": components["schemas"]["Envelope"];
-            };
+        EventHistoryPage: {
+            items: components["schemas"]["JournalEvent"][];
+            /** Format: int64 */
+            next_cursor: number;
+            /** Format: int64 */
+            latest_id: number;
+            has_more: boolean;
+            /** @description Some events after the cursor were pruned; reload full state before continuing */
+            truncated: boolean;
         };
-        /** @description Current health counters and actionable summary */
-        HealthReport: {
-            headers: {
-                [name: string]: unknown;
-            };
-            content: {
-                "application/json": components["schemas"]["Envelope"] & {
-                    data?: components["schemas"]["HealthReport"];
-                };
+        SubscriptionBundle: {
+            /** @enum {string} */
+            format: "animateautotool.subscriptions";
+            /** @enum {integer} */
+            version: 1;
+            /** Format: date-time */
+            exported_at?: string;
+            app_version?: string;
+            subscriptions: components["schemas"]["SubscriptionBundleItem"][];
+        };
+        SubscriptionBundleItem: {
+            title: string;
+            /** Format: uri */
+            rss_url: string;
+            mikan_id?: string;
+            image?: string;
+            subtitle_group?: string;
+            season?: string;
+            filter_rule?: string;
+            exclude_rule?: string;
+            resolution_filter?: string;
+            subtitle_language?: string;
+            backup_rss_url?: string;
+            parser?: string;
+            expected_episodes?: number;
+            auto_disable_on_done?: boolean;
+            allow_multi_subgroup?: boolean;
+            stale_after_hours?: number;
+            check_interval_minutes?: number;
+            save_path?: string;
+            rename_enabled?: boolean;
+            offset?: number;
+            is_active: boolean;
+            metadata?: {
+                title?: string;
+                bangumi_id?: number;
+                tmdb_id?: number;
+                anilist_id?: number;
+            };
+            resources?: {
+                fingerprint: string;
+                canonical_key?: string;
+                title?: string;
+                episode?: string;
+                season_val?: string;
+                subgroup?: string;
+                version_tag?: string;
+                info_hash?: string;
+                torrent_url?: string;
+                /** Format: date-time */
+                completed_at?: string;
+            }[];
+        };
+        SubscriptionImportItem: {
+            index: number;
+            title: string;
+            rss_url: string;
+            season?: string;
+            /** @enum {string} */
+            action: "create" | "restore" | "skip" | "failed";
+            reason?: string;
+            /** @description The RSS URL is already subscribed or repeated earlier in the file */
+            duplicate: boolean;
+            existing_id?: number;
+            /** @description Completed episodes that will be or were recorded */
+            resources: number;
+            subscription_id?: number;
+            /** @description Name of the rule or series in the source tool */
+            source_name?: string;
+            /** @description Parts of the source rule that could not be mapped exactly */
+            notes?: string[];
+        };
+        SubscriptionSourcePreview: {
+            /** @enum {string} */
+            source: "autobangumi" | "sonarr" | "qbittorrent";
+            items: components["schemas"]["SubscriptionImportItem"][];
+            summary: components["schemas"]["SubscriptionImportSummary"];
+            bundle: components["schemas"]["SubscriptionBundle"];
+        };
+        BangumiSyncStatus: {
+            enabled: boolean;
+            /** @description Whether a Bangumi account is logged in */
+            connected: boolean;
+            /** @enum {string} */
+            mode: "propose" | "subscribe";
+            types: ("wish" | "watching")[];
+            /** @description Subgroup preference order */
+            subgroups: string[];
+            config_error?: string;
+            running: boolean;
+            /** Format: date-time */
+            next_run_at?: string;
+            last_run?: components["schemas"]["BangumiSyncReport"];
+            records: components["schemas"]["BangumiSyncRecord"][];
+        };
+        BangumiSyncReport: {
+            /** @enum {string} */
+            trigger: "schedule" | "manual";
+            /** Format: date-time */
+            started_at: string;
+            /** Format: date-time */
+            finished_at: string;
+            seen: number;
+            counts: {
+                [key: string]: number;
+            };
+            subscribed?: string[];
+            proposed?: string[];
+            error?: string;
+        };
+        BangumiSyncRecord: {
+            id: number;
+            subject_id: number;
+            title: string;
+            image?: string;
+            /**
+             * @description 1 = wish, 3 = watching
+             * @enum {integer}
+             */
+            collection_type: 1 | 3;
+            /** @enum {string} */
+            status: "subscribed" | "proposed" | "existing" | "dismissed" | "deleted" | "unresolved" | "failed";
+            mikan_id?: string;
+            subgroup_id?: string;
+            subgroup_name?: string;
+            rss_url?: string;
+            subscription_id?: number;
+            reason?: string;
+            /** Format: date-time */
+            last_seen_at?: string | null;
+            /** Format: date-time */
+            created_at?: string;
+            /** Format: date-time */
+            updated_at?: string;
+        };
+        AniListEntryInput: {
+            /** @enum {string} */
+            status?: "CURRENT" | "PLANNING" | "COMPLETED" | "DROPPED" | "PAUSED" | "REPEATING";
+            progress?: number;
+            /** @description Score on the 100-point scale */
+            score?: number;
+        };
+        AniListListEntry: {
+            id: number;
+            progress: number;
+            /** @enum {string} */
+            status: "CURRENT" | "PLANNING" | "COMPLETED" | "DROPPED" | "PAUSED" | "REPEATING";
+            score: number;
+            /**
+             * Format: int64
+             * @description Unix seconds
+             */
+            updatedAt?: number;
+        };
+        SubscriptionImportResult: {
+            dry_run: boolean;
+            /** @enum {string} */
+            format: "json" | "opml";
+            items: components["schemas"]["SubscriptionImportItem"][];
+            summary: components["schemas"]["SubscriptionImportSummary"];
+        };
+        SubscriptionImportSummary: {
+            total: number;
+            create: number;
+            restore: number;
+            skip: number;
+            failed: number;
+            duplicates: number;
+            resources: number;
+        };
+        Error: {
+            error: {
+                code: string;
+                message: string;
+            };
+        };
+    };
+    responses: {
+        /** @description Successful response */
+        Success: {
+            headers: {
+                [name: string]: unknown;
+            };
+            content: {
+                "application/json": components["schemas"]["Envelope"];
+            };
+        };
+        /** @description Current health counters and actionable summary */
+        HealthReport: {
+            headers: {
+                [name: string]: unknown;
+            };
+            content: {
+                "application/json": components["schemas"]["Envelope"] & {
+                    data?: components["schemas"]["HealthReport"];
+                };
             };
         };
         /** @description Current browser session and local-only capability state */
@@ -2833,6 +3682,24 @@ export interface operations {
             429: components["responses"]["Error"];
         };
     };
+    loginSecondFactor: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody: {
+            content: {
+                "application/json": components["schemas"]["TwoFactorCodeInput"];
+            };
+        };
+        responses: {
+            200: components["responses"]["Success"];
+            401: components["responses"]["Error"];
+            429: components["responses"]["Error"];
+        };
+    };
     createLocalBootstrapSession: {
         parameters: {
             query?: never;
@@ -2890,6 +3757,85 @@ export interface operations {
             401: components["responses"]["Error"];
         };
     };
+    getTwoFactorStatus: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            200: components["responses"]["Success"];
+        };
+    };
+    enrollTwoFactor: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            200: components["responses"]["Success"];
+            409: components["responses"]["Error"];
+        };
+    };
+    confirmTwoFactor: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody: {
+            content: {
+                "application/json": components["schemas"]["TwoFactorCodeInput"];
+            };
+        };
+        responses: {
+            200: components["responses"]["Success"];
+            400: components["responses"]["Error"];
+            409: components["responses"]["Error"];
+        };
+    };
+    regenerateRecoveryCodes: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody: {
+            content: {
+                "application/json": components["schemas"]["TwoFactorPasswordInput"];
+            };
+        };
+        responses: {
+            200: components["responses"]["Success"];
+            400: components["responses"]["Error"];
+            409: components["responses"]["Error"];
+        };
+    };
+    disableTwoFactor: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody: {
+            content: {
+                "application/json": components["schemas"]["TwoFactorPasswordInput"];
+            };
+        };
+        responses: {
+            200: components["responses"]["Success"];
+            400: components["responses"]["Error"];
+            409: components["responses"]["Error"];
+        };
+    };
     recoverPassword: {
         parameters: {
             query?: never;
@@ -2960,7 +3906,7 @@ export interface operations {
         };
         requestBody?: never;
         responses: {
-            /** @description Current in-process task snapshot */
+            /** @description The 50 most recently updated tasks, merging in-process state with persisted history */
             200: {
                 headers: {
                     [name: string]: unknown;
@@ -3000,6 +3946,33 @@ export interface operations {
             404: components["responses"]["Error"];
         };
     };
+    cancelTask: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path: {
+                task_id: string;
+            };
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            /** @description Cancellation requested */
+            202: {
+                headers: {
+                    [name: string]: unknown;
+                };
+                content: {
+                    "application/json": components["schemas"]["Envelope"] & {
+                        data?: components["schemas"]["TaskUpdate"];
+                    };
+                };
+            };
+            403: components["responses"]["Error"];
+            404: components["responses"]["Error"];
+            409: components["responses"]["Error"];
+        };
+    };
     startSync: {
         parameters: {
             query?: never;
@@ -3014,8 +3987,12 @@ export interface operations {
     };
     streamEvents: {
         parameters: {
-            query?: never;
-            header?: never;
+            query?: {
+                last_event_id?: string;
+            };
+            header?: {
+                "Last-Event-ID"?: string;
+            };
             path?: never;
             cookie?: never;
         };
@@ -3032,6 +4009,34 @@ export interface operations {
             };
         };
     };
+    listEventHistory: {
+        parameters: {
+            query?: {
+                after?: number;
+                limit?: number;
+                /** @description Comma-separated event types */
+                types?: string;
+            };
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            /** @description Events after the cursor in ID order */
+            200: {
+                headers: {
+                    [name: string]: unknown;
+                };
+                content: {
+                    "application/json": components["schemas"]["Envelope"] & {
+                        data?: components["schemas"]["EventHistoryPage"];
+                    };
+                };
+            };
+            400: components["responses"]["Error"];
+        };
+    };
     listSubscriptions: {
         parameters: {
             query?: {
@@ -3101,44 +4106,231 @@ export interface operations {
             200: components["responses"]["Success"];
         };
     };
-    validateSubscriptionRss: {
+    exportSubscriptions: {
         parameters: {
-            query?: never;
+            query?: {
+                format?: "json" | "opml";
+                /** @description Include completed episodes in the JSON bundle */
+                include_resources?: boolean;
+            };
             header?: never;
             path?: never;
             cookie?: never;
         };
         requestBody?: never;
         responses: {
-            200: components["responses"]["Success"];
+            /** @description Export file sent as an attachment */
+            200: {
+                headers: {
+                    [name: string]: unknown;
+                };
+                content: {
+                    "application/json": components["schemas"]["SubscriptionBundle"];
+                    "text/x-opml": string;
+                };
+            };
+            400: components["responses"]["Error"];
         };
     };
-    searchMikan: {
+    importSubscriptions: {
         parameters: {
-            query: {
-                q: string;
+            query?: {
+                /** @description Detected from the body when omitted */
+                format?: "json" | "opml";
+                dry_run?: boolean;
+                /** @description Record completed episodes from the bundle so they are not downloaded again */
+                include_resources?: boolean;
             };
             header?: never;
             path?: never;
             cookie?: never;
         };
-        requestBody?: never;
-        responses: {
-            200: components["responses"]["Success"];
-        };
-    };
-    previewRss: {
-        parameters: {
-            query: {
-                rss: string;
+        requestBody: {
+            content: {
+                "application/json": components["schemas"]["SubscriptionBundle"];
+                "text/x-opml": string;
             };
-            header?: never;
-            path?: never;
-            cookie?: never;
         };
-        requestBody?: never;
         responses: {
-            200: components["responses"]["Success"];
+            /** @description Import plan or result */
+            200: {
+                headers: {
+                    [name: string]: unknown;
+                };
+                content: {
+                    "application/json": components["schemas"]["Envelope"] & {
+                        data?: components["schemas"]["SubscriptionImportResult"];
+                    };
+                };
+            };
+            400: components["responses"]["Error"];
+            413: components["responses"]["Error"];
+        };
+    };
+    previewSourceSubscriptions: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path: {
+                source: "autobangumi" | "sonarr" | "qbittorrent";
+            };
+            cookie?: never;
+        };
+        requestBody?: {
+            content: {
+                "application/octet-stream": string;
+                "application/json": Record<string, never>[];
+            };
+        };
+        responses: {
+            /** @description Reviewable import plan and the bundle to import */
+            200: {
+                headers: {
+                    [name: string]: unknown;
+                };
+                content: {
+                    "application/json": components["schemas"]["Envelope"] & {
+                        data?: components["schemas"]["SubscriptionSourcePreview"];
+                    };
+                };
+            };
+            400: components["responses"]["Error"];
+            413: components["responses"]["Error"];
+            502: components["responses"]["Error"];
+        };
+    };
+    getBangumiSync: {
+        parameters: {
+            query?: {
+                /** @description Comma separated record statuses */
+                status?: string;
+            };
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            /** @description Sync status and records */
+            200: {
+                headers: {
+                    [name: string]: unknown;
+                };
+                content: {
+                    "application/json": components["schemas"]["Envelope"] & {
+                        data?: components["schemas"]["BangumiSyncStatus"];
+                    };
+                };
+            };
+            500: components["responses"]["Error"];
+        };
+    };
+    runBangumiSync: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            202: components["responses"]["TaskAccepted"];
+            400: components["responses"]["Error"];
+            409: components["responses"]["Error"];
+        };
+    };
+    acceptBangumiSyncProposal: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path: {
+                id: number;
+            };
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            /** @description Updated record */
+            200: {
+                headers: {
+                    [name: string]: unknown;
+                };
+                content: {
+                    "application/json": components["schemas"]["Envelope"] & {
+                        data?: components["schemas"]["BangumiSyncRecord"];
+                    };
+                };
+            };
+            400: components["responses"]["Error"];
+            404: components["responses"]["Error"];
+            409: components["responses"]["Error"];
+        };
+    };
+    dismissBangumiSyncRecord: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path: {
+                id: number;
+            };
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            /** @description Updated record */
+            200: {
+                headers: {
+                    [name: string]: unknown;
+                };
+                content: {
+                    "application/json": components["schemas"]["Envelope"] & {
+                        data?: components["schemas"]["BangumiSyncRecord"];
+                    };
+                };
+            };
+            400: components["responses"]["Error"];
+            404: components["responses"]["Error"];
+            409: components["responses"]["Error"];
+        };
+    };
+    validateSubscriptionRss: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            200: components["responses"]["Success"];
+        };
+    };
+    searchMikan: {
+        parameters: {
+            query: {
+                q: string;
+            };
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            200: components["responses"]["Success"];
+        };
+    };
+    previewRss: {
+        parameters: {
+            query: {
+                rss: string;
+            };
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            200: components["responses"]["Success"];
         };
     };
     getMikanDashboard: {
@@ -3851,7 +5043,7 @@ export interface operations {
             200: components["responses"]["Success"];
         };
     };
-    getJellyfinPlayInfo: {
+    getLocalEpisodePlayInfo: {
         parameters: {
             query?: never;
             header?: never;
@@ -3862,20 +5054,22 @@ export interface operations {
         };
         requestBody?: never;
         responses: {
-            /** @description Resolved proxy and optional browser-direct Jellyfin playback URLs */
+            /** @description Built-in direct-play stream URL and local media info */
             200: {
                 headers: {
                     [name: string]: unknown;
                 };
                 content: {
-                    "application/json": components["schemas"]["JellyfinPlayInfo"];
+                    "application/json": {
+                        data: components["schemas"]["JellyfinPlayInfo"];
+                    };
                 };
             };
+            403: components["responses"]["Error"];
             404: components["responses"]["Error"];
-            503: components["responses"]["Error"];
         };
     };
-    streamJellyfinEpisode: {
+    streamLocalEpisode: {
         parameters: {
             query?: never;
             header?: never;
@@ -3886,7 +5080,7 @@ export interface operations {
         };
         requestBody?: never;
         responses: {
-            /** @description Proxied video stream */
+            /** @description Local video file */
             200: {
                 headers: {
                     [name: string]: unknown;
@@ -3895,7 +5089,7 @@ export interface operations {
                     "video/*": string;
                 };
             };
-            /** @description Partial proxied video stream */
+            /** @description Partial local video file */
             206: {
                 headers: {
                     [name: string]: unknown;
@@ -3904,8 +5098,10 @@ export interface operations {
                     "video/*": string;
                 };
             };
-            /** @description Episode or Jellyfin item not found */
-            404: {
+            403: components["responses"]["Error"];
+            404: components["responses"]["Error"];
+            /** @description Requested range not satisfiable */
+            416: {
                 headers: {
                     [name: string]: unknown;
                 };
@@ -3913,11 +5109,63 @@ export interface operations {
             };
         };
     };
-    streamJellyfinEpisodeThroughNetBird: {
+    listLocalEpisodeSubtitles: {
         parameters: {
-            query: {
-                token: string;
+            query?: never;
+            header?: never;
+            path: {
+                id: components["parameters"]["Id"];
+            };
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            /** @description Subtitle tracks */
+            200: {
+                headers: {
+                    [name: string]: unknown;
+                };
+                content: {
+                    "application/json": {
+                        data: {
+                            items: components["schemas"]["PlaybackSubtitle"][];
+                        };
+                    };
+                };
+            };
+            404: components["responses"]["Error"];
+        };
+    };
+    getLocalEpisodeSubtitle: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path: {
+                id: components["parameters"]["Id"];
+                track: string;
+            };
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            /** @description WebVTT subtitle */
+            200: {
+                headers: {
+                    [name: string]: unknown;
+                };
+                content: {
+                    "text/vtt": string;
+                };
             };
+            403: components["responses"]["Error"];
+            404: components["responses"]["Error"];
+            422: components["responses"]["Error"];
+            503: components["responses"]["Error"];
+        };
+    };
+    getJellyfinPlayInfo: {
+        parameters: {
+            query?: never;
             header?: never;
             path: {
                 id: components["parameters"]["Id"];
@@ -3926,17 +5174,32 @@ export interface operations {
         };
         requestBody?: never;
         responses: {
-            /** @description Proxied video stream */
+            /** @description Resolved proxy and optional browser-direct Jellyfin playback URLs */
             200: {
                 headers: {
                     [name: string]: unknown;
                 };
                 content: {
-                    "video/*": string;
+                    "application/json": components["schemas"]["JellyfinPlayInfo"];
                 };
             };
-            /** @description Partial proxied video stream */
-            206: {
+            404: components["responses"]["Error"];
+            503: components["responses"]["Error"];
+        };
+    };
+    streamJellyfinEpisode: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path: {
+                id: components["parameters"]["Id"];
+            };
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            /** @description Proxied video stream */
+            200: {
                 headers: {
                     [name: string]: unknown;
                 };
@@ -3944,12 +5207,14 @@ export interface operations {
                     "video/*": string;
                 };
             };
-            /** @description Missing, invalid, mismatched, or expired stream token */
-            401: {
+            /** @description Partial proxied video stream */
+            206: {
                 headers: {
                     [name: string]: unknown;
                 };
-                content?: never;
+                content: {
+                    "video/*": string;
+                };
             };
             /** @description Episode or Jellyfin item not found */
             404: {
@@ -3960,15 +5225,14 @@ export interface operations {
             };
         };
     };
-    streamMediaItemThroughNetBird: {
+    streamJellyfinEpisodeThroughNetBird: {
         parameters: {
             query: {
                 token: string;
             };
             header?: never;
             path: {
-                provider: components["parameters"]["MediaProvider"];
-                item_id: components["parameters"]["MediaItemId"];
+                id: components["parameters"]["Id"];
             };
             cookie?: never;
         };
@@ -3999,7 +5263,55 @@ export interface operations {
                 };
                 content?: never;
             };
-            /** @description Provider or media item not found */
+            /** @description Episode or Jellyfin item not found */
+            404: {
+                headers: {
+                    [name: string]: unknown;
+                };
+                content?: never;
+            };
+        };
+    };
+    streamMediaItemThroughNetBird: {
+        parameters: {
+            query: {
+                token: string;
+            };
+            header?: never;
+            path: {
+                provider: components["parameters"]["MediaProvider"];
+                item_id: components["parameters"]["MediaItemId"];
+            };
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            /** @description Proxied video stream */
+            200: {
+                headers: {
+                    [name: string]: unknown;
+                };
+                content: {
+                    "video/*": string;
+                };
+            };
+            /** @description Partial proxied video stream */
+            206: {
+                headers: {
+                    [name: string]: unknown;
+                };
+                content: {
+                    "video/*": string;
+                };
+            };
+            /** @description Missing, invalid, mismatched, or expired stream token */
+            401: {
+                headers: {
+                    [name: string]: unknown;
+                };
+                content?: never;
+            };
+            /** @description Provider or media item not found */
             404: {
                 headers: {
                     [name: string]: unknown;
@@ -4286,6 +5598,7 @@ export interface operations {
         responses: {
             200: components["responses"]["Success"];
             400: components["responses"]["Error"];
+            404: components["responses"]["Error"];
             503: components["responses"]["Error"];
         };
     };
@@ -4320,6 +5633,7 @@ export interface operations {
                 };
             };
             400: components["responses"]["Error"];
+            404: components["responses"]["Error"];
             503: components["responses"]["Error"];
         };
     };
@@ -4458,6 +5772,53 @@ export interface operations {
             200: components["responses"]["Success"];
         };
     };
+    saveAniListEntry: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path: {
+                /** @description AniList media ID */
+                id: number;
+            };
+            cookie?: never;
+        };
+        requestBody: {
+            content: {
+                "application/json": components["schemas"]["AniListEntryInput"];
+            };
+        };
+        responses: {
+            /** @description Saved list entry */
+            200: {
+                headers: {
+                    [name: string]: unknown;
+                };
+                content: {
+                    "application/json": components["schemas"]["Envelope"] & {
+                        data?: components["schemas"]["AniListListEntry"];
+                    };
+                };
+            };
+            400: components["responses"]["Error"];
+            403: components["responses"]["Error"];
+            502: components["responses"]["Error"];
+        };
+    };
+    runAniListSync: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            202: components["responses"]["TaskAccepted"];
+            400: components["responses"]["Error"];
+            403: components["responses"]["Error"];
+            409: components["responses"]["Error"];
+        };
+    };
     exportDiagnosticLogs: {
         parameters: {
             query?: never;
@@ -4663,11 +6024,42 @@ export interface operations {
             path?: never;
             cookie?: never;
         };
-        requestBody: components["requestBodies"]["JsonObject"];
+        requestBody: {
+            content: {
+                "application/json": components["schemas"]["BackupDestinationTestInput"];
+            };
+        };
+        responses: {
+            200: components["responses"]["Success"];
+            400: components["responses"]["Error"];
+        };
+    };
+    getBackupSchedule: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody?: never;
         responses: {
             200: components["responses"]["Success"];
         };
     };
+    runBackupSchedule: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            202: components["responses"]["TaskAccepted"];
+            400: components["responses"]["Error"];
+            409: components["responses"]["Error"];
+        };
+    };
     getHealth: {
         parameters: {
             query?: never;
@@ -4707,6 +6099,121 @@ export interface operations {
             200: components["responses"]["Paginated"];
         };
     };
+    listUsers: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            200: components["responses"]["Success"];
+            403: components["responses"]["Error"];
+        };
+    };
+    createUser: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody: {
+            content: {
+                "application/json": components["schemas"]["UserInput"];
+            };
+        };
+        responses: {
+            201: components["responses"]["Success"];
+            400: components["responses"]["Error"];
+            403: components["responses"]["Error"];
+            409: components["responses"]["Error"];
+        };
+    };
+    updateUser: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path: {
+                id: number;
+            };
+            cookie?: never;
+        };
+        requestBody: {
+            content: {
+                "application/json": components["schemas"]["UserInput"];
+            };
+        };
+        responses: {
+            200: components["responses"]["Success"];
+            400: components["responses"]["Error"];
+            403: components["responses"]["Error"];
+            404: components["responses"]["Error"];
+            409: components["responses"]["Error"];
+        };
+    };
+    deleteUser: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path: {
+                id: number;
+            };
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            200: components["responses"]["Success"];
+            403: components["responses"]["Error"];
+            404: components["responses"]["Error"];
+            409: components["responses"]["Error"];
+        };
+    };
+    listAPITokens: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            200: components["responses"]["Success"];
+        };
+    };
+    createAPIToken: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody: {
+            content: {
+                "application/json": components["schemas"]["APITokenInput"];
+            };
+        };
+        responses: {
+            201: components["responses"]["Success"];
+            400: components["responses"]["Error"];
+        };
+    };
+    revokeAPIToken: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path: {
+                id: number;
+            };
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            200: components["responses"]["Success"];
+            404: components["responses"]["Error"];
+        };
+    };
     getSettings: {
         parameters: {
             query?: never;
@@ -4731,6 +6238,31 @@ export interface operations {
             200: components["responses"]["Success"];
         };
     };
+    getSettingsSecretsStatus: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            200: components["responses"]["Success"];
+        };
+    };
+    rotateSettingsKey: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            200: components["responses"]["Success"];
+            500: components["responses"]["Error"];
+        };
+    };
     testProxy: {
         parameters: {
             query?: never;
@@ -4745,6 +6277,108 @@ export interface operations {
             502: components["responses"]["Error"];
         };
     };
+    testDownloaderPathMappings: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody: components["requestBodies"]["JsonObject"];
+        responses: {
+            200: components["responses"]["Success"];
+            400: components["responses"]["Error"];
+            404: components["responses"]["Error"];
+            502: components["responses"]["Error"];
+        };
+    };
+    listNotificationChannels: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            200: components["responses"]["Success"];
+        };
+    };
+    createNotificationChannel: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody: components["requestBodies"]["JsonObject"];
+        responses: {
+            201: components["responses"]["Success"];
+            400: components["responses"]["Error"];
+        };
+    };
+    listNotificationDeliveries: {
+        parameters: {
+            query?: {
+                channel_id?: number;
+                limit?: number;
+            };
+            header?: never;
+            path?: never;
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            200: components["responses"]["Success"];
+        };
+    };
+    updateNotificationChannel: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path: {
+                id: number;
+            };
+            cookie?: never;
+        };
+        requestBody: components["requestBodies"]["JsonObject"];
+        responses: {
+            200: components["responses"]["Success"];
+            400: components["responses"]["Error"];
+            404: components["responses"]["Error"];
+        };
+    };
+    deleteNotificationChannel: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path: {
+                id: number;
+            };
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            200: components["responses"]["Success"];
+            404: components["responses"]["Error"];
+        };
+    };
+    testNotificationChannel: {
+        parameters: {
+            query?: never;
+            header?: never;
+            path: {
+                id: number;
+            };
+            cookie?: never;
+        };
+        requestBody?: never;
+        responses: {
+            200: components["responses"]["Success"];
+            404: components["responses"]["Error"];
+            502: components["responses"]["Error"];
+        };
+    };
     getConnectionStatus: {
         parameters: {
             query?: {
@@ -1,9 +1,11 @@
 import { describe, expect, it, vi } from 'vitest'
 import { mount } from '@vue/test-utils'
 import type { Subscription } from '../../api/types'
+import BangumiSyncDialog from './BangumiSyncDialog.vue'
 import SubscriptionCard from './SubscriptionCard.vue'
 import SubscriptionHistoryDialog from './SubscriptionHistoryDialog.vue'
 import SubscriptionOverview from './SubscriptionOverview.vue'
+import SubscriptionTransferDialog from './SubscriptionTransferDialog.vue'
 
 function subscription(overrides: Partial<Subscription> = {}): Subscription {
   return {
@@ -178,4 +180,125 @@ describe('subscription presentational components', () => {
     expect(wrapper.text()).not.toContain('42.0%')
     expect(wrapper.find('[role="progressbar"]').exists()).toBe(false)
   })
+
+  it('summarises an import preview and only enables importable rows', async () => {
+    const wrapper = mount(SubscriptionTransferDialog, {
+      props: {
+        open: true,
+        fileName: 'subscriptions.json',
+        includeResources: true,
+        source: 'bundle',
+        preview: {
+          dry_run: true,
+          format: 'json',
+          items: [
+            { index: 0, title: '已有番剧', rss_url: 'https://example.test/a', action: 'skip', reason: '已存在相同 RSS 地址的订阅', duplicate: true, resources: 0 },
+            { index: 1, title: '新番剧', rss_url: 'https://example.test/b', action: 'create', duplicate: false, resources: 3 },
+          ],
+          summary: { total: 2, create: 1, restore: 0, skip: 1, failed: 0, duplicates: 1, resources: 3 },
+        },
+        exportLoading: () => false,
+        previewLoading: false,
+        importLoading: false,
+      },
+      global: {
+        stubs: {
+          AppDialog: { template: '<div><slot /></div>' },
+        },
+      },
+    })
+
+    expect(wrapper.text()).toContain('跳过 1（重复 1）')
+    expect(wrapper.text()).toContain('已存在相同 RSS 地址的订阅')
+    await wrapper.findAll('button').find(button => button.text().includes('确认导入 1 个订阅'))!.trigger('click')
+    await wrapper.findAll('button').find(button => button.text().includes('导出 OPML'))!.trigger('click')
+    expect(wrapper.emitted('import')).toHaveLength(1)
+    expect(wrapper.emitted('export')).toEqual([['opml']])
+  })
+
+  it('previews rules read from another tool with their notes', async () => {
+    const wrapper = mount(SubscriptionTransferDialog, {
+      props: {
+        open: true,
+        fileName: '',
+        includeResources: false,
+        source: 'qbittorrent',
+        preview: {
+          source: 'qbittorrent',
+          items: [
+            {
+              index: 0,
+              title: 'Frieren',
+              source_name: '芙莉莲 1080p',
+              rss_url: 'https://mikanani.me/RSS/Bangumi?bangumiId=3141',
+              action: 'create',
+              duplicate: false,
+              resources: 0,
+              notes: ['剧集过滤 1x01-; 没有对应的订阅规则，已忽略'],
+            },
+          ],
+          summary: { total: 1, create: 1, restore: 0, skip: 0, failed: 0, duplicates: 0, resources: 0 },
+          bundle: { format: 'animateautotool.subscriptions', version: 1, exported_at: '2026-10-17T08:00:00Z', subscriptions: [] },
+        },
+        exportLoading: () => false,
+        previewLoading: false,
+        importLoading: false,
+      },
+      global: {
+        stubs: {
+          AppDialog: { template: '<div><slot /></div>' },
+        },
+      },
+    })
+
+    expect(wrapper.find('input[type="file"]').exists()).toBe(false)
+    expect(wrapper.text()).toContain('来源：芙莉莲 1080p')
+    expect(wrapper.text()).toContain('剧集过滤 1x01-;')
+    await wrapper.findAll('button').find(button => button.text().includes('读取 qBittorrent'))!.trigger('click')
+    await wrapper.get('select[aria-label="导入来源"]').setValue('sonarr')
+    expect(wrapper.emitted('fetch')).toHaveLength(1)
+    expect(wrapper.emitted('update:source')).toEqual([['sonarr']])
+  })
+
+  it('lists Bangumi sync proposals and emits accept and dismiss', async () => {
+    const wrapper = mount(BangumiSyncDialog, {
+      props: {
+        open: true,
+        loading: false,
+        runLoading: false,
+        isBusy: () => false,
+        status: {
+          enabled: true,
+          connected: true,
+          mode: 'propose',
+          types: ['wish', 'watching'],
+          subgroups: ['LoliHouse'],
+          running: false,
+          last_run: { trigger: 'schedule', started_at: '2026-10-17T03:00:00Z', finished_at: '2026-10-17T03:00:05Z', seen: 3, counts: { proposed: 1 }, proposed: ['葬送的芙莉莲'] },
+          records: [
+            { id: 1, subject_id: 400602, title: '葬送的芙莉莲', collection_type: 3, status: 'proposed', subgroup_name: 'LoliHouse', reason: '匹配到偏好字幕组 LoliHouse' },
+            { id: 2, subject_id: 999999, title: 'Unreleased', collection_type: 1, status: 'unresolved', reason: 'Mikan 上还没有对应番组，下次同步时重试' },
+            { id: 3, subject_id: 428735, title: '药屋少女的呢喃', collection_type: 3, status: 'deleted', reason: '订阅已被删除，不会再自动订阅' },
+          ],
+        },
+      },
+      global: {
+        stubs: {
+          AppDialog: { template: '<div><slot /></div>' },
+        },
+      },
+    })
+
+    expect(wrapper.text()).toContain('需要处理 2 部')
+    expect(wrapper.text()).toContain('字幕组偏好：LoliHouse')
+    expect(wrapper.text()).toContain('已处理 1 部')
+    const buttons = wrapper.findAll('button')
+    expect(buttons.filter(button => button.text() === '订阅')).toHaveLength(1)
+    await buttons.find(button => button.text() === '订阅')!.trigger('click')
+    await buttons.filter(button => button.text() === '忽略')[1].trigger('click')
+    await buttons.find(button => button.text().includes('立即同步'))!.trigger('click')
+    expect(wrapper.emitted('accept')).toEqual([[1]])
+    expect(wrapper.emitted('dismiss')).toEqual([[2]])
+    expect(wrapper.emitted('run')).toHaveLength(1)
+  })
 })
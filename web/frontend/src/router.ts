@@ -21,9 +21,9 @@ const routes = [
   { path: '/media/library/:libraryId', component: () => import('./views/MediaLibraryView.vue'), meta: { title: '媒体库', workspace: 'media' } },
   { path: '/media/item/:provider/:itemId', component: () => import('./views/MediaItemView.vue'), meta: { title: '媒体详情', workspace: 'media' } },
   { path: '/media/play/:provider/:itemId', component: () => import('./views/MediaPlayerView.vue'), meta: { title: '正在播放', workspace: 'media' } },
-  { path: '/backup', component: () => import('./views/BackupView.vue'), meta: { title: '备份与恢复', workspace: 'manage' } },
-  { path: '/health', component: () => import('./views/HealthView.vue'), meta: { title: '系统健康', workspace: 'manage' } },
-  { path: '/settings', component: () => import('./views/SettingsView.vue'), meta: { title: '系统设置', workspace: 'manage' } },
+  { path: '/backup', component: () => import('./views/BackupView.vue'), meta: { title: '备份与恢复', workspace: 'manage', admin: true } },
+  { path: '/health', component: () => import('./views/HealthView.vue'), meta: { title: '系统健康', workspace: 'manage', admin: true } },
+  { path: '/settings', component: () => import('./views/SettingsView.vue'), meta: { title: '系统设置', workspace: 'manage', admin: true } },
   { path: '/assistant', component: () => import('./views/AssistantRedirectView.vue'), meta: { title: 'AI 助手' } },
   { path: '/:pathMatch(.*)*', component: () => import('./views/NotFoundView.vue'), meta: { title: '页面不存在' } },
 ]
@@ -38,6 +38,7 @@ router.beforeEach(async to => {
   if (!session.authenticated) return `/login?redirect=${encodeURIComponent(to.fullPath)}`
   if (session.setupPending && to.path !== '/setup') return '/setup'
   if (!session.setupPending && to.path === '/setup') return '/'
+  if (to.meta.admin && !session.isAdmin) return '/'
   if (to.path === '/assistant') {
     useAssistantStore().expand()
     const fallback = workspace.isMedia ? '/media' : '/'
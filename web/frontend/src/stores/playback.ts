@@ -1,6 +1,6 @@
 import { defineStore } from 'pinia'
-import { api } from '../api/client'
-import type { ContinueWatchingItem, JellyfinPlayInfo, PlaybackProgressInput } from '../api/types'
+import { api, ApiError } from '../api/client'
+import type { ContinueWatchingItem, JellyfinPlayInfo, PlaybackProgressInput, PlaybackSubtitle } from '../api/types'
 import { useUIStore } from './ui'
 
 export type PlaybackSourceMode = 'proxy' | 'direct'
@@ -18,6 +18,7 @@ export type ActivePlaybackInfo = {
   episode_favorite?: boolean
   series_favorite?: boolean
   media: JellyfinPlayInfo['media']
+  subtitles?: PlaybackSubtitle[]
 }
 
 export interface PlaybackSelection {
@@ -55,6 +56,17 @@ function storedSourceMode(): PlaybackSourceMode {
   return result
 }
 
+// Without Jellyfin, local episodes fall back to AnimateTool's built-in direct-play stream.
+async function localPlayInfo(episodeId: number) {
+  try {
+    return await api<ActivePlaybackInfo>(`/jellyfin/play/${episodeId}`)
+  } catch (error) {
+    const code = (error instanceof ApiError ? (error.details as { diagnostic?: { code?: string } } | undefined)?.diagnostic?.code : '') || ''
+    if (code !== 'jellyfin_not_configured') throw error
+    return api<ActivePlaybackInfo>(`/local-anime/episodes/${episodeId}/play`)
+  }
+}
+
 function selectionKey(selection?: PlaybackSelection | null) {
   if (!selection) return ''
   if (selection.provider !== 'local' && selection.itemId) return `${selection.provider}:${selection.itemId}`
@@ -162,9 +174,9 @@ export const usePlaybackStore = defineStore('playback', {
       try {
         const external = selection.provider !== 'local' && selection.itemId
         if (!external && !selection.localEpisodeId) throw new Error('本地剧集缺少可播放的剧集 ID')
-        const info = await api<ActivePlaybackInfo>(external
-          ? `/media/providers/${encodeURIComponent(selection.provider)}/items/${encodeURIComponent(selection.itemId!)}/play`
-          : `/jellyfin/play/${selection.localEpisodeId}`)
+        const info = external
+          ? await api<ActivePlaybackInfo>(`/media/providers/${encodeURIComponent(selection.provider)}/items/${encodeURIComponent(selection.itemId!)}/play`)
+          : await localPlayInfo(selection.localEpisodeId!)
         if (request !== prepareRequest || selectionKey(this.current) !== selectionKey(selection)) return
         this.playInfo = info
         const resumeTicks = options.resumeTicks ?? info.resume_ticks
@@ -9,7 +9,12 @@ export const useSessionStore = defineStore('session', {
     setupPending: s => Boolean(s.state?.setup_pending),
     localSetupAvailable: s => Boolean(s.state?.local_setup_available),
     localRecoveryAvailable: s => Boolean(s.state?.local_recovery_available),
-    passwordless: s => s.state?.auth_mode === 'ip_allowlist',
+    passwordless: s => s.state?.auth_mode === 'ip_allowlist' || s.state?.auth_mode === 'forward_auth',
+    passwordlessLabel: s => (s.state?.auth_mode === 'forward_auth' ? '反向代理登录' : 'IP 白名单免密'),
+    // Servers without roles only have administrators.
+    role: s => s.state?.role ?? 'admin',
+    isAdmin(): boolean { return this.role === 'admin' },
+    canManage(): boolean { return this.role === 'admin' || this.role === 'member' },
   },
   actions: {
     async load(force = false) {
@@ -17,8 +22,14 @@ export const useSessionStore = defineStore('session', {
       this.loading = true
       try { this.state = await api<SessionState>('/session'); return this.state } finally { this.loading = false }
     },
+    // Resolves to null when the account needs its second factor next.
     async login(username: string, password: string, remember_me: boolean) {
-      await api('/session/login', { method: 'POST', body: JSON.stringify({ username, password, remember_me }), headers: { 'Content-Type': 'application/json' } })
+      const result = await api<{ two_factor_required?: boolean }>('/session/login', { method: 'POST', body: JSON.stringify({ username, password, remember_me }), headers: { 'Content-Type': 'application/json' } })
+      if (result?.two_factor_required) return null
+      return this.load(true)
+    },
+    async verifySecondFactor(code: string) {
+      await api('/session/login/2fa', { method: 'POST', body: JSON.stringify({ code }), headers: { 'Content-Type': 'application/json' } })
       return this.load(true)
     },
     async beginLocalSetup() {
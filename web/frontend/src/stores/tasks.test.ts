@@ -62,6 +62,40 @@ describe('task stream store', () => {
     store.disconnect()
   })
 
+  it('posts cancellation requests and maps cancelled tasks', async () => {
+    const fetchMock = vi.fn(() => response({
+      task_id: 'local-organize-1', kind: 'organize', title: '整理本地番剧', status: 'running', message: '正在取消任务', cancellable: true, cancel_requested: true, updated_at: '2026-10-17T00:00:01Z',
+    }))
+    vi.stubGlobal('fetch', fetchMock)
+    const store = useTaskStore()
+    store.upsert({ id: 'local-organize-1', kind: 'organize', title: '整理本地番剧', detail: '正在整理', tone: 'running', cancellable: true, updatedAt: '2026-10-17T00:00:00Z' })
+
+    await store.cancel('local-organize-1')
+    expect(fetchMock).toHaveBeenCalledWith('/api/v1/tasks/local-organize-1/cancel', expect.objectContaining({ method: 'POST' }))
+    expect(store.taskByID('local-organize-1')?.cancelRequested).toBe(true)
+
+    store.connect()
+    FakeEventSource.instances[0].emit('task_update', {
+      task_id: 'local-organize-1', kind: 'organize', title: '整理本地番剧', status: 'cancelled', message: '任务已取消', updated_at: '2026-10-17T00:00:02Z',
+    })
+    expect(store.taskByID('local-organize-1')?.tone).toBe('cancelled')
+    expect(store.runningCount).toBe(0)
+    store.disconnect()
+  })
+
+  it('rehydrates task snapshots when the stream asks for a resync', async () => {
+    const fetchMock = vi.fn(() => response({ items: [] }))
+    vi.stubGlobal('fetch', fetchMock)
+    const store = useTaskStore()
+    store.connect()
+    await vi.waitFor(() => expect(fetchMock).toHaveBeenCalledTimes(1))
+
+    FakeEventSource.instances[0].emit('resync', { latest_id: 42 })
+    await vi.waitFor(() => expect(fetchMock).toHaveBeenCalledTimes(2))
+    expect(fetchMock).toHaveBeenLastCalledWith('/api/v1/tasks', expect.anything())
+    store.disconnect()
+  })
+
   it('does not overwrite an already completed task with a late accepted response', () => {
     const store = useTaskStore()
     store.upsert({ id: 'fast-task', kind: 'sync', title: '同步', detail: '已完成', tone: 'success', updatedAt: '2026-07-23T00:00:01Z' })
@@ -10,7 +10,10 @@ export interface LiveTask {
   detail: string
   current?: number
   total?: number
-  tone: 'running' | 'success' | 'error'
+  tone: 'running' | 'success' | 'error' | 'cancelled'
+  cancellable?: boolean
+  cancelRequested?: boolean
+  username?: string
   updatedAt?: string
 }
 
@@ -25,7 +28,10 @@ function toLiveTask(task: TaskUpdate): LiveTask {
     detail: task.message,
     current: task.current,
     total: task.total,
-    tone: task.status === 'error' ? 'error' : task.status === 'completed' ? 'success' : 'running',
+    tone: task.status === 'error' ? 'error' : task.status === 'completed' ? 'success' : task.status === 'cancelled' ? 'cancelled' : 'running',
+    cancellable: task.cancellable,
+    cancelRequested: task.cancel_requested,
+    username: task.username,
     updatedAt: task.updated_at,
   }
 }
@@ -39,6 +45,8 @@ function liveTasksEqual(left: LiveTask, right: LiveTask) {
     && left.current === right.current
     && left.total === right.total
     && left.tone === right.tone
+    && left.cancellable === right.cancellable
+    && left.cancelRequested === right.cancelRequested
     && left.updatedAt === right.updatedAt
 }
 
@@ -72,6 +80,8 @@ export const useTaskStore = defineStore('tasks', {
       this.source = source
       source.onopen = () => { this.connected = true; void this.hydrate() }
       source.onerror = () => { this.connected = false }
+      // The server replays missed events on reconnect; resync means the gap was too old to replay.
+      source.addEventListener('resync', () => { void this.hydrate() })
       source.addEventListener('task_update', event => {
         const task = JSON.parse((event as MessageEvent).data || '{}') as TaskUpdate
         if (task.task_id) this.upsert(toLiveTask(task))
@@ -118,6 +128,10 @@ export const useTaskStore = defineStore('tasks', {
       if (existing) return
       this.upsert({ id: task.task_id, kind, title, detail, tone: 'running' })
     },
+    async cancel(taskID: string) {
+      const task = await api<TaskUpdate>(`/tasks/${encodeURIComponent(taskID)}/cancel`, { method: 'POST' })
+      this.upsert(toLiveTask(task))
+    },
     consumeTransitions() {
       return this.transitions.splice(0)
     },
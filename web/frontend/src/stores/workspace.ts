@@ -1,5 +1,6 @@
 import { defineStore } from 'pinia'
 import { api } from '../api/client'
+import { anyMediaProviderConfigured } from '../utils/mediaProvider'
 
 export type WorkspaceMode = 'manage' | 'media'
 
@@ -52,8 +53,7 @@ export const useWorkspaceStore = defineStore('workspace', {
       this.mediaStatusLoading = true
       try {
         const payload = await api<{ providers?: Array<{ id?: string; configured?: boolean }> }>('/media/providers')
-        const jellyfin = payload.providers?.find(provider => provider.id === 'jellyfin')
-        this.mediaConfigured = Boolean(jellyfin?.configured)
+        this.mediaConfigured = anyMediaProviderConfigured(payload.providers)
       } catch {
         this.mediaConfigured = null
       } finally {
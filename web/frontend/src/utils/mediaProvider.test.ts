@@ -0,0 +1,15 @@
+import { describe, expect, it } from 'vitest'
+import { activeMediaProvider, anyMediaProviderConfigured } from './mediaProvider'
+
+describe('media provider selection', () => {
+  it('prefers Jellyfin and falls back to the local library', () => {
+    expect(activeMediaProvider([{ id: 'jellyfin', configured: true }, { id: 'local', configured: true }])).toBe('jellyfin')
+    expect(activeMediaProvider([{ id: 'jellyfin', configured: false }, { id: 'local', configured: true }])).toBe('local')
+    expect(activeMediaProvider(undefined)).toBe('jellyfin')
+  })
+
+  it('reports media mode as available when any provider is configured', () => {
+    expect(anyMediaProviderConfigured([{ id: 'jellyfin', configured: false }, { id: 'local', configured: true }])).toBe(true)
+    expect(anyMediaProviderConfigured([{ id: 'jellyfin', configured: false }])).toBe(false)
+  })
+})
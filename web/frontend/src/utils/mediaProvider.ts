@@ -0,0 +1,12 @@
+type ProviderStatus = { id?: string; configured?: boolean }
+
+// Jellyfin stays the preferred source; the scanned local library is used when Jellyfin is not configured.
+export function activeMediaProvider(providers?: ProviderStatus[]) {
+  if (providers?.some(provider => provider.id === 'jellyfin' && provider.configured)) return 'jellyfin'
+  if (providers?.some(provider => provider.id === 'local' && provider.configured)) return 'local'
+  return 'jellyfin'
+}
+
+export function anyMediaProviderConfigured(providers?: ProviderStatus[]) {
+  return Boolean(providers?.some(provider => provider.configured))
+}
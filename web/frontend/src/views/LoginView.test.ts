@@ -65,3 +65,24 @@ describe('LoginView first-run setup', () => {
     expect(router.currentRoute.value.path).toBe('/setup')
   })
 })
+
+describe('LoginView two-factor login', () => {
+  it('asks for the second factor before leaving the login page', async () => {
+    const { wrapper, router, session } = await renderLogin({ ...baseState, setup_pending: false, local_setup_available: false })
+    const login = vi.spyOn(session, 'login').mockResolvedValue(null)
+    const verify = vi.spyOn(session, 'verifySecondFactor').mockResolvedValue({ ...baseState, setup_pending: false, authenticated: true })
+    await wrapper.find('input[type="password"]').setValue('secret-pass')
+    await wrapper.find('form').trigger('submit')
+    await flushPromises()
+    expect(login).toHaveBeenCalledOnce()
+    expect(router.currentRoute.value.path).toBe('/login')
+
+    const form = wrapper.find('[data-testid="two-factor-form"]')
+    expect(form.exists()).toBe(true)
+    await form.find('input').setValue(' 123456 ')
+    await form.trigger('submit')
+    await flushPromises()
+    expect(verify).toHaveBeenCalledWith('123456')
+    expect(router.currentRoute.value.path).toBe('/')
+  })
+})